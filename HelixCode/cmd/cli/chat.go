@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/session"
+)
+
+// chatTaskType is the task type whose criteria select a model for chat
+const chatTaskType = "chat"
+
+// chatSession is a conversation with one model. The history of completed
+// turns is sent as context with every new message, and older turns are
+// summarized once it no longer fits the model's context.
+type chatSession struct {
+	provider     llm.Provider
+	model        string
+	maxTokens    int
+	temperature  float64
+	conversation *llm.Conversation
+
+	// A named session is saved to store after every completed turn
+	store *session.ChatStore
+	saved *session.ChatSession
+}
+
+// handleChat opens an interactive chat with the model given by --model, or
+// the one SelectOptimalModel picks when none is given. With --session the
+// conversation is saved under a name and resumed by later chats, unless
+// --new starts it over.
+func (c *CLI) handleChat(ctx context.Context, args []string, model string, maxTokens int, temperature float64) error {
+	flags := flag.NewFlagSet("chat", flag.ContinueOnError)
+	flags.StringVar(&model, "model", model, "LLM model to chat with (selected automatically when empty)")
+	flags.IntVar(&maxTokens, "max-tokens", maxTokens, "Maximum tokens to generate per response")
+	flags.Float64Var(&temperature, "temperature", temperature, "Generation temperature")
+	name := flags.String("session", "", "Save the conversation under a name, resuming it if it exists")
+	fresh := flags.Bool("new", false, "Start the --session conversation over instead of resuming it")
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+	if flags.NArg() > 0 {
+		return configError(fmt.Errorf("usage: chat [--model name] [--session name [--new]] [--max-tokens N] [--temperature T]"))
+	}
+	if *fresh && *name == "" {
+		return configError(fmt.Errorf("--new requires --session"))
+	}
+
+	var store *session.ChatStore
+	var saved *session.ChatSession
+	if *name != "" {
+		var err error
+		store = session.NewChatStore(session.DefaultChatDir())
+		saved, err = loadChat(store, *name, *fresh)
+		if err != nil {
+			return configError(err)
+		}
+		if model == "" {
+			// Resume with the model the conversation was held with
+			model = saved.Model
+		}
+	}
+
+	c.initLLM()
+	chat, err := c.newChatSession(model, maxTokens, temperature)
+	if err != nil {
+		return err
+	}
+	if saved != nil {
+		chat.resume(store, saved)
+	}
+	return c.runChat(ctx, chat, os.Stdin, os.Stdout)
+}
+
+// loadChat returns the session saved under name, or a new one when there is
+// none or fresh is set. A fresh session replaces the saved one on its first turn.
+func loadChat(store *session.ChatStore, name string, fresh bool) (*session.ChatSession, error) {
+	if err := session.ValidateChatName(name); err != nil {
+		return nil, err
+	}
+	if fresh {
+		return &session.ChatSession{Name: name}, nil
+	}
+	saved, err := store.Load(name)
+	if errors.Is(err, session.ErrChatNotFound) {
+		return &session.ChatSession{Name: name}, nil
+	}
+	return saved, err
+}
+
+// handleSessions lists the saved chat sessions
+func (c *CLI) handleSessions(args []string, asJSON bool) error {
+	if len(args) == 0 || args[0] != "list" {
+		return configError(fmt.Errorf("usage: sessions list [--json]"))
+	}
+	flags := flag.NewFlagSet("sessions list", flag.ContinueOnError)
+	flags.BoolVar(&asJSON, "json", asJSON, "Output the sessions as JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return configError(err)
+	}
+
+	sessions, err := session.NewChatStore(session.DefaultChatDir()).List()
+	if err != nil {
+		return err
+	}
+	return printChatSessions(os.Stdout, sessions, asJSON)
+}
+
+// printChatSessions writes the saved sessions as a table or as JSON
+func printChatSessions(w io.Writer, sessions []session.ChatSessionInfo, asJSON bool) error {
+	if asJSON {
+		if sessions == nil {
+			sessions = []session.ChatSessionInfo{}
+		}
+		data, err := json.MarshalIndent(sessions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode chat sessions: %v", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No saved chat sessions. Start one with: chat --session <name>")
+		return nil
+	}
+	fmt.Fprintln(w, "\n=== Chat Sessions ===")
+	fmt.Fprintf(w, "%-24s %-32s %8s  %s\n", "NAME", "MODEL", "MESSAGES", "UPDATED")
+	for _, info := range sessions {
+		fmt.Fprintf(w, "%-24s %-32s %8d  %s\n", info.Name, info.Model, info.Messages, info.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// newChatSession resolves the provider serving model, retrying its transient
+// failures, and bounds the history by its context size. A model that no provider lists is tried on the default
+// provider, which reports it missing.
+func (c *CLI) newChatSession(model string, maxTokens int, temperature float64) (*chatSession, error) {
+	chat, err := c.resolveChatProvider(model, maxTokens, temperature)
+	if err != nil {
+		return nil, err
+	}
+	chat.provider = c.retrying(chat.provider)
+
+	chat.conversation = llm.NewConversation(chatTokenBudget(chat.provider, chat.model, maxTokens))
+	summarizer := llm.NewSummarizer(chat.provider)
+	summarizer.Model = chat.model
+	summarizer.KeepRecent = chatKeepRecent
+	chat.conversation.SetSummarizer(summarizer)
+	return chat, nil
+}
+
+// chatKeepRecent is how many of the latest messages, the last exchange,
+// summarizing the chat history keeps verbatim
+const chatKeepRecent = 2
+
+// chatTokenBudget returns how many tokens of history fit in the model's
+// context next to a response of maxTokens
+func chatTokenBudget(provider llm.Provider, model string, maxTokens int) int {
+	contextSize := llm.DefaultContextSize
+	for _, info := range provider.GetModels() {
+		if info.Name == model && info.ContextSize > 0 {
+			contextSize = info.ContextSize
+			break
+		}
+	}
+	if budget := contextSize - maxTokens; budget > 0 {
+		return budget
+	}
+	return contextSize / 2
+}
+
+// resolveChatProvider returns a chat session, without history, on the
+// provider serving model, selecting a model when none is given
+func (c *CLI) resolveChatProvider(model string, maxTokens int, temperature float64) (*chatSession, error) {
+	chat := &chatSession{model: model, maxTokens: maxTokens, temperature: temperature}
+
+	if model == "" {
+		selected, err := c.modelManager.SelectOptimalModel(llm.CriteriaForTask(chatTaskType))
+		if err != nil {
+			return nil, fmt.Errorf("%w: no model to chat with: %v", llm.ErrProviderUnavailable, err)
+		}
+		provider, err := c.modelManager.GetProviderForModel(selected.Name, selected.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", llm.ErrProviderUnavailable, err)
+		}
+		chat.provider = provider
+		chat.model = selected.Name
+		return chat, nil
+	}
+
+	for _, available := range c.modelManager.GetAvailableModels() {
+		if available.Name != model {
+			continue
+		}
+		if provider, err := c.modelManager.GetProviderForModel(available.Name, available.Provider); err == nil {
+			chat.provider = provider
+			return chat, nil
+		}
+	}
+	if c.llmProvider == nil {
+		return nil, fmt.Errorf("%w: no provider for model %s", llm.ErrProviderUnavailable, model)
+	}
+	chat.provider = c.llmProvider
+	return chat, nil
+}
+
+// runChat reads messages from in, one per line, and writes each response to
+// out until the input ends, the user types exit or quit, or ctx is cancelled.
+// An interrupt while a response is generated cancels only that response.
+func (c *CLI) runChat(ctx context.Context, chat *chatSession, in io.Reader, out io.Writer) error {
+	fmt.Fprintf(out, "=== Helix Chat with %s (%s) ===\n", chat.model, chat.provider.GetName())
+	if history := chat.conversation.Messages(); chat.saved != nil && len(history) > 0 {
+		fmt.Fprintf(out, "Resumed session %s (%d messages)\n", chat.saved.Name, len(history))
+	}
+	fmt.Fprintln(out, "Type 'exit' or 'quit' to leave; Ctrl-C cancels a response")
+
+	// Read input in the background so an interrupt ends the session even
+	// while waiting for a line
+	lines := make(chan string)
+	inputErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		inputErr <- scanner.Err()
+	}()
+
+	for {
+		fmt.Fprint(out, "\nyou> ")
+
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out, "\n\nShutting down...")
+			return nil
+		case err := <-inputErr:
+			fmt.Fprintln(out)
+			return err
+		case line = <-lines:
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			fmt.Fprintln(out, "Goodbye!")
+			return nil
+		}
+
+		turnCtx, cancel := context.WithCancel(ctx)
+		c.setTurnCancel(cancel)
+		err := chat.send(turnCtx, line, out)
+		c.setTurnCancel(nil)
+		cancel()
+
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			fmt.Fprintln(out, "\n\nShutting down...")
+			return nil
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintln(out, "\n⚠️ Response cancelled")
+		default:
+			fmt.Fprintf(out, "\n❌ %v\n", err)
+		}
+	}
+}
+
+// send generates the response to message with the history as context,
+// streaming it to out when the provider supports it. The history is trimmed
+// to the model's context before it is sent, so a resumed session fits too.
+// The turn is added to the history only once the response is complete.
+func (s *chatSession) send(ctx context.Context, message string, out io.Writer) error {
+	if err := s.conversation.Trim(ctx); err != nil {
+		return err
+	}
+	messages := append(s.conversation.Messages(), llm.Message{Role: "user", Content: message})
+
+	request := &llm.LLMRequest{
+		ID:          uuid.New(),
+		Model:       s.model,
+		Messages:    messages,
+		MaxTokens:   s.maxTokens,
+		Temperature: s.temperature,
+		Stream:      llm.GetProviderCapabilities(s.provider).Streaming,
+		CreatedAt:   time.Now(),
+	}
+
+	fmt.Fprint(out, "\nhelix> ")
+	reply, err := s.generate(ctx, request, out)
+	fmt.Fprintln(out)
+	if err != nil {
+		return err
+	}
+
+	s.conversation.Add("user", message)
+	s.conversation.Add("assistant", reply)
+	if err := s.conversation.Trim(ctx); err != nil {
+		return err
+	}
+	if err := s.save(); err != nil {
+		log.Printf("⚠️ Chat session not saved: %v", err)
+	}
+	return nil
+}
+
+// resume continues the saved session, saving every later turn to store. The
+// restored history is trimmed before the next message is sent.
+func (s *chatSession) resume(store *session.ChatStore, saved *session.ChatSession) {
+	s.store = store
+	s.saved = saved
+	for _, message := range saved.Messages {
+		s.conversation.AddMessage(message)
+	}
+}
+
+// save writes the history to the named session, if any
+func (s *chatSession) save() error {
+	if s.store == nil {
+		return nil
+	}
+	s.saved.Model = s.model
+	s.saved.Messages = s.conversation.Messages()
+	return s.store.Save(s.saved)
+}
+
+// generate writes the response to request to out and returns its text
+func (s *chatSession) generate(ctx context.Context, request *llm.LLMRequest, out io.Writer) (string, error) {
+	if !request.Stream {
+		response, err := s.provider.Generate(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprint(out, response.Content)
+		return response.Content, nil
+	}
+
+	ch := make(chan llm.LLMResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.provider.GenerateStream(ctx, request, ch)
+	}()
+
+	var reply strings.Builder
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				ch = nil
+				continue
+			}
+			fmt.Fprint(out, chunk.Content)
+			reply.WriteString(chunk.Content)
+		case err := <-errCh:
+			return reply.String(), err
+		}
+	}
+}
+
+// setTurnCancel records the cancel function of the chat response being
+// generated, or clears it with nil
+func (c *CLI) setTurnCancel(cancel context.CancelFunc) {
+	c.turnMu.Lock()
+	defer c.turnMu.Unlock()
+	c.cancelTurn = cancel
+}
+
+// Interrupt cancels the chat response being generated, reporting whether
+// there was one. The signal handler shuts down the CLI otherwise.
+func (c *CLI) Interrupt() bool {
+	c.turnMu.Lock()
+	defer c.turnMu.Unlock()
+	if c.cancelTurn == nil {
+		return false
+	}
+	c.cancelTurn()
+	c.cancelTurn = nil
+	return true
+}
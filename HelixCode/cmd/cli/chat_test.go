@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/session"
+)
+
+// newChatCLI returns a CLI whose only provider is an Ollama server answering
+// "Hi!" to every message, streamed when asked, or blocking until cancelled on
+// "slow". The messages of each chat request are sent on the returned channel.
+func newChatCLI(t *testing.T) (*CLI, chan []llm.Message) {
+	t.Helper()
+	requests := make(chan []llm.Message, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			fmt.Fprint(w, `{"models":[{"name":"chatty"}]}`)
+			return
+		}
+		var body struct {
+			Messages []llm.Message `json:"messages"`
+			Stream   bool          `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests <- body.Messages
+
+		if body.Messages[len(body.Messages)-1].Content == "slow" {
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		if !body.Stream {
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hi!"},"done":true}`)
+			return
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hi"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"!"},"done":true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	manager := llm.NewModelManager()
+	if err := manager.RegisterProvider(provider); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+	return &CLI{modelManager: manager}, requests
+}
+
+func TestRunChat_History(t *testing.T) {
+	cli, requests := newChatCLI(t)
+	chat, err := cli.newChatSession("", 100, 0.7)
+	if err != nil {
+		t.Fatalf("failed to select a model: %v", err)
+	}
+	if chat.model != "chatty" {
+		t.Fatalf("selected model %q, want chatty", chat.model)
+	}
+
+	var out bytes.Buffer
+	input := strings.NewReader("hello\n\nhow are you?\nexit\nunreached\n")
+	if err := cli.runChat(context.Background(), chat, input, &out); err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+
+	first, second := <-requests, <-requests
+	if len(first) != 1 || first[0].Content != "hello" {
+		t.Fatalf("first request sent %+v", first)
+	}
+	want := []llm.Message{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "Hi!"}, {Role: "user", Content: "how are you?"}}
+	if len(second) != len(want) {
+		t.Fatalf("second request sent %+v, want %+v", second, want)
+	}
+	for i := range want {
+		if second[i].Role != want[i].Role || second[i].Content != want[i].Content {
+			t.Fatalf("second request sent %+v, want %+v", second, want)
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatal("input after exit was sent")
+	}
+	if got := out.String(); !strings.Contains(got, "helix> Hi!") || !strings.Contains(got, "Goodbye!") {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
+
+func TestRunChat_SummarizesLongHistory(t *testing.T) {
+	cli, requests := newChatCLI(t)
+	// Leave room for about 100 tokens of history in the default context
+	chat, err := cli.newChatSession("chatty", llm.DefaultContextSize-100, 0.7)
+	if err != nil {
+		t.Fatalf("failed to create chat: %v", err)
+	}
+
+	long := strings.Repeat("a", 200)
+	input := strings.NewReader(long + "\n" + long + "\nlast\n")
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.runChat(context.Background(), chat, input, io.Discard)
+	}()
+
+	// The second turn overflows the budget, so the first is summarized
+	// before the third is sent
+	<-requests
+	<-requests
+	if summary := <-requests; len(summary) != 1 || !strings.Contains(summary[0].Content, long) {
+		t.Fatalf("summary request sent %+v", summary)
+	}
+	last := <-requests
+	if len(last) != 4 || last[0].Name != llm.SummaryMessageName || last[1].Content != long || last[3].Content != "last" {
+		t.Fatalf("request after summarizing sent %+v", last)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+}
+
+func TestChat_ResumedHistoryIsTrimmed(t *testing.T) {
+	cli, requests := newChatCLI(t)
+	chat, err := cli.newChatSession("chatty", llm.DefaultContextSize-100, 0.7)
+	if err != nil {
+		t.Fatalf("failed to create chat: %v", err)
+	}
+
+	// The saved history alone is over the budget
+	long := strings.Repeat("a", 200)
+	saved := &session.ChatSession{Name: "long", Model: "chatty", Messages: []llm.Message{
+		{Role: "user", Content: long}, {Role: "assistant", Content: "Hi!"},
+		{Role: "user", Content: long}, {Role: "assistant", Content: "Hi!"},
+	}}
+	chat.resume(nil, saved)
+	if err := cli.runChat(context.Background(), chat, strings.NewReader("next\n"), io.Discard); err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+
+	if summary := <-requests; len(summary) != 1 {
+		t.Fatalf("expected a summary request first, got %+v", summary)
+	}
+	first := <-requests
+	if len(first) != 4 || first[0].Name != llm.SummaryMessageName || first[3].Content != "next" {
+		t.Fatalf("first request after resuming sent %+v", first)
+	}
+}
+
+func TestRunChat_InterruptCancelsResponse(t *testing.T) {
+	cli, requests := newChatCLI(t)
+	chat, err := cli.newChatSession("chatty", 100, 0.7)
+	if err != nil {
+		t.Fatalf("failed to create chat: %v", err)
+	}
+	if cli.Interrupt() {
+		t.Fatal("interrupt reported a response before one started")
+	}
+
+	input, writer := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.runChat(context.Background(), chat, input, &out)
+	}()
+
+	fmt.Fprintln(writer, "slow")
+	<-requests
+	deadline := time.Now().Add(2 * time.Second)
+	for !cli.Interrupt() {
+		if time.Now().After(deadline) {
+			t.Fatal("no response to interrupt")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The chat goes on without the cancelled turn in its history
+	fmt.Fprintln(writer, "hello")
+	if messages := <-requests; len(messages) != 1 || messages[0].Content != "hello" {
+		t.Fatalf("request after cancellation sent %+v", messages)
+	}
+	fmt.Fprintln(writer, "quit")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("chat failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("chat did not end on quit")
+	}
+	if !strings.Contains(out.String(), "Response cancelled") {
+		t.Fatalf("cancellation not reported:\n%s", out.String())
+	}
+}
+
+func TestRunChat_ContextCancelled(t *testing.T) {
+	cli, _ := newChatCLI(t)
+	chat, err := cli.newChatSession("chatty", 100, 0.7)
+	if err != nil {
+		t.Fatalf("failed to create chat: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	input, _ := io.Pipe()
+	if err := cli.runChat(ctx, chat, input, io.Discard); err != nil {
+		t.Fatalf("expected a clean exit, got %v", err)
+	}
+}
+
+func TestChat_Sessions(t *testing.T) {
+	t.Setenv("HELIX_SESSIONS_DIR", t.TempDir())
+	store := session.NewChatStore(session.DefaultChatDir())
+	cli, requests := newChatCLI(t)
+
+	chat := func(input string, fresh bool) {
+		t.Helper()
+		saved, err := loadChat(store, "mywork", fresh)
+		if err != nil {
+			t.Fatalf("failed to load session: %v", err)
+		}
+		resumed, err := cli.newChatSession(saved.Model, 100, 0.7)
+		if err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+		resumed.resume(store, saved)
+		if err := cli.runChat(context.Background(), resumed, strings.NewReader(input), io.Discard); err != nil {
+			t.Fatalf("chat failed: %v", err)
+		}
+	}
+
+	chat("hello\n", false)
+	<-requests
+	saved, err := store.Load("mywork")
+	if err != nil {
+		t.Fatalf("session not saved: %v", err)
+	}
+	if saved.Model != "chatty" || len(saved.Messages) != 2 {
+		t.Fatalf("saved %+v", saved)
+	}
+
+	// A later chat resumes the conversation, unless it starts over
+	chat("again\n", false)
+	if messages := <-requests; len(messages) != 3 || messages[0].Content != "hello" {
+		t.Fatalf("resumed chat sent %+v", messages)
+	}
+	chat("fresh\n", true)
+	if messages := <-requests; len(messages) != 1 {
+		t.Fatalf("new chat sent %+v", messages)
+	}
+	if saved, _ := store.Load("mywork"); len(saved.Messages) != 2 || saved.Messages[0].Content != "fresh" {
+		t.Fatalf("new chat saved %+v", saved)
+	}
+
+	if _, err := loadChat(store, "../mywork", false); err == nil {
+		t.Fatal("expected an error for an invalid session name")
+	}
+	if code := exitCode(cli.handleChat(context.Background(), []string{"--new"}, "", 100, 0.7)); code != exitConfig {
+		t.Fatalf("--new without --session: exit code %d, want %d", code, exitConfig)
+	}
+}
+
+func TestPrintChatSessions(t *testing.T) {
+	sessions := []session.ChatSessionInfo{{Name: "mywork", Model: "llama3", Messages: 4, UpdatedAt: time.Now()}}
+
+	var out bytes.Buffer
+	if err := printChatSessions(&out, sessions, false); err != nil {
+		t.Fatalf("failed to print sessions: %v", err)
+	}
+	if !strings.Contains(out.String(), "mywork") || !strings.Contains(out.String(), "llama3") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := printChatSessions(&out, nil, true); err != nil {
+		t.Fatalf("failed to print sessions: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Fatalf("expected an empty JSON list, got %s", out.String())
+	}
+
+	cli := &CLI{}
+	if code := exitCode(cli.handleSessions(nil, false)); code != exitConfig {
+		t.Fatalf("sessions without list: exit code %d, want %d", code, exitConfig)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"dev.helix.code/internal/llm"
+)
+
+// Exit codes let scripts branch on the kind of failure
+const (
+	exitOK                  = 0   // The command succeeded
+	exitFailure             = 1   // Any other failure
+	exitConfig              = 2   // Invalid flags, configuration or input files
+	exitProviderUnavailable = 3   // No LLM provider could serve the request
+	exitUnhealthy           = 4   // --health found an unhealthy subsystem
+	exitInterrupted         = 130 // Cancelled by SIGINT or SIGTERM
+)
+
+// exitError attaches an exit code to an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// configError marks err as caused by invalid flags or configuration
+func configError(err error) error {
+	return &exitError{code: exitConfig, err: err}
+}
+
+// unhealthyError marks err as a failed health check
+func unhealthyError(err error) error {
+	return &exitError{code: exitUnhealthy, err: err}
+}
+
+// exitCode maps an error returned by Run to the process exit code
+func exitCode(err error) int {
+	var coded *exitError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, llm.ErrProviderUnavailable):
+		return exitProviderUnavailable
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"dev.helix.code/internal/llm"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"success", nil, exitOK},
+		{"failure", errors.New("generation failed"), exitFailure},
+		{"config", configError(errors.New("preference must be task=model")), exitConfig},
+		{"wrapped config", fmt.Errorf("recommend: %w", configError(errors.New("unknown task type"))), exitConfig},
+		{"provider unavailable", fmt.Errorf("pull: %w", llm.ErrProviderUnavailable), exitProviderUnavailable},
+		{"unhealthy", unhealthyError(errors.New("Worker Pool is timeout")), exitUnhealthy},
+		{"interrupted", fmt.Errorf("command interrupted: %w", context.Canceled), exitInterrupted},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := exitCode(tc.err); code != tc.code {
+				t.Fatalf("exitCode(%v) = %d, want %d", tc.err, code, tc.code)
+			}
+		})
+	}
+}
+
+func TestExitCode_DefaultProviderUnavailable(t *testing.T) {
+	_, err := llm.NewModelManager().GetDefaultProvider()
+	if code := exitCode(err); code != exitProviderUnavailable {
+		t.Fatalf("exitCode(%v) = %d, want %d", err, code, exitProviderUnavailable)
+	}
+}
+
+func TestHandleSetPreference_ConfigError(t *testing.T) {
+	err := (&CLI{}).handleSetPreference(context.Background(), "planning")
+	if code := exitCode(err); code != exitConfig {
+		t.Fatalf("exitCode(%v) = %d, want %d", err, code, exitConfig)
+	}
+}
@@ -2,17 +2,32 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+
+	"dev.helix.code/internal/ascii"
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/config"
+	"dev.helix.code/internal/paths"
+	"dev.helix.code/internal/hardware"
+	"dev.helix.code/internal/health"
 	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/llm/probe"
 	"dev.helix.code/internal/notification"
+	"dev.helix.code/internal/task"
 	"dev.helix.code/internal/worker"
 )
 
@@ -20,7 +35,15 @@ import (
 type CLI struct {
 	workerPool *worker.SSHWorkerPool
 	llmProvider llm.Provider
+	modelManager *llm.ModelManager
 	notificationEngine *notification.NotificationEngine
+	modelPath string
+	modelsDir string
+	discoveredModels []llm.DiscoveredModel
+	preferences *llm.ModelPreferences
+	postProcess *llm.PostProcessorPipeline // Applied to generated text, nil to print it as is
+	turnMu sync.Mutex
+	cancelTurn context.CancelFunc // Cancels the chat response being generated, nil between responses
 }
 
 // NewCLI creates a new CLI instance
@@ -31,42 +54,109 @@ func NewCLI() *CLI {
 	}
 }
 
-// Run executes the CLI
-func (c *CLI) Run() error {
+// Run executes the CLI. Cancelling ctx aborts the running command.
+func (c *CLI) Run(ctx context.Context) error {
 	// Parse command-line flags
 	var (
 		command     = flag.String("command", "", "Command to execute")
 		workerHost  = flag.String("worker", "", "Worker host to add")
 		workerUser  = flag.String("user", "", "Worker SSH username")
 		workerKey   = flag.String("key", "", "Worker SSH key path")
-		model       = flag.String("model", "llama-3-8b", "LLM model to use")
+		drainWorker = flag.String("drain-worker", "", "Drain a worker by ID through the server")
+		serverURL   = flag.String("server", "http://localhost:8080", "HelixCode server URL (authenticates with HELIX_API_KEY)")
+		model       = flag.String("model", "", "LLM model to use (the provider's default when empty)")
 		prompt      = flag.String("prompt", "", "Prompt for LLM generation")
 		maxTokens   = flag.Int("max-tokens", 1000, "Maximum tokens to generate")
 		temperature = flag.Float64("temperature", 0.7, "Generation temperature")
-		stream      = flag.Bool("stream", false, "Stream the response")
+		stream      = flag.Bool("stream", false, "Stream the response (the default when the provider supports it)")
+		noStream    = flag.Bool("no-stream", false, "Print the whole response at once")
+		extractCode = flag.Bool("extract-code", false, "Print only the code from the response")
 		listWorkers = flag.Bool("list-workers", false, "List all workers")
 		listModels  = flag.Bool("list-models", false, "List available models")
+		probeModels = flag.Bool("probe-models", false, "Probe and rank available models by reasoning, tool calling and code generation")
+		pullModel   = flag.String("pull", "", "Download a model through the default provider")
+		recommend   = flag.String("recommend", "", "Recommend a model for a task type (planning, building, testing, ...)")
+		prefer      = flag.String("prefer", "", "Prefer a model for a task type (e.g. planning=llama3)")
+		clearPreference = flag.String("clear-preference", "", "Clear the preferred model for a task type")
 		healthCheck = flag.Bool("health", false, "Perform health check")
+		healthTimeout = flag.Duration("health-timeout", health.DefaultTimeout, "Timeout for each subsystem's health check")
+		showHardware = flag.Bool("hardware", false, "Show detected hardware")
+		jsonOutput  = flag.Bool("json", false, "Output in JSON format")
+		verbose     = flag.Bool("verbose", false, "Log LLM requests and responses (secrets redacted)")
+		quiet       = flag.Bool("quiet", false, "Suppress informational logs, keeping warnings and errors")
+		modelPath   = flag.String("model-path", "", "GGUF model file for llama.cpp (auto-discovered when empty)")
+		modelsDir   = flag.String("models-dir", llm.DefaultModelsDir(), "Directory scanned for GGUF models")
+		hardwareProfile = flag.String("hardware-profile", "", "Load a saved hardware profile instead of detecting")
+		simulateRAM = flag.String("simulate-ram", "", "Simulate total RAM (e.g. 16GB)")
+		simulateVRAM = flag.String("simulate-vram", "", "Simulate GPU VRAM (e.g. 8GB)")
 		notify      = flag.String("notify", "", "Send notification with message")
 		notifyType  = flag.String("notify-type", "info", "Notification type")
 		notifyPriority = flag.String("notify-priority", "medium", "Notification priority")
 	)
 	flag.Parse()
 
-	ctx := context.Background()
+	if *quiet {
+		log.SetOutput(newQuietWriter(os.Stderr))
+	}
+	if *verbose {
+		llm.SetVerboseLogging(true)
+	}
+	streaming, err := streamModeFromFlags(*stream, *noStream)
+	if err != nil {
+		return configError(err)
+	}
+	c.modelPath = paths.Expand(*modelPath)
+	c.modelsDir = paths.Expand(*modelsDir)
+	if *extractCode {
+		pipeline, err := llm.NewPostProcessorPipeline(llm.PostProcessExtractCode, llm.PostProcessTrim)
+		if err != nil {
+			return configError(err)
+		}
+		c.postProcess = pipeline
+	}
 
 	// Handle different commands
 	switch {
+	case flag.Arg(0) == "models":
+		return c.handleModels(ctx, flag.Args()[1:], *jsonOutput)
+	case flag.Arg(0) == "chat":
+		return c.handleChat(ctx, flag.Args()[1:], *model, *maxTokens, *temperature)
+	case flag.Arg(0) == "sessions":
+		return c.handleSessions(flag.Args()[1:], *jsonOutput)
+	case flag.NArg() > 0:
+		return c.handleTemplate(ctx, flag.Arg(0), flag.Args()[1:], *model, *maxTokens, *temperature, streaming, *jsonOutput)
 	case *listWorkers:
 		return c.handleListWorkers(ctx)
 	case *listModels:
 		return c.handleListModels(ctx)
+	case *probeModels:
+		return c.handleProbeModels(ctx, *jsonOutput)
+	case *pullModel != "":
+		return c.handlePullModel(ctx, *pullModel)
+	case *recommend != "":
+		return c.handleRecommendModel(ctx, *recommend, *jsonOutput)
+	case *prefer != "":
+		return c.handleSetPreference(ctx, *prefer)
+	case *clearPreference != "":
+		return c.handleClearPreference(ctx, *clearPreference)
 	case *healthCheck:
-		return c.handleHealthCheck(ctx)
+		return c.handleHealthCheck(ctx, *healthTimeout, false)
+	case *showHardware:
+		opts := hardware.SimulationOptions{RAM: *simulateRAM, VRAM: *simulateVRAM}
+		if *hardwareProfile != "" {
+			profile, err := hardware.LoadHardwareProfile(*hardwareProfile)
+			if err != nil {
+				return configError(err)
+			}
+			opts.Profile = profile
+		}
+		return c.handleHardware(ctx, opts, *jsonOutput)
 	case *workerHost != "":
 		return c.handleAddWorker(ctx, *workerHost, *workerUser, *workerKey)
+	case *drainWorker != "":
+		return c.handleDrainWorker(ctx, *serverURL, *drainWorker)
 	case *prompt != "":
-		return c.handleGenerate(ctx, *prompt, *model, *maxTokens, *temperature, *stream)
+		return c.handleGenerate(ctx, *prompt, *model, *maxTokens, *temperature, streaming, *jsonOutput)
 	case *notify != "":
 		return c.handleNotification(ctx, *notify, *notifyType, *notifyPriority)
 	case *command != "":
@@ -91,11 +181,129 @@ func (c *CLI) handleListWorkers(ctx context.Context) error {
 	return nil
 }
 
+// initLLM initializes LLM providers from configuration on first use
+func (c *CLI) initLLM() {
+	if c.modelManager != nil {
+		return
+	}
+
+	llmConfig := config.LLMConfig{
+		DefaultProvider: []string{"local"},
+		Providers:       map[string]string{"local": "http://localhost:11434"},
+		RetryMax:        llm.DefaultMaxRetries,
+		RetryBaseDelay:  int(llm.DefaultRetryBaseDelay / time.Second),
+		RetryMaxDelay:   int(llm.DefaultRetryMaxDelay / time.Second),
+	}
+	if cfg, err := config.Load(); err == nil {
+		llmConfig = cfg.LLM
+		if cfg.Logging.Level == "debug" {
+			llm.SetVerboseLogging(true)
+		}
+	} else {
+		log.Printf("⚠️ Using default LLM configuration: %v", err)
+	}
+
+	c.modelManager = llm.NewModelManager()
+	if _, err := c.modelManager.InitFromConfig(llmConfig); err != nil {
+		log.Printf("⚠️ LLM providers not initialized: %v", err)
+	}
+
+	if provider, err := c.modelManager.GetDefaultProvider(); err == nil {
+		c.llmProvider = provider
+	}
+
+	if prefs, err := llm.LoadModelPreferences(llm.DefaultPreferencesPath()); err == nil {
+		c.preferences = prefs
+		c.modelManager.SetPreferences(prefs)
+	} else {
+		log.Printf("⚠️ Model preferences not loaded: %v", err)
+	}
+
+	c.initLocalModel()
+}
+
+// initLocalModel sets up a llama.cpp provider for an explicit --model-path, or for the
+// best GGUF model in the models directory that fits the detected hardware
+func (c *CLI) initLocalModel() {
+	cache, err := llm.LoadGGUFCache(llm.DefaultGGUFCachePath())
+	if err != nil {
+		log.Printf("⚠️ GGUF cache not loaded: %v", err)
+		cache = llm.NewGGUFCache()
+	}
+	models, err := llm.DiscoverModelsCached(c.modelsDir, cache)
+	if err != nil {
+		log.Printf("⚠️ Model discovery failed: %v", err)
+	}
+	c.discoveredModels = models
+
+	modelPath := c.modelPath
+	if modelPath != "" {
+		checked, err := llm.CheckModelPath(modelPath)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			return
+		}
+		modelPath = checked
+	} else {
+		detector := hardware.NewDetector()
+		if _, err := detector.Detect(); err != nil {
+			log.Printf("⚠️ Hardware detection failed: %v", err)
+		}
+		best, err := llm.SelectBestModel(models, detector)
+		if err != nil {
+			return
+		}
+		log.Printf("✅ Selected local model %s (%s)", best.Name, best.SizeLabel)
+		modelPath = best.Path
+	}
+
+	provider, err := llm.NewLlamaCPPProvider(llm.LlamaConfig{ModelPath: modelPath})
+	if err != nil {
+		log.Printf("⚠️ Failed to initialize llama.cpp provider: %v", err)
+		return
+	}
+	if err := c.modelManager.RegisterProvider(provider); err != nil {
+		log.Printf("⚠️ llama.cpp provider not registered: %v", err)
+	}
+
+	// An explicit model path takes precedence over configured providers
+	if c.modelPath != "" || c.llmProvider == nil {
+		c.llmProvider = provider
+	}
+}
+
 // handleListModels lists available models
 func (c *CLI) handleListModels(ctx context.Context) error {
-	// For now, return static list
-	// In production, this would query the model manager
-	
+	c.initLLM()
+
+	if len(c.discoveredModels) > 0 {
+		fmt.Printf("\n=== Discovered GGUF Models (%s) ===\n", c.modelsDir)
+		for _, model := range c.discoveredModels {
+			fmt.Printf("Name: %s\n", model.Name)
+			fmt.Printf("  Path: %s\n", model.Path)
+			fmt.Printf("  Size: %s\n", model.SizeLabel)
+			if model.Architecture != "" {
+				fmt.Printf("  Architecture: %s\n", model.Architecture)
+			}
+			if model.ContextLength > 0 {
+				fmt.Printf("  Context Size: %d\n", model.ContextLength)
+			}
+			fmt.Println()
+		}
+	}
+
+	if available := c.modelManager.GetAvailableModels(); len(available) > 0 {
+		fmt.Println("\n=== Available Models ===")
+		for _, model := range available {
+			fmt.Printf("ID: %s\n", model.Name)
+			fmt.Printf("  Provider: %s\n", model.Provider)
+			fmt.Printf("  Context Size: %d\n", model.ContextSize)
+			fmt.Printf("  Status: available\n\n")
+		}
+		return nil
+	}
+
+	// Fall back to a static list when no provider is reachable
 	models := []struct {
 		ID          string
 		Name        string
@@ -120,36 +328,357 @@ func (c *CLI) handleListModels(ctx context.Context) error {
 	return nil
 }
 
-// handleHealthCheck performs system health check
-func (c *CLI) handleHealthCheck(ctx context.Context) error {
+// handleProbeModels probes every available model and prints them ranked by capability
+func (c *CLI) handleProbeModels(ctx context.Context, asJSON bool) error {
+	c.initLLM()
+
+	available := c.modelManager.GetAvailableModels()
+	if len(available) == 0 {
+		return fmt.Errorf("no models available to probe")
+	}
+
+	var reports []probe.CapabilityReport
+	for _, model := range available {
+		provider, err := c.modelManager.GetProviderForModel(model.Name, model.Provider)
+		if err != nil {
+			log.Printf("⚠️ Skipping %s: %v", model.Name, err)
+			continue
+		}
+		fmt.Printf("🔄 Probing %s...\n", model.Name)
+		reports = append(reports, probe.ProbeModel(ctx, provider, model.Name))
+	}
+	reports = probe.RankModels(reports)
+
+	if asJSON {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode probe reports: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println("\n=== Model Capability Ranking ===")
+	for i, report := range reports {
+		status := "⚠️ limited"
+		if report.Passed {
+			status = "✅ ready"
+		}
+		fmt.Printf("%d. %s (%s) score %.0f%% %s\n", i+1, report.Model, report.Provider, report.Score*100, status)
+		for _, category := range []probe.Category{probe.CategoryReasoning, probe.CategoryToolCalling, probe.CategoryCodeGeneration} {
+			if summary := report.Categories[category]; summary != nil {
+				fmt.Printf("   %s: %d/%d\n", category, summary.Passed, summary.Total)
+			}
+		}
+	}
+
+	return nil
+}
+
+// handleModels runs a models subcommand; rank is the only one
+func (c *CLI) handleModels(ctx context.Context, args []string, asJSON bool) error {
+	if len(args) == 0 || args[0] != "rank" {
+		return configError(fmt.Errorf("usage: models rank [--json] [--runs N]"))
+	}
+
+	flags := flag.NewFlagSet("models rank", flag.ContinueOnError)
+	flags.BoolVar(&asJSON, "json", asJSON, "Output the ranking as JSON")
+	runs := flags.Int("runs", probe.DefaultBenchmarkRuns, "Timed requests per model in the latency benchmark")
+	if err := flags.Parse(args[1:]); err != nil {
+		return configError(err)
+	}
+	if *runs <= 0 {
+		return configError(fmt.Errorf("--runs must be positive, got %d", *runs))
+	}
+
+	c.initLLM()
+	available := c.modelManager.GetAvailableModels()
+	if len(available) == 0 {
+		return fmt.Errorf("no models available to rank")
+	}
+
+	var candidates []probe.Candidate
+	for _, model := range available {
+		provider, err := c.modelManager.GetProviderForModel(model.Name, model.Provider)
+		if err != nil {
+			log.Printf("⚠️ Skipping %s: %v", model.Name, err)
+			continue
+		}
+		fits := c.modelManager.FitsHardware(model)
+		if !fits {
+			log.Printf("⚠️ Skipping %s: too large for this hardware", model.Name)
+		}
+		candidates = append(candidates, probe.Candidate{Model: model.Name, Provider: provider, FitsHardware: fits})
+	}
+
+	rankings := probe.RankCandidates(ctx, candidates, *runs, func(model string) {
+		log.Printf("🔄 Probing and benchmarking %s...", model)
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(rankings) == 0 {
+		return fmt.Errorf("none of the %d models fit this hardware", len(candidates))
+	}
+
+	return printRankings(os.Stdout, rankings, asJSON)
+}
+
+// printRankings writes a model ranking as a table or as JSON
+func printRankings(w io.Writer, rankings []probe.Ranking, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(rankings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode model ranking: %v", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintln(w, "\n=== Model Ranking ===")
+	fmt.Fprintf(w, "%-4s %-32s %-10s %6s %9s %6s %8s %9s %6s\n",
+		"#", "MODEL", "PROVIDER", "SCORE", "REASONING", "TOOLS", "CODEGEN", "LATENCY", "READY")
+	for _, ranking := range rankings {
+		latency := "failed"
+		if ranking.Benchmark.Error == "" {
+			latency = ranking.Benchmark.Median.Round(time.Millisecond).String()
+		}
+		ready := "no"
+		if ranking.Passed {
+			ready = "yes"
+		}
+		fmt.Fprintf(w, "%-4d %-32s %-10s %5.0f%% %8.0f%% %5.0f%% %7.0f%% %9s %6s\n",
+			ranking.Rank, ranking.Model, ranking.Provider, ranking.Score*100,
+			ranking.Reasoning*100, ranking.ToolCalling*100, ranking.CodeGeneration*100, latency, ready)
+	}
+	return nil
+}
+
+// modelRecommendation is the JSON output of handleRecommendModel
+type modelRecommendation struct {
+	TaskType   string  `json:"task_type"`
+	Model      string  `json:"model"`
+	Provider   string  `json:"provider"`
+	Score      float64 `json:"score"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// handleRecommendModel prints the model best suited to a task type and why
+func (c *CLI) handleRecommendModel(ctx context.Context, taskType string, asJSON bool) error {
+	parsed, err := task.ParseTaskType(taskType)
+	if err != nil {
+		return configError(err)
+	}
+	c.initLLM()
+
+	criteria := llm.CriteriaForTask(string(parsed))
+	best, err := c.modelManager.RecommendModel(criteria)
+	if err != nil {
+		return fmt.Errorf("no model recommended for %s tasks: %v", parsed, err)
+	}
+
+	recommendation := modelRecommendation{
+		TaskType:   string(parsed),
+		Model:      best.Model.Name,
+		Provider:   string(best.Model.Provider),
+		Score:      best.Score,
+		Confidence: best.Confidence,
+		Reason:     best.Reason,
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(recommendation, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode recommendation: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("\n=== Recommended Model for %s ===\n", parsed)
+	fmt.Printf("🎯 %s (%s)\n", recommendation.Model, recommendation.Provider)
+	fmt.Printf("  Score: %.2f (confidence %.0f%%)\n", recommendation.Score, recommendation.Confidence*100)
+	fmt.Printf("  Reason: %s\n", recommendation.Reason)
+	return nil
+}
+
+// handleSetPreference records the preferred model for a task type, given as
+// task=model. A model that cannot currently serve the task is saved with a warning.
+func (c *CLI) handleSetPreference(ctx context.Context, assignment string) error {
+	taskType, model, ok := strings.Cut(assignment, "=")
+	if !ok || model == "" {
+		return configError(fmt.Errorf("preference must be task=model, got %q", assignment))
+	}
+	parsed, err := task.ParseTaskType(taskType)
+	if err != nil {
+		return configError(err)
+	}
+	c.initLLM()
+	if c.preferences == nil {
+		return fmt.Errorf("model preferences are unavailable")
+	}
+
+	pref := llm.ModelPreference{Model: model}
+	if err := c.modelManager.CheckPreference(pref, llm.CriteriaForTask(string(parsed))); err != nil {
+		fmt.Printf("⚠️ %v; model selection will fall back to scoring until it is available\n", err)
+	}
+	if err := c.preferences.Set(string(parsed), pref); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Preferred model for %s tasks: %s\n", parsed, model)
+	return nil
+}
+
+// handleClearPreference removes the preferred model for a task type
+func (c *CLI) handleClearPreference(ctx context.Context, taskType string) error {
+	parsed, err := task.ParseTaskType(taskType)
+	if err != nil {
+		return configError(err)
+	}
+	c.initLLM()
+	if c.preferences == nil {
+		return fmt.Errorf("model preferences are unavailable")
+	}
+
+	if err := c.preferences.Clear(string(parsed)); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Cleared preferred model for %s tasks\n", parsed)
+	return nil
+}
+
+// handleHealthCheck performs system health check. Each subsystem is checked
+// concurrently with its own timeout and printed as soon as it answers.
+// Provider checks made within the health cache TTL are reused unless refresh is set.
+func (c *CLI) handleHealthCheck(ctx context.Context, timeout time.Duration, refresh bool) error {
+	c.initLLM()
 	fmt.Println("\n=== System Health Check ===")
-	
-	// Check worker pool
-	stats := c.workerPool.GetWorkerStats(ctx)
-	if stats.HealthyWorkers > 0 {
-		fmt.Printf("✅ Worker Pool: %d healthy workers\n", stats.HealthyWorkers)
-	} else {
-		fmt.Printf("⚠️ Worker Pool: No healthy workers\n")
+
+	results := health.Run(ctx, c.healthChecks(timeout, refresh), func(result health.Result) {
+		fmt.Printf("%s %s: %s (%v)\n", healthSymbol(result.Status), result.Name, result.Message,
+			result.Latency.Round(time.Millisecond))
+	})
+
+	for _, result := range results {
+		if !result.OK() {
+			fmt.Println("⚠️ System is degraded")
+			return unhealthyError(fmt.Errorf("%s is %s", result.Name, result.Status))
+		}
 	}
-	
-	// Check notification engine
-	channelStats := c.notificationEngine.GetChannelStats()
-	enabledChannels := 0
-	for _, stats := range channelStats {
-		if statsMap, ok := stats.(map[string]interface{}); ok {
-			if enabled, ok := statsMap["enabled"].(bool); ok && enabled {
-				enabledChannels++
+	fmt.Println("✅ System is operational")
+	return nil
+}
+
+// healthChecks returns the health check of each subsystem
+func (c *CLI) healthChecks(timeout time.Duration, refresh bool) []health.Check {
+	checks := []health.Check{
+		{Name: "Worker Pool", Timeout: timeout, Run: func(ctx context.Context) (health.Status, string) {
+			stats := c.workerPool.GetWorkerStats(ctx)
+			if stats.HealthyWorkers == 0 {
+				return health.StatusDegraded, "No healthy workers"
+			}
+			return health.StatusHealthy, fmt.Sprintf("%d healthy workers", stats.HealthyWorkers)
+		}},
+		{Name: "Notification System", Timeout: timeout, Run: func(ctx context.Context) (health.Status, string) {
+			healthyChannels := 0
+			var misconfigured []string
+			for _, channel := range c.notificationEngine.TestChannels(ctx) {
+				if channel.Healthy {
+					healthyChannels++
+				} else {
+					misconfigured = append(misconfigured, fmt.Sprintf("%s (%s)", channel.Name, channel.Error))
+				}
+			}
+			if len(misconfigured) > 0 {
+				return health.StatusDegraded, "Misconfigured channels: " + strings.Join(misconfigured, ", ")
+			}
+			if healthyChannels == 0 {
+				return health.StatusDegraded, "No enabled channels"
 			}
+			return health.StatusHealthy, fmt.Sprintf("%d channels reachable", healthyChannels)
+		}},
+	}
+
+	providers := c.modelManager.Providers()
+	if len(providers) == 0 {
+		checks = append(checks, health.Check{Name: "LLM Providers", Timeout: timeout, Run: func(ctx context.Context) (health.Status, string) {
+			return health.StatusDegraded, "No providers available"
+		}})
+	}
+	for _, provider := range providers {
+		provider := provider
+		checks = append(checks, health.Check{
+			Name:    "LLM Provider " + provider.GetName(),
+			Timeout: timeout,
+			Run: func(ctx context.Context) (health.Status, string) {
+				status, err := c.modelManager.ProviderHealth(ctx, provider.GetType(), refresh)
+				if err != nil {
+					return health.StatusUnhealthy, err.Error()
+				}
+				switch status.Status {
+				case "healthy":
+					if status.Version != "" {
+						return health.StatusHealthy, fmt.Sprintf("%d models, version %s", status.ModelCount, status.Version)
+					}
+					return health.StatusHealthy, fmt.Sprintf("%d models", status.ModelCount)
+				case "unhealthy":
+					return health.StatusUnhealthy, status.Status
+				default:
+					return health.StatusDegraded, status.Status
+				}
+			},
+		})
+	}
+	return checks
+}
+
+// healthSymbol returns the symbol printed for a health status
+func healthSymbol(status health.Status) string {
+	switch status {
+	case health.StatusHealthy:
+		return "✅"
+	case health.StatusDegraded:
+		return "⚠️"
+	case health.StatusTimeout:
+		return "⏱️"
+	default:
+		return "❌"
+	}
+}
+
+// handleHardware displays detected or simulated hardware information
+func (c *CLI) handleHardware(ctx context.Context, opts hardware.SimulationOptions, asJSON bool) error {
+	detector, err := hardware.NewSimulatedDetector(opts)
+	if err != nil {
+		return configError(fmt.Errorf("invalid hardware simulation: %v", err))
+	}
+
+	info, err := detector.Detect()
+	if err != nil {
+		return fmt.Errorf("hardware detection failed: %v", err)
+	}
+
+	if asJSON {
+		data, err := info.Marshal(hardware.ProfileFormatJSON)
+		if err != nil {
+			return fmt.Errorf("failed to marshal hardware info: %v", err)
 		}
+		fmt.Println(string(data))
+		return nil
 	}
-	
-	if enabledChannels > 0 {
-		fmt.Printf("✅ Notification System: %d enabled channels\n", enabledChannels)
+
+	if detector.IsSimulated() {
+		fmt.Println("\n=== Hardware Information (simulated) ===")
 	} else {
-		fmt.Printf("⚠️ Notification System: No enabled channels\n")
+		fmt.Println("\n=== Hardware Information ===")
 	}
-	
-	fmt.Println("✅ System is operational")
+	fmt.Printf("CPU: %s (%s, %d cores)\n", info.CPU.Model, info.CPU.Architecture, info.CPU.Cores)
+	fmt.Printf("GPU: %s %s (VRAM: %s)\n", info.GPU.Vendor, info.GPU.Model, info.GPU.VRAM)
+	fmt.Printf("Memory: %s\n", info.Memory.TotalRAM)
+	fmt.Printf("Platform: %s/%s (%s)\n", info.Platform.OS, info.Platform.Architecture, info.Platform.Hostname)
+	fmt.Printf("Optimal Model Size: %s\n", detector.GetOptimalModelSize())
+
 	return nil
 }
 
@@ -180,32 +709,302 @@ func (c *CLI) handleAddWorker(ctx context.Context, host, username, keyPath strin
 	return nil
 }
 
-// handleGenerate performs LLM generation
-func (c *CLI) handleGenerate(ctx context.Context, prompt, model string, maxTokens int, temperature float64, stream bool) error {
-	fmt.Printf("\n=== Generating with %s ===\n", model)
-	fmt.Printf("Prompt: %s\n\n", prompt)
-	
-	// For now, simulate generation
-	// In production, this would use the actual LLM provider
-	
+// handleDrainWorker asks the server to drain a worker and reports progress
+// until the worker is drained
+func (c *CLI) handleDrainWorker(ctx context.Context, serverURL, workerID string) error {
+	if _, err := uuid.Parse(workerID); err != nil {
+		return fmt.Errorf("invalid worker ID %q: %v", workerID, err)
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/workers/%s/drain", strings.TrimRight(serverURL, "/"), workerID)
+
+	progress, err := requestDrain(ctx, http.MethodPost, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to drain worker: %v", err)
+	}
+	fmt.Printf("🔄 Draining worker %s\n", progress.Hostname)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	last := *progress
+	fmt.Printf("   %d task(s) in flight\n", last.InFlight)
+	for progress.Status != worker.WorkerStatusDrained {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		progress, err = requestDrain(ctx, http.MethodGet, endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to get drain progress: %v", err)
+		}
+		if progress.InFlight != last.InFlight || progress.Reassigned != last.Reassigned {
+			fmt.Printf("   %d task(s) in flight, %d reassigned\n", progress.InFlight, progress.Reassigned)
+			last = *progress
+		}
+	}
+
+	fmt.Printf("✅ Worker %s drained (%d task(s) reassigned)\n", progress.Hostname, progress.Reassigned)
+	return nil
+}
+
+// requestDrain calls a worker drain endpoint and returns the reported progress
+func requestDrain(ctx context.Context, method, endpoint string) (*worker.DrainProgress, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if key := os.Getenv("HELIX_API_KEY"); key != "" {
+		req.Header.Set(auth.APIKeyHeader, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Message string               `json:"message"`
+		Error   string               `json:"error"`
+		Drain   worker.DrainProgress `json:"drain"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		if body.Error != "" {
+			return nil, fmt.Errorf("%s: %s", body.Message, body.Error)
+		}
+		return nil, fmt.Errorf("%s", body.Message)
+	}
+	return &body.Drain, nil
+}
+
+// handlePullModel downloads a model through the default provider, rendering its progress
+func (c *CLI) handlePullModel(ctx context.Context, model string) error {
+	c.initLLM()
+
+	provider, err := c.modelManager.GetDefaultProvider()
+	if err != nil {
+		return err
+	}
+
+	progress := make(chan llm.Progress, 16)
+	rendered := make(chan struct{})
+	go func() {
+		renderProgress(progress)
+		close(rendered)
+	}()
+
+	err = c.modelManager.PullModel(ctx, provider.GetType(), model, progress)
+	<-rendered
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Model %s pulled\n", model)
+	return nil
+}
+
+// renderProgress redraws a progress bar for each event until the channel is closed
+func renderProgress(progress <-chan llm.Progress) {
+	for p := range progress {
+		fmt.Printf("\r%s %s %3.0f%% %-30s", p.Operation, ascii.GenerateProgressBar(p.Fraction(), 30), p.Fraction()*100, p.Message)
+	}
+	fmt.Println()
+}
+
+// handleGenerate performs LLM generation, printing the response as a JSON
+// object when asJSON is set
+func (c *CLI) handleGenerate(ctx context.Context, prompt, model string, maxTokens int, temperature float64, mode streamMode, asJSON bool) error {
+	label := model
+	if label == "" {
+		label = "default"
+	}
+	if !asJSON {
+		fmt.Printf("\n=== Generating with %s ===\n", label)
+		fmt.Printf("Prompt: %s\n\n", prompt)
+	}
+
+	c.initLLM()
+	// Post-processing and JSON output need the whole response
+	stream := resolveStreaming(mode, c.llmProvider, asJSON || c.postProcess != nil)
+	if c.llmProvider != nil {
+		return c.generateWithProvider(ctx, prompt, model, maxTokens, temperature, stream, asJSON)
+	}
+
+	// Simulate generation when no provider is available
 	if stream {
 		// Simulate streaming response
 		words := strings.Split(prompt+" This is a simulated streaming response from the model.", " ")
 		for _, word := range words {
 			fmt.Printf("%s ", word)
-			time.Sleep(100 * time.Millisecond)
+			if err := sleepContext(ctx, 100*time.Millisecond); err != nil {
+				fmt.Println()
+				return err
+			}
 		}
 		fmt.Println()
 	} else {
 		// Simulate non-streaming response
-		response := fmt.Sprintf("Generated response for: %s\n\nThis is a simulated response from the %s model. The prompt was processed successfully and the model generated appropriate output based on the input provided.", prompt, model)
-		fmt.Println(response)
+		response := fmt.Sprintf("Generated response for: %s\n\nThis is a simulated response from the %s model. The prompt was processed successfully and the model generated appropriate output based on the input provided.", prompt, label)
+		if asJSON {
+			return printGeneration(os.Stdout, generationOutput{Model: label, Content: c.postProcess.Process(response)})
+		}
+		fmt.Println(c.postProcess.Process(response))
 	}
 	
 	fmt.Printf("\n✅ Generation completed\n")
 	return nil
 }
 
+// retrying wraps provider so its Generate calls retry transient failures,
+// such as a local server still loading its model, under the model manager's
+// retry policy
+func (c *CLI) retrying(provider llm.Provider) llm.Provider {
+	return llm.NewRetryingProvider(provider, c.modelManager.RetryPolicy())
+}
+
+// generateWithProvider performs generation with the default LLM provider
+func (c *CLI) generateWithProvider(ctx context.Context, prompt, model string, maxTokens int, temperature float64, stream, asJSON bool) error {
+	request := &llm.LLMRequest{
+		ID:          uuid.New(),
+		Model:       model,
+		Messages:    []llm.Message{{Role: "user", Content: prompt}},
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		Stream:      stream,
+		CreatedAt:   time.Now(),
+	}
+
+	if !stream {
+		response, err := c.retrying(c.llmProvider).Generate(ctx, request)
+		if err != nil {
+			c.reportMissingModel(ctx, err)
+			return fmt.Errorf("generation failed: %v", err)
+		}
+		c.postProcess.Apply(response)
+		if asJSON {
+			if model == "" {
+				model = c.llmProvider.GetName()
+			}
+			return printGeneration(os.Stdout, generationOutput{
+				Model:        model,
+				Content:      response.Content,
+				FinishReason: response.FinishReason,
+				Usage:        response.Usage,
+			})
+		}
+		fmt.Println(response.Content)
+		fmt.Printf("\n✅ Generation completed\n")
+		return nil
+	}
+
+	ch := make(chan llm.LLMResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.llmProvider.GenerateStream(ctx, request, ch)
+	}()
+
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				ch = nil
+				continue
+			}
+			fmt.Print(chunk.Content)
+		case err := <-errCh:
+			fmt.Println()
+			if err != nil {
+				c.reportMissingModel(ctx, err)
+				return fmt.Errorf("streaming failed: %v", err)
+			}
+			fmt.Printf("\n✅ Generation completed\n")
+			return nil
+		}
+	}
+}
+
+// reportMissingModel prints the models available instead of a missing one,
+// and how to pull it when the provider can download models
+func (c *CLI) reportMissingModel(ctx context.Context, err error) {
+	var notFound *llm.ModelNotFoundError
+	if !errors.As(err, &notFound) {
+		return
+	}
+
+	fmt.Printf("\n❌ Model %s is not available on %s\n", notFound.Model, notFound.Provider)
+	available := notFound.Available
+	if len(available) == 0 {
+		for _, model := range c.modelManager.ModelAlternatives(ctx, notFound.Model) {
+			available = append(available, model.Name)
+		}
+	}
+	if len(available) > 0 {
+		fmt.Println("Available models:")
+		for _, model := range available {
+			fmt.Printf("  - %s\n", model)
+		}
+	}
+	if _, ok := c.llmProvider.(llm.ModelPuller); ok {
+		fmt.Printf("Download it with: --pull %s\n", notFound.Model)
+	}
+}
+
+// handleTemplate renders a prompt template, such as "explain", for the code
+// given by its arguments and generates a response for it
+func (c *CLI) handleTemplate(ctx context.Context, name string, args []string, model string, maxTokens int, temperature float64, stream streamMode, asJSON bool) error {
+	library := llm.NewTemplateLibrary()
+	if err := library.LoadDir(llm.DefaultTemplateDir()); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	if !library.Has(name) {
+		return configError(fmt.Errorf("unknown command or template %q (templates: %s)", name, strings.Join(library.Names(), ", ")))
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := flags.String("file", "", "File containing the code (stdin when empty or -)")
+	language := flags.String("language", "", "Language of the code (derived from --file when empty)")
+	var params []string
+	flags.Func("param", "Template parameter as key=value, repeatable", func(value string) error {
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("parameter must be key=value, got %q", value)
+		}
+		params = append(params, value)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+
+	data := llm.TemplateData{Language: *language, Params: make(map[string]string)}
+	for _, param := range params {
+		key, value, _ := strings.Cut(param, "=")
+		data.Params[key] = value
+	}
+
+	var code []byte
+	var err error
+	if *file == "" || *file == "-" {
+		code, err = io.ReadAll(os.Stdin)
+	} else {
+		data.File = *file
+		code, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return configError(fmt.Errorf("failed to read code: %v", err))
+	}
+	data.Code = string(code)
+
+	prompt, err := library.Render(name, data)
+	if err != nil {
+		return configError(err)
+	}
+	return c.handleGenerate(ctx, prompt, model, maxTokens, temperature, stream, asJSON)
+}
+
 // handleNotification sends a notification
 func (c *CLI) handleNotification(ctx context.Context, message, notifyType, priority string) error {
 	notificationType := notification.NotificationType(notifyType)
@@ -236,7 +1035,9 @@ func (c *CLI) handleCommand(ctx context.Context, command string) error {
 	// In production, this would execute on a worker
 	
 	fmt.Printf("Executing: %s\n", command)
-	time.Sleep(1 * time.Second)
+	if err := sleepContext(ctx, 1*time.Second); err != nil {
+		return fmt.Errorf("command interrupted: %w", err)
+	}
 	fmt.Printf("Command completed successfully\n")
 	
 	return nil
@@ -247,28 +1048,33 @@ func (c *CLI) handleInteractive(ctx context.Context) error {
 	fmt.Println("=== Helix CLI Interactive Mode ===")
 	fmt.Println("Type 'help' for available commands, 'exit' to quit")
 	
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Read input in the background so an interrupt ends the session even
+	// while waiting for a line
+	inputs := make(chan string)
+	inputErr := make(chan error, 1)
+	go func() {
+		for {
+			var input string
+			_, err := fmt.Scanln(&input)
+			if err != nil && err.Error() != "unexpected newline" {
+				inputErr <- err
+				return
+			}
+			inputs <- input
+		}
+	}()
 	
 	for {
-		select {
-		case <-sigChan:
-			fmt.Println("\n\nShutting down...")
-			return nil
-		default:
-			// Continue with interactive loop
-		}
-		
 		fmt.Print("\nhelix> ")
 		
 		var input string
-		_, err := fmt.Scanln(&input)
-		if err != nil {
-			if err.Error() == "unexpected newline" {
-				continue
-			}
+		select {
+		case <-ctx.Done():
+			fmt.Println("\n\nShutting down...")
+			return nil
+		case err := <-inputErr:
 			return err
+		case input = <-inputs:
 		}
 		
 		input = strings.TrimSpace(input)
@@ -293,7 +1099,9 @@ func (c *CLI) handleInteractive(ctx context.Context) error {
 		case "models":
 			c.handleListModels(ctx)
 		case "health":
-			c.handleHealthCheck(ctx)
+			c.handleHealthCheck(ctx, health.DefaultTimeout, false)
+		case "health refresh":
+			c.handleHealthCheck(ctx, health.DefaultTimeout, true)
 		default:
 			fmt.Printf("Unknown command: %s. Type 'help' for available commands.\n", input)
 		}
@@ -306,28 +1114,114 @@ func (c *CLI) showHelp() {
 	fmt.Println("workers          - List all workers")
 	fmt.Println("models           - List available models")
 	fmt.Println("health           - Perform system health check")
+	fmt.Println("health refresh   - Perform health check, probing providers again")
 	fmt.Println("help             - Show this help message")
 	fmt.Println("exit/quit        - Exit the CLI")
 	fmt.Println("")
 	fmt.Println("=== Command Line Options ===")
 	fmt.Println("--list-workers   - List all workers")
 	fmt.Println("--list-models    - List available models")
+	fmt.Println("--probe-models   - Probe and rank available models by capability")
+	fmt.Println("models rank      - Rank models by probes and a latency benchmark, skipping ones too large for this hardware")
+	fmt.Println("                   (--runs N timed requests per model, default 3; --json for JSON output)")
+	fmt.Println("--recommend      - Recommend a model for a task type (use --json for JSON output)")
+	fmt.Println("--prefer         - Prefer a model for a task type (task=model)")
+	fmt.Println("--clear-preference - Clear the preferred model for a task type")
 	fmt.Println("--health         - Perform health check")
+	fmt.Println("--health-timeout - Timeout for each subsystem's health check (default 5s)")
+	fmt.Println("--hardware       - Show detected hardware (use --json for JSON output)")
+	fmt.Println("--simulate-ram   - Simulate total RAM with --hardware (e.g. 16GB)")
+	fmt.Println("--simulate-vram  - Simulate GPU VRAM with --hardware (e.g. 8GB)")
+	fmt.Println("--hardware-profile - Use a saved hardware profile with --hardware")
 	fmt.Println("--worker         - Add a worker (requires --user)")
 	fmt.Println("--user           - Worker SSH username")
 	fmt.Println("--key            - Worker SSH key path")
-	fmt.Println("--prompt         - Generate with LLM")
+	fmt.Println("--drain-worker   - Drain a worker by ID, letting its running tasks finish")
+	fmt.Println("--server         - HelixCode server URL for --drain-worker")
+	fmt.Println("--prompt         - Generate with LLM (--json prints the response as a JSON object)")
+	fmt.Println("chat             - Chat with a model, keeping the conversation as context; Ctrl-C cancels a response")
+	fmt.Println("                   (--model to pick the model, selected automatically when empty; exit/quit to leave)")
+	fmt.Println("                   (--session <name> saves the chat and resumes it next time; --new starts it over)")
+	fmt.Println("sessions list    - List saved chat sessions (--json for JSON output; stored in ~/.config/helixcode/sessions)")
+	fmt.Println("<template> --file <path> - Generate from a prompt template: explain, test, refactor or document")
+	fmt.Println("                   (--language and repeatable --param key=value; user templates in ~/.config/helixcode/templates/*.tmpl)")
 	fmt.Println("--model          - LLM model to use")
-	fmt.Println("--stream         - Stream the response")
+	fmt.Println("--model-path     - GGUF model file for llama.cpp")
+	fmt.Println("--models-dir     - Directory scanned for GGUF models (default ~/models)")
+	fmt.Println("--stream         - Stream the response (the default when the provider supports it)")
+	fmt.Println("--no-stream      - Print the whole response at once (always the case with --json or --extract-code)")
+	fmt.Println("--extract-code   - Print only the code from the response")
+	fmt.Println("--verbose        - Log LLM requests and responses (secrets redacted)")
+	fmt.Println("--quiet          - Suppress informational logs; warnings and errors still go to stderr")
 	fmt.Println("--notify         - Send notification")
 	fmt.Println("--notify-type    - Notification type (info/warning/error/success/alert)")
 	fmt.Println("--notify-priority - Notification priority (low/medium/high/urgent)")
+	fmt.Println("")
+	fmt.Println("=== Exit Codes ===")
+	fmt.Println("0   - Success")
+	fmt.Println("1   - Other failure")
+	fmt.Println("2   - Invalid flags, configuration or input files")
+	fmt.Println("3   - No LLM provider available")
+	fmt.Println("4   - Health check found an unhealthy subsystem")
+	fmt.Println("130 - Interrupted")
+}
+
+// Close releases the providers opened by the CLI
+func (c *CLI) Close() {
+	if c.modelManager == nil {
+		return
+	}
+	for _, provider := range c.modelManager.Providers() {
+		if err := provider.Close(); err != nil {
+			log.Printf("⚠️ Failed to close provider %s: %v", provider.GetName(), err)
+		}
+	}
+}
+
+// sleepContext waits for d, returning early with ctx's error if it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 func main() {
 	cli := NewCLI()
-	
-	if err := cli.Run(); err != nil {
-		log.Fatalf("Error: %v", err)
+
+	// Handle interrupt signals
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		for sig := range sigCh {
+			// Ctrl-C while a chat response is generated cancels only that response
+			if sig == syscall.SIGINT && cli.Interrupt() {
+				continue
+			}
+			log.Println("🛑 Received interrupt signal, shutting down...")
+			cancel()
+			// A second signal terminates immediately
+			signal.Stop(sigCh)
+			return
+		}
+	}()
+
+	err := cli.Run(ctx)
+	cli.Close()
+	if ctx.Err() != nil {
+		// Whatever the command reported, the interrupt is why it ended
+		err = ctx.Err()
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Error: %v", err)
 	}
+	os.Exit(exitCode(err))
 }
\ No newline at end of file
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"dev.helix.code/internal/llm"
+)
+
+// instantClock is a mock clock whose waits advance it at once
+type instantClock struct {
+	*clock.Mock
+}
+
+func (c instantClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	return c.Mock.After(0)
+}
+
+// TestHandleCommand_Cancelled tests that cancelling the context aborts a
+// long-running command instead of waiting for it to finish
+func TestHandleCommand_Cancelled(t *testing.T) {
+	cli := &CLI{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- cli.handleCommand(ctx, "make build")
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Fatalf("command took %v after cancellation", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command did not stop after cancellation")
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGenerateWithProvider_RetriesWarmingServer(t *testing.T) {
+	// The server answers 503 until its model has loaded
+	var chats atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			fmt.Fprint(w, `{"models":[{"name":"chatty"}]}`)
+			return
+		}
+		if chats.Add(1) == 1 {
+			http.Error(w, "loading model", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hi!"},"done":true}`)
+	}))
+	defer server.Close()
+
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	manager := llm.NewModelManager()
+	manager.SetRetryPolicy(llm.RetryPolicy{MaxRetries: 2, Clock: instantClock{clock.NewMock(time.Now())}})
+	cli := &CLI{modelManager: manager, llmProvider: provider}
+
+	if err := cli.generateWithProvider(context.Background(), "hello", "chatty", 100, 0.7, false, true); err != nil {
+		t.Fatalf("expected the generation to be retried, got %v", err)
+	}
+	if n := chats.Load(); n != 2 {
+		t.Fatalf("expected 2 chat requests, got %d", n)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/llm/probe"
+)
+
+// newRankingCLI returns a CLI whose only provider is an Ollama server with
+// two models that answer every prompt the same way
+func newRankingCLI(t *testing.T) *CLI {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			fmt.Fprint(w, `{"models":[{"name":"tiny"},{"name":"llama2:70b"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"OK"},"done":true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	manager := llm.NewModelManager()
+	if err := manager.RegisterProvider(provider); err != nil {
+		t.Fatalf("failed to register provider: %v", err)
+	}
+	return &CLI{modelManager: manager}
+}
+
+func TestHandleModels_Rank(t *testing.T) {
+	cli := newRankingCLI(t)
+
+	if err := cli.handleModels(context.Background(), []string{"rank", "--runs", "1"}, true); err != nil {
+		t.Fatalf("models rank failed: %v", err)
+	}
+
+	for _, args := range [][]string{nil, {"list"}, {"rank", "--runs", "0"}} {
+		err := cli.handleModels(context.Background(), args, false)
+		if code := exitCode(err); code != exitConfig {
+			t.Fatalf("models %v: exit code %d (%v), want %d", args, code, err, exitConfig)
+		}
+	}
+}
+
+func TestPrintRankings(t *testing.T) {
+	rankings := []probe.Ranking{
+		{Rank: 1, Model: "capable", Provider: "ollama", Score: 0.92, Reasoning: 0.9, ToolCalling: 1, CodeGeneration: 0.85,
+			Passed: true, Benchmark: probe.BenchmarkResult{Runs: 3, Median: 1234 * time.Millisecond}},
+		{Rank: 2, Model: "broken", Provider: "ollama", Benchmark: probe.BenchmarkResult{Error: "model failed to load"}},
+	}
+
+	var table bytes.Buffer
+	if err := printRankings(&table, rankings, false); err != nil {
+		t.Fatalf("printRankings failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected title, header and 2 rows, got:\n%s", table.String())
+	}
+	for _, want := range []string{"capable", "92%", "100%", "1.234s", "yes"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("row %q does not contain %q", lines[2], want)
+		}
+	}
+	if !strings.Contains(lines[3], "failed") || !strings.HasSuffix(lines[3], "no") {
+		t.Errorf("row %q does not show the failed benchmark", lines[3])
+	}
+
+	var out bytes.Buffer
+	if err := printRankings(&out, rankings, true); err != nil {
+		t.Fatalf("printRankings failed: %v", err)
+	}
+	var decoded []probe.Ranking
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].Model != "capable" || decoded[1].Benchmark.Error == "" {
+		t.Fatalf("unexpected JSON ranking: %+v", decoded)
+	}
+}
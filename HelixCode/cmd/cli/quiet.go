@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// warningMarkers identify log lines that still surface in quiet mode
+var warningMarkers = []string{"⚠️", "❌", "Warning", "Error", "error", "Failed", "failed", "unhealthy"}
+
+// quietWriter drops informational log lines, passing warnings and errors
+// through to out. The log package writes each message with a single call.
+type quietWriter struct {
+	out io.Writer
+}
+
+// newQuietWriter creates a writer that suppresses informational log lines
+func newQuietWriter(out io.Writer) io.Writer {
+	return &quietWriter{out: out}
+}
+
+func (w *quietWriter) Write(p []byte) (int, error) {
+	if !isWarning(string(p)) {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// isWarning reports whether a log line reports a warning or an error
+func isWarning(line string) bool {
+	for _, marker := range warningMarkers {
+		if strings.Contains(line, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestQuietWriter tests that quiet mode drops informational logs but keeps
+// warnings and errors
+func TestQuietWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(newQuietWriter(&buf), "", 0)
+
+	logger.Println("✅ Ollama provider initialized with 3 models")
+	logger.Printf("🔄 Switched model to %s", "llama3")
+	logger.Printf("⚠️ Model discovery failed: %s", "no such directory")
+	logger.Printf("Warning: Failed to discover Ollama models: %s", "connection refused")
+	logger.Printf("Error: %s", "generation failed")
+
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"⚠️ Model discovery failed: no such directory",
+		"Warning: Failed to discover Ollama models: connection refused",
+		"Error: generation failed",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("quiet output = %q, want %q", got, want)
+	}
+}
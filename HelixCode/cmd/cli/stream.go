@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"dev.helix.code/internal/llm"
+)
+
+// streamMode is the choice made with --stream and --no-stream
+type streamMode int
+
+const (
+	streamAuto streamMode = iota // Stream when the provider supports it
+	streamOn                     // --stream
+	streamOff                    // --no-stream
+)
+
+// streamModeFromFlags returns the mode chosen by the --stream and
+// --no-stream flags, which cannot both be set
+func streamModeFromFlags(stream, noStream bool) (streamMode, error) {
+	switch {
+	case stream && noStream:
+		return streamAuto, fmt.Errorf("--stream and --no-stream cannot be used together")
+	case stream:
+		return streamOn, nil
+	case noStream:
+		return streamOff, nil
+	}
+	return streamAuto, nil
+}
+
+// resolveStreaming reports whether a response is streamed. Output that needs
+// the whole response, such as a JSON object, is always buffered; otherwise
+// an explicit choice wins, and by default a response is streamed when the
+// provider supports it.
+func resolveStreaming(mode streamMode, provider llm.Provider, buffered bool) bool {
+	switch {
+	case buffered, mode == streamOff:
+		return false
+	case mode == streamOn:
+		return true
+	case provider == nil:
+		return false
+	}
+	return llm.GetProviderCapabilities(provider).Streaming
+}
+
+// generationOutput is the JSON output of a generation
+type generationOutput struct {
+	Model        string    `json:"model"`
+	Content      string    `json:"content"`
+	FinishReason string    `json:"finish_reason,omitempty"`
+	Usage        llm.Usage `json:"usage"`
+}
+
+// printGeneration writes a generation as a JSON object
+func printGeneration(w io.Writer, output generationOutput) error {
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %v", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"dev.helix.code/internal/llm"
+)
+
+// streamingProvider reports streaming support; the embedded provider is never called
+type streamingProvider struct {
+	llm.Provider
+}
+
+func (p streamingProvider) SupportsStreaming() bool {
+	return true
+}
+
+// bufferedProvider does not report streaming support
+type bufferedProvider struct {
+	llm.Provider
+}
+
+func TestStreamModeFromFlags(t *testing.T) {
+	tests := []struct {
+		stream, noStream bool
+		want             streamMode
+	}{
+		{false, false, streamAuto},
+		{true, false, streamOn},
+		{false, true, streamOff},
+	}
+	for _, tt := range tests {
+		mode, err := streamModeFromFlags(tt.stream, tt.noStream)
+		if err != nil || mode != tt.want {
+			t.Errorf("streamModeFromFlags(%v, %v) = %v, %v; want %v", tt.stream, tt.noStream, mode, err, tt.want)
+		}
+	}
+	if _, err := streamModeFromFlags(true, true); err == nil {
+		t.Error("expected an error for --stream with --no-stream")
+	}
+}
+
+func TestResolveStreaming(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     streamMode
+		provider llm.Provider
+		buffered bool
+		want     bool
+	}{
+		{"default streams when supported", streamAuto, streamingProvider{}, false, true},
+		{"default buffers when unsupported", streamAuto, bufferedProvider{}, false, false},
+		{"default buffers without a provider", streamAuto, nil, false, false},
+		{"--stream", streamOn, bufferedProvider{}, false, true},
+		{"--no-stream", streamOff, streamingProvider{}, false, false},
+		{"--json buffers by default", streamAuto, streamingProvider{}, true, false},
+		{"--json buffers with --stream", streamOn, streamingProvider{}, true, false},
+	}
+	for _, tt := range tests {
+		if got := resolveStreaming(tt.mode, tt.provider, tt.buffered); got != tt.want {
+			t.Errorf("%s: resolveStreaming = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrintGeneration(t *testing.T) {
+	var out bytes.Buffer
+	output := generationOutput{Model: "llama3", Content: "hello", FinishReason: "stop", Usage: llm.Usage{TotalTokens: 3}}
+	if err := printGeneration(&out, output); err != nil {
+		t.Fatalf("failed to print generation: %v", err)
+	}
+
+	var decoded generationOutput
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, out.String())
+	}
+	if decoded != output {
+		t.Fatalf("decoded %+v, want %+v", decoded, output)
+	}
+}
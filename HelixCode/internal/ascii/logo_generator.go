@@ -1,4 +1,5 @@
-// internal/ascii/logo_generator.go
+// Package ascii renders the HelixCode logo and status displays as colored
+// terminal text
 package ascii
 
 import (
@@ -17,6 +18,7 @@ type LogoASCIIGenerator struct {
 	height   int
 }
 
+// NewLogoASCIIGenerator creates a generator for the PNG logo at logoPath
 func NewLogoASCIIGenerator(logoPath string, width, height int) *LogoASCIIGenerator {
 	return &LogoASCIIGenerator{
 		logoPath: logoPath,
@@ -36,7 +38,7 @@ var (
 		"\033[38;5;46m",  // Very bright green
 		"\033[38;5;118m", // Light green
 	}
-	
+
 	// ASCII characters from darkest to lightest
 	ASCIIChars = []string{" ", ".", ":", "-", "=", "+", "*", "#", "%", "@"}
 )
@@ -49,18 +51,18 @@ func (g *LogoASCIIGenerator) GenerateFromLogo() (string, error) {
 		return g.GenerateFallbackLogo(), fmt.Errorf("failed to open logo: %w", err)
 	}
 	defer file.Close()
-	
+
 	img, err := png.Decode(file)
 	if err != nil {
 		return g.GenerateFallbackLogo(), fmt.Errorf("failed to decode PNG: %w", err)
 	}
-	
+
 	// Resize image to desired ASCII dimensions
 	resized := g.resizeImage(img, g.width, g.height)
-	
+
 	// Convert to ASCII with colors
 	asciiArt := g.convertToASCII(resized)
-	
+
 	return asciiArt, nil
 }
 
@@ -69,20 +71,20 @@ func (g *LogoASCIIGenerator) resizeImage(img image.Image, width, height int) ima
 	bounds := img.Bounds()
 	imgWidth := bounds.Dx()
 	imgHeight := bounds.Dy()
-	
+
 	// Calculate aspect ratio preserving dimensions
 	aspectRatio := float64(imgWidth) / float64(imgHeight)
 	newWidth := width
 	newHeight := int(float64(width) / aspectRatio)
-	
+
 	if newHeight > height {
 		newHeight = height
 		newWidth = int(float64(height) * aspectRatio)
 	}
-	
+
 	// Create new image with calculated dimensions
 	resized := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
-	
+
 	// Simple nearest-neighbor resize
 	for y := 0; y < newHeight; y++ {
 		for x := 0; x < newWidth; x++ {
@@ -91,7 +93,7 @@ func (g *LogoASCIIGenerator) resizeImage(img image.Image, width, height int) ima
 			resized.Set(x, y, img.At(srcX, srcY))
 		}
 	}
-	
+
 	return resized
 }
 
@@ -100,17 +102,17 @@ func (g *LogoASCIIGenerator) convertToASCII(img image.Image) string {
 	bounds := img.Bounds()
 	width := bounds.Dx()
 	height := bounds.Dy()
-	
+
 	var ascii strings.Builder
-	
+
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			// Get pixel color
 			c := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
-			
+
 			// Convert to brightness (0-255)
 			brightness := float64(c.Y)
-			
+
 			// Map brightness to ASCII character
 			charIndex := int((brightness / 255.0) * float64(len(ASCIIChars)-1))
 			if charIndex < 0 {
@@ -119,7 +121,7 @@ func (g *LogoASCIIGenerator) convertToASCII(img image.Image) string {
 			if charIndex >= len(ASCIIChars) {
 				charIndex = len(ASCIIChars) - 1
 			}
-			
+
 			// Map brightness to green color
 			colorIndex := int((brightness / 255.0) * float64(len(GreenPalette)-1))
 			if colorIndex < 0 {
@@ -128,14 +130,14 @@ func (g *LogoASCIIGenerator) convertToASCII(img image.Image) string {
 			if colorIndex >= len(GreenPalette) {
 				colorIndex = len(GreenPalette) - 1
 			}
-			
+
 			// Write colored character
 			ascii.WriteString(GreenPalette[colorIndex])
 			ascii.WriteString(ASCIIChars[charIndex])
 		}
 		ascii.WriteString("\033[0m\n") // Reset color and newline
 	}
-	
+
 	return ascii.String()
 }
 
@@ -189,12 +191,12 @@ func (g *LogoASCIIGenerator) GenerateMinimalLogo() string {
 // GenerateLogoWithStatus creates a logo with system status information
 func (g *LogoASCIIGenerator) GenerateLogoWithStatus(workers, models, sessions int) string {
 	logo := g.GenerateSimpleLogo()
-	
+
 	status := fmt.Sprintf(`
 `+GreenPalette[2]+`Status:`+GreenPalette[5]+` Workers: %d | Models: %d | Sessions: %d
 `+GreenPalette[2]+`Ready for distributed AI development. Type 'help' for available commands.`+"\033[0m",
 		workers, models, sessions)
-	
+
 	return logo + status
 }
 
@@ -203,15 +205,15 @@ func (g *LogoASCIIGenerator) GenerateAnimatedLogo(step int) string {
 	// Cycle through different green shades for animation
 	animationColors := []string{
 		GreenPalette[2],
-		GreenPalette[3], 
+		GreenPalette[3],
 		GreenPalette[4],
 		GreenPalette[5],
 		GreenPalette[4],
 		GreenPalette[3],
 	}
-	
+
 	colorIndex := step % len(animationColors)
-	
+
 	logo := animationColors[colorIndex] + `
     ╦ ╦┌─┐┬  ┌─┐┌─┐┌┬┐┌─┐┌─┐
     ║║║├┤ │  │  │ ││││├┤ └─┐
@@ -252,11 +254,11 @@ func (g *LogoASCIIGenerator) GetLogoDimensions() (int, int, error) {
 		return 0, 0, err
 	}
 	defer file.Close()
-	
+
 	img, err := png.DecodeConfig(file)
 	if err != nil {
 		return 0, 0, err
 	}
-	
+
 	return img.Width, img.Height, nil
-}
\ No newline at end of file
+}
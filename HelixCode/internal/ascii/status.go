@@ -1,15 +1,10 @@
-package main
+package ascii
 
 import (
 	"fmt"
-	"os"
 )
 
-func main() {
-	logo := GenerateHelixCodeLogo()
-	fmt.Println(logo)
-}
-
+// GenerateHelixCodeLogo returns the colored HelixCode banner
 func GenerateHelixCodeLogo() string {
 	return `
 [32m    ██╗  ██╗███████╗██╗     ██╗██╗  ██╗     ██████╗ ██████╗ ██████╗ ███████╗[0m
@@ -30,10 +25,11 @@ func GenerateHelixCodeLogo() string {
 `
 }
 
+// GenerateProgressBar renders progress (0-1) as a colored bar of the given width
 func GenerateProgressBar(progress float64, width int) string {
 	filled := int(progress * float64(width))
 	bar := ""
-	
+
 	for i := 0; i < width; i++ {
 		if i < filled {
 			bar += "[42m [0m" // Green background
@@ -41,14 +37,15 @@ func GenerateProgressBar(progress float64, width int) string {
 			bar += "[47m [0m" // White background
 		}
 	}
-	
+
 	return fmt.Sprintf("[%s] %.1f%%", bar, progress*100)
 }
 
+// GenerateWorkerStatus renders a status table for workers
 func GenerateWorkerStatus(workers []WorkerStatus) string {
 	status := "\n[36mWorker Status:[0m\n"
 	status += "[33m╔══════════════════════════════════════════════════════════╗\u001b[0m\n"
-	
+
 	for _, worker := range workers {
 		var statusColor, statusText string
 		switch worker.Status {
@@ -65,17 +62,18 @@ func GenerateWorkerStatus(workers []WorkerStatus) string {
 			statusColor = "[37m" // White
 			statusText = "● UNKNOWN"
 		}
-		
+
 		status += fmt.Sprintf("[33m║[0m %s%-12s[0m %-20s %-8s %-6s [33m║\u001b[0m\n",
-			statusColor, statusText, worker.Hostname, 
+			statusColor, statusText, worker.Hostname,
 			fmt.Sprintf("CPU:%.1f%%", worker.CPUUsage),
 			fmt.Sprintf("Tasks:%d", worker.CurrentTasks))
 	}
-	
+
 	status += "[33m╚══════════════════════════════════════════════════════════╝\u001b[0m"
 	return status
 }
 
+// WorkerStatus is a worker row in GenerateWorkerStatus
 type WorkerStatus struct {
 	Hostname     string
 	Status       string
@@ -83,10 +81,11 @@ type WorkerStatus struct {
 	CurrentTasks int
 }
 
+// GenerateTaskStatus renders a status table for tasks
 func GenerateTaskStatus(tasks []TaskStatus) string {
 	status := "\n[36mActive Tasks:[0m\n"
 	status += "[33m╔══════════════════════════════════════════════════════════════════════════════╗\u001b[0m\n"
-	
+
 	for _, task := range tasks {
 		var statusColor, statusText string
 		switch task.Status {
@@ -106,18 +105,19 @@ func GenerateTaskStatus(tasks []TaskStatus) string {
 			statusColor = "[37m" // White
 			statusText = "? UNKNOWN"
 		}
-		
+
 		progressBar := GenerateProgressBar(task.Progress, 20)
 		status += fmt.Sprintf("[33m║[0m %s%-10s[0m %-25s %-30s [33m║\u001b[0m\n",
 			statusColor, statusText, task.Name, progressBar)
 	}
-	
+
 	status += "[33m╚══════════════════════════════════════════════════════════════════════════════╝\u001b[0m"
 	return status
 }
 
+// TaskStatus is a task row in GenerateTaskStatus
 type TaskStatus struct {
 	Name     string
 	Status   string
 	Progress float64
-}
\ No newline at end of file
+}
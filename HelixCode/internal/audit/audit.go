@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"github.com/google/uuid"
+)
+
+// EntityType identifies what an audit event is about
+type EntityType string
+
+const (
+	EntityTask   EntityType = "task"
+	EntityWorker EntityType = "worker"
+)
+
+// Source identifies which part of the system caused an event
+type Source string
+
+const (
+	SourceAPI       Source = "api"
+	SourceScheduler Source = "scheduler"
+	SourceMonitor   Source = "monitor"
+	SourceSystem    Source = "system"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreated       = "created"
+	ActionStatusChanged = "status_changed"
+	ActionDeleted       = "deleted"
+	ActionReassigned    = "reassigned"
+)
+
+// DefaultPageSize and MaxPageSize bound the events returned by one query
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Event is one entry of the append-only audit log
+type Event struct {
+	ID         uuid.UUID              `json:"id"`
+	EntityType EntityType             `json:"entity_type"`
+	EntityID   uuid.UUID              `json:"entity_id"`
+	Action     string                 `json:"action"`
+	FromStatus string                 `json:"from_status,omitempty"`
+	ToStatus   string                 `json:"to_status,omitempty"`
+	Source     Source                 `json:"source"`
+	Actor      string                 `json:"actor,omitempty"` // e.g. the API user
+	Details    map[string]interface{} `json:"details,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Query selects audit events. Zero fields match everything; events are
+// returned oldest first.
+type Query struct {
+	EntityType EntityType
+	EntityID   uuid.UUID
+	Since      time.Time // Inclusive
+	Until      time.Time // Exclusive
+	Limit      int       // DefaultPageSize when zero, at most MaxPageSize
+	Offset     int
+}
+
+// Page is one page of query results
+type Page struct {
+	Events []*Event `json:"events"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+// Store persists audit events. Events are only ever appended.
+type Store interface {
+	Append(ctx context.Context, event *Event) error
+	Query(ctx context.Context, query Query) (*Page, error)
+}
+
+// Actor is who or what caused an event
+type Actor struct {
+	Source Source
+	Name   string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose audited changes are attributed to actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or fallback
+func ActorFromContext(ctx context.Context, fallback Source) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Source: fallback}
+}
+
+// Log records task and worker changes to a store. A nil Log records nothing,
+// so components can audit unconditionally.
+type Log struct {
+	store Store
+	mu    sync.RWMutex
+	clock clock.Clock
+}
+
+// NewLog creates an audit log backed by store
+func NewLog(store Store) *Log {
+	return &Log{store: store, clock: clock.New()}
+}
+
+// SetClock replaces the clock used to timestamp events
+func (l *Log) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+}
+
+// Record appends an event for an entity, attributed to the actor in ctx or
+// else to fallback. Failures are logged rather than returned so that auditing
+// never blocks the change being audited.
+func (l *Log) Record(ctx context.Context, fallback Source, entityType EntityType, entityID uuid.UUID, action, from, to string, details map[string]interface{}) {
+	if l == nil {
+		return
+	}
+
+	l.mu.RLock()
+	now := l.clock.Now()
+	l.mu.RUnlock()
+
+	actor := ActorFromContext(ctx, fallback)
+	event := &Event{
+		ID:         uuid.New(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		FromStatus: from,
+		ToStatus:   to,
+		Source:     actor.Source,
+		Actor:      actor.Name,
+		Details:    details,
+		CreatedAt:  now,
+	}
+	if err := l.store.Append(ctx, event); err != nil {
+		log.Printf("⚠️ Failed to write audit event for %s %s: %v", entityType, entityID, err)
+	}
+}
+
+// Query returns a page of events from the log's store
+func (l *Log) Query(ctx context.Context, query Query) (*Page, error) {
+	return l.store.Query(ctx, query.normalized())
+}
+
+// normalized applies the default and maximum page size
+func (q Query) normalized() Query {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// matches reports whether an event satisfies the query filters
+func (q Query) matches(event *Event) bool {
+	if q.EntityType != "" && event.EntityType != q.EntityType {
+		return false
+	}
+	if q.EntityID != uuid.Nil && event.EntityID != q.EntityID {
+		return false
+	}
+	if !q.Since.IsZero() && event.CreatedAt.Before(q.Since) {
+		return false
+	}
+	return q.Until.IsZero() || event.CreatedAt.Before(q.Until)
+}
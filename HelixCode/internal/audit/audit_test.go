@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_RecordAndQuery(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(start)
+	auditLog := NewLog(NewMemoryStore())
+	auditLog.SetClock(mock)
+
+	taskID := uuid.New()
+	workerID := uuid.New()
+	apiCtx := WithActor(ctx, Actor{Source: SourceAPI, Name: "alice"})
+
+	auditLog.Record(apiCtx, SourceScheduler, EntityTask, taskID, ActionCreated, "", "pending", nil)
+	mock.Advance(time.Minute)
+	auditLog.Record(ctx, SourceMonitor, EntityWorker, workerID, ActionStatusChanged, "active", "offline", nil)
+	mock.Advance(time.Minute)
+	auditLog.Record(ctx, SourceScheduler, EntityTask, taskID, ActionStatusChanged, "pending", "running",
+		map[string]interface{}{"worker_id": workerID.String()})
+
+	page, err := auditLog.Query(ctx, Query{EntityID: taskID})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, ActionCreated, page.Events[0].Action)
+	assert.Equal(t, SourceAPI, page.Events[0].Source)
+	assert.Equal(t, "alice", page.Events[0].Actor)
+	assert.Equal(t, "running", page.Events[1].ToStatus)
+	assert.Equal(t, SourceScheduler, page.Events[1].Source)
+	assert.Empty(t, page.Events[1].Actor)
+
+	page, err = auditLog.Query(ctx, Query{EntityType: EntityWorker})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, SourceMonitor, page.Events[0].Source)
+
+	// Since is inclusive and Until exclusive
+	page, err = auditLog.Query(ctx, Query{Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, workerID, page.Events[0].EntityID)
+}
+
+func TestMemoryStore_Pagination(t *testing.T) {
+	ctx := context.Background()
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	auditLog := NewLog(NewMemoryStore())
+	auditLog.SetClock(mock)
+
+	taskID := uuid.New()
+	for i := 0; i < 5; i++ {
+		auditLog.Record(ctx, SourceScheduler, EntityTask, taskID, ActionStatusChanged, "", "", map[string]interface{}{"step": i})
+		mock.Advance(time.Second)
+	}
+
+	page, err := auditLog.Query(ctx, Query{Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.Total)
+	assert.Equal(t, 2, page.Limit)
+	require.Len(t, page.Events, 2)
+	assert.Equal(t, 2, page.Events[0].Details["step"])
+	assert.Equal(t, 3, page.Events[1].Details["step"])
+
+	page, err = auditLog.Query(ctx, Query{Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPageSize, page.Limit)
+	assert.Empty(t, page.Events)
+
+	page, err = auditLog.Query(ctx, Query{Limit: MaxPageSize + 1})
+	require.NoError(t, err)
+	assert.Equal(t, MaxPageSize, page.Limit)
+}
+
+func TestLog_NilRecordsNothing(t *testing.T) {
+	var auditLog *Log
+	assert.NotPanics(t, func() {
+		auditLog.Record(context.Background(), SourceSystem, EntityTask, uuid.New(), ActionCreated, "", "pending", nil)
+	})
+}
+
+func TestWhereClause(t *testing.T) {
+	where, args := whereClause(Query{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+
+	id := uuid.New()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	where, args = whereClause(Query{EntityType: EntityWorker, EntityID: id, Since: since})
+	assert.Equal(t, " WHERE entity_type = $1 AND entity_id = $2 AND created_at >= $3", where)
+	assert.Equal(t, []interface{}{"worker", id, since}, args)
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+)
+
+// NewStore returns a database store when a database is configured and an
+// in-memory store otherwise
+func NewStore(db *database.Database) Store {
+	if db.IsConfigured() {
+		return NewDatabaseStore(db)
+	}
+	return NewMemoryStore()
+}
+
+// MemoryStore keeps audit events in memory, for tests and deployments
+// without a database
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events []*Event
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append adds an event to the store
+func (s *MemoryStore) Append(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *event
+	s.events = append(s.events, &copied)
+	return nil
+}
+
+// Query returns a page of matching events, oldest first
+func (s *MemoryStore) Query(ctx context.Context, query Query) (*Page, error) {
+	query = query.normalized()
+
+	s.mu.RLock()
+	var matched []*Event
+	for _, event := range s.events {
+		if query.matches(event) {
+			copied := *event
+			matched = append(matched, &copied)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	page := &Page{Events: []*Event{}, Total: len(matched), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := query.Offset + query.Limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page.Events = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// DatabaseStore persists audit events in the audit_events table
+type DatabaseStore struct {
+	db *database.Database
+}
+
+// NewDatabaseStore creates a store backed by the database
+func NewDatabaseStore(db *database.Database) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+// Append inserts an event
+func (s *DatabaseStore) Append(ctx context.Context, event *Event) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %v", err)
+	}
+
+	_, err = s.db.Pool.Exec(ctx, `
+		INSERT INTO audit_events (
+			id, entity_type, entity_id, action, from_status, to_status,
+			source, actor, details, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, event.ID, string(event.EntityType), event.EntityID, event.Action, event.FromStatus, event.ToStatus,
+		string(event.Source), event.Actor, details, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store audit event: %v", err)
+	}
+	return nil
+}
+
+// Query returns a page of matching events, oldest first
+func (s *DatabaseStore) Query(ctx context.Context, query Query) (*Page, error) {
+	if !s.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+	query = query.normalized()
+	where, args := whereClause(query)
+
+	page := &Page{Events: []*Event{}, Limit: query.Limit, Offset: query.Offset}
+	if err := s.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count audit events: %v", err)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.db.Pool.Query(ctx, fmt.Sprintf(`
+		SELECT id, entity_type, entity_id, action, from_status, to_status,
+			source, actor, details, created_at
+		FROM audit_events%s
+		ORDER BY created_at, id
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event      Event
+			entityType string
+			source     string
+			details    []byte
+		)
+		if err := rows.Scan(&event.ID, &entityType, &event.EntityID, &event.Action, &event.FromStatus,
+			&event.ToStatus, &source, &event.Actor, &details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %v", err)
+		}
+		event.EntityType = EntityType(entityType)
+		event.Source = Source(source)
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &event.Details); err != nil {
+				return nil, fmt.Errorf("failed to decode audit details: %v", err)
+			}
+		}
+		page.Events = append(page.Events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit rows: %v", err)
+	}
+	return page, nil
+}
+
+// whereClause builds the SQL filter for a query and its arguments
+func whereClause(query Query) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.EntityType != "" {
+		add("entity_type = $%d", string(query.EntityType))
+	}
+	if query.EntityID != uuid.Nil {
+		add("entity_id = $%d", query.EntityID)
+	}
+	if !query.Since.IsZero() {
+		add("created_at >= $%d", query.Since)
+	}
+	if !query.Until.IsZero() {
+		add("created_at < $%d", query.Until)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,414 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks HelixCode API keys so they are recognisable in configs and logs
+const apiKeyPrefix = "hx_"
+
+// API key errors
+var (
+	ErrAPIKeyInvalid  = errors.New("invalid API key")
+	ErrAPIKeyRevoked  = errors.New("API key revoked")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// Scope limits what a credential may do
+type Scope string
+
+const (
+	ScopeFull     Scope = "full"
+	ScopeReadOnly Scope = "read"
+)
+
+// AllowsMethod reports whether a credential with this scope may make a
+// request with the HTTP method. Read-only credentials may only read.
+func (s Scope) AllowsMethod(method string) bool {
+	if s != ScopeReadOnly {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey is a long-lived credential for scripts and workers. Only a hash of
+// the key is stored; the key itself is shown once, when it is minted.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the key, to tell keys apart
+	Scope      Scope      `json:"scope"`
+	Role       Role       `json:"role"` // The minting user's role; keys act with the user's current role when it is known
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyStore persists API keys by the hash of the key
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey, keyHash string) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error
+}
+
+// UserLookup finds users by ID
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
+}
+
+// APIKeyService mints, verifies and revokes API keys
+type APIKeyService struct {
+	store APIKeyStore
+	users UserLookup
+}
+
+// NewAPIKeyService creates an API key service backed by store. Keys act with
+// their owner's current role from users; users may be nil, and owners it does
+// not know, such as users of an external backend, keep the role the key was
+// minted with.
+func NewAPIKeyService(store APIKeyStore, users UserLookup) *APIKeyService {
+	return &APIKeyService{store: store, users: users}
+}
+
+// Mint creates a key for user and returns it with its record. The key is not
+// stored and cannot be recovered later.
+func (s *APIKeyService) Mint(ctx context.Context, user *User, name string, scope Scope) (string, *APIKey, error) {
+	if scope == "" {
+		scope = ScopeFull
+	}
+	if scope != ScopeFull && scope != ScopeReadOnly {
+		return "", nil, fmt.Errorf("invalid scope %q: use %q or %q", scope, ScopeFull, ScopeReadOnly)
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", nil, errors.New("API key name is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
+	record := &APIKey{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Username:  user.Username,
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		Scope:     scope,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateAPIKey(ctx, record, hashAPIKey(key)); err != nil {
+		return "", nil, fmt.Errorf("failed to store API key: %v", err)
+	}
+	return key, record, nil
+}
+
+// List returns the keys of a user, including revoked ones
+func (s *APIKeyService) List(ctx context.Context, userID uuid.UUID) ([]*APIKey, error) {
+	return s.store.ListAPIKeys(ctx, userID)
+}
+
+// Revoke revokes one of a user's keys
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	return s.store.RevokeAPIKey(ctx, userID, keyID, time.Now())
+}
+
+// Verify returns the record of a key that is valid and not revoked
+func (s *APIKeyService) Verify(ctx context.Context, key string) (*APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	record, err := s.store.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	if record.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if err := s.store.TouchAPIKey(ctx, record.ID, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to record API key use: %v", err)
+	}
+	return record, nil
+}
+
+// Authenticate verifies the request's X-API-Key header. The user is limited
+// to the key's scope, and keys whose owner has been deleted are rejected.
+func (s *APIKeyService) Authenticate(ctx context.Context, r *http.Request) (*User, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	record, err := s.Verify(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	role := record.Role
+	if s.users != nil {
+		owner, err := s.users.GetUserByID(ctx, record.UserID)
+		switch {
+		case err == nil && !owner.IsActive:
+			return nil, ErrUserInactive
+		case err == nil:
+			role = owner.Role
+		case errors.Is(err, ErrUserNotFound):
+			return nil, ErrAPIKeyInvalid
+		default:
+			return nil, fmt.Errorf("failed to look up API key owner: %v", err)
+		}
+	}
+	return &User{ID: record.UserID, Username: record.Username, IsActive: true, Role: role, Scope: record.Scope}, nil
+}
+
+// hashAPIKey returns the stored form of a key. Keys are random, so a fast
+// hash is enough to make a leaked table useless.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKeyStore returns a database store when a database is configured and
+// an in-memory store otherwise
+func NewAPIKeyStore(db *database.Database) APIKeyStore {
+	if db.IsConfigured() {
+		return NewDatabaseAPIKeyStore(db)
+	}
+	return NewMemoryAPIKeyStore()
+}
+
+// MemoryAPIKeyStore keeps API keys in memory, for tests and deployments
+// without a database
+type MemoryAPIKeyStore struct {
+	mu     sync.RWMutex
+	keys   map[uuid.UUID]*APIKey
+	hashes map[string]uuid.UUID
+}
+
+// NewMemoryAPIKeyStore creates an empty in-memory store
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:   make(map[uuid.UUID]*APIKey),
+		hashes: make(map[string]uuid.UUID),
+	}
+}
+
+// CreateAPIKey stores a key by its hash
+func (s *MemoryAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey, keyHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *key
+	s.keys[key.ID] = &copied
+	s.hashes[keyHash] = key.ID
+	return nil
+}
+
+// GetAPIKeyByHash returns the key with the given hash
+func (s *MemoryAPIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, exists := s.hashes[keyHash]
+	if !exists {
+		return nil, ErrAPIKeyNotFound
+	}
+	copied := *s.keys[id]
+	return &copied, nil
+}
+
+// ListAPIKeys returns a user's keys, oldest first
+func (s *MemoryAPIKeyStore) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []*APIKey{}
+	for _, key := range s.keys {
+		if key.UserID == userID {
+			copied := *key
+			keys = append(keys, &copied)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of a user's keys
+func (s *MemoryAPIKeyStore) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[keyID]
+	if !exists || key.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+// TouchAPIKey records when a key was last used
+func (s *MemoryAPIKeyStore) TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, exists := s.keys[keyID]; exists {
+		key.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+// DatabaseAPIKeyStore persists API keys in the api_keys table
+type DatabaseAPIKeyStore struct {
+	db *database.Database
+}
+
+// NewDatabaseAPIKeyStore creates a store backed by the database
+func NewDatabaseAPIKeyStore(db *database.Database) *DatabaseAPIKeyStore {
+	return &DatabaseAPIKeyStore{db: db}
+}
+
+// CreateAPIKey inserts a key
+func (s *DatabaseAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey, keyHash string) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO api_keys (id, user_id, username, name, key_prefix, key_hash, scope, role, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, key.ID, key.UserID, key.Username, key.Name, key.Prefix, keyHash, string(key.Scope), string(key.Role), key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert API key: %v", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash returns the key with the given hash
+func (s *DatabaseAPIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	if !s.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
+	key, err := scanAPIKey(s.db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, username, name, key_prefix, scope, role, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE key_hash = $1
+	`, keyHash))
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns a user's keys, oldest first
+func (s *DatabaseAPIKeyStore) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error) {
+	if !s.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, user_id, username, name, key_prefix, scope, role, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []*APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key rows: %v", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of a user's keys
+func (s *DatabaseAPIKeyStore) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID, revokedAt time.Time) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	tag, err := s.db.Pool.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`, keyID, userID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records when a key was last used
+func (s *DatabaseAPIKeyStore) TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := s.db.Pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, keyID, usedAt); err != nil {
+		return fmt.Errorf("failed to update API key: %v", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by a single row and by a row set
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey reads an API key selected in the column order used above
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var (
+		key         APIKey
+		scope, role string
+	)
+	if err := row.Scan(&key.ID, &key.UserID, &key.Username, &key.Name, &key.Prefix, &scope, &role,
+		&key.CreatedAt, &key.LastUsedAt, &key.RevokedAt); err != nil {
+		return nil, err
+	}
+	key.Scope = Scope(scope)
+	key.Role = Role(role)
+	return &key, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAPIKeyService_MintVerifyRevoke(t *testing.T) {
+	store := NewMemoryAPIKeyStore()
+	service := NewAPIKeyService(store, nil)
+	user := &User{ID: uuid.New(), Username: "ci-bot"}
+	ctx := context.Background()
+
+	key, record, err := service.Mint(ctx, user, "nightly build", ScopeReadOnly)
+	if err != nil {
+		t.Fatalf("Failed to mint key: %v", err)
+	}
+	if !strings.HasPrefix(key, record.Prefix) || record.Scope != ScopeReadOnly {
+		t.Errorf("Unexpected key record: %+v", record)
+	}
+	if _, err := store.GetAPIKeyByHash(ctx, key); err == nil {
+		t.Error("Expected the key to be stored hashed, not in plain text")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, key)
+	authenticated, err := service.Authenticate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to authenticate with key: %v", err)
+	}
+	if authenticated.ID != user.ID || authenticated.Scope != ScopeReadOnly {
+		t.Errorf("Unexpected user: %+v", authenticated)
+	}
+	if authenticated.Scope.AllowsMethod(http.MethodPost) || !authenticated.Scope.AllowsMethod(http.MethodGet) {
+		t.Error("Expected a read-only key to allow reads only")
+	}
+
+	keys, err := service.List(ctx, user.ID)
+	if err != nil || len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Fatalf("Expected one key with its last use recorded, got %+v (%v)", keys, err)
+	}
+
+	if err := service.Revoke(ctx, uuid.New(), record.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected another user's revoke to fail with ErrAPIKeyNotFound, got %v", err)
+	}
+	if err := service.Revoke(ctx, user.ID, record.ID); err != nil {
+		t.Fatalf("Failed to revoke key: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, req); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("Expected ErrAPIKeyRevoked, got %v", err)
+	}
+
+	req.Header.Set(APIKeyHeader, "hx_forged")
+	if _, err := service.Authenticate(ctx, req); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Expected ErrAPIKeyInvalid, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials without a key, got %v", err)
+	}
+	if _, _, err := service.Mint(ctx, user, "admin", Scope("root")); err == nil {
+		t.Error("Expected an unknown scope to be rejected")
+	}
+}
+
+func TestAPIKeyService_OwnerRole(t *testing.T) {
+	users := NewMemoryAuthRepository()
+	service := NewAPIKeyService(NewMemoryAPIKeyStore(), users)
+	ctx := context.Background()
+	owner := &User{ID: uuid.New(), Username: "ops", Email: "ops@example.com", Role: RoleAdmin, IsActive: true}
+	if err := users.CreateUser(ctx, owner, ""); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	key, _, err := service.Mint(ctx, owner, "deploy", ScopeFull)
+	if err != nil {
+		t.Fatalf("Failed to mint key: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, key)
+
+	if err := users.SetUserRole(ctx, owner.ID, RoleUser); err != nil {
+		t.Fatalf("Failed to demote user: %v", err)
+	}
+	if authenticated, err := service.Authenticate(ctx, req); err != nil || authenticated.Role != RoleUser {
+		t.Errorf("Expected the key to act with the owner's current role, got %v (%v)", authenticated, err)
+	}
+
+	// Keys of deleted owners no longer authenticate
+	deleted := &User{ID: uuid.New(), Username: "deleted", Role: RoleAdmin}
+	key, _, err = service.Mint(ctx, deleted, "sync", ScopeFull)
+	if err != nil {
+		t.Fatalf("Failed to mint key: %v", err)
+	}
+	req.Header.Set(APIKeyHeader, key)
+	if _, err := service.Authenticate(ctx, req); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Expected a deleted owner's key to be rejected, got %v", err)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log"
 	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -23,6 +25,8 @@ var (
 	ErrTokenInvalid       = errors.New("invalid token")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserExists         = errors.New("user already exists")
+	ErrUserInactive       = errors.New("account is deactivated")
+	ErrInvalidRegistration = errors.New("invalid registration")
 )
 
 // User represents an authenticated user
@@ -37,6 +41,29 @@ type User struct {
 	LastLogin    time.Time `json:"last_login"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	Role         Role      `json:"role"`
+	Scope        Scope     `json:"scope,omitempty"` // Set for API keys; empty is full access
+}
+
+// Role determines which endpoints a user may call
+type Role string
+
+const (
+	RoleAdmin Role = "admin" // Manages workers and the system
+	RoleUser  Role = "user"  // Works with projects and tasks
+)
+
+// HasRole reports whether the user may act with role. Admins hold every
+// role, and users without a role are plain users.
+func (u *User) HasRole(role Role) bool {
+	switch u.Role {
+	case RoleAdmin:
+		return true
+	case "":
+		return role == RoleUser
+	default:
+		return u.Role == role
+	}
 }
 
 // Session represents a user session
@@ -61,6 +88,7 @@ type AuthConfig struct {
 	Argon2Memory       uint32
 	Argon2Threads      uint8
 	Argon2KeyLength    uint32
+	InitialAdmin       string // Username made an admin when registered or on BootstrapAdmin
 }
 
 // DefaultConfig returns a default authentication configuration
@@ -94,6 +122,7 @@ type AuthRepository interface {
 	GetSession(ctx context.Context, token string) (*Session, error)
 	DeleteSession(ctx context.Context, token string) error
 	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	SetUserRole(ctx context.Context, id uuid.UUID, role Role) error
 }
 
 // NewAuthService creates a new authentication service
@@ -108,15 +137,15 @@ func NewAuthService(config AuthConfig, db AuthRepository) *AuthService {
 func (s *AuthService) Register(ctx context.Context, username, email, password, displayName string) (*User, error) {
 	// Validate input
 	if err := s.validateRegistration(username, email, password); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
 	}
 
 	// Check if user already exists
-	if _, _, err := s.db.GetUserByUsername(ctx, username); err == nil {
+	if _, _, err := s.db.GetUserByUsername(ctx, strings.ToLower(username)); err == nil {
 		return nil, ErrUserExists
 	}
 
-	if _, _, err := s.db.GetUserByEmail(ctx, email); err == nil {
+	if _, _, err := s.db.GetUserByEmail(ctx, strings.ToLower(email)); err == nil {
 		return nil, ErrUserExists
 	}
 
@@ -126,6 +155,12 @@ func (s *AuthService) Register(ctx context.Context, username, email, password, d
 		return nil, fmt.Errorf("failed to hash password: %v", err)
 	}
 
+	// The configured initial admin bootstraps administration
+	role := RoleUser
+	if s.isInitialAdmin(username) {
+		role = RoleAdmin
+	}
+
 	// Create user
 	user := &User{
 		ID:          uuid.New(),
@@ -135,18 +170,48 @@ func (s *AuthService) Register(ctx context.Context, username, email, password, d
 		IsActive:    true,
 		IsVerified:  false,
 		MFAEnabled:  false,
+		Role:        role,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
 	// Save user to database
 	if err := s.db.CreateUser(ctx, user, passwordHash); err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return user, nil
 }
 
+// BootstrapAdmin promotes the configured initial admin, if they have already
+// registered, so a deployment always has someone who can manage it
+func (s *AuthService) BootstrapAdmin(ctx context.Context) error {
+	if s.config.InitialAdmin == "" {
+		return nil
+	}
+	user, _, err := s.db.GetUserByUsername(ctx, strings.ToLower(s.config.InitialAdmin))
+	if errors.Is(err, ErrUserNotFound) {
+		log.Printf("⚠️ Initial admin %s has not registered yet; they will be made an admin when they do", s.config.InitialAdmin)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up initial admin: %v", err)
+	}
+	if user.Role == RoleAdmin {
+		return nil
+	}
+	if err := s.db.SetUserRole(ctx, user.ID, RoleAdmin); err != nil {
+		return fmt.Errorf("failed to promote initial admin: %v", err)
+	}
+	log.Printf("✅ Promoted %s to admin", user.Username)
+	return nil
+}
+
+// isInitialAdmin reports whether username is the configured initial admin
+func (s *AuthService) isInitialAdmin(username string) bool {
+	return s.config.InitialAdmin != "" && strings.EqualFold(username, s.config.InitialAdmin)
+}
+
 // Login authenticates a user and creates a session
 func (s *AuthService) Login(ctx context.Context, username, password, clientType, ipAddress, userAgent string) (*Session, *User, error) {
 	// Get user and password hash
@@ -161,7 +226,7 @@ func (s *AuthService) Login(ctx context.Context, username, password, clientType,
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, nil, errors.New("account is deactivated")
+		return nil, nil, ErrUserInactive
 	}
 
 	// Verify password
@@ -216,15 +281,18 @@ func (s *AuthService) VerifySession(ctx context.Context, sessionToken string) (*
 		return nil, ErrTokenExpired
 	}
 
-	user, err := s.db.GetUserByID(ctx, session.UserID)
+	return s.activeUser(ctx, session.UserID)
+}
+
+// activeUser returns the stored user with the given ID if they are active
+func (s *AuthService) activeUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	user, err := s.db.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
-
 	if !user.IsActive {
-		return nil, errors.New("account is deactivated")
+		return nil, ErrUserInactive
 	}
-
 	return user, nil
 }
 
@@ -244,6 +312,7 @@ func (s *AuthService) GenerateJWT(user *User) (string, error) {
 		"user_id": user.ID.String(),
 		"username": user.Username,
 		"email":    user.Email,
+		"role":     string(user.Role),
 		"exp":      time.Now().Add(s.config.TokenExpiry).Unix(),
 		"iat":      time.Now().Unix(),
 	}
@@ -278,16 +347,44 @@ func (s *AuthService) VerifyJWT(tokenString string) (*User, error) {
 
 		// In a real implementation, you would fetch the user from the database
 		// For now, return a minimal user object
+		username, _ := claims["username"].(string)
+		email, _ := claims["email"].(string)
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = string(RoleUser)
+		}
 		return &User{
 			ID:       userID,
-			Username: claims["username"].(string),
-			Email:    claims["email"].(string),
+			Username: username,
+			Email:    email,
+			Role:     Role(role),
 		}, nil
 	}
 
 	return nil, ErrTokenInvalid
 }
 
+// Authenticate verifies the request's bearer token, which may be a JWT or,
+// when a repository is configured, a session token. With a repository the
+// user, and so their role, is read from it rather than from the token, so a
+// changed role or deactivated account takes effect at once.
+func (s *AuthService) Authenticate(ctx context.Context, r *http.Request) (*User, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	if user, err := s.VerifyJWT(token); err == nil {
+		if s.db == nil {
+			return user, nil
+		}
+		return s.activeUser(ctx, user.ID)
+	}
+	if s.db == nil {
+		return nil, ErrTokenInvalid
+	}
+	return s.VerifySession(ctx, token)
+}
+
 // Helper methods
 
 func (s *AuthService) validateRegistration(username, email, password string) error {
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials it understands, so that another backend may try
+var ErrNoCredentials = errors.New("no credentials provided")
+
+// Authenticator verifies the credentials of an HTTP request and returns the
+// user they belong to. Backends such as OIDC, API keys or mTLS plug in by
+// implementing it; AuthService is the local JWT and session implementation.
+type Authenticator interface {
+	Authenticate(ctx context.Context, r *http.Request) (*User, error)
+}
+
+// chainAuthenticator tries several authenticators in order
+type chainAuthenticator []Authenticator
+
+// NewChainAuthenticator returns an authenticator that tries each backend in
+// order, so several backends may accept the same kind of credentials
+func NewChainAuthenticator(authenticators ...Authenticator) Authenticator {
+	return chainAuthenticator(authenticators)
+}
+
+// Authenticate returns the user from the first backend that accepts the
+// request. If none does, the first error other than ErrNoCredentials is
+// returned, explaining why the credentials were rejected.
+func (c chainAuthenticator) Authenticate(ctx context.Context, r *http.Request) (*User, error) {
+	rejected := ErrNoCredentials
+	for _, authenticator := range c {
+		user, err := authenticator.Authenticate(ctx, r)
+		if err == nil {
+			return user, nil
+		}
+		if errors.Is(rejected, ErrNoCredentials) {
+			rejected = err
+		}
+	}
+	return nil, rejected
+}
+
+// BearerToken returns the token of a request's "Authorization: Bearer" header
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len("Bearer "):])
+	return token, token != ""
+}
+
+type userKey struct{}
+
+// WithUser returns a context carrying the authenticated user
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user set with WithUser
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userKey{}).(*User)
+	return user, ok && user != nil
+}
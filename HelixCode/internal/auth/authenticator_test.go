@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// stubAuthenticator accepts requests carrying its bearer token
+type stubAuthenticator struct {
+	token string
+	user  *User
+}
+
+func (s *stubAuthenticator) Authenticate(ctx context.Context, r *http.Request) (*User, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	if token != s.token {
+		return nil, ErrTokenInvalid
+	}
+	return s.user, nil
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	alice := &User{Username: "alice"}
+	bob := &User{Username: "bob"}
+	chain := NewChainAuthenticator(
+		&stubAuthenticator{token: "alice-token", user: alice},
+		NewAuthService(DefaultConfig(), nil),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	if user, err := chain.Authenticate(context.Background(), req); err != nil || user != alice {
+		t.Fatalf("Expected the first backend to authenticate alice, got %v (%v)", user, err)
+	}
+
+	// A token the first backend rejects is passed to the next
+	service := NewAuthService(DefaultConfig(), nil)
+	token, err := service.GenerateJWT(bob)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if user, err := chain.Authenticate(context.Background(), req); err != nil || user.Username != "bob" {
+		t.Errorf("Expected the JWT backend to authenticate bob, got %v (%v)", user, err)
+	}
+
+	req.Header.Set("Authorization", "Bearer forged")
+	if _, err := chain.Authenticate(context.Background(), req); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected ErrTokenInvalid when every backend rejects the token, got %v", err)
+	}
+	if _, err := chain.Authenticate(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials without a header, got %v", err)
+	}
+}
+
+func TestUser_HasRole(t *testing.T) {
+	tests := []struct {
+		role  Role
+		check Role
+		want  bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleUser, true},
+		{RoleUser, RoleUser, true},
+		{RoleUser, RoleAdmin, false},
+		{"", RoleUser, true},
+		{"", RoleAdmin, false},
+	}
+	for _, tt := range tests {
+		user := &User{Role: tt.role}
+		if got := user.HasRole(tt.check); got != tt.want {
+			t.Errorf("User with role %q: HasRole(%q) = %v, want %v", tt.role, tt.check, got, tt.want)
+		}
+	}
+
+	// The role survives a JWT round trip
+	service := NewAuthService(DefaultConfig(), nil)
+	token, err := service.GenerateJWT(&User{Username: "root", Role: RoleAdmin})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	user, err := service.VerifyJWT(token)
+	if err != nil || user.Role != RoleAdmin {
+		t.Errorf("Expected the admin role from the JWT, got %v (%v)", user, err)
+	}
+}
+
+func TestAuthService_AuthenticateUsesStoredUser(t *testing.T) {
+	repo := NewMemoryAuthRepository()
+	service := NewAuthService(DefaultConfig(), repo)
+	ctx := context.Background()
+	user := &User{ID: uuid.New(), Username: "root", Email: "root@example.com", Role: RoleAdmin, IsActive: true}
+	if err := repo.CreateUser(ctx, user, ""); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token, err := service.GenerateJWT(user)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if err := repo.SetUserRole(ctx, user.ID, RoleUser); err != nil {
+		t.Fatalf("Failed to demote user: %v", err)
+	}
+	if authenticated, err := service.Authenticate(ctx, req); err != nil || authenticated.Role != RoleUser {
+		t.Errorf("Expected the stored role rather than the JWT's, got %v (%v)", authenticated, err)
+	}
+
+	token, err = service.GenerateJWT(&User{ID: uuid.New(), Username: "ghost", Role: RoleAdmin})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if _, err := service.Authenticate(ctx, req); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for a JWT of an unknown user, got %v", err)
+	}
+}
+
+func TestAuthService_InitialAdmin(t *testing.T) {
+	repo := NewMemoryAuthRepository()
+	config := DefaultConfig()
+	config.InitialAdmin = "Ops"
+	service := NewAuthService(config, repo)
+	ctx := context.Background()
+
+	// Nobody is promoted before the initial admin registers
+	if err := service.BootstrapAdmin(ctx); err != nil {
+		t.Fatalf("Failed to bootstrap admin: %v", err)
+	}
+	other, err := service.Register(ctx, "dev", "dev@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	if other.Role != RoleUser {
+		t.Errorf("Expected other users to register as %s, got %s", RoleUser, other.Role)
+	}
+	ops, err := service.Register(ctx, "ops", "ops@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	if ops.Role != RoleAdmin {
+		t.Errorf("Expected the initial admin to register as %s, got %s", RoleAdmin, ops.Role)
+	}
+
+	// An initial admin who registered as a user is promoted on start
+	if err := repo.SetUserRole(ctx, ops.ID, RoleUser); err != nil {
+		t.Fatalf("Failed to demote user: %v", err)
+	}
+	if err := service.BootstrapAdmin(ctx); err != nil {
+		t.Fatalf("Failed to bootstrap admin: %v", err)
+	}
+	if stored, err := repo.GetUserByID(ctx, ops.ID); err != nil || stored.Role != RoleAdmin {
+		t.Errorf("Expected the initial admin to be promoted, got %v (%v)", stored, err)
+	}
+	if stored, err := repo.GetUserByID(ctx, other.ID); err != nil || stored.Role != RoleUser {
+		t.Errorf("Expected other users to keep their role, got %v (%v)", stored, err)
+	}
+}
@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// NewAuthRepository returns a database repository when a database is
+// configured and an in-memory one otherwise
+func NewAuthRepository(db *database.Database) AuthRepository {
+	if db.IsConfigured() {
+		return NewDatabaseAuthRepository(db)
+	}
+	return NewMemoryAuthRepository()
+}
+
+// memoryUser is a user held by MemoryAuthRepository with its password hash
+type memoryUser struct {
+	user         User
+	passwordHash string
+}
+
+// MemoryAuthRepository keeps users and sessions in memory, for tests and
+// deployments without a database
+type MemoryAuthRepository struct {
+	mu       sync.RWMutex
+	users    map[uuid.UUID]*memoryUser
+	sessions map[string]*Session
+}
+
+// NewMemoryAuthRepository creates an empty in-memory repository
+func NewMemoryAuthRepository() *MemoryAuthRepository {
+	return &MemoryAuthRepository{
+		users:    make(map[uuid.UUID]*memoryUser),
+		sessions: make(map[string]*Session),
+	}
+}
+
+// CreateUser stores a user, rejecting a taken username or email
+func (r *MemoryAuthRepository) CreateUser(ctx context.Context, user *User, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.user.Username == user.Username || existing.user.Email == user.Email {
+			return ErrUserExists
+		}
+	}
+	r.users[user.ID] = &memoryUser{user: *user, passwordHash: passwordHash}
+	return nil
+}
+
+// GetUserByUsername returns a user and their password hash
+func (r *MemoryAuthRepository) GetUserByUsername(ctx context.Context, username string) (*User, string, error) {
+	return r.findUser(func(user *User) bool { return user.Username == username })
+}
+
+// GetUserByEmail returns a user and their password hash
+func (r *MemoryAuthRepository) GetUserByEmail(ctx context.Context, email string) (*User, string, error) {
+	return r.findUser(func(user *User) bool { return user.Email == email })
+}
+
+// GetUserByID returns a user
+func (r *MemoryAuthRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	user, _, err := r.findUser(func(user *User) bool { return user.ID == id })
+	return user, err
+}
+
+// SetUserRole changes a user's role
+func (r *MemoryAuthRepository) SetUserRole(ctx context.Context, id uuid.UUID, role Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	stored.user.Role = role
+	return nil
+}
+
+// UpdateUserLastLogin records that a user logged in now
+func (r *MemoryAuthRepository) UpdateUserLastLogin(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	stored.user.LastLogin = time.Now()
+	return nil
+}
+
+// CreateSession stores a session by its token
+func (r *MemoryAuthRepository) CreateSession(ctx context.Context, session *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *session
+	r.sessions[session.SessionToken] = &copied
+	return nil
+}
+
+// GetSession returns the session with the given token
+func (r *MemoryAuthRepository) GetSession(ctx context.Context, token string) (*Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[token]
+	if !exists {
+		return nil, ErrTokenInvalid
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// DeleteSession removes a session
+func (r *MemoryAuthRepository) DeleteSession(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, token)
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of a user
+func (r *MemoryAuthRepository) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for token, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, token)
+		}
+	}
+	return nil
+}
+
+// findUser returns a copy of the first user matching match
+func (r *MemoryAuthRepository) findUser(match func(*User) bool) (*User, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.users {
+		if match(&stored.user) {
+			user := stored.user
+			return &user, stored.passwordHash, nil
+		}
+	}
+	return nil, "", ErrUserNotFound
+}
+
+// DatabaseAuthRepository persists users and sessions in the users and
+// user_sessions tables
+type DatabaseAuthRepository struct {
+	db *database.Database
+}
+
+// NewDatabaseAuthRepository creates a repository backed by the database
+func NewDatabaseAuthRepository(db *database.Database) *DatabaseAuthRepository {
+	return &DatabaseAuthRepository{db: db}
+}
+
+// userColumns are the users columns scanned by scanUser, in order
+const userColumns = `id, username, email, COALESCE(display_name, ''), is_active, is_verified, mfa_enabled,
+	role, last_login, created_at, updated_at`
+
+// CreateUser inserts a user
+func (r *DatabaseAuthRepository) CreateUser(ctx context.Context, user *User, passwordHash string) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO users (id, username, email, password_hash, display_name, is_active, is_verified, mfa_enabled, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, user.ID, user.Username, user.Email, passwordHash, user.DisplayName, user.IsActive, user.IsVerified,
+		user.MFAEnabled, string(user.Role), user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed to insert user: %v", err)
+	}
+	return nil
+}
+
+// GetUserByUsername returns a user and their password hash
+func (r *DatabaseAuthRepository) GetUserByUsername(ctx context.Context, username string) (*User, string, error) {
+	return r.getUserWithHash(ctx, "username", username)
+}
+
+// GetUserByEmail returns a user and their password hash
+func (r *DatabaseAuthRepository) GetUserByEmail(ctx context.Context, email string) (*User, string, error) {
+	return r.getUserWithHash(ctx, "email", email)
+}
+
+// GetUserByID returns a user
+func (r *DatabaseAuthRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	if !r.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	return user, nil
+}
+
+// UpdateUserLastLogin records that a user logged in now
+func (r *DatabaseAuthRepository) UpdateUserLastLogin(ctx context.Context, id uuid.UUID) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := r.db.Pool.Exec(ctx, `UPDATE users SET last_login = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to update last login: %v", err)
+	}
+	return nil
+}
+
+// SetUserRole changes a user's role
+func (r *DatabaseAuthRepository) SetUserRole(ctx context.Context, id uuid.UUID, role Role) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	result, err := r.db.Pool.Exec(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, string(role), id)
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CreateSession inserts a session
+func (r *DatabaseAuthRepository) CreateSession(ctx context.Context, session *Session) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	var ip *string
+	if session.IPAddress != nil {
+		address := session.IPAddress.String()
+		ip = &address
+	}
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO user_sessions (id, user_id, session_token, client_type, ip_address, user_agent, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5::inet, $6, $7, $8)
+	`, session.ID, session.UserID, session.SessionToken, session.ClientType, ip, session.UserAgent,
+		session.ExpiresAt, session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %v", err)
+	}
+	return nil
+}
+
+// GetSession returns the session with the given token
+func (r *DatabaseAuthRepository) GetSession(ctx context.Context, token string) (*Session, error) {
+	if !r.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
+	var (
+		session   Session
+		ip        *string
+		userAgent *string
+	)
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, session_token, client_type, host(ip_address), user_agent, expires_at, created_at
+		FROM user_sessions WHERE session_token = $1
+	`, token).Scan(&session.ID, &session.UserID, &session.SessionToken, &session.ClientType, &ip, &userAgent,
+		&session.ExpiresAt, &session.CreatedAt)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if ip != nil {
+		session.IPAddress = net.ParseIP(*ip)
+	}
+	if userAgent != nil {
+		session.UserAgent = *userAgent
+	}
+	return &session, nil
+}
+
+// DeleteSession removes a session
+func (r *DatabaseAuthRepository) DeleteSession(ctx context.Context, token string) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := r.db.Pool.Exec(ctx, `DELETE FROM user_sessions WHERE session_token = $1`, token); err != nil {
+		return fmt.Errorf("failed to delete session: %v", err)
+	}
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of a user
+func (r *DatabaseAuthRepository) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	if !r.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := r.db.Pool.Exec(ctx, `DELETE FROM user_sessions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %v", err)
+	}
+	return nil
+}
+
+// getUserWithHash returns the user whose column equals value, with their
+// password hash. column is one of the fixed names used above.
+func (r *DatabaseAuthRepository) getUserWithHash(ctx context.Context, column, value string) (*User, string, error) {
+	if !r.db.IsConfigured() {
+		return nil, "", database.ErrNotConfigured
+	}
+
+	var passwordHash string
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, `SELECT `+userColumns+`, password_hash FROM users WHERE `+column+` = $1`, value),
+		&passwordHash)
+	if err == pgx.ErrNoRows {
+		return nil, "", ErrUserNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user: %v", err)
+	}
+	return user, passwordHash, nil
+}
+
+// scanUser reads a user selected with userColumns, followed by any extra
+// columns into extra
+func scanUser(row rowScanner, extra ...interface{}) (*User, error) {
+	var (
+		user      User
+		role      string
+		lastLogin *time.Time
+	)
+	dest := append([]interface{}{&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.IsActive,
+		&user.IsVerified, &user.MFAEnabled, &role, &lastLogin, &user.CreatedAt, &user.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	user.Role = Role(role)
+	if lastLogin != nil {
+		user.LastLogin = *lastLogin
+	}
+	return &user, nil
+}
@@ -0,0 +1,119 @@
+// Package clock abstracts the passage of time so that TTLs, heartbeats,
+// retries and expiry can be tested deterministically.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// Real is a Clock backed by the system time
+type Real struct{}
+
+// New returns the system clock
+func New() Clock {
+	return Real{}
+}
+
+// Now returns the current system time
+func (Real) Now() time.Time { return time.Now() }
+
+// Since returns the time elapsed since t
+func (Real) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// After waits for d to elapse and then sends the current time
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Sleep pauses the current goroutine for d
+func (Real) Sleep(d time.Duration) { time.Sleep(d) }
+
+// Mock is a Clock that only moves when told to. Sleep advances the clock
+// instead of blocking, so code that waits runs instantly under test.
+type Mock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []mockWaiter
+}
+
+type mockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewMock creates a mock clock set to start
+func NewMock(start time.Time) *Mock {
+	return &Mock{now: start}
+}
+
+// Now returns the mock's current time
+func (m *Mock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Since returns the mock time elapsed since t
+func (m *Mock) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+// After returns a channel that receives the mock time once the clock has
+// been advanced by at least d
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- m.now
+		return ch
+	}
+	m.waiters = append(m.waiters, mockWaiter{deadline: m.now.Add(d), ch: ch})
+	return ch
+}
+
+// Sleep advances the clock by d and returns immediately
+func (m *Mock) Sleep(d time.Duration) {
+	m.Advance(d)
+}
+
+// Advance moves the clock forward by d, firing any After channels that fall due
+func (m *Mock) Advance(d time.Duration) {
+	m.mu.Lock()
+	m.set(m.now.Add(d))
+	m.mu.Unlock()
+}
+
+// Set moves the clock to t, firing any After channels that fall due
+func (m *Mock) Set(t time.Time) {
+	m.mu.Lock()
+	m.set(t)
+	m.mu.Unlock()
+}
+
+// set updates the time and fires due waiters in deadline order. The caller must hold m.mu.
+func (m *Mock) set(t time.Time) {
+	m.now = t
+
+	sort.Slice(m.waiters, func(i, j int) bool {
+		return m.waiters[i].deadline.Before(m.waiters[j].deadline)
+	})
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.deadline.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- t
+	}
+	m.waiters = pending
+}
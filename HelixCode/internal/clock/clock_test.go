@@ -0,0 +1,52 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMock_AdvanceFiresAfter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := NewMock(start)
+
+	short := mock.After(time.Second)
+	long := mock.After(time.Minute)
+
+	mock.Advance(30 * time.Second)
+	select {
+	case fired := <-short:
+		if !fired.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("Expected fire time %v, got %v", start.Add(30*time.Second), fired)
+		}
+	default:
+		t.Fatal("Expected the 1s timer to fire after advancing 30s")
+	}
+	select {
+	case <-long:
+		t.Fatal("The 1m timer fired early")
+	default:
+	}
+
+	mock.Advance(30 * time.Second)
+	select {
+	case <-long:
+	default:
+		t.Fatal("Expected the 1m timer to fire after advancing 1m")
+	}
+}
+
+func TestMock_SleepAdvances(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := NewMock(start)
+
+	mock.Sleep(5 * time.Minute)
+	if got := mock.Since(start); got != 5*time.Minute {
+		t.Errorf("Expected 5m elapsed, got %v", got)
+	}
+
+	select {
+	case <-mock.After(0):
+	default:
+		t.Error("Expected a zero-duration After to fire immediately")
+	}
+}
@@ -2,11 +2,15 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 
 	"github.com/spf13/viper"
+	"dev.helix.code/internal/paths"
 	"dev.helix.code/internal/database"
 )
 
@@ -29,6 +33,7 @@ type ServerConfig struct {
 	WriteTimeout    int    `mapstructure:"write_timeout"`
 	IdleTimeout     int    `mapstructure:"idle_timeout"`
 	ShutdownTimeout int    `mapstructure:"shutdown_timeout"`
+	ProjectRoot     string `mapstructure:"project_root"` // Directory project paths must be inside; empty allows any
 }
 
 // AuthConfig represents authentication configuration
@@ -37,13 +42,16 @@ type AuthConfig struct {
 	TokenExpiry        int    `mapstructure:"token_expiry"`
 	SessionExpiry      int    `mapstructure:"session_expiry"`
 	BcryptCost         int    `mapstructure:"bcrypt_cost"`
+	InitialAdmin       string `mapstructure:"initial_admin"` // Username made an admin on registration or start; empty makes none
 }
 
-// WorkersConfig represents worker configuration
+// WorkersConfig represents worker configuration. Intervals are in seconds.
 type WorkersConfig struct {
 	HealthCheckInterval int `mapstructure:"health_check_interval"`
 	HealthTTL           int `mapstructure:"health_ttl"`
 	MaxConcurrentTasks  int `mapstructure:"max_concurrent_tasks"`
+	RebalanceInterval   int `mapstructure:"rebalance_interval"`  // Seconds between moving queued tasks off overloaded workers; 0 disables it
+	RebalanceThreshold  int `mapstructure:"rebalance_threshold"` // Load difference between workers that triggers a move
 }
 
 // TasksConfig represents task configuration
@@ -51,14 +59,18 @@ type TasksConfig struct {
 	MaxRetries         int `mapstructure:"max_retries"`
 	CheckpointInterval int `mapstructure:"checkpoint_interval"`
 	CleanupInterval    int `mapstructure:"cleanup_interval"`
+	MaxQueueSize       int `mapstructure:"max_queue_size"` // Queued tasks before new ones are rejected; 0 is unlimited
 }
 
-// LLMConfig represents LLM configuration
+// LLMConfig represents LLM configuration. Retry delays are in seconds.
 type LLMConfig struct {
-	DefaultProvider string            `mapstructure:"default_provider"`
+	DefaultProvider []string          `mapstructure:"default_provider"` // Tried in order; a single name or comma-separated string is accepted
 	Providers       map[string]string `mapstructure:"providers"`
 	MaxTokens       int               `mapstructure:"max_tokens"`
 	Temperature     float64           `mapstructure:"temperature"`
+	RetryMax        int               `mapstructure:"retry_max"`        // Retries of transient provider failures; 0 disables them
+	RetryBaseDelay  int               `mapstructure:"retry_base_delay"` // Backoff before the first retry, doubled for each further one
+	RetryMaxDelay   int               `mapstructure:"retry_max_delay"`  // Cap on the backoff between retries
 }
 
 // LoggingConfig represents logging configuration
@@ -97,9 +109,9 @@ func Load() (*Config, error) {
 			return nil, fmt.Errorf("failed to read config file: %v", err)
 		}
 		// Config file not found, but we can continue with defaults
-		fmt.Println("⚠️  No config file found, using defaults and environment variables")
+		log.Println("⚠️  No config file found, using defaults and environment variables")
 	} else {
-		fmt.Printf("📁 Using config file: %s\n", viper.ConfigFileUsed())
+		log.Printf("📁 Using config file: %s", viper.ConfigFileUsed())
 	}
 
 	// Unmarshal config
@@ -138,21 +150,28 @@ func setDefaults() {
 	viper.SetDefault("auth.token_expiry", 86400) // 24 hours
 	viper.SetDefault("auth.session_expiry", 604800) // 7 days
 	viper.SetDefault("auth.bcrypt_cost", 12)
+	viper.SetDefault("auth.initial_admin", "")
 
 	// Workers defaults
 	viper.SetDefault("workers.health_check_interval", 30)
 	viper.SetDefault("workers.health_ttl", 120)
 	viper.SetDefault("workers.max_concurrent_tasks", 10)
+	viper.SetDefault("workers.rebalance_interval", 60)
+	viper.SetDefault("workers.rebalance_threshold", 2)
 
 	// Tasks defaults
 	viper.SetDefault("tasks.max_retries", 3)
 	viper.SetDefault("tasks.checkpoint_interval", 300)
 	viper.SetDefault("tasks.cleanup_interval", 3600)
+	viper.SetDefault("tasks.max_queue_size", 10000)
 
 	// LLM defaults
 	viper.SetDefault("llm.default_provider", "local")
 	viper.SetDefault("llm.max_tokens", 4096)
 	viper.SetDefault("llm.temperature", 0.7)
+	viper.SetDefault("llm.retry_max", 3)
+	viper.SetDefault("llm.retry_base_delay", 1)
+	viper.SetDefault("llm.retry_max_delay", 10)
 
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
@@ -163,7 +182,7 @@ func setDefaults() {
 // findConfigFile searches for config file in various locations
 func findConfigFile() string {
 	// Check environment variable first
-	if configPath := os.Getenv("HELIX_CONFIG"); configPath != "" {
+	if configPath := paths.Expand(os.Getenv("HELIX_CONFIG")); configPath != "" {
 		if _, err := os.Stat(configPath); err == nil {
 			return configPath
 		}
@@ -173,12 +192,12 @@ func findConfigFile() string {
 	locations := []string{
 		"./config/config.yaml",
 		"./config.yaml",
-		"$HOME/.config/helixcode/config.yaml",
+		"~/.config/helixcode/config.yaml",
 		"/etc/helixcode/config.yaml",
 	}
 
 	for _, location := range locations {
-		if expanded := os.ExpandEnv(location); expanded != location {
+		if expanded := paths.Expand(location); expanded != location {
 			if _, err := os.Stat(expanded); err == nil {
 				return expanded
 			}
@@ -212,14 +231,26 @@ func validateConfig(cfg *Config) error {
 	if cfg.Workers.HealthCheckInterval < 1 {
 		return fmt.Errorf("health check interval must be positive")
 	}
+	if cfg.Workers.HealthTTL < 1 {
+		return fmt.Errorf("health TTL must be positive")
+	}
 	if cfg.Workers.MaxConcurrentTasks < 1 {
 		return fmt.Errorf("max concurrent tasks must be positive")
 	}
+	if cfg.Workers.RebalanceInterval < 0 {
+		return fmt.Errorf("rebalance interval cannot be negative")
+	}
+	if cfg.Workers.RebalanceThreshold < 0 {
+		return fmt.Errorf("rebalance threshold cannot be negative")
+	}
 
 	// Tasks validation
 	if cfg.Tasks.MaxRetries < 0 {
 		return fmt.Errorf("max retries cannot be negative")
 	}
+	if cfg.Tasks.MaxQueueSize < 0 {
+		return fmt.Errorf("max queue size cannot be negative")
+	}
 
 	// LLM validation
 	if cfg.LLM.MaxTokens < 1 {
@@ -228,12 +259,55 @@ func validateConfig(cfg *Config) error {
 	if cfg.LLM.Temperature < 0 || cfg.LLM.Temperature > 2 {
 		return fmt.Errorf("temperature must be between 0 and 2")
 	}
+	if cfg.LLM.RetryMax < 0 {
+		return fmt.Errorf("LLM retry max cannot be negative")
+	}
+	if cfg.LLM.RetryBaseDelay < 1 || cfg.LLM.RetryMaxDelay < cfg.LLM.RetryBaseDelay {
+		return fmt.Errorf("LLM retry base delay must be positive and no longer than the max delay")
+	}
+	if err := checkPortConflicts(cfg); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// checkPortConflicts rejects LLM provider endpoints on this host that use the
+// server's port, since the provider's server and the HTTP server cannot both
+// bind it
+func checkPortConflicts(cfg *Config) error {
+	names := make([]string, 0, len(cfg.LLM.Providers))
+	for name := range cfg.LLM.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		endpoint, err := url.Parse(cfg.LLM.Providers[name])
+		if err != nil || endpoint.Port() != strconv.Itoa(cfg.Server.Port) {
+			continue
+		}
+		if isServerHost(endpoint.Hostname(), cfg.Server.Address) {
+			return fmt.Errorf("LLM provider %s endpoint %s uses the server port %d; change server.port or the provider's port",
+				name, cfg.LLM.Providers[name], cfg.Server.Port)
+		}
+	}
+	return nil
+}
+
+// isServerHost reports whether host names the machine the server listens on
+func isServerHost(host, address string) bool {
+	switch host {
+	case "localhost", "127.0.0.1", "::1", "0.0.0.0", address:
+		return true
+	}
+	return false
+}
+
 // CreateDefaultConfig creates a default configuration file
 func CreateDefaultConfig(path string) error {
+	path = paths.Expand(path)
+
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -250,6 +324,7 @@ server:
   write_timeout: 30
   idle_timeout: 60
   shutdown_timeout: 30
+  project_root: "" # Projects must be inside this directory when set
 
 database:
   host: "localhost"
@@ -264,24 +339,31 @@ auth:
   token_expiry: 86400
   session_expiry: 604800
   bcrypt_cost: 12
+  initial_admin: "" # Username made an admin when registered or on start
 
 workers:
-  health_check_interval: 30
-  health_ttl: 120
+  health_check_interval: 30 # seconds
+  health_ttl: 120 # seconds
   max_concurrent_tasks: 10
+  rebalance_interval: 60 # seconds, 0 disables rebalancing
+  rebalance_threshold: 2
 
 tasks:
   max_retries: 3
   checkpoint_interval: 300
   cleanup_interval: 3600
+  max_queue_size: 10000 # Critical tasks may exceed it by a quarter
 
 llm:
-  default_provider: "local"
+  default_provider: "local" # Or an ordered list, e.g. ["local", "openai"]
   providers:
     local: "http://localhost:11434"
     openai: "" # Set API key via environment variable
   max_tokens: 4096
   temperature: 0.7
+  retry_max: 3 # retries of transient provider failures, 0 disables them
+  retry_base_delay: 1 # seconds, doubled for each retry
+  retry_max_delay: 10 # seconds
 
 logging:
   level: "info"
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// TestLoad_DefaultProvider tests that the default provider may be a single name, a comma-separated string or a list
+func TestLoad_DefaultProvider(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{`"local"`, []string{"local"}},
+		{`"local,openai"`, []string{"local", "openai"}},
+		{`["local", "openai"]`, []string{"local", "openai"}},
+	}
+
+	for _, tt := range tests {
+		viper.Reset()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "auth:\n  jwt_secret: test-secret\nllm:\n  default_provider: " + tt.value + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		t.Setenv("HELIX_CONFIG", path)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() with default_provider %s failed: %v", tt.value, err)
+		}
+		if !reflect.DeepEqual(cfg.LLM.DefaultProvider, tt.expected) {
+			t.Errorf("default_provider %s = %q, want %q", tt.value, cfg.LLM.DefaultProvider, tt.expected)
+		}
+	}
+	viper.Reset()
+}
+
+// TestLoad_RejectsNonPositiveWorkerIntervals tests that zero or negative worker intervals fail validation
+func TestLoad_RejectsNonPositiveWorkerIntervals(t *testing.T) {
+	for _, setting := range []string{"health_check_interval: 0", "health_ttl: -5"} {
+		viper.Reset()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "auth:\n  jwt_secret: test-secret\nworkers:\n  " + setting + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		t.Setenv("HELIX_CONFIG", path)
+
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with workers %s succeeded, want error", setting)
+		}
+	}
+	viper.Reset()
+}
+
+// TestLoad_RejectsPortConflicts tests that a local LLM provider endpoint on the server port fails validation
+func TestLoad_RejectsPortConflicts(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		conflict bool
+	}{
+		{"http://localhost:8080", true},
+		{"http://127.0.0.1:8080/v1", true},
+		{"http://localhost:8081", false},
+		{"http://llama.internal:8080", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		viper.Reset()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "auth:\n  jwt_secret: test-secret\nserver:\n  port: 8080\nllm:\n  providers:\n    llama-cpp: \"" + tt.endpoint + "\"\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		t.Setenv("HELIX_CONFIG", path)
+
+		_, err := Load()
+		if tt.conflict && err == nil {
+			t.Errorf("Load() with llama-cpp at %q succeeded, want a port conflict", tt.endpoint)
+		}
+		if !tt.conflict && err != nil {
+			t.Errorf("Load() with llama-cpp at %q failed: %v", tt.endpoint, err)
+		}
+	}
+	viper.Reset()
+}
+
+// TestLoad_LLMRetries tests the LLM retry defaults and that invalid retry settings fail validation
+func TestLoad_LLMRetries(t *testing.T) {
+	viper.Reset()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("auth:\n  jwt_secret: test-secret\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("HELIX_CONFIG", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.LLM.RetryMax != 3 || cfg.LLM.RetryBaseDelay != 1 || cfg.LLM.RetryMaxDelay != 10 {
+		t.Errorf("Unexpected retry defaults: max %d, base delay %d, max delay %d", cfg.LLM.RetryMax, cfg.LLM.RetryBaseDelay, cfg.LLM.RetryMaxDelay)
+	}
+
+	for _, setting := range []string{"retry_max: -1", "retry_base_delay: 0", "retry_max_delay: 0"} {
+		viper.Reset()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "auth:\n  jwt_secret: test-secret\nllm:\n  " + setting + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		t.Setenv("HELIX_CONFIG", path)
+
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with llm %s succeeded, want error", setting)
+		}
+	}
+	viper.Reset()
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -17,6 +18,9 @@ type Database struct {
 	Pool *pgxpool.Pool
 }
 
+// ErrNotConfigured is returned by database-backed operations when no database is configured
+var ErrNotConfigured = errors.New("no database configured")
+
 // Config holds database configuration
 type Config struct {
 	Host     string
@@ -63,16 +67,27 @@ func New(config Config) (*Database, error) {
 	return &Database{Pool: pool}, nil
 }
 
+// IsConfigured reports whether a connection pool is available.
+// It is safe to call on a nil Database.
+func (db *Database) IsConfigured() bool {
+	return db != nil && db.Pool != nil
+}
+
 // Close closes the database connection pool
 func (db *Database) Close() {
-	if db.Pool != nil {
+	if db.IsConfigured() {
 		db.Pool.Close()
 		log.Println("✅ Database connection pool closed")
 	}
 }
 
-// InitializeSchema creates the database schema if it doesn't exist
+// InitializeSchema creates the database schema if it doesn't exist and
+// migrates an existing one to the current version
 func (db *Database) InitializeSchema() error {
+	if !db.IsConfigured() {
+		return ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	// Check if schema exists
@@ -80,7 +95,7 @@ func (db *Database) InitializeSchema() error {
 	err := db.Pool.QueryRow(ctx, `
 		SELECT EXISTS(
 			SELECT 1 FROM information_schema.tables 
-			WHERE table_schema = 'public' AND table_name = 'users'
+			WHERE table_schema = current_schema() AND table_name = 'users'
 		)
 	`).Scan(&schemaExists)
 
@@ -90,25 +105,25 @@ func (db *Database) InitializeSchema() error {
 
 	if schemaExists {
 		log.Println("✅ Database schema already exists")
-		return nil
-	}
+	} else {
+		log.Println("🔧 Creating database schema...")
 
-	log.Println("🔧 Creating database schema...")
+		// Execute schema creation
+		_, err = db.Pool.Exec(ctx, createSchemaSQL)
+		if err != nil {
+			return fmt.Errorf("failed to create schema: %v", err)
+		}
 
-	// Execute schema creation
-	_, err = db.Pool.Exec(ctx, createSchemaSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create schema: %v", err)
+		log.Println("✅ Database schema created successfully")
 	}
 
-	log.Println("✅ Database schema created successfully")
-	return nil
+	return db.migrate(ctx)
 }
 
 // GetDB returns a standard sql.DB for compatibility with other libraries
 func (db *Database) GetDB() (*sql.DB, error) {
-	if db.Pool == nil {
-		return nil, fmt.Errorf("database pool is not initialized")
+	if !db.IsConfigured() {
+		return nil, ErrNotConfigured
 	}
 
 	// Convert pgxpool.Pool to *sql.DB
@@ -117,6 +132,10 @@ func (db *Database) GetDB() (*sql.DB, error) {
 
 // HealthCheck performs a health check on the database
 func (db *Database) HealthCheck() error {
+	if !db.IsConfigured() {
+		return ErrNotConfigured
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -144,6 +163,7 @@ CREATE TABLE users (
     is_verified BOOLEAN NOT NULL DEFAULT false,
     mfa_enabled BOOLEAN NOT NULL DEFAULT false,
     mfa_secret VARCHAR(255),
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('admin', 'user')),
     last_login TIMESTAMPTZ,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
@@ -180,7 +200,7 @@ CREATE TABLE workers (
     capabilities TEXT[] NOT NULL DEFAULT '{}',
     resources JSONB NOT NULL DEFAULT '{}',
     status VARCHAR(50) NOT NULL DEFAULT 'active' 
-        CHECK (status IN ('active', 'inactive', 'maintenance', 'failed', 'offline')),
+        CHECK (status IN ('active', 'inactive', 'maintenance', 'failed', 'offline', 'draining', 'drained')),
     health_status VARCHAR(50) NOT NULL DEFAULT 'healthy'
         CHECK (health_status IN ('healthy', 'degraded', 'unhealthy', 'unknown')),
     last_heartbeat TIMESTAMPTZ,
@@ -239,10 +259,13 @@ CREATE TABLE distributed_tasks (
     estimated_duration INTERVAL,
     started_at TIMESTAMPTZ,
     completed_at TIMESTAMPTZ,
+    version INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+CREATE INDEX distributed_tasks_created_by_idx ON distributed_tasks (created_by);
 CREATE INDEX distributed_tasks_status_idx ON distributed_tasks (status);
 CREATE INDEX distributed_tasks_criticality_idx ON distributed_tasks (criticality);
 CREATE INDEX distributed_tasks_assigned_worker_idx ON distributed_tasks (assigned_worker_id);
@@ -250,6 +273,13 @@ CREATE INDEX distributed_tasks_priority_idx ON distributed_tasks (priority);
 CREATE INDEX distributed_tasks_dependencies_idx ON distributed_tasks USING GIN (dependencies);
 CREATE INDEX distributed_tasks_created_at_idx ON distributed_tasks (created_at);
 
+CREATE TABLE task_duration_stats (
+    task_type VARCHAR(100) PRIMARY KEY,
+    average_duration_ms BIGINT NOT NULL,
+    sample_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE task_checkpoints (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     task_id UUID NOT NULL REFERENCES distributed_tasks(id) ON DELETE CASCADE,
@@ -275,6 +305,59 @@ CREATE INDEX worker_connectivity_events_worker_id_idx ON worker_connectivity_eve
 CREATE INDEX worker_connectivity_events_event_type_idx ON worker_connectivity_events (event_type);
 CREATE INDEX worker_connectivity_events_created_at_idx ON worker_connectivity_events (created_at);
 
+-- Append-only audit log of task and worker changes. Rows outlive the
+-- entities they describe, so there are no foreign keys.
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entity_type VARCHAR(50) NOT NULL CHECK (entity_type IN ('task', 'worker')),
+    entity_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    from_status VARCHAR(50) NOT NULL DEFAULT '',
+    to_status VARCHAR(50) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    details JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+
+-- Long-lived API keys for scripts and workers. Only a SHA-256 hash of each
+-- key is stored. Keys may belong to users of an external authentication
+-- backend, so there is no foreign key to users.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('full', 'read')),
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('admin', 'user')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+
+-- Outbox of notifications not yet delivered on every channel. Rows are kept
+-- after delivery so a restart never sends a notification twice.
+CREATE TABLE notification_outbox (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    priority VARCHAR(50) NOT NULL DEFAULT '',
+    pending_channels JSONB NOT NULL DEFAULT '[]',
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX notification_outbox_pending_idx ON notification_outbox (created_at) WHERE delivered_at IS NULL;
+
 -- =============================================
 -- 4. PROJECTS & SESSIONS
 -- =============================================
@@ -297,6 +380,15 @@ CREATE INDEX projects_owner_id_idx ON projects (owner_id);
 CREATE INDEX projects_status_idx ON projects (status);
 CREATE INDEX projects_created_at_idx ON projects (created_at);
 
+CREATE TABLE project_collaborators (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX project_collaborators_user_id_idx ON project_collaborators (user_id);
+
 CREATE TABLE sessions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     project_id UUID NOT NULL REFERENCES projects(id),
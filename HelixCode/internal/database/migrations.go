@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// migration brings a schema created by an earlier release up to date
+type migration struct {
+	name string
+	sql  string
+}
+
+// migrations run in order on every start, after the schema is created. Each
+// must be idempotent, and createSchemaSQL must already contain its result so
+// that new databases need no migrating.
+var migrations = []migration{
+	{
+		name: "users role",
+		sql: `ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('admin', 'user'));`,
+	},
+	{
+		name: "worker drain statuses",
+		sql: `ALTER TABLE workers DROP CONSTRAINT IF EXISTS workers_status_check;
+ALTER TABLE workers ADD CONSTRAINT workers_status_check
+    CHECK (status IN ('active', 'inactive', 'maintenance', 'failed', 'offline', 'draining', 'drained'));`,
+	},
+	{
+		name: "task versions",
+		sql:  `ALTER TABLE distributed_tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		name: "task creators",
+		sql: `ALTER TABLE distributed_tasks ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS distributed_tasks_created_by_idx ON distributed_tasks (created_by);`,
+	},
+	{
+		name: "task duration stats",
+		sql: `CREATE TABLE IF NOT EXISTS task_duration_stats (
+    task_type VARCHAR(100) PRIMARY KEY,
+    average_duration_ms BIGINT NOT NULL,
+    sample_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);`,
+	},
+	{
+		name: "audit events",
+		sql: `CREATE TABLE IF NOT EXISTS audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entity_type VARCHAR(50) NOT NULL CHECK (entity_type IN ('task', 'worker')),
+    entity_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    from_status VARCHAR(50) NOT NULL DEFAULT '',
+    to_status VARCHAR(50) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    details JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);`,
+	},
+	{
+		name: "api keys",
+		sql: `CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('full', 'read')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('admin', 'user'));
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);`,
+	},
+	{
+		name: "notification outbox",
+		sql: `CREATE TABLE IF NOT EXISTS notification_outbox (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    priority VARCHAR(50) NOT NULL DEFAULT '',
+    pending_channels JSONB NOT NULL DEFAULT '[]',
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_idx ON notification_outbox (created_at) WHERE delivered_at IS NULL;`,
+	},
+	{
+		name: "project collaborators",
+		sql: `CREATE TABLE IF NOT EXISTS project_collaborators (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS project_collaborators_user_id_idx ON project_collaborators (user_id);`,
+	},
+	{
+		name: "api key owners",
+		sql: `DELETE FROM api_keys WHERE user_id NOT IN (SELECT id FROM users);
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'api_keys_user_id_fkey' AND conrelid = 'api_keys'::regclass
+    ) THEN
+        ALTER TABLE api_keys ADD CONSTRAINT api_keys_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+    END IF;
+END $$;`,
+	},
+}
+
+// migrate applies every migration
+func (db *Database) migrate(ctx context.Context) error {
+	for _, m := range migrations {
+		if _, err := db.Pool.Exec(ctx, m.sql); err != nil {
+			return fmt.Errorf("failed to apply migration %q: %v", m.name, err)
+		}
+	}
+	log.Printf("✅ Applied %d database migrations", len(migrations))
+	return nil
+}
@@ -0,0 +1,131 @@
+//go:build integration
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestDatabase connects to the database in HELIX_TEST_DATABASE_URL with a
+// fresh schema first on the search path, which is dropped after the test
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	url := os.Getenv("HELIX_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("HELIX_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+	schema := fmt.Sprintf("helix_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("Failed to parse database URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return &Database{Pool: pool}
+}
+
+func TestInitializeSchema_MigratesBaseline(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	baseline, err := os.ReadFile("testdata/baseline_schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read baseline schema: %v", err)
+	}
+	if _, err := db.Pool.Exec(ctx, string(baseline)); err != nil {
+		t.Fatalf("Failed to create baseline schema: %v", err)
+	}
+
+	// Migrations run on every start, so a second run must succeed too
+	for i := 0; i < 2; i++ {
+		if err := db.InitializeSchema(); err != nil {
+			t.Fatalf("Failed to initialize schema on run %d: %v", i+1, err)
+		}
+	}
+
+	columns := map[string][]string{
+		"users":                 {"role"},
+		"distributed_tasks":     {"version", "created_by"},
+		"api_keys":              {"role", "key_hash"},
+		"audit_events":          {"entity_id"},
+		"notification_outbox":   {"pending_channels"},
+		"task_duration_stats":   {"average_duration_ms"},
+		"project_collaborators": {"user_id"},
+	}
+	for table, names := range columns {
+		for _, column := range names {
+			var exists bool
+			err := db.Pool.QueryRow(ctx, `
+				SELECT EXISTS(
+					SELECT 1 FROM information_schema.columns
+					WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+				)
+			`, table, column).Scan(&exists)
+			if err != nil || !exists {
+				t.Errorf("Expected column %s.%s after migrating (%v)", table, column, err)
+			}
+		}
+	}
+
+	if _, err := db.Pool.Exec(ctx, `
+		INSERT INTO workers (hostname, ssh_config, status) VALUES ('drained-host', '{}', 'drained')
+	`); err != nil {
+		t.Errorf("Expected workers to accept the drained status: %v", err)
+	}
+
+	// Deleting a user deletes their API keys
+	var userID string
+	if err := db.Pool.QueryRow(ctx, `
+		INSERT INTO users (username, email, password_hash) VALUES ('owner', 'owner@example.com', '') RETURNING id
+	`).Scan(&userID); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if _, err := db.Pool.Exec(ctx, `
+		INSERT INTO api_keys (id, user_id, username, name, key_prefix, key_hash, scope)
+		VALUES (uuid_generate_v4(), $1, 'owner', 'deploy', 'hx_', repeat('a', 64), 'full')
+	`, userID); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	var keys int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM api_keys`).Scan(&keys); err != nil || keys != 0 {
+		t.Errorf("Expected the user's API keys to be deleted, got %d (%v)", keys, err)
+	}
+}
+
+func TestInitializeSchema_NewDatabase(t *testing.T) {
+	db := newTestDatabase(t)
+
+	// The migrations must be no-ops on a freshly created schema
+	for i := 0; i < 2; i++ {
+		if err := db.InitializeSchema(); err != nil {
+			t.Fatalf("Failed to initialize schema on run %d: %v", i+1, err)
+		}
+	}
+}
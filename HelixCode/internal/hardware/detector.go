@@ -1,55 +1,68 @@
 package hardware
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"runtime"
-	"strconv"
 	"strings"
 )
 
 // HardwareInfo contains comprehensive hardware information
 type HardwareInfo struct {
-	CPU      CPUInfo      `json:"cpu"`
-	GPU      GPUInfo      `json:"gpu"`
-	Memory   MemoryInfo   `json:"memory"`
-	Platform PlatformInfo `json:"platform"`
+	CPU      CPUInfo      `json:"cpu" yaml:"cpu"`
+	GPU      GPUInfo      `json:"gpu" yaml:"gpu"`
+	Memory   MemoryInfo   `json:"memory" yaml:"memory"`
+	Platform PlatformInfo `json:"platform" yaml:"platform"`
 }
 
 // CPUInfo contains CPU-specific information
 type CPUInfo struct {
-	Architecture string `json:"architecture"`
-	Vendor       string `json:"vendor"`
-	Model        string `json:"model"`
-	Cores        int    `json:"cores"`
-	Threads      int    `json:"threads"`
+	Architecture string `json:"architecture" yaml:"architecture"`
+	Vendor       string `json:"vendor" yaml:"vendor"`
+	Model        string `json:"model" yaml:"model"`
+	Cores        int    `json:"cores" yaml:"cores"`
+	Threads      int    `json:"threads" yaml:"threads"`
 }
 
 // GPUInfo contains GPU-specific information
 type GPUInfo struct {
-	Vendor       string `json:"vendor"`
-	Model        string `json:"model"`
-	VRAM         string `json:"vram"`
-	SupportsCUDA bool   `json:"supports_cuda"`
-	SupportsMetal bool  `json:"supports_metal"`
+	Vendor        string `json:"vendor" yaml:"vendor"`
+	Model         string `json:"model" yaml:"model"`
+	VRAM          string `json:"vram" yaml:"vram"`
+	SupportsCUDA  bool   `json:"supports_cuda" yaml:"supports_cuda"`
+	SupportsMetal bool   `json:"supports_metal" yaml:"supports_metal"`
 }
 
 // MemoryInfo contains memory information
 type MemoryInfo struct {
-	TotalRAM string `json:"total_ram"`
+	TotalRAM string `json:"total_ram" yaml:"total_ram"`
 }
 
 // PlatformInfo contains platform-specific information
 type PlatformInfo struct {
-	OS           string `json:"os"`
-	Architecture string `json:"architecture"`
-	Hostname     string `json:"hostname"`
+	OS           string `json:"os" yaml:"os"`
+	Architecture string `json:"architecture" yaml:"architecture"`
+	Hostname     string `json:"hostname" yaml:"hostname"`
 }
 
 // Detector handles hardware detection
 type Detector struct {
-	info *HardwareInfo
+	info      *HardwareInfo
+	simulated bool
+}
+
+// SimulationOptions describes a hardware spec to simulate instead of detecting
+type SimulationOptions struct {
+	Profile *HardwareInfo // Base profile, e.g. loaded with LoadHardwareProfile
+	RAM     string        // Overrides Memory.TotalRAM, e.g. "16GB"
+	VRAM    string        // Overrides GPU.VRAM, e.g. "8GB"
+}
+
+// IsEmpty reports whether no simulated values were provided
+func (o SimulationOptions) IsEmpty() bool {
+	return o.Profile == nil && o.RAM == "" && o.VRAM == ""
 }
 
 // NewDetector creates a new hardware detector
@@ -59,8 +72,54 @@ func NewDetector() *Detector {
 	}
 }
 
+// NewSimulatedDetector creates a detector that answers for a simulated hardware spec.
+// Fields not provided by the options are filled from real hardware detection.
+// When the options are empty, a regular detector is returned.
+func NewSimulatedDetector(opts SimulationOptions) (*Detector, error) {
+	if opts.IsEmpty() {
+		return NewDetector(), nil
+	}
+
+	var info HardwareInfo
+	if opts.Profile != nil {
+		info = *opts.Profile
+	} else {
+		detected, err := NewDetector().Detect()
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect base hardware: %v", err)
+		}
+		info = *detected
+	}
+
+	if opts.RAM != "" {
+		info.Memory.TotalRAM = opts.RAM
+	}
+	if opts.VRAM != "" {
+		info.GPU.VRAM = opts.VRAM
+	}
+
+	if err := validateSimulatedInfo(&info); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🧪 Using simulated hardware profile (RAM: %s, VRAM: %s)", info.Memory.TotalRAM, info.GPU.VRAM)
+	return &Detector{
+		info:      &info,
+		simulated: true,
+	}, nil
+}
+
+// IsSimulated reports whether the detector uses a simulated hardware spec
+func (d *Detector) IsSimulated() bool {
+	return d.simulated
+}
+
 // Detect performs comprehensive hardware detection
 func (d *Detector) Detect() (*HardwareInfo, error) {
+	if d.simulated {
+		return d.info, nil
+	}
+
 	log.Println("🔍 Starting hardware detection...")
 
 	// Detect CPU information
@@ -90,20 +149,10 @@ func (d *Detector) Detect() (*HardwareInfo, error) {
 // GetOptimalModelSize calculates the optimal model size for the hardware
 func (d *Detector) GetOptimalModelSize() string {
 	// Calculate based on available VRAM and RAM
-	var vramGB, ramGB int
-	
-	// Parse VRAM
-	if strings.Contains(d.info.GPU.VRAM, "GB") {
-		vramGB, _ = strconv.Atoi(strings.TrimSuffix(d.info.GPU.VRAM, "GB"))
-	} else if strings.Contains(d.info.GPU.VRAM, "MB") {
-		vramMB, _ := strconv.Atoi(strings.TrimSuffix(d.info.GPU.VRAM, "MB"))
-		vramGB = vramMB / 1024
-	}
-	
-	// Parse RAM
-	if strings.Contains(d.info.Memory.TotalRAM, "GB") {
-		ramGB, _ = strconv.Atoi(strings.TrimSuffix(d.info.Memory.TotalRAM, "GB"))
-	}
+	// Unknown or malformed sizes count as no memory
+	vramBytes, _ := ParseMemorySize(d.info.GPU.VRAM)
+	ramBytes, _ := ParseMemorySize(d.info.Memory.TotalRAM)
+	vramGB, ramGB := bytesToGB(vramBytes), bytesToGB(ramBytes)
 
 	// Determine optimal model size based on available memory
 	totalMemory := vramGB + (ramGB / 2) // Use half of RAM for model loading
@@ -124,21 +173,17 @@ func (d *Detector) GetOptimalModelSize() string {
 
 // CanRunModel checks if the hardware can run a specific model size
 func (d *Detector) CanRunModel(modelSize string) bool {
-	optimalSize := d.GetOptimalModelSize()
-	
-	// Model size comparison (larger numbers are better)
-	sizeOrder := map[string]int{
-		"3B":  1,
-		"7B":  2,
-		"13B": 3,
-		"34B": 4,
-		"70B": 5,
+	requested, err := parseParameterCount(modelSize)
+	if err != nil {
+		return false
 	}
-	
-	requestedOrder := sizeOrder[modelSize]
-	optimalOrder := sizeOrder[optimalSize]
-	
-	return requestedOrder <= optimalOrder
+
+	optimal, err := parseParameterCount(d.GetOptimalModelSize())
+	if err != nil {
+		return false
+	}
+
+	return requested <= optimal
 }
 
 // GetCompilationFlags returns hardware-specific compilation flags
@@ -317,4 +362,20 @@ func (d *Detector) detectPlatform() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// validateSimulatedInfo validates the memory values of a simulated hardware spec
+func validateSimulatedInfo(info *HardwareInfo) error {
+	if info.Memory.TotalRAM == "" {
+		return fmt.Errorf("simulated RAM is required")
+	}
+	if _, err := ParseMemorySize(info.Memory.TotalRAM); err != nil {
+		return fmt.Errorf("invalid simulated RAM: %v", err)
+	}
+	if info.GPU.VRAM != "" {
+		if _, err := ParseMemorySize(info.GPU.VRAM); err != nil {
+			return fmt.Errorf("invalid simulated VRAM: %v", err)
+		}
+	}
+	return nil
+}
@@ -139,4 +139,79 @@ func TestModelSizeCalculation(t *testing.T) {
 	}
 
 	t.Logf("✅ Model size calculation test passed: optimal size is %s", currentOptimal)
-}
\ No newline at end of file
+}
+
+// TestSimulatedDetector tests model fit answers for a simulated hardware spec
+func TestSimulatedDetector(t *testing.T) {
+	detector, err := NewSimulatedDetector(SimulationOptions{RAM: "8GB", VRAM: "4GB"})
+	if err != nil {
+		t.Fatalf("Failed to create simulated detector: %v", err)
+	}
+
+	if !detector.IsSimulated() {
+		t.Error("Detector should report simulated mode")
+	}
+
+	info, err := detector.Detect()
+	if err != nil {
+		t.Fatalf("Simulated detection failed: %v", err)
+	}
+	if info.Memory.TotalRAM != "8GB" || info.GPU.VRAM != "4GB" {
+		t.Errorf("Simulated values not applied: RAM %s, VRAM %s", info.Memory.TotalRAM, info.GPU.VRAM)
+	}
+
+	// 4GB VRAM + 8GB RAM / 2 = 8GB total
+	if size := detector.GetOptimalModelSize(); size != "13B" {
+		t.Errorf("Expected optimal size 13B for simulated spec, got %s", size)
+	}
+	if !detector.CanRunModel("7B") {
+		t.Error("Simulated spec should run 7B models")
+	}
+	if detector.CanRunModel("34B") {
+		t.Error("Simulated spec should not run 34B models")
+	}
+
+	// A loaded profile is used as the base, with flags taking precedence
+	profileDetector, err := NewSimulatedDetector(SimulationOptions{Profile: sampleHardwareInfo(), RAM: "8GB"})
+	if err != nil {
+		t.Fatalf("Failed to create profile-based detector: %v", err)
+	}
+	if size := profileDetector.GetOptimalModelSize(); size != "34B" {
+		t.Errorf("Expected optimal size 34B for profile with 8GB RAM, got %s", size)
+	}
+
+	t.Log("✅ Simulated detector test passed")
+}
+
+// TestSimulatedDetectorValidation tests validation of simulated inputs and real fallback
+func TestSimulatedDetectorValidation(t *testing.T) {
+	invalid := []SimulationOptions{
+		{RAM: "lots"},
+		{RAM: "16"},
+		{RAM: "16GB", VRAM: "-8GB"},
+		{Profile: &HardwareInfo{}},
+	}
+	for _, opts := range invalid {
+		if _, err := NewSimulatedDetector(opts); err == nil {
+			t.Errorf("Expected validation error for %+v", opts)
+		}
+	}
+
+	// Without simulated values the detector falls back to real detection
+	detector, err := NewSimulatedDetector(SimulationOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create fallback detector: %v", err)
+	}
+	if detector.IsSimulated() {
+		t.Error("Detector without simulated values should not be simulated")
+	}
+	info, err := detector.Detect()
+	if err != nil {
+		t.Fatalf("Real detection failed: %v", err)
+	}
+	if info.CPU.Cores == 0 {
+		t.Error("Real detection should report CPU cores")
+	}
+
+	t.Log("✅ Simulated detector validation test passed")
+}
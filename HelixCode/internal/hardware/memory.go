@@ -0,0 +1,82 @@
+package hardware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Memory size units in bytes. Decimal and binary suffixes are both treated
+// as binary multiples, matching how RAM and VRAM sizes are reported.
+const (
+	KB uint64 = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+var memoryUnits = map[string]uint64{
+	"B":   1,
+	"K":   KB,
+	"KB":  KB,
+	"KIB": KB,
+	"M":   MB,
+	"MB":  MB,
+	"MIB": MB,
+	"G":   GB,
+	"GB":  GB,
+	"GIB": GB,
+	"T":   TB,
+	"TB":  TB,
+	"TIB": TB,
+}
+
+// ParseMemorySize parses a human-readable memory size such as "16GB",
+// "16 GiB", "512MB" or "1.5TB" into bytes
+func ParseMemorySize(s string) (uint64, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, fmt.Errorf("empty memory size")
+	}
+
+	split := strings.IndexFunc(value, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if split <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q: expected a number followed by a unit", s)
+	}
+
+	number, err := strconv.ParseFloat(value[:split], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: %v", s, err)
+	}
+
+	unit := strings.ToUpper(strings.TrimSpace(value[split:]))
+	multiplier, ok := memoryUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory size %q: unknown unit %q", s, unit)
+	}
+
+	return uint64(number * float64(multiplier)), nil
+}
+
+// bytesToGB converts a byte count to gigabytes
+func bytesToGB(bytes uint64) float64 {
+	return float64(bytes) / float64(GB)
+}
+
+// parseParameterCount parses a model size such as "7B" or "1.5B" into billions of parameters
+func parseParameterCount(size string) (float64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	if !strings.HasSuffix(value, "B") {
+		return 0, fmt.Errorf("invalid model size %q", size)
+	}
+
+	count, err := strconv.ParseFloat(strings.TrimSuffix(value, "B"), 64)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid model size %q", size)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,74 @@
+package hardware
+
+import (
+	"testing"
+)
+
+// TestParseMemorySize tests parsing of human-readable memory sizes
+func TestParseMemorySize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected uint64
+	}{
+		{"16GB", 16 * GB},
+		{"16 GB", 16 * GB},
+		{"16GiB", 16 * GB},
+		{"16 GiB", 16 * GB},
+		{"16gb", 16 * GB},
+		{"  8GB  ", 8 * GB},
+		{"512MB", 512 * MB},
+		{"512 MiB", 512 * MB},
+		{"8192MB", 8 * GB},
+		{"1TB", TB},
+		{"2 TiB", 2 * TB},
+		{"1.5GB", GB + GB/2},
+		{"64KB", 64 * KB},
+		{"1024B", KB},
+		{"0GB", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			bytes, err := ParseMemorySize(tc.input)
+			if err != nil {
+				t.Fatalf("ParseMemorySize(%q) failed: %v", tc.input, err)
+			}
+			if bytes != tc.expected {
+				t.Errorf("ParseMemorySize(%q) = %d, want %d", tc.input, bytes, tc.expected)
+			}
+		})
+	}
+}
+
+// TestParseMemorySizeInvalid tests that malformed memory sizes are rejected
+func TestParseMemorySizeInvalid(t *testing.T) {
+	for _, input := range []string{"", "GB", "16", "16XB", "-8GB", "1.2.3GB", "sixteen GB", "16 G B"} {
+		if _, err := ParseMemorySize(input); err == nil {
+			t.Errorf("ParseMemorySize(%q) should fail", input)
+		}
+	}
+}
+
+// TestCanRunModelNumericComparison tests model fit checks against parsed memory sizes
+func TestCanRunModelNumericComparison(t *testing.T) {
+	// 8 GiB VRAM + 16 GiB RAM / 2 = 16 GiB usable, so 34B is optimal
+	detector, err := NewSimulatedDetector(SimulationOptions{RAM: "16 GiB", VRAM: "8192MB"})
+	if err != nil {
+		t.Fatalf("Failed to create simulated detector: %v", err)
+	}
+
+	expected := map[string]bool{
+		"1B":      true,
+		"8B":      true,
+		"13B":     true,
+		"34B":     true,
+		"70B":     false,
+		"":        false,
+		"invalid": false,
+	}
+	for size, want := range expected {
+		if got := detector.CanRunModel(size); got != want {
+			t.Errorf("CanRunModel(%q) = %t, want %t", size, got, want)
+		}
+	}
+}
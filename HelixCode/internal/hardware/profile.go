@@ -0,0 +1,86 @@
+package hardware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dev.helix.code/internal/paths"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileFormat represents the serialization format of a hardware profile
+type ProfileFormat string
+
+const (
+	ProfileFormatJSON ProfileFormat = "json"
+	ProfileFormatYAML ProfileFormat = "yaml"
+)
+
+// Marshal serializes the hardware information in the given format
+func (h *HardwareInfo) Marshal(format ProfileFormat) ([]byte, error) {
+	switch format {
+	case ProfileFormatJSON, "":
+		return json.MarshalIndent(h, "", "  ")
+	case ProfileFormatYAML:
+		return yaml.Marshal(h)
+	default:
+		return nil, fmt.Errorf("unsupported profile format: %s", format)
+	}
+}
+
+// Save writes the hardware information to a profile file.
+// The format is chosen from the file extension (.yaml/.yml or JSON otherwise).
+func (h *HardwareInfo) Save(path string) error {
+	path = paths.Expand(path)
+	data, err := h.Marshal(profileFormatForPath(path))
+	if err != nil {
+		return fmt.Errorf("failed to marshal hardware profile: %v", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create profile directory: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write hardware profile: %v", err)
+	}
+
+	return nil
+}
+
+// LoadHardwareProfile restores hardware information from a profile file
+func LoadHardwareProfile(path string) (*HardwareInfo, error) {
+	path = paths.Expand(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hardware profile: %v", err)
+	}
+
+	info := &HardwareInfo{}
+	switch profileFormatForPath(path) {
+	case ProfileFormatYAML:
+		err = yaml.Unmarshal(data, info)
+	default:
+		err = json.Unmarshal(data, info)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hardware profile %s: %v", path, err)
+	}
+
+	return info, nil
+}
+
+// profileFormatForPath determines the profile format from a file extension
+func profileFormatForPath(path string) ProfileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ProfileFormatYAML
+	default:
+		return ProfileFormatJSON
+	}
+}
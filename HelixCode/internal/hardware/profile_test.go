@@ -0,0 +1,80 @@
+package hardware
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sampleHardwareInfo() *HardwareInfo {
+	return &HardwareInfo{
+		CPU: CPUInfo{
+			Architecture: "amd64",
+			Vendor:       "GenuineIntel",
+			Model:        "Intel(R) Xeon(R) CPU",
+			Cores:        16,
+			Threads:      32,
+		},
+		GPU: GPUInfo{
+			Vendor:       "NVIDIA",
+			Model:        "RTX 4090",
+			VRAM:         "24GB",
+			SupportsCUDA: true,
+		},
+		Memory: MemoryInfo{
+			TotalRAM: "64GB",
+		},
+		Platform: PlatformInfo{
+			OS:           "linux",
+			Architecture: "amd64",
+			Hostname:     "build-box",
+		},
+	}
+}
+
+// TestHardwareProfileRoundTrip tests saving and loading hardware profiles
+func TestHardwareProfileRoundTrip(t *testing.T) {
+	original := sampleHardwareInfo()
+
+	for _, name := range []string{"profile.json", "profile.yaml", "profile.yml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			if err := original.Save(path); err != nil {
+				t.Fatalf("Failed to save profile: %v", err)
+			}
+
+			loaded, err := LoadHardwareProfile(path)
+			if err != nil {
+				t.Fatalf("Failed to load profile: %v", err)
+			}
+
+			if !reflect.DeepEqual(original, loaded) {
+				t.Errorf("Profile did not round-trip:\nwant %+v\ngot  %+v", original, loaded)
+			}
+		})
+	}
+}
+
+// TestHardwareProfileMarshal tests marshalling in each supported format
+func TestHardwareProfileMarshal(t *testing.T) {
+	info := sampleHardwareInfo()
+
+	for _, format := range []ProfileFormat{ProfileFormatJSON, ProfileFormatYAML} {
+		data, err := info.Marshal(format)
+		if err != nil {
+			t.Errorf("Marshal(%s) failed: %v", format, err)
+		}
+		if len(data) == 0 {
+			t.Errorf("Marshal(%s) returned no data", format)
+		}
+	}
+
+	if _, err := info.Marshal("xml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+
+	if _, err := LoadHardwareProfile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error when loading a missing profile")
+	}
+}
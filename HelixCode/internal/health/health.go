@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTimeout bounds a check that does not set its own timeout
+const DefaultTimeout = 5 * time.Second
+
+// Status is the outcome of a health check
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+	StatusTimeout   Status = "timeout"
+)
+
+// Check is the health check of one subsystem
+type Check struct {
+	Name    string
+	Timeout time.Duration // DefaultTimeout when zero
+	// Run checks the subsystem and describes its state. ctx ends at the
+	// check's timeout.
+	Run func(ctx context.Context) (Status, string)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string        `json:"name"`
+	Status  Status        `json:"status"`
+	Message string        `json:"message"`
+	Latency time.Duration `json:"latency"`
+}
+
+// OK reports whether the subsystem is usable, possibly degraded
+func (r Result) OK() bool {
+	return r.Status == StatusHealthy || r.Status == StatusDegraded
+}
+
+// Run runs the checks concurrently, each bounded by its own timeout, so a
+// slow subsystem delays only its own result. report, which may be nil, is
+// called with each result as it completes. The results are returned in the
+// order of checks.
+func Run(ctx context.Context, checks []Check, report func(Result)) []Result {
+	results := make([]Result, len(checks))
+	done := make(chan int)
+	for i, check := range checks {
+		go func(i int, check Check) {
+			results[i] = runCheck(ctx, check)
+			done <- i
+		}(i, check)
+	}
+
+	for range checks {
+		i := <-done
+		if report != nil {
+			report(results[i])
+		}
+	}
+	return results
+}
+
+// runCheck runs one check, giving up when its timeout passes even if the
+// check ignores its context
+func runCheck(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		status  Status
+		message string
+	}
+	finished := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		status, message := check.Run(ctx)
+		finished <- outcome{status, message}
+	}()
+
+	result := Result{Name: check.Name}
+	select {
+	case o := <-finished:
+		result.Status, result.Message = o.status, o.message
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Status = StatusTimeout
+			result.Message = fmt.Sprintf("no response within %v", timeout)
+		} else {
+			result.Status = StatusUnhealthy
+			result.Message = ctx.Err().Error()
+		}
+	}
+	result.Latency = time.Since(start)
+	return result
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticCheck returns a check that answers after delay, or not at all when
+// block is set
+func staticCheck(name string, timeout, delay time.Duration, block bool, status Status) Check {
+	return Check{
+		Name:    name,
+		Timeout: timeout,
+		Run: func(ctx context.Context) (Status, string) {
+			if block {
+				// Ignores ctx, like a provider stuck in a call without a deadline
+				time.Sleep(time.Hour)
+			}
+			time.Sleep(delay)
+			return status, name + " answered"
+		},
+	}
+}
+
+// TestRun_PerCheckTimeout tests that a hanging check times out on its own
+// without holding back the other results
+func TestRun_PerCheckTimeout(t *testing.T) {
+	checks := []Check{
+		staticCheck("hanging", 50*time.Millisecond, 0, true, StatusHealthy),
+		staticCheck("slow", time.Second, 100*time.Millisecond, false, StatusDegraded),
+		staticCheck("fast", time.Second, 0, false, StatusHealthy),
+	}
+
+	var order []string
+	start := time.Now()
+	results := Run(context.Background(), checks, func(result Result) {
+		order = append(order, result.Name)
+	})
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{"fast", "hanging", "slow"}, order)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "hanging", results[0].Name)
+	assert.Equal(t, StatusTimeout, results[0].Status)
+	assert.Equal(t, "no response within 50ms", results[0].Message)
+	assert.False(t, results[0].OK())
+
+	assert.Equal(t, StatusDegraded, results[1].Status)
+	assert.GreaterOrEqual(t, results[1].Latency, 100*time.Millisecond)
+	assert.True(t, results[1].OK())
+
+	assert.Equal(t, StatusHealthy, results[2].Status)
+	assert.Equal(t, "fast answered", results[2].Message)
+}
+
+func TestRun_ContextPassedToCheck(t *testing.T) {
+	var deadline time.Time
+	check := Check{Name: "deadline", Run: func(ctx context.Context) (Status, string) {
+		deadline, _ = ctx.Deadline()
+		return StatusHealthy, ""
+	}}
+
+	start := time.Now()
+	Run(context.Background(), []Check{check}, nil)
+	assert.WithinDuration(t, start.Add(DefaultTimeout), deadline, time.Second)
+}
+
+func TestRun_ParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := Run(ctx, []Check{staticCheck("blocked", time.Second, 0, true, StatusHealthy)}, nil)
+	assert.Equal(t, StatusUnhealthy, results[0].Status)
+	assert.Equal(t, context.Canceled.Error(), results[0].Message)
+}
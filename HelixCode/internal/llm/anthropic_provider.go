@@ -0,0 +1,445 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Anthropic Messages API defaults
+const (
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	DefaultAnthropicModel   = "claude-sonnet-4-5"
+	anthropicVersion        = "2023-06-01"
+)
+
+// AnthropicConfig holds configuration for the Anthropic provider
+type AnthropicConfig struct {
+	APIKey       string `json:"api_key"`
+	BaseURL      string `json:"base_url"`      // Empty uses DefaultAnthropicBaseURL
+	DefaultModel string `json:"default_model"` // Used for requests without a model
+	// HTTPClient replaces the shared HTTP client, e.g. in tests
+	HTTPClient *http.Client `json:"-"`
+}
+
+// AnthropicProvider implements the Provider interface for Claude models
+// through the Anthropic Messages API
+type AnthropicProvider struct {
+	config     AnthropicConfig
+	httpClient *http.Client
+	models     []ModelInfo
+	lastHealth *ProviderHealth
+}
+
+// NewAnthropicProvider creates a new Anthropic provider
+func NewAnthropicProvider(config AnthropicConfig) (*AnthropicProvider, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key is required")
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultAnthropicBaseURL
+	}
+	if config.DefaultModel == "" {
+		config.DefaultModel = DefaultAnthropicModel
+	}
+
+	provider := &AnthropicProvider{
+		config:     config,
+		httpClient: newProviderHTTPClient(config.HTTPClient),
+		lastHealth: &ProviderHealth{
+			Status:    "unknown",
+			LastCheck: time.Now(),
+		},
+	}
+	provider.initializeModels()
+
+	return provider, nil
+}
+
+// GetType returns the provider type
+func (ap *AnthropicProvider) GetType() ProviderType {
+	return ProviderTypeAnthropic
+}
+
+// GetName returns the provider name
+func (ap *AnthropicProvider) GetName() string {
+	return "Anthropic"
+}
+
+// GetModels returns the known Claude models
+func (ap *AnthropicProvider) GetModels() []ModelInfo {
+	return ap.models
+}
+
+// GetCapabilities returns provider capabilities
+func (ap *AnthropicProvider) GetCapabilities() []ModelCapability {
+	return []ModelCapability{
+		CapabilityTextGeneration,
+		CapabilityCodeGeneration,
+		CapabilityReasoning,
+	}
+}
+
+// Generate generates a response using Claude models
+func (ap *AnthropicProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	if err := NormalizeRequest(request); err != nil {
+		return nil, err
+	}
+	if err := rejectImages(ap.GetName(), request); err != nil {
+		return nil, err
+	}
+
+	logRequest(ap.GetName(), request, ap.config.APIKey)
+	startTime := time.Now()
+
+	resp, err := ap.post(ctx, ap.convertRequest(request, false))
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var message AnthropicMessageResponse
+	if err := decodeResponse(ap.GetName(), resp.Body, &message); err != nil {
+		return nil, fmt.Errorf("Anthropic request failed: %w", err)
+	}
+
+	llmResponse := &LLMResponse{
+		ID:           uuid.New(),
+		RequestID:    request.ID,
+		Content:      message.Text(),
+		FinishReason: message.StopReason,
+		Usage: Usage{
+			PromptTokens:     message.Usage.InputTokens,
+			CompletionTokens: message.Usage.OutputTokens,
+			TotalTokens:      message.Usage.InputTokens + message.Usage.OutputTokens,
+		},
+		ProcessingTime: time.Since(startTime),
+		CreatedAt:      time.Now(),
+	}
+	logResponse(ap.GetName(), llmResponse, llmResponse.ProcessingTime, ap.config.APIKey)
+
+	return llmResponse, nil
+}
+
+// GenerateStream generates a streaming response, sending each text delta as
+// it arrives
+func (ap *AnthropicProvider) GenerateStream(ctx context.Context, request *LLMRequest, ch chan<- LLMResponse) error {
+	defer close(ch)
+
+	if err := NormalizeRequest(request); err != nil {
+		return err
+	}
+	if err := rejectImages(ap.GetName(), request); err != nil {
+		return err
+	}
+	logRequest(ap.GetName(), request, ap.config.APIKey)
+
+	resp, err := ap.post(ctx, ap.convertRequest(request, true))
+	if err != nil {
+		return fmt.Errorf("Anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	stop := closeOnCancel(ctx, resp.Body)
+	defer stop()
+
+	events := NewSSEReader(resp.Body)
+	for {
+		event, err := events.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read stream: %w", streamError(ctx, io.ErrUnexpectedEOF))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", streamError(ctx, err))
+		}
+
+		var streamEvent AnthropicStreamEvent
+		if err := decodeResponse(ap.GetName(), strings.NewReader(event.Data), &streamEvent); err != nil {
+			return streamError(ctx, err)
+		}
+
+		switch streamEvent.Type {
+		case "content_block_delta":
+			if streamEvent.Delta.Text == "" {
+				continue
+			}
+			response := LLMResponse{
+				ID:        uuid.New(),
+				RequestID: request.ID,
+				Content:   streamEvent.Delta.Text,
+				CreatedAt: time.Now(),
+			}
+			select {
+			case ch <- response:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case "message_stop":
+			return nil
+		}
+	}
+}
+
+// SupportsStreaming reports whether GenerateStream is supported
+func (ap *AnthropicProvider) SupportsStreaming() bool {
+	return true
+}
+
+// SupportsNativeTools reports whether tools are sent to the model natively.
+// Requests do not include tool definitions yet, so tools are prompt-based.
+func (ap *AnthropicProvider) SupportsNativeTools() bool {
+	return false
+}
+
+// IsAvailable checks if the provider is available
+func (ap *AnthropicProvider) IsAvailable(ctx context.Context) bool {
+	health, err := ap.GetHealth(ctx)
+	return err == nil && health.Status == "healthy"
+}
+
+// GetHealth checks that the API accepts the key by listing models
+func (ap *AnthropicProvider) GetHealth(ctx context.Context) (*ProviderHealth, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", ap.config.BaseURL+"/v1/models", nil)
+	if err != nil {
+		ap.updateHealth("unhealthy", 0, 1)
+		return ap.lastHealth, fmt.Errorf("failed to create health check request: %v", err)
+	}
+	ap.setHeaders(req)
+
+	start := time.Now()
+	resp, err := ap.httpClient.Do(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		ap.updateHealth("unhealthy", latency, ap.lastHealth.ErrorCount+1)
+		return ap.lastHealth, fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		ap.updateHealth("unhealthy", latency, ap.lastHealth.ErrorCount+1)
+		return ap.lastHealth, fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+
+	var modelsResponse struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResponse); err != nil {
+		ap.updateHealth("degraded", latency, ap.lastHealth.ErrorCount)
+		return ap.lastHealth, nil // Still consider it available
+	}
+
+	ap.updateHealth("healthy", latency, 0)
+	ap.lastHealth.ModelCount = len(modelsResponse.Data)
+
+	return ap.lastHealth, nil
+}
+
+// Close closes the provider
+func (ap *AnthropicProvider) Close() error {
+	ap.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// Helper methods
+
+func (ap *AnthropicProvider) initializeModels() {
+	capabilities := ap.GetCapabilities()
+	ap.models = []ModelInfo{
+		{
+			Name:          "claude-opus-4-1",
+			Provider:      ProviderTypeAnthropic,
+			ContextSize:   200000,
+			Capabilities:  capabilities,
+			MaxTokens:     32000,
+			SupportsTools: true,
+			Description:   "Anthropic's most capable model for complex reasoning",
+		},
+		{
+			Name:          "claude-sonnet-4-5",
+			Provider:      ProviderTypeAnthropic,
+			ContextSize:   200000,
+			Capabilities:  capabilities,
+			MaxTokens:     64000,
+			SupportsTools: true,
+			Description:   "Anthropic's balanced model for coding and agents",
+		},
+		{
+			Name:          "claude-sonnet-4-0",
+			Provider:      ProviderTypeAnthropic,
+			ContextSize:   200000,
+			Capabilities:  capabilities,
+			MaxTokens:     64000,
+			SupportsTools: true,
+			Description:   "Anthropic's previous generation balanced model",
+		},
+		{
+			Name:          "claude-3-7-sonnet-latest",
+			Provider:      ProviderTypeAnthropic,
+			ContextSize:   200000,
+			Capabilities:  capabilities,
+			MaxTokens:     64000,
+			SupportsTools: true,
+			Description:   "Anthropic's Claude 3.7 Sonnet model",
+		},
+		{
+			Name:          "claude-3-5-haiku-latest",
+			Provider:      ProviderTypeAnthropic,
+			ContextSize:   200000,
+			Capabilities:  capabilities,
+			MaxTokens:     8192,
+			SupportsTools: true,
+			Description:   "Anthropic's fastest model",
+		},
+	}
+
+	log.Printf("✅ Anthropic provider initialized with %d models", len(ap.models))
+}
+
+// convertRequest builds a Messages API request. System messages move to the
+// top-level system prompt, and consecutive messages from the same role are
+// merged since the API requires user and assistant turns to alternate.
+func (ap *AnthropicProvider) convertRequest(request *LLMRequest, stream bool) *AnthropicRequest {
+	model := request.Model
+	if model == "" {
+		model = ap.config.DefaultModel
+	}
+
+	var system []string
+	var messages []AnthropicMessage
+	for _, msg := range request.Messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "assistant"
+		}
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content += "\n\n" + msg.Content
+			continue
+		}
+		messages = append(messages, AnthropicMessage{Role: role, Content: msg.Content})
+	}
+
+	return &AnthropicRequest{
+		Model:       model,
+		System:      strings.Join(system, "\n\n"),
+		Messages:    messages,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Stream:      stream,
+	}
+}
+
+// post sends a Messages API request, returning the response when it succeeded
+func (ap *AnthropicProvider) post(ctx context.Context, request *AnthropicRequest) (*http.Response, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ap.config.BaseURL+"/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	ap.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ap.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newStatusError("Anthropic API", resp)
+	}
+
+	return resp, nil
+}
+
+func (ap *AnthropicProvider) setHeaders(req *http.Request) {
+	req.Header.Set("x-api-key", ap.config.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+}
+
+func (ap *AnthropicProvider) updateHealth(status string, latency time.Duration, errorCount int) {
+	ap.lastHealth.Status = status
+	ap.lastHealth.Latency = latency
+	ap.lastHealth.ErrorCount = errorCount
+	ap.lastHealth.LastCheck = time.Now()
+}
+
+// Anthropic API types
+
+type AnthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature,omitempty"`
+	TopP        float64            `json:"top_p,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicContentBlock is one block of a response; only text blocks carry Text
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type AnthropicMessageResponse struct {
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Role       string                  `json:"role"`
+	Model      string                  `json:"model"`
+	Content    []AnthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      AnthropicUsage          `json:"usage"`
+}
+
+// Text returns the concatenated text blocks of the response
+func (r *AnthropicMessageResponse) Text() string {
+	var text strings.Builder
+	for _, block := range r.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String()
+}
+
+// AnthropicStreamEvent is the data of one streamed event. Text arrives in
+// content_block_delta events and the stream ends with message_stop.
+type AnthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text,omitempty"`
+		StopReason string `json:"stop_reason,omitempty"`
+	} `json:"delta"`
+}
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAnthropicTestServer answers /v1/messages with reply, recording request
+// bodies, and lists two models on /v1/models
+func newAnthropicTestServer(t *testing.T, reply func(w http.ResponseWriter, body []byte)) (*httptest.Server, chan []byte) {
+	t.Helper()
+
+	bodies := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/models":
+			fmt.Fprint(w, `{"data":[{"id":"claude-sonnet-4-5"},{"id":"claude-opus-4-1"}]}`)
+		case "/v1/messages":
+			body, _ := io.ReadAll(r.Body)
+			bodies <- body
+			reply(w, body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func newTestAnthropicProvider(t *testing.T, baseURL string) *AnthropicProvider {
+	t.Helper()
+	provider, err := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: baseURL + "/"})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestNewAnthropicProvider(t *testing.T) {
+	_, err := NewAnthropicProvider(AnthropicConfig{})
+	assert.ErrorContains(t, err, "API key is required")
+
+	provider, err := NewAnthropicProvider(AnthropicConfig{APIKey: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeAnthropic, provider.GetType())
+	assert.Equal(t, DefaultAnthropicBaseURL, provider.config.BaseURL)
+	assert.Equal(t, DefaultAnthropicModel, provider.config.DefaultModel)
+	assert.Zero(t, provider.httpClient.Timeout)
+	assert.ElementsMatch(t, []ModelCapability{CapabilityTextGeneration, CapabilityCodeGeneration, CapabilityReasoning}, provider.GetCapabilities())
+
+	models := provider.GetModels()
+	require.NotEmpty(t, models)
+	for _, model := range models {
+		assert.True(t, strings.HasPrefix(model.Name, "claude-"), model.Name)
+		assert.Equal(t, 200000, model.ContextSize)
+		assert.Contains(t, model.Capabilities, CapabilityCodeGeneration)
+	}
+
+	// The registry builds it from a provider config entry
+	registered, err := NewProviderByName("anthropic", ProviderConfigEntry{APIKey: "key", Models: []string{"claude-opus-4-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "claude-opus-4-1", registered.(*AnthropicProvider).config.DefaultModel)
+}
+
+func TestAnthropicProvider_Generate(t *testing.T) {
+	server, bodies := newAnthropicTestServer(t, func(w http.ResponseWriter, body []byte) {
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"func main() {}"}],"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":5}}`)
+	})
+	provider := newTestAnthropicProvider(t, server.URL)
+
+	response, err := provider.Generate(context.Background(), &LLMRequest{
+		Messages: []Message{
+			{Role: "system", Content: "You write Go."},
+			{Role: "user", Content: "Write an empty program."},
+			{Role: "user", Content: "No comments."},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "func main() {}", response.Content)
+	assert.Equal(t, "end_turn", response.FinishReason)
+	assert.Equal(t, Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}, response.Usage)
+
+	var sent AnthropicRequest
+	require.NoError(t, json.Unmarshal(<-bodies, &sent))
+	assert.Equal(t, DefaultAnthropicModel, sent.Model)
+	assert.Equal(t, "You write Go.", sent.System)
+	assert.Equal(t, DefaultMaxTokens, sent.MaxTokens)
+	assert.Equal(t, []AnthropicMessage{{Role: "user", Content: "Write an empty program.\n\nNo comments."}}, sent.Messages)
+	assert.False(t, sent.Stream)
+}
+
+func TestAnthropicProvider_GenerateErrors(t *testing.T) {
+	server, _ := newAnthropicTestServer(t, func(w http.ResponseWriter, body []byte) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad model"}}`)
+	})
+
+	_, err := newTestAnthropicProvider(t, server.URL).Generate(context.Background(), &LLMRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	assert.ErrorContains(t, err, "status 400")
+	assert.ErrorContains(t, err, "bad model")
+
+	provider, err := NewAnthropicProvider(AnthropicConfig{APIKey: "wrong", BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.False(t, provider.IsAvailable(context.Background()))
+
+	_, err = newTestAnthropicProvider(t, server.URL).Generate(context.Background(), imageRequest())
+	assert.ErrorIs(t, err, ErrUnsupportedInput)
+}
+
+const anthropicStream = "event: message_start\n" +
+	`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":3}}}` + "\n\n" +
+	": keep-alive\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}` + "\n\n" +
+	"event: ping\n" +
+	`data: {"type":"ping"}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}` + "\n\n" +
+	"event: message_delta\n" +
+	`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+	"event: message_stop\n" +
+	`data: {"type":"message_stop"}` + "\n\n"
+
+func TestAnthropicProvider_GenerateStream(t *testing.T) {
+	server, bodies := newAnthropicTestServer(t, func(w http.ResponseWriter, body []byte) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, anthropicStream)
+	})
+	provider := newTestAnthropicProvider(t, server.URL)
+
+	ch := make(chan LLMResponse, 8)
+	err := provider.GenerateStream(context.Background(), &LLMRequest{Model: "claude-opus-4-1", Messages: []Message{{Role: "user", Content: "greet"}}}, ch)
+	require.NoError(t, err)
+
+	var chunks []string
+	for chunk := range ch {
+		chunks = append(chunks, chunk.Content)
+	}
+	assert.Equal(t, []string{"Hello", ", world"}, chunks)
+
+	var sent AnthropicRequest
+	require.NoError(t, json.Unmarshal(<-bodies, &sent))
+	assert.True(t, sent.Stream)
+	assert.Equal(t, "claude-opus-4-1", sent.Model)
+}
+
+func TestAnthropicProvider_StreamCutOff(t *testing.T) {
+	server, _ := newAnthropicTestServer(t, func(w http.ResponseWriter, body []byte) {
+		fmt.Fprint(w, strings.SplitAfter(anthropicStream, "\n\n")[3])
+	})
+
+	ch := make(chan LLMResponse, 8)
+	err := newTestAnthropicProvider(t, server.URL).GenerateStream(context.Background(), &LLMRequest{Messages: []Message{{Role: "user", Content: "greet"}}}, ch)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestAnthropicProvider_ToolCallingStream(t *testing.T) {
+	server, _ := newAnthropicTestServer(t, func(w http.ResponseWriter, body []byte) {
+		fmt.Fprint(w, anthropicStream)
+	})
+	tools := NewToolCallingProvider(newTestAnthropicProvider(t, server.URL))
+	assert.True(t, tools.SupportsStreaming())
+
+	ch := make(chan LLMResponse, 8)
+	require.NoError(t, tools.GenerateStream(context.Background(), &LLMRequest{Messages: []Message{{Role: "user", Content: "greet"}}}, ch))
+
+	var text strings.Builder
+	for chunk := range ch {
+		text.WriteString(chunk.Content)
+	}
+	assert.Equal(t, "Hello, world", text.String())
+
+	health, err := newTestAnthropicProvider(t, server.URL).GetHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+	assert.Equal(t, 2, health.ModelCount)
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCapability_RoundTrip(t *testing.T) {
+	capabilities := AllCapabilities()
+	require.Len(t, capabilities, 9)
+
+	for _, capability := range capabilities {
+		parsed, err := ParseCapability(capability.String())
+		require.NoError(t, err)
+		assert.Equal(t, capability, parsed)
+
+		// Hyphens, case and padding are accepted
+		loose := " " + strings.ToUpper(strings.ReplaceAll(capability.String(), "_", "-")) + " "
+		parsed, err = ParseCapability(loose)
+		require.NoError(t, err)
+		assert.Equal(t, capability, parsed)
+	}
+}
+
+func TestParseCapability_Unknown(t *testing.T) {
+	_, err := ParseCapability("mind_reading")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown capability "mind_reading"`)
+	assert.Contains(t, err.Error(), "code_generation")
+
+	_, err = ParseCapabilities([]string{"planning", "telepathy"})
+	assert.ErrorContains(t, err, "telepathy")
+
+	parsed, err := ParseCapabilities([]string{"planning", "Vision"})
+	require.NoError(t, err)
+	assert.Equal(t, []ModelCapability{CapabilityPlanning, CapabilityVision}, parsed)
+}
+
+func TestModelCapability_JSON(t *testing.T) {
+	criteria := ModelSelectionCriteria{RequiredCapabilities: AllCapabilities()}
+	data, err := json.Marshal(criteria)
+	require.NoError(t, err)
+
+	var decoded ModelSelectionCriteria
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, criteria.RequiredCapabilities, decoded.RequiredCapabilities)
+
+	var capabilities []ModelCapability
+	require.NoError(t, json.Unmarshal([]byte(`["code-generation", "testing"]`), &capabilities))
+	assert.Equal(t, []ModelCapability{CapabilityCodeGeneration, CapabilityTesting}, capabilities)
+
+	assert.Error(t, json.Unmarshal([]byte(`["teleportation"]`), &capabilities))
+}
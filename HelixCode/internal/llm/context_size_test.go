@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLlamaCPPProvider_ContextSize(t *testing.T) {
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "model.gguf"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultContextSize, provider.GetModels()[0].ContextSize)
+
+	// A model trained on a shorter context keeps to it
+	path := filepath.Join(t.TempDir(), "small.gguf")
+	writeGGUFFixture(t, path, map[string]interface{}{
+		"general.architecture": "llama",
+		"llama.context_length": uint32(2048),
+	})
+	provider, err = NewLlamaCPPProvider(LlamaConfig{ModelPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, 2048, provider.GetModels()[0].ContextSize)
+
+	provider, err = NewLlamaCPPProvider(LlamaConfig{ModelPath: path, ContextSize: 8192})
+	require.NoError(t, err)
+	assert.Equal(t, 8192, provider.GetModels()[0].ContextSize)
+
+	_, err = NewLlamaCPPProvider(LlamaConfig{ModelPath: path, ContextSize: -1})
+	assert.ErrorContains(t, err, "context size cannot be negative")
+}
+
+func TestOllamaProvider_ContextSize(t *testing.T) {
+	server, bodies := newCaptureServer(t, "/api/chat",
+		`{"model":"llama3","message":{"role":"assistant","content":"hi"},"done":true}`)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+	for _, model := range provider.GetModels() {
+		assert.Equal(t, DefaultContextSize, model.ContextSize)
+	}
+
+	_, err = provider.Generate(context.Background(), &LLMRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "hello"}}})
+	require.NoError(t, err)
+
+	var body struct {
+		Options map[string]interface{} `json:"options"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &body))
+	assert.EqualValues(t, DefaultContextSize, body.Options["num_ctx"])
+
+	_, err = NewOllamaProvider(OllamaConfig{BaseURL: server.URL, ContextSize: -4096})
+	assert.ErrorContains(t, err, "context size cannot be negative")
+}
@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStalledServer returns a server that answers model listing until stall
+// is called and otherwise never responds until the client gives up. started
+// receives a value as each stalled request arrives.
+func newStalledServer(t *testing.T) (server *httptest.Server, started <-chan struct{}, stall func()) {
+	t.Helper()
+
+	startedCh := make(chan struct{}, 10)
+	var stalling atomic.Bool
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if !stalling.Load() && (r.URL.Path == "/api/tags" || r.URL.Path == "/models") {
+			fmt.Fprint(w, `{"models":[]}`)
+			return
+		}
+
+		startedCh <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, startedCh, func() { stalling.Store(true) }
+}
+
+// assertCancelAborts runs call, cancels its context once the request reaches
+// the server and checks that call returns promptly with context.Canceled
+func assertCancelAborts(t *testing.T, started <-chan struct{}, call func(ctx context.Context) error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- call(ctx) }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the server")
+	}
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("request did not stop after cancellation")
+	}
+}
+
+// TestProviders_GenerateCancellation tests that cancelling the caller's context aborts an in-flight Generate
+func TestProviders_GenerateCancellation(t *testing.T) {
+	generate := func(provider Provider) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			_, err := provider.Generate(ctx, &LLMRequest{
+				Model:    "test-model",
+				Messages: []Message{{Role: "user", Content: "hello"}},
+			})
+			return err
+		}
+	}
+
+	t.Run("ollama", func(t *testing.T) {
+		server, started, _ := newStalledServer(t)
+		provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 30})
+		require.NoError(t, err)
+		assertCancelAborts(t, started, generate(provider))
+	})
+
+	t.Run("local", func(t *testing.T) {
+		server, started, _ := newStalledServer(t)
+		provider, err := NewLocalProvider(ProviderConfigEntry{Endpoint: server.URL})
+		require.NoError(t, err)
+		assertCancelAborts(t, started, generate(provider))
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		server, started, _ := newStalledServer(t)
+		provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+		require.NoError(t, err)
+		assertCancelAborts(t, started, generate(provider))
+	})
+
+	t.Run("llama-cpp", func(t *testing.T) {
+		provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "test.gguf", ContextSize: 2048})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = provider.Generate(ctx, &LLMRequest{Messages: []Message{{Role: "user", Content: "hello"}}})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// TestOllamaProvider_HealthCancellation tests that health checks use the caller's context
+func TestOllamaProvider_HealthCancellation(t *testing.T) {
+	server, started, stall := newStalledServer(t)
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 30})
+	require.NoError(t, err)
+	stall()
+
+	done := make(chan bool, 1)
+	assertCancelAborts(t, started, func(ctx context.Context) error {
+		done <- provider.IsAvailable(ctx)
+		return ctx.Err()
+	})
+	assert.False(t, <-done)
+
+	assertCancelAborts(t, started, func(ctx context.Context) error {
+		health, err := provider.GetHealth(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "degraded", health.Status)
+		return ctx.Err()
+	})
+}
+
+// TestOllamaProvider_GenerationOutlastsTimeout tests that the metadata timeout
+// does not cut off a generation the caller's context still allows
+func TestOllamaProvider_GenerationOutlastsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			fmt.Fprint(w, `{"models":[]}`)
+			return
+		}
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"done"},"done":true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	provider.timeout = 100 * time.Millisecond // Timeout is whole seconds; keep the test fast
+
+	response, err := provider.Generate(context.Background(), &LLMRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", response.Content)
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Conversation holds chat history bounded by a token budget
+type Conversation struct {
+	messages    []Message
+	tokenBudget int
+	summarizer  *Summarizer
+	mu          sync.Mutex
+}
+
+// NewConversation creates a conversation limited to the given token budget.
+// A budget of zero or less disables trimming.
+func NewConversation(tokenBudget int) *Conversation {
+	return &Conversation{
+		messages:    make([]Message, 0),
+		tokenBudget: tokenBudget,
+	}
+}
+
+// SetSummarizer makes trimming summarize older turns instead of dropping them
+func (c *Conversation) SetSummarizer(summarizer *Summarizer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.summarizer = summarizer
+}
+
+// Add appends a message to the conversation
+func (c *Conversation) Add(role, content string) {
+	c.AddMessage(Message{Role: role, Content: content})
+}
+
+// AddMessage appends a message as it is, e.g. one restored from a saved
+// conversation
+func (c *Conversation) AddMessage(message Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = append(c.messages, message)
+}
+
+// Messages returns a copy of the conversation history
+func (c *Conversation) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := make([]Message, len(c.messages))
+	copy(messages, c.messages)
+	return messages
+}
+
+// EstimateTokens returns the approximate token count of the conversation
+func (c *Conversation) EstimateTokens() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return EstimateTokens(c.messages)
+}
+
+// Trim brings the conversation within its token budget. With a summarizer,
+// older turns are compressed into a summary message; otherwise, or if
+// summarization fails, the oldest non-system turns are dropped.
+func (c *Conversation) Trim(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokenBudget <= 0 || EstimateTokens(c.messages) <= c.tokenBudget {
+		return nil
+	}
+
+	if c.summarizer != nil {
+		summarized, err := c.summarizer.Summarize(ctx, c.messages)
+		if err == nil {
+			c.messages = summarized
+			if EstimateTokens(c.messages) <= c.tokenBudget {
+				return nil
+			}
+		} else {
+			log.Printf("⚠️ Conversation summarization failed, dropping old turns: %v", err)
+		}
+	}
+
+	c.dropOldest()
+	return nil
+}
+
+// dropOldest removes the oldest non-system turns until the budget is met,
+// always keeping the most recent message
+func (c *Conversation) dropOldest() {
+	for EstimateTokens(c.messages) > c.tokenBudget {
+		index := -1
+		for i, message := range c.messages[:len(c.messages)-1] {
+			if message.Role != "system" {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return
+		}
+		c.messages = append(c.messages[:index], c.messages[index+1:]...)
+	}
+}
+
+// EstimateTokens approximates the token count of messages (about 4 characters per token)
+func EstimateTokens(messages []Message) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += len(message.Content)/4 + 4 // Per-message overhead for role and formatting
+	}
+	return tokens
+}
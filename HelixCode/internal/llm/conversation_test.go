@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newLongConversation creates a conversation with a system prompt and numbered turns
+func newLongConversation(budget, turns int) *Conversation {
+	conversation := NewConversation(budget)
+	conversation.Add("system", "You are a helpful assistant.")
+	for i := 0; i < turns; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		conversation.Add(role, fmt.Sprintf("turn %d: %s", i, strings.Repeat("x", 80)))
+	}
+	return conversation
+}
+
+// TestConversation_TrimBelowBudget tests that conversations within budget are not summarized
+func TestConversation_TrimBelowBudget(t *testing.T) {
+	mockProvider := new(MockProvider)
+	conversation := newLongConversation(10000, 6)
+	conversation.SetSummarizer(NewSummarizer(mockProvider))
+
+	require.NoError(t, conversation.Trim(context.Background()))
+
+	assert.Len(t, conversation.Messages(), 7)
+	mockProvider.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+}
+
+// TestConversation_TrimSummarizes tests that exceeding the budget summarizes older turns
+func TestConversation_TrimSummarizes(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("Generate", mock.Anything, mock.MatchedBy(func(request *LLMRequest) bool {
+		prompt := request.Messages[0].Content
+		return strings.HasPrefix(prompt, "Custom summary prompt") &&
+			strings.Contains(prompt, "turn 0:") &&
+			!strings.Contains(prompt, "turn 9:")
+	})).Return(&LLMResponse{Content: "The user discussed turns zero to seven."}, nil).Once()
+
+	conversation := newLongConversation(150, 10)
+	original := conversation.Messages()
+
+	summarizer := NewSummarizer(mockProvider)
+	summarizer.Prompt = "Custom summary prompt"
+	summarizer.KeepRecent = 2
+	conversation.SetSummarizer(summarizer)
+
+	require.NoError(t, conversation.Trim(context.Background()))
+	messages := conversation.Messages()
+
+	require.Len(t, messages, 4)
+	assert.Equal(t, original[0], messages[0])
+	assert.Equal(t, SummaryMessageName, messages[1].Name)
+	assert.Contains(t, messages[1].Content, "turns zero to seven")
+	assert.Equal(t, original[len(original)-2:], messages[2:])
+	assert.LessOrEqual(t, conversation.EstimateTokens(), 150)
+
+	mockProvider.AssertExpectations(t)
+}
+
+// TestConversation_TrimDropsWithoutSummarizer tests dropping old turns as the fallback
+func TestConversation_TrimDropsWithoutSummarizer(t *testing.T) {
+	conversation := newLongConversation(100, 10)
+	original := conversation.Messages()
+
+	require.NoError(t, conversation.Trim(context.Background()))
+	messages := conversation.Messages()
+
+	assert.Less(t, len(messages), len(original))
+	assert.Equal(t, "system", messages[0].Role)
+	assert.Equal(t, original[len(original)-1], messages[len(messages)-1])
+	assert.LessOrEqual(t, conversation.EstimateTokens(), 100)
+}
+
+// TestConversation_TrimFallsBackOnSummaryError tests dropping turns when summarization fails
+func TestConversation_TrimFallsBackOnSummaryError(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(nil, errors.New("model offline"))
+
+	conversation := newLongConversation(100, 10)
+	conversation.SetSummarizer(NewSummarizer(mockProvider))
+
+	require.NoError(t, conversation.Trim(context.Background()))
+
+	assert.LessOrEqual(t, conversation.EstimateTokens(), 100)
+	for _, message := range conversation.Messages() {
+		assert.NotEqual(t, SummaryMessageName, message.Name)
+	}
+}
+
+// TestSummarizer_NegativeKeepRecent tests that a negative KeepRecent summarizes every turn
+func TestSummarizer_NegativeKeepRecent(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{Content: "Everything so far."}, nil).Once()
+
+	summarizer := NewSummarizer(mockProvider)
+	summarizer.KeepRecent = -1
+
+	messages, err := summarizer.Summarize(context.Background(), newLongConversation(0, 4).Messages())
+	require.NoError(t, err)
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].Role)
+	assert.Equal(t, SummaryMessageName, messages[1].Name)
+	mockProvider.AssertExpectations(t)
+}
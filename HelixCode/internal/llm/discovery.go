@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dev.helix.code/internal/hardware"
+	"dev.helix.code/internal/paths"
+)
+
+// sizeLabelPattern matches parameter counts such as "7B" or "1.5b" in model names
+var sizeLabelPattern = regexp.MustCompile(`(?i)(?:^|[^a-z0-9.])(\d+(?:\.\d+)?)b(?:$|[^a-z])`)
+
+// DiscoveredModel is a GGUF model file found on disk
+type DiscoveredModel struct {
+	Path          string        `json:"path"`
+	Name          string        `json:"name"`
+	Architecture  string        `json:"architecture"`
+	SizeLabel     string        `json:"size_label"`
+	ContextLength int           `json:"context_length"`
+	FileSize      int64         `json:"file_size"`
+	Metadata      *GGUFMetadata `json:"metadata,omitempty"`
+}
+
+// DefaultModelsDir returns the directory scanned for local models.
+// HELIX_MODELS_DIR overrides the default of ~/models.
+func DefaultModelsDir() string {
+	if dir := os.Getenv("HELIX_MODELS_DIR"); dir != "" {
+		return paths.Expand(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "models"
+	}
+	return filepath.Join(home, "models")
+}
+
+// DiscoverModels scans a directory tree for .gguf files and reads their metadata.
+// A missing directory yields no models and no error. Files with unreadable
+// metadata are still returned, described from their file name.
+func DiscoverModels(dir string) ([]DiscoveredModel, error) {
+	return discoverModels(dir, ReadGGUFMetadata)
+}
+
+// DiscoverModelsCached is DiscoverModels reading metadata through cache, which
+// is saved afterwards so unchanged files are not parsed on the next run
+func DiscoverModelsCached(dir string, cache *GGUFCache) ([]DiscoveredModel, error) {
+	models, err := discoverModels(dir, cache.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Save(); err != nil {
+		log.Printf("⚠️ Failed to save GGUF cache: %v", err)
+	}
+	return models, nil
+}
+
+// discoverModels scans dir, reading each file's metadata with readMetadata
+func discoverModels(dir string, readMetadata func(path string) (*GGUFMetadata, error)) ([]DiscoveredModel, error) {
+	dir = paths.Expand(dir)
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return []DiscoveredModel{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to access models directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("models path %s is not a directory", dir)
+	}
+
+	models := []DiscoveredModel{}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".gguf") {
+			return nil
+		}
+
+		model := DiscoveredModel{
+			Path: path,
+			Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		}
+		if fileInfo, err := entry.Info(); err == nil {
+			model.FileSize = fileInfo.Size()
+		}
+
+		meta, err := readMetadata(path)
+		if err != nil {
+			log.Printf("⚠️ Could not read GGUF metadata from %s: %v", path, err)
+		} else {
+			model.Metadata = meta
+			model.Architecture = meta.Architecture
+			model.ContextLength = meta.ContextLength
+			if meta.Name != "" {
+				model.Name = meta.Name
+			}
+			model.SizeLabel = meta.SizeLabel
+			if model.SizeLabel == "" && meta.ParameterCount > 0 {
+				model.SizeLabel = formatParameterCount(meta.ParameterCount)
+			}
+		}
+		if model.SizeLabel == "" {
+			model.SizeLabel = sizeLabelFromName(filepath.Base(path))
+		}
+
+		models = append(models, model)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan models directory: %v", err)
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].Path < models[j].Path })
+	return models, nil
+}
+
+// SelectBestModel picks the largest discovered model the hardware can run.
+// Models without a known size are only chosen when nothing else fits.
+func SelectBestModel(models []DiscoveredModel, detector *hardware.Detector) (*DiscoveredModel, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models discovered")
+	}
+
+	var best, unknown *DiscoveredModel
+	bestSize := 0.0
+	for i := range models {
+		model := &models[i]
+		size := parameterBillions(model.SizeLabel)
+		if size == 0 {
+			if unknown == nil {
+				unknown = model
+			}
+			continue
+		}
+		if detector != nil && !detector.CanRunModel(model.SizeLabel) {
+			continue
+		}
+		if size > bestSize {
+			best, bestSize = model, size
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if unknown != nil {
+		return unknown, nil
+	}
+	return nil, fmt.Errorf("none of the %d discovered models fit the available hardware", len(models))
+}
+
+// sizeLabelFromName extracts a parameter size label such as "7B" from a file name
+func sizeLabelFromName(name string) string {
+	match := sizeLabelPattern.FindStringSubmatch(name)
+	if match == nil {
+		return ""
+	}
+	return match[1] + "B"
+}
+
+// formatParameterCount converts a raw parameter count into a size label
+func formatParameterCount(count uint64) string {
+	billions := float64(count) / 1e9
+	if billions >= 10 {
+		return fmt.Sprintf("%.0fB", billions)
+	}
+	return strconv.FormatFloat(float64(int(billions*10+0.5))/10, 'f', -1, 64) + "B"
+}
+
+// parameterBillions parses a size label, returning 0 when it is not a parameter count
+func parameterBillions(label string) float64 {
+	value := strings.ToUpper(strings.TrimSpace(label))
+	if !strings.HasSuffix(value, "B") {
+		return 0
+	}
+	count, err := strconv.ParseFloat(strings.TrimSuffix(value, "B"), 64)
+	if err != nil || count <= 0 {
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dev.helix.code/internal/hardware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeGGUFFixture writes a GGUF v3 header with the given metadata and no tensors
+func writeGGUFFixture(t *testing.T, path string, metadata map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	write := func(v interface{}) { require.NoError(t, binary.Write(&buf, binary.LittleEndian, v)) }
+	writeString := func(s string) {
+		write(uint64(len(s)))
+		buf.WriteString(s)
+	}
+
+	write(uint32(ggufMagic))
+	write(uint32(3))
+	write(uint64(0))
+	write(uint64(len(metadata) + 1))
+
+	// A tokenizer-style array that the reader has to skip
+	writeString("tokenizer.ggml.tokens")
+	write(ggufTypeArray)
+	write(ggufTypeString)
+	write(uint64(2))
+	writeString("<s>")
+	writeString("</s>")
+
+	for key, value := range metadata {
+		writeString(key)
+		switch v := value.(type) {
+		case string:
+			write(ggufTypeString)
+			writeString(v)
+		case uint32:
+			write(ggufTypeUint32)
+			write(v)
+		case uint64:
+			write(ggufTypeUint64)
+			write(v)
+		default:
+			t.Fatalf("unsupported fixture value %T", value)
+		}
+	}
+
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestReadGGUFMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	writeGGUFFixture(t, path, map[string]interface{}{
+		"general.name":         "Tiny Llama",
+		"general.architecture": "llama",
+		"general.size_label":   "1.1B",
+		"llama.context_length": uint32(2048),
+	})
+
+	meta, err := ReadGGUFMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), meta.Version)
+	assert.Equal(t, "Tiny Llama", meta.Name)
+	assert.Equal(t, "llama", meta.Architecture)
+	assert.Equal(t, "1.1B", meta.SizeLabel)
+	assert.Equal(t, 2048, meta.ContextLength)
+
+	notGGUF := filepath.Join(t.TempDir(), "fake.gguf")
+	require.NoError(t, os.WriteFile(notGGUF, []byte("not a model"), 0644))
+	_, err = ReadGGUFMetadata(notGGUF)
+	assert.Error(t, err)
+}
+
+func TestDiscoverModels(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0755))
+
+	writeGGUFFixture(t, filepath.Join(dir, "tiny.gguf"), map[string]interface{}{
+		"general.name":         "Tiny",
+		"general.architecture": "llama",
+		"general.size_label":   "1.5B",
+	})
+	writeGGUFFixture(t, filepath.Join(dir, "nested", "mistral.GGUF"), map[string]interface{}{
+		"general.architecture":    "llama",
+		"general.parameter_count": uint64(7_240_000_000),
+		"llama.context_length":    uint32(32768),
+	})
+	// Unreadable metadata falls back to the size in the file name
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "llama-2-70b-chat.Q4_K_M.gguf"), []byte("truncated"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("notes"), 0644))
+
+	models, err := DiscoverModels(dir)
+	require.NoError(t, err)
+	require.Len(t, models, 3)
+
+	byName := make(map[string]DiscoveredModel)
+	for _, model := range models {
+		byName[model.Name] = model
+	}
+	assert.Equal(t, "1.5B", byName["Tiny"].SizeLabel)
+	assert.Equal(t, "7.2B", byName["mistral"].SizeLabel)
+	assert.Equal(t, 32768, byName["mistral"].ContextLength)
+	assert.Equal(t, "70B", byName["llama-2-70b-chat.Q4_K_M"].SizeLabel)
+	assert.Nil(t, byName["llama-2-70b-chat.Q4_K_M"].Metadata)
+
+	// 8GB RAM + 4GB VRAM fits up to 13B models, so the 70B model is skipped
+	detector, err := hardware.NewSimulatedDetector(hardware.SimulationOptions{
+		Profile: &hardware.HardwareInfo{},
+		RAM:     "8GB",
+		VRAM:    "4GB",
+	})
+	require.NoError(t, err)
+
+	best, err := SelectBestModel(models, detector)
+	require.NoError(t, err)
+	assert.Equal(t, "mistral", best.Name)
+
+	// Missing and empty directories are not errors
+	missing, err := DiscoverModels(filepath.Join(dir, "does-not-exist"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	empty, err := DiscoverModels(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = SelectBestModel(empty, detector)
+	assert.Error(t, err)
+}
+
+func TestSizeLabelFromName(t *testing.T) {
+	assert.Equal(t, "7B", sizeLabelFromName("llama-2-7b-chat.gguf"))
+	assert.Equal(t, "1.5B", sizeLabelFromName("qwen2-1.5B-instruct.gguf"))
+	assert.Equal(t, "", sizeLabelFromName("phi-mini.gguf"))
+}
@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ggufMagic is the "GGUF" file magic in little-endian byte order
+const ggufMagic = 0x46554747
+
+// maxGGUFStringLength guards against corrupt files claiming huge strings
+const maxGGUFStringLength = 1 << 24
+
+// GGUF metadata value types
+const (
+	ggufTypeUint8 uint32 = iota
+	ggufTypeInt8
+	ggufTypeUint16
+	ggufTypeInt16
+	ggufTypeUint32
+	ggufTypeInt32
+	ggufTypeFloat32
+	ggufTypeBool
+	ggufTypeString
+	ggufTypeArray
+	ggufTypeUint64
+	ggufTypeInt64
+	ggufTypeFloat64
+)
+
+// GGUFMetadata contains the model metadata stored in a GGUF file header
+type GGUFMetadata struct {
+	Version        uint32 `json:"version"`
+	Name           string `json:"name"`
+	Architecture   string `json:"architecture"`
+	SizeLabel      string `json:"size_label"`
+	ParameterCount uint64 `json:"parameter_count"`
+	ContextLength  int    `json:"context_length"`
+	FileType       int    `json:"file_type"`
+}
+
+// ReadGGUFMetadata reads the metadata key/value section of a GGUF model file.
+// Tensor data is not read.
+func ReadGGUFMetadata(path string) (*GGUFMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GGUF file: %v", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+
+	var header struct {
+		Magic   uint32
+		Version uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read GGUF header: %v", err)
+	}
+	if header.Magic != ggufMagic {
+		return nil, fmt.Errorf("%s is not a GGUF file", path)
+	}
+	if header.Version < 2 {
+		return nil, fmt.Errorf("unsupported GGUF version %d", header.Version)
+	}
+
+	var counts struct {
+		Tensors uint64
+		KVs     uint64
+	}
+	if err := binary.Read(r, binary.LittleEndian, &counts); err != nil {
+		return nil, fmt.Errorf("failed to read GGUF header: %v", err)
+	}
+
+	values := make(map[string]interface{})
+	for i := uint64(0); i < counts.KVs; i++ {
+		key, err := readGGUFString(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GGUF metadata key: %v", err)
+		}
+		var valueType uint32
+		if err := binary.Read(r, binary.LittleEndian, &valueType); err != nil {
+			return nil, fmt.Errorf("failed to read GGUF metadata type for %s: %v", key, err)
+		}
+		value, err := readGGUFValue(r, valueType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GGUF metadata value for %s: %v", key, err)
+		}
+		if value != nil {
+			values[key] = value
+		}
+	}
+
+	meta := &GGUFMetadata{Version: header.Version}
+	meta.Name, _ = values["general.name"].(string)
+	meta.Architecture, _ = values["general.architecture"].(string)
+	meta.SizeLabel, _ = values["general.size_label"].(string)
+	if count, ok := ggufUint(values["general.parameter_count"]); ok {
+		meta.ParameterCount = count
+	}
+	if fileType, ok := ggufUint(values["general.file_type"]); ok {
+		meta.FileType = int(fileType)
+	}
+	if meta.Architecture != "" {
+		if length, ok := ggufUint(values[meta.Architecture+".context_length"]); ok {
+			meta.ContextLength = int(length)
+		}
+	}
+
+	return meta, nil
+}
+
+// readGGUFString reads a length-prefixed GGUF string
+func readGGUFString(r io.Reader) (string, error) {
+	var length uint64
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+	if length > maxGGUFStringLength {
+		return "", fmt.Errorf("string length %d exceeds limit", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readGGUFValue reads a metadata value. Arrays are skipped and returned as nil.
+func readGGUFValue(r io.Reader, valueType uint32) (interface{}, error) {
+	switch valueType {
+	case ggufTypeUint8:
+		var v uint8
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeInt8:
+		var v int8
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeUint16:
+		var v uint16
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeInt16:
+		var v int16
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeUint32:
+		var v uint32
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeInt32:
+		var v int32
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeFloat32:
+		var v float32
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeBool:
+		var v uint8
+		return v != 0, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeString:
+		return readGGUFString(r)
+	case ggufTypeUint64:
+		var v uint64
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeInt64:
+		var v int64
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeFloat64:
+		var v float64
+		return v, binary.Read(r, binary.LittleEndian, &v)
+	case ggufTypeArray:
+		var elemType uint32
+		var count uint64
+		if err := binary.Read(r, binary.LittleEndian, &elemType); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < count; i++ {
+			if _, err := readGGUFValue(r, elemType); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown value type %d", valueType)
+	}
+}
+
+// ggufUint converts an integer metadata value to uint64
+func ggufUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case int8:
+		return uint64(v), v >= 0
+	case int16:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/paths"
+)
+
+// ggufCacheEntry is the cached metadata of one model file, valid while the
+// file keeps its size and modification time
+type ggufCacheEntry struct {
+	ModTime  time.Time     `json:"mod_time"`
+	Size     int64         `json:"size"`
+	Metadata *GGUFMetadata `json:"metadata"`
+}
+
+// GGUFCache caches GGUF metadata by file path so unchanged model files are
+// not parsed again. Caches loaded from a file are written back by Save.
+type GGUFCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]ggufCacheEntry
+	dirty   bool
+	read    func(path string) (*GGUFMetadata, error)
+}
+
+// DefaultGGUFCachePath returns the GGUF metadata cache file, from
+// HELIX_GGUF_CACHE or else in the user's HelixCode cache directory
+func DefaultGGUFCachePath() string {
+	if path := os.Getenv("HELIX_GGUF_CACHE"); path != "" {
+		return paths.Expand(path)
+	}
+	return paths.Expand("~/.cache/helixcode/gguf_metadata.json")
+}
+
+// NewGGUFCache creates a cache that is kept in memory only
+func NewGGUFCache() *GGUFCache {
+	return &GGUFCache{
+		entries: make(map[string]ggufCacheEntry),
+		read:    ReadGGUFMetadata,
+	}
+}
+
+// LoadGGUFCache reads a cache from a JSON file. A missing or corrupt file
+// gives an empty cache that Save writes to path.
+func LoadGGUFCache(path string) (*GGUFCache, error) {
+	cache := NewGGUFCache()
+	cache.path = paths.Expand(path)
+
+	data, err := os.ReadFile(cache.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GGUF cache: %v", err)
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		log.Printf("⚠️ Ignoring corrupt GGUF cache %s: %v", cache.path, err)
+		cache.entries = make(map[string]ggufCacheEntry)
+	}
+	if cache.entries == nil {
+		cache.entries = make(map[string]ggufCacheEntry)
+	}
+	return cache, nil
+}
+
+// Metadata returns the metadata of a GGUF file, parsing it only when the file
+// is not cached or has changed since it was. Files that fail to parse are
+// not cached.
+func (c *GGUFCache) Metadata(path string) (*GGUFMetadata, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat GGUF file: %v", err)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) && entry.Metadata != nil {
+		meta := *entry.Metadata
+		return &meta, nil
+	}
+
+	meta, err := c.read(path)
+	if err != nil {
+		c.mu.Lock()
+		if _, ok := c.entries[path]; ok {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	cached := *meta
+	c.mu.Lock()
+	c.entries[path] = ggufCacheEntry{ModTime: info.ModTime(), Size: info.Size(), Metadata: &cached}
+	c.dirty = true
+	c.mu.Unlock()
+	return meta, nil
+}
+
+// Save writes the cache to its file if it changed since it was loaded.
+// Entries for files that no longer exist are dropped.
+func (c *GGUFCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode GGUF cache: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create GGUF cache directory: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write GGUF cache: %v", err)
+	}
+	c.dirty = false
+	return nil
+}
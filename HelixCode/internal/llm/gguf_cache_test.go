@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countReads makes cache count the files it parses
+func countReads(cache *GGUFCache) *int {
+	reads := 0
+	cache.read = func(path string) (*GGUFMetadata, error) {
+		reads++
+		return ReadGGUFMetadata(path)
+	}
+	return &reads
+}
+
+func TestGGUFCache_HitAndInvalidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	writeGGUFFixture(t, path, map[string]interface{}{"general.name": "First"})
+
+	cache := NewGGUFCache()
+	reads := countReads(cache)
+
+	meta, err := cache.Metadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "First", meta.Name)
+
+	// Unchanged file: served from the cache
+	meta, err = cache.Metadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "First", meta.Name)
+	assert.Equal(t, 1, *reads)
+
+	// A rewritten file gets a new modification time and is parsed again
+	writeGGUFFixture(t, path, map[string]interface{}{"general.name": "Second"})
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	meta, err = cache.Metadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Second", meta.Name)
+	assert.Equal(t, 2, *reads)
+}
+
+func TestGGUFCache_Persisted(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache", "gguf.json")
+	modelsDir := filepath.Join(dir, "models")
+	require.NoError(t, os.MkdirAll(modelsDir, 0755))
+	writeGGUFFixture(t, filepath.Join(modelsDir, "tiny.gguf"), map[string]interface{}{
+		"general.name":       "Tiny",
+		"general.size_label": "1.5B",
+	})
+
+	cache, err := LoadGGUFCache(cachePath)
+	require.NoError(t, err)
+	_, err = DiscoverModelsCached(modelsDir, cache)
+	require.NoError(t, err)
+	assert.FileExists(t, cachePath)
+
+	// A new run reuses the saved metadata without parsing the file
+	cache, err = LoadGGUFCache(cachePath)
+	require.NoError(t, err)
+	reads := countReads(cache)
+	models, err := DiscoverModelsCached(modelsDir, cache)
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, "Tiny", models[0].Name)
+	assert.Equal(t, "1.5B", models[0].SizeLabel)
+	assert.Equal(t, 0, *reads)
+
+	// Entries for deleted files are dropped when saving
+	require.NoError(t, os.Remove(filepath.Join(modelsDir, "tiny.gguf")))
+	require.NoError(t, cache.Save())
+	cache, err = LoadGGUFCache(cachePath)
+	require.NoError(t, err)
+	assert.Empty(t, cache.entries)
+}
+
+func TestLoadGGUFCache_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gguf.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	cache, err := LoadGGUFCache(path)
+	require.NoError(t, err)
+	assert.Empty(t, cache.entries)
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClientConfig tunes the transport shared by HTTP-based providers
+type HTTPClientConfig struct {
+	MaxIdleConns        int           `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `json:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout"`
+	DialTimeout         time.Duration `json:"dial_timeout"`
+	KeepAlive           time.Duration `json:"keep_alive"`
+	TLSHandshakeTimeout time.Duration `json:"tls_handshake_timeout"`
+	TLSConfig           *tls.Config   `json:"-"`
+}
+
+// DefaultHTTPClientConfig returns settings suited to a few LLM endpoints
+// receiving many concurrent requests
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         10 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// NewHTTPTransport creates a transport from the given configuration
+func NewHTTPTransport(config HTTPClientConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        config.MaxIdleConns,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		IdleConnTimeout:     config.IdleConnTimeout,
+		TLSHandshakeTimeout: config.TLSHandshakeTimeout,
+		TLSClientConfig:     config.TLSConfig,
+	}
+}
+
+var (
+	sharedTransportMu sync.Mutex
+	sharedTransport   *http.Transport
+)
+
+// SharedTransport returns the transport used by providers that are not given
+// their own HTTP client, so that they reuse connections
+func SharedTransport() *http.Transport {
+	sharedTransportMu.Lock()
+	defer sharedTransportMu.Unlock()
+
+	if sharedTransport == nil {
+		sharedTransport = NewHTTPTransport(DefaultHTTPClientConfig())
+	}
+	return sharedTransport
+}
+
+// ConfigureSharedTransport replaces the shared transport. Providers created
+// afterwards use the new settings; idle connections of the old transport are
+// closed.
+func ConfigureSharedTransport(config HTTPClientConfig) {
+	sharedTransportMu.Lock()
+	defer sharedTransportMu.Unlock()
+
+	if sharedTransport != nil {
+		sharedTransport.CloseIdleConnections()
+	}
+	sharedTransport = NewHTTPTransport(config)
+}
+
+// newProviderHTTPClient returns client if set, otherwise a client on the
+// shared transport. The client has no overall timeout, which would cut off
+// streams and long generations: requests are bounded by their context, and
+// the transport bounds dialing and TLS handshakes.
+func newProviderHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Transport: SharedTransport()}
+}
+
+// providerTimeout returns the entry's timeout for metadata requests, or
+// fallback when none is set
+func providerTimeout(config ProviderConfigEntry, fallback time.Duration) time.Duration {
+	if config.Timeout > 0 {
+		return time.Duration(config.Timeout) * time.Second
+	}
+	return fallback
+}
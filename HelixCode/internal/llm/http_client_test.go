@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConnCountingServer serves Ollama chat responses and counts new TCP connections
+func newConnCountingServer(t *testing.T) (*httptest.Server, *int32) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OllamaAPIResponse{Response: "ok", Done: true})
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &connections
+}
+
+// roundTripCounter counts requests sent through a custom client
+type roundTripCounter struct {
+	requests int32
+}
+
+func (c *roundTripCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestProviderHTTPClient_ConnectionReuse tests that providers share pooled connections
+func TestProviderHTTPClient_ConnectionReuse(t *testing.T) {
+	ConfigureSharedTransport(DefaultHTTPClientConfig())
+	server, connections := newConnCountingServer(t)
+
+	first, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+	second, err := NewProviderByName("ollama", ProviderConfigEntry{Endpoint: server.URL})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		for _, provider := range []Provider{first, second} {
+			_, err := provider.Generate(context.Background(), &LLMRequest{
+				Model:    "llama3",
+				Messages: []Message{{Role: "user", Content: "hi"}},
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(connections))
+	assert.Same(t, SharedTransport(), first.apiClient.Transport)
+}
+
+// TestProviderHTTPClient_Custom tests injecting a custom client, and that
+// default clients leave timeouts to the request context
+func TestProviderHTTPClient_Custom(t *testing.T) {
+	server, _ := newConnCountingServer(t)
+	counter := &roundTripCounter{}
+	client := &http.Client{Transport: counter}
+
+	provider, err := NewProviderByName("ollama", ProviderConfigEntry{Endpoint: server.URL, HTTPClient: client})
+	require.NoError(t, err)
+	_, err = provider.Generate(context.Background(), &LLMRequest{
+		Model:    "llama3",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&counter.requests)) // model discovery and generate
+
+	openAI, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key", Timeout: 5})
+	require.NoError(t, err)
+	assert.Zero(t, openAI.httpClient.Timeout)
+
+	local, err := NewLocalProvider(ProviderConfigEntry{Endpoint: server.URL})
+	require.NoError(t, err)
+	assert.Zero(t, local.httpClient.Timeout)
+	assert.Same(t, SharedTransport(), local.httpClient.Transport)
+}
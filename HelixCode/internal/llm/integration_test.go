@@ -66,7 +66,7 @@ func TestOllamaProviderIntegration(t *testing.T) {
 		BaseURL:       "http://localhost:11434",
 		DefaultModel:  "llama2",
 		Timeout:       30,
-		KeepAlive:     300,
+		KeepAlive:     KeepAlive(300),
 		StreamEnabled: false,
 	}
 
@@ -146,7 +146,7 @@ func TestModelManagerIntegration(t *testing.T) {
 	selectedModel, err := manager.SelectOptimalModel(criteria)
 	if err != nil {
 		t.Logf("Model selection failed (may be normal): %v", err)
-	} else if selectedModel != nil {
+	} else {
 		t.Logf("Selected model: %s", selectedModel.Name)
 	}
 
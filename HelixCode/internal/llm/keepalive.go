@@ -0,0 +1,32 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// KeepAliveUnload unloads the model as soon as the request completes
+	KeepAliveUnload time.Duration = 0
+	// KeepAliveForever keeps the model loaded until it is explicitly unloaded
+	KeepAliveForever time.Duration = -1 * time.Second
+)
+
+type keepAliveKey struct{}
+
+// KeepAlive returns a keep-alive value for LLMRequest.KeepAlive
+func KeepAlive(d time.Duration) *time.Duration {
+	return &d
+}
+
+// WithKeepAlive returns a context that applies a keep-alive duration to every
+// request made with it, e.g. to keep a model resident for a whole session
+func WithKeepAlive(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, keepAliveKey{}, d)
+}
+
+// KeepAliveFromContext returns the keep-alive duration set with WithKeepAlive
+func KeepAliveFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(keepAliveKey{}).(time.Duration)
+	return d, ok
+}
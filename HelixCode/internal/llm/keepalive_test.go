@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKeepAliveServer starts a mock Ollama server that records the keep_alive of each chat request
+func newKeepAliveServer(t *testing.T) (*httptest.Server, chan json.RawMessage) {
+	received := make(chan json.RawMessage, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[{"name":"llama3"}]}`))
+		case "/api/chat":
+			var body map[string]json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- body["keep_alive"]
+			w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"ok"},"done":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, received
+}
+
+// TestOllamaProvider_KeepAlive tests that keep-alive values are forwarded to Ollama
+func TestOllamaProvider_KeepAlive(t *testing.T) {
+	server, received := newKeepAliveServer(t)
+
+	provider, err := NewOllamaProvider(OllamaConfig{
+		BaseURL:   server.URL,
+		Timeout:   5,
+		KeepAlive: KeepAlive(5 * time.Minute),
+	})
+	require.NoError(t, err)
+
+	newRequest := func() *LLMRequest {
+		return &LLMRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "hi"}}}
+	}
+
+	// Provider default
+	_, err = provider.Generate(context.Background(), newRequest())
+	require.NoError(t, err)
+	assert.JSONEq(t, `"5m0s"`, string(<-received))
+
+	// Per-request unload
+	request := newRequest()
+	request.KeepAlive = KeepAlive(KeepAliveUnload)
+	_, err = provider.Generate(context.Background(), request)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"0s"`, string(<-received))
+
+	// Session-wide value from the context, overridden by the request
+	ctx := WithKeepAlive(context.Background(), 30*time.Minute)
+	_, err = provider.Generate(ctx, newRequest())
+	require.NoError(t, err)
+	assert.JSONEq(t, `"30m0s"`, string(<-received))
+
+	request = newRequest()
+	request.KeepAlive = KeepAlive(KeepAliveForever)
+	_, err = provider.Generate(ctx, request)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"-1s"`, string(<-received))
+}
+
+// TestOllamaProvider_KeepAliveOmitted tests that no keep_alive is sent when none is configured
+func TestOllamaProvider_KeepAliveOmitted(t *testing.T) {
+	server, received := newKeepAliveServer(t)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	_, err = provider.Generate(context.Background(), &LLMRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "hi"}}})
+	require.NoError(t, err)
+	assert.Nil(t, <-received)
+}
+
+// TestOllamaProvider_KeepAliveZero tests that a configured zero keep_alive unloads the model
+func TestOllamaProvider_KeepAliveZero(t *testing.T) {
+	server, received := newKeepAliveServer(t)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5, KeepAlive: KeepAlive(KeepAliveUnload)})
+	require.NoError(t, err)
+
+	_, err = provider.Generate(context.Background(), &LLMRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "hi"}}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"0s"`, string(<-received))
+}
@@ -2,41 +2,103 @@ package llm
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	"dev.helix.code/internal/paths"
 )
 
 // LlamaCPPProvider implements the LLM provider interface for Llama.cpp
 type LlamaCPPProvider struct {
 	config    LlamaConfig
 	isRunning bool
+	mu        sync.Mutex     // Guards isRunning, server, port and config.ModelPath, which Load changes
+	server    *managedServer // The llama-server process, when ServerBinary is set
+	port      int            // The port server listens on
 }
 
 // LlamaConfig holds configuration for Llama.cpp
 type LlamaConfig struct {
 	ModelPath     string        `json:"model_path"`
-	ContextSize   int           `json:"context_size"`
+	ContextSize   int           `json:"context_size"` // Tokens; 0 uses DefaultContextSize, capped to the model's trained length
 	GPUEnabled    bool          `json:"gpu_enabled"`
 	GPULayers     int           `json:"gpu_layers"`
 	Threads       int           `json:"threads"`
 	ServerHost    string        `json:"server_host"`
-	ServerPort    int           `json:"server_port"`
-	ServerTimeout time.Duration `json:"server_timeout"`
+	ServerPort    int           `json:"server_port"` // 0 selects a free port for a managed server
+	ServerTimeout int           `json:"server_timeout"` // Seconds the server waits on a client's read or write; 0 uses llama-server's default
+	// ServerBinary is the llama-server executable the provider starts and
+	// stops with the model. No process is managed when it is empty.
+	ServerBinary string   `json:"server_binary"`
+	ServerArgs   []string `json:"server_args"` // Appended to the generated arguments
+	// StopTimeout is how long the server has to exit before it is killed;
+	// DefaultServerStopTimeout when zero
+	StopTimeout time.Duration `json:"stop_timeout"`
 }
 
 // NewLlamaCPPProvider creates a new Llama.cpp provider
 func NewLlamaCPPProvider(config LlamaConfig) (*LlamaCPPProvider, error) {
+	contextSize, err := normalizeContextSize(config.ContextSize, config.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Llama.cpp config: %v", err)
+	}
+	config.ContextSize = contextSize
+	if _, err := timeoutSeconds("server timeout", config.ServerTimeout, 0); err != nil {
+		return nil, fmt.Errorf("invalid Llama.cpp config: %v", err)
+	}
+
 	provider := &LlamaCPPProvider{
 		config:    config,
 		isRunning: true,
 	}
+	if err := provider.startServer(); err != nil {
+		return nil, err
+	}
 
 	log.Printf("✅ Llama.cpp provider initialized with model: %s", config.ModelPath)
 	return provider, nil
 }
 
+// modelPathHint tells users how to fix a model path that cannot be used
+const modelPathHint = "download a GGUF model into the models directory or fix the model path in the config"
+
+// CheckModelPath expands ~ in a model path and checks that it names a
+// readable .gguf file, returning the expanded path
+func CheckModelPath(modelPath string) (string, error) {
+	expanded := paths.Expand(modelPath)
+	if expanded == "" {
+		return "", fmt.Errorf("no model path set: %s", modelPathHint)
+	}
+	if !strings.EqualFold(filepath.Ext(expanded), ".gguf") {
+		return "", fmt.Errorf("model file %s is not a .gguf file: %s", expanded, modelPathHint)
+	}
+
+	info, err := os.Stat(expanded)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("model file not found at %s: %s", expanded, modelPathHint)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot access model file %s: %v", expanded, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("model path %s is a directory, not a .gguf file", expanded)
+	}
+
+	file, err := os.Open(expanded)
+	if err != nil {
+		return "", fmt.Errorf("model file %s is not readable: %v", expanded, err)
+	}
+	file.Close()
+	return expanded, nil
+}
+
 // GetType returns the provider type
 func (p *LlamaCPPProvider) GetType() ProviderType {
 	return ProviderTypeLocal
@@ -49,9 +111,10 @@ func (p *LlamaCPPProvider) GetName() string {
 
 // GetModels returns available models
 func (p *LlamaCPPProvider) GetModels() []ModelInfo {
+	modelPath, _ := p.state()
 	return []ModelInfo{
 		{
-			Name:         p.config.ModelPath,
+			Name:         modelPath,
 			Provider:     ProviderTypeLocal,
 			ContextSize:  p.config.ContextSize,
 			Capabilities: []ModelCapability{CapabilityTextGeneration, CapabilityCodeGeneration, CapabilityCodeAnalysis},
@@ -78,14 +141,29 @@ func (p *LlamaCPPProvider) GetCapabilities() []ModelCapability {
 
 // Generate generates a response using Llama.cpp
 func (p *LlamaCPPProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
-	if !p.isRunning {
+	if _, running := p.state(); !running {
 		return nil, ErrProviderUnavailable
 	}
 
+	if err := NormalizeRequest(request); err != nil {
+		return nil, err
+	}
+	if err := p.checkModel(request.Model); err != nil {
+		return nil, err
+	}
+	if err := rejectImages(p.GetName(), request); err != nil {
+		return nil, err
+	}
+	logRequest(p.GetName(), request)
+
 	// Simulate processing time
-	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(100 * time.Millisecond):
+	}
 
-	return &LLMResponse{
+	response := &LLMResponse{
 		ID:        uuid.New(),
 		RequestID: request.ID,
 		Content:   "This is a simulated response from Llama.cpp provider",
@@ -96,15 +174,29 @@ func (p *LlamaCPPProvider) Generate(ctx context.Context, request *LLMRequest) (*
 		},
 		ProcessingTime: 100 * time.Millisecond,
 		CreatedAt:      time.Now(),
-	}, nil
+	}
+	logResponse(p.GetName(), response, response.ProcessingTime)
+
+	return response, nil
 }
 
 // GenerateStream generates a streaming response
 func (p *LlamaCPPProvider) GenerateStream(ctx context.Context, request *LLMRequest, ch chan<- LLMResponse) error {
-	if !p.isRunning {
+	if _, running := p.state(); !running {
 		return ErrProviderUnavailable
 	}
 
+	if err := NormalizeRequest(request); err != nil {
+		return err
+	}
+	if err := p.checkModel(request.Model); err != nil {
+		return err
+	}
+	if err := rejectImages(p.GetName(), request); err != nil {
+		return err
+	}
+	logRequest(p.GetName(), request)
+
 	// Simulate streaming response
 	chunks := []string{"This", " is", " a", " streaming", " response"}
 	for _, chunk := range chunks {
@@ -124,14 +216,27 @@ func (p *LlamaCPPProvider) GenerateStream(ctx context.Context, request *LLMReque
 	return nil
 }
 
+// SupportsStreaming reports whether GenerateStream is supported
+func (p *LlamaCPPProvider) SupportsStreaming() bool {
+	return true
+}
+
+// SupportsNativeTools reports whether tools are sent to the model natively.
+// The llama.cpp server is not asked for tool calls, so tools are prompt-based.
+func (p *LlamaCPPProvider) SupportsNativeTools() bool {
+	return false
+}
+
 // IsAvailable checks if the provider is available
 func (p *LlamaCPPProvider) IsAvailable(ctx context.Context) bool {
-	return p.isRunning
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isRunning && (p.server == nil || p.server.running())
 }
 
 // GetHealth returns provider health status
 func (p *LlamaCPPProvider) GetHealth(ctx context.Context) (*ProviderHealth, error) {
-	if !p.isRunning {
+	if _, running := p.state(); !running {
 		return &ProviderHealth{
 			Status:    "unhealthy",
 			LastCheck: time.Now(),
@@ -147,9 +252,141 @@ func (p *LlamaCPPProvider) GetHealth(ctx context.Context) (*ProviderHealth, erro
 	}, nil
 }
 
-// Close stops the Llama.cpp provider
+// Unload stops the Llama.cpp server, releasing the model's memory
+func (p *LlamaCPPProvider) Unload(ctx context.Context, model string) error {
+	p.stopServer()
+	p.setRunning(false)
+	log.Printf("✅ Llama.cpp model unloaded: %s", model)
+	return nil
+}
+
+// Load restarts the Llama.cpp server with the given model
+func (p *LlamaCPPProvider) Load(ctx context.Context, model string) error {
+	// A model that cannot be loaded leaves the running one in place
+	if model != "" && p.config.ServerBinary != "" {
+		if _, err := CheckModelPath(model); err != nil {
+			return err
+		}
+	}
+
+	p.stopServer()
+	if model != "" {
+		p.mu.Lock()
+		p.config.ModelPath = model
+		p.mu.Unlock()
+	}
+	if err := p.startServer(); err != nil {
+		return err
+	}
+	p.setRunning(true)
+	modelPath, _ := p.state()
+	log.Printf("✅ Llama.cpp model loaded: %s", modelPath)
+	return nil
+}
+
+// Close stops the Llama.cpp provider, terminating a managed server
 func (p *LlamaCPPProvider) Close() error {
-	p.isRunning = false
+	p.stopServer()
+	p.setRunning(false)
 	log.Println("✅ Llama.cpp provider closed")
 	return nil
-}
\ No newline at end of file
+}
+
+// startServer starts the managed llama-server, if one is configured, after
+// checking that the model file can be loaded
+func (p *LlamaCPPProvider) startServer() error {
+	if p.config.ServerBinary == "" {
+		return nil
+	}
+	p.mu.Lock()
+	args := p.config
+	p.mu.Unlock()
+
+	modelPath, err := CheckModelPath(args.ModelPath)
+	if err != nil {
+		return err
+	}
+	args.ModelPath = modelPath
+	p.mu.Lock()
+	p.config.ModelPath = modelPath
+	p.mu.Unlock()
+
+	host := args.ServerHost
+	if host == "" {
+		host = defaultServerHost
+	}
+	if args.ServerPort == 0 {
+		port, err := reservePort(host)
+		if err != nil {
+			return err
+		}
+		args.ServerPort = port
+	} else if err := checkPortFree(host, args.ServerPort); err != nil {
+		return fmt.Errorf("cannot start llama-server: %w", err)
+	}
+
+	server, err := startManagedServer(p.config.ServerBinary, args.serverArgs())
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ llama-server listening on %s:%d", host, args.ServerPort)
+	p.mu.Lock()
+	p.server = server
+	p.port = args.ServerPort
+	p.mu.Unlock()
+	return nil
+}
+
+// ServerPort returns the port of the managed llama-server, which is chosen
+// automatically when none is configured. Without a managed server it is the
+// configured port.
+func (p *LlamaCPPProvider) ServerPort() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.server != nil {
+		return p.port
+	}
+	return p.config.ServerPort
+}
+
+// stopServer stops the managed llama-server, if one is running, waiting for
+// it to exit so repeated loads never leave processes behind
+func (p *LlamaCPPProvider) stopServer() {
+	p.mu.Lock()
+	server := p.server
+	p.server = nil
+	p.mu.Unlock()
+
+	if server != nil {
+		server.stop(p.config.StopTimeout)
+	}
+}
+
+// state returns the loaded model's path and whether the provider is running
+func (p *LlamaCPPProvider) state() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config.ModelPath, p.isRunning
+}
+
+// setRunning records whether the provider can serve requests
+func (p *LlamaCPPProvider) setRunning(running bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.isRunning = running
+}
+
+// checkModel rejects a request for a model other than the one loaded. The
+// model may be named by its path, file name or file name without extension;
+// an empty name uses the loaded model.
+func (p *LlamaCPPProvider) checkModel(model string) error {
+	modelPath, _ := p.state()
+	if model == "" || model == modelPath {
+		return nil
+	}
+	base := filepath.Base(modelPath)
+	if model == base || model == strings.TrimSuffix(base, filepath.Ext(base)) {
+		return nil
+	}
+	return &ModelNotFoundError{Provider: p.GetType(), Model: model, Available: []string{modelPath}}
+}
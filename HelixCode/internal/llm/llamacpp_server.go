@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultServerStopTimeout is how long a managed llama-server has to exit
+// after SIGTERM before it is killed
+const DefaultServerStopTimeout = 5 * time.Second
+
+// ErrPortInUse is wrapped by errors for a configured server port that is
+// already bound, which llama-server would otherwise fail on after starting
+var ErrPortInUse = errors.New("port already in use")
+
+// defaultServerHost is where llama-server listens when no host is configured
+const defaultServerHost = "127.0.0.1"
+
+// reservePort finds a free port on host by binding port 0 and releasing it
+// for the managed server to bind
+func reservePort(host string) (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to select a port on %s: %v", host, err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// checkPortFree returns ErrPortInUse if port cannot be bound on host
+func checkPortFree(host string, port int) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("%w: %s:%d: %v", ErrPortInUse, host, port, err)
+	}
+	return listener.Close()
+}
+
+// managedServer is a llama-server process started by the provider. The
+// process is waited on as soon as it starts, so it never lingers as a zombie.
+type managedServer struct {
+	cmd  *exec.Cmd
+	done chan struct{} // Closed once the process has exited and been reaped
+	err  error         // Exit error, set before done is closed
+}
+
+// startManagedServer starts binary with args. The process is stopped with
+// the parent where the platform allows it, so a crashed CLI does not orphan it.
+func startManagedServer(binary string, args []string) (*managedServer, error) {
+	cmd := exec.Command(binary, args...)
+	stopWithParent(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %v", binary, err)
+	}
+
+	server := &managedServer{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		server.err = cmd.Wait()
+		close(server.done)
+	}()
+
+	log.Printf("✅ Started llama-server (pid %d)", cmd.Process.Pid)
+	return server, nil
+}
+
+// running reports whether the process has not exited yet
+func (s *managedServer) running() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// stop asks the process to exit with SIGTERM and kills it if it is still
+// running after grace. It returns once the process has been reaped.
+func (s *managedServer) stop(grace time.Duration) {
+	if !s.running() {
+		return
+	}
+	if grace <= 0 {
+		grace = DefaultServerStopTimeout
+	}
+
+	pid := s.cmd.Process.Pid
+	if err := s.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		// Not supported on every platform; fall through to the kill
+		grace = 0
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+		log.Printf("✅ Stopped llama-server (pid %d)", pid)
+		return
+	case <-timer.C:
+	}
+
+	log.Printf("⚠️ llama-server (pid %d) did not exit within %v, killing it", pid, grace)
+	s.cmd.Process.Kill()
+	<-s.done
+}
+
+// serverArgs returns the llama-server command line for the configuration
+func (c LlamaConfig) serverArgs() []string {
+	args := []string{"-m", c.ModelPath}
+	if c.ServerHost != "" {
+		args = append(args, "--host", c.ServerHost)
+	}
+	if c.ServerPort != 0 {
+		args = append(args, "--port", strconv.Itoa(c.ServerPort))
+	}
+	if c.ContextSize != 0 {
+		args = append(args, "-c", strconv.Itoa(c.ContextSize))
+	}
+	if c.Threads != 0 {
+		args = append(args, "-t", strconv.Itoa(c.Threads))
+	}
+	if c.GPUEnabled && c.GPULayers != 0 {
+		args = append(args, "-ngl", strconv.Itoa(c.GPULayers))
+	}
+	if c.ServerTimeout != 0 {
+		args = append(args, "--timeout", strconv.Itoa(c.ServerTimeout))
+	}
+	return append(args, c.ServerArgs...)
+}
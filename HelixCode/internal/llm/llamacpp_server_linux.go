@@ -0,0 +1,13 @@
+//go:build linux
+
+package llm
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopWithParent has the kernel send SIGTERM to the process when the CLI exits
+func stopWithParent(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package llm
+
+import "os/exec"
+
+// stopWithParent is a no-op where the platform cannot tie a process's
+// lifetime to its parent; Close still stops the server
+func stopWithParent(cmd *exec.Cmd) {}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeServerScript writes a shell script that stands in for llama-server,
+// running until it is signalled
+func writeServerScript(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("managed server tests use a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "llama-server")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return path
+}
+
+// writeModelFile writes an empty model file for a managed server to load
+func writeModelFile(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	return path
+}
+
+// requireExited checks that a managed server's process has been reaped
+func requireExited(t *testing.T, server *managedServer) {
+	t.Helper()
+	require.NotNil(t, server)
+	assert.False(t, server.running())
+	assert.NotNil(t, server.cmd.ProcessState, "process was not reaped")
+}
+
+func TestLlamaCPPProvider_CloseStopsServer(t *testing.T) {
+	binary := writeServerScript(t, "exec sleep 60")
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "model.gguf"), ServerBinary: binary, StopTimeout: 2 * time.Second})
+	require.NoError(t, err)
+
+	server := provider.server
+	require.NotNil(t, server)
+	assert.True(t, provider.IsAvailable(context.Background()))
+
+	start := time.Now()
+	require.NoError(t, provider.Close())
+	assert.Less(t, time.Since(start), 2*time.Second)
+	requireExited(t, server)
+	assert.False(t, provider.IsAvailable(context.Background()))
+}
+
+func TestLlamaCPPProvider_CloseKillsStubbornServer(t *testing.T) {
+	// The script ignores SIGTERM, so only the kill after the grace period stops it
+	binary := writeServerScript(t, "trap '' TERM\nwhile true; do sleep 0.1; done")
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "model.gguf"), ServerBinary: binary, StopTimeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+	server := provider.server
+
+	// Let the shell install its trap before signalling it
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, provider.Close())
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+	assert.Less(t, elapsed, 2*time.Second)
+	requireExited(t, server)
+}
+
+func TestLlamaCPPProvider_ReloadReapsServers(t *testing.T) {
+	binary := writeServerScript(t, "exec sleep 60")
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "first.gguf"), ServerBinary: binary, StopTimeout: 2 * time.Second})
+	require.NoError(t, err)
+	defer provider.Close()
+
+	var previous []*managedServer
+	for _, model := range []string{writeModelFile(t, "second.gguf"), writeModelFile(t, "third.gguf")} {
+		previous = append(previous, provider.server)
+		require.NoError(t, provider.Load(context.Background(), model))
+	}
+	require.NoError(t, provider.Unload(context.Background(), "third.gguf"))
+	assert.Nil(t, provider.server)
+
+	for _, server := range previous {
+		requireExited(t, server)
+	}
+}
+
+func TestCheckModelPath(t *testing.T) {
+	path := writeModelFile(t, "model.gguf")
+	checked, err := CheckModelPath(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, checked)
+
+	_, err = CheckModelPath(filepath.Join(t.TempDir(), "missing.gguf"))
+	assert.ErrorContains(t, err, "model file not found at")
+	assert.ErrorContains(t, err, "models directory")
+
+	_, err = CheckModelPath(writeModelFile(t, "model.bin"))
+	assert.ErrorContains(t, err, "is not a .gguf file")
+
+	_, err = CheckModelPath(t.TempDir() + ".gguf")
+	assert.Error(t, err)
+
+	unreadable := writeModelFile(t, "locked.gguf")
+	require.NoError(t, os.Chmod(unreadable, 0))
+	if file, err := os.Open(unreadable); err == nil {
+		file.Close()
+		t.Log("file permissions are not enforced for this user; skipping the unreadable case")
+	} else {
+		_, err = CheckModelPath(unreadable)
+		assert.ErrorContains(t, err, "is not readable")
+	}
+}
+
+func TestLlamaCPPProvider_MissingModelFile(t *testing.T) {
+	binary := writeServerScript(t, "exec sleep 60")
+	_, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: filepath.Join(t.TempDir(), "missing.gguf"), ServerBinary: binary})
+	assert.ErrorContains(t, err, "model file not found at")
+
+	// A failed load keeps the running model
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "model.gguf"), ServerBinary: binary, StopTimeout: 2 * time.Second})
+	require.NoError(t, err)
+	defer provider.Close()
+	server := provider.server
+
+	assert.Error(t, provider.Load(context.Background(), "missing.gguf"))
+	assert.Same(t, server, provider.server)
+	assert.True(t, provider.IsAvailable(context.Background()))
+}
+
+func TestLlamaConfig_ServerArgs(t *testing.T) {
+	config := LlamaConfig{
+		ModelPath:   "/models/llama.gguf",
+		ServerHost:  "127.0.0.1",
+		ServerPort:  8081,
+		ContextSize: 4096,
+		Threads:     8,
+		GPUEnabled:  true,
+		GPULayers:   35,
+		ServerArgs:  []string{"--flash-attn"},
+	}
+	assert.Equal(t, []string{
+		"-m", "/models/llama.gguf", "--host", "127.0.0.1", "--port", "8081",
+		"-c", "4096", "-t", "8", "-ngl", "35", "--flash-attn",
+	}, config.serverArgs())
+}
+
+func TestLlamaCPPProvider_AutoPort(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	binary := writeServerScript(t, `echo "$@" > `+argsFile+"\nexec sleep 60")
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "model.gguf"), ServerBinary: binary, StopTimeout: 2 * time.Second})
+	require.NoError(t, err)
+	defer provider.Close()
+
+	port := provider.ServerPort()
+	require.NotZero(t, port)
+	assert.Zero(t, provider.config.ServerPort, "the configured port stays automatic across reloads")
+
+	require.Eventually(t, func() bool {
+		args, err := os.ReadFile(argsFile)
+		return err == nil && strings.Contains(string(args), "--port "+strconv.Itoa(port))
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// The HTTP server cannot share the chosen port
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+	assert.ErrorIs(t, manager.CheckPortConflict(port), ErrPortConflict)
+	assert.NoError(t, manager.CheckPortConflict(port+1))
+}
+
+func TestLlamaCPPProvider_PortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	taken := listener.Addr().(*net.TCPAddr).Port
+
+	binary := writeServerScript(t, "exec sleep 60")
+	_, err = NewLlamaCPPProvider(LlamaConfig{ModelPath: writeModelFile(t, "model.gguf"), ServerBinary: binary, ServerHost: "127.0.0.1", ServerPort: taken})
+	assert.ErrorIs(t, err, ErrPortInUse)
+	assert.ErrorContains(t, err, strconv.Itoa(taken))
+}
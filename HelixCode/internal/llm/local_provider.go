@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"strings"
@@ -33,17 +32,17 @@ func NewLocalProvider(config ProviderConfigEntry) (*LocalProvider, error) {
 	provider := &LocalProvider{
 		config: config,
 		endpoint: endpoint,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: newProviderHTTPClient(config.HTTPClient),
 		lastHealth: &ProviderHealth{
 			Status:    "unknown",
 			LastCheck: time.Now(),
 		},
 	}
 
-	// Initialize models
-	if err := provider.initializeModels(); err != nil {
+	// Initialize models, bounded so an unreachable endpoint doesn't stall startup
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout(config, 10*time.Second))
+	defer cancel()
+	if err := provider.initializeModels(ctx); err != nil {
 		log.Printf("Warning: Failed to initialize local provider models: %v", err)
 	}
 
@@ -80,6 +79,11 @@ func (lp *LocalProvider) GetCapabilities() []ModelCapability {
 
 // Generate generates a response using local models
 func (lp *LocalProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	if err := NormalizeRequest(request); err != nil {
+		return nil, err
+	}
+
+	logRequest(lp.GetName(), request)
 	startTime := time.Now()
 
 	// Convert to Ollama-compatible format
@@ -91,11 +95,12 @@ func (lp *LocalProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 	// Make request to Ollama API
 	response, err := lp.makeOllamaRequest(ctx, ollamaRequest)
 	if err != nil {
-		return nil, fmt.Errorf("ollama request failed: %v", err)
+		return nil, fmt.Errorf("ollama request failed: %w", err)
 	}
 
 	// Convert response
 	llmResponse := lp.convertFromOllamaResponse(response, request.ID, time.Since(startTime))
+	logResponse(lp.GetName(), llmResponse, llmResponse.ProcessingTime)
 	
 	return llmResponse, nil
 }
@@ -104,6 +109,11 @@ func (lp *LocalProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 func (lp *LocalProvider) GenerateStream(ctx context.Context, request *LLMRequest, ch chan<- LLMResponse) error {
 	defer close(ch)
 
+	if err := NormalizeRequest(request); err != nil {
+		return err
+	}
+	logRequest(lp.GetName(), request)
+
 	// Convert to Ollama-compatible format
 	ollamaRequest, err := lp.convertToOllamaRequest(request)
 	if err != nil {
@@ -117,6 +127,17 @@ func (lp *LocalProvider) GenerateStream(ctx context.Context, request *LLMRequest
 	return lp.makeOllamaStreamRequest(ctx, ollamaRequest, ch, request.ID)
 }
 
+// SupportsStreaming reports whether GenerateStream is supported
+func (lp *LocalProvider) SupportsStreaming() bool {
+	return true
+}
+
+// SupportsNativeTools reports whether tools are sent to the model natively.
+// Requests do not include tool definitions, so tools are prompt-based.
+func (lp *LocalProvider) SupportsNativeTools() bool {
+	return false
+}
+
 // IsAvailable checks if the provider is available
 func (lp *LocalProvider) IsAvailable(ctx context.Context) bool {
 	health, err := lp.GetHealth(ctx)
@@ -138,7 +159,7 @@ func (lp *LocalProvider) GetHealth(ctx context.Context) (*ProviderHealth, error)
 
 	if err != nil {
 		lp.updateHealth("unhealthy", latency, lp.lastHealth.ErrorCount+1)
-		return lp.lastHealth, fmt.Errorf("health check failed: %v", err)
+		return lp.lastHealth, fmt.Errorf("health check failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -173,10 +194,7 @@ func (lp *LocalProvider) Close() error {
 
 // Helper methods
 
-func (lp *LocalProvider) initializeModels() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (lp *LocalProvider) initializeModels(ctx context.Context) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/tags", lp.endpoint), nil)
 	if err != nil {
 		return err
@@ -225,9 +243,11 @@ func (lp *LocalProvider) initializeModels() error {
 }
 
 func (lp *LocalProvider) convertToOllamaRequest(request *LLMRequest) (*OllamaRequest, error) {
-	// Build prompt from messages
+	// Build prompt from messages; the generate endpoint takes images separately
 	var prompt strings.Builder
+	var images [][]byte
 	for _, msg := range request.Messages {
+		images = append(images, msg.Images...)
 		switch msg.Role {
 		case "system":
 			prompt.WriteString(fmt.Sprintf("System: %s\n", msg.Content))
@@ -242,6 +262,7 @@ func (lp *LocalProvider) convertToOllamaRequest(request *LLMRequest) (*OllamaReq
 	return &OllamaRequest{
 		Model:  request.Model,
 		Prompt: prompt.String(),
+		Images: images,
 		Options: map[string]interface{}{
 			"temperature": request.Temperature,
 			"top_p":       request.TopP,
@@ -286,12 +307,11 @@ func (lp *LocalProvider) makeOllamaRequest(ctx context.Context, request *OllamaR
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError("ollama API", resp)
 	}
 
 	var response OllamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := decodeResponse(lp.GetName(), resp.Body, &response); err != nil {
 		return nil, err
 	}
 
@@ -317,16 +337,18 @@ func (lp *LocalProvider) makeOllamaStreamRequest(ctx context.Context, request *O
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
+		return newStatusError("ollama API", resp)
 	}
 
+	stop := closeOnCancel(ctx, resp.Body)
+	defer stop()
+
 	// Stream responses
 	decoder := json.NewDecoder(resp.Body)
 	for decoder.More() {
 		var streamResp OllamaStreamResponse
-		if err := decoder.Decode(&streamResp); err != nil {
-			return err
+		if err := decodeChunk(lp.GetName(), decoder, &streamResp); err != nil {
+			return streamError(ctx, err)
 		}
 
 		response := LLMResponse{
@@ -362,6 +384,7 @@ func (lp *LocalProvider) updateHealth(status string, latency time.Duration, erro
 type OllamaRequest struct {
 	Model   string                 `json:"model"`
 	Prompt  string                 `json:"prompt"`
+	Images  [][]byte               `json:"images,omitempty"` // Sent base64-encoded
 	Options map[string]interface{} `json:"options"`
 	Stream  bool                   `json:"stream"`
 }
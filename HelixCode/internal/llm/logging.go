@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLogTruncateLength is the default number of prompt/response characters logged
+const DefaultLogTruncateLength = 200
+
+var (
+	verboseLogging    atomic.Bool
+	logTruncateLength atomic.Int64
+)
+
+func init() {
+	logTruncateLength.Store(DefaultLogTruncateLength)
+}
+
+// secretPatterns match credentials that must never appear in logs
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-]+`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret|password)["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+}
+
+// SetVerboseLogging enables or disables provider request/response logging
+func SetVerboseLogging(enabled bool) {
+	verboseLogging.Store(enabled)
+}
+
+// VerboseLogging reports whether provider request/response logging is enabled
+func VerboseLogging() bool {
+	return verboseLogging.Load()
+}
+
+// SetLogTruncateLength sets how many prompt/response characters are logged
+func SetLogTruncateLength(length int) {
+	if length <= 0 {
+		length = DefaultLogTruncateLength
+	}
+	logTruncateLength.Store(int64(length))
+}
+
+// MaskSecret returns a masked form of a secret that reveals at most its last 4 characters
+func MaskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// RedactSecrets masks known secrets and credential-like patterns in text
+func RedactSecrets(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, MaskSecret(secret))
+		}
+	}
+
+	text = secretPatterns[0].ReplaceAllString(text, "sk-****")
+	for _, pattern := range secretPatterns[1:] {
+		text = pattern.ReplaceAllString(text, "${1}****")
+	}
+	return text
+}
+
+// truncateForLog shortens text to the given number of characters
+func truncateForLog(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return fmt.Sprintf("%s... (%d more chars)", string(runes[:limit]), len(runes)-limit)
+}
+
+// formatRequestLog describes an outgoing request with redacted, truncated prompt text
+func formatRequestLog(providerName string, request *LLMRequest, secrets ...string) string {
+	var prompt strings.Builder
+	for i, message := range request.Messages {
+		if i > 0 {
+			prompt.WriteString(" | ")
+		}
+		fmt.Fprintf(&prompt, "%s: %s", message.Role, message.Content)
+	}
+
+	text := truncateForLog(RedactSecrets(prompt.String(), secrets...), int(logTruncateLength.Load()))
+	return fmt.Sprintf("[%s] request %s model=%s messages=%d max_tokens=%d temperature=%.2f stream=%t tools=%d prompt=%q",
+		providerName, request.ID, request.Model, len(request.Messages), request.MaxTokens,
+		request.Temperature, request.Stream, len(request.Tools), text)
+}
+
+// formatResponseLog describes a response with redacted, truncated content
+func formatResponseLog(providerName string, response *LLMResponse, latency time.Duration, secrets ...string) string {
+	text := truncateForLog(RedactSecrets(response.Content, secrets...), int(logTruncateLength.Load()))
+	return fmt.Sprintf("[%s] response %s latency=%v prompt_tokens=%d completion_tokens=%d finish=%s tool_calls=%d content=%q",
+		providerName, response.RequestID, latency, response.Usage.PromptTokens, response.Usage.CompletionTokens,
+		response.FinishReason, len(response.ToolCalls), text)
+}
+
+// logRequest logs an outgoing request when verbose logging is enabled
+func logRequest(providerName string, request *LLMRequest, secrets ...string) {
+	if !verboseLogging.Load() {
+		return
+	}
+	log.Printf("🛰️ %s", formatRequestLog(providerName, request, secrets...))
+}
+
+// logResponse logs a provider response when verbose logging is enabled
+func logResponse(providerName string, response *LLMResponse, latency time.Duration, secrets ...string) {
+	if !verboseLogging.Load() || response == nil {
+		return
+	}
+	log.Printf("📨 %s", formatResponseLog(providerName, response, latency, secrets...))
+}
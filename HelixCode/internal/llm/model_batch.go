@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of batched requests run at once
+const DefaultBatchConcurrency = 4
+
+// GenerationRequest is a single request in a batch. It only adds batch
+// routing to an LLMRequest, which is what the provider receives.
+type GenerationRequest struct {
+	// Request is sent as it is; images for vision models go on its messages
+	Request *LLMRequest `json:"request"`
+	// ProviderType selects the provider, bypassing model selection in
+	// Generate. When empty, batches use the provider of the current model,
+	// falling back to the default provider.
+	ProviderType ProviderType `json:"provider_type,omitempty"`
+	// Criteria guides Generate's model selection when ProviderType is empty
+	Criteria ModelSelectionCriteria `json:"criteria"`
+	// AllowFallback lets Generate select another provider when ProviderType
+	// is unhealthy instead of failing
+	AllowFallback bool `json:"allow_fallback,omitempty"`
+	// ResponseFormat, when it asks for JSON, makes the response content a
+	// JSON value checked against its schema. See ParseStructuredOutput.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// GenerationResponse is the result of a single batched request, wrapping the
+// provider's LLMResponse
+type GenerationResponse struct {
+	Index    int           `json:"index"`
+	Response *LLMResponse  `json:"response,omitempty"`
+	Error    error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// NewGenerationRequest wraps an LLMRequest for a batch, routing it by the
+// request's own ProviderType
+func NewGenerationRequest(req *LLMRequest) GenerationRequest {
+	if req == nil {
+		return GenerationRequest{}
+	}
+	return GenerationRequest{Request: req, ProviderType: req.ProviderType}
+}
+
+// Result returns the wrapped response and error as Provider.Generate would
+func (r GenerationResponse) Result() (*LLMResponse, error) {
+	return r.Response, r.Error
+}
+
+// BatchStats reports aggregate timing for a batch
+type BatchStats struct {
+	Total       int           `json:"total"`
+	Succeeded   int           `json:"succeeded"`
+	Failed      int           `json:"failed"`
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	// RequestTime is the sum of the individual request durations; compared
+	// with Duration it shows the time saved over running serially
+	RequestTime time.Duration `json:"request_time"`
+}
+
+// GenerateBatch runs requests with DefaultBatchConcurrency. See GenerateBatchWithStats.
+func (m *ModelManager) GenerateBatch(ctx context.Context, reqs []GenerationRequest) ([]GenerationResponse, error) {
+	responses, _, err := m.GenerateBatchWithStats(ctx, reqs, DefaultBatchConcurrency)
+	return responses, err
+}
+
+// GenerateBatchWithStats runs requests with at most concurrency in flight.
+// Results are in input order and a failed request records its error without
+// stopping the rest of the batch. An error is returned only if ctx ends
+// before the batch completes.
+func (m *ModelManager) GenerateBatchWithStats(ctx context.Context, reqs []GenerationRequest, concurrency int) ([]GenerationResponse, *BatchStats, error) {
+	return m.GenerateBatchWithProgress(ctx, reqs, concurrency, nil)
+}
+
+// GenerateBatchWithProgress is GenerateBatchWithStats reporting each finished
+// request on progress, which is closed when the batch returns. progress may be nil.
+func (m *ModelManager) GenerateBatchWithProgress(ctx context.Context, reqs []GenerationRequest, concurrency int, progress chan<- Progress) (responses []GenerationResponse, stats *BatchStats, err error) {
+	reporter := newProgressReporter(ctx, progress, "batch", int64(len(reqs)))
+	defer func() { reporter.finish(err) }()
+
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > len(reqs) {
+		concurrency = len(reqs)
+	}
+
+	start := time.Now()
+	responses = make([]GenerationResponse, len(reqs))
+	stats = &BatchStats{Total: len(reqs), Concurrency: concurrency}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				responses[i] = m.generateBatchItem(ctx, i, reqs[i])
+				reporter.add(1, fmt.Sprintf("request %d finished", i))
+			}
+		}()
+	}
+
+dispatch:
+	for i := range reqs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			for j := i; j < len(reqs); j++ {
+				responses[j] = GenerationResponse{Index: j, Error: ctx.Err()}
+			}
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, resp := range responses {
+		if resp.Error != nil {
+			stats.Failed++
+		} else {
+			stats.Succeeded++
+		}
+		stats.RequestTime += resp.Duration
+	}
+	stats.Duration = time.Since(start)
+
+	log.Printf("✅ Batch of %d requests completed in %v (%d failed, concurrency %d)",
+		stats.Total, stats.Duration, stats.Failed, stats.Concurrency)
+
+	if err := ctx.Err(); err != nil {
+		return responses, stats, fmt.Errorf("batch interrupted: %w", err)
+	}
+	return responses, stats, nil
+}
+
+// generateBatchItem runs one batched request against its provider
+func (m *ModelManager) generateBatchItem(ctx context.Context, index int, req GenerationRequest) GenerationResponse {
+	start := time.Now()
+	result := GenerationResponse{Index: index}
+
+	if req.Request == nil {
+		result.Error = fmt.Errorf("%w: request %d is nil", ErrInvalidRequest, index)
+		return result
+	}
+
+	provider, model, err := m.batchProvider(req.ProviderType)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	llmReq := *req.Request
+	if llmReq.Model == "" {
+		llmReq.Model = model
+	}
+
+	result.Response, result.Error = generateWithFormat(ctx, m.retrying(provider), &llmReq, req.ResponseFormat)
+	result.Duration = time.Since(start)
+	return result
+}
+
+// batchProvider resolves the provider for a batched request and the model to
+// use when the request does not name one
+func (m *ModelManager) batchProvider(providerType ProviderType) (Provider, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	model := ""
+	if m.currentModel != nil {
+		if providerType == "" {
+			providerType = m.currentModel.Provider
+		}
+		if providerType == m.currentModel.Provider {
+			model = m.currentModel.Name
+		}
+	}
+	if providerType == "" {
+		providerType = m.defaultProvider
+	}
+
+	provider, exists := m.providers[providerType]
+	if !exists {
+		return nil, "", fmt.Errorf("provider %s not available", providerType)
+	}
+	return provider, model, nil
+}
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchTestProvider echoes prompts after a delay and tracks how many requests run at once
+type batchTestProvider struct {
+	*MockProvider
+	inFlight    int32
+	maxInFlight int32
+	mu          sync.Mutex
+	models      []string
+}
+
+func newBatchTestProvider() *batchTestProvider {
+	provider := &batchTestProvider{MockProvider: new(MockProvider)}
+	provider.On("GetType").Return(ProviderType("batch"))
+	provider.On("GetName").Return("batch")
+	provider.On("GetModels").Return([]ModelInfo{{Name: "batch-model", Provider: "batch"}})
+	return provider
+}
+
+func (p *batchTestProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	current := atomic.AddInt32(&p.inFlight, 1)
+	defer atomic.AddInt32(&p.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&p.maxInFlight)
+		if current <= max || atomic.CompareAndSwapInt32(&p.maxInFlight, max, current) {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	p.models = append(p.models, req.Model)
+	p.mu.Unlock()
+
+	prompt := req.Messages[0].Content
+	// Later prompts finish first so ordering depends on the index, not completion
+	time.Sleep(time.Duration(20-len(prompt)) * time.Millisecond)
+	if prompt == "fail" {
+		return nil, errors.New("generation failed")
+	}
+	return &LLMResponse{Content: "echo: " + prompt}, nil
+}
+
+func batchRequests(prompts ...string) []GenerationRequest {
+	reqs := make([]GenerationRequest, len(prompts))
+	for i, prompt := range prompts {
+		reqs[i] = GenerationRequest{
+			Request:      &LLMRequest{Messages: []Message{{Role: "user", Content: prompt}}},
+			ProviderType: "batch",
+		}
+	}
+	return reqs
+}
+
+// TestModelManager_GenerateBatch tests ordering, partial failures and the concurrency limit
+func TestModelManager_GenerateBatch(t *testing.T) {
+	manager := NewModelManager()
+	provider := newBatchTestProvider()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	var prompts []string
+	for i := 0; i < 10; i++ {
+		prompts = append(prompts, fmt.Sprintf("p%d", i))
+	}
+	prompts[3] = "fail"
+	reqs := batchRequests(prompts...)
+	reqs = append(reqs, GenerationRequest{ProviderType: "batch"}, GenerationRequest{
+		Request:      &LLMRequest{Messages: []Message{{Role: "user", Content: "x"}}},
+		ProviderType: "missing",
+	})
+
+	responses, stats, err := manager.GenerateBatchWithStats(context.Background(), reqs, 3)
+	require.NoError(t, err)
+	require.Len(t, responses, len(reqs))
+
+	for i, prompt := range prompts {
+		assert.Equal(t, i, responses[i].Index)
+		if prompt == "fail" {
+			assert.Error(t, responses[i].Error)
+			assert.Nil(t, responses[i].Response)
+			continue
+		}
+		require.NoError(t, responses[i].Error)
+		assert.Equal(t, "echo: "+prompt, responses[i].Response.Content)
+		assert.Greater(t, responses[i].Duration, time.Duration(0))
+	}
+	assert.ErrorIs(t, responses[10].Error, ErrInvalidRequest)
+	assert.Error(t, responses[11].Error)
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&provider.maxInFlight), int32(3))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.maxInFlight))
+	assert.Equal(t, 12, stats.Total)
+	assert.Equal(t, 9, stats.Succeeded)
+	assert.Equal(t, 3, stats.Failed)
+	assert.Equal(t, 3, stats.Concurrency)
+	assert.Greater(t, stats.RequestTime, stats.Duration)
+}
+
+// TestModelManager_GenerateBatchCurrentModel tests that the current model fills in missing models
+func TestModelManager_GenerateBatchCurrentModel(t *testing.T) {
+	manager := NewModelManager()
+	provider := newBatchTestProvider()
+	require.NoError(t, manager.RegisterProvider(provider))
+	_, err := manager.SwitchModel(context.Background(), "batch-model", "batch")
+	require.NoError(t, err)
+
+	reqs := batchRequests("a", "b")
+	reqs[0].ProviderType = ""
+	reqs[1].Request.Model = "explicit"
+
+	responses, err := manager.GenerateBatch(context.Background(), reqs)
+	require.NoError(t, err)
+	for _, resp := range responses {
+		assert.NoError(t, resp.Error)
+	}
+	assert.ElementsMatch(t, []string{"batch-model", "explicit"}, provider.models)
+}
+
+// TestModelManager_GenerateBatchCancelled tests that a cancelled context ends the batch
+func TestModelManager_GenerateBatchCancelled(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newBatchTestProvider()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	responses, err := manager.GenerateBatch(ctx, batchRequests("a", "b", "c"))
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, responses, 3)
+
+	responses, err = manager.GenerateBatch(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, responses)
+}
+
+// TestGenerationRequest_SharesProviderTypes tests that a batch request reaches a
+// tool-calling provider and its mock base as the same LLMRequest type
+func TestGenerationRequest_SharesProviderTypes(t *testing.T) {
+	base := newBatchTestProvider()
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(NewToolCallingProvider(base)))
+
+	req := &LLMRequest{ProviderType: "batch", Model: "batch-model", Messages: []Message{{Role: "user", Content: "hi"}}}
+	responses, err := manager.GenerateBatch(context.Background(), []GenerationRequest{NewGenerationRequest(req)})
+	require.NoError(t, err)
+	require.Len(t, responses, 1)
+
+	response, err := responses[0].Result()
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hi", response.Content)
+	assert.Equal(t, []string{"batch-model"}, base.models)
+
+	assert.Equal(t, GenerationRequest{}, NewGenerationRequest(nil))
+}
@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActiveGeneration describes a tracked generation that is still running
+type ActiveGeneration struct {
+	ID        uuid.UUID    `json:"id"`
+	Model     string       `json:"model"`
+	Provider  ProviderType `json:"provider"`
+	StartedAt time.Time    `json:"started_at"`
+}
+
+// TrackedGeneration is a generation started with StartGeneration. It can be
+// cancelled by ID through the manager until it finishes.
+type TrackedGeneration struct {
+	ActiveGeneration
+	cancel   context.CancelFunc
+	done     chan struct{}
+	response *LLMResponse
+	err      error
+}
+
+// Done is closed when the generation finishes
+func (g *TrackedGeneration) Done() <-chan struct{} {
+	return g.done
+}
+
+// Wait blocks until the generation finishes and returns its result. A
+// cancelled generation returns the provider's cancellation error.
+func (g *TrackedGeneration) Wait() (*LLMResponse, error) {
+	<-g.done
+	return g.response, g.err
+}
+
+// Generate runs a request on the provider named by req.ProviderType, or on
+// the model chosen by SelectOptimalModel for req.Criteria when none is named.
+// A named provider that is not registered is an error, as is one that is
+// unhealthy unless req.AllowFallback is set, in which case a model is selected
+// from the other providers. A JSON req.ResponseFormat is enforced as described
+// for generateWithFormat.
+func (m *ModelManager) Generate(ctx context.Context, req GenerationRequest) (*LLMResponse, error) {
+	if req.Request == nil {
+		return nil, fmt.Errorf("%w: request is nil", ErrInvalidRequest)
+	}
+
+	provider, model, err := m.overrideProvider(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		provider, model, err = m.selectedProvider(req.Criteria, req.ProviderType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	llmReq := *req.Request
+	if llmReq.Model == "" {
+		llmReq.Model = model
+	}
+	return generateWithFormat(ctx, m.retrying(provider), &llmReq, req.ResponseFormat)
+}
+
+// overrideProvider returns the provider named by a request and the model to
+// use when the request names none. It returns a nil provider when the request
+// names no provider, or names an unhealthy one and allows fallback.
+func (m *ModelManager) overrideProvider(ctx context.Context, req GenerationRequest) (Provider, string, error) {
+	if req.ProviderType == "" {
+		return nil, "", nil
+	}
+
+	provider, model, err := m.batchProvider(req.ProviderType)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+
+	health, err := m.ProviderHealth(ctx, req.ProviderType, false)
+	if err == nil && health != nil && health.Status == "unhealthy" {
+		err = fmt.Errorf("provider %s is unhealthy", req.ProviderType)
+	}
+	if err != nil {
+		if !req.AllowFallback {
+			return nil, "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+		}
+		log.Printf("⚠️ Provider %s unavailable, selecting another: %v", req.ProviderType, err)
+		return nil, "", nil
+	}
+
+	return provider, model, nil
+}
+
+// selectedProvider returns the provider and model chosen for criteria from
+// the providers other than excluded
+func (m *ModelManager) selectedProvider(criteria ModelSelectionCriteria, excluded ProviderType) (Provider, string, error) {
+	best, err := m.recommendModel(criteria, excluded)
+	if err != nil {
+		return nil, "", err
+	}
+	model := best.Model
+	log.Printf("🎯 Selected model: %s (score: %.2f, reason: %s)", model.Name, best.Score, best.Reason)
+
+	m.mu.RLock()
+	provider, exists := m.providers[model.Provider]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, "", fmt.Errorf("%w: provider %s of model %s", ErrProviderUnavailable, model.Provider, model.Name)
+	}
+	return provider, model.Name, nil
+}
+
+// StartGeneration runs a request in the background and returns at once with a
+// handle whose ID can be passed to CancelGeneration. The provider is chosen as
+// for GenerateBatch. The request's ID is used as the generation ID, and set
+// when empty.
+func (m *ModelManager) StartGeneration(ctx context.Context, providerType ProviderType, req *LLMRequest) (*TrackedGeneration, error) {
+	if req == nil {
+		return nil, fmt.Errorf("%w: request is nil", ErrInvalidRequest)
+	}
+
+	provider, model, err := m.batchProvider(providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	llmReq := *req
+	if llmReq.Model == "" {
+		llmReq.Model = model
+	}
+	if llmReq.ID == uuid.Nil {
+		llmReq.ID = uuid.New()
+	}
+
+	retrying := m.retrying(provider)
+	ctx, cancel := context.WithCancel(ctx)
+	generation := &TrackedGeneration{
+		ActiveGeneration: ActiveGeneration{
+			ID:        llmReq.ID,
+			Model:     llmReq.Model,
+			Provider:  provider.GetType(),
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	if _, exists := m.generations[generation.ID]; exists {
+		m.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("%w: generation %s is already running", ErrInvalidRequest, generation.ID)
+	}
+	if m.generations == nil {
+		m.generations = make(map[uuid.UUID]*TrackedGeneration)
+	}
+	m.generations[generation.ID] = generation
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		generation.response, generation.err = retrying.Generate(ctx, &llmReq)
+
+		m.mu.Lock()
+		delete(m.generations, generation.ID)
+		m.mu.Unlock()
+		close(generation.done)
+	}()
+
+	return generation, nil
+}
+
+// CancelGeneration cancels a tracked generation by ID. It returns
+// ErrGenerationNotFound if no such generation is running.
+func (m *ModelManager) CancelGeneration(id uuid.UUID) error {
+	m.mu.RLock()
+	generation, exists := m.generations[id]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrGenerationNotFound, id)
+	}
+
+	generation.cancel()
+	return nil
+}
+
+// ListActiveGenerations returns the running tracked generations, oldest first
+func (m *ModelManager) ListActiveGenerations() []ActiveGeneration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	active := make([]ActiveGeneration, 0, len(m.generations))
+	for _, generation := range m.generations {
+		active = append(active, generation.ActiveGeneration)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+	return active
+}
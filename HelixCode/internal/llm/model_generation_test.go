@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingProvider answers immediately when the prompt is "quick" and
+// otherwise blocks until the request is cancelled
+type blockingProvider struct {
+	*MockProvider
+	started chan string
+}
+
+func newBlockingProvider() *blockingProvider {
+	provider := &blockingProvider{MockProvider: new(MockProvider), started: make(chan string, 10)}
+	provider.On("GetType").Return(ProviderType("blocking"))
+	provider.On("GetName").Return("blocking")
+	provider.On("GetModels").Return([]ModelInfo{{Name: "blocking-model", Provider: "blocking"}})
+	return provider
+}
+
+func (p *blockingProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	prompt := req.Messages[0].Content
+	p.started <- prompt
+	if prompt == "quick" {
+		return &LLMResponse{Content: "done"}, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestModelManager_CancelGeneration(t *testing.T) {
+	manager := NewModelManager()
+	provider := newBlockingProvider()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	request := func(prompt string) *LLMRequest {
+		return &LLMRequest{Model: "blocking-model", Messages: []Message{{Role: "user", Content: prompt}}}
+	}
+
+	slow, err := manager.StartGeneration(context.Background(), "blocking", request("slow"))
+	require.NoError(t, err)
+	other, err := manager.StartGeneration(context.Background(), "blocking", request("other"))
+	require.NoError(t, err)
+	<-provider.started
+	<-provider.started
+
+	active := manager.ListActiveGenerations()
+	require.Len(t, active, 2)
+	assert.ElementsMatch(t, []uuid.UUID{slow.ID, other.ID}, []uuid.UUID{active[0].ID, active[1].ID})
+	assert.Equal(t, "blocking-model", active[0].Model)
+	assert.Equal(t, ProviderType("blocking"), active[0].Provider)
+
+	// Cancelling one generation leaves the other running
+	require.NoError(t, manager.CancelGeneration(slow.ID))
+	_, err = slow.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	active = manager.ListActiveGenerations()
+	require.Len(t, active, 1)
+	assert.Equal(t, other.ID, active[0].ID)
+
+	assert.ErrorIs(t, manager.CancelGeneration(slow.ID), ErrGenerationNotFound)
+	require.NoError(t, manager.CancelGeneration(other.ID))
+	select {
+	case <-other.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Cancelled generation did not finish")
+	}
+	assert.Empty(t, manager.ListActiveGenerations())
+}
+
+func TestModelManager_StartGeneration(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newBlockingProvider()))
+
+	id := uuid.New()
+	req := &LLMRequest{ID: id, Messages: []Message{{Role: "user", Content: "quick"}}}
+	generation, err := manager.StartGeneration(context.Background(), "blocking", req)
+	require.NoError(t, err)
+	assert.Equal(t, id, generation.ID)
+
+	response, err := generation.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, "done", response.Content)
+	assert.ErrorIs(t, manager.CancelGeneration(id), ErrGenerationNotFound)
+
+	_, err = manager.StartGeneration(context.Background(), "blocking", nil)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	_, err = manager.StartGeneration(context.Background(), "missing", req)
+	assert.Error(t, err)
+}
+
+// newRoutingProvider returns a mock provider with one model that answers with
+// the provider's name
+func newRoutingProvider(name string, status string, capabilities ...ModelCapability) *MockProvider {
+	provider := new(MockProvider)
+	provider.On("GetType").Return(ProviderType(name))
+	provider.On("GetName").Return(name)
+	provider.On("GetModels").Return([]ModelInfo{{Name: name + "-model", ContextSize: 8192, Capabilities: capabilities}})
+	provider.On("IsAvailable", mock.Anything).Return(true).Maybe()
+	provider.On("GetHealth", mock.Anything).Return(&ProviderHealth{Status: status}, nil).Maybe()
+	provider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{Content: name}, nil).Maybe()
+	return provider
+}
+
+// requestedModel checks that provider was asked to generate with model
+func requestedModel(t *testing.T, provider *MockProvider, model string) {
+	t.Helper()
+	provider.AssertCalled(t, "Generate", mock.Anything, mock.MatchedBy(func(req *LLMRequest) bool {
+		return req.Model == model
+	}))
+}
+
+func TestModelManager_GenerateProviderOverride(t *testing.T) {
+	manager := NewModelManager()
+	coder := newRoutingProvider("coder", "healthy", CapabilityCodeGeneration, CapabilityTextGeneration)
+	writer := newRoutingProvider("writer", "healthy", CapabilityTextGeneration)
+	require.NoError(t, manager.RegisterProvider(coder))
+	require.NoError(t, manager.RegisterProvider(writer))
+
+	request := GenerationRequest{
+		Request:  &LLMRequest{Messages: []Message{{Role: "user", Content: "write a function"}}},
+		Criteria: ModelSelectionCriteria{MaxTokens: 1024, RequiredCapabilities: []ModelCapability{CapabilityCodeGeneration}},
+	}
+
+	// Without an override the selected model answers
+	response, err := manager.Generate(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "coder", response.Content)
+	requestedModel(t, coder, "coder-model")
+
+	// The override bypasses selection
+	request.ProviderType = "writer"
+	response, err = manager.Generate(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "writer", response.Content)
+	writer.AssertNumberOfCalls(t, "Generate", 1)
+
+	request.ProviderType = "missing"
+	_, err = manager.Generate(context.Background(), request)
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestModelManager_GenerateUnhealthyOverride(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newRoutingProvider("down", "unhealthy", CapabilityTextGeneration)))
+	up := newRoutingProvider("up", "healthy", CapabilityTextGeneration)
+	require.NoError(t, manager.RegisterProvider(up))
+
+	request := GenerationRequest{
+		Request:      &LLMRequest{Messages: []Message{{Role: "user", Content: "hello"}}},
+		ProviderType: "down",
+		Criteria:     ModelSelectionCriteria{MaxTokens: 1024},
+	}
+	_, err := manager.Generate(context.Background(), request)
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	assert.ErrorContains(t, err, "unhealthy")
+
+	request.AllowFallback = true
+	response, err := manager.Generate(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "up", response.Content)
+	requestedModel(t, up, "up-model")
+}
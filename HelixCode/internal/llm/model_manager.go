@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"dev.helix.code/internal/clock"
+	"dev.helix.code/internal/config"
 	"dev.helix.code/internal/hardware"
+	"github.com/google/uuid"
 )
 
 // ModelManager manages LLM models and their selection
@@ -17,7 +21,48 @@ type ModelManager struct {
 	hardwareDetector *hardware.Detector
 	providers        map[ProviderType]Provider
 	modelRegistry    map[string]*ModelInfo
+	defaultProvider  ProviderType
+	currentModel     *ModelInfo
+	preferences      *ModelPreferences
+	capabilityPolicy CapabilityPolicy
+	generations      map[uuid.UUID]*TrackedGeneration
+	retryPolicy      RetryPolicy
 	mu               sync.RWMutex
+	switchMu         sync.Mutex // Serializes SwitchModel, which unloads and loads without holding mu
+
+	healthTTL   time.Duration
+	healthCache map[ProviderType]healthSnapshot
+	healthClock clock.Clock
+	healthMu    sync.Mutex
+}
+
+// CapabilityPolicy decides how models registered without capabilities are
+// handled, since capability-based selection cannot see them
+type CapabilityPolicy string
+
+const (
+	// CapabilityPolicyInfer assumes text generation for models without capabilities
+	CapabilityPolicyInfer CapabilityPolicy = "infer"
+	// CapabilityPolicyReject refuses providers with models without capabilities
+	CapabilityPolicyReject CapabilityPolicy = "reject"
+)
+
+// DefaultHealthCacheTTL is how long a provider health check is reused before
+// the provider is probed again
+const DefaultHealthCacheTTL = 5 * time.Second
+
+// healthSnapshot is the cached outcome of one provider health check
+type healthSnapshot struct {
+	health    *ProviderHealth
+	err       error
+	checkedAt time.Time
+}
+
+// ProviderInitResult reports the outcome of initializing providers from configuration
+type ProviderInitResult struct {
+	Registered      []string
+	Failed          map[string]error
+	DefaultProvider ProviderType
 }
 
 // ModelSelectionCriteria defines criteria for model selection
@@ -44,6 +89,12 @@ func NewModelManager() *ModelManager {
 		hardwareDetector: hardware.NewDetector(),
 		providers:        make(map[ProviderType]Provider),
 		modelRegistry:    make(map[string]*ModelInfo),
+		generations:      make(map[uuid.UUID]*TrackedGeneration),
+		retryPolicy:      RetryPolicy{MaxRetries: DefaultMaxRetries},
+		capabilityPolicy: CapabilityPolicyInfer,
+		healthTTL:        DefaultHealthCacheTTL,
+		healthCache:      make(map[ProviderType]healthSnapshot),
+		healthClock:      clock.New(),
 	}
 }
 
@@ -57,10 +108,24 @@ func (m *ModelManager) RegisterProvider(provider Provider) error {
 		return fmt.Errorf("provider %s already registered", providerType)
 	}
 
+	// Copied so that filled-in fields do not change the provider's own list
+	models := append([]ModelInfo(nil), provider.GetModels()...)
+	for i := range models {
+		if models[i].Provider == "" {
+			models[i].Provider = providerType
+		}
+		if len(models[i].Capabilities) > 0 {
+			continue
+		}
+		if m.capabilityPolicy == CapabilityPolicyReject {
+			return fmt.Errorf("model %s of provider %s: %w", models[i].Name, providerType, ErrNoCapabilities)
+		}
+		models[i].Capabilities = []ModelCapability{CapabilityTextGeneration}
+	}
+
 	m.providers[providerType] = provider
 
 	// Register provider's models
-	models := provider.GetModels()
 	for i := range models {
 		model := &models[i]
 		modelKey := m.getModelKey(providerType, model.Name)
@@ -71,33 +136,197 @@ func (m *ModelManager) RegisterProvider(provider Provider) error {
 	return nil
 }
 
-// SelectOptimalModel selects the best model for given criteria
+// InitFromConfig constructs the configured providers through the provider registry,
+// registers the healthy ones and sets the default provider to the first healthy one in
+// the configured order. Providers that fail to initialize are reported in the result
+// without aborting the others. The configured retry policy replaces the current one.
+func (m *ModelManager) InitFromConfig(cfg config.LLMConfig) (*ProviderInitResult, error) {
+	result := &ProviderInitResult{
+		Failed: make(map[string]error),
+	}
+	m.SetRetryPolicy(RetryPolicyFromConfig(cfg))
+
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	registeredTypes := make(map[string]ProviderType)
+	for _, name := range names {
+		entry := ProviderConfigEntry{
+			Type:     ProviderType(name),
+			Endpoint: cfg.Providers[name],
+			APIKey:   os.Getenv(strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_API_KEY"),
+			Enabled:  true,
+		}
+
+		provider, err := NewProviderByName(name, entry)
+		if err != nil {
+			result.Failed[name] = err
+			log.Printf("⚠️ Failed to initialize LLM provider %s: %v", name, err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		available := provider.IsAvailable(ctx)
+		cancel()
+		if !available {
+			provider.Close()
+			result.Failed[name] = ErrProviderUnavailable
+			log.Printf("⚠️ LLM provider %s is not available", name)
+			continue
+		}
+
+		if err := m.RegisterProvider(provider); err != nil {
+			provider.Close()
+			result.Failed[name] = err
+			log.Printf("⚠️ Failed to register LLM provider %s: %v", name, err)
+			continue
+		}
+
+		registeredTypes[name] = provider.GetType()
+		result.Registered = append(result.Registered, name)
+	}
+
+	if len(result.Registered) == 0 {
+		if len(names) == 0 {
+			return result, fmt.Errorf("no LLM providers configured")
+		}
+		return result, fmt.Errorf("no LLM providers could be initialized (%d failed)", len(result.Failed))
+	}
+
+	defaultName := resolveDefaultProvider(cfg.DefaultProvider, registeredTypes)
+	if defaultName == "" {
+		defaultName = result.Registered[0]
+		if len(cfg.DefaultProvider) > 0 {
+			log.Printf("⚠️ Default LLM providers %s unavailable, using %s", strings.Join(cfg.DefaultProvider, ", "), defaultName)
+		}
+	}
+	defaultType := registeredTypes[defaultName]
+
+	m.mu.Lock()
+	m.defaultProvider = defaultType
+	m.mu.Unlock()
+	result.DefaultProvider = defaultType
+
+	log.Printf("✅ Initialized %d LLM providers (default: %s)", len(result.Registered), defaultType)
+	return result, nil
+}
+
+// resolveDefaultProvider returns the first provider in order that was
+// registered, logging the ones skipped, or an empty string when none was
+func resolveDefaultProvider(order []string, registered map[string]ProviderType) string {
+	for _, name := range order {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := registered[name]; ok {
+			log.Printf("🎯 Default LLM provider: %s", name)
+			return name
+		}
+		log.Printf("⚠️ Default LLM provider %s unavailable, trying the next one", name)
+	}
+	return ""
+}
+
+// GetDefaultProvider returns the default provider
+func (m *ModelManager) GetDefaultProvider() (Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	provider, exists := m.providers[m.defaultProvider]
+	if !exists {
+		return nil, fmt.Errorf("no default provider configured: %w", ErrProviderUnavailable)
+	}
+
+	return provider, nil
+}
+
+// SelectOptimalModel selects the best model for given criteria. It returns
+// either a model or an error, never neither; an error wrapping
+// ErrNoModelSelected means no model matched.
 func (m *ModelManager) SelectOptimalModel(criteria ModelSelectionCriteria) (*ModelInfo, error) {
+	bestModel, err := m.RecommendModel(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎯 Selected model: %s (score: %.2f, reason: %s)", 
+		bestModel.Model.Name, bestModel.Score, bestModel.Reason)
+
+	return bestModel.Model, nil
+}
+
+// RecommendModel scores the available models for given criteria and returns
+// the best one with the reason for its score. A valid preference for the task
+// type wins over scoring; an invalid one is logged and ignored. When no model
+// matches, the error wraps ErrNoModelSelected; the score is never nil without
+// an error.
+func (m *ModelManager) RecommendModel(criteria ModelSelectionCriteria) (*ModelScore, error) {
+	return m.recommendModel(criteria, "")
+}
+
+// recommendModel is RecommendModel leaving out the models of the excluded provider
+func (m *ModelManager) recommendModel(criteria ModelSelectionCriteria, excluded ProviderType) (*ModelScore, error) {
+	best, err := m.scoreBestModel(criteria, excluded)
+	if err != nil {
+		return nil, err
+	}
+	if best == nil || best.Model == nil {
+		return nil, fmt.Errorf("%w: model selection returned no model", ErrNoModelSelected)
+	}
+	return best, nil
+}
+
+// scoreBestModel returns the preferred or highest scoring model for criteria
+// from the providers other than excluded
+func (m *ModelManager) scoreBestModel(criteria ModelSelectionCriteria, excluded ProviderType) (*ModelScore, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if m.preferences != nil && criteria.TaskType != "" {
+		if pref, ok := m.preferences.Get(criteria.TaskType); ok {
+			model, err := m.preferredModel(pref, criteria)
+			if err == nil && excluded != "" && model.Provider == excluded {
+				err = fmt.Errorf("provider %s is excluded", excluded)
+			}
+			if err == nil {
+				score := m.calculateModelScore(model, criteria)
+				score.Reason = fmt.Sprintf("preferred for %s tasks", criteria.TaskType)
+				return &score, nil
+			}
+			log.Printf("⚠️ Ignoring model preference for %s tasks: %v", criteria.TaskType, err)
+		}
+	}
+
 	// Get available models
-	availableModels := m.getAvailableModels()
+	var availableModels []*ModelInfo
+	for _, model := range m.getAvailableModels() {
+		if model != nil && (excluded == "" || model.Provider != excluded) {
+			availableModels = append(availableModels, model)
+		}
+	}
 	if len(availableModels) == 0 {
-		return nil, fmt.Errorf("no models available")
+		return nil, fmt.Errorf("%w: no models available", ErrNoModelSelected)
 	}
 
 	// Score models based on criteria
 	scoredModels := m.scoreModels(availableModels, criteria)
 	if len(scoredModels) == 0 {
-		return nil, fmt.Errorf("no suitable models found for criteria")
+		return nil, fmt.Errorf("%w: no suitable models found for criteria", ErrNoModelSelected)
 	}
 
-	// Sort by score (descending)
+	// Sort by score (descending), by name among equals for a stable choice
 	sort.Slice(scoredModels, func(i, j int) bool {
-		return scoredModels[i].Score > scoredModels[j].Score
+		if scoredModels[i].Score != scoredModels[j].Score {
+			return scoredModels[i].Score > scoredModels[j].Score
+		}
+		return scoredModels[i].Model.Name < scoredModels[j].Model.Name
 	})
 
-	bestModel := scoredModels[0]
-	log.Printf("🎯 Selected model: %s (score: %.2f, reason: %s)", 
-		bestModel.Model.Name, bestModel.Score, bestModel.Reason)
-
-	return bestModel.Model, nil
+	return &scoredModels[0], nil
 }
 
 // GetAvailableModels returns all available models
@@ -141,14 +370,160 @@ func (m *ModelManager) GetProviderForModel(modelName string, providerType Provid
 	return provider, nil
 }
 
-// HealthCheck performs health checks on all providers
-func (m *ModelManager) HealthCheck(ctx context.Context) map[ProviderType]*ProviderHealth {
+// GetToolCallingProvider returns the provider for a specific model with tool
+// calling support, sharing the provider instance the manager uses
+func (m *ModelManager) GetToolCallingProvider(modelName string, providerType ProviderType) (EnhancedLLMProvider, error) {
+	provider, err := m.GetProviderForModel(modelName, providerType)
+	if err != nil {
+		return nil, err
+	}
+	return WithToolCalling(provider), nil
+}
+
+// GetProviderCapabilities reports the streaming and native tool support of a
+// registered provider
+func (m *ModelManager) GetProviderCapabilities(providerType ProviderType) (ProviderCapabilities, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	provider, exists := m.providers[providerType]
+	if !exists {
+		return ProviderCapabilities{}, fmt.Errorf("provider %s not available", providerType)
+	}
+
+	return GetProviderCapabilities(provider), nil
+}
+
+// CheckPortConflict returns ErrPortConflict if a registered provider runs
+// its server on port, which another listener such as the HTTP server needs
+func (m *ModelManager) CheckPortConflict(port int) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	health := make(map[ProviderType]*ProviderHealth)
 	for providerType, provider := range m.providers {
-		if healthStatus, err := provider.GetHealth(ctx); err == nil {
+		reporter, ok := provider.(ServerPortReporter)
+		if ok && port != 0 && reporter.ServerPort() == port {
+			return fmt.Errorf("%w: provider %s runs its server on port %d", ErrPortConflict, providerType, port)
+		}
+	}
+	return nil
+}
+
+// SetCapabilityPolicy sets how providers registered afterwards treat models
+// that declare no capabilities. The default is CapabilityPolicyInfer.
+func (m *ModelManager) SetCapabilityPolicy(policy CapabilityPolicy) error {
+	switch policy {
+	case CapabilityPolicyInfer, CapabilityPolicyReject:
+	default:
+		return fmt.Errorf("unknown capability policy %q (use %s or %s)", policy, CapabilityPolicyInfer, CapabilityPolicyReject)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.capabilityPolicy = policy
+	return nil
+}
+
+// SetRetryPolicy sets how generations through the manager retry transient
+// provider failures. The default makes DefaultMaxRetries retries.
+func (m *ModelManager) SetRetryPolicy(policy RetryPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retryPolicy = policy
+}
+
+// RetryPolicy returns how generations through the manager retry transient
+// provider failures
+func (m *ModelManager) RetryPolicy() RetryPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.retryPolicy
+}
+
+// RetryPolicyFromConfig returns the retry policy configured in cfg, whose
+// delays are in seconds
+func RetryPolicyFromConfig(cfg config.LLMConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: cfg.RetryMax,
+		BaseDelay:  time.Duration(cfg.RetryBaseDelay) * time.Second,
+		MaxDelay:   time.Duration(cfg.RetryMaxDelay) * time.Second,
+	}
+}
+
+// retrying wraps provider so its Generate calls retry under the retry policy
+func (m *ModelManager) retrying(provider Provider) Provider {
+	return NewRetryingProvider(provider, m.RetryPolicy())
+}
+
+// SetHealthCacheTTL sets how long provider health checks are reused. A TTL of
+// zero or less disables the cache, so every query probes the providers.
+func (m *ModelManager) SetHealthCacheTTL(ttl time.Duration) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.healthTTL = ttl
+	m.healthCache = make(map[ProviderType]healthSnapshot)
+}
+
+// SetHealthClock replaces the clock used to expire cached health checks
+func (m *ModelManager) SetHealthClock(c clock.Clock) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.healthClock = c
+}
+
+// ProviderHealth returns the health of a registered provider. A check made
+// within the health cache TTL is reused unless refresh is set, so rapid
+// queries do not probe rate-limited providers again.
+func (m *ModelManager) ProviderHealth(ctx context.Context, providerType ProviderType, refresh bool) (*ProviderHealth, error) {
+	m.mu.RLock()
+	provider, exists := m.providers[providerType]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("provider %s not available", providerType)
+	}
+
+	m.healthMu.Lock()
+	snapshot, cached := m.healthCache[providerType]
+	fresh := cached && m.healthTTL > 0 && m.healthClock.Since(snapshot.checkedAt) < m.healthTTL
+	m.healthMu.Unlock()
+	if fresh && !refresh {
+		return snapshot.health, snapshot.err
+	}
+
+	health, err := provider.GetHealth(ctx)
+
+	// A check cut short by the caller says nothing about the provider
+	if ctx.Err() == nil {
+		m.healthMu.Lock()
+		m.healthCache[providerType] = healthSnapshot{health: health, err: err, checkedAt: m.healthClock.Now()}
+		m.healthMu.Unlock()
+	}
+
+	return health, err
+}
+
+// HealthCheck returns the health of all providers, reusing checks made within
+// the health cache TTL
+func (m *ModelManager) HealthCheck(ctx context.Context) map[ProviderType]*ProviderHealth {
+	return m.healthCheck(ctx, false)
+}
+
+// RefreshHealth probes all providers, replacing their cached health
+func (m *ModelManager) RefreshHealth(ctx context.Context) map[ProviderType]*ProviderHealth {
+	return m.healthCheck(ctx, true)
+}
+
+func (m *ModelManager) healthCheck(ctx context.Context, refresh bool) map[ProviderType]*ProviderHealth {
+	m.mu.RLock()
+	providerTypes := make([]ProviderType, 0, len(m.providers))
+	for providerType := range m.providers {
+		providerTypes = append(providerTypes, providerType)
+	}
+	m.mu.RUnlock()
+
+	health := make(map[ProviderType]*ProviderHealth)
+	for _, providerType := range providerTypes {
+		if healthStatus, err := m.ProviderHealth(ctx, providerType, refresh); err == nil {
 			health[providerType] = healthStatus
 		} else {
 			health[providerType] = &ProviderHealth{
@@ -162,6 +537,21 @@ func (m *ModelManager) HealthCheck(ctx context.Context) map[ProviderType]*Provid
 	return health
 }
 
+// Providers returns the registered providers ordered by type
+func (m *ModelManager) Providers() []Provider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	providers := make([]Provider, 0, len(m.providers))
+	for _, provider := range m.providers {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].GetType() < providers[j].GetType()
+	})
+	return providers
+}
+
 // Private helper methods
 
 func (m *ModelManager) getAvailableModels() []*ModelInfo {
@@ -266,7 +656,7 @@ func (m *ModelManager) calculateTaskSuitability(model *ModelInfo, taskType strin
 		if m.hasCapability(model.Capabilities, CapabilityPlanning) {
 			return 1.2
 		}
-	case "code_generation":
+	case "code_generation", "building", "porting":
 		// Code generation benefits from code-specific training
 		if m.hasCapability(model.Capabilities, CapabilityCodeGeneration) {
 			return 1.3
@@ -291,6 +681,12 @@ func (m *ModelManager) calculateTaskSuitability(model *ModelInfo, taskType strin
 	return 1.0 // Default suitability
 }
 
+// FitsHardware reports whether this machine can run a model, judged by the
+// parameter count in its name. Models of unknown size are assumed to fit.
+func (m *ModelManager) FitsHardware(model *ModelInfo) bool {
+	return m.calculateHardwareCompatibility(model) > 0
+}
+
 func (m *ModelManager) calculateHardwareCompatibility(model *ModelInfo) float64 {
 	// Check if model can run on current hardware
 	_, err := m.hardwareDetector.Detect()
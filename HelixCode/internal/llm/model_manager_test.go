@@ -0,0 +1,310 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"dev.helix.code/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// registerMockFactory registers a factory returning a mock provider for the duration of a test
+func registerMockFactory(t *testing.T, name string, available bool, createErr error) *MockProvider {
+	t.Helper()
+
+	mockProvider := new(MockProvider)
+	mockProvider.On("GetType").Return(ProviderType(name)).Maybe()
+	mockProvider.On("GetName").Return(name).Maybe()
+	mockProvider.On("GetModels").Return([]ModelInfo{{Name: name + "-model", Provider: ProviderType(name)}}).Maybe()
+	mockProvider.On("IsAvailable", mock.Anything).Return(available).Maybe()
+	mockProvider.On("Close").Return(nil).Maybe()
+
+	err := RegisterProviderFactory(name, func(entry ProviderConfigEntry) (Provider, error) {
+		if createErr != nil {
+			return nil, createErr
+		}
+		return mockProvider, nil
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { UnregisterProviderFactory(name) })
+
+	return mockProvider
+}
+
+// TestModelManager_InitFromConfig tests initializing multiple providers from config
+func TestModelManager_InitFromConfig(t *testing.T) {
+	registerMockFactory(t, "mock-alpha", true, nil)
+	beta := registerMockFactory(t, "mock-beta", true, nil)
+	unhealthy := registerMockFactory(t, "mock-unhealthy", false, nil)
+	registerMockFactory(t, "mock-broken", true, errors.New("bad endpoint"))
+
+	manager := NewModelManager()
+	result, err := manager.InitFromConfig(config.LLMConfig{
+		DefaultProvider: []string{"mock-beta"},
+		Providers: map[string]string{
+			"mock-alpha":     "http://alpha",
+			"mock-beta":      "http://beta",
+			"mock-unhealthy": "http://unhealthy",
+			"mock-broken":    "http://broken",
+			"mock-unknown":   "http://unknown",
+		},
+		RetryMax:       5,
+		RetryBaseDelay: 2,
+		RetryMaxDelay:  30,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, RetryPolicy{MaxRetries: 5, BaseDelay: 2 * time.Second, MaxDelay: 30 * time.Second}, manager.RetryPolicy())
+	assert.Equal(t, []string{"mock-alpha", "mock-beta"}, result.Registered)
+	assert.Len(t, result.Failed, 3)
+	assert.Contains(t, result.Failed, "mock-unhealthy")
+	assert.Contains(t, result.Failed, "mock-broken")
+	assert.Contains(t, result.Failed, "mock-unknown")
+	assert.Equal(t, ProviderType("mock-beta"), result.DefaultProvider)
+
+	defaultProvider, err := manager.GetDefaultProvider()
+	require.NoError(t, err)
+	assert.Equal(t, beta, defaultProvider)
+
+	assert.Len(t, manager.GetAvailableModels(), 2)
+	unhealthy.AssertCalled(t, "Close")
+}
+
+// TestModelManager_InitFromConfigDefaultFallback tests falling back when the default provider fails
+func TestModelManager_InitFromConfigDefaultFallback(t *testing.T) {
+	registerMockFactory(t, "mock-gamma", true, nil)
+	registerMockFactory(t, "mock-down", false, nil)
+
+	manager := NewModelManager()
+	result, err := manager.InitFromConfig(config.LLMConfig{
+		DefaultProvider: []string{"mock-down"},
+		Providers: map[string]string{
+			"mock-gamma": "",
+			"mock-down":  "",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderType("mock-gamma"), result.DefaultProvider)
+}
+
+// TestModelManager_InitFromConfigProviderOrder tests that the first healthy provider in the order is the default
+func TestModelManager_InitFromConfigProviderOrder(t *testing.T) {
+	registerMockFactory(t, "mock-local", false, nil)
+	hosted := registerMockFactory(t, "mock-hosted", true, nil)
+	registerMockFactory(t, "mock-backup", true, nil)
+
+	manager := NewModelManager()
+	result, err := manager.InitFromConfig(config.LLMConfig{
+		DefaultProvider: []string{"mock-local", " mock-hosted", "mock-backup"},
+		Providers: map[string]string{
+			"mock-local":  "",
+			"mock-hosted": "",
+			"mock-backup": "",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderType("mock-hosted"), result.DefaultProvider)
+
+	defaultProvider, err := manager.GetDefaultProvider()
+	require.NoError(t, err)
+	assert.Equal(t, hosted, defaultProvider)
+}
+
+// TestModelManager_InitFromConfigNoProviders tests errors when nothing can be initialized
+func TestModelManager_InitFromConfigNoProviders(t *testing.T) {
+	manager := NewModelManager()
+
+	_, err := manager.InitFromConfig(config.LLMConfig{})
+	assert.Error(t, err)
+
+	result, err := manager.InitFromConfig(config.LLMConfig{
+		Providers: map[string]string{"mock-missing": ""},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, result.Failed, "mock-missing")
+
+	_, err = manager.GetDefaultProvider()
+	assert.Error(t, err)
+}
+
+// newModelsProvider returns a mock provider offering the given models
+func newModelsProvider(name string, models []ModelInfo) *MockProvider {
+	mockProvider := new(MockProvider)
+	mockProvider.On("GetType").Return(ProviderType(name))
+	mockProvider.On("GetName").Return(name)
+	mockProvider.On("GetModels").Return(models)
+	return mockProvider
+}
+
+// TestModelManager_CapabilityInference tests that models without capabilities are assumed to generate text
+func TestModelManager_CapabilityInference(t *testing.T) {
+	models := []ModelInfo{
+		{Name: "bare-model"},
+		{Name: "code-model", Capabilities: []ModelCapability{CapabilityCodeGeneration}},
+	}
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newModelsProvider("mock-infer", models)))
+
+	textModels := manager.GetModelsByCapability([]ModelCapability{CapabilityTextGeneration})
+	require.Len(t, textModels, 1)
+	assert.Equal(t, "bare-model", textModels[0].Name)
+	assert.Len(t, manager.GetModelsByCapability([]ModelCapability{CapabilityCodeGeneration}), 1)
+
+	// The provider's own model list is left alone
+	assert.Empty(t, models[0].Capabilities)
+}
+
+// TestModelManager_CapabilityRejection tests rejecting models without capabilities
+func TestModelManager_CapabilityRejection(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.SetCapabilityPolicy(CapabilityPolicyReject))
+	assert.Error(t, manager.SetCapabilityPolicy("guess"))
+
+	err := manager.RegisterProvider(newModelsProvider("mock-reject", []ModelInfo{{Name: "bare-model"}}))
+	assert.ErrorIs(t, err, ErrNoCapabilities)
+	assert.ErrorContains(t, err, "bare-model")
+	assert.Empty(t, manager.Providers())
+	assert.Empty(t, manager.GetAvailableModels())
+
+	require.NoError(t, manager.RegisterProvider(newModelsProvider("mock-declared",
+		[]ModelInfo{{Name: "text-model", Capabilities: []ModelCapability{CapabilityTextGeneration}}})))
+	assert.Len(t, manager.GetAvailableModels(), 1)
+}
+
+// TestModelManager_HealthCache tests that providers are not probed again within the health cache TTL
+func TestModelManager_HealthCache(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("GetType").Return(ProviderType("mock-hosted"))
+	mockProvider.On("GetName").Return("mock-hosted")
+	mockProvider.On("GetModels").Return([]ModelInfo{})
+	mockProvider.On("GetHealth", mock.Anything).Return(&ProviderHealth{Status: "healthy"}, nil)
+
+	manager := NewModelManager()
+	mockClock := clock.NewMock(time.Now())
+	manager.SetHealthClock(mockClock)
+	require.NoError(t, manager.RegisterProvider(mockProvider))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		health := manager.HealthCheck(ctx)
+		assert.Equal(t, "healthy", health["mock-hosted"].Status)
+	}
+	_, err := manager.ProviderHealth(ctx, "mock-hosted", false)
+	require.NoError(t, err)
+	mockProvider.AssertNumberOfCalls(t, "GetHealth", 1)
+
+	// A forced refresh probes again and restarts the TTL
+	manager.RefreshHealth(ctx)
+	mockProvider.AssertNumberOfCalls(t, "GetHealth", 2)
+
+	mockClock.Advance(DefaultHealthCacheTTL - time.Second)
+	manager.HealthCheck(ctx)
+	mockProvider.AssertNumberOfCalls(t, "GetHealth", 2)
+
+	// Once the TTL has passed the provider is probed again
+	mockClock.Advance(time.Second)
+	manager.HealthCheck(ctx)
+	mockProvider.AssertNumberOfCalls(t, "GetHealth", 3)
+
+	// Without a TTL every query probes
+	manager.SetHealthCacheTTL(0)
+	manager.HealthCheck(ctx)
+	manager.HealthCheck(ctx)
+	mockProvider.AssertNumberOfCalls(t, "GetHealth", 5)
+
+	_, err = manager.ProviderHealth(ctx, "mock-missing", false)
+	assert.Error(t, err)
+}
+
+// TestProviderCapabilities tests the streaming and native tool query for each provider
+func TestProviderCapabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models": []}`))
+	}))
+	defer server.Close()
+
+	ollama, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+	llamaCPP, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "test.gguf", ContextSize: 2048})
+	require.NoError(t, err)
+	local, err := NewLocalProvider(ProviderConfigEntry{Endpoint: server.URL})
+	require.NoError(t, err)
+	openAI, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+	require.NoError(t, err)
+
+	streamingOnly := ProviderCapabilities{Streaming: true}
+	jsonMode := ProviderCapabilities{Streaming: true, JSONMode: true}
+	cases := []struct {
+		name     string
+		provider Provider
+		expected ProviderCapabilities
+	}{
+		{"ollama", ollama, jsonMode},
+		{"llama-cpp", llamaCPP, streamingOnly},
+		{"local", local, streamingOnly},
+		{"openai", openAI, jsonMode},
+		{"tool-calling", NewToolCallingProvider(ollama), streamingOnly},
+		{"unreported", new(MockProvider), ProviderCapabilities{}},
+		{"tool-calling-unreported", NewToolCallingProvider(new(MockProvider)), ProviderCapabilities{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetProviderCapabilities(tc.provider))
+		})
+	}
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(ollama))
+
+	caps, err := manager.GetProviderCapabilities(ProviderTypeLocal)
+	require.NoError(t, err)
+	assert.True(t, caps.Streaming)
+	assert.False(t, caps.NativeTools)
+
+	_, err = manager.GetProviderCapabilities(ProviderTypeOpenAI)
+	assert.Error(t, err)
+}
+
+// TestModelManager_SelectOptimalModelNoMatch tests that failing to select a
+// model is always an error and never a nil model without one
+func TestModelManager_SelectOptimalModelNoMatch(t *testing.T) {
+	provider := newModelsProvider("mock-select", []ModelInfo{
+		{Name: "small", ContextSize: 2048, Capabilities: []ModelCapability{CapabilityTextGeneration}},
+	})
+	provider.On("IsAvailable", mock.Anything).Return(true)
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	cases := map[string]struct {
+		manager  *ModelManager
+		criteria ModelSelectionCriteria
+	}{
+		"no models":          {NewModelManager(), ModelSelectionCriteria{}},
+		"missing capability": {manager, ModelSelectionCriteria{RequiredCapabilities: []ModelCapability{CapabilityVision}}},
+		"context too small":  {manager, ModelSelectionCriteria{MaxTokens: 8192}},
+		"nil registry entry": {&ModelManager{modelRegistry: map[string]*ModelInfo{"broken": nil}, providers: map[ProviderType]Provider{}}, ModelSelectionCriteria{}},
+	}
+	for name, tc := range cases {
+		model, err := tc.manager.SelectOptimalModel(tc.criteria)
+		assert.ErrorIs(t, err, ErrNoModelSelected, name)
+		assert.Nil(t, model, name)
+
+		score, err := tc.manager.RecommendModel(tc.criteria)
+		assert.ErrorIs(t, err, ErrNoModelSelected, name)
+		assert.Nil(t, score, name)
+	}
+
+	// A match is a model without an error
+	model, err := manager.SelectOptimalModel(ModelSelectionCriteria{})
+	require.NoError(t, err)
+	require.NotNil(t, model)
+	assert.Equal(t, "small", model.Name)
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModelNotFoundError reports that a provider does not have the requested model
+type ModelNotFoundError struct {
+	Provider  ProviderType
+	Model     string
+	Available []string // The provider's models, when it could list them
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("model %s not found on provider %s", e.Model, e.Provider)
+}
+
+// Unwrap lets errors.Is match ErrModelNotFound
+func (e *ModelNotFoundError) Unwrap() error {
+	return ErrModelNotFound
+}
+
+// ModelAlternatives returns the models of available providers that could
+// stand in for a missing one: those of the same family (the name before any
+// ":" tag) first, then the rest, each sorted by name
+func (m *ModelManager) ModelAlternatives(ctx context.Context, missing string) []ModelInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	family := modelFamily(missing)
+	var alternatives []ModelInfo
+	for _, model := range m.modelRegistry {
+		if model.Name == missing {
+			continue
+		}
+		provider, exists := m.providers[model.Provider]
+		if !exists || !provider.IsAvailable(ctx) {
+			continue
+		}
+		alternatives = append(alternatives, *model)
+	}
+
+	sort.Slice(alternatives, func(i, j int) bool {
+		iFamily := modelFamily(alternatives[i].Name) == family
+		jFamily := modelFamily(alternatives[j].Name) == family
+		if iFamily != jFamily {
+			return iFamily
+		}
+		if alternatives[i].Name != alternatives[j].Name {
+			return alternatives[i].Name < alternatives[j].Name
+		}
+		return alternatives[i].Provider < alternatives[j].Provider
+	})
+	return alternatives
+}
+
+// PullMissingModel pulls the model that err reports missing, when its
+// provider can download models. progress may be nil and is closed when
+// PullMissingModel returns.
+func (m *ModelManager) PullMissingModel(ctx context.Context, err error, progress chan<- Progress) error {
+	var notFound *ModelNotFoundError
+	if !errors.As(err, &notFound) {
+		if progress != nil {
+			close(progress)
+		}
+		return fmt.Errorf("not a missing model error: %v", err)
+	}
+	return m.PullModel(ctx, notFound.Provider, notFound.Model, progress)
+}
+
+// modelFamily returns a model name without its tag, e.g. "llama3" for "llama3:8b"
+func modelFamily(name string) string {
+	family, _, _ := strings.Cut(name, ":")
+	return family
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMissingModelServer mocks an Ollama server that has not pulled
+// llama3:70b until /api/pull is called
+func newMissingModelServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	models := []string{"llama3:8b", "codellama"}
+	has := func(name string) bool {
+		for _, model := range models {
+			if model == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[`)
+			for i, model := range models {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"name":%q}`, model)
+			}
+			fmt.Fprint(w, `]}`)
+		case "/api/chat":
+			if !has("llama3:70b") {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":"model \"llama3:70b\" not found, try pulling it first"}`)
+				return
+			}
+			fmt.Fprint(w, `{"model":"llama3:70b","message":{"role":"assistant","content":"ok"},"done":true}`)
+		case "/api/pull":
+			models = append(models, "llama3:70b")
+			fmt.Fprint(w, `{"status":"success"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func missingModelRequest() *LLMRequest {
+	return &LLMRequest{
+		Model:    "llama3:70b",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	}
+}
+
+func requireModelNotFound(t *testing.T, err error) *ModelNotFoundError {
+	t.Helper()
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrModelNotFound)
+	var notFound *ModelNotFoundError
+	require.True(t, errors.As(err, &notFound), "expected *ModelNotFoundError, got %T: %v", err, err)
+	return notFound
+}
+
+func TestOllamaProvider_ModelNotFound(t *testing.T) {
+	server := newMissingModelServer(t)
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	_, err = provider.Generate(context.Background(), missingModelRequest())
+	notFound := requireModelNotFound(t, err)
+	assert.Equal(t, "llama3:70b", notFound.Model)
+	assert.Equal(t, ProviderTypeLocal, notFound.Provider)
+	assert.Equal(t, []string{"llama3:8b", "codellama"}, notFound.Available)
+
+	err = provider.GenerateStream(context.Background(), missingModelRequest(), make(chan LLMResponse, 1))
+	requireModelNotFound(t, err)
+}
+
+func TestLlamaCPPProvider_ModelNotFound(t *testing.T) {
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "/models/codellama-7b.Q4_K_M.gguf", ContextSize: 2048})
+	require.NoError(t, err)
+
+	for _, model := range []string{"", "/models/codellama-7b.Q4_K_M.gguf", "codellama-7b.Q4_K_M.gguf", "codellama-7b.Q4_K_M"} {
+		request := missingModelRequest()
+		request.Model = model
+		_, err := provider.Generate(context.Background(), request)
+		assert.NoError(t, err, "model %q", model)
+	}
+
+	_, err = provider.Generate(context.Background(), missingModelRequest())
+	notFound := requireModelNotFound(t, err)
+	assert.Equal(t, ProviderTypeLocal, notFound.Provider)
+	assert.Equal(t, []string{"/models/codellama-7b.Q4_K_M.gguf"}, notFound.Available)
+
+	err = provider.GenerateStream(context.Background(), missingModelRequest(), make(chan LLMResponse, 1))
+	requireModelNotFound(t, err)
+}
+
+func TestModelManager_ModelAlternatives(t *testing.T) {
+	server := newMissingModelServer(t)
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	var names []string
+	for _, model := range manager.ModelAlternatives(context.Background(), "llama3:70b") {
+		names = append(names, model.Name)
+	}
+	assert.Equal(t, []string{"llama3:8b", "codellama"}, names)
+}
+
+func TestModelManager_PullMissingModel(t *testing.T) {
+	server := newMissingModelServer(t)
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	_, err = provider.Generate(context.Background(), missingModelRequest())
+	requireModelNotFound(t, err)
+
+	require.NoError(t, manager.PullMissingModel(context.Background(), err, nil))
+	_, err = manager.GetProviderForModel("llama3:70b", ProviderTypeLocal)
+	assert.NoError(t, err)
+
+	response, err := provider.Generate(context.Background(), missingModelRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response.Content)
+
+	err = manager.PullMissingModel(context.Background(), ErrProviderUnavailable, nil)
+	assert.Error(t, err)
+}
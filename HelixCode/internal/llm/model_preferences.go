@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"dev.helix.code/internal/paths"
+)
+
+// ModelPreference names the model preferred for a task type
+type ModelPreference struct {
+	Model string `json:"model"`
+	// Provider restricts the preference to one provider's model of that name;
+	// any provider's matches when empty
+	Provider ProviderType `json:"provider,omitempty"`
+}
+
+// ModelPreferences maps task types to preferred models. Preferences loaded
+// from a file are written back to it whenever they change.
+type ModelPreferences struct {
+	path        string
+	mu          sync.RWMutex
+	preferences map[string]ModelPreference
+}
+
+// DefaultPreferencesPath returns the model preferences file, from
+// HELIX_MODEL_PREFERENCES or else in the user's HelixCode config directory
+func DefaultPreferencesPath() string {
+	if path := os.Getenv("HELIX_MODEL_PREFERENCES"); path != "" {
+		return paths.Expand(path)
+	}
+	return paths.Expand("~/.config/helixcode/model_preferences.json")
+}
+
+// NewModelPreferences creates preferences that are kept in memory only
+func NewModelPreferences() *ModelPreferences {
+	return &ModelPreferences{preferences: make(map[string]ModelPreference)}
+}
+
+// LoadModelPreferences reads preferences from a JSON file. A missing file
+// gives empty preferences that are saved to path once set.
+func LoadModelPreferences(path string) (*ModelPreferences, error) {
+	prefs := NewModelPreferences()
+	prefs.path = paths.Expand(path)
+
+	data, err := os.ReadFile(prefs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model preferences: %v", err)
+	}
+	if err := json.Unmarshal(data, &prefs.preferences); err != nil {
+		return nil, fmt.Errorf("failed to parse model preferences %s: %v", prefs.path, err)
+	}
+	if prefs.preferences == nil {
+		prefs.preferences = make(map[string]ModelPreference)
+	}
+	return prefs, nil
+}
+
+// Get returns the preference for a task type
+func (p *ModelPreferences) Get(taskType string) (ModelPreference, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pref, ok := p.preferences[taskType]
+	return pref, ok
+}
+
+// TaskTypes returns the task types that have a preference, sorted
+func (p *ModelPreferences) TaskTypes() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	taskTypes := make([]string, 0, len(p.preferences))
+	for taskType := range p.preferences {
+		taskTypes = append(taskTypes, taskType)
+	}
+	sort.Strings(taskTypes)
+	return taskTypes
+}
+
+// Set records the preferred model for a task type
+func (p *ModelPreferences) Set(taskType string, pref ModelPreference) error {
+	if pref.Model == "" {
+		return fmt.Errorf("preferred model for %s tasks is empty", taskType)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.preferences[taskType] = pref
+	return p.saveLocked()
+}
+
+// Clear removes the preference for a task type
+func (p *ModelPreferences) Clear(taskType string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.preferences, taskType)
+	return p.saveLocked()
+}
+
+// saveLocked writes the preferences to their file, if any. The caller must hold p.mu.
+func (p *ModelPreferences) saveLocked() error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(p.preferences, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode model preferences: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return fmt.Errorf("failed to create preferences directory: %v", err)
+	}
+	if err := os.WriteFile(p.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model preferences: %v", err)
+	}
+	return nil
+}
+
+// SetPreferences makes model selection honour per-task preferences before
+// scoring. nil removes them.
+func (m *ModelManager) SetPreferences(prefs *ModelPreferences) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.preferences = prefs
+}
+
+// CheckPreference reports why a preferred model cannot serve tasks matching
+// criteria: it is not registered, lacks a required capability or its
+// provider is unavailable
+func (m *ModelManager) CheckPreference(pref ModelPreference, criteria ModelSelectionCriteria) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, err := m.preferredModel(pref, criteria)
+	return err
+}
+
+// preferredModel resolves and validates a preference. The caller must hold m.mu.
+func (m *ModelManager) preferredModel(pref ModelPreference, criteria ModelSelectionCriteria) (*ModelInfo, error) {
+	var model *ModelInfo
+	for _, candidate := range m.getAvailableModels() {
+		if candidate.Name != pref.Model || (pref.Provider != "" && candidate.Provider != pref.Provider) {
+			continue
+		}
+		// Prefer a deterministic match when several providers offer the model
+		if model == nil || candidate.Provider < model.Provider {
+			model = candidate
+		}
+	}
+	if model == nil {
+		return nil, fmt.Errorf("preferred model %s not found", pref.Model)
+	}
+
+	for _, capability := range criteria.RequiredCapabilities {
+		if !m.hasCapability(model.Capabilities, capability) {
+			return nil, fmt.Errorf("preferred model %s lacks capability %s", model.Name, capability)
+		}
+	}
+
+	provider, exists := m.providers[model.Provider]
+	if !exists || !provider.IsAvailable(context.Background()) {
+		return nil, fmt.Errorf("provider %s of preferred model %s is unavailable", model.Provider, model.Name)
+	}
+	return model, nil
+}
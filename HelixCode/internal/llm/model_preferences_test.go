@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelPreferences_Honored(t *testing.T) {
+	manager := newRecommendationManager(t)
+	prefs := NewModelPreferences()
+	manager.SetPreferences(prefs)
+
+	// Every model can draw diagrams, so each preference changes the choice
+	for _, name := range []string{"debugger", "coder"} {
+		require.NoError(t, prefs.Set("diagram", ModelPreference{Model: name, Provider: "mock"}))
+
+		model, err := manager.SelectOptimalModel(CriteriaForTask("diagram"))
+		require.NoError(t, err)
+		assert.Equal(t, name, model.Name)
+	}
+
+	best, err := manager.RecommendModel(CriteriaForTask("diagram"))
+	require.NoError(t, err)
+	assert.Equal(t, "preferred for diagram tasks", best.Reason)
+
+	require.NoError(t, prefs.Clear("diagram"))
+	_, ok := prefs.Get("diagram")
+	assert.False(t, ok)
+}
+
+func TestModelPreferences_InvalidFallsBackToScoring(t *testing.T) {
+	manager := newRecommendationManager(t)
+	prefs := NewModelPreferences()
+	manager.SetPreferences(prefs)
+
+	cases := map[string]ModelPreference{
+		"missing model":      {Model: "absent"},
+		"missing capability": {Model: "coder"},
+		"other provider":     {Model: "planner", Provider: "elsewhere"},
+	}
+	for name, pref := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, prefs.Set("planning", pref))
+			assert.Error(t, manager.CheckPreference(pref, CriteriaForTask("planning")))
+
+			model, err := manager.SelectOptimalModel(CriteriaForTask("planning"))
+			require.NoError(t, err)
+			assert.Equal(t, "planner", model.Name)
+		})
+	}
+
+	assert.Error(t, prefs.Set("planning", ModelPreference{}))
+}
+
+func TestModelPreferences_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helix", "model_preferences.json")
+
+	prefs, err := LoadModelPreferences(path)
+	require.NoError(t, err)
+	assert.Empty(t, prefs.TaskTypes())
+
+	require.NoError(t, prefs.Set("testing", ModelPreference{Model: "coder"}))
+	require.NoError(t, prefs.Set("planning", ModelPreference{Model: "planner", Provider: "mock"}))
+	require.NoError(t, prefs.Clear("testing"))
+
+	reloaded, err := LoadModelPreferences(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"planning"}, reloaded.TaskTypes())
+	pref, ok := reloaded.Get("planning")
+	require.True(t, ok)
+	assert.Equal(t, ModelPreference{Model: "planner", Provider: "mock"}, pref)
+}
@@ -0,0 +1,54 @@
+package llm
+
+// taskCriteria holds the selection criteria for each task type, keyed by the
+// task package's TaskType values
+var taskCriteria = map[string]ModelSelectionCriteria{
+	"planning": {
+		RequiredCapabilities: []ModelCapability{CapabilityPlanning},
+		QualityPreference:    "quality",
+	},
+	"building": {
+		RequiredCapabilities: []ModelCapability{CapabilityCodeGeneration},
+		QualityPreference:    "balanced",
+	},
+	"testing": {
+		RequiredCapabilities: []ModelCapability{CapabilityTesting, CapabilityCodeGeneration},
+		QualityPreference:    "balanced",
+	},
+	"refactoring": {
+		RequiredCapabilities: []ModelCapability{CapabilityRefactoring, CapabilityCodeAnalysis},
+		QualityPreference:    "quality",
+	},
+	"debugging": {
+		RequiredCapabilities: []ModelCapability{CapabilityDebugging, CapabilityCodeAnalysis},
+		QualityPreference:    "quality",
+	},
+	"design": {
+		RequiredCapabilities: []ModelCapability{CapabilityPlanning},
+		QualityPreference:    "quality",
+	},
+	"diagram": {
+		RequiredCapabilities: []ModelCapability{CapabilityTextGeneration},
+		QualityPreference:    "fast",
+	},
+	"deployment": {
+		RequiredCapabilities: []ModelCapability{CapabilityCodeGeneration},
+		QualityPreference:    "fast",
+	},
+	"porting": {
+		RequiredCapabilities: []ModelCapability{CapabilityCodeGeneration, CapabilityCodeAnalysis},
+		QualityPreference:    "quality",
+	},
+}
+
+// CriteriaForTask returns the model selection criteria suited to a task type.
+// Unknown task types get balanced criteria without required capabilities.
+func CriteriaForTask(taskType string) ModelSelectionCriteria {
+	criteria, ok := taskCriteria[taskType]
+	if !ok {
+		criteria = ModelSelectionCriteria{QualityPreference: "balanced"}
+	}
+	criteria.TaskType = taskType
+	criteria.RequiredCapabilities = append([]ModelCapability(nil), criteria.RequiredCapabilities...)
+	return criteria
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecommendationManager registers one provider with a model specialised for
+// each of planning, debugging and code generation
+func newRecommendationManager(t *testing.T) *ModelManager {
+	t.Helper()
+
+	provider := new(MockProvider)
+	provider.On("GetType").Return(ProviderType("mock"))
+	provider.On("GetName").Return("mock")
+	provider.On("IsAvailable", mock.Anything).Return(true)
+	provider.On("GetModels").Return([]ModelInfo{
+		{Name: "planner", Provider: "mock", ContextSize: 8192,
+			Capabilities: []ModelCapability{CapabilityTextGeneration, CapabilityPlanning}},
+		{Name: "debugger", Provider: "mock", ContextSize: 8192,
+			Capabilities: []ModelCapability{CapabilityTextGeneration, CapabilityDebugging, CapabilityCodeAnalysis}},
+		{Name: "coder", Provider: "mock", ContextSize: 8192,
+			Capabilities: []ModelCapability{CapabilityTextGeneration, CapabilityCodeGeneration, CapabilityCodeAnalysis}},
+	})
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+	return manager
+}
+
+func TestRecommendModel_ByTaskType(t *testing.T) {
+	manager := newRecommendationManager(t)
+
+	cases := map[string]string{
+		"planning":  "planner",
+		"design":    "planner",
+		"debugging": "debugger",
+		"building":  "coder",
+		"porting":   "coder",
+	}
+	for taskType, expected := range cases {
+		t.Run(taskType, func(t *testing.T) {
+			best, err := manager.RecommendModel(CriteriaForTask(taskType))
+			require.NoError(t, err)
+			assert.Equal(t, expected, best.Model.Name)
+			assert.Contains(t, best.Reason, "hardware:")
+		})
+	}
+}
+
+func TestCriteriaForTask(t *testing.T) {
+	criteria := CriteriaForTask("debugging")
+	assert.Equal(t, "debugging", criteria.TaskType)
+	assert.Equal(t, []ModelCapability{CapabilityDebugging, CapabilityCodeAnalysis}, criteria.RequiredCapabilities)
+
+	// Callers may change the criteria without affecting later calls
+	criteria.RequiredCapabilities[0] = CapabilityVision
+	assert.Equal(t, CapabilityDebugging, CriteriaForTask("debugging").RequiredCapabilities[0])
+
+	unknown := CriteriaForTask("cooking")
+	assert.Equal(t, "cooking", unknown.TaskType)
+	assert.Empty(t, unknown.RequiredCapabilities)
+	assert.Equal(t, "balanced", unknown.QualityPreference)
+}
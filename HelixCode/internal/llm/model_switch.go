@@ -0,0 +1,210 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ModelUnloader is implemented by providers that can release a loaded model's memory
+type ModelUnloader interface {
+	Unload(ctx context.Context, model string) error
+}
+
+// ModelLoader is implemented by providers that can load a model ahead of the first request
+type ModelLoader interface {
+	Load(ctx context.Context, model string) error
+}
+
+// ModelPuller is implemented by providers that can download models, reporting progress
+type ModelPuller interface {
+	Pull(ctx context.Context, model string, progress chan<- Progress) error
+}
+
+// VRAMReporter is implemented by providers that can report the VRAM used by loaded models
+type VRAMReporter interface {
+	LoadedVRAM(ctx context.Context) (uint64, error)
+}
+
+// ModelSwitchResult reports the outcome of switching the active model
+type ModelSwitchResult struct {
+	From         *ModelInfo    `json:"from,omitempty"`
+	To           *ModelInfo    `json:"to"`
+	Unloaded     bool          `json:"unloaded"`
+	Loaded       bool          `json:"loaded"`
+	VRAMBefore   uint64        `json:"vram_before"`
+	VRAMAfter    uint64        `json:"vram_after"`
+	VRAMReported bool          `json:"vram_reported"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// CurrentModel returns the active model, or nil if none has been selected
+func (m *ModelManager) CurrentModel() *ModelInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.currentModel
+}
+
+// SwitchModel unloads the active model and loads the target one so that both
+// are never resident at once. Providers without unload or load support are
+// skipped, leaving memory management to the provider. Switches run one at a
+// time, and the manager stays readable while models are unloaded and loaded.
+func (m *ModelManager) SwitchModel(ctx context.Context, modelName string, providerType ProviderType) (*ModelSwitchResult, error) {
+	m.switchMu.Lock()
+	defer m.switchMu.Unlock()
+
+	start := time.Now()
+
+	m.mu.RLock()
+	target, exists := m.modelRegistry[m.getModelKey(providerType, modelName)]
+	targetProvider, providerExists := m.providers[providerType]
+	current := m.currentModel
+	var currentProvider Provider
+	if current != nil {
+		currentProvider = m.providers[current.Provider]
+	}
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("model %s not found for provider %s", modelName, providerType)
+	}
+	if !providerExists {
+		return nil, fmt.Errorf("provider %s not available", providerType)
+	}
+
+	result := &ModelSwitchResult{From: current, To: target}
+	result.VRAMBefore, result.VRAMReported = m.loadedVRAM(ctx)
+
+	if current != nil {
+		if current == target {
+			result.VRAMAfter = result.VRAMBefore
+			result.Duration = time.Since(start)
+			return result, nil
+		}
+
+		if provider, ok := currentProvider.(ModelUnloader); ok {
+			if err := provider.Unload(ctx, current.Name); err != nil {
+				return nil, fmt.Errorf("failed to unload model %s: %v", current.Name, err)
+			}
+			result.Unloaded = true
+		} else {
+			log.Printf("⚠️ Provider %s does not support unloading, %s stays resident", current.Provider, current.Name)
+		}
+	}
+
+	if provider, ok := targetProvider.(ModelLoader); ok {
+		if err := provider.Load(ctx, target.Name); err != nil {
+			return nil, fmt.Errorf("failed to load model %s: %v", target.Name, err)
+		}
+		result.Loaded = true
+	}
+
+	m.mu.Lock()
+	m.currentModel = target
+	m.mu.Unlock()
+
+	vramAfter, reported := m.loadedVRAM(ctx)
+	result.VRAMAfter = vramAfter
+	result.VRAMReported = result.VRAMReported && reported
+	result.Duration = time.Since(start)
+
+	log.Printf("🔄 Switched model to %s (VRAM: %d -> %d bytes)", target.Name, result.VRAMBefore, result.VRAMAfter)
+	return result, nil
+}
+
+// loadedVRAM sums the VRAM reported by all providers that support it
+func (m *ModelManager) loadedVRAM(ctx context.Context) (uint64, bool) {
+	m.mu.RLock()
+	reporters := make(map[ProviderType]VRAMReporter)
+	for providerType, provider := range m.providers {
+		if reporter, ok := provider.(VRAMReporter); ok {
+			reporters[providerType] = reporter
+		}
+	}
+	m.mu.RUnlock()
+
+	var total uint64
+	reported := false
+
+	for providerType, reporter := range reporters {
+		vram, err := reporter.LoadedVRAM(ctx)
+		if err != nil {
+			log.Printf("⚠️ Failed to read VRAM usage from %s: %v", providerType, err)
+			continue
+		}
+		total += vram
+		reported = true
+	}
+
+	return total, reported
+}
+
+// PullModel downloads a model through a provider that supports it and
+// registers the provider's refreshed model list. progress may be nil and is
+// closed when PullModel returns.
+func (m *ModelManager) PullModel(ctx context.Context, providerType ProviderType, model string, progress chan<- Progress) error {
+	m.mu.RLock()
+	provider, exists := m.providers[providerType]
+	m.mu.RUnlock()
+
+	puller, ok := provider.(ModelPuller)
+	if !exists || !ok {
+		err := fmt.Errorf("provider %s does not support pulling models", providerType)
+		if !exists {
+			err = fmt.Errorf("provider %s not available", providerType)
+		}
+		newProgressReporter(ctx, progress, "pull "+model, 0).finish(err)
+		return err
+	}
+
+	if err := puller.Pull(ctx, model, progress); err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", model, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	models := provider.GetModels()
+	for i := range models {
+		m.modelRegistry[m.getModelKey(providerType, models[i].Name)] = &models[i]
+	}
+
+	log.Printf("✅ Model pulled: %s", model)
+	return nil
+}
+
+// WarmupModels loads models ahead of their first request, reporting one step
+// per model. Every model is attempted; the errors of those that failed are
+// returned together. progress may be nil and is closed when WarmupModels returns.
+func (m *ModelManager) WarmupModels(ctx context.Context, providerType ProviderType, models []string, progress chan<- Progress) (err error) {
+	reporter := newProgressReporter(ctx, progress, "warmup", int64(len(models)))
+	defer func() { reporter.finish(err) }()
+
+	m.mu.RLock()
+	provider, exists := m.providers[providerType]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("provider %s not available", providerType)
+	}
+	loader, ok := provider.(ModelLoader)
+	if !ok {
+		return fmt.Errorf("provider %s does not support loading models", providerType)
+	}
+
+	var errs []error
+	for _, model := range models {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			errs = append(errs, fmt.Errorf("warmup interrupted: %w", ctxErr))
+			break
+		}
+		if err := loader.Load(ctx, model); err != nil {
+			errs = append(errs, fmt.Errorf("failed to load model %s: %w", model, err))
+		}
+		reporter.add(1, model)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchingProvider is a mock provider that tracks loaded models and their VRAM
+type switchingProvider struct {
+	*MockProvider
+	sizes  map[string]uint64
+	loaded map[string]bool
+	calls  []string
+}
+
+func newSwitchingProvider(providerType ProviderType, sizes map[string]uint64) *switchingProvider {
+	var models []ModelInfo
+	for name := range sizes {
+		models = append(models, ModelInfo{Name: name, Provider: providerType})
+	}
+
+	mockProvider := new(MockProvider)
+	mockProvider.On("GetType").Return(providerType)
+	mockProvider.On("GetName").Return(string(providerType))
+	mockProvider.On("GetModels").Return(models)
+
+	return &switchingProvider{MockProvider: mockProvider, sizes: sizes, loaded: make(map[string]bool)}
+}
+
+func (p *switchingProvider) Unload(ctx context.Context, model string) error {
+	p.calls = append(p.calls, "unload:"+model)
+	delete(p.loaded, model)
+	return nil
+}
+
+func (p *switchingProvider) Load(ctx context.Context, model string) error {
+	p.calls = append(p.calls, "load:"+model)
+	p.loaded[model] = true
+	return nil
+}
+
+func (p *switchingProvider) LoadedVRAM(ctx context.Context) (uint64, error) {
+	var total uint64
+	for model := range p.loaded {
+		total += p.sizes[model]
+	}
+	return total, nil
+}
+
+// TestModelManager_SwitchModel tests that switching unloads the active model before loading the next
+func TestModelManager_SwitchModel(t *testing.T) {
+	manager := NewModelManager()
+	provider := newSwitchingProvider("mock-local", map[string]uint64{"small": 4 << 30, "large": 12 << 30})
+	require.NoError(t, manager.RegisterProvider(provider))
+	ctx := context.Background()
+
+	result, err := manager.SwitchModel(ctx, "small", "mock-local")
+	require.NoError(t, err)
+	assert.Nil(t, result.From)
+	assert.False(t, result.Unloaded)
+	assert.True(t, result.Loaded)
+	assert.True(t, result.VRAMReported)
+	assert.Equal(t, uint64(0), result.VRAMBefore)
+	assert.Equal(t, uint64(4<<30), result.VRAMAfter)
+
+	result, err = manager.SwitchModel(ctx, "large", "mock-local")
+	require.NoError(t, err)
+	assert.Equal(t, "small", result.From.Name)
+	assert.Equal(t, "large", result.To.Name)
+	assert.True(t, result.Unloaded)
+	assert.Equal(t, uint64(4<<30), result.VRAMBefore)
+	assert.Equal(t, uint64(12<<30), result.VRAMAfter)
+	assert.Equal(t, []string{"load:small", "unload:small", "load:large"}, provider.calls)
+	assert.Equal(t, "large", manager.CurrentModel().Name)
+
+	// Switching to the active model is a no-op
+	result, err = manager.SwitchModel(ctx, "large", "mock-local")
+	require.NoError(t, err)
+	assert.False(t, result.Unloaded)
+	assert.False(t, result.Loaded)
+	assert.Len(t, provider.calls, 3)
+
+	_, err = manager.SwitchModel(ctx, "missing", "mock-local")
+	assert.Error(t, err)
+}
+
+// TestModelManager_SwitchModelWithoutUnloadSupport tests that providers without unload support are skipped
+func TestModelManager_SwitchModelWithoutUnloadSupport(t *testing.T) {
+	manager := NewModelManager()
+
+	remote := new(MockProvider)
+	remote.On("GetType").Return(ProviderType("mock-remote"))
+	remote.On("GetName").Return("mock-remote")
+	remote.On("GetModels").Return([]ModelInfo{{Name: "hosted", Provider: "mock-remote"}})
+	require.NoError(t, manager.RegisterProvider(remote))
+
+	local := newSwitchingProvider("mock-local", map[string]uint64{"small": 4 << 30})
+	require.NoError(t, manager.RegisterProvider(local))
+	ctx := context.Background()
+
+	result, err := manager.SwitchModel(ctx, "hosted", "mock-remote")
+	require.NoError(t, err)
+	assert.False(t, result.Loaded)
+
+	result, err = manager.SwitchModel(ctx, "small", "mock-local")
+	require.NoError(t, err)
+	assert.False(t, result.Unloaded)
+	assert.True(t, result.Loaded)
+	assert.Equal(t, "small", manager.CurrentModel().Name)
+	remote.AssertNotCalled(t, "Close")
+}
+
+// blockingLoadProvider is a switching provider whose loads wait for release
+type blockingLoadProvider struct {
+	*switchingProvider
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingLoadProvider) Load(ctx context.Context, model string) error {
+	close(p.loading)
+	<-p.release
+	return p.switchingProvider.Load(ctx, model)
+}
+
+// TestModelManager_SwitchModelKeepsManagerReadable tests that a slow load does not block readers
+func TestModelManager_SwitchModelKeepsManagerReadable(t *testing.T) {
+	manager := NewModelManager()
+	provider := &blockingLoadProvider{
+		switchingProvider: newSwitchingProvider("mock-local", map[string]uint64{"small": 4 << 30}),
+		loading:           make(chan struct{}),
+		release:           make(chan struct{}),
+	}
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	switched := make(chan error, 1)
+	go func() {
+		_, err := manager.SwitchModel(context.Background(), "small", "mock-local")
+		switched <- err
+	}()
+	<-provider.loading
+
+	read := make(chan int, 1)
+	go func() {
+		manager.CurrentModel()
+		read <- len(manager.GetAvailableModels())
+	}()
+	select {
+	case n := <-read:
+		assert.Equal(t, 1, n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("manager was locked while the model loaded")
+	}
+	assert.Nil(t, manager.CurrentModel())
+
+	close(provider.release)
+	require.NoError(t, <-switched)
+	assert.Equal(t, "small", manager.CurrentModel().Name)
+}
+
+// TestOllamaProvider_Unload tests that unloading sends keep_alive 0 for the model
+func TestOllamaProvider_Unload(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/generate":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			received <- body
+			w.Write([]byte(`{"done":true}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[{"name":"a","size_vram":100},{"name":"b","size_vram":50}]}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	require.NoError(t, provider.Unload(context.Background(), "llama3"))
+	body := <-received
+	assert.Equal(t, "llama3", body["model"])
+	assert.Equal(t, float64(0), body["keep_alive"])
+
+	vram, err := provider.LoadedVRAM(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), vram)
+}
@@ -16,6 +16,7 @@ import (
 type OllamaProvider struct {
 	config     OllamaConfig
 	apiClient  *http.Client
+	timeout    time.Duration // Bounds metadata requests, from config.Timeout
 	models     []OllamaModel
 	isRunning  bool
 }
@@ -24,9 +25,12 @@ type OllamaProvider struct {
 type OllamaConfig struct {
 	BaseURL       string        `json:"base_url"`
 	DefaultModel  string        `json:"default_model"`
-	Timeout       time.Duration `json:"timeout"`
-	KeepAlive     time.Duration `json:"keep_alive"`
+	Timeout       int           `json:"timeout"` // Seconds model discovery and status requests may take; 0 uses DefaultOllamaTimeout
+	KeepAlive     *time.Duration `json:"keep_alive"` // nil leaves it to the server; 0 unloads after each request
 	StreamEnabled bool          `json:"stream_enabled"`
+	ContextSize   int           `json:"context_size"` // Tokens, sent as num_ctx; 0 uses DefaultContextSize
+	// HTTPClient replaces the shared HTTP client, e.g. in tests
+	HTTPClient    *http.Client  `json:"-"`
 }
 
 // OllamaModel represents an Ollama model
@@ -54,6 +58,8 @@ type OllamaAPIRequest struct {
 	Messages   []Message              `json:"messages"`
 	Stream     bool                   `json:"stream"`
 	Options    map[string]interface{} `json:"options"`
+	KeepAlive  string                 `json:"keep_alive,omitempty"`
+	Format     string                 `json:"format,omitempty"` // "json" constrains output to JSON
 }
 
 // OllamaAPIResponse represents a response from the Ollama API
@@ -61,6 +67,7 @@ type OllamaAPIResponse struct {
 	Model              string `json:"model"`
 	CreatedAt          string `json:"created_at"`
 	Response           string `json:"response"`
+	Message            *Message `json:"message,omitempty"`
 	Done               bool   `json:"done"`
 	Context            []int  `json:"context"`
 	TotalDuration      int64  `json:"total_duration"`
@@ -71,25 +78,95 @@ type OllamaAPIResponse struct {
 	EvalDuration       int64  `json:"eval_duration"`
 }
 
+// DefaultOllamaTimeout bounds Ollama's model discovery and status requests
+// when no timeout is configured
+const DefaultOllamaTimeout = 30 * time.Second
+
 // NewOllamaProvider creates a new Ollama provider
 func NewOllamaProvider(config OllamaConfig) (*OllamaProvider, error) {
+	contextSize, err := normalizeContextSize(config.ContextSize, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ollama config: %v", err)
+	}
+	config.ContextSize = contextSize
+	timeout, err := timeoutSeconds("timeout", config.Timeout, DefaultOllamaTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ollama config: %v", err)
+	}
+
 	provider := &OllamaProvider{
 		config: config,
-		apiClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		apiClient: newProviderHTTPClient(config.HTTPClient),
+		timeout: timeout,
 		isRunning: true,
 	}
 
-	// Discover available models
-	if err := provider.discoverModels(); err != nil {
-		log.Printf("Warning: Failed to discover Ollama models: %v", err)
+	// Discover available models, probing the server to explain a failure
+	if err := provider.discoverModels(context.Background()); err != nil {
+		if status := provider.Status(context.Background()); !status.Available {
+			log.Printf("⚠️ Ollama not reachable at %s: %s", provider.getAPIURL(""), status.Error)
+		} else {
+			log.Printf("Warning: Failed to discover Ollama models: %v", err)
+		}
 	}
 
 	log.Printf("✅ Ollama provider initialized with %d models", len(provider.models))
 	return provider, nil
 }
 
+// OllamaStatus is the result of probing an Ollama server
+type OllamaStatus struct {
+	Available bool   `json:"available"`
+	Version   string `json:"version,omitempty"` // Empty when the server does not report one
+	Error     string `json:"error,omitempty"`
+}
+
+// ProbeOllama reports whether an Ollama server answers at baseURL, and its
+// version. ctx bounds the probe.
+func ProbeOllama(ctx context.Context, baseURL string) OllamaStatus {
+	provider := &OllamaProvider{
+		config:    OllamaConfig{BaseURL: baseURL},
+		apiClient: newProviderHTTPClient(nil),
+		timeout:   DefaultOllamaTimeout,
+		isRunning: true,
+	}
+	return provider.Status(ctx)
+}
+
+// Status probes the server's /api/version endpoint. Servers without it are
+// available when they list their models.
+func (p *OllamaProvider) Status(ctx context.Context) OllamaStatus {
+	ctx, cancel := p.metadataContext(ctx)
+	defer cancel()
+
+	resp, err := p.get(ctx, "/api/version")
+	if err != nil {
+		return OllamaStatus{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var version struct {
+			Version string `json:"version"`
+		}
+		json.NewDecoder(resp.Body).Decode(&version)
+		return OllamaStatus{Available: true, Version: version.Version}
+	case http.StatusNotFound:
+		tags, err := p.get(ctx, "/api/tags")
+		if err != nil {
+			return OllamaStatus{Error: err.Error()}
+		}
+		tags.Body.Close()
+		if tags.StatusCode != http.StatusOK {
+			return OllamaStatus{Error: fmt.Sprintf("API returned status %d", tags.StatusCode)}
+		}
+		return OllamaStatus{Available: true}
+	default:
+		return OllamaStatus{Error: fmt.Sprintf("API returned status %d", resp.StatusCode)}
+	}
+}
+
 // GetType returns the provider type
 func (p *OllamaProvider) GetType() ProviderType {
 	return ProviderTypeLocal
@@ -105,14 +182,19 @@ func (p *OllamaProvider) GetModels() []ModelInfo {
 	var modelInfos []ModelInfo
 	
 	for _, model := range p.models {
+		capabilities := []ModelCapability{CapabilityTextGeneration, CapabilityCodeGeneration, CapabilityCodeAnalysis}
+		vision := isOllamaVisionModel(model)
+		if vision {
+			capabilities = append(capabilities, CapabilityVision)
+		}
 		modelInfos = append(modelInfos, ModelInfo{
 			Name:         model.Name,
 			Provider:     ProviderTypeLocal,
-			ContextSize:  4096, // Default context size
-			Capabilities: []ModelCapability{CapabilityTextGeneration, CapabilityCodeGeneration, CapabilityCodeAnalysis},
-			MaxTokens:    4096,
+			ContextSize:  p.config.ContextSize,
+			Capabilities: capabilities,
+			MaxTokens:    p.config.ContextSize,
 			SupportsTools: false,
-			SupportsVision: false,
+			SupportsVision: vision,
 			Description:  fmt.Sprintf("Ollama model: %s", model.Name),
 		})
 	}
@@ -139,6 +221,11 @@ func (p *OllamaProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 		return nil, ErrProviderUnavailable
 	}
 
+	if err := NormalizeRequest(request); err != nil {
+		return nil, err
+	}
+	logRequest(p.GetName(), request)
+
 	// Prepare API request
 	apiRequest := OllamaAPIRequest{
 		Model:    p.getModelName(request.Model),
@@ -148,7 +235,12 @@ func (p *OllamaProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 			"temperature": request.Temperature,
 			"top_p":       request.TopP,
 			"num_predict": request.MaxTokens,
+			"num_ctx":     p.config.ContextSize,
 		},
+		KeepAlive: p.getKeepAlive(ctx, request),
+	}
+	if request.JSONMode {
+		apiRequest.Format = "json"
 	}
 
 	// Make API call
@@ -160,10 +252,16 @@ func (p *OllamaProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 
 	processingTime := time.Since(startTime)
 
-	return &LLMResponse{
+	// The chat endpoint answers in message, the generate endpoint in response
+	content := response.Response
+	if response.Message != nil {
+		content = response.Message.Content
+	}
+
+	llmResponse := &LLMResponse{
 		ID:        uuid.New(),
 		RequestID: request.ID,
-		Content:   response.Response,
+		Content:   content,
 		Usage: Usage{
 			PromptTokens:     response.PromptEvalCount,
 			CompletionTokens: response.EvalCount,
@@ -171,7 +269,10 @@ func (p *OllamaProvider) Generate(ctx context.Context, request *LLMRequest) (*LL
 		},
 		ProcessingTime: processingTime,
 		CreatedAt:      time.Now(),
-	}, nil
+	}
+	logResponse(p.GetName(), llmResponse, processingTime)
+
+	return llmResponse, nil
 }
 
 // GenerateStream generates a streaming response
@@ -180,6 +281,11 @@ func (p *OllamaProvider) GenerateStream(ctx context.Context, request *LLMRequest
 		return ErrProviderUnavailable
 	}
 
+	if err := NormalizeRequest(request); err != nil {
+		return err
+	}
+	logRequest(p.GetName(), request)
+
 	// Prepare streaming API request
 	apiRequest := OllamaAPIRequest{
 		Model:    p.getModelName(request.Model),
@@ -189,27 +295,41 @@ func (p *OllamaProvider) GenerateStream(ctx context.Context, request *LLMRequest
 			"temperature": request.Temperature,
 			"top_p":       request.TopP,
 			"num_predict": request.MaxTokens,
+			"num_ctx":     p.config.ContextSize,
 		},
+		KeepAlive: p.getKeepAlive(ctx, request),
+	}
+	if request.JSONMode {
+		apiRequest.Format = "json"
 	}
 
 	// Make streaming request
 	return p.makeStreamingRequest(ctx, apiRequest, ch)
 }
 
+// SupportsStreaming reports whether GenerateStream is supported
+func (p *OllamaProvider) SupportsStreaming() bool {
+	return true
+}
+
+// SupportsNativeTools reports whether tools are sent to the model natively.
+// Requests do not include tool definitions, so tools are prompt-based.
+func (p *OllamaProvider) SupportsNativeTools() bool {
+	return false
+}
+
+// SupportsJSONMode reports that requests can set Ollama's JSON format
+func (p *OllamaProvider) SupportsJSONMode() bool {
+	return true
+}
+
 // IsAvailable checks if the provider is available
 func (p *OllamaProvider) IsAvailable(ctx context.Context) bool {
 	if !p.isRunning {
 		return false
 	}
 
-	// Test API endpoint
-	resp, err := p.apiClient.Get(p.getAPIURL("/api/tags"))
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	
-	return resp.StatusCode == http.StatusOK
+	return p.Status(ctx).Available
 }
 
 // GetHealth returns provider health status
@@ -224,10 +344,10 @@ func (p *OllamaProvider) GetHealth(ctx context.Context) (*ProviderHealth, error)
 
 	// Test API endpoint
 	start := time.Now()
-	resp, err := p.apiClient.Get(p.getAPIURL("/api/tags"))
+	status := p.Status(ctx)
 	latency := time.Since(start)
 
-	if err != nil || resp.StatusCode != http.StatusOK {
+	if !status.Available {
 		return &ProviderHealth{
 			Status:    "degraded",
 			Latency:   latency,
@@ -239,6 +359,7 @@ func (p *OllamaProvider) GetHealth(ctx context.Context) (*ProviderHealth, error)
 
 	return &ProviderHealth{
 		Status:     "healthy",
+		Version:    status.Version,
 		Latency:    latency,
 		LastCheck:  time.Now(),
 		ErrorCount: 0,
@@ -246,6 +367,125 @@ func (p *OllamaProvider) GetHealth(ctx context.Context) (*ProviderHealth, error)
 	}, nil
 }
 
+// Unload asks Ollama to release the model immediately
+func (p *OllamaProvider) Unload(ctx context.Context, model string) error {
+	return p.postGenerate(ctx, map[string]interface{}{
+		"model":      p.getModelName(model),
+		"keep_alive": 0,
+	})
+}
+
+// Load asks Ollama to load the model without generating anything
+func (p *OllamaProvider) Load(ctx context.Context, model string) error {
+	request := map[string]interface{}{
+		"model": p.getModelName(model),
+	}
+	if p.config.KeepAlive != nil {
+		request["keep_alive"] = p.config.KeepAlive.String()
+	}
+	return p.postGenerate(ctx, request)
+}
+
+// Pull downloads a model, reporting the bytes received across all of its
+// layers on progress. progress may be nil and is closed when Pull returns.
+// A download can take far longer than the configured timeout, so only ctx
+// bounds it.
+func (p *OllamaProvider) Pull(ctx context.Context, model string, progress chan<- Progress) (err error) {
+	reporter := newProgressReporter(ctx, progress, "pull "+model, 0)
+	defer func() { reporter.finish(err) }()
+
+	requestBody, err := json.Marshal(map[string]interface{}{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.getAPIURL("/api/pull"), strings.NewReader(string(requestBody)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	stop := closeOnCancel(ctx, resp.Body)
+	defer stop()
+
+	// Ollama streams a status line per update; layers are identified by digest
+	layers := make(map[string][2]int64)
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var update struct {
+			Status    string `json:"status"`
+			Digest    string `json:"digest"`
+			Total     int64  `json:"total"`
+			Completed int64  `json:"completed"`
+		}
+		if err := decodeChunk(p.GetName(), decoder, &update); err != nil {
+			return fmt.Errorf("pull failed: %w", streamError(ctx, err))
+		}
+
+		if update.Digest != "" {
+			layers[update.Digest] = [2]int64{update.Completed, update.Total}
+		}
+		var completed, total int64
+		for _, layer := range layers {
+			completed += layer[0]
+			total += layer[1]
+		}
+		reporter.set(completed, total, update.Status)
+	}
+
+	if err := p.discoverModels(ctx); err != nil {
+		log.Printf("Warning: Failed to refresh Ollama models after pull: %v", err)
+	}
+	return nil
+}
+
+// LoadedVRAM returns the VRAM used by the models Ollama currently has loaded
+func (p *OllamaProvider) LoadedVRAM(ctx context.Context) (uint64, error) {
+	ctx, cancel := p.metadataContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.getAPIURL("/api/ps"), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Models []struct {
+			Name     string `json:"name"`
+			SizeVRAM uint64 `json:"size_vram"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var total uint64
+	for _, model := range response.Models {
+		total += model.SizeVRAM
+	}
+	return total, nil
+}
+
 // Close stops the Ollama provider
 func (p *OllamaProvider) Close() error {
 	p.isRunning = false
@@ -255,8 +495,11 @@ func (p *OllamaProvider) Close() error {
 
 // Private helper methods
 
-func (p *OllamaProvider) discoverModels() error {
-	resp, err := p.apiClient.Get(p.getAPIURL("/api/tags"))
+func (p *OllamaProvider) discoverModels(ctx context.Context) error {
+	ctx, cancel := p.metadataContext(ctx)
+	defer cancel()
+
+	resp, err := p.get(ctx, "/api/tags")
 	if err != nil {
 		return fmt.Errorf("failed to fetch models: %w", err)
 	}
@@ -278,6 +521,47 @@ func (p *OllamaProvider) discoverModels() error {
 	return nil
 }
 
+// ollamaVisionFamilies are the model families of image encoders, which Ollama
+// lists alongside the language model of vision models
+var ollamaVisionFamilies = []string{"clip", "mllama"}
+
+// isOllamaVisionModel reports whether a model accepts images, from its
+// families or, when Ollama does not list them, its name
+func isOllamaVisionModel(model OllamaModel) bool {
+	for _, family := range model.Details.Families {
+		for _, vision := range ollamaVisionFamilies {
+			if family == vision {
+				return true
+			}
+		}
+	}
+	name := strings.ToLower(model.Name)
+	for _, marker := range []string{"llava", "vision", "moondream"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError describes a failed chat request. Ollama answers 404 when the
+// model has not been pulled; the error then lists the models it does have.
+func (p *OllamaProvider) statusError(ctx context.Context, resp *http.Response, model string) error {
+	if resp.StatusCode != http.StatusNotFound {
+		return &StatusError{API: "API", StatusCode: resp.StatusCode}
+	}
+
+	notFound := &ModelNotFoundError{Provider: p.GetType(), Model: model}
+	if err := p.discoverModels(ctx); err != nil {
+		log.Printf("Warning: Failed to list Ollama models: %v", err)
+		return notFound
+	}
+	for _, available := range p.models {
+		notFound.Available = append(notFound.Available, available.Name)
+	}
+	return notFound
+}
+
 func (p *OllamaProvider) getModelName(requestedModel string) string {
 	if requestedModel != "" {
 		return requestedModel
@@ -295,6 +579,22 @@ func (p *OllamaProvider) getModelName(requestedModel string) string {
 	return "llama2" // Fallback default
 }
 
+// getKeepAlive resolves how long Ollama keeps the model loaded after the request.
+// The request value wins over the context value, which wins over the provider
+// config. An empty result leaves the choice to the Ollama server.
+func (p *OllamaProvider) getKeepAlive(ctx context.Context, request *LLMRequest) string {
+	if request.KeepAlive != nil {
+		return request.KeepAlive.String()
+	}
+	if keepAlive, ok := KeepAliveFromContext(ctx); ok {
+		return keepAlive.String()
+	}
+	if p.config.KeepAlive != nil {
+		return p.config.KeepAlive.String()
+	}
+	return ""
+}
+
 func (p *OllamaProvider) getAPIURL(path string) string {
 	baseURL := p.config.BaseURL
 	if baseURL == "" {
@@ -304,6 +604,50 @@ func (p *OllamaProvider) getAPIURL(path string) string {
 	return strings.TrimSuffix(baseURL, "/") + path
 }
 
+// metadataContext bounds a model listing or status request by the configured
+// timeout. Generation, loads and pulls are bounded only by the caller's ctx,
+// as they legitimately run for minutes.
+func (p *OllamaProvider) metadataContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
+// get sends a GET request bound to ctx
+func (p *OllamaProvider) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.getAPIURL(path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return p.apiClient.Do(req)
+}
+
+// postGenerate sends a generate request used to load or unload a model
+func (p *OllamaProvider) postGenerate(ctx context.Context, request map[string]interface{}) error {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.getAPIURL("/api/generate"), strings.NewReader(string(requestBody)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (p *OllamaProvider) makeAPIRequest(ctx context.Context, request OllamaAPIRequest) (*OllamaAPIResponse, error) {
 	url := p.getAPIURL("/api/chat")
 	
@@ -326,11 +670,11 @@ func (p *OllamaProvider) makeAPIRequest(ctx context.Context, request OllamaAPIRe
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, p.statusError(ctx, resp, request.Model)
 	}
 
 	var response OllamaAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := decodeResponse(p.GetName(), resp.Body, &response); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -338,9 +682,6 @@ func (p *OllamaProvider) makeAPIRequest(ctx context.Context, request OllamaAPIRe
 }
 
 func (p *OllamaProvider) makeStreamingRequest(ctx context.Context, request OllamaAPIRequest, ch chan<- LLMResponse) error {
-	// Simplified streaming implementation
-	// In a real implementation, this would handle Server-Sent Events (SSE)
-	
 	url := p.getAPIURL("/api/chat")
 	
 	requestBody, err := json.Marshal(request)
@@ -362,23 +703,38 @@ func (p *OllamaProvider) makeStreamingRequest(ctx context.Context, request Ollam
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	var response OllamaAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Send the complete response as a single chunk for now
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case ch <- LLMResponse{
-		ID:        uuid.New(),
-		Content:   response.Response,
-		CreatedAt: time.Now(),
-	}:
+		return p.statusError(ctx, resp, request.Model)
+	}
+
+	stop := closeOnCancel(ctx, resp.Body)
+	defer stop()
+
+	// Ollama streams newline-delimited JSON chunks until done is set
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var chunk OllamaAPIResponse
+		if err := decodeChunk(p.GetName(), decoder, &chunk); err != nil {
+			return fmt.Errorf("failed to decode response: %w", streamError(ctx, err))
+		}
+
+		content := chunk.Response
+		if chunk.Message != nil {
+			content = chunk.Message.Content
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- LLMResponse{
+			ID:        uuid.New(),
+			Content:   content,
+			CreatedAt: time.Now(),
+		}:
+		}
+
+		if chunk.Done {
+			break
+		}
 	}
 
 	return nil
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version": "0.5.7"}`))
+		case "/api/tags":
+			w.Write([]byte(`{"models": [{"name": "llama3"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	status := ProbeOllama(context.Background(), server.URL)
+	assert.Equal(t, OllamaStatus{Available: true, Version: "0.5.7"}, status)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.True(t, provider.IsAvailable(context.Background()))
+	assert.Len(t, provider.GetModels(), 1)
+
+	providerHealth, err := provider.GetHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", providerHealth.Status)
+	assert.Equal(t, "0.5.7", providerHealth.Version)
+}
+
+func TestProbeOllama_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	status := ProbeOllama(context.Background(), server.URL)
+	assert.False(t, status.Available)
+	assert.Contains(t, status.Error, "status 503")
+
+	// A stopped server is unavailable with the connection error
+	url := server.URL
+	server.Close()
+	status = ProbeOllama(context.Background(), url)
+	assert.False(t, status.Available)
+	assert.NotEmpty(t, status.Error)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: url})
+	require.NoError(t, err)
+	assert.False(t, provider.IsAvailable(context.Background()))
+	assert.Empty(t, provider.GetModels())
+}
+
+func TestProbeOllama_WithoutVersionEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	assert.Equal(t, OllamaStatus{Available: true}, ProbeOllama(context.Background(), server.URL))
+}
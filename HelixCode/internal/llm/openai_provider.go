@@ -3,11 +3,14 @@ package llm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -39,9 +42,7 @@ func NewOpenAIProvider(config ProviderConfigEntry) (*OpenAIProvider, error) {
 		config: config,
 		endpoint: endpoint,
 		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		httpClient: newProviderHTTPClient(config.HTTPClient),
 		lastHealth: &ProviderHealth{
 			Status:    "unknown",
 			LastCheck: time.Now(),
@@ -85,6 +86,11 @@ func (op *OpenAIProvider) GetCapabilities() []ModelCapability {
 
 // Generate generates a response using OpenAI models
 func (op *OpenAIProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	if err := NormalizeRequest(request); err != nil {
+		return nil, err
+	}
+
+	logRequest(op.GetName(), request, op.apiKey)
 	startTime := time.Now()
 
 	// Convert to OpenAI-compatible format
@@ -96,11 +102,12 @@ func (op *OpenAIProvider) Generate(ctx context.Context, request *LLMRequest) (*L
 	// Make request to OpenAI API
 	response, err := op.makeOpenAIRequest(ctx, openaiRequest)
 	if err != nil {
-		return nil, fmt.Errorf("OpenAI request failed: %v", err)
+		return nil, fmt.Errorf("OpenAI request failed: %w", err)
 	}
 
 	// Convert response
 	llmResponse := op.convertFromOpenAIResponse(response, request.ID, time.Since(startTime))
+	logResponse(op.GetName(), llmResponse, llmResponse.ProcessingTime, op.apiKey)
 	
 	return llmResponse, nil
 }
@@ -109,6 +116,11 @@ func (op *OpenAIProvider) Generate(ctx context.Context, request *LLMRequest) (*L
 func (op *OpenAIProvider) GenerateStream(ctx context.Context, request *LLMRequest, ch chan<- LLMResponse) error {
 	defer close(ch)
 
+	if err := NormalizeRequest(request); err != nil {
+		return err
+	}
+	logRequest(op.GetName(), request, op.apiKey)
+
 	// Convert to OpenAI-compatible format
 	openaiRequest, err := op.convertToOpenAIRequest(request)
 	if err != nil {
@@ -122,6 +134,22 @@ func (op *OpenAIProvider) GenerateStream(ctx context.Context, request *LLMReques
 	return op.makeOpenAIStreamRequest(ctx, openaiRequest, ch, request.ID)
 }
 
+// SupportsStreaming reports whether GenerateStream is supported
+func (op *OpenAIProvider) SupportsStreaming() bool {
+	return true
+}
+
+// SupportsNativeTools reports whether tools are sent to the model natively.
+// Requests do not include tool definitions yet, so tools are prompt-based.
+func (op *OpenAIProvider) SupportsNativeTools() bool {
+	return false
+}
+
+// SupportsJSONMode reports that requests can set OpenAI's JSON response format
+func (op *OpenAIProvider) SupportsJSONMode() bool {
+	return true
+}
+
 // IsAvailable checks if the provider is available
 func (op *OpenAIProvider) IsAvailable(ctx context.Context) bool {
 	health, err := op.GetHealth(ctx)
@@ -145,7 +173,7 @@ func (op *OpenAIProvider) GetHealth(ctx context.Context) (*ProviderHealth, error
 
 	if err != nil {
 		op.updateHealth("unhealthy", latency, op.lastHealth.ErrorCount+1)
-		return op.lastHealth, fmt.Errorf("health check failed: %v", err)
+		return op.lastHealth, fmt.Errorf("health check failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -234,7 +262,7 @@ func (op *OpenAIProvider) convertToOpenAIRequest(request *LLMRequest) (*OpenAIRe
 	for _, msg := range request.Messages {
 		openaiMsg := OpenAIMessage{
 			Role:    msg.Role,
-			Content: msg.Content,
+			Content: openAIContent(msg),
 		}
 		if msg.Name != "" {
 			openaiMsg.Name = msg.Name
@@ -242,22 +270,27 @@ func (op *OpenAIProvider) convertToOpenAIRequest(request *LLMRequest) (*OpenAIRe
 		messages = append(messages, openaiMsg)
 	}
 
-	return &OpenAIRequest{
+	openaiRequest := &OpenAIRequest{
 		Model:       request.Model,
 		Messages:    messages,
 		MaxTokens:   request.MaxTokens,
 		Temperature: request.Temperature,
 		TopP:        request.TopP,
 		Stream:      request.Stream,
-	}, nil
+	}
+	if request.JSONMode {
+		openaiRequest.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+	}
+	return openaiRequest, nil
 }
 
 func (op *OpenAIProvider) convertFromOpenAIResponse(openaiResp *OpenAIResponse, requestID uuid.UUID, processingTime time.Duration) *LLMResponse {
-	var content string
+	var content, finishReason string
 
 	if len(openaiResp.Choices) > 0 {
 		choice := openaiResp.Choices[0]
 		content = choice.Message.Content
+		finishReason = choice.FinishReason
 	}
 
 	return &LLMResponse{
@@ -269,7 +302,7 @@ func (op *OpenAIProvider) convertFromOpenAIResponse(openaiResp *OpenAIResponse,
 			CompletionTokens: openaiResp.Usage.CompletionTokens,
 			TotalTokens:      openaiResp.Usage.TotalTokens,
 		},
-		FinishReason:   openaiResp.Choices[0].FinishReason,
+		FinishReason:   finishReason,
 		ProcessingTime: processingTime,
 		CreatedAt:      time.Now(),
 	}
@@ -296,14 +329,16 @@ func (op *OpenAIProvider) makeOpenAIRequest(ctx context.Context, request *OpenAI
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError("OpenAI API", resp)
 	}
 
 	var response OpenAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := decodeResponse(op.GetName(), resp.Body, &response); err != nil {
 		return nil, err
 	}
+	if len(response.Choices) == 0 {
+		return nil, &ResponseError{Provider: op.GetName(), Field: "choices", Reason: "missing"}
+	}
 
 	return &response, nil
 }
@@ -329,16 +364,29 @@ func (op *OpenAIProvider) makeOpenAIStreamRequest(ctx context.Context, request *
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("OpenAI API returned status %d: %s", resp.StatusCode, string(body))
+		return newStatusError("OpenAI API", resp)
 	}
 
-	// Stream responses
-	decoder := json.NewDecoder(resp.Body)
-	for decoder.More() {
+	stop := closeOnCancel(ctx, resp.Body)
+	defer stop()
+
+	// Chunks arrive as server-sent events until a [DONE] event
+	events := NewSSEReader(resp.Body)
+	for {
+		event, err := events.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", streamError(ctx, err))
+		}
+		if event.Data == sseDone {
+			return nil
+		}
+
 		var streamResp OpenAIStreamResponse
-		if err := decoder.Decode(&streamResp); err != nil {
-			return err
+		if err := decodeResponse(op.GetName(), strings.NewReader(event.Data), &streamResp); err != nil {
+			return streamError(ctx, err)
 		}
 
 		if len(streamResp.Choices) > 0 {
@@ -359,12 +407,10 @@ func (op *OpenAIProvider) makeOpenAIStreamRequest(ctx context.Context, request *
 			}
 		}
 
-		if streamResp.Choices[0].FinishReason != "" {
-			break
+		if len(streamResp.Choices) > 0 && streamResp.Choices[0].FinishReason != "" {
+			return nil
 		}
 	}
-
-	return nil
 }
 
 func (op *OpenAIProvider) setAuthHeaders(req *http.Request) {
@@ -387,12 +433,46 @@ type OpenAIRequest struct {
 	Temperature float64         `json:"temperature,omitempty"`
 	TopP        float64         `json:"top_p,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat selects JSON mode with type "json_object"
+type OpenAIResponseFormat struct {
+	Type string `json:"type"`
 }
 
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-	Name    string `json:"name,omitempty"`
+	Role string `json:"role"`
+	// Content is a string, or []OpenAIContentPart for messages with images
+	Content interface{} `json:"content"`
+	Name    string      `json:"name,omitempty"`
+}
+
+// OpenAIContentPart is one part of a multimodal message
+type OpenAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+}
+
+// OpenAIImageURL references an image, here always inline as a data URL
+type OpenAIImageURL struct {
+	URL string `json:"url"`
+}
+
+// openAIContent returns the text of a message, or text and image_url parts
+// when it carries images
+func openAIContent(msg Message) interface{} {
+	if len(msg.Images) == 0 {
+		return msg.Content
+	}
+
+	parts := []OpenAIContentPart{{Type: "text", Text: msg.Content}}
+	for _, image := range msg.Images {
+		url := fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(image), base64.StdEncoding.EncodeToString(image))
+		parts = append(parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: url}})
+	}
+	return parts
 }
 
 type OpenAIResponse struct {
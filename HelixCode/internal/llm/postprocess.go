@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PostProcessor transforms the text of a generation, e.g. to strip markdown
+type PostProcessor func(text string) string
+
+// Built-in post-processors
+const (
+	PostProcessTrim        = "trim"         // Trims surrounding whitespace
+	PostProcessStripFences = "strip-fences" // Removes markdown code fence lines, keeping their content
+	PostProcessExtractCode = "extract-code" // Keeps only the first fenced code block, if there is one
+)
+
+var (
+	postProcessors   = make(map[string]PostProcessor)
+	postProcessorsMu sync.RWMutex
+)
+
+func init() {
+	builtins := map[string]PostProcessor{
+		PostProcessTrim:        strings.TrimSpace,
+		PostProcessStripFences: StripCodeFences,
+		PostProcessExtractCode: ExtractCode,
+	}
+
+	for name, processor := range builtins {
+		if err := RegisterPostProcessor(name, processor); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterPostProcessor registers a post-processor that pipelines can refer
+// to by name
+func RegisterPostProcessor(name string, processor PostProcessor) error {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return fmt.Errorf("post-processor name cannot be empty")
+	}
+	if processor == nil {
+		return fmt.Errorf("post-processor %s cannot be nil", key)
+	}
+
+	postProcessorsMu.Lock()
+	defer postProcessorsMu.Unlock()
+
+	if _, exists := postProcessors[key]; exists {
+		return fmt.Errorf("post-processor %s already registered", key)
+	}
+	postProcessors[key] = processor
+	return nil
+}
+
+// UnregisterPostProcessor removes a registered post-processor
+func UnregisterPostProcessor(name string) {
+	postProcessorsMu.Lock()
+	defer postProcessorsMu.Unlock()
+
+	delete(postProcessors, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// RegisteredPostProcessors returns the sorted names of registered post-processors
+func RegisteredPostProcessors() []string {
+	postProcessorsMu.RLock()
+	defer postProcessorsMu.RUnlock()
+
+	names := make([]string, 0, len(postProcessors))
+	for name := range postProcessors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PostProcessorPipeline applies post-processors to generated text in order
+type PostProcessorPipeline struct {
+	names      []string
+	processors []PostProcessor
+}
+
+// NewPostProcessorPipeline builds a pipeline from registered post-processors,
+// applied in the order given
+func NewPostProcessorPipeline(names ...string) (*PostProcessorPipeline, error) {
+	postProcessorsMu.RLock()
+	defer postProcessorsMu.RUnlock()
+
+	pipeline := &PostProcessorPipeline{}
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		processor, exists := postProcessors[key]
+		if !exists {
+			return nil, fmt.Errorf("unknown post-processor %q", name)
+		}
+		pipeline.names = append(pipeline.names, key)
+		pipeline.processors = append(pipeline.processors, processor)
+	}
+	return pipeline, nil
+}
+
+// Then appends an unregistered post-processor to the pipeline
+func (p *PostProcessorPipeline) Then(name string, processor PostProcessor) *PostProcessorPipeline {
+	p.names = append(p.names, name)
+	p.processors = append(p.processors, processor)
+	return p
+}
+
+// Names returns the post-processors of the pipeline in order
+func (p *PostProcessorPipeline) Names() []string {
+	return append([]string(nil), p.names...)
+}
+
+// Process runs text through every post-processor in order
+func (p *PostProcessorPipeline) Process(text string) string {
+	if p == nil {
+		return text
+	}
+	for _, processor := range p.processors {
+		text = processor(text)
+	}
+	return text
+}
+
+// Apply post-processes a response's content in place
+func (p *PostProcessorPipeline) Apply(response *LLMResponse) {
+	if response != nil {
+		response.Content = p.Process(response.Content)
+	}
+}
+
+// ExtractCodeBlock returns the content of the first fenced code block whose
+// language is language, or of any language when it is empty. A block left
+// open runs to the end of the text.
+func ExtractCodeBlock(text, language string) (string, bool) {
+	var block []string
+	inBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		info, isFence := fenceInfo(line)
+		if !inBlock {
+			if isFence && (language == "" || strings.EqualFold(firstWord(info), language)) {
+				inBlock = true
+			}
+			continue
+		}
+		if isFence && info == "" {
+			break
+		}
+		block = append(block, line)
+	}
+	if !inBlock {
+		return "", false
+	}
+	return strings.Join(block, "\n"), true
+}
+
+// ExtractCode returns the content of the first fenced code block, or the
+// text unchanged if it has none
+func ExtractCode(text string) string {
+	if code, ok := ExtractCodeBlock(text, ""); ok {
+		return code
+	}
+	return text
+}
+
+// StripCodeFences removes the fence lines of markdown code blocks, keeping
+// their content and the text around them
+func StripCodeFences(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if _, isFence := fenceInfo(line); !isFence {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// fenceInfo reports whether line is a code fence and returns its info
+// string, such as the block's language
+func fenceInfo(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, fence) {
+			return strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])), true
+		}
+	}
+	return "", false
+}
+
+// firstWord returns the first whitespace-separated word of s
+func firstWord(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
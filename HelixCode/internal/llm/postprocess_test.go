@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fencedResponse = "Here is the function:\n\n```go\nfunc add(a, b int) int {\n\treturn a + b\n}\n```\n\nIt adds two numbers.\n"
+
+func TestPostProcessorPipeline_Chained(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors []string
+		input      string
+		expected   string
+	}{
+		{
+			name:       "extract code then trim",
+			processors: []string{PostProcessExtractCode, PostProcessTrim},
+			input:      fencedResponse,
+			expected:   "func add(a, b int) int {\n\treturn a + b\n}",
+		},
+		{
+			name:       "strip fences then trim",
+			processors: []string{PostProcessStripFences, PostProcessTrim},
+			input:      fencedResponse,
+			expected:   "Here is the function:\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n\nIt adds two numbers.",
+		},
+		{
+			name:       "extract code without a block keeps the text",
+			processors: []string{PostProcessExtractCode, PostProcessTrim},
+			input:      "  just prose  \n",
+			expected:   "just prose",
+		},
+		{
+			name:     "empty pipeline",
+			input:    fencedResponse,
+			expected: fencedResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewPostProcessorPipeline(tt.processors...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pipeline.Process(tt.input))
+		})
+	}
+}
+
+func TestPostProcessorPipeline_Custom(t *testing.T) {
+	require.NoError(t, RegisterPostProcessor("upper", strings.ToUpper))
+	defer UnregisterPostProcessor("upper")
+	assert.Error(t, RegisterPostProcessor("upper", strings.ToUpper), "duplicate names are rejected")
+	assert.Contains(t, RegisteredPostProcessors(), "upper")
+
+	pipeline, err := NewPostProcessorPipeline(PostProcessExtractCode, "upper")
+	require.NoError(t, err)
+	pipeline.Then("suffix", func(text string) string { return text + ";" })
+	assert.Equal(t, []string{PostProcessExtractCode, "upper", "suffix"}, pipeline.Names())
+
+	response := &LLMResponse{Content: "```sql\nselect 1\n```"}
+	pipeline.Apply(response)
+	assert.Equal(t, "SELECT 1;", response.Content)
+
+	_, err = NewPostProcessorPipeline("missing")
+	assert.Error(t, err)
+}
+
+func TestExtractCodeBlock(t *testing.T) {
+	text := "```python\nprint(1)\n```\n```go\npackage main\n```"
+
+	code, ok := ExtractCodeBlock(text, "go")
+	assert.True(t, ok)
+	assert.Equal(t, "package main", code)
+
+	code, ok = ExtractCodeBlock(text, "")
+	assert.True(t, ok)
+	assert.Equal(t, "print(1)", code)
+
+	_, ok = ExtractCodeBlock(text, "rust")
+	assert.False(t, ok)
+
+	code, ok = ExtractCodeBlock("~~~\nunclosed\nblock", "")
+	assert.True(t, ok)
+	assert.Equal(t, "unclosed\nblock", code)
+}
@@ -0,0 +1,338 @@
+// Package probe measures what a model can do by sending it reasoning, tool
+// calling and code generation prompts and scoring the responses.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"dev.helix.code/internal/llm"
+)
+
+// Category groups probes by the capability they measure
+type Category string
+
+const (
+	CategoryReasoning      Category = "reasoning"
+	CategoryToolCalling    Category = "tool_calling"
+	CategoryCodeGeneration Category = "code_generation"
+)
+
+// DefaultProbeTimeout bounds a single probe request
+const DefaultProbeTimeout = 90 * time.Second
+
+// Result is the outcome of a single probe
+type Result struct {
+	Name      string        `json:"name"`
+	Category  Category      `json:"category"`
+	Score     int           `json:"score"`
+	MaxScore  int           `json:"max_score"`
+	Threshold int           `json:"threshold"`
+	Passed    bool          `json:"passed"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// CategorySummary counts the passed probes in a category
+type CategorySummary struct {
+	Passed int `json:"passed"`
+	Total  int `json:"total"`
+}
+
+// CapabilityReport summarizes how a model performed across all probes
+type CapabilityReport struct {
+	Model      string                        `json:"model"`
+	Provider   string                        `json:"provider"`
+	Results    []Result                      `json:"results"`
+	Categories map[Category]*CategorySummary `json:"categories"`
+	// Score is the fraction of available points earned, between 0 and 1
+	Score float64 `json:"score"`
+	// Passed is set when the model passed enough probes to be used for
+	// development work
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration"`
+}
+
+// PassThreshold is the number of probes a model must pass
+const PassThreshold = 4
+
+type probeSpec struct {
+	name        string
+	category    Category
+	prompt      string
+	temperature float64
+	maxTokens   int
+	maxScore    int
+	threshold   int
+	score       func(content string) int
+}
+
+// probes are run in order by ProbeModel
+var probes = []probeSpec{
+	{
+		name:        "reasoning:cycle-detection",
+		category:    CategoryReasoning,
+		prompt:      reasoningPrompt("Design an algorithm to detect cycles in a linked list"),
+		temperature: 0.3,
+		maxTokens:   1000,
+		maxScore:    maxReasoningScore,
+		threshold:   5,
+		score:       scoreReasoning,
+	},
+	{
+		name:        "reasoning:architecture",
+		category:    CategoryReasoning,
+		prompt:      reasoningPrompt("Plan a microservices architecture for an e-commerce platform"),
+		temperature: 0.3,
+		maxTokens:   1000,
+		maxScore:    maxReasoningScore,
+		threshold:   5,
+		score:       scoreReasoning,
+	},
+	{
+		name:        "tool_calling:create_file",
+		category:    CategoryToolCalling,
+		prompt:      toolCallingPrompt,
+		temperature: 0.7,
+		maxTokens:   500,
+		maxScore:    maxToolCallingScore,
+		threshold:   4,
+		score:       scoreToolCalling,
+	},
+	{
+		name:        "code_generation:simple",
+		category:    CategoryCodeGeneration,
+		prompt:      "Create a Go function that reverses a string. Return only the code without explanations.",
+		temperature: 0.3,
+		maxTokens:   1500,
+		maxScore:    5,
+		threshold:   4,
+		score:       func(content string) int { return scoreCodeGeneration(content, "simple") },
+	},
+	{
+		name:        "code_generation:medium",
+		category:    CategoryCodeGeneration,
+		prompt:      "Create a Go HTTP middleware that logs requests and responses with timing information. Return only the code without explanations.",
+		temperature: 0.3,
+		maxTokens:   1500,
+		maxScore:    6,
+		threshold:   5,
+		score:       func(content string) int { return scoreCodeGeneration(content, "medium") },
+	},
+}
+
+// ProbeModel runs every probe against model through provider. Failed requests
+// score zero and are recorded in the result's Error rather than aborting the
+// report.
+func ProbeModel(ctx context.Context, provider llm.Provider, model string) CapabilityReport {
+	start := time.Now()
+	report := CapabilityReport{
+		Model:      model,
+		Provider:   provider.GetName(),
+		Categories: make(map[Category]*CategorySummary),
+	}
+
+	earned, available, passed := 0, 0, 0
+	for _, spec := range probes {
+		result := runProbe(ctx, provider, model, spec)
+		report.Results = append(report.Results, result)
+
+		summary, exists := report.Categories[spec.category]
+		if !exists {
+			summary = &CategorySummary{}
+			report.Categories[spec.category] = summary
+		}
+		summary.Total++
+		if result.Passed {
+			summary.Passed++
+			passed++
+		}
+
+		earned += result.Score
+		available += result.MaxScore
+	}
+
+	if available > 0 {
+		report.Score = float64(earned) / float64(available)
+	}
+	report.Passed = passed >= PassThreshold
+	report.Duration = time.Since(start)
+	return report
+}
+
+// RankModels orders reports from most to least capable
+func RankModels(reports []CapabilityReport) []CapabilityReport {
+	ranked := append([]CapabilityReport(nil), reports...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Passed != ranked[j].Passed {
+			return ranked[i].Passed
+		}
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+func runProbe(ctx context.Context, provider llm.Provider, model string, spec probeSpec) Result {
+	start := time.Now()
+	result := Result{
+		Name:      spec.name,
+		Category:  spec.category,
+		MaxScore:  spec.maxScore,
+		Threshold: spec.threshold,
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, DefaultProbeTimeout)
+	defer cancel()
+
+	resp, err := provider.Generate(probeCtx, &llm.LLMRequest{
+		Model:       model,
+		Messages:    []llm.Message{{Role: "user", Content: spec.prompt}},
+		MaxTokens:   spec.maxTokens,
+		Temperature: spec.temperature,
+	})
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if resp == nil || strings.TrimSpace(resp.Content) == "" {
+		result.Error = llm.ErrEmptyResponse.Error()
+		return result
+	}
+
+	result.Score = spec.score(resp.Content)
+	if result.Score > result.MaxScore {
+		result.Score = result.MaxScore
+	}
+	result.Passed = result.Score >= spec.threshold
+	return result
+}
+
+func reasoningPrompt(problem string) string {
+	return fmt.Sprintf(`Think step by step about this problem:
+
+%s
+
+Show your reasoning process clearly with steps.`, problem)
+}
+
+const toolCallingPrompt = `You have access to these tools:
+
+- create_file: Create a new file with content
+  Parameters: filename (string), content (string)
+
+- run_tests: Execute tests in a directory
+  Parameters: directory (string), verbose (boolean)
+
+- git_commit: Commit changes to git
+  Parameters: message (string), files (array of strings)
+
+When you need to use a tool, respond in this exact format:
+TOOL: tool_name
+ARGS: {"param1": "value1", "param2": "value2"}
+
+User request: Create a new Go file called "utils.go" with helper functions for string manipulation.
+
+Respond with tool calls if needed:`
+
+const (
+	maxReasoningScore   = 12
+	maxToolCallingScore = 8
+)
+
+// scoreReasoning rewards reasoning vocabulary and explicitly structured steps
+func scoreReasoning(content string) int {
+	lower := strings.ToLower(content)
+	score := 0
+	for _, indicator := range []string{"step", "first", "then", "next", "therefore", "conclusion", "reason", "because"} {
+		if strings.Contains(lower, indicator) {
+			score++
+		}
+	}
+	if strings.Contains(content, "1.") && strings.Contains(content, "2.") {
+		score += 2
+	}
+	if strings.Contains(content, "Step 1:") || strings.Contains(content, "First,") {
+		score += 2
+	}
+	return score
+}
+
+// scoreToolCalling rewards responses that follow the requested tool call format
+func scoreToolCalling(content string) int {
+	score := 0
+	if strings.Contains(content, "TOOL:") {
+		score += 3
+	}
+	if strings.Contains(content, "create_file") {
+		score += 2
+	}
+	if strings.Contains(content, "ARGS:") || strings.Contains(content, `{"filename"`) {
+		score += 2
+	}
+	if strings.Contains(content, "utils.go") || strings.Contains(content, "string manipulation") {
+		score++
+	}
+	return score
+}
+
+// scoreCodeGeneration rewards valid-looking Go code with the structure the
+// prompt of the given complexity asked for
+func scoreCodeGeneration(content, complexity string) int {
+	code := ExtractGoCode(content)
+	if code == "" {
+		return 0
+	}
+
+	score := 0
+	for _, keyword := range []string{"package ", "import ", "func "} {
+		if strings.Contains(code, keyword) {
+			score++
+		}
+	}
+
+	switch complexity {
+	case "simple":
+		if strings.Contains(code, "string") && strings.Contains(code, "range") {
+			score += 2
+		}
+	case "medium":
+		if strings.Contains(code, "http.Handler") && strings.Contains(code, "middleware") {
+			score += 2
+		}
+		if strings.Contains(code, "time.Now()") || strings.Contains(code, "duration") {
+			score++
+		}
+	}
+	return score
+}
+
+// ExtractGoCode returns the Go code in a model response, taken from a ```go
+// fenced block or, failing that, from the first package declaration on
+func ExtractGoCode(response string) string {
+	if code, ok := llm.ExtractCodeBlock(response, "go"); ok {
+		return strings.TrimSpace(code)
+	}
+
+	var codeLines []string
+	inCode := false
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "package ") {
+			inCode = true
+		}
+		if !inCode {
+			continue
+		}
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "explanation") || strings.Contains(lower, "note:") {
+			break
+		}
+		codeLines = append(codeLines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(codeLines, "\n"))
+}
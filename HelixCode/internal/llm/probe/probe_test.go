@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	goodReasoning = `Step 1: First, we need a reason to look at the list.
+1. Use two pointers, then advance them at different speeds.
+2. Next, if they meet there is a cycle, because the fast pointer laps the slow one.
+Therefore, the conclusion is Floyd's algorithm.`
+
+	goodToolCall = `TOOL: create_file
+ARGS: {"filename": "utils.go", "content": "package utils"}`
+
+	goodSimpleCode = "```go\npackage main\n\nimport \"fmt\"\n\nfunc Reverse(s string) string {\n\tr := []rune(s)\n\tfor i, j := range r {\n\t\t_ = j\n\t\t_ = i\n\t}\n\treturn string(r)\n}\n```"
+
+	goodMiddleware = "```go\npackage middleware\n\nimport (\n\t\"net/http\"\n\t\"time\"\n)\n\n// Logging middleware\nfunc Logging(next http.Handler) http.Handler {\n\treturn http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {\n\t\tstart := time.Now()\n\t\tnext.ServeHTTP(w, r)\n\t})\n}\n```"
+)
+
+// newProbeServer serves Ollama chat requests. The "capable" model answers
+// every probe well, "broken" fails, and any other model gives a vague reply.
+func newProbeServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+
+		var req llm.OllamaAPIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		prompt := req.Messages[len(req.Messages)-1].Content
+
+		if req.Model == "broken" {
+			http.Error(w, "model failed to load", http.StatusInternalServerError)
+			return
+		}
+
+		answer := "I would first create utils.go, but I am not sure."
+		if req.Model == "capable" {
+			switch {
+			case strings.Contains(prompt, "Think step by step"):
+				answer = goodReasoning
+			case strings.Contains(prompt, "TOOL: tool_name"):
+				answer = goodToolCall
+			case strings.Contains(prompt, "reverses a string"):
+				answer = goodSimpleCode
+			case strings.Contains(prompt, "middleware"):
+				answer = goodMiddleware
+			}
+		}
+		json.NewEncoder(w).Encode(llm.OllamaAPIResponse{Model: req.Model, Response: answer, Done: true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestProbeModel tests scoring of capable, weak and failing models
+func TestProbeModel(t *testing.T) {
+	server := newProbeServer(t)
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	capable := ProbeModel(ctx, provider, "capable")
+	assert.Equal(t, "capable", capable.Model)
+	assert.Equal(t, "ollama", capable.Provider)
+	require.Len(t, capable.Results, len(probes))
+	for _, result := range capable.Results {
+		assert.True(t, result.Passed, "%s scored %d/%d", result.Name, result.Score, result.Threshold)
+		assert.Empty(t, result.Error)
+	}
+	assert.True(t, capable.Passed)
+	assert.Equal(t, &CategorySummary{Passed: 2, Total: 2}, capable.Categories[CategoryReasoning])
+	assert.Equal(t, &CategorySummary{Passed: 1, Total: 1}, capable.Categories[CategoryToolCalling])
+	assert.Equal(t, &CategorySummary{Passed: 2, Total: 2}, capable.Categories[CategoryCodeGeneration])
+	assert.Greater(t, capable.Score, 0.8)
+
+	weak := ProbeModel(ctx, provider, "weak")
+	assert.False(t, weak.Passed)
+	assert.Less(t, weak.Score, capable.Score)
+	for _, result := range weak.Results {
+		assert.False(t, result.Passed)
+	}
+
+	broken := ProbeModel(ctx, provider, "broken")
+	assert.False(t, broken.Passed)
+	assert.Zero(t, broken.Score)
+	for _, result := range broken.Results {
+		assert.NotEmpty(t, result.Error)
+	}
+
+	ranked := RankModels([]CapabilityReport{broken, weak, capable})
+	assert.Equal(t, []string{"capable", "weak", "broken"}, []string{ranked[0].Model, ranked[1].Model, ranked[2].Model})
+}
+
+// TestScoring tests the individual scoring functions
+func TestScoring(t *testing.T) {
+	assert.Equal(t, maxReasoningScore, scoreReasoning(goodReasoning))
+	assert.Zero(t, scoreReasoning("42"))
+
+	assert.Equal(t, maxToolCallingScore, scoreToolCalling(goodToolCall))
+	assert.Equal(t, 1, scoreToolCalling("I would create utils.go"))
+
+	assert.Equal(t, 5, scoreCodeGeneration(goodSimpleCode, "simple"))
+	assert.Equal(t, 6, scoreCodeGeneration(goodMiddleware, "medium"))
+	assert.Zero(t, scoreCodeGeneration("Use strings.Builder.", "simple"))
+}
+
+// TestExtractGoCode tests extracting code from fenced and unfenced responses
+func TestExtractGoCode(t *testing.T) {
+	assert.Equal(t, "package main\n\nfunc main() {}", ExtractGoCode("Here:\n```go\npackage main\n\nfunc main() {}\n```\nDone."))
+	assert.Equal(t, "package main\n\nfunc main() {}", ExtractGoCode("Sure.\npackage main\n\nfunc main() {}\nExplanation: it does nothing."))
+	assert.Empty(t, ExtractGoCode("No code here."))
+}
@@ -0,0 +1,178 @@
+package probe
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"dev.helix.code/internal/llm"
+)
+
+// DefaultBenchmarkRuns is how many timed requests Benchmark makes by default
+const DefaultBenchmarkRuns = 3
+
+// LatencyWeight is the share of a ranking score that comes from latency; the
+// rest comes from the capability probes
+const LatencyWeight = 0.2
+
+// benchmarkPrompt asks for a short, fixed answer so that the timing measures
+// the model's responsiveness rather than the length of its reply
+const benchmarkPrompt = "Reply with the single word OK."
+
+// BenchmarkResult is the latency of a model answering a short prompt
+type BenchmarkResult struct {
+	Runs   int           `json:"runs"`
+	Median time.Duration `json:"median"`
+	// TokensPerSecond is measured when the provider reports token usage
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Benchmark times runs short generations of model and reports the median.
+// It stops at the first failed request, recording its error.
+func Benchmark(ctx context.Context, provider llm.Provider, model string, runs int) BenchmarkResult {
+	if runs <= 0 {
+		runs = DefaultBenchmarkRuns
+	}
+
+	var durations []time.Duration
+	tokens := 0
+	var total time.Duration
+	for i := 0; i < runs; i++ {
+		runCtx, cancel := context.WithTimeout(ctx, DefaultProbeTimeout)
+		start := time.Now()
+		resp, err := provider.Generate(runCtx, &llm.LLMRequest{
+			Model:     model,
+			Messages:  []llm.Message{{Role: "user", Content: benchmarkPrompt}},
+			MaxTokens: 16,
+		})
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			return BenchmarkResult{Runs: len(durations), Error: err.Error()}
+		}
+
+		durations = append(durations, elapsed)
+		total += elapsed
+		if resp != nil {
+			tokens += resp.Usage.CompletionTokens
+		}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	result := BenchmarkResult{Runs: len(durations), Median: durations[len(durations)/2]}
+	if tokens > 0 && total > 0 {
+		result.TokensPerSecond = float64(tokens) / total.Seconds()
+	}
+	return result
+}
+
+// Candidate is a model to rank, with the provider that serves it
+type Candidate struct {
+	Model    string
+	Provider llm.Provider
+	// FitsHardware is false for models too large for this machine, which
+	// are left out of the ranking without being probed
+	FitsHardware bool
+}
+
+// Ranking is a model's place in a ranking with the scores behind it
+type Ranking struct {
+	Rank     int    `json:"rank"`
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+	// Category scores are the fraction of probe points earned, 0 to 1
+	Reasoning      float64 `json:"reasoning"`
+	ToolCalling    float64 `json:"tool_calling"`
+	CodeGeneration float64 `json:"code_generation"`
+	// Latency is 1 for the fastest ranked model, falling with its median
+	Latency   float64          `json:"latency"`
+	Score     float64          `json:"score"`
+	Passed    bool             `json:"passed"`
+	Benchmark BenchmarkResult  `json:"benchmark"`
+	Report    CapabilityReport `json:"report"`
+}
+
+// RankCandidates probes and benchmarks every candidate that fits the
+// hardware and returns them ranked, best first
+func RankCandidates(ctx context.Context, candidates []Candidate, runs int, progress func(model string)) []Ranking {
+	var rankings []Ranking
+	for _, candidate := range candidates {
+		if !candidate.FitsHardware {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if progress != nil {
+			progress(candidate.Model)
+		}
+
+		report := ProbeModel(ctx, candidate.Provider, candidate.Model)
+		rankings = append(rankings, Ranking{
+			Model:     candidate.Model,
+			Provider:  report.Provider,
+			Report:    report,
+			Passed:    report.Passed,
+			Benchmark: Benchmark(ctx, candidate.Provider, candidate.Model, runs),
+		})
+	}
+	return Rank(rankings)
+}
+
+// Rank scores rankings from their reports and benchmarks and orders them,
+// best first. Models that passed the probes come before those that did not.
+func Rank(rankings []Ranking) []Ranking {
+	ranked := append([]Ranking(nil), rankings...)
+
+	var fastest time.Duration
+	for _, ranking := range ranked {
+		median := ranking.Benchmark.Median
+		if ranking.Benchmark.Error == "" && median > 0 && (fastest == 0 || median < fastest) {
+			fastest = median
+		}
+	}
+
+	for i := range ranked {
+		ranking := &ranked[i]
+		ranking.Reasoning = categoryScore(ranking.Report, CategoryReasoning)
+		ranking.ToolCalling = categoryScore(ranking.Report, CategoryToolCalling)
+		ranking.CodeGeneration = categoryScore(ranking.Report, CategoryCodeGeneration)
+
+		ranking.Latency = 0
+		if ranking.Benchmark.Error == "" && ranking.Benchmark.Median > 0 {
+			ranking.Latency = float64(fastest) / float64(ranking.Benchmark.Median)
+		}
+		ranking.Score = (1-LatencyWeight)*ranking.Report.Score + LatencyWeight*ranking.Latency
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Passed != ranked[j].Passed {
+			return ranked[i].Passed
+		}
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return strings.Compare(ranked[i].Model, ranked[j].Model) < 0
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked
+}
+
+// categoryScore returns the fraction of a category's probe points a report earned
+func categoryScore(report CapabilityReport, category Category) float64 {
+	earned, available := 0, 0
+	for _, result := range report.Results {
+		if result.Category == category {
+			earned += result.Score
+			available += result.MaxScore
+		}
+	}
+	if available == 0 {
+		return 0
+	}
+	return float64(earned) / float64(available)
+}
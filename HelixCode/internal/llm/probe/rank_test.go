@@ -0,0 +1,70 @@
+package probe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRankCandidates tests ranking probed models and leaving out those that do not fit
+func TestRankCandidates(t *testing.T) {
+	server := newProbeServer(t)
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	var probed []string
+	rankings := RankCandidates(context.Background(), []Candidate{
+		{Model: "weak", Provider: provider, FitsHardware: true},
+		{Model: "huge", Provider: provider, FitsHardware: false},
+		{Model: "broken", Provider: provider, FitsHardware: true},
+		{Model: "capable", Provider: provider, FitsHardware: true},
+	}, 2, func(model string) { probed = append(probed, model) })
+
+	assert.Equal(t, []string{"weak", "broken", "capable"}, probed)
+	require.Len(t, rankings, 3)
+	assert.Equal(t, []string{"capable", "weak", "broken"}, []string{rankings[0].Model, rankings[1].Model, rankings[2].Model})
+	for i, ranking := range rankings {
+		assert.Equal(t, i+1, ranking.Rank)
+		assert.Equal(t, "ollama", ranking.Provider)
+	}
+
+	capable := rankings[0]
+	assert.True(t, capable.Passed)
+	assert.Equal(t, 1.0, capable.ToolCalling)
+	assert.Greater(t, capable.Reasoning, 0.8)
+	assert.Greater(t, capable.CodeGeneration, 0.8)
+	assert.Equal(t, 2, capable.Benchmark.Runs)
+	assert.Empty(t, capable.Benchmark.Error)
+
+	broken := rankings[2]
+	assert.NotEmpty(t, broken.Benchmark.Error)
+	assert.Zero(t, broken.Latency)
+	assert.Zero(t, broken.Score)
+}
+
+// TestRank tests combining capability and latency scores
+func TestRank(t *testing.T) {
+	report := func(score float64, passed bool) CapabilityReport {
+		return CapabilityReport{Score: score, Passed: passed}
+	}
+
+	rankings := Rank([]Ranking{
+		{Model: "slow", Passed: true, Report: report(0.9, true), Benchmark: BenchmarkResult{Median: 4 * time.Second}},
+		{Model: "fast", Passed: true, Report: report(0.9, true), Benchmark: BenchmarkResult{Median: time.Second}},
+		{Model: "quick-but-weak", Report: report(0.3, false), Benchmark: BenchmarkResult{Median: 500 * time.Millisecond}},
+	})
+
+	require.Len(t, rankings, 3)
+	assert.Equal(t, "fast", rankings[0].Model)
+	assert.Equal(t, "slow", rankings[1].Model)
+	assert.Equal(t, "quick-but-weak", rankings[2].Model, "models that failed the probes rank last")
+
+	assert.Equal(t, 0.5, rankings[0].Latency)
+	assert.Equal(t, 0.125, rankings[1].Latency)
+	assert.Equal(t, 1.0, rankings[2].Latency)
+	assert.InDelta(t, 0.8*0.9+0.2*0.5, rankings[0].Score, 1e-9)
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Progress reports how far a long-running operation such as a model pull,
+// warmup or batch has got. Completed never decreases, and the last event sent
+// before the channel is closed has Done set.
+type Progress struct {
+	Operation string `json:"operation"`
+	Message   string `json:"message,omitempty"`
+	Completed int64  `json:"completed"`
+	Total     int64  `json:"total"`
+	Done      bool   `json:"done"`
+}
+
+// Fraction returns the completed share between 0 and 1, or 0 while the total is unknown
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		if p.Done {
+			return 1
+		}
+		return 0
+	}
+	if p.Completed >= p.Total {
+		return 1
+	}
+	return float64(p.Completed) / float64(p.Total)
+}
+
+// progressReporter sends monotonic progress to an optional channel and closes
+// it exactly once. Intermediate updates are dropped when the channel is full so
+// a slow reader never stalls the operation; the final update waits for the
+// reader unless ctx ends.
+type progressReporter struct {
+	ctx       context.Context
+	ch        chan<- Progress
+	operation string
+
+	mu        sync.Mutex
+	completed int64
+	total     int64
+	closed    bool
+}
+
+func newProgressReporter(ctx context.Context, ch chan<- Progress, operation string, total int64) *progressReporter {
+	return &progressReporter{ctx: ctx, ch: ch, operation: operation, total: total}
+}
+
+// add advances progress by n
+func (r *progressReporter) add(n int64, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(r.completed+n, r.total, message)
+}
+
+// set reports absolute progress, ignoring any step backwards
+func (r *progressReporter) set(completed, total int64, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(completed, total, message)
+}
+
+func (r *progressReporter) setLocked(completed, total int64, message string) {
+	if r.closed {
+		return
+	}
+	if completed > r.completed {
+		r.completed = completed
+	}
+	if total > r.total {
+		r.total = total
+	}
+	if r.ch == nil {
+		return
+	}
+
+	select {
+	case r.ch <- r.snapshot(message, false):
+	default:
+	}
+}
+
+// finish sends the final event, carrying err's message if the operation
+// failed, and closes the channel. Later calls do nothing.
+func (r *progressReporter) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.closed = true
+	if r.ch == nil {
+		return
+	}
+
+	message := "completed"
+	if err != nil {
+		message = err.Error()
+	}
+
+	select {
+	case r.ch <- r.snapshot(message, true):
+	case <-r.ctx.Done():
+	}
+	close(r.ch)
+}
+
+func (r *progressReporter) snapshot(message string, done bool) Progress {
+	return Progress{
+		Operation: r.operation,
+		Message:   message,
+		Completed: r.completed,
+		Total:     r.total,
+		Done:      done,
+	}
+}
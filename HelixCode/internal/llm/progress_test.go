@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectProgress drains a progress channel until it is closed
+func collectProgress(ch <-chan Progress) []Progress {
+	var events []Progress
+	for event := range ch {
+		events = append(events, event)
+	}
+	return events
+}
+
+// assertMonotonic checks completed never decreases and only the last event is done
+func assertMonotonic(t *testing.T, events []Progress) {
+	t.Helper()
+	require.NotEmpty(t, events)
+	for i := 1; i < len(events); i++ {
+		assert.GreaterOrEqual(t, events[i].Completed, events[i-1].Completed, "progress went backwards at event %d", i)
+	}
+	for _, event := range events[:len(events)-1] {
+		assert.False(t, event.Done)
+	}
+	assert.True(t, events[len(events)-1].Done)
+}
+
+func TestModelManager_GenerateBatchWithProgress(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newBatchTestProvider()))
+
+	reqs := batchRequests("a", "b", "fail", "d", "e")
+	progress := make(chan Progress, len(reqs)+1)
+
+	responses, stats, err := manager.GenerateBatchWithProgress(context.Background(), reqs, 2, progress)
+	require.NoError(t, err)
+	assert.Len(t, responses, len(reqs))
+	assert.Equal(t, 1, stats.Failed)
+
+	events := collectProgress(progress)
+	assertMonotonic(t, events)
+	assert.Len(t, events, len(reqs)+1)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "batch", last.Operation)
+	assert.Equal(t, int64(len(reqs)), last.Completed)
+	assert.Equal(t, int64(len(reqs)), last.Total)
+	assert.Equal(t, 1.0, last.Fraction())
+	assert.Equal(t, "completed", last.Message)
+}
+
+func TestModelManager_GenerateBatchWithProgress_Cancelled(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newBatchTestProvider()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	progress := make(chan Progress, 10)
+	_, _, err := manager.GenerateBatchWithProgress(ctx, batchRequests("a", "b"), 1, progress)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The channel is closed even when the final event cannot be delivered
+	for range progress {
+	}
+}
+
+func TestProgressReporter_Monotonic(t *testing.T) {
+	ch := make(chan Progress, 10)
+	reporter := newProgressReporter(context.Background(), ch, "test", 0)
+
+	reporter.set(50, 100, "half")
+	reporter.set(20, 80, "stale")
+	reporter.add(10, "more")
+	reporter.finish(fmt.Errorf("boom"))
+	reporter.finish(nil)
+	reporter.add(1, "ignored")
+
+	events := collectProgress(ch)
+	assertMonotonic(t, events)
+	require.Len(t, events, 4)
+	assert.Equal(t, int64(50), events[1].Completed)
+	assert.Equal(t, int64(100), events[1].Total)
+	assert.Equal(t, int64(60), events[3].Completed)
+	assert.Equal(t, "boom", events[3].Message)
+
+	// A full channel drops intermediate updates instead of blocking
+	full := make(chan Progress)
+	reporter = newProgressReporter(context.Background(), full, "test", 1)
+	reporter.add(1, "dropped")
+	go reporter.finish(nil)
+	assert.Equal(t, []Progress{{Operation: "test", Message: "completed", Completed: 1, Total: 1, Done: true}}, collectProgress(full))
+}
+
+func TestOllamaProvider_PullProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"tiny:latest"}]}`)
+		case "/api/pull":
+			for _, line := range []string{
+				`{"status":"pulling manifest"}`,
+				`{"status":"downloading","digest":"sha256:a","total":100,"completed":40}`,
+				`{"status":"downloading","digest":"sha256:b","total":50,"completed":10}`,
+				`{"status":"downloading","digest":"sha256:a","total":100,"completed":100}`,
+				`{"status":"downloading","digest":"sha256:b","total":50,"completed":50}`,
+				`{"status":"success"}`,
+			} {
+				fmt.Fprintln(w, line)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	progress := make(chan Progress, 10)
+	require.NoError(t, provider.Pull(context.Background(), "tiny", progress))
+
+	events := collectProgress(progress)
+	assertMonotonic(t, events)
+	last := events[len(events)-1]
+	assert.Equal(t, int64(150), last.Completed)
+	assert.Equal(t, int64(150), last.Total)
+	assert.Equal(t, "pull tiny", last.Operation)
+}
+
+// TestOllamaProvider_PullOutlastsTimeout tests that a pull streaming for longer
+// than the provider's timeout is not cut off
+func TestOllamaProvider_PullOutlastsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			fmt.Fprint(w, `{"models":[]}`)
+			return
+		}
+		for completed := 25; completed <= 100; completed += 25 {
+			fmt.Fprintf(w, `{"status":"downloading","digest":"sha256:a","total":100,"completed":%d}`+"\n", completed)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+		fmt.Fprintln(w, `{"status":"success"}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	provider.timeout = 150 * time.Millisecond // Timeout is whole seconds; keep the test fast
+
+	progress := make(chan Progress, 10)
+	require.NoError(t, provider.Pull(context.Background(), "tiny", progress))
+	events := collectProgress(progress)
+	assert.Equal(t, int64(100), events[len(events)-1].Completed)
+}
+
+func TestModelManager_PullErrorAndWarmup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/pull" {
+			fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+			return
+		}
+		fmt.Fprint(w, `{"models":[]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(provider))
+
+	progress := make(chan Progress, 10)
+	err = manager.PullModel(context.Background(), provider.GetType(), "missing", progress)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file does not exist")
+
+	events := collectProgress(progress)
+	require.NotEmpty(t, events)
+	assert.True(t, events[len(events)-1].Done)
+	assert.Contains(t, events[len(events)-1].Message, "file does not exist")
+
+	// Warming up through the same provider reports one step per model
+	warmup := make(chan Progress, 10)
+	err = manager.WarmupModels(context.Background(), provider.GetType(), []string{"a", "b"}, warmup)
+	require.NoError(t, err)
+	events = collectProgress(warmup)
+	assertMonotonic(t, events)
+	assert.Equal(t, int64(2), events[len(events)-1].Completed)
+}
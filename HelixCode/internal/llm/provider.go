@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
@@ -34,8 +35,79 @@ const (
 	CapabilityRefactoring    ModelCapability = "refactoring"
 	CapabilityTesting        ModelCapability = "testing"
 	CapabilityVision         ModelCapability = "vision"
+	CapabilityReasoning      ModelCapability = "reasoning"
 )
 
+// allCapabilities lists the defined capabilities in declaration order
+var allCapabilities = []ModelCapability{
+	CapabilityTextGeneration,
+	CapabilityCodeGeneration,
+	CapabilityCodeAnalysis,
+	CapabilityPlanning,
+	CapabilityDebugging,
+	CapabilityRefactoring,
+	CapabilityTesting,
+	CapabilityVision,
+	CapabilityReasoning,
+}
+
+// AllCapabilities returns every defined capability
+func AllCapabilities() []ModelCapability {
+	return append([]ModelCapability(nil), allCapabilities...)
+}
+
+// ParseCapability converts a capability name from configuration or an API
+// request into a ModelCapability. Case, surrounding space and hyphens in
+// place of underscores are accepted, so "Code-Generation" parses.
+func ParseCapability(name string) (ModelCapability, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+	for _, capability := range allCapabilities {
+		if string(capability) == normalized {
+			return capability, nil
+		}
+	}
+
+	valid := make([]string, len(allCapabilities))
+	for i, capability := range allCapabilities {
+		valid[i] = string(capability)
+	}
+	return "", fmt.Errorf("unknown capability %q (valid: %s)", name, strings.Join(valid, ", "))
+}
+
+// ParseCapabilities parses a list of capability names, failing on the first unknown one
+func ParseCapabilities(names []string) ([]ModelCapability, error) {
+	capabilities := make([]ModelCapability, 0, len(names))
+	for _, name := range names {
+		capability, err := ParseCapability(name)
+		if err != nil {
+			return nil, err
+		}
+		capabilities = append(capabilities, capability)
+	}
+	return capabilities, nil
+}
+
+// String returns the capability's configuration name
+func (c ModelCapability) String() string {
+	return string(c)
+}
+
+// MarshalText encodes the capability as its configuration name
+func (c ModelCapability) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+// UnmarshalText parses a capability name, rejecting unknown ones, so JSON
+// and YAML requests cannot carry capabilities that nothing provides
+func (c *ModelCapability) UnmarshalText(text []byte) error {
+	capability, err := ParseCapability(string(text))
+	if err != nil {
+		return err
+	}
+	*c = capability
+	return nil
+}
+
 // LLMRequest represents a request to an LLM provider
 type LLMRequest struct {
 	ID           uuid.UUID         `json:"id"`
@@ -49,6 +121,8 @@ type LLMRequest struct {
 	Tools        []Tool            `json:"tools"`
 	ToolChoice   string            `json:"tool_choice"`
 	Capabilities []ModelCapability `json:"capabilities"`
+	KeepAlive    *time.Duration    `json:"keep_alive,omitempty"` // How long a local model stays loaded; nil uses the provider default
+	JSONMode     bool              `json:"json_mode,omitempty"`  // Constrain output to JSON; honored by JSONModeSupporter providers
 	CreatedAt    time.Time         `json:"created_at"`
 }
 
@@ -57,6 +131,9 @@ type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 	Name    string `json:"name,omitempty"`
+	// Images are raw image files (PNG, JPEG, ...) for vision models. Providers
+	// without vision support reject them with ErrUnsupportedInput.
+	Images [][]byte `json:"images,omitempty"`
 }
 
 // Tool represents a function/tool that the LLM can call
@@ -123,6 +200,17 @@ type Provider interface {
 	Close() error
 }
 
+// Every provider, including the tool-calling wrapper, speaks LLMRequest and
+// LLMResponse through Provider
+var (
+	_ Provider            = (*OllamaProvider)(nil)
+	_ Provider            = (*LlamaCPPProvider)(nil)
+	_ Provider            = (*LocalProvider)(nil)
+	_ Provider            = (*OpenAIProvider)(nil)
+	_ Provider            = (*AnthropicProvider)(nil)
+	_ EnhancedLLMProvider = (*ToolCallingProvider)(nil)
+)
+
 // ModelInfo represents information about an available model
 type ModelInfo struct {
 	Name         string            `json:"name"`
@@ -135,9 +223,45 @@ type ModelInfo struct {
 	Description  string            `json:"description"`
 }
 
+// DefaultContextSize is the context size, in tokens, used when a provider
+// config leaves it unset
+const DefaultContextSize = 4096
+
+// normalizeContextSize returns the context size to use for a configured one.
+// Negative sizes are rejected. Zero becomes DefaultContextSize, or the
+// model's trained context length from its GGUF metadata when that is smaller.
+func normalizeContextSize(contextSize int, modelPath string) (int, error) {
+	if contextSize < 0 {
+		return 0, fmt.Errorf("context size cannot be negative: %d", contextSize)
+	}
+	if contextSize > 0 {
+		return contextSize, nil
+	}
+
+	if modelPath != "" {
+		if meta, err := ReadGGUFMetadata(modelPath); err == nil && meta.ContextLength > 0 && meta.ContextLength < DefaultContextSize {
+			return meta.ContextLength, nil
+		}
+	}
+	return DefaultContextSize, nil
+}
+
+// timeoutSeconds converts a timeout configured in whole seconds to a
+// duration. Zero selects fallback; negative timeouts are rejected.
+func timeoutSeconds(name string, seconds int, fallback time.Duration) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, fmt.Errorf("%s must be a positive number of seconds: %d", name, seconds)
+	}
+	if seconds == 0 {
+		return fallback, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
 // ProviderHealth represents the health status of a provider
 type ProviderHealth struct {
 	Status      string    `json:"status"`
+	Version     string    `json:"version,omitempty"` // Server version, when the provider reports one
 	Latency     time.Duration `json:"latency"`
 	LastCheck   time.Time `json:"last_check"`
 	ErrorCount  int       `json:"error_count"`
@@ -146,8 +270,9 @@ type ProviderHealth struct {
 
 // ProviderManager manages multiple LLM providers
 type ProviderManager struct {
-	providers map[ProviderType]Provider
-	config    ProviderConfig
+	providers   map[ProviderType]Provider
+	config      ProviderConfig
+	retryPolicy RetryPolicy
 }
 
 // ProviderConfig holds configuration for the provider manager
@@ -166,16 +291,29 @@ type ProviderConfigEntry struct {
 	Models     []string                `json:"models"`
 	Enabled    bool                    `json:"enabled"`
 	Parameters map[string]interface{}  `json:"parameters"`
+	// Timeout is the number of seconds model discovery and status requests
+	// may take; 0 uses the provider's default. Generation is bounded only by
+	// the caller's context.
+	Timeout    int                     `json:"timeout,omitempty"`
+	// HTTPClient replaces the shared HTTP client, e.g. in tests
+	HTTPClient *http.Client            `json:"-"`
 }
 
 // NewProviderManager creates a new provider manager
 func NewProviderManager(config ProviderConfig) *ProviderManager {
 	return &ProviderManager{
-		providers: make(map[ProviderType]Provider),
-		config:    config,
+		providers:   make(map[ProviderType]Provider),
+		config:      config,
+		retryPolicy: RetryPolicy{MaxRetries: config.MaxRetries},
 	}
 }
 
+// SetRetryPolicy sets how Generate retries transient provider failures. The
+// default makes the configured MaxRetries retries.
+func (pm *ProviderManager) SetRetryPolicy(policy RetryPolicy) {
+	pm.retryPolicy = policy
+}
+
 // RegisterProvider registers a new LLM provider
 func (pm *ProviderManager) RegisterProvider(provider Provider) error {
 	providerType := provider.GetType()
@@ -191,12 +329,17 @@ func (pm *ProviderManager) RegisterProvider(provider Provider) error {
 
 // GetProvider returns a provider by type
 func (pm *ProviderManager) GetProvider(providerType ProviderType) (Provider, error) {
+	return pm.getProvider(context.Background(), providerType)
+}
+
+// getProvider returns an available provider, checking availability within ctx
+func (pm *ProviderManager) getProvider(ctx context.Context, providerType ProviderType) (Provider, error) {
 	provider, exists := pm.providers[providerType]
 	if !exists {
 		return nil, fmt.Errorf("provider %s not found", providerType)
 	}
 	
-	if !provider.IsAvailable(context.Background()) {
+	if !provider.IsAvailable(ctx) {
 		return nil, fmt.Errorf("provider %s is not available", providerType)
 	}
 	
@@ -215,9 +358,9 @@ func (pm *ProviderManager) Generate(ctx context.Context, request *LLMRequest) (*
 	
 	// Use specified provider or default
 	if request.ProviderType != "" {
-		provider, err = pm.GetProvider(request.ProviderType)
+		provider, err = pm.getProvider(ctx, request.ProviderType)
 	} else {
-		provider, err = pm.GetDefaultProvider()
+		provider, err = pm.getProvider(ctx, pm.config.DefaultProvider)
 	}
 	
 	if err != nil {
@@ -230,8 +373,8 @@ func (pm *ProviderManager) Generate(ctx context.Context, request *LLMRequest) (*
 	}
 	request.CreatedAt = time.Now()
 	
-	// Generate response
-	response, err := provider.Generate(ctx, request)
+	// Generate response, retrying transient failures
+	response, err := NewRetryingProvider(provider, pm.retryPolicy).Generate(ctx, request)
 	if err != nil {
 		return nil, fmt.Errorf("generation failed: %v", err)
 	}
@@ -326,19 +469,109 @@ var (
 	ErrInvalidRequest      = errors.New("invalid request")
 	ErrRateLimited         = errors.New("rate limited")
 	ErrContextTooLong      = errors.New("context too long")
+	ErrStreamingNotSupported = errors.New("streaming not supported")
+	ErrEmptyResponse       = errors.New("empty response")
+	ErrGenerationNotFound  = errors.New("generation not found")
+	ErrUnsupportedInput    = errors.New("unsupported input")
+	ErrNoCapabilities      = errors.New("model declares no capabilities")
+	ErrPortConflict        = errors.New("port conflict")
+	ErrNoModelSelected     = errors.New("no model selected")
 )
 
+// HasImages reports whether any message of the request carries images
+func (r *LLMRequest) HasImages() bool {
+	for _, message := range r.Messages {
+		if len(message.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectImages returns ErrUnsupportedInput for requests with images sent to
+// a provider without vision support
+func rejectImages(provider string, request *LLMRequest) error {
+	if request.HasImages() {
+		return fmt.Errorf("%w: %s does not accept images", ErrUnsupportedInput, provider)
+	}
+	return nil
+}
+
+// DefaultEmptyResponseRetries is how many times an empty response is retried
+// before ErrEmptyResponse is returned
+const DefaultEmptyResponseRetries = 1
+
+// generateNonEmpty calls Generate, retrying when the provider returns no
+// content and no tool calls, e.g. because the model hit a stop sequence
+// immediately. It returns ErrEmptyResponse once the retries, or ctx's
+// RetryBudget, are used up.
+func generateNonEmpty(ctx context.Context, provider Provider, req *LLMRequest, retries int) (*LLMResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := provider.Generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil && (strings.TrimSpace(resp.Content) != "" || len(resp.ToolCalls) > 0) {
+			return resp, nil
+		}
+		if attempt >= retries {
+			return nil, ErrEmptyResponse
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !spendRetry(ctx) {
+			return nil, ErrEmptyResponse
+		}
+		log.Printf("⚠️ Empty response from %s, retrying (%d/%d)", provider.GetName(), attempt+1, retries)
+	}
+}
+
+// StreamingSupporter is implemented by providers that can report whether
+// GenerateStream is supported
+type StreamingSupporter interface {
+	SupportsStreaming() bool
+}
+
+// NativeToolSupporter is implemented by providers that can report whether they
+// send tool definitions to the model and return structured tool calls
+type NativeToolSupporter interface {
+	SupportsNativeTools() bool
+}
+
+// ServerPortReporter is implemented by providers that run a local server,
+// reporting the port it listens on, or 0 when it has none
+type ServerPortReporter interface {
+	ServerPort() int
+}
+
+// ProviderCapabilities describes which code paths a provider supports
+type ProviderCapabilities struct {
+	Streaming   bool `json:"streaming"`
+	NativeTools bool `json:"native_tools"`
+	JSONMode    bool `json:"json_mode"`
+}
+
+// GetProviderCapabilities queries a provider's capabilities. A capability the
+// provider does not report is assumed to be unsupported.
+func GetProviderCapabilities(provider Provider) ProviderCapabilities {
+	var caps ProviderCapabilities
+	if supporter, ok := provider.(StreamingSupporter); ok {
+		caps.Streaming = supporter.SupportsStreaming()
+	}
+	if supporter, ok := provider.(NativeToolSupporter); ok {
+		caps.NativeTools = supporter.SupportsNativeTools()
+	}
+	if supporter, ok := provider.(JSONModeSupporter); ok {
+		caps.JSONMode = supporter.SupportsJSONMode()
+	}
+	return caps
+}
+
 // ProviderFactory creates providers based on configuration
 type ProviderFactory struct{}
 
-// CreateProvider creates a provider from configuration
+// CreateProvider creates a provider from configuration using the provider registry
 func (pf *ProviderFactory) CreateProvider(config ProviderConfigEntry) (Provider, error) {
-	switch config.Type {
-	case ProviderTypeLocal:
-		return NewLocalProvider(config)
-	case ProviderTypeOpenAI:
-		return NewOpenAIProvider(config)
-	default:
-		return nil, fmt.Errorf("unsupported provider type: %s", config.Type)
-	}
+	return NewProviderByName(string(config.Type), config)
 }
\ No newline at end of file
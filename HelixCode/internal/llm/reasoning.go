@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -57,6 +58,7 @@ const (
 	ReasoningTypeTreeOfThoughts   ReasoningType = "tree_of_thoughts"
 	ReasoningTypeSelfReflection   ReasoningType = "self_reflection"
 	ReasoningTypeProgressive      ReasoningType = "progressive"
+	ReasoningTypeReAct            ReasoningType = "react"
 )
 
 // ReasoningTool represents a tool that can be used during reasoning
@@ -124,6 +126,8 @@ func (e *ReasoningEngine) GenerateWithReasoning(ctx context.Context, req Reasoni
 		err = e.executeSelfReflection(ctx, req, response)
 	case ReasoningTypeProgressive:
 		err = e.executeProgressiveReasoning(ctx, req, response)
+	case ReasoningTypeReAct:
+		err = e.executeReAct(ctx, req, response)
 	default:
 		err = fmt.Errorf("unsupported reasoning type: %s", req.ReasoningType)
 	}
@@ -146,17 +150,23 @@ func (e *ReasoningEngine) executeChainOfThought(ctx context.Context, req Reasoni
 		thoughtPrompt := e.buildChainOfThoughtPrompt(currentThought, step, req.MaxSteps)
 		thought, err := e.generateThought(ctx, thoughtPrompt, req.Temperature)
 		if err != nil {
-			return fmt.Errorf("failed to generate thought at step %d: %v", step, err)
+			return fmt.Errorf("failed to generate thought at step %d: %w", step, err)
 		}
 
+		parsed := ParseReasoningSteps(thought)
+
 		// Check if we have a final answer
-		if e.isFinalAnswer(thought) {
-			response.FinalAnswer = e.extractFinalAnswer(thought)
+		if answer, ok := FinalAnswer(parsed); ok {
+			e.recordSteps(response, parsed, nil, nil)
+			response.FinalAnswer = answer
 			break
 		}
 
 		// Check if we need to use tools
-		toolCall, shouldUseTool := e.shouldUseTool(thought)
+		toolCall, shouldUseTool := e.parsedToolCall(parsed)
+		if !shouldUseTool {
+			toolCall, shouldUseTool = e.shouldUseTool(thought)
+		}
 		var result interface{}
 		if shouldUseTool {
 			result, err = e.executeTool(ctx, toolCall)
@@ -168,16 +178,8 @@ func (e *ReasoningEngine) executeChainOfThought(ctx context.Context, req Reasoni
 			response.ToolsUsed = append(response.ToolsUsed, toolCall.ToolName)
 		}
 
-		// Record reasoning step
-		stepRecord := ReasoningStep{
-			StepNumber: step,
-			Thought:    thought,
-			Action:     e.determineAction(thought, shouldUseTool),
-			ToolCall:   toolCall,
-			Result:     result,
-			Confidence: e.calculateConfidence(thought),
-		}
-		response.ReasoningSteps = append(response.ReasoningSteps, stepRecord)
+		// Record reasoning steps
+		e.recordSteps(response, parsed, toolCall, result)
 
 		// Update current thought with result
 		if shouldUseTool && result != nil {
@@ -198,6 +200,67 @@ func (e *ReasoningEngine) executeChainOfThought(ctx context.Context, req Reasoni
 	return nil
 }
 
+// recordSteps appends parsed steps to the response. The tool call and its
+// result, if any, belong to the last parsed step.
+func (e *ReasoningEngine) recordSteps(response *ReasoningResponse, parsed []ParsedStep, toolCall *ReasoningToolCall, result interface{}) {
+	for i, p := range parsed {
+		stepRecord := ReasoningStep{
+			StepNumber: len(response.ReasoningSteps) + 1,
+			Thought:    p.Thought,
+			Action:     e.determineAction(p.Thought, false),
+			Confidence: p.Confidence,
+		}
+		if p.FinalAnswer != "" {
+			stepRecord.Action = "final_answer"
+		}
+		if p.Observation != "" {
+			stepRecord.Result = p.Observation
+		}
+		if i == len(parsed)-1 && toolCall != nil {
+			stepRecord.Action = e.determineAction(p.Thought, true)
+			stepRecord.ToolCall = toolCall
+			stepRecord.Result = result
+		}
+		if !p.HasConfidence {
+			stepRecord.Confidence = e.calculateConfidence(p.Thought)
+		}
+		response.ReasoningSteps = append(response.ReasoningSteps, stepRecord)
+	}
+}
+
+// parsedToolCall returns a tool call for the last parsed step when its action
+// names a registered tool
+func (e *ReasoningEngine) parsedToolCall(parsed []ParsedStep) (*ReasoningToolCall, bool) {
+	if len(parsed) == 0 {
+		return nil, false
+	}
+	last := parsed[len(parsed)-1]
+	if last.Action == "" || last.Observation != "" {
+		return nil, false
+	}
+	if _, exists := e.tools[last.Action]; !exists {
+		return nil, false
+	}
+	return &ReasoningToolCall{
+		ToolName:  last.Action,
+		Arguments: parseActionInput(last.ActionInput),
+	}, true
+}
+
+// parseActionInput decodes a ReAct action input. JSON objects become the
+// argument map; any other non-empty input is passed as "input".
+func parseActionInput(input string) map[string]interface{} {
+	args := make(map[string]interface{})
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return args
+	}
+	if err := json.Unmarshal([]byte(input), &args); err == nil {
+		return args
+	}
+	return map[string]interface{}{"input": input}
+}
+
 // Helper methods
 
 func (e *ReasoningEngine) validateRequest(req ReasoningRequest) error {
@@ -232,7 +295,7 @@ func (e *ReasoningEngine) generateThought(ctx context.Context, prompt string, te
 		Stream:      false,
 	}
 
-	resp, err := e.provider.Generate(ctx, genReq)
+	resp, err := generateNonEmpty(ctx, e.provider, genReq, DefaultEmptyResponseRetries)
 	if err != nil {
 		return "", err
 	}
@@ -270,7 +333,12 @@ func (e *ReasoningEngine) executeTool(ctx context.Context, toolCall *ReasoningTo
 		return nil, fmt.Errorf("tool not found: %s", toolCall.ToolName)
 	}
 
-	return tool.Handler(ctx, toolCall.Arguments)
+	args, err := CoerceToolArguments(tool.Parameters, toolCall.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %v", toolCall.ToolName, err)
+	}
+
+	return tool.Handler(ctx, args)
 }
 
 func (e *ReasoningEngine) determineAction(thought string, usedTool bool) string {
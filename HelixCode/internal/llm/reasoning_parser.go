@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParsedStep is a reasoning step recognized in model output
+type ParsedStep struct {
+	Number        int
+	Thought       string
+	Action        string
+	ActionInput   string
+	Observation   string
+	FinalAnswer   string
+	Confidence    float64
+	HasConfidence bool
+}
+
+var (
+	reactLinePattern    = regexp.MustCompile(`(?i)^\s*(thought|action input|action|observation|final answer)\s*\d*\s*:\s*(.*)$`)
+	numberedLinePattern = regexp.MustCompile(`(?i)^\s*(?:step\s+)?(\d+)\s*[.):]\s+(.*)$`)
+	finalLinePattern    = regexp.MustCompile(`(?i)^\s*final answer\s*:\s*(.*)$`)
+	confidencePattern   = regexp.MustCompile(`(?i)\(?\bconfidence\s*[:=]?\s*(?:(\d+(?:\.\d+)?)\s*(%)?|(very high|high|medium|moderate|low|very low))\)?`)
+)
+
+// confidenceLevels maps verbal confidence markers to scores
+var confidenceLevels = map[string]float64{
+	"very high": 0.95,
+	"high":      0.85,
+	"medium":    0.6,
+	"moderate":  0.6,
+	"low":       0.3,
+	"very low":  0.1,
+}
+
+// ParseReasoningSteps splits model output into reasoning steps. It recognizes
+// ReAct output (Thought:/Action:/Action Input:/Observation:/Final Answer:),
+// numbered steps ("1.", "2)", "Step 3:") and confidence markers such as
+// "Confidence: 0.8", "confidence: 80%" or "(confidence: high)". Output without
+// any structure is returned as a single step; empty output yields no steps.
+func ParseReasoningSteps(text string) []ParsedStep {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+
+	var steps []ParsedStep
+	switch {
+	case isReActOutput(lines):
+		steps = parseReActSteps(lines)
+	case isNumberedOutput(lines):
+		steps = parseNumberedSteps(lines)
+	default:
+		steps = []ParsedStep{parseUnstructuredStep(text)}
+	}
+
+	for i := range steps {
+		steps[i].Number = i + 1
+		steps[i].Thought, steps[i].Confidence, steps[i].HasConfidence = extractConfidence(steps[i].Thought)
+		steps[i].Thought = strings.TrimSpace(steps[i].Thought)
+		steps[i].Action = strings.TrimSpace(steps[i].Action)
+		steps[i].ActionInput = strings.TrimSpace(steps[i].ActionInput)
+		steps[i].Observation = strings.TrimSpace(steps[i].Observation)
+		steps[i].FinalAnswer = strings.TrimSpace(steps[i].FinalAnswer)
+	}
+	return steps
+}
+
+// FinalAnswer returns the final answer found in parsed steps, if any
+func FinalAnswer(steps []ParsedStep) (string, bool) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].FinalAnswer != "" {
+			return steps[i].FinalAnswer, true
+		}
+	}
+	return "", false
+}
+
+func isReActOutput(lines []string) bool {
+	for _, line := range lines {
+		if match := reactLinePattern.FindStringSubmatch(line); match != nil {
+			switch strings.ToLower(match[1]) {
+			case "thought", "action", "observation":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNumberedOutput(lines []string) bool {
+	for _, line := range lines {
+		if numberedLinePattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseReActSteps(lines []string) []ParsedStep {
+	var steps []ParsedStep
+	var current *ParsedStep
+	var field *string
+
+	start := func() {
+		if current != nil {
+			steps = append(steps, *current)
+		}
+		current = &ParsedStep{}
+	}
+
+	for _, line := range lines {
+		match := reactLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			if field != nil {
+				*field += "\n" + line
+			} else if strings.TrimSpace(line) != "" {
+				// Text before the first label is treated as a thought
+				start()
+				current.Thought = line
+				field = &current.Thought
+			}
+			continue
+		}
+
+		label, value := strings.ToLower(match[1]), match[2]
+		switch label {
+		case "thought":
+			if current == nil || current.Thought != "" || current.Action != "" || current.Observation != "" {
+				start()
+			}
+			current.Thought = value
+			field = &current.Thought
+		case "action":
+			if current == nil || current.Action != "" || current.Observation != "" {
+				start()
+			}
+			current.Action = value
+			field = &current.Action
+		case "action input":
+			if current == nil {
+				start()
+			}
+			current.ActionInput = value
+			field = &current.ActionInput
+		case "observation":
+			if current == nil {
+				start()
+			}
+			current.Observation = value
+			field = &current.Observation
+		case "final answer":
+			if current == nil {
+				start()
+			}
+			current.FinalAnswer = value
+			field = &current.FinalAnswer
+		}
+	}
+
+	if current != nil {
+		steps = append(steps, *current)
+	}
+	return steps
+}
+
+func parseNumberedSteps(lines []string) []ParsedStep {
+	var steps []ParsedStep
+	var current *ParsedStep
+	inFinal := false
+
+	for _, line := range lines {
+		if match := finalLinePattern.FindStringSubmatch(line); match != nil {
+			if current == nil {
+				steps = append(steps, ParsedStep{})
+				current = &steps[len(steps)-1]
+			}
+			current.FinalAnswer = match[1]
+			inFinal = true
+			continue
+		}
+
+		if match := numberedLinePattern.FindStringSubmatch(line); match != nil {
+			steps = append(steps, ParsedStep{Thought: match[2]})
+			current = &steps[len(steps)-1]
+			inFinal = false
+			continue
+		}
+
+		switch {
+		case inFinal:
+			current.FinalAnswer += "\n" + line
+		case current != nil:
+			current.Thought += "\n" + line
+		case strings.TrimSpace(line) != "":
+			// Text before the first numbered step becomes its own step
+			steps = append(steps, ParsedStep{Thought: line})
+			current = &steps[len(steps)-1]
+		}
+	}
+	return steps
+}
+
+func parseUnstructuredStep(text string) ParsedStep {
+	step := ParsedStep{Thought: text}
+	if idx := strings.Index(strings.ToLower(text), "final answer:"); idx != -1 {
+		step.Thought = text[:idx]
+		step.FinalAnswer = text[idx+len("final answer:"):]
+		if strings.TrimSpace(step.Thought) == "" {
+			step.Thought = text
+		}
+	}
+	return step
+}
+
+// extractConfidence finds a confidence marker, removes it from the text and
+// returns the confidence between 0 and 1
+func extractConfidence(text string) (string, float64, bool) {
+	loc := confidencePattern.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return text, 0, false
+	}
+
+	var confidence float64
+	switch {
+	case loc[2] != -1:
+		value, err := strconv.ParseFloat(text[loc[2]:loc[3]], 64)
+		if err != nil {
+			return text, 0, false
+		}
+		if loc[4] != -1 || value > 1 {
+			value /= 100
+		}
+		if value < 0 || value > 1 {
+			return text, 0, false
+		}
+		confidence = value
+	case loc[6] != -1:
+		confidence = confidenceLevels[strings.ToLower(text[loc[6]:loc[7]])]
+	default:
+		return text, 0, false
+	}
+
+	return text[:loc[0]] + text[loc[1]:], confidence, true
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseReasoningSteps_ReAct tests parsing of Thought/Action/Observation output
+func TestParseReasoningSteps_ReAct(t *testing.T) {
+	output := `Thought: I need the current weather.
+Action: weather
+Action Input: {"city": "Paris"}
+Observation: 18C and sunny
+Thought: The weather is known now. Confidence: 0.9
+Final Answer: It is 18C and sunny in Paris.`
+
+	steps := ParseReasoningSteps(output)
+	require.Len(t, steps, 2)
+
+	assert.Equal(t, 1, steps[0].Number)
+	assert.Equal(t, "I need the current weather.", steps[0].Thought)
+	assert.Equal(t, "weather", steps[0].Action)
+	assert.Equal(t, `{"city": "Paris"}`, steps[0].ActionInput)
+	assert.Equal(t, "18C and sunny", steps[0].Observation)
+	assert.False(t, steps[0].HasConfidence)
+
+	assert.Equal(t, 2, steps[1].Number)
+	assert.Equal(t, "The weather is known now.", steps[1].Thought)
+	assert.True(t, steps[1].HasConfidence)
+	assert.InDelta(t, 0.9, steps[1].Confidence, 0.001)
+	assert.Equal(t, "It is 18C and sunny in Paris.", steps[1].FinalAnswer)
+
+	answer, ok := FinalAnswer(steps)
+	assert.True(t, ok)
+	assert.Equal(t, "It is 18C and sunny in Paris.", answer)
+}
+
+// TestParseReasoningSteps_Numbered tests parsing of numbered step output
+func TestParseReasoningSteps_Numbered(t *testing.T) {
+	output := `Let me work through this.
+1. Identify the inputs (confidence: high)
+2) Add the numbers together
+   which gives 4
+Step 3: Check the result. Confidence: 75%
+Final Answer: 4`
+
+	steps := ParseReasoningSteps(output)
+	require.Len(t, steps, 4)
+
+	assert.Equal(t, "Let me work through this.", steps[0].Thought)
+	assert.Equal(t, "Identify the inputs", steps[1].Thought)
+	assert.True(t, steps[1].HasConfidence)
+	assert.InDelta(t, 0.85, steps[1].Confidence, 0.001)
+	assert.Equal(t, "Add the numbers together\n   which gives 4", steps[2].Thought)
+	assert.False(t, steps[2].HasConfidence)
+	assert.Equal(t, "Check the result.", steps[3].Thought)
+	assert.InDelta(t, 0.75, steps[3].Confidence, 0.001)
+	assert.Equal(t, "4", steps[3].FinalAnswer)
+
+	for i, step := range steps {
+		assert.Equal(t, i+1, step.Number)
+	}
+}
+
+// TestParseReasoningSteps_Unstructured tests fallback to a single step
+func TestParseReasoningSteps_Unstructured(t *testing.T) {
+	steps := ParseReasoningSteps("  The sky is blue because of Rayleigh scattering.  ")
+	require.Len(t, steps, 1)
+	assert.Equal(t, 1, steps[0].Number)
+	assert.Equal(t, "The sky is blue because of Rayleigh scattering.", steps[0].Thought)
+	assert.Empty(t, steps[0].Action)
+	assert.Empty(t, steps[0].FinalAnswer)
+
+	steps = ParseReasoningSteps("This is a test response with FINAL ANSWER: The answer is 42.")
+	require.Len(t, steps, 1)
+	assert.Equal(t, "This is a test response with", steps[0].Thought)
+	assert.Equal(t, "The answer is 42.", steps[0].FinalAnswer)
+
+	assert.Nil(t, ParseReasoningSteps("   \n  "))
+}
+
+// TestReasoningEngine_ParsedReActSteps tests that parsed actions drive tool calls
+func TestReasoningEngine_ParsedReActSteps(t *testing.T) {
+	mockProvider := new(MockProvider)
+	engine := NewReasoningEngine(mockProvider)
+
+	var received map[string]interface{}
+	require.NoError(t, engine.RegisterTool(ReasoningTool{
+		Name:        "calculator",
+		Description: "Evaluates arithmetic",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			received = args
+			return "4", nil
+		},
+	}))
+
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: I should compute this. Confidence: 0.7\nAction: calculator\nAction Input: {\"expression\": \"2+2\"}",
+	}, nil).Once()
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: The calculator returned 4.\nFinal Answer: 4",
+	}, nil).Once()
+
+	response, err := engine.GenerateWithReasoning(context.Background(), ReasoningRequest{
+		Prompt:        "What is 2 + 2?",
+		ReasoningType: ReasoningTypeChainOfThought,
+		MaxSteps:      5,
+		Temperature:   0.3,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "4", response.FinalAnswer)
+	assert.Equal(t, []string{"calculator"}, response.ToolsUsed)
+	assert.Equal(t, map[string]interface{}{"expression": "2+2"}, received)
+
+	require.Len(t, response.ReasoningSteps, 2)
+	first := response.ReasoningSteps[0]
+	assert.Equal(t, 1, first.StepNumber)
+	assert.Equal(t, "I should compute this.", first.Thought)
+	assert.Equal(t, "tool_execution", first.Action)
+	require.NotNil(t, first.ToolCall)
+	assert.Equal(t, "calculator", first.ToolCall.ToolName)
+	assert.Equal(t, "4", first.Result)
+	assert.InDelta(t, 0.7, first.Confidence, 0.001)
+
+	second := response.ReasoningSteps[1]
+	assert.Equal(t, 2, second.StepNumber)
+	assert.Equal(t, "final_answer", second.Action)
+
+	mockProvider.AssertExpectations(t)
+}
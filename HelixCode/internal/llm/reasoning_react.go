@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// executeReAct implements ReAct reasoning: the model alternates between a
+// thought and an action, each action runs a tool, and the tool's result is fed
+// back as an observation until the model gives a final answer.
+func (e *ReasoningEngine) executeReAct(ctx context.Context, req ReasoningRequest, response *ReasoningResponse) error {
+	tools := e.requestTools(req)
+	var transcript strings.Builder
+
+	for step := 1; step <= req.MaxSteps; step++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("react reasoning cancelled at step %d: %v", step, err)
+		}
+
+		prompt := e.buildReActPrompt(req.Prompt, tools, transcript.String())
+		output, err := e.generateThought(ctx, prompt, req.Temperature)
+		if err != nil {
+			return fmt.Errorf("failed to generate thought at step %d: %w", step, err)
+		}
+
+		parsed := reActRound(ParseReasoningSteps(output))
+		if len(parsed) == 0 {
+			continue
+		}
+
+		last := &parsed[len(parsed)-1]
+		if last.Action == "" {
+			// Without an action the output is the answer, whether or not the
+			// model labelled it as final
+			e.recordSteps(response, parsed, nil, nil)
+			if answer, ok := FinalAnswer(parsed); ok {
+				response.FinalAnswer = answer
+			} else {
+				response.FinalAnswer = last.Thought
+			}
+			return nil
+		}
+
+		toolCall := &ReasoningToolCall{
+			ToolName:  last.Action,
+			Arguments: parseActionInput(last.ActionInput),
+		}
+		observation := e.runReActTool(ctx, tools, toolCall)
+		response.ToolsUsed = append(response.ToolsUsed, toolCall.ToolName)
+		e.recordSteps(response, parsed, toolCall, observation)
+
+		for _, p := range parsed {
+			if p.Thought != "" {
+				fmt.Fprintf(&transcript, "Thought: %s\n", p.Thought)
+			}
+		}
+		fmt.Fprintf(&transcript, "Action: %s\n", last.Action)
+		if last.ActionInput != "" {
+			fmt.Fprintf(&transcript, "Action Input: %s\n", last.ActionInput)
+		}
+		fmt.Fprintf(&transcript, "Observation: %v\n", observation)
+	}
+
+	return fmt.Errorf("react reasoning reached max steps (%d) without a final answer", req.MaxSteps)
+}
+
+// reActRound keeps the steps up to and including the first action. Anything
+// the model wrote after it, such as an invented observation, is discarded.
+func reActRound(steps []ParsedStep) []ParsedStep {
+	for i := range steps {
+		if steps[i].Action != "" {
+			steps[i].Observation = ""
+			steps[i].FinalAnswer = ""
+			return steps[:i+1]
+		}
+	}
+	return steps
+}
+
+// runReActTool executes a tool call and returns the observation to feed back.
+// Tool failures become observations so the model can recover from them.
+func (e *ReasoningEngine) runReActTool(ctx context.Context, tools map[string]ReasoningTool, toolCall *ReasoningToolCall) interface{} {
+	tool, exists := tools[toolCall.ToolName]
+	if !exists {
+		return fmt.Sprintf("Tool not found: %s. Available tools: %s", toolCall.ToolName, strings.Join(sortedToolNames(tools), ", "))
+	}
+
+	args, err := CoerceToolArguments(tool.Parameters, toolCall.Arguments)
+	if err != nil {
+		return fmt.Sprintf("Tool error: %v", err)
+	}
+	toolCall.Arguments = args
+
+	result, err := tool.Handler(ctx, args)
+	if err != nil {
+		log.Printf("Tool execution failed: %v", err)
+		return fmt.Sprintf("Tool error: %v", err)
+	}
+	return result
+}
+
+// requestTools merges the engine's registered tools with the request's tools.
+// Request tools take precedence.
+func (e *ReasoningEngine) requestTools(req ReasoningRequest) map[string]ReasoningTool {
+	tools := make(map[string]ReasoningTool, len(e.tools)+len(req.Tools))
+	for name, tool := range e.tools {
+		tools[name] = tool
+	}
+	for _, tool := range req.Tools {
+		if tool.Name != "" && tool.Handler != nil {
+			tools[tool.Name] = tool
+		}
+	}
+	return tools
+}
+
+func (e *ReasoningEngine) buildReActPrompt(question string, tools map[string]ReasoningTool, transcript string) string {
+	var toolList strings.Builder
+	for _, name := range sortedToolNames(tools) {
+		fmt.Fprintf(&toolList, "- %s: %s\n", name, tools[name].Description)
+	}
+	if toolList.Len() == 0 {
+		toolList.WriteString("(none)\n")
+	}
+
+	return fmt.Sprintf(`Answer the question using the tools below.
+
+Available tools:
+%s
+Use this format:
+Thought: your reasoning about what to do next
+Action: the tool to use, exactly as named above
+Action Input: the tool arguments as a JSON object
+Observation: the tool result (provided to you, do not write it yourself)
+... repeat Thought/Action/Action Input/Observation as needed ...
+Thought: I know the answer
+Final Answer: the answer to the question
+
+Question: %s
+%s`, toolList.String(), question, transcript)
+}
+
+func sortedToolNames(tools map[string]ReasoningTool) []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReasoningEngine_ReActTrace tests a multi-step ReAct trace with mock tools
+func TestReasoningEngine_ReActTrace(t *testing.T) {
+	mockProvider := new(MockProvider)
+	engine := NewReasoningEngine(mockProvider)
+
+	require.NoError(t, engine.RegisterTool(ReasoningTool{
+		Name:        "lookup",
+		Description: "Looks up a country's capital",
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"country": map[string]interface{}{"type": "string"}},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return "Paris", nil
+		},
+	}))
+	require.NoError(t, engine.RegisterTool(ReasoningTool{
+		Name:        "population",
+		Description: "Returns a city's population in millions",
+		Parameters: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			assert.Equal(t, "Paris", args["city"])
+			return 2.1, nil
+		},
+	}))
+
+	var prompts []string
+	capture := func(args mock.Arguments) {
+		req := args.Get(1).(*LLMRequest)
+		prompts = append(prompts, req.Messages[0].Content)
+	}
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: I need the capital first.\nAction: lookup\nAction Input: {\"country\": \"France\"}\nObservation: Lyon",
+	}, nil).Run(capture).Once()
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: Now the population of Paris.\nAction: population\nAction Input: {\"city\": \"Paris\"}",
+	}, nil).Run(capture).Once()
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: I know the answer.\nFinal Answer: About 2.1 million people live in Paris.",
+	}, nil).Run(capture).Once()
+
+	response, err := engine.GenerateWithReasoning(context.Background(), ReasoningRequest{
+		Prompt:        "How many people live in the capital of France?",
+		ReasoningType: ReasoningTypeReAct,
+		MaxSteps:      5,
+		Temperature:   0.2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "About 2.1 million people live in Paris.", response.FinalAnswer)
+	assert.Equal(t, []string{"lookup", "population"}, response.ToolsUsed)
+
+	require.Len(t, response.ReasoningSteps, 3)
+	assert.Equal(t, "lookup", response.ReasoningSteps[0].ToolCall.ToolName)
+	assert.Equal(t, map[string]interface{}{"country": "France"}, response.ReasoningSteps[0].ToolCall.Arguments)
+	assert.Equal(t, "Paris", response.ReasoningSteps[0].Result)
+	assert.Equal(t, "population", response.ReasoningSteps[1].ToolCall.ToolName)
+	assert.Equal(t, 2.1, response.ReasoningSteps[1].Result)
+	assert.Equal(t, "final_answer", response.ReasoningSteps[2].Action)
+	for i, step := range response.ReasoningSteps {
+		assert.Equal(t, i+1, step.StepNumber)
+	}
+
+	// Observations come from the tools, not from the model's own output
+	require.Len(t, prompts, 3)
+	assert.Contains(t, prompts[1], "Observation: Paris")
+	assert.NotContains(t, prompts[1], "Lyon")
+	assert.Contains(t, prompts[2], "Observation: 2.1")
+
+	mockProvider.AssertExpectations(t)
+}
+
+// TestReasoningEngine_ReActUnknownTool tests that unknown tools become observations
+func TestReasoningEngine_ReActUnknownTool(t *testing.T) {
+	mockProvider := new(MockProvider)
+	engine := NewReasoningEngine(mockProvider)
+
+	var secondPrompt string
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: Let me search.\nAction: search\nAction Input: weather",
+	}, nil).Once()
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Final Answer: I cannot look that up.",
+	}, nil).Run(func(args mock.Arguments) {
+		secondPrompt = args.Get(1).(*LLMRequest).Messages[0].Content
+	}).Once()
+
+	response, err := engine.GenerateWithReasoning(context.Background(), ReasoningRequest{
+		Prompt:        "What's the weather?",
+		ReasoningType: ReasoningTypeReAct,
+		MaxSteps:      3,
+		Tools: []ReasoningTool{{
+			Name:        "calculator",
+			Description: "Evaluates arithmetic",
+			Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "I cannot look that up.", response.FinalAnswer)
+	assert.Contains(t, response.ReasoningSteps[0].Result, "Tool not found: search")
+	assert.Contains(t, secondPrompt, "- calculator: Evaluates arithmetic")
+	assert.Contains(t, secondPrompt, "Available tools: calculator")
+}
+
+// TestReasoningEngine_ReActBounds tests the step limit and context cancellation
+func TestReasoningEngine_ReActBounds(t *testing.T) {
+	mockProvider := new(MockProvider)
+	engine := NewReasoningEngine(mockProvider)
+	require.NoError(t, engine.RegisterTool(ReasoningTool{
+		Name: "echo",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return "again", nil
+		},
+	}))
+
+	mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{
+		Content: "Thought: Keep going.\nAction: echo",
+	}, nil)
+
+	request := ReasoningRequest{
+		Prompt:        "Loop forever",
+		ReasoningType: ReasoningTypeReAct,
+		MaxSteps:      3,
+	}
+
+	response, err := engine.GenerateWithReasoning(context.Background(), request)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "max steps"))
+	assert.Len(t, response.ToolsUsed, 3)
+	mockProvider.AssertNumberOfCalls(t, "Generate", 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = engine.GenerateWithReasoning(ctx, request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cancelled")
+	mockProvider.AssertNumberOfCalls(t, "Generate", 3)
+}
@@ -210,4 +210,28 @@ func TestReasoningEngine_GenerateWithReasoning(t *testing.T) {
 	assert.Empty(t, response.Error)
 
 	mockProvider.AssertExpectations(t)
-}
\ No newline at end of file
+}
+// TestReasoningEngine_EmptyResponse tests that empty model output ends reasoning with ErrEmptyResponse
+func TestReasoningEngine_EmptyResponse(t *testing.T) {
+	for _, reasoningType := range []ReasoningType{ReasoningTypeChainOfThought, ReasoningTypeReAct} {
+		t.Run(string(reasoningType), func(t *testing.T) {
+			mockProvider := new(MockProvider)
+			engine := NewReasoningEngine(mockProvider)
+
+			mockProvider.On("GetName").Return("mock")
+			mockProvider.On("Generate", mock.Anything, mock.Anything).Return(&LLMResponse{Content: ""}, nil)
+
+			response, err := engine.GenerateWithReasoning(context.Background(), ReasoningRequest{
+				Prompt:        "Say something",
+				ReasoningType: reasoningType,
+				MaxSteps:      5,
+				Temperature:   0.5,
+			})
+
+			assert.ErrorIs(t, err, ErrEmptyResponse)
+			assert.Empty(t, response.FinalAnswer)
+			assert.Empty(t, response.ReasoningSteps)
+			mockProvider.AssertNumberOfCalls(t, "Generate", 1+DefaultEmptyResponseRetries)
+		})
+	}
+}
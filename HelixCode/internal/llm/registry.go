@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/paths"
+)
+
+// ProviderConstructor builds a provider from a configuration entry
+type ProviderConstructor func(config ProviderConfigEntry) (Provider, error)
+
+var (
+	providerRegistry   = make(map[string]ProviderConstructor)
+	providerRegistryMu sync.RWMutex
+)
+
+func init() {
+	builtins := map[string]ProviderConstructor{
+		string(ProviderTypeLocal): func(config ProviderConfigEntry) (Provider, error) {
+			return NewLocalProvider(config)
+		},
+		string(ProviderTypeOpenAI): func(config ProviderConfigEntry) (Provider, error) {
+			return NewOpenAIProvider(config)
+		},
+		string(ProviderTypeAnthropic): newAnthropicProviderFromEntry,
+		"ollama":                      newOllamaProviderFromEntry,
+		"llama-cpp":                   newLlamaCPPProviderFromEntry,
+	}
+
+	for name, constructor := range builtins {
+		if err := RegisterProviderFactory(name, constructor); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterProviderFactory registers a constructor for a provider type
+func RegisterProviderFactory(providerType string, constructor ProviderConstructor) error {
+	key := normalizeProviderType(providerType)
+	if key == "" {
+		return fmt.Errorf("provider type cannot be empty")
+	}
+	if constructor == nil {
+		return fmt.Errorf("constructor for provider type %s cannot be nil", key)
+	}
+
+	providerRegistryMu.Lock()
+	defer providerRegistryMu.Unlock()
+
+	if _, exists := providerRegistry[key]; exists {
+		return fmt.Errorf("provider type %s already registered", key)
+	}
+
+	providerRegistry[key] = constructor
+	return nil
+}
+
+// UnregisterProviderFactory removes the constructor for a provider type
+func UnregisterProviderFactory(providerType string) {
+	providerRegistryMu.Lock()
+	defer providerRegistryMu.Unlock()
+
+	delete(providerRegistry, normalizeProviderType(providerType))
+}
+
+// RegisteredProviderTypes returns the sorted list of registered provider types
+func RegisteredProviderTypes() []string {
+	providerRegistryMu.RLock()
+	defer providerRegistryMu.RUnlock()
+
+	types := make([]string, 0, len(providerRegistry))
+	for name := range providerRegistry {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewProviderByName creates a provider using the constructor registered for the type
+func NewProviderByName(providerType string, config ProviderConfigEntry) (Provider, error) {
+	key := normalizeProviderType(providerType)
+
+	providerRegistryMu.RLock()
+	constructor, exists := providerRegistry[key]
+	providerRegistryMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown provider type %q (registered: %s)",
+			providerType, strings.Join(RegisteredProviderTypes(), ", "))
+	}
+
+	provider, err := constructor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %v", key, err)
+	}
+
+	return provider, nil
+}
+
+// normalizeProviderType normalizes a provider type used as a registry key
+func normalizeProviderType(providerType string) string {
+	return strings.ToLower(strings.TrimSpace(providerType))
+}
+
+// newOllamaProviderFromEntry adapts a configuration entry to an Ollama provider
+func newOllamaProviderFromEntry(config ProviderConfigEntry) (Provider, error) {
+	ollamaConfig := OllamaConfig{
+		BaseURL:       config.Endpoint,
+		Timeout:       config.Timeout,
+		StreamEnabled: true,
+		HTTPClient:    config.HTTPClient,
+	}
+	if ollamaConfig.BaseURL == "" {
+		ollamaConfig.BaseURL = "http://localhost:11434"
+	}
+	if len(config.Models) > 0 {
+		ollamaConfig.DefaultModel = config.Models[0]
+	}
+	if contextSize, ok := intParameter(config.Parameters, "context_size"); ok {
+		ollamaConfig.ContextSize = contextSize
+	}
+	if keepAlive, ok := config.Parameters["keep_alive"].(string); ok {
+		duration, err := time.ParseDuration(keepAlive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keep_alive %q: %v", keepAlive, err)
+		}
+		ollamaConfig.KeepAlive = &duration
+	}
+
+	return NewOllamaProvider(ollamaConfig)
+}
+
+// newAnthropicProviderFromEntry adapts a configuration entry to an Anthropic provider
+func newAnthropicProviderFromEntry(config ProviderConfigEntry) (Provider, error) {
+	anthropicConfig := AnthropicConfig{
+		APIKey:     config.APIKey,
+		BaseURL:    config.Endpoint,
+		HTTPClient: config.HTTPClient,
+	}
+	if len(config.Models) > 0 {
+		anthropicConfig.DefaultModel = config.Models[0]
+	}
+
+	return NewAnthropicProvider(anthropicConfig)
+}
+
+// newLlamaCPPProviderFromEntry adapts a configuration entry to a Llama.cpp provider
+func newLlamaCPPProviderFromEntry(entry ProviderConfigEntry) (Provider, error) {
+	llamaConfig := LlamaConfig{ServerHost: "localhost"}
+
+	// The endpoint names where the server listens; without a port one is
+	// selected when the server starts
+	if entry.Endpoint != "" {
+		endpoint, err := url.Parse(entry.Endpoint)
+		if err != nil || endpoint.Hostname() == "" {
+			return nil, fmt.Errorf("invalid llama-cpp endpoint %q", entry.Endpoint)
+		}
+		llamaConfig.ServerHost = endpoint.Hostname()
+		if port := endpoint.Port(); port != "" {
+			llamaConfig.ServerPort, _ = strconv.Atoi(port)
+		}
+	}
+	if port, ok := intParameter(entry.Parameters, "server_port"); ok {
+		llamaConfig.ServerPort = port
+	}
+
+	if modelPath, ok := entry.Parameters["model_path"].(string); ok {
+		llamaConfig.ModelPath = paths.Expand(modelPath)
+	} else if len(entry.Models) > 0 {
+		llamaConfig.ModelPath = paths.Expand(entry.Models[0])
+	}
+	if contextSize, ok := intParameter(entry.Parameters, "context_size"); ok {
+		llamaConfig.ContextSize = contextSize
+	}
+	if gpuLayers, ok := intParameter(entry.Parameters, "gpu_layers"); ok {
+		llamaConfig.GPULayers = gpuLayers
+		llamaConfig.GPUEnabled = gpuLayers > 0
+	}
+	if threads, ok := intParameter(entry.Parameters, "threads"); ok {
+		llamaConfig.Threads = threads
+	}
+	if binary, ok := entry.Parameters["server_binary"].(string); ok {
+		llamaConfig.ServerBinary = paths.Expand(binary)
+	}
+
+	return NewLlamaCPPProvider(llamaConfig)
+}
+
+// intParameter reads an integer parameter that may have been decoded as int or float64
+func intParameter(params map[string]interface{}, key string) (int, bool) {
+	switch value := params[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	default:
+		return 0, false
+	}
+}
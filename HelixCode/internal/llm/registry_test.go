@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProviderRegistry_Builtins tests that built-in providers are registered
+func TestProviderRegistry_Builtins(t *testing.T) {
+	types := RegisteredProviderTypes()
+
+	for _, expected := range []string{"local", "openai", "anthropic", "ollama", "llama-cpp"} {
+		assert.Contains(t, types, expected)
+	}
+}
+
+// TestProviderRegistry_Register tests registering custom provider constructors
+func TestProviderRegistry_Register(t *testing.T) {
+	mockProvider := new(MockProvider)
+	var received ProviderConfigEntry
+
+	err := RegisterProviderFactory("Test-Provider", func(config ProviderConfigEntry) (Provider, error) {
+		received = config
+		return mockProvider, nil
+	})
+	require.NoError(t, err)
+	defer UnregisterProviderFactory("test-provider")
+
+	assert.Contains(t, RegisteredProviderTypes(), "test-provider")
+
+	// Duplicate registration is rejected regardless of case
+	err = RegisterProviderFactory("test-provider", func(config ProviderConfigEntry) (Provider, error) {
+		return nil, nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+
+	// Invalid registrations
+	assert.Error(t, RegisterProviderFactory("", func(config ProviderConfigEntry) (Provider, error) { return nil, nil }))
+	assert.Error(t, RegisterProviderFactory("nil-constructor", nil))
+
+	// Construction by name passes the configuration through
+	config := ProviderConfigEntry{Endpoint: "http://example.test", Models: []string{"m1"}}
+	provider, err := NewProviderByName("test-provider", config)
+	require.NoError(t, err)
+	assert.Equal(t, mockProvider, provider)
+	assert.Equal(t, config.Endpoint, received.Endpoint)
+	assert.Equal(t, config.Models, received.Models)
+
+	// The factory resolves by the configured type
+	provider, err = (&ProviderFactory{}).CreateProvider(ProviderConfigEntry{Type: "test-provider"})
+	require.NoError(t, err)
+	assert.Equal(t, mockProvider, provider)
+}
+
+// TestProviderRegistry_Errors tests construction errors
+func TestProviderRegistry_Errors(t *testing.T) {
+	_, err := NewProviderByName("does-not-exist", ProviderConfigEntry{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown provider type")
+	assert.Contains(t, err.Error(), "does-not-exist")
+
+	err = RegisterProviderFactory("failing", func(config ProviderConfigEntry) (Provider, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+	defer UnregisterProviderFactory("failing")
+
+	_, err = NewProviderByName("failing", ProviderConfigEntry{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+
+	// Built-in constructors still validate their configuration
+	_, err = NewProviderByName("openai", ProviderConfigEntry{})
+	assert.Error(t, err)
+}
+
+// TestProviderRegistry_LlamaCPPFromEntry tests building a Llama.cpp provider from config parameters
+func TestProviderRegistry_LlamaCPPFromEntry(t *testing.T) {
+	provider, err := NewProviderByName("llama-cpp", ProviderConfigEntry{
+		Parameters: map[string]interface{}{
+			"model_path":   "/models/test.gguf",
+			"context_size": float64(8192),
+			"gpu_layers":   20,
+		},
+	})
+	require.NoError(t, err)
+
+	llamaProvider, ok := provider.(*LlamaCPPProvider)
+	require.True(t, ok)
+	assert.Equal(t, "/models/test.gguf", llamaProvider.config.ModelPath)
+	assert.Equal(t, 8192, llamaProvider.config.ContextSize)
+	assert.Equal(t, 20, llamaProvider.config.GPULayers)
+	assert.True(t, llamaProvider.config.GPUEnabled)
+	assert.Zero(t, llamaProvider.ServerPort(), "no port is configured, so a managed server gets a free one")
+
+	// The endpoint sets where the server listens
+	provider, err = NewProviderByName("llama-cpp", ProviderConfigEntry{
+		Endpoint:   "http://127.0.0.1:9091",
+		Parameters: map[string]interface{}{"model_path": "/models/test.gguf"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", provider.(*LlamaCPPProvider).config.ServerHost)
+	assert.Equal(t, 9091, provider.(*LlamaCPPProvider).ServerPort())
+}
@@ -0,0 +1,340 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/paths"
+)
+
+// ReplayMode selects what a ReplayProvider does with requests
+type ReplayMode string
+
+const (
+	// ReplayPassthrough sends requests to the wrapped provider unchanged
+	ReplayPassthrough ReplayMode = "passthrough"
+	// ReplayRecord sends requests to the wrapped provider and records the responses
+	ReplayRecord ReplayMode = "record"
+	// ReplayReplay answers requests from the recording without a provider
+	ReplayReplay ReplayMode = "replay"
+)
+
+// ErrReplayMiss is returned in replay mode for a request that was not recorded
+var ErrReplayMiss = errors.New("no recorded response for request")
+
+// replayRecording is the recorded outcome of one request. Streamed requests
+// keep their chunks; others keep the response.
+type replayRecording struct {
+	Response *LLMResponse  `json:"response,omitempty"`
+	Chunks   []LLMResponse `json:"chunks,omitempty"`
+}
+
+// replayFile is the format of a recording file. The provider's identity is
+// kept so that replays look like the recorded provider.
+type replayFile struct {
+	Type       ProviderType               `json:"type"`
+	Name       string                     `json:"name"`
+	Models     []ModelInfo                `json:"models"`
+	Recordings map[string]replayRecording `json:"recordings"`
+}
+
+// ReplayProvider wraps a provider to record its responses to a file and
+// replay them offline, matching requests by a hash of their content. It makes
+// tests and demos reproducible without a live model.
+type ReplayProvider struct {
+	base  Provider
+	mode  ReplayMode
+	path  string
+	mu    sync.Mutex
+	file  replayFile
+	dirty bool
+}
+
+// NewReplayProvider wraps base in the given mode. Recordings are read from
+// path in replay mode and written to it by Save or Close in record mode.
+// base may be nil in replay mode.
+func NewReplayProvider(base Provider, mode ReplayMode, path string) (*ReplayProvider, error) {
+	p := &ReplayProvider{
+		base: base,
+		mode: mode,
+		path: paths.Expand(path),
+		file: replayFile{Recordings: make(map[string]replayRecording)},
+	}
+
+	switch mode {
+	case ReplayPassthrough, ReplayRecord:
+		if base == nil {
+			return nil, fmt.Errorf("%s mode needs a provider", mode)
+		}
+		if mode == ReplayRecord && p.path == "" {
+			return nil, fmt.Errorf("record mode needs a recording file")
+		}
+	case ReplayReplay:
+		if err := p.load(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown replay mode %q (use %s, %s or %s)", mode, ReplayPassthrough, ReplayRecord, ReplayReplay)
+	}
+
+	return p, nil
+}
+
+// NewReplayProviderFromEnv wraps base as HELIX_LLM_REPLAY (record, replay or
+// passthrough) and HELIX_LLM_REPLAY_FILE select. base is returned unwrapped
+// when HELIX_LLM_REPLAY is not set.
+func NewReplayProviderFromEnv(base Provider) (Provider, error) {
+	mode := os.Getenv("HELIX_LLM_REPLAY")
+	if mode == "" {
+		return base, nil
+	}
+	return NewReplayProvider(base, ReplayMode(mode), os.Getenv("HELIX_LLM_REPLAY_FILE"))
+}
+
+// load reads the recording file for replay
+func (p *ReplayProvider) load() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read replay recording: %v", err)
+	}
+	if err := json.Unmarshal(data, &p.file); err != nil {
+		return fmt.Errorf("failed to parse replay recording %s: %v", p.path, err)
+	}
+	if p.file.Recordings == nil {
+		p.file.Recordings = make(map[string]replayRecording)
+	}
+	return nil
+}
+
+// Save writes the recorded responses to the recording file. It does nothing
+// outside record mode or when nothing new was recorded.
+func (p *ReplayProvider) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mode != ReplayRecord || !p.dirty {
+		return nil
+	}
+
+	p.file.Type = p.base.GetType()
+	p.file.Name = p.base.GetName()
+	p.file.Models = p.base.GetModels()
+
+	data, err := json.MarshalIndent(p.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode replay recording: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return fmt.Errorf("failed to create replay recording directory: %v", err)
+	}
+	if err := os.WriteFile(p.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write replay recording: %v", err)
+	}
+	p.dirty = false
+	return nil
+}
+
+// replayKey hashes the parts of a request that determine its response.
+// IDs and timestamps differ between runs and are left out.
+func replayKey(kind string, req *LLMRequest) string {
+	data, _ := json.Marshal(struct {
+		Kind         string            `json:"kind"`
+		ProviderType ProviderType      `json:"provider_type"`
+		Model        string            `json:"model"`
+		Messages     []Message         `json:"messages"`
+		MaxTokens    int               `json:"max_tokens"`
+		Temperature  float64           `json:"temperature"`
+		TopP         float64           `json:"top_p"`
+		Tools        []Tool            `json:"tools"`
+		ToolChoice   string            `json:"tool_choice"`
+		Capabilities []ModelCapability `json:"capabilities"`
+	}{kind, req.ProviderType, req.Model, req.Messages, req.MaxTokens, req.Temperature,
+		req.TopP, req.Tools, req.ToolChoice, req.Capabilities})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recording returns the recorded outcome of a request in replay mode
+func (p *ReplayProvider) recording(kind string, req *LLMRequest) (replayRecording, error) {
+	key := replayKey(kind, req)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recording, ok := p.file.Recordings[key]
+	if !ok {
+		return replayRecording{}, fmt.Errorf("%w: %s request %s for model %s", ErrReplayMiss, kind, key[:12], req.Model)
+	}
+	return recording, nil
+}
+
+// record stores the outcome of a request in record mode
+func (p *ReplayProvider) record(kind string, req *LLMRequest, recording replayRecording) {
+	if p.mode != ReplayRecord {
+		return
+	}
+
+	key := replayKey(kind, req)
+	p.mu.Lock()
+	p.file.Recordings[key] = recording
+	p.dirty = true
+	p.mu.Unlock()
+}
+
+// Generate answers from the recording in replay mode and otherwise calls the
+// wrapped provider, recording successful responses in record mode
+func (p *ReplayProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	if p.mode == ReplayReplay {
+		recording, err := p.recording("generate", req)
+		if err != nil {
+			return nil, err
+		}
+		if recording.Response == nil {
+			return nil, fmt.Errorf("%w: recording has no response", ErrReplayMiss)
+		}
+		response := *recording.Response
+		response.RequestID = req.ID
+		return &response, nil
+	}
+
+	response, err := p.base.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := *response
+	p.record("generate", req, replayRecording{Response: &recorded})
+	return response, nil
+}
+
+// GenerateStream replays recorded chunks in replay mode and otherwise streams
+// from the wrapped provider, recording the chunks of complete streams in
+// record mode
+func (p *ReplayProvider) GenerateStream(ctx context.Context, req *LLMRequest, ch chan<- LLMResponse) error {
+	if p.mode == ReplayReplay {
+		recording, err := p.recording("stream", req)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range recording.Chunks {
+			chunk.RequestID = req.ID
+			select {
+			case ch <- chunk:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	if p.mode != ReplayRecord {
+		return p.base.GenerateStream(ctx, req, ch)
+	}
+
+	// Chunks are forwarded as they arrive and kept for the recording
+	inner := make(chan LLMResponse)
+	var chunks []LLMResponse
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for chunk := range inner {
+			chunks = append(chunks, chunk)
+			ch <- chunk
+		}
+	}()
+
+	err := p.base.GenerateStream(ctx, req, inner)
+	close(inner)
+	<-done
+	if err != nil {
+		return err
+	}
+
+	p.record("stream", req, replayRecording{Chunks: chunks})
+	return nil
+}
+
+// GetType returns the type of the wrapped or recorded provider
+func (p *ReplayProvider) GetType() ProviderType {
+	if p.base != nil {
+		return p.base.GetType()
+	}
+	return p.file.Type
+}
+
+// GetName returns the name of the wrapped or recorded provider
+func (p *ReplayProvider) GetName() string {
+	if p.base != nil {
+		return p.base.GetName()
+	}
+	return p.file.Name
+}
+
+// GetModels returns the models of the wrapped or recorded provider
+func (p *ReplayProvider) GetModels() []ModelInfo {
+	if p.base != nil {
+		return p.base.GetModels()
+	}
+	return p.file.Models
+}
+
+// GetCapabilities returns the capabilities of the wrapped or recorded provider
+func (p *ReplayProvider) GetCapabilities() []ModelCapability {
+	if p.base != nil {
+		return p.base.GetCapabilities()
+	}
+
+	seen := make(map[ModelCapability]bool)
+	var capabilities []ModelCapability
+	for _, model := range p.file.Models {
+		for _, capability := range model.Capabilities {
+			if !seen[capability] {
+				seen[capability] = true
+				capabilities = append(capabilities, capability)
+			}
+		}
+	}
+	return capabilities
+}
+
+// IsAvailable reports whether requests can be answered. A replay is always
+// available, even when the recorded provider is not.
+func (p *ReplayProvider) IsAvailable(ctx context.Context) bool {
+	if p.mode == ReplayReplay {
+		return true
+	}
+	return p.base.IsAvailable(ctx)
+}
+
+// GetHealth returns the health of the wrapped provider, or a healthy status
+// in replay mode
+func (p *ReplayProvider) GetHealth(ctx context.Context) (*ProviderHealth, error) {
+	if p.mode == ReplayReplay {
+		return &ProviderHealth{
+			Status:     "healthy",
+			LastCheck:  time.Now(),
+			ModelCount: len(p.file.Models),
+		}, nil
+	}
+	return p.base.GetHealth(ctx)
+}
+
+// Close saves a recording and closes the wrapped provider
+func (p *ReplayProvider) Close() error {
+	saveErr := p.Save()
+
+	if p.base != nil {
+		if err := p.base.Close(); err != nil {
+			return err
+		}
+	}
+	return saveErr
+}
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func replayRequest(prompt string) *LLMRequest {
+	return &LLMRequest{
+		ID:       uuid.New(),
+		Model:    "scripted-model",
+		Messages: []Message{{Role: "user", Content: prompt}},
+	}
+}
+
+// streamContent collects the content streamed for a request
+func streamContent(t *testing.T, provider Provider, req *LLMRequest) (string, error) {
+	t.Helper()
+	ch := make(chan LLMResponse, 16)
+	err := provider.GenerateStream(context.Background(), req, ch)
+	close(ch)
+
+	content := ""
+	for chunk := range ch {
+		content += chunk.Content
+	}
+	return content, err
+}
+
+func TestReplayProvider_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recordings", "session.json")
+
+	base := newScriptedProvider(true, "first answer", "streamed answer")
+	base.On("GetType").Return(ProviderType("scripted"))
+	base.On("GetName").Return("scripted")
+	base.On("GetModels").Return([]ModelInfo{{Name: "scripted-model", Capabilities: []ModelCapability{CapabilityTextGeneration}}})
+	base.On("Close").Return(nil)
+
+	recorder, err := NewReplayProvider(base, ReplayRecord, path)
+	require.NoError(t, err)
+
+	response, err := recorder.Generate(context.Background(), replayRequest("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "first answer", response.Content)
+
+	content, err := streamContent(t, recorder, replayRequest("stream please"))
+	require.NoError(t, err)
+	assert.Equal(t, "streamed answer", content)
+	require.NoError(t, recorder.Close())
+	assert.FileExists(t, path)
+
+	// The replay needs no provider and ignores request IDs
+	replayer, err := NewReplayProvider(nil, ReplayReplay, path)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderType("scripted"), replayer.GetType())
+	assert.Equal(t, "scripted", replayer.GetName())
+	require.Len(t, replayer.GetModels(), 1)
+	assert.True(t, replayer.IsAvailable(context.Background()))
+
+	request := replayRequest("hello")
+	response, err = replayer.Generate(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "first answer", response.Content)
+	assert.Equal(t, request.ID, response.RequestID)
+
+	content, err = streamContent(t, replayer, replayRequest("stream please"))
+	require.NoError(t, err)
+	assert.Equal(t, "streamed answer", content)
+
+	// Requests that were not recorded are misses
+	_, err = replayer.Generate(context.Background(), replayRequest("something else"))
+	assert.ErrorIs(t, err, ErrReplayMiss)
+	_, err = streamContent(t, replayer, replayRequest("hello"))
+	assert.ErrorIs(t, err, ErrReplayMiss)
+}
+
+func TestReplayProvider_Modes(t *testing.T) {
+	base := newScriptedProvider(false, "live answer")
+
+	passthrough, err := NewReplayProvider(base, ReplayPassthrough, "")
+	require.NoError(t, err)
+	response, err := passthrough.Generate(context.Background(), replayRequest("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "live answer", response.Content)
+	require.NoError(t, passthrough.Save())
+
+	_, err = NewReplayProvider(nil, ReplayRecord, filepath.Join(t.TempDir(), "session.json"))
+	assert.Error(t, err)
+	_, err = NewReplayProvider(base, ReplayRecord, "")
+	assert.Error(t, err)
+	_, err = NewReplayProvider(nil, ReplayReplay, filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+	_, err = NewReplayProvider(base, "rewind", "")
+	assert.Error(t, err)
+}
+
+func TestNewReplayProviderFromEnv(t *testing.T) {
+	base := newScriptedProvider(false)
+
+	t.Setenv("HELIX_LLM_REPLAY", "")
+	provider, err := NewReplayProviderFromEnv(base)
+	require.NoError(t, err)
+	assert.Same(t, base, provider)
+
+	t.Setenv("HELIX_LLM_REPLAY", "passthrough")
+	provider, err = NewReplayProviderFromEnv(base)
+	require.NoError(t, err)
+	assert.IsType(t, &ReplayProvider{}, provider)
+}
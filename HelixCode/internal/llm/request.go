@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Request normalization limits shared by all providers
+const (
+	DefaultMaxTokens = 2048
+	MinTemperature   = 0.0
+	MaxTemperature   = 2.0
+	MaxPromptLength  = 1 << 20 // 1 MiB of prompt text
+)
+
+// NormalizeRequest validates a request and fills in defaults so every provider
+// sees consistent input. Empty or oversized prompts and negative token limits
+// are rejected with ErrInvalidRequest or ErrContextTooLong, a zero MaxTokens
+// is replaced with DefaultMaxTokens and Temperature is clamped to the valid range.
+func NormalizeRequest(request *LLMRequest) error {
+	if request == nil {
+		return fmt.Errorf("%w: request cannot be nil", ErrInvalidRequest)
+	}
+
+	promptLength := 0
+	for _, message := range request.Messages {
+		promptLength += len(strings.TrimSpace(message.Content))
+	}
+	if promptLength == 0 {
+		return fmt.Errorf("%w: prompt cannot be empty", ErrInvalidRequest)
+	}
+	if promptLength > MaxPromptLength {
+		return fmt.Errorf("%w: prompt is %d bytes, limit is %d", ErrContextTooLong, promptLength, MaxPromptLength)
+	}
+
+	if request.MaxTokens < 0 {
+		return fmt.Errorf("%w: max tokens cannot be negative", ErrInvalidRequest)
+	}
+	if request.MaxTokens == 0 {
+		request.MaxTokens = DefaultMaxTokens
+	}
+
+	if request.Temperature < MinTemperature || request.Temperature > MaxTemperature {
+		clamped := request.Temperature
+		if clamped < MinTemperature {
+			clamped = MinTemperature
+		} else {
+			clamped = MaxTemperature
+		}
+		log.Printf("⚠️ Temperature %.2f out of range, clamped to %.2f", request.Temperature, clamped)
+		request.Temperature = clamped
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeRequest tests shared request validation and defaults
+func TestNormalizeRequest(t *testing.T) {
+	userMessage := []Message{{Role: "user", Content: "Write a function"}}
+
+	testCases := []struct {
+		name        string
+		request     *LLMRequest
+		expectedErr error
+		maxTokens   int
+		temperature float64
+	}{
+		{
+			name:        "valid request unchanged",
+			request:     &LLMRequest{Messages: userMessage, MaxTokens: 512, Temperature: 0.7},
+			maxTokens:   512,
+			temperature: 0.7,
+		},
+		{
+			name:        "nil request",
+			expectedErr: ErrInvalidRequest,
+		},
+		{
+			name:        "no messages",
+			request:     &LLMRequest{MaxTokens: 512},
+			expectedErr: ErrInvalidRequest,
+		},
+		{
+			name:        "whitespace prompt",
+			request:     &LLMRequest{Messages: []Message{{Role: "user", Content: "  \n\t "}}},
+			expectedErr: ErrInvalidRequest,
+		},
+		{
+			name:        "oversized prompt",
+			request:     &LLMRequest{Messages: []Message{{Role: "user", Content: strings.Repeat("a", MaxPromptLength+1)}}},
+			expectedErr: ErrContextTooLong,
+		},
+		{
+			name:        "zero max tokens defaulted",
+			request:     &LLMRequest{Messages: userMessage, Temperature: 0.5},
+			maxTokens:   DefaultMaxTokens,
+			temperature: 0.5,
+		},
+		{
+			name:        "negative max tokens rejected",
+			request:     &LLMRequest{Messages: userMessage, MaxTokens: -1},
+			expectedErr: ErrInvalidRequest,
+		},
+		{
+			name:        "temperature clamped high",
+			request:     &LLMRequest{Messages: userMessage, MaxTokens: 100, Temperature: 5},
+			maxTokens:   100,
+			temperature: MaxTemperature,
+		},
+		{
+			name:        "temperature clamped low",
+			request:     &LLMRequest{Messages: userMessage, MaxTokens: 100, Temperature: -0.3},
+			maxTokens:   100,
+			temperature: MinTemperature,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NormalizeRequest(tc.request)
+			if tc.expectedErr != nil {
+				assert.True(t, errors.Is(err, tc.expectedErr), "expected %v, got %v", tc.expectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.maxTokens, tc.request.MaxTokens)
+			assert.Equal(t, tc.temperature, tc.request.Temperature)
+		})
+	}
+}
+
+// TestProviderRejectsEmptyPrompt tests that providers apply request normalization
+func TestProviderRejectsEmptyPrompt(t *testing.T) {
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "test.gguf", ContextSize: 2048})
+	require.NoError(t, err)
+
+	_, err = provider.Generate(context.Background(), &LLMRequest{})
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+	err = provider.GenerateStream(context.Background(), &LLMRequest{}, make(chan LLMResponse, 1))
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+}
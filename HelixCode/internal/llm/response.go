@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrMalformedResponse is wrapped by errors for provider responses that are
+// not the expected shape, such as an error body or a field of the wrong type
+var ErrMalformedResponse = errors.New("malformed provider response")
+
+// ResponseError describes a provider response with an unexpected shape
+type ResponseError struct {
+	Provider string // Empty when the shape was checked outside a provider
+	Field    string // Dotted path of the offending field, empty for the whole body
+	Reason   string
+}
+
+func (e *ResponseError) Error() string {
+	message := "malformed response"
+	if e.Provider != "" {
+		message = fmt.Sprintf("malformed %s response", e.Provider)
+	}
+	if e.Field != "" {
+		message += ": field " + e.Field
+	}
+	return message + ": " + e.Reason
+}
+
+// Unwrap lets errors.Is match ErrMalformedResponse
+func (e *ResponseError) Unwrap() error {
+	return ErrMalformedResponse
+}
+
+// StatusError reports an HTTP error status returned by a provider's API
+type StatusError struct {
+	API        string // Names the API in the message, such as "OpenAI API"
+	StatusCode int
+	Body       string // The response body, empty when it was not read
+}
+
+func (e *StatusError) Error() string {
+	message := fmt.Sprintf("%s returned status %d", e.API, e.StatusCode)
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+	return message
+}
+
+// newStatusError reads the body of an error response into a *StatusError
+func newStatusError(api string, resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(resp.Body)
+	return &StatusError{API: api, StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// decodeResponse decodes one JSON response body into v. An error body and
+// fields of the wrong type are reported as *ResponseError.
+func decodeResponse(provider string, r io.Reader, v interface{}) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := responseBodyError(provider, body); err != nil {
+		return err
+	}
+	return responseShapeError(provider, json.Unmarshal(body, v))
+}
+
+// decodeChunk decodes the next value of a streamed response into v, checking
+// it the same way as decodeResponse
+func decodeChunk(provider string, decoder *json.Decoder, v interface{}) error {
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return responseShapeError(provider, err)
+	}
+	if err := responseBodyError(provider, raw); err != nil {
+		return err
+	}
+	return responseShapeError(provider, json.Unmarshal(raw, v))
+}
+
+// responseBodyError returns a *ResponseError if body is a JSON object with an
+// "error" member, which backends send instead of the expected response
+func responseBodyError(provider string, body []byte) error {
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(body, &envelope) != nil {
+		return nil
+	}
+	raw, ok := envelope["error"]
+	if !ok || string(raw) == "null" || string(raw) == `""` {
+		return nil
+	}
+	return &ResponseError{Provider: provider, Field: "error", Reason: errorMessage(raw)}
+}
+
+// errorMessage extracts a readable message from an error member, which is
+// either a string or an object with a message
+func errorMessage(raw json.RawMessage) string {
+	var message string
+	if json.Unmarshal(raw, &message) == nil {
+		return message
+	}
+
+	var object map[string]interface{}
+	if json.Unmarshal(raw, &object) == nil {
+		if message, err := StringField(object, "message"); err == nil {
+			return message
+		}
+	}
+	return string(raw)
+}
+
+// responseShapeError converts JSON type and syntax errors into *ResponseError
+func responseShapeError(provider string, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		return &ResponseError{
+			Provider: provider,
+			Field:    typeErr.Field,
+			Reason:   fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &ResponseError{Provider: provider, Reason: fmt.Sprintf("invalid JSON: %v", err)}
+	default:
+		return err
+	}
+}
+
+// StringField returns the string at key in a decoded JSON object, or a
+// *ResponseError if it is missing or not a string
+func StringField(object map[string]interface{}, key string) (string, error) {
+	value, ok := object[key]
+	if !ok || value == nil {
+		return "", &ResponseError{Field: key, Reason: "missing"}
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", &ResponseError{Field: key, Reason: fmt.Sprintf("expected string, got %s", jsonTypeName(value))}
+	}
+	return s, nil
+}
+
+// jsonTypeName names the JSON type of a value decoded into interface{}
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBodyServer returns a server that answers model listing with no models
+// and every other request with body
+func newBodyServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/api/tags" || r.URL.Path == "/models" {
+			fmt.Fprint(w, `{"models":[],"data":[]}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testRequest() *LLMRequest {
+	return &LLMRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	}
+}
+
+func requireResponseError(t *testing.T, err error) *ResponseError {
+	t.Helper()
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+	var responseErr *ResponseError
+	require.True(t, errors.As(err, &responseErr), "expected *ResponseError, got %T: %v", err, err)
+	return responseErr
+}
+
+// TestMalformedResponses tests that unexpected response shapes return typed errors instead of panicking
+func TestMalformedResponses(t *testing.T) {
+	ollama := func(t *testing.T, body string) error {
+		server := newBodyServer(t, body)
+		provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+		require.NoError(t, err)
+		_, err = provider.Generate(context.Background(), testRequest())
+		return err
+	}
+	openAI := func(t *testing.T, body string) error {
+		server := newBodyServer(t, body)
+		provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+		require.NoError(t, err)
+		_, err = provider.Generate(context.Background(), testRequest())
+		return err
+	}
+
+	t.Run("openai without choices", func(t *testing.T) {
+		responseErr := requireResponseError(t, openAI(t, `{"id":"x","choices":[]}`))
+		assert.Equal(t, "choices", responseErr.Field)
+		assert.Equal(t, "missing", responseErr.Reason)
+	})
+
+	t.Run("openai error body", func(t *testing.T) {
+		responseErr := requireResponseError(t, openAI(t, `{"error":{"message":"rate limited","type":"requests"}}`))
+		assert.Equal(t, "error", responseErr.Field)
+		assert.Equal(t, "rate limited", responseErr.Reason)
+	})
+
+	t.Run("ollama wrong type", func(t *testing.T) {
+		responseErr := requireResponseError(t, ollama(t, `{"response":123,"done":true}`))
+		assert.Equal(t, "response", responseErr.Field)
+		assert.Contains(t, responseErr.Error(), "expected string")
+	})
+
+	t.Run("ollama error body", func(t *testing.T) {
+		err := ollama(t, `{"error":"model not found"}`)
+		responseErr := requireResponseError(t, err)
+		assert.Equal(t, "model not found", responseErr.Reason)
+		assert.Contains(t, err.Error(), "model not found")
+	})
+
+	t.Run("ollama truncated", func(t *testing.T) {
+		responseErr := requireResponseError(t, ollama(t, `{"response":"hel`))
+		assert.Empty(t, responseErr.Field)
+	})
+
+	t.Run("ollama stream error chunk", func(t *testing.T) {
+		server := newBodyServer(t, `{"response":"hel","done":false}`+"\n"+`{"error":"out of memory"}`+"\n")
+		provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+		require.NoError(t, err)
+
+		ch := make(chan LLMResponse, 10)
+		err = provider.GenerateStream(context.Background(), testRequest(), ch)
+		responseErr := requireResponseError(t, err)
+		assert.Equal(t, "out of memory", responseErr.Reason)
+	})
+}
+
+func TestStringField(t *testing.T) {
+	object := map[string]interface{}{"name": "llama3", "size": 42.0, "empty": nil}
+
+	value, err := StringField(object, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "llama3", value)
+
+	_, err = StringField(object, "missing")
+	responseErr := requireResponseError(t, err)
+	assert.Equal(t, "missing", responseErr.Field)
+	assert.Equal(t, "missing", responseErr.Reason)
+
+	_, err = StringField(object, "empty")
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+
+	_, err = StringField(object, "size")
+	responseErr = requireResponseError(t, err)
+	assert.Equal(t, "expected string, got number", responseErr.Reason)
+}
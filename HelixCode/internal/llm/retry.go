@@ -0,0 +1,255 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"dev.helix.code/internal/clock"
+)
+
+type retryBudgetKey struct{}
+
+// RetryBudget bounds the retries made for one request across every layer that
+// retries, so nested retry loops cannot multiply beyond what the caller
+// tolerates. Share it through the request context with WithRetryBudget.
+type RetryBudget struct {
+	mu          sync.Mutex
+	maxRetries  int
+	maxDuration time.Duration
+	used        int
+	start       time.Time
+	clock       clock.Clock
+}
+
+// NewRetryBudget creates a budget of at most maxRetries retries made within
+// maxDuration of now. A zero limit is not enforced.
+func NewRetryBudget(maxRetries int, maxDuration time.Duration) *RetryBudget {
+	c := clock.New()
+	return &RetryBudget{
+		maxRetries:  maxRetries,
+		maxDuration: maxDuration,
+		start:       c.Now(),
+		clock:       c,
+	}
+}
+
+// SetClock replaces the clock used for the time limit, which restarts from the
+// clock's current time
+func (b *RetryBudget) SetClock(c clock.Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = c
+	b.start = c.Now()
+}
+
+// Spend takes one retry from the budget, reporting false if none is left
+func (b *RetryBudget) Spend() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.exhaustedLocked() {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// Used returns how many retries have been taken
+func (b *RetryBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Exhausted reports whether no further retry is allowed
+func (b *RetryBudget) Exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhaustedLocked()
+}
+
+func (b *RetryBudget) exhaustedLocked() bool {
+	if b.maxRetries > 0 && b.used >= b.maxRetries {
+		return true
+	}
+	return b.maxDuration > 0 && b.clock.Since(b.start) >= b.maxDuration
+}
+
+// WithRetryBudget returns a context whose retries, at every layer, are taken
+// from budget
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the budget set with WithRetryBudget, or nil
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// spendRetry takes a retry from ctx's budget, if it carries one, reporting
+// whether the caller may retry
+func spendRetry(ctx context.Context) bool {
+	budget := RetryBudgetFromContext(ctx)
+	if budget == nil || budget.Spend() {
+		return true
+	}
+	log.Printf("⚠️ Retry budget exhausted after %d retries", budget.Used())
+	return false
+}
+
+// Retry calls fn until it succeeds, making at most retries further attempts.
+// Each retry is taken from ctx's RetryBudget, so nested Retry calls share one
+// limit. When the retries, the budget or ctx run out, the last error is returned.
+func Retry(ctx context.Context, retries int, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= retries || ctx.Err() != nil || !spendRetry(ctx) {
+			return err
+		}
+	}
+}
+
+// Default retries of transient provider failures and the backoff between them
+const (
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryMaxDelay  = 10 * time.Second
+)
+
+// RetryPolicy configures the retries of provider calls that fail transiently.
+// The delay before retry n is BaseDelay doubled n-1 times, capped at
+// MaxDelay, of which a random half is jitter.
+type RetryPolicy struct {
+	MaxRetries int           // 0 disables retries
+	BaseDelay  time.Duration // 0 uses DefaultRetryBaseDelay
+	MaxDelay   time.Duration // 0 uses DefaultRetryMaxDelay
+
+	// Clock waits out the backoff and Jitter returns a random number in
+	// [0, n); nil uses the system clock and math/rand. Tests replace them.
+	Clock  clock.Clock
+	Jitter func(n int64) int64
+}
+
+// backoff returns the delay before the given retry, counting from 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	base, limit := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	if limit <= 0 {
+		limit = DefaultRetryMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < retry && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	jitter := p.Jitter
+	if jitter == nil {
+		jitter = rand.Int63n
+	}
+	half := delay / 2
+	return half + time.Duration(jitter(int64(half)+1))
+}
+
+// clock returns the clock that times the backoff
+func (p RetryPolicy) clock() clock.Clock {
+	if p.Clock == nil {
+		return clock.New()
+	}
+	return p.Clock
+}
+
+// IsTransientError reports whether err is a failure worth retrying: a
+// connection that could not be made or was dropped, a 5xx status, or a
+// provider that is not serving yet. Cancellation, invalid requests and other
+// statuses are not transient.
+func IsTransientError(err error) bool {
+	var status *StatusError
+	var opErr *net.OpError
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &status):
+		return status.StatusCode >= 500
+	case errors.As(err, &opErr),
+		errors.Is(err, ErrProviderUnavailable),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	return false
+}
+
+// RetryingProvider retries the Generate calls of a provider that fail with a
+// transient error, such as a local server that is still loading its model,
+// backing off exponentially with jitter. Streams are not retried, since
+// chunks may already have been delivered.
+type RetryingProvider struct {
+	Provider
+	policy RetryPolicy
+}
+
+// NewRetryingProvider wraps provider so Generate is retried under policy
+func NewRetryingProvider(provider Provider, policy RetryPolicy) *RetryingProvider {
+	return &RetryingProvider{Provider: provider, policy: policy}
+}
+
+// Generate calls the provider until it succeeds or fails with an error that
+// is not transient. Each retry is taken from ctx's RetryBudget, and no retry
+// is made when ctx's deadline would pass before it starts.
+func (p *RetryingProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	clk := p.policy.clock()
+	for retry := 1; ; retry++ {
+		resp, err := p.Provider.Generate(ctx, request)
+		if err == nil || retry > p.policy.MaxRetries || !IsTransientError(err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := p.policy.backoff(retry)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+		if !spendRetry(ctx) {
+			return nil, err
+		}
+		log.Printf("🔄 %s failed, retrying in %v (%d/%d): %v", p.GetName(), delay.Round(time.Millisecond), retry, p.policy.MaxRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-clk.After(delay):
+		}
+	}
+}
+
+// SupportsStreaming reports whether the wrapped provider supports streaming
+func (p *RetryingProvider) SupportsStreaming() bool {
+	return GetProviderCapabilities(p.Provider).Streaming
+}
+
+// SupportsNativeTools reports whether the wrapped provider sends tools natively
+func (p *RetryingProvider) SupportsNativeTools() bool {
+	return GetProviderCapabilities(p.Provider).NativeTools
+}
+
+// SupportsJSONMode reports whether the wrapped provider has a JSON mode
+func (p *RetryingProvider) SupportsJSONMode() bool {
+	return GetProviderCapabilities(p.Provider).JSONMode
+}
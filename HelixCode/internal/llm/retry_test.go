@@ -0,0 +1,318 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCall returns a function that fails every time, numbering its errors
+func failingCall(calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		return fmt.Errorf("attempt %d failed", *calls)
+	}
+}
+
+func TestRetry_WithoutBudget(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), 2, failingCall(&calls))
+	assert.EqualError(t, err, "attempt 3 failed")
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = Retry(context.Background(), 5, func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return fmt.Errorf("transient")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+// TestRetry_NestedSharesBudget tests that nested retry loops draw on one budget
+func TestRetry_NestedSharesBudget(t *testing.T) {
+	budget := NewRetryBudget(4, 0)
+	ctx := WithRetryBudget(context.Background(), budget)
+
+	calls := 0
+	err := Retry(ctx, 3, func(ctx context.Context) error {
+		return Retry(ctx, 3, failingCall(&calls))
+	})
+
+	// Without the budget the loops would make 16 calls
+	assert.Equal(t, 5, calls)
+	assert.EqualError(t, err, "attempt 5 failed")
+	assert.Equal(t, 4, budget.Used())
+	assert.True(t, budget.Exhausted())
+}
+
+func TestRetry_TimeBudget(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	budget := NewRetryBudget(0, 3*time.Second)
+	budget.SetClock(mock)
+	ctx := WithRetryBudget(context.Background(), budget)
+
+	calls := 0
+	err := Retry(ctx, 10, func(ctx context.Context) error {
+		calls++
+		mock.Advance(time.Second)
+		return fmt.Errorf("attempt %d failed", calls)
+	})
+
+	assert.EqualError(t, err, "attempt 3 failed")
+	assert.Equal(t, 3, calls)
+}
+
+// TestGenerateNonEmpty_SharesBudget tests that empty-response retries inside an
+// outer retry loop stop when the shared budget runs out
+func TestGenerateNonEmpty_SharesBudget(t *testing.T) {
+	provider := newScriptedProvider(false)
+	provider.On("GetName").Return("scripted")
+	ctx := WithRetryBudget(context.Background(), NewRetryBudget(2, 0))
+	req := &LLMRequest{Messages: []Message{{Role: "user", Content: "hello"}}}
+
+	err := Retry(ctx, 5, func(ctx context.Context) error {
+		_, err := generateNonEmpty(ctx, provider, req, 5)
+		return err
+	})
+
+	require.ErrorIs(t, err, ErrEmptyResponse)
+	assert.Len(t, provider.prompts, 3)
+}
+
+// flakyProvider fails Generate with each of errs in turn, then succeeds
+type flakyProvider struct {
+	*MockProvider
+	errs  []error
+	calls int
+}
+
+func newFlakyProvider(errs ...error) *flakyProvider {
+	base := new(MockProvider)
+	base.On("GetName").Return("flaky").Maybe()
+	base.On("GetType").Return(ProviderTypeLocal).Maybe()
+	base.On("IsAvailable", mock.Anything).Return(true).Maybe()
+	return &flakyProvider{MockProvider: base, errs: errs}
+}
+
+func (p *flakyProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+	return &LLMResponse{Content: "ready"}, nil
+}
+
+func TestIsTransientError(t *testing.T) {
+	// A connection to a closed server is refused
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	_, refused := http.Get(server.URL)
+	require.Error(t, refused)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", refused, true},
+		{"dial error", fmt.Errorf("API request failed: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), true},
+		{"connection reset", syscall.ECONNRESET, true},
+		{"server error", &StatusError{API: "API", StatusCode: 503}, true},
+		{"provider unavailable", ErrProviderUnavailable, true},
+		{"wrapped server error", fmt.Errorf("generation failed: %w", &StatusError{API: "API", StatusCode: 500}), true},
+		{"client error", &StatusError{API: "API", StatusCode: 400}, false},
+		{"rate limited", &StatusError{API: "API", StatusCode: 429}, false},
+		{"invalid request", ErrInvalidRequest, false},
+		{"missing model", &ModelNotFoundError{Model: "llama3"}, false},
+		{"malformed response", &ResponseError{Reason: "invalid JSON"}, false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", fmt.Errorf("request failed: %w", context.DeadlineExceeded), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsTransientError(tt.err), tt.name)
+	}
+}
+
+// instantClock is a mock clock whose waits advance it at once, recording
+// how long each wait was
+type instantClock struct {
+	*clock.Mock
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func newInstantClock() *instantClock {
+	return &instantClock{Mock: clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))}
+}
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+	c.Advance(d)
+	return c.Mock.After(0)
+}
+
+// instantRetries returns a policy of retries whose backoff takes no real
+// time, with the longest jitter
+func instantRetries(retries int) (RetryPolicy, *instantClock) {
+	c := newInstantClock()
+	return RetryPolicy{MaxRetries: retries, Clock: c, Jitter: func(n int64) int64 { return n - 1 }}, c
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	shortest := func(n int64) int64 { return 0 }
+	longest := func(n int64) int64 { return n - 1 }
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		retry     int
+		jitter    func(n int64) int64
+		wantDelay time.Duration
+	}{
+		{1, shortest, 50 * time.Millisecond},
+		{1, longest, 100 * time.Millisecond},
+		{3, shortest, 200 * time.Millisecond},
+		{3, longest, 400 * time.Millisecond},
+		{30, shortest, 500 * time.Millisecond},
+		{30, longest, time.Second},
+	}
+	for _, tt := range tests {
+		policy.Jitter = tt.jitter
+		assert.Equal(t, tt.wantDelay, policy.backoff(tt.retry), "retry %d", tt.retry)
+	}
+
+	defaults := RetryPolicy{Jitter: longest}
+	assert.Equal(t, DefaultRetryBaseDelay, defaults.backoff(1))
+	assert.Equal(t, DefaultRetryMaxDelay, defaults.backoff(30))
+
+	// Without a jitter function the delay is random within its range
+	random := RetryPolicy{}.backoff(1)
+	assert.True(t, random >= DefaultRetryBaseDelay/2 && random <= DefaultRetryBaseDelay, "default backoff %v", random)
+}
+
+func TestRetryingProvider_Generate(t *testing.T) {
+	warmingUp := &StatusError{API: "API", StatusCode: 503, Body: "loading model"}
+
+	t.Run("recovers", func(t *testing.T) {
+		policy, clk := instantRetries(3)
+		policy.BaseDelay, policy.MaxDelay = time.Second, 3*time.Second
+		base := newFlakyProvider(warmingUp, warmingUp)
+		resp, err := NewRetryingProvider(base, policy).Generate(context.Background(), &LLMRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "ready", resp.Content)
+		assert.Equal(t, 3, base.calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clk.delays)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		policy, clk := instantRetries(3)
+		base := newFlakyProvider(warmingUp, warmingUp, warmingUp, warmingUp, warmingUp)
+		_, err := NewRetryingProvider(base, policy).Generate(context.Background(), &LLMRequest{})
+		assert.ErrorIs(t, err, warmingUp)
+		assert.Equal(t, 1+policy.MaxRetries, base.calls)
+		assert.Len(t, clk.delays, policy.MaxRetries)
+	})
+
+	t.Run("not transient", func(t *testing.T) {
+		policy, clk := instantRetries(3)
+		base := newFlakyProvider(fmt.Errorf("%w: no messages", ErrInvalidRequest))
+		_, err := NewRetryingProvider(base, policy).Generate(context.Background(), &LLMRequest{})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+		assert.Equal(t, 1, base.calls)
+		assert.Empty(t, clk.delays)
+	})
+
+	t.Run("retries disabled", func(t *testing.T) {
+		base := newFlakyProvider(warmingUp)
+		_, err := NewRetryingProvider(base, RetryPolicy{}).Generate(context.Background(), &LLMRequest{})
+		assert.ErrorIs(t, err, warmingUp)
+		assert.Equal(t, 1, base.calls)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		// The backoff would outlast the deadline, so no retry is made
+		policy, clk := instantRetries(3)
+		policy.BaseDelay = time.Minute
+		base := newFlakyProvider(warmingUp)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := NewRetryingProvider(base, policy).Generate(ctx, &LLMRequest{})
+		assert.ErrorIs(t, err, warmingUp)
+		assert.Equal(t, 1, base.calls)
+		assert.Empty(t, clk.delays)
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		policy, _ := instantRetries(3)
+		base := newFlakyProvider(warmingUp, warmingUp, warmingUp)
+		ctx := WithRetryBudget(context.Background(), NewRetryBudget(1, 0))
+		_, err := NewRetryingProvider(base, policy).Generate(ctx, &LLMRequest{})
+		assert.True(t, errors.Is(err, warmingUp))
+		assert.Equal(t, 2, base.calls)
+	})
+}
+
+func TestProviderManager_GenerateRetries(t *testing.T) {
+	base := newFlakyProvider(&StatusError{API: "API", StatusCode: 502})
+	manager := NewProviderManager(ProviderConfig{DefaultProvider: ProviderTypeLocal, MaxRetries: 2})
+	require.NoError(t, manager.RegisterProvider(base))
+	policy, clk := instantRetries(manager.retryPolicy.MaxRetries)
+	manager.SetRetryPolicy(policy)
+
+	resp, err := manager.Generate(context.Background(), &LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ready", resp.Content)
+	assert.Equal(t, 2, base.calls)
+	assert.Len(t, clk.delays, 1)
+
+	// Streaming support is still reported through the wrapper
+	assert.Equal(t, GetProviderCapabilities(base), GetProviderCapabilities(NewRetryingProvider(base, RetryPolicy{})))
+}
+
+func TestModelManager_GenerateRetries(t *testing.T) {
+	// A llama.cpp server that is still starting reports itself unavailable
+	base := newFlakyProvider(ErrProviderUnavailable)
+	base.On("GetModels").Return([]ModelInfo{{Name: "warming", Capabilities: []ModelCapability{CapabilityTextGeneration}}})
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(base))
+	policy, _ := instantRetries(2)
+	manager.SetRetryPolicy(policy)
+
+	generation, err := manager.StartGeneration(context.Background(), ProviderTypeLocal, &LLMRequest{})
+	require.NoError(t, err)
+	resp, err := generation.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, "ready", resp.Content)
+	assert.Equal(t, 2, base.calls)
+
+	base.calls = 0
+	responses, err := manager.GenerateBatch(context.Background(), []GenerationRequest{{ProviderType: ProviderTypeLocal, Request: &LLMRequest{}}})
+	require.NoError(t, err)
+	require.NoError(t, responses[0].Error)
+	assert.Equal(t, 2, base.calls)
+
+	manager.SetRetryPolicy(RetryPolicy{})
+	base.calls = 0
+	generation, err = manager.StartGeneration(context.Background(), ProviderTypeLocal, &LLMRequest{})
+	require.NoError(t, err)
+	_, err = generation.Wait()
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+}
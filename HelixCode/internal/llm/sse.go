@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sseDone is the data of the event that ends an OpenAI-compatible stream
+const sseDone = "[DONE]"
+
+// SSEEvent is one server-sent event
+type SSEEvent struct {
+	Event string // The event type; empty for the default "message"
+	Data  string // Data lines joined with newlines
+	ID    string
+}
+
+// SSEReader reads server-sent events from a stream. Events may be split
+// across reads of the underlying reader.
+type SSEReader struct {
+	reader *bufio.Reader
+}
+
+// NewSSEReader creates a reader of the server-sent events in r
+func NewSSEReader(r io.Reader) *SSEReader {
+	return &SSEReader{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next event with data, skipping comments such as
+// keep-alives. It returns io.EOF at the end of the stream. An event cut off by
+// the end of the stream is still returned, since some servers omit the final
+// blank line.
+func (r *SSEReader) Next() (*SSEEvent, error) {
+	event := &SSEEvent{}
+	var data []string
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		atEOF := err != nil
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		switch {
+		case line == "" && !atEOF:
+			// A blank line ends the event; events without data are dropped
+			if data != nil {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			event = &SSEEvent{}
+		case strings.HasPrefix(line, ":"):
+			// Comment, used for keep-alives
+		case line != "":
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data = append(data, value)
+			case "event":
+				event.Event = value
+			case "id":
+				event.ID = value
+			}
+		}
+
+		if atEOF {
+			if data != nil {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
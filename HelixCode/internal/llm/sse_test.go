@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvents reads every event from an SSE body
+func readEvents(t *testing.T, r io.Reader) []*SSEEvent {
+	t.Helper()
+
+	var events []*SSEEvent
+	reader := NewSSEReader(r)
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return events
+		}
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+}
+
+func TestSSEReader(t *testing.T) {
+	body := ": keep-alive\n\n" +
+		"data: first\n\n" +
+		"event: update\r\nid: 7\r\ndata: line one\r\ndata:line two\r\n\r\n" +
+		"retry: 1000\n\n" +
+		"data: [DONE]"
+
+	expected := []*SSEEvent{
+		{Data: "first"},
+		{Event: "update", ID: "7", Data: "line one\nline two"},
+		{Data: "[DONE]"},
+	}
+
+	assert.Equal(t, expected, readEvents(t, strings.NewReader(body)))
+	// Events split across reads parse the same
+	assert.Equal(t, expected, readEvents(t, iotest.OneByteReader(strings.NewReader(body))))
+	assert.Empty(t, readEvents(t, strings.NewReader(": only a comment\n\n")))
+}
+
+func TestOpenAIProvider_SSEStream(t *testing.T) {
+	chunk := func(content string) string {
+		return `data: {"choices":[{"delta":{"content":"` + content + `"}}]}` + "\n\n"
+	}
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"done sentinel", chunk("Hel") + ": keep-alive\n\n" + chunk("lo") + "data: [DONE]\n\n" + chunk("ignored")},
+		{"finish reason", chunk("Hel") + chunk("lo") + `data: {"choices":[{"delta":{},"finish_reason":"stop"}]}` + "\n\n" + chunk("ignored")},
+		{"end of body", chunk("Hel") + chunk("lo")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newBodyServer(t, tc.body)
+			provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+			require.NoError(t, err)
+
+			ch := make(chan LLMResponse, 10)
+			require.NoError(t, provider.GenerateStream(context.Background(), testRequest(), ch))
+			assert.Equal(t, []string{"Hel", "lo"}, collect(ch))
+		})
+	}
+
+	t.Run("error event", func(t *testing.T) {
+		server := newBodyServer(t, chunk("Hel")+`data: {"error":{"message":"overloaded"}}`+"\n\n")
+		provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+		require.NoError(t, err)
+
+		ch := make(chan LLMResponse, 10)
+		responseErr := requireResponseError(t, provider.GenerateStream(context.Background(), testRequest(), ch))
+		assert.Equal(t, "overloaded", responseErr.Reason)
+	})
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"io"
+)
+
+// closeOnCancel closes a streaming response body as soon as the context is
+// cancelled, so blocked reads return and the upstream connection is released
+// instead of being drained to completion. The returned function stops the
+// watcher and must be called when streaming ends.
+func closeOnCancel(ctx context.Context, body io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			body.Close()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// streamError prefers the context error when a stream read failed because of cancellation
+func streamError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// ResponseStream is a stream read from a returned channel. C is closed once
+// the producer has finished, after which Err reports how it finished.
+type ResponseStream struct {
+	C   <-chan LLMResponse
+	err error
+}
+
+// Err returns the producer's error. It is only valid once C is closed. If ctx
+// ended while chunks were still arriving, the context error is returned even
+// when the producer reported none, since chunks may have been dropped.
+func (s *ResponseStream) Err() error {
+	return s.err
+}
+
+// NewResponseStream runs a producer that sends to a caller-provided channel,
+// as Provider.GenerateStream does, and exposes its output as a returned
+// channel. Completion is signalled by produce returning, so producers may
+// close their channel or leave it open. If ctx ends the remaining chunks are
+// discarded so the producer never blocks.
+func NewResponseStream(ctx context.Context, produce func(ctx context.Context, ch chan<- LLMResponse) error) *ResponseStream {
+	in := make(chan LLMResponse)
+	out := make(chan LLMResponse)
+	stream := &ResponseStream{C: out}
+
+	done := make(chan struct{})
+	var produceErr error
+	go func() {
+		defer close(done)
+		produceErr = produce(ctx, in)
+	}()
+
+	go func() {
+		defer close(out)
+
+		dropped := false
+		send := func(resp LLMResponse) {
+			if ctx.Err() != nil {
+				dropped = true
+				return
+			}
+			select {
+			case out <- resp:
+			case <-ctx.Done():
+				dropped = true
+			}
+		}
+
+		source := (<-chan LLMResponse)(in)
+	forward:
+		for {
+			select {
+			case resp, ok := <-source:
+				if !ok {
+					// Closed by the producer; wait for it to return
+					source = nil
+					continue
+				}
+				send(resp)
+			case <-done:
+				break forward
+			}
+		}
+
+		// Collect anything sent between the last receive and produce returning
+		for source != nil {
+			select {
+			case resp, ok := <-source:
+				if !ok {
+					source = nil
+					continue
+				}
+				send(resp)
+			default:
+				source = nil
+			}
+		}
+
+		stream.err = produceErr
+		if stream.err == nil && dropped {
+			stream.err = ctx.Err()
+		}
+	}()
+
+	return stream
+}
+
+// OpenStream starts streaming a request from provider into a returned channel.
+// See NewResponseStream.
+func OpenStream(ctx context.Context, provider Provider, req *LLMRequest) *ResponseStream {
+	return NewResponseStream(ctx, func(ctx context.Context, ch chan<- LLMResponse) error {
+		return provider.GenerateStream(ctx, req, ch)
+	})
+}
+
+// ForwardTo copies the stream to a caller-provided channel, as
+// Provider.GenerateStream implementations do, and closes ch when the stream
+// ends. It returns the stream's error, or ctx's error if ctx ends first, in
+// which case the rest of the stream is discarded.
+func (s *ResponseStream) ForwardTo(ctx context.Context, ch chan<- LLMResponse) error {
+	defer close(ch)
+
+	for {
+		select {
+		case resp, ok := <-s.C:
+			if !ok {
+				return s.Err()
+			}
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+				go s.discard()
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			go s.discard()
+			return ctx.Err()
+		}
+	}
+}
+
+// discard drains the stream so its producer can finish
+func (s *ResponseStream) discard() {
+	for range s.C {
+	}
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newSlowStreamServer returns a server that streams one chunk and then stalls,
+// signalling on the returned channel when the client drops the connection
+func newSlowStreamServer(t *testing.T, chunk string) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+
+	disconnected := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			fmt.Fprint(w, `{"models":[]}`)
+			return
+		}
+
+		fmt.Fprintln(w, chunk)
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-r.Context().Done():
+			close(disconnected)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, disconnected
+}
+
+// assertStreamCancellation cancels a stream after its first chunk and checks the upstream connection closes
+func assertStreamCancellation(t *testing.T, provider Provider, disconnected <-chan struct{}) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan LLMResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- provider.GenerateStream(ctx, &LLMRequest{
+			Messages: []Message{{Role: "user", Content: "hello"}},
+		}, ch)
+	}()
+
+	select {
+	case chunk := <-ch:
+		assert.Equal(t, "first", chunk.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for first chunk")
+	}
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not stop after cancellation")
+	}
+
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream connection was not closed after cancellation")
+	}
+}
+
+// TestLocalProvider_StreamCancellation tests that cancelling a stream closes the upstream connection
+func TestLocalProvider_StreamCancellation(t *testing.T) {
+	server, disconnected := newSlowStreamServer(t, `{"response":"first","done":false}`)
+
+	provider, err := NewLocalProvider(ProviderConfigEntry{Endpoint: server.URL})
+	require.NoError(t, err)
+
+	assertStreamCancellation(t, provider, disconnected)
+}
+
+// TestOllamaProvider_StreamCancellation tests that cancelling an Ollama stream closes the upstream connection
+func TestOllamaProvider_StreamCancellation(t *testing.T) {
+	server, disconnected := newSlowStreamServer(t, `{"message":{"role":"assistant","content":"first"},"done":false}`)
+
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 30})
+	require.NoError(t, err)
+
+	assertStreamCancellation(t, provider, disconnected)
+}
+
+// TestOpenAIProvider_StreamCancellation tests that cancelling an OpenAI stream closes the upstream connection
+func TestOpenAIProvider_StreamCancellation(t *testing.T) {
+	server, disconnected := newSlowStreamServer(t, "data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n")
+
+	provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+	require.NoError(t, err)
+
+	assertStreamCancellation(t, provider, disconnected)
+}
+
+// chunkProducer sends each content as a chunk, optionally closing the channel,
+// and then returns err
+func chunkProducer(closeCh bool, err error, contents ...string) func(ctx context.Context, ch chan<- LLMResponse) error {
+	return func(ctx context.Context, ch chan<- LLMResponse) error {
+		if closeCh {
+			defer close(ch)
+		}
+		for _, content := range contents {
+			select {
+			case ch <- LLMResponse{Content: content}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return err
+	}
+}
+
+func collect(ch <-chan LLMResponse) []string {
+	var contents []string
+	for resp := range ch {
+		contents = append(contents, resp.Content)
+	}
+	return contents
+}
+
+// TestResponseStream_FromCallerChannel tests adapting caller-provided channel
+// producers, whether or not they close the channel
+func TestResponseStream_FromCallerChannel(t *testing.T) {
+	failure := errors.New("stream failed")
+	cases := []struct {
+		name    string
+		closeCh bool
+		err     error
+	}{
+		{"closes channel", true, nil},
+		{"leaves channel open", false, nil},
+		{"closes channel with error", true, failure},
+		{"leaves channel open with error", false, failure},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := NewResponseStream(context.Background(), chunkProducer(tc.closeCh, tc.err, "a", "b", "c"))
+			assert.Equal(t, []string{"a", "b", "c"}, collect(stream.C))
+			assert.Equal(t, tc.err, stream.Err())
+		})
+	}
+}
+
+// TestResponseStream_ForwardTo tests adapting a returned stream back to a
+// caller-provided channel
+func TestResponseStream_ForwardTo(t *testing.T) {
+	failure := errors.New("stream failed")
+	stream := NewResponseStream(context.Background(), chunkProducer(false, failure, "a", "b"))
+
+	ch := make(chan LLMResponse)
+	errCh := make(chan error, 1)
+	go func() { errCh <- stream.ForwardTo(context.Background(), ch) }()
+
+	// ch is closed by ForwardTo, so ranging over it ends
+	assert.Equal(t, []string{"a", "b"}, collect(ch))
+	assert.Equal(t, failure, <-errCh)
+}
+
+// TestResponseStream_Cancellation tests that cancelling stops the stream
+// without blocking the producer and reports the context error
+func TestResponseStream_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	produced := make(chan struct{})
+	stream := NewResponseStream(ctx, func(ctx context.Context, ch chan<- LLMResponse) error {
+		defer close(produced)
+		ch <- LLMResponse{Content: "first"}
+		// Ignores ctx while sending, which the adapter must absorb
+		for i := 0; i < 3; i++ {
+			ch <- LLMResponse{Content: "late"}
+		}
+		return nil
+	})
+
+	first := <-stream.C
+	assert.Equal(t, "first", first.Content)
+	cancel()
+
+	select {
+	case <-produced:
+	case <-time.After(time.Second):
+		t.Fatal("Producer blocked after cancellation")
+	}
+	collect(stream.C)
+	assert.ErrorIs(t, stream.Err(), context.Canceled)
+
+	// ForwardTo returns at once on a cancelled context and still closes ch
+	stream = NewResponseStream(context.Background(), chunkProducer(false, nil, "a", "b"))
+	ch := make(chan LLMResponse)
+	require.ErrorIs(t, stream.ForwardTo(ctx, ch), context.Canceled)
+	_, open := <-ch
+	assert.False(t, open)
+}
+
+// TestOpenStream_RoundTrip tests that a provider's stream survives being
+// adapted to a returned channel and back
+func TestOpenStream_RoundTrip(t *testing.T) {
+	provider := new(MockProvider)
+	req := &LLMRequest{Messages: []Message{{Role: "user", Content: "hi"}}}
+	provider.On("GenerateStream", mock.Anything, req, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(2).(chan<- LLMResponse)
+		ch <- LLMResponse{Content: "hel"}
+		ch <- LLMResponse{Content: "lo"}
+	}).Return(nil)
+
+	ch := make(chan LLMResponse, 10)
+	require.NoError(t, OpenStream(context.Background(), provider, req).ForwardTo(context.Background(), ch))
+	assert.Equal(t, []string{"hel", "lo"}, collect(ch))
+	provider.AssertExpectations(t)
+}
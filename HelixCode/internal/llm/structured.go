@@ -0,0 +1,379 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// ResponseFormatType selects the shape of a generation's output
+type ResponseFormatType string
+
+const (
+	ResponseFormatText ResponseFormatType = "text"
+	ResponseFormatJSON ResponseFormatType = "json"
+)
+
+// DefaultStructuredOutputRetries is how many times a response that does not
+// conform to its ResponseFormat is retried before a parse error is returned
+const DefaultStructuredOutputRetries = 2
+
+// ErrStructuredOutput is wrapped by errors for responses that could not be
+// parsed or repaired into the requested format
+var ErrStructuredOutput = errors.New("structured output does not conform")
+
+// ResponseFormat asks for output of a given shape. In JSON mode the response
+// content is a single JSON value, matching Schema when one is set.
+type ResponseFormat struct {
+	Type ResponseFormatType `json:"type"`
+	// Schema is a JSON Schema the value must match. Types, required
+	// properties, array items and enums are checked.
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	// MaxRetries caps the retries after invalid output; 0 uses
+	// DefaultStructuredOutputRetries and a negative value disables retries
+	MaxRetries int `json:"max_retries,omitempty"`
+}
+
+// StructuredOutputError describes output that did not conform after every attempt
+type StructuredOutputError struct {
+	Attempts int
+	Output   string // The last output received
+	Reason   string
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("structured output invalid after %d attempts: %s", e.Attempts, e.Reason)
+}
+
+// Unwrap lets errors.Is match ErrStructuredOutput
+func (e *StructuredOutputError) Unwrap() error {
+	return ErrStructuredOutput
+}
+
+// JSONModeSupporter is implemented by providers that can constrain a model
+// to emit JSON when LLMRequest.JSONMode is set
+type JSONModeSupporter interface {
+	SupportsJSONMode() bool
+}
+
+// IsJSON reports whether the format asks for JSON output
+func (f *ResponseFormat) IsJSON() bool {
+	return f != nil && f.Type == ResponseFormatJSON
+}
+
+// Validate checks the format type and that the schema is well formed
+func (f *ResponseFormat) Validate() error {
+	switch f.Type {
+	case "", ResponseFormatText, ResponseFormatJSON:
+	default:
+		return fmt.Errorf("%w: unknown response format %q", ErrInvalidRequest, f.Type)
+	}
+	if f.Schema != nil {
+		if err := validateJSONSchema(f.Schema, "schema"); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+	}
+	return nil
+}
+
+// retries returns the number of retries allowed after invalid output
+func (f *ResponseFormat) retries() int {
+	switch {
+	case f.MaxRetries < 0:
+		return 0
+	case f.MaxRetries == 0:
+		return DefaultStructuredOutputRetries
+	}
+	return f.MaxRetries
+}
+
+// instructions is the system prompt that asks for JSON output
+func (f *ResponseFormat) instructions() string {
+	prompt := "Respond with a single valid JSON value and nothing else: no explanation and no code fences."
+	if f.Schema != nil {
+		schema, _ := json.Marshal(f.Schema)
+		prompt += "\nThe JSON must match this JSON Schema:\n" + string(schema)
+	}
+	return prompt
+}
+
+// generateWithFormat runs a request on provider, enforcing format when it
+// asks for JSON. Providers with a native JSON mode have it enabled; every
+// response is then parsed, repaired where possible and checked against the
+// schema. Invalid output is sent back to the model with the reason, up to the
+// format's retry cap, after which a *StructuredOutputError is returned. The
+// returned content is the compact JSON encoding of the value.
+func generateWithFormat(ctx context.Context, provider Provider, req *LLMRequest, format *ResponseFormat) (*LLMResponse, error) {
+	if !format.IsJSON() {
+		return provider.Generate(ctx, req)
+	}
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	structured := *req
+	if supporter, ok := provider.(JSONModeSupporter); ok && supporter.SupportsJSONMode() {
+		structured.JSONMode = true
+	}
+	// The instructions are sent in JSON mode too, as OpenAI requires the
+	// prompt to ask for JSON
+	structured.Messages = append([]Message{{Role: "system", Content: format.instructions()}}, req.Messages...)
+
+	retries := format.retries()
+	var usage Usage
+	for attempt := 0; ; attempt++ {
+		resp, err := provider.Generate(ctx, &structured)
+		if err != nil {
+			return nil, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+
+		content, err := ParseStructuredOutput(resp.Content, format.Schema)
+		if err == nil {
+			result := *resp
+			result.Content = content
+			result.Usage = usage
+			return &result, nil
+		}
+
+		if attempt >= retries {
+			return nil, &StructuredOutputError{Attempts: attempt + 1, Output: resp.Content, Reason: err.Error()}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !spendRetry(ctx) {
+			return nil, &StructuredOutputError{Attempts: attempt + 1, Output: resp.Content, Reason: err.Error()}
+		}
+		log.Printf("⚠️ Invalid JSON from %s, retrying (%d/%d): %v", provider.GetName(), attempt+1, retries, err)
+
+		structured.Messages = append(structured.Messages,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: fmt.Sprintf("That response was not valid: %v. Reply with only the corrected JSON.", err)},
+		)
+	}
+}
+
+// ParseStructuredOutput parses model output as JSON and checks it against
+// schema, which may be nil. Common slips are repaired first: code fences,
+// prose around the value, trailing commas, unclosed brackets at the end of
+// truncated output, and scalars of the wrong type that convert cleanly, such
+// as "3" for an integer. It returns the compact JSON encoding of the value.
+func ParseStructuredOutput(text string, schema map[string]interface{}) (string, error) {
+	candidate := repairJSON(text)
+	if candidate == "" {
+		return "", fmt.Errorf("no JSON value found")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(candidate), &value); err != nil {
+		return "", fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	if schema != nil {
+		coerced, err := coerceValue(schema, value, "$")
+		if err != nil {
+			return "", err
+		}
+		if err := checkSchemaValue(schema, coerced, "$"); err != nil {
+			return "", err
+		}
+		value = coerced
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(encoded), nil
+}
+
+// repairJSON extracts the first JSON object or array from text, dropping
+// trailing commas and closing brackets left open at the end
+func repairJSON(text string) string {
+	if code, ok := ExtractCodeBlock(text, ""); ok {
+		text = code
+	}
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return strings.TrimSpace(text)
+	}
+
+	var out strings.Builder
+	var open []byte
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			open = append(open, '}')
+		case '[':
+			open = append(open, ']')
+		case '}', ']':
+			trimTrailingComma(&out)
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+		out.WriteByte(c)
+		if len(open) == 0 {
+			return out.String()
+		}
+	}
+
+	// Truncated output: close the string and brackets left open
+	if inString {
+		out.WriteByte('"')
+	}
+	for len(open) > 0 {
+		trimTrailingComma(&out)
+		out.WriteByte(open[len(open)-1])
+		open = open[:len(open)-1]
+	}
+	return out.String()
+}
+
+// trimTrailingComma removes a comma, and the space after it, at the end of b
+func trimTrailingComma(b *strings.Builder) {
+	s := strings.TrimRight(b.String(), " \t\r\n")
+	if strings.HasSuffix(s, ",") {
+		b.Reset()
+		b.WriteString(strings.TrimSuffix(s, ","))
+	}
+}
+
+// checkSchemaValue checks the parts of a schema that coercion does not:
+// value types where no conversion applied, required properties and enums
+func checkSchemaValue(schema map[string]interface{}, value interface{}, path string) error {
+	if types := schemaTypes(schema); len(types) > 0 && !matchesSchemaType(types, schema["type"], value) {
+		return fmt.Errorf("%s must be %s, got %s", path, strings.Join(types, " or "), schemaTypeName(value))
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(normalizeJSONNumber(allowed), normalizeJSONNumber(value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s must be one of %v, got %v", path, enum, value)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var missing []string
+		for _, name := range requiredArguments(schema) {
+			if _, ok := v[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s is missing required properties: %s", path, strings.Join(missing, ", "))
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			propertySchema, ok := property.(map[string]interface{})
+			if item, present := v[name]; ok && present {
+				if err := checkSchemaValue(propertySchema, item, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := checkSchemaValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesSchemaType reports whether value has one of the types, allowing
+// null when the declared type list includes it
+func matchesSchemaType(types []string, declared interface{}, value interface{}) bool {
+	if value == nil {
+		if list, ok := declared.([]interface{}); ok {
+			for _, item := range list {
+				if item == "null" {
+					return true
+				}
+			}
+		}
+		return declared == "null"
+	}
+	for _, schemaType := range types {
+		switch schemaType {
+		case "integer":
+			if _, ok := value.(int); ok {
+				return true
+			}
+		case "number":
+			switch value.(type) {
+			case float64, int:
+				return true
+			}
+		default:
+			if schemaTypeName(value) == schemaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaTypeName names the JSON Schema type of a decoded, coerced value
+func schemaTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalizeJSONNumber converts whole numbers to float64 so enum values from
+// a schema compare equal to coerced integers
+func normalizeJSONNumber(value interface{}) interface{} {
+	if i, ok := value.(int); ok {
+		return float64(i)
+	}
+	return value
+}
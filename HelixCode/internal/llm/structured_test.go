@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var personSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"name": map[string]interface{}{"type": "string"},
+		"age":  map[string]interface{}{"type": "integer"},
+		"role": map[string]interface{}{"type": "string", "enum": []interface{}{"admin", "user"}},
+		"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required": []interface{}{"name", "age"},
+}
+
+func TestParseStructuredOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+		err      string
+	}{
+		{name: "valid", output: `{"name": "Ada", "age": 36}`, expected: `{"age":36,"name":"Ada"}`},
+		{name: "fenced with prose", output: "Here you go:\n```json\n{\"name\": \"Ada\", \"age\": 36}\n```\nAnything else?", expected: `{"age":36,"name":"Ada"}`},
+		{name: "surrounding prose", output: `Sure! {"name": "Ada", "age": 36} Hope that helps.`, expected: `{"age":36,"name":"Ada"}`},
+		{name: "trailing commas", output: `{"name": "Ada", "age": 36, "tags": ["a", "b",],}`, expected: `{"age":36,"name":"Ada","tags":["a","b"]}`},
+		{name: "truncated", output: `{"name": "Ada", "age": 36, "tags": ["math", "engi`, expected: `{"age":36,"name":"Ada","tags":["math","engi"]}`},
+		{name: "braces in strings", output: `{"name": "a}b\"{", "age": 1}`, expected: `{"age":1,"name":"a}b\"{"}`},
+		{name: "coerced scalar", output: `{"name": "Ada", "age": "36"}`, expected: `{"age":36,"name":"Ada"}`},
+		{name: "missing required", output: `{"name": "Ada"}`, err: "missing required properties: age"},
+		{name: "wrong type", output: `{"name": "Ada", "age": "old"}`, err: "age"},
+		{name: "enum", output: `{"name": "Ada", "age": 36, "role": "root"}`, err: "$.role must be one of"},
+		{name: "not an object", output: `["Ada", 36]`, err: "object"},
+		{name: "no JSON", output: "I cannot help with that.", err: "invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := ParseStructuredOutput(tt.output, personSchema)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, content)
+		})
+	}
+
+	// Without a schema any JSON value is accepted
+	content, err := ParseStructuredOutput("```\n[1, 2,]\n```", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2]", content)
+}
+
+// jsonModeProvider is a scripted provider with a native JSON mode that
+// records whether requests asked for it
+type jsonModeProvider struct {
+	*scriptedProvider
+	jsonMode []bool
+}
+
+func (p *jsonModeProvider) SupportsJSONMode() bool {
+	return true
+}
+
+func (p *jsonModeProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	p.jsonMode = append(p.jsonMode, request.JSONMode)
+	return p.scriptedProvider.Generate(ctx, request)
+}
+
+func newStructuredTestProvider(responses ...string) *scriptedProvider {
+	provider := newScriptedProvider(false, responses...)
+	provider.On("GetName").Return("scripted").Maybe()
+	return provider
+}
+
+func TestGenerateWithFormat(t *testing.T) {
+	format := &ResponseFormat{Type: ResponseFormatJSON, Schema: personSchema}
+	request := func() *LLMRequest {
+		return &LLMRequest{Messages: []Message{{Role: "user", Content: "Describe Ada Lovelace"}}}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		provider := newStructuredTestProvider(`{"name": "Ada", "age": 36}`)
+		resp, err := generateWithFormat(context.Background(), provider, request(), format)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name": "Ada", "age": 36}`, resp.Content)
+		assert.Len(t, provider.prompts, 1)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		provider := newStructuredTestProvider(`{"name": "Ada"}`, `{"name": "Ada", "age": 36}`)
+		resp, err := generateWithFormat(context.Background(), provider, request(), format)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name": "Ada", "age": 36}`, resp.Content)
+		require.Len(t, provider.prompts, 2)
+		assert.Contains(t, provider.prompts[1], "missing required properties: age")
+	})
+
+	t.Run("unrepairable", func(t *testing.T) {
+		provider := newStructuredTestProvider("no", "still no", "never", "unreached")
+		_, err := generateWithFormat(context.Background(), provider, request(), format)
+		require.ErrorIs(t, err, ErrStructuredOutput)
+		var outputErr *StructuredOutputError
+		require.True(t, errors.As(err, &outputErr))
+		assert.Equal(t, DefaultStructuredOutputRetries+1, outputErr.Attempts)
+		assert.Equal(t, "never", outputErr.Output)
+
+		// The retry cap is configurable
+		provider = newStructuredTestProvider("no", "unreached")
+		_, err = generateWithFormat(context.Background(), provider, request(), &ResponseFormat{Type: ResponseFormatJSON, MaxRetries: -1})
+		assert.ErrorIs(t, err, ErrStructuredOutput)
+		assert.Len(t, provider.prompts, 1)
+	})
+
+	t.Run("native JSON mode", func(t *testing.T) {
+		provider := &jsonModeProvider{scriptedProvider: newStructuredTestProvider(`{"name": "Ada", "age": 36}`)}
+		_, err := generateWithFormat(context.Background(), provider, request(), format)
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true}, provider.jsonMode)
+
+		plain := newStructuredTestProvider(`{"name": "Ada", "age": 36}`)
+		req := request()
+		_, err = generateWithFormat(context.Background(), plain, req, format)
+		require.NoError(t, err)
+		assert.False(t, req.JSONMode)
+		assert.Len(t, req.Messages, 1, "the caller's messages are not modified")
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		bad := &ResponseFormat{Type: ResponseFormatJSON, Schema: map[string]interface{}{"type": "shape"}}
+		_, err := generateWithFormat(context.Background(), newStructuredTestProvider(), request(), bad)
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
+
+func TestModelManager_GenerateBatchStructured(t *testing.T) {
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(newBatchTestProvider()))
+
+	// The batch provider echoes prose, which only passes without a JSON format
+	reqs := batchRequests("plain", "structured")
+	reqs[1].ResponseFormat = &ResponseFormat{Type: ResponseFormatJSON, MaxRetries: -1}
+
+	responses, err := manager.GenerateBatch(context.Background(), reqs)
+	require.NoError(t, err)
+	require.Len(t, responses, 2)
+	require.NoError(t, responses[0].Error)
+	assert.Equal(t, "echo: plain", responses[0].Response.Content)
+	assert.ErrorIs(t, responses[1].Error, ErrStructuredOutput)
+}
+
+func TestProviders_JSONMode(t *testing.T) {
+	openAIServer, openAIBodies := newCaptureServer(t, "/chat/completions",
+		`{"choices":[{"message":{"role":"assistant","content":"{}"},"finish_reason":"stop"}]}`)
+	openAI, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: openAIServer.URL, APIKey: "test-key"})
+	require.NoError(t, err)
+	ollamaServer, ollamaBodies := newCaptureServer(t, "/api/chat",
+		`{"model":"llama3","message":{"role":"assistant","content":"{}"},"done":true}`)
+	ollama, err := NewOllamaProvider(OllamaConfig{BaseURL: ollamaServer.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	format := &ResponseFormat{Type: ResponseFormatJSON}
+	for _, provider := range []Provider{openAI, ollama} {
+		resp, err := generateWithFormat(context.Background(), provider, &LLMRequest{Model: "llama3", Messages: []Message{{Role: "user", Content: "hi"}}}, format)
+		require.NoError(t, err)
+		assert.Equal(t, "{}", resp.Content)
+	}
+
+	var openAIBody struct {
+		ResponseFormat map[string]string `json:"response_format"`
+	}
+	require.NoError(t, json.Unmarshal(<-openAIBodies, &openAIBody))
+	assert.Equal(t, "json_object", openAIBody.ResponseFormat["type"])
+
+	var ollamaBody struct {
+		Format string `json:"format"`
+	}
+	require.NoError(t, json.Unmarshal(<-ollamaBodies, &ollamaBody))
+	assert.Equal(t, "json", ollamaBody.Format)
+}
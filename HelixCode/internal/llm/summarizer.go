@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSummaryPrompt instructs the model how to compress older conversation turns
+const DefaultSummaryPrompt = `Summarize the following conversation so it can replace the original turns.
+Preserve key facts, decisions, file names, code identifiers and open questions.
+Be concise and write the summary in the third person.`
+
+// SummaryMessageName marks the message that holds a conversation summary
+const SummaryMessageName = "conversation_summary"
+
+// Summarizer compresses older conversation turns into a summary message
+type Summarizer struct {
+	provider   Provider
+	Prompt     string
+	Model      string
+	KeepRecent int // Number of most recent turns kept verbatim
+	MaxTokens  int
+}
+
+// NewSummarizer creates a summarizer that uses the given provider
+func NewSummarizer(provider Provider) *Summarizer {
+	return &Summarizer{
+		provider:   provider,
+		Prompt:     DefaultSummaryPrompt,
+		KeepRecent: 4,
+		MaxTokens:  512,
+	}
+}
+
+// Summarize replaces all but the most recent turns with a summary message.
+// Leading system messages are kept as-is. A negative KeepRecent keeps no turns.
+func (s *Summarizer) Summarize(ctx context.Context, messages []Message) ([]Message, error) {
+	keepRecent := max(s.KeepRecent, 0)
+
+	systemCount := 0
+	for systemCount < len(messages) && messages[systemCount].Role == "system" && messages[systemCount].Name != SummaryMessageName {
+		systemCount++
+	}
+
+	turns := messages[systemCount:]
+	if len(turns) <= keepRecent {
+		return messages, nil
+	}
+
+	older := turns[:len(turns)-keepRecent]
+	recent := turns[len(turns)-keepRecent:]
+
+	var transcript strings.Builder
+	for _, message := range older {
+		fmt.Fprintf(&transcript, "%s: %s\n", message.Role, message.Content)
+	}
+
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = DefaultSummaryPrompt
+	}
+
+	response, err := s.provider.Generate(ctx, &LLMRequest{
+		ID:          uuid.New(),
+		Model:       s.Model,
+		Messages:    []Message{{Role: "user", Content: prompt + "\n\nConversation:\n" + transcript.String()}},
+		MaxTokens:   s.MaxTokens,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize conversation: %v", err)
+	}
+
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return nil, fmt.Errorf("failed to summarize conversation: empty summary")
+	}
+
+	result := make([]Message, 0, systemCount+1+len(recent))
+	result = append(result, messages[:systemCount]...)
+	result = append(result, Message{
+		Role:    "system",
+		Name:    SummaryMessageName,
+		Content: "Summary of earlier conversation: " + summary,
+	})
+	result = append(result, recent...)
+	return result, nil
+}
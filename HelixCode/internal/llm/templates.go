@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"dev.helix.code/internal/paths"
+)
+
+// TemplateData is the data available to generation templates
+type TemplateData struct {
+	Code     string
+	Language string            // Derived from File when empty
+	File     string            // Path of the code, if it came from a file
+	Params   map[string]string // Template-specific options, read with index
+}
+
+// builtinTemplates are the prompts for common coding tasks. Optional
+// parameters are read with index so that missing ones render empty.
+var builtinTemplates = map[string]string{
+	"explain": `You are an expert software engineer. Explain what the following {{if .Language}}{{.Language}} {{end}}code does.
+{{- if .File}}
+File: {{.File}}
+{{- end}}
+
+` + "```" + `{{lower .Language}}
+{{.Code}}
+` + "```" + `
+
+Describe its purpose, walk through the main logic step by step, and point out non-obvious behavior, edge cases and potential bugs.
+{{- with index .Params "focus"}}
+Pay particular attention to: {{.}}
+{{- end}}`,
+
+	"test": `You are an expert software engineer. Write unit tests for the following {{if .Language}}{{.Language}} {{end}}code.
+{{- if .File}}
+File: {{.File}}
+{{- end}}
+
+` + "```" + `{{lower .Language}}
+{{.Code}}
+` + "```" + `
+
+Use the standard testing framework of the language{{with index .Params "framework"}} ({{.}}){{end}}. Cover normal cases, edge cases and error paths, and give each test a name that states the behavior it checks. Return only the complete test code.`,
+
+	"refactor": `You are an expert software engineer. Refactor the following {{if .Language}}{{.Language}} {{end}}code to improve its readability and maintainability without changing its behavior.
+{{- if .File}}
+File: {{.File}}
+{{- end}}
+{{- with index .Params "goal"}}
+Goal: {{.}}
+{{- end}}
+
+` + "```" + `{{lower .Language}}
+{{.Code}}
+` + "```" + `
+
+Return the complete refactored code, followed by a short list of the changes and why each one helps.`,
+
+	"document": `You are an expert software engineer. Add documentation to the following {{if .Language}}{{.Language}} {{end}}code, following the language's documentation conventions.
+{{- if .File}}
+File: {{.File}}
+{{- end}}
+
+` + "```" + `{{lower .Language}}
+{{.Code}}
+` + "```" + `
+
+Document every exported or public declaration with what it does, its parameters, return values and errors. Do not change the code itself. Return the complete documented code.`,
+}
+
+// languagesByExtension names the language of common source file extensions
+var languagesByExtension = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".ts":    "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cpp":   "C++",
+	".cc":    "C++",
+	".cs":    "C#",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".sh":    "Shell",
+	".sql":   "SQL",
+}
+
+// TemplateLibrary holds the named prompt templates for coding tasks
+type TemplateLibrary struct {
+	templates map[string]*template.Template
+	mu        sync.RWMutex
+}
+
+// NewTemplateLibrary creates a library containing the built-in templates
+func NewTemplateLibrary() *TemplateLibrary {
+	library := &TemplateLibrary{
+		templates: make(map[string]*template.Template),
+	}
+
+	for name, text := range builtinTemplates {
+		if err := library.Register(name, text); err != nil {
+			panic(fmt.Sprintf("invalid built-in template %s: %v", name, err))
+		}
+	}
+
+	return library
+}
+
+// Register parses, validates and stores a template, replacing any existing one with the same name
+func (l *TemplateLibrary) Register(name, text string) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").
+		Funcs(template.FuncMap{"lower": strings.ToLower}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %v", name, err)
+	}
+
+	// Render against sample data so field errors surface at load time
+	sample := TemplateData{Code: "func main() {}", Language: "Go", File: "main.go", Params: map[string]string{}}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.templates[name] = tmpl
+	return nil
+}
+
+// LoadDir loads user templates from *.tmpl files in a directory. The file
+// name without extension is the template name; a built-in template of the
+// same name is replaced. A missing directory is not an error.
+func (l *TemplateLibrary) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(paths.Expand(dir), "*.tmpl"))
+	if err != nil {
+		return fmt.Errorf("failed to list templates: %v", err)
+	}
+
+	var errs []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if err := l.Register(name, string(data)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load templates: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Has reports whether a template with the given name is registered
+func (l *TemplateLibrary) Has(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, exists := l.templates[name]
+	return exists
+}
+
+// Names returns the registered template names, sorted
+func (l *TemplateLibrary) Names() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := make([]string, 0, len(l.templates))
+	for name := range l.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the named template. The language is derived from the file
+// extension when not given.
+func (l *TemplateLibrary) Render(name string, data TemplateData) (string, error) {
+	l.mu.RLock()
+	tmpl := l.templates[name]
+	l.mu.RUnlock()
+
+	if tmpl == nil {
+		return "", fmt.Errorf("unknown template %s (available: %s)", name, strings.Join(l.Names(), ", "))
+	}
+	if strings.TrimSpace(data.Code) == "" {
+		return "", fmt.Errorf("template %s needs code to work on", name)
+	}
+	if data.Language == "" {
+		data.Language = LanguageFromPath(data.File)
+	}
+	if data.Params == nil {
+		data.Params = map[string]string{}
+	}
+	data.Code = strings.TrimRight(data.Code, "\r\n")
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %v", name, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// LanguageFromPath returns the language of a source file from its extension,
+// or an empty string when it is not recognized
+func LanguageFromPath(path string) string {
+	return languagesByExtension[strings.ToLower(filepath.Ext(path))]
+}
+
+// DefaultTemplateDir returns the directory searched for user templates
+func DefaultTemplateDir() string {
+	if dir := os.Getenv("HELIX_TEMPLATES_DIR"); dir != "" {
+		return paths.Expand(dir)
+	}
+	return paths.Expand("~/.config/helixcode/templates")
+}
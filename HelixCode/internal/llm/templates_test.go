@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templateCode = "func Add(a, b int) int {\n\treturn a + b\n}"
+
+func TestTemplateLibrary_RenderBuiltins(t *testing.T) {
+	library := NewTemplateLibrary()
+	assert.Equal(t, []string{"document", "explain", "refactor", "test"}, library.Names())
+
+	cases := map[string]struct {
+		params   map[string]string
+		expected []string
+	}{
+		"explain": {
+			params:   map[string]string{"focus": "overflow"},
+			expected: []string{"Explain what the following Go code does", "walk through the main logic", "Pay particular attention to: overflow"},
+		},
+		"test": {
+			params:   map[string]string{"framework": "testify"},
+			expected: []string{"Write unit tests for the following Go code", "(testify)", "edge cases and error paths"},
+		},
+		"refactor": {
+			params:   map[string]string{"goal": "remove duplication"},
+			expected: []string{"Refactor the following Go code", "without changing its behavior", "Goal: remove duplication"},
+		},
+		"document": {
+			expected: []string{"Add documentation to the following Go code", "Do not change the code itself"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			prompt, err := library.Render(name, TemplateData{Code: templateCode, File: "math/add.go", Params: tc.params})
+			require.NoError(t, err)
+
+			assert.Contains(t, prompt, "File: math/add.go")
+			assert.Contains(t, prompt, "```go\n"+templateCode+"\n```")
+			for _, expected := range tc.expected {
+				assert.Contains(t, prompt, expected)
+			}
+		})
+	}
+}
+
+func TestTemplateLibrary_OptionalFields(t *testing.T) {
+	library := NewTemplateLibrary()
+
+	prompt, err := library.Render("explain", TemplateData{Code: templateCode})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Explain what the following code does")
+	assert.NotContains(t, prompt, "File:")
+	assert.NotContains(t, prompt, "Pay particular attention")
+
+	prompt, err = library.Render("test", TemplateData{Code: "def add(a, b): return a + b", Language: "Python"})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "```python\n")
+	assert.Contains(t, prompt, "standard testing framework of the language.")
+}
+
+func TestTemplateLibrary_Errors(t *testing.T) {
+	library := NewTemplateLibrary()
+
+	_, err := library.Render("translate", TemplateData{Code: templateCode})
+	assert.ErrorContains(t, err, "unknown template translate")
+
+	_, err = library.Render("explain", TemplateData{Code: "  \n"})
+	assert.Error(t, err)
+
+	assert.Error(t, library.Register("broken", "{{.Missing}}"))
+}
+
+func TestTemplateLibrary_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.tmpl"),
+		[]byte(`Review this {{.Language}} code for {{index .Params "aspect"}}:
+{{.Code}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+
+	library := NewTemplateLibrary()
+	require.NoError(t, library.LoadDir(dir))
+	assert.True(t, library.Has("review"))
+	assert.False(t, library.Has("notes"))
+
+	prompt, err := library.Render("review", TemplateData{Code: templateCode, File: "add.go", Params: map[string]string{"aspect": "security"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Review this Go code for security:\n"+templateCode, prompt)
+
+	require.NoError(t, library.LoadDir(filepath.Join(dir, "missing")))
+}
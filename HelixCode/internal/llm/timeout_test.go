@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutSeconds(t *testing.T) {
+	tests := []struct {
+		seconds  int
+		expected time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Second},
+		{30, 30 * time.Second},
+		{300, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		timeout, err := timeoutSeconds("timeout", tt.seconds, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, timeout, "timeout %d", tt.seconds)
+	}
+
+	_, err := timeoutSeconds("timeout", -1, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestProviders_TimeoutInSeconds(t *testing.T) {
+	llama, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "model.gguf", ServerTimeout: 30})
+	require.NoError(t, err)
+	assert.Contains(t, llama.config.serverArgs(), "--timeout")
+	assert.Equal(t, "30", llama.config.serverArgs()[len(llama.config.serverArgs())-1])
+
+	_, err = NewLlamaCPPProvider(LlamaConfig{ModelPath: "model.gguf", ServerTimeout: -30})
+	assert.Error(t, err)
+
+	server, _ := newCaptureServer(t, "/api/generate", "ok")
+	ollama, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, ollama.timeout)
+	assert.Zero(t, ollama.apiClient.Timeout)
+
+	ollama, err = NewOllamaProvider(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOllamaTimeout, ollama.timeout)
+
+	_, err = NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CoerceToolArguments converts tool call arguments to the types declared in
+// the tool's JSON Schema. Models often send numbers and booleans as strings;
+// these are converted so handlers receive int, float64, bool, string, slice or
+// map values. Arguments without a declared type are passed through unchanged.
+func CoerceToolArguments(schema map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		return nil, nil
+	}
+
+	return coerceObject(schema, args, "")
+}
+
+// coerceObject coerces the properties of an object against an object schema
+func coerceObject(schema map[string]interface{}, object map[string]interface{}, path string) (map[string]interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	result := make(map[string]interface{}, len(object))
+	for name, value := range object {
+		propertySchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			result[name] = value
+			continue
+		}
+
+		coerced, err := coerceValue(propertySchema, value, joinArgumentPath(path, name))
+		if err != nil {
+			return nil, err
+		}
+		result[name] = coerced
+	}
+	return result, nil
+}
+
+// coerceValue converts a single value to the type its schema declares
+func coerceValue(schema map[string]interface{}, value interface{}, path string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var lastErr error
+	for _, schemaType := range schemaTypes(schema) {
+		coerced, err := coerceToType(schema, schemaType, value, path)
+		if err == nil {
+			return coerced, nil
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return value, nil
+}
+
+// schemaTypes returns the declared types, which may be a single type or a list
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+		return types
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func coerceToType(schema map[string]interface{}, schemaType string, value interface{}, path string) (interface{}, error) {
+	switch schemaType {
+	case "integer":
+		return coerceInteger(value, path)
+	case "number":
+		return coerceNumber(value, path)
+	case "boolean":
+		return coerceBoolean(value, path)
+	case "string":
+		return coerceString(value, path)
+	case "array":
+		return coerceArray(schema, value, path)
+	case "object":
+		return coerceObjectValue(schema, value, path)
+	}
+	return value, nil
+}
+
+func coerceInteger(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), nil
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.Atoi(s); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) {
+			return int(f), nil
+		}
+	}
+	return nil, coercionError(path, value, "integer")
+}
+
+func coerceNumber(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, coercionError(path, value, "number")
+}
+
+func coerceBoolean(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "1":
+			return true, nil
+		case "false", "no", "0":
+			return false, nil
+		}
+	case float64:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case int:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	}
+	return nil, coercionError(path, value, "boolean")
+}
+
+func coerceString(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return nil, coercionError(path, value, "string")
+}
+
+func coerceArray(schema map[string]interface{}, value interface{}, path string) (interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		s, isString := value.(string)
+		if !isString || json.Unmarshal([]byte(s), &items) != nil {
+			return nil, coercionError(path, value, "array")
+		}
+	}
+
+	itemSchema, _ := schema["items"].(map[string]interface{})
+	if itemSchema == nil {
+		return items, nil
+	}
+
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		coerced, err := coerceValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		result[i] = coerced
+	}
+	return result, nil
+}
+
+func coerceObjectValue(schema map[string]interface{}, value interface{}, path string) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		s, isString := value.(string)
+		if !isString || json.Unmarshal([]byte(s), &object) != nil || object == nil {
+			return nil, coercionError(path, value, "object")
+		}
+	}
+	return coerceObject(schema, object, path)
+}
+
+func coercionError(path string, value interface{}, schemaType string) error {
+	return fmt.Errorf("invalid argument %s: cannot convert %v (%T) to %s", path, value, value, schemaType)
+}
+
+func joinArgumentPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
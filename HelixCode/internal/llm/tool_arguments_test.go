@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToolSchema declares one property of each supported type
+var testToolSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"count":   map[string]interface{}{"type": "integer"},
+		"ratio":   map[string]interface{}{"type": "number"},
+		"enabled": map[string]interface{}{"type": "boolean"},
+		"label":   map[string]interface{}{"type": "string"},
+		"limit":   map[string]interface{}{"type": []interface{}{"integer", "null"}},
+		"ids": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "integer"},
+		},
+		"options": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"verbose": map[string]interface{}{"type": "boolean"},
+			},
+		},
+	},
+}
+
+// TestCoerceToolArguments tests conversion of each primitive type
+func TestCoerceToolArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		argument string
+		value    interface{}
+		expected interface{}
+	}{
+		{"integer from string", "count", "42", 42},
+		{"integer from float", "count", float64(7), 7},
+		{"integer from whole float string", "count", "3.0", 3},
+		{"number from string", "ratio", "0.25", 0.25},
+		{"number from integer", "ratio", 2, float64(2)},
+		{"boolean from string", "enabled", "true", true},
+		{"boolean from false string", "enabled", "False", false},
+		{"boolean from number", "enabled", float64(1), true},
+		{"string from number", "label", float64(12.5), "12.5"},
+		{"string from boolean", "label", true, "true"},
+		{"nullable integer", "limit", "10", 10},
+		{"null value", "limit", nil, nil},
+		{"array items", "ids", []interface{}{"1", float64(2)}, []interface{}{1, 2}},
+		{"array from JSON string", "ids", "[3, 4]", []interface{}{3, 4}},
+		{"nested object", "options", map[string]interface{}{"verbose": "yes"}, map[string]interface{}{"verbose": true}},
+		{"undeclared argument", "extra", "unchanged", "unchanged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := CoerceToolArguments(testToolSchema, map[string]interface{}{tt.argument: tt.value})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args[tt.argument])
+		})
+	}
+}
+
+// TestCoerceToolArgumentsErrors tests that impossible conversions are reported clearly
+func TestCoerceToolArgumentsErrors(t *testing.T) {
+	tests := []struct {
+		argument string
+		value    interface{}
+		message  string
+	}{
+		{"count", "many", "invalid argument count: cannot convert many (string) to integer"},
+		{"count", 1.5, "to integer"},
+		{"ratio", "fast", "to number"},
+		{"enabled", "maybe", "to boolean"},
+		{"label", []interface{}{"a"}, "to string"},
+		{"ids", []interface{}{"x"}, "invalid argument ids[0]"},
+		{"options", map[string]interface{}{"verbose": "sometimes"}, "invalid argument options.verbose"},
+	}
+
+	for _, tt := range tests {
+		_, err := CoerceToolArguments(testToolSchema, map[string]interface{}{tt.argument: tt.value})
+		require.Error(t, err, tt.argument)
+		assert.Contains(t, err.Error(), tt.message)
+	}
+}
+
+// TestReasoningEngine_ExecuteToolCoercesArguments tests that handlers receive declared types
+func TestReasoningEngine_ExecuteToolCoercesArguments(t *testing.T) {
+	engine := NewReasoningEngine(new(MockProvider))
+
+	var received map[string]interface{}
+	require.NoError(t, engine.RegisterTool(ReasoningTool{
+		Name:       "resize",
+		Parameters: testToolSchema,
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			received = args
+			return "ok", nil
+		},
+	}))
+
+	_, err := engine.executeTool(context.Background(), &ReasoningToolCall{
+		ToolName:  "resize",
+		Arguments: map[string]interface{}{"count": "3", "enabled": "false"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, received["count"])
+	assert.Equal(t, false, received["enabled"])
+
+	_, err = engine.executeTool(context.Background(), &ReasoningToolCall{
+		ToolName:  "resize",
+		Arguments: map[string]interface{}{"count": "three"},
+	})
+	assert.ErrorContains(t, err, "cannot convert three")
+}
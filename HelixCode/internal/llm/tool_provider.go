@@ -3,8 +3,10 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,13 +17,33 @@ import (
 
 // ToolGenerationRequest represents a request for generation with tools
 type ToolGenerationRequest struct {
-	ID          uuid.UUID              `json:"id"`
-	Prompt      string                 `json:"prompt"`
-	Tools       []Tool                 `json:"tools"`
-	MaxTokens   int                    `json:"max_tokens"`
-	Temperature float64                `json:"temperature"`
-	Stream      bool                   `json:"stream"`
-	Context     map[string]interface{} `json:"context"`
+	ID            uuid.UUID              `json:"id"`
+	Prompt        string                 `json:"prompt"`
+	Tools         []Tool                 `json:"tools"`
+	MaxTokens     int                    `json:"max_tokens"`
+	Temperature   float64                `json:"temperature"`
+	Stream        bool                   `json:"stream"`
+	Context       map[string]interface{} `json:"context"`
+	MaxIterations int                    `json:"max_iterations"` // Maximum model calls in the tool loop; 0 uses DefaultMaxToolIterations
+}
+
+// DefaultMaxToolIterations bounds the tool loop when a request does not set MaxIterations
+const DefaultMaxToolIterations = 10
+
+// ToolIteration records one round of the tool loop
+type ToolIteration struct {
+	Iteration int                    `json:"iteration"`
+	ToolCalls []string               `json:"tool_calls"`
+	Results   map[string]interface{} `json:"results,omitempty"`
+	Invalid   []InvalidToolCall      `json:"invalid,omitempty"`
+	Duration  time.Duration          `json:"duration"`
+}
+
+// InvalidToolCall is a tool call rejected before execution, with the reason
+// reported back to the model
+type InvalidToolCall struct {
+	Call   ToolCall `json:"call"`
+	Reason string   `json:"reason"`
 }
 
 // ToolGenerationResponse represents the response from tool-based generation
@@ -29,8 +51,11 @@ type ToolGenerationResponse struct {
 	ID        uuid.UUID              `json:"id"`
 	Text      string                 `json:"text"`
 	ToolCalls []ToolCall             `json:"tool_calls"`
-	Reasoning string                 `json:"reasoning"`
-	Metadata  map[string]interface{} `json:"metadata"`
+	// ToolResults holds what each executed tool returned, by tool name. A
+	// failed tool's entry is its error message.
+	ToolResults map[string]interface{} `json:"tool_results,omitempty"`
+	Reasoning   string                 `json:"reasoning"`
+	Metadata    map[string]interface{} `json:"metadata"`
 }
 
 // ToolStreamChunk represents a streaming chunk for tool-based generation
@@ -38,7 +63,9 @@ type ToolStreamChunk struct {
 	ID        uuid.UUID              `json:"id"`
 	Content   string                 `json:"content"`
 	ToolCalls []ToolCall             `json:"tool_calls"`
-	Reasoning string                 `json:"reasoning"`
+	// ToolResults is set on the final chunk when tools were executed
+	ToolResults map[string]interface{} `json:"tool_results,omitempty"`
+	Reasoning   string                 `json:"reasoning"`
 	Done      bool                   `json:"done"`
 	Error     string                 `json:"error,omitempty"`
 }
@@ -56,6 +83,7 @@ type EnhancedLLMProvider interface {
 type ToolCallingProvider struct {
 	baseProvider    Provider
 	tools           map[string]Tool
+	handlers        map[string]ReasoningToolHandler
 	reasoningEngine *ReasoningEngine
 }
 
@@ -64,18 +92,35 @@ func NewToolCallingProvider(baseProvider Provider) *ToolCallingProvider {
 	return &ToolCallingProvider{
 		baseProvider:   baseProvider,
 		tools:          make(map[string]Tool),
+		handlers:       make(map[string]ReasoningToolHandler),
 		reasoningEngine: NewReasoningEngine(baseProvider),
 	}
 }
 
-// GenerateWithTools performs generation with tool calling support
+// WithToolCalling returns provider with tool calling support. Providers that
+// already support tools are returned as they are, so wrapping is idempotent and
+// tools registered on them are kept.
+func WithToolCalling(provider Provider) EnhancedLLMProvider {
+	if enhanced, ok := provider.(EnhancedLLMProvider); ok {
+		return enhanced
+	}
+	return NewToolCallingProvider(provider)
+}
+
+// GenerateWithTools performs generation with tool calling support. The model
+// is called repeatedly, with the results of its tool calls fed back, until it
+// answers without requesting a tool or MaxIterations is reached.
 func (p *ToolCallingProvider) GenerateWithTools(ctx context.Context, req ToolGenerationRequest) (*ToolGenerationResponse, error) {
 	startTime := time.Now()
 
+	maxIterations := req.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxToolIterations
+	}
+
 	// Build tool-enhanced prompt
 	enhancedPrompt := p.buildToolEnhancedPrompt(req.Prompt, req.Tools)
 
-	// Generate initial response
 	genReq := &LLMRequest{
 		Model:       "default",
 		Messages:    []Message{{Role: "user", Content: enhancedPrompt}},
@@ -84,51 +129,106 @@ func (p *ToolCallingProvider) GenerateWithTools(ctx context.Context, req ToolGen
 		Stream:      false,
 	}
 
-	resp, err := p.baseProvider.Generate(ctx, genReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate with tools: %v", err)
-	}
+	var (
+		allToolCalls  []ToolCall
+		toolResults   = make(map[string]interface{})
+		reasoningList []string
+		iterations    []ToolIteration
+		text          string
+		limitReached  bool
+	)
 
-	// Parse tool calls from response
-	toolCalls, reasoning := p.extractToolCallsAndReasoning(resp.Content)
+	for i := 1; ; i++ {
+		iterationStart := time.Now()
 
-	// Execute tool calls if any
-	if len(toolCalls) > 0 {
-		results, err := p.executeToolCalls(ctx, toolCalls)
+		resp, err := generateNonEmpty(ctx, p.baseProvider, genReq, DefaultEmptyResponseRetries)
 		if err != nil {
-			log.Printf("Warning: Some tool calls failed: %v", err)
+			return nil, fmt.Errorf("failed to generate with tools (iteration %d): %w", i, err)
+		}
+		text = resp.Content
+
+		// Parse tool calls from response
+		toolCalls, reasoning := p.extractToolCallsAndReasoning(resp.Content)
+		if reasoning != "" {
+			reasoningList = append(reasoningList, reasoning)
+		}
+
+		iteration := ToolIteration{Iteration: i}
+		for _, toolCall := range toolCalls {
+			iteration.ToolCalls = append(iteration.ToolCalls, toolCall.Function.Name)
+		}
+
+		// The model is done once it stops requesting tools
+		if len(toolCalls) == 0 {
+			iteration.Duration = time.Since(iterationStart)
+			iterations = append(iterations, iteration)
+			break
+		}
+
+		if i >= maxIterations {
+			iteration.Duration = time.Since(iterationStart)
+			iterations = append(iterations, iteration)
+			limitReached = true
+			log.Printf("⚠️ Tool loop stopped after %d iterations with tool calls pending", i)
+			break
 		}
 
-		// Generate final response with tool results
-		finalPrompt := p.buildFinalPrompt(req.Prompt, resp.Content, results)
-		genReq.Messages = []Message{{Role: "user", Content: finalPrompt}}
-		
-		finalResp, err := p.baseProvider.Generate(ctx, genReq)
+		// Invalid calls are not executed; the model is told why so it can retry
+		valid, invalid := p.validateToolCalls(toolCalls)
+		results, err := p.executeToolCalls(ctx, valid)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate final response: %v", err)
+			log.Printf("Warning: Some tool calls failed: %v", err)
+		}
+		allToolCalls = append(allToolCalls, valid...)
+		for name, result := range results {
+			toolResults[name] = result
 		}
-		resp = finalResp
+		iteration.Results = results
+		iteration.Invalid = invalid
+		iteration.Duration = time.Since(iterationStart)
+		iterations = append(iterations, iteration)
+
+		// Feed the tool results back for the next round
+		genReq.Messages = append(genReq.Messages,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: p.buildToolFeedbackPrompt(invalid) + p.buildToolResultsPrompt(results)},
+		)
 	}
 
 	return &ToolGenerationResponse{
-		ID:        uuid.New(),
-		Text:      resp.Content,
-		ToolCalls: toolCalls,
-		Reasoning: reasoning,
+		ID:          uuid.New(),
+		Text:        text,
+		ToolCalls:   allToolCalls,
+		ToolResults: toolResults,
+		Reasoning:   strings.Join(reasoningList, "\n"),
 		Metadata: map[string]interface{}{
-			"duration_ms": time.Since(startTime).Milliseconds(),
-			"tools_used":   len(toolCalls),
+			"duration_ms":            time.Since(startTime).Milliseconds(),
+			"tools_used":             len(allToolCalls),
+			"iterations":             iterations,
+			"iteration_count":        len(iterations),
+			"max_iterations_reached": limitReached,
 		},
 	}, nil
 }
 
-// StreamWithTools performs streaming generation with tool calling support
+// StreamWithTools performs streaming generation with tool calling support.
+// Base providers that cannot stream are called with Generate and their
+// response is delivered as a single chunk.
 func (p *ToolCallingProvider) StreamWithTools(ctx context.Context, req ToolGenerationRequest) (<-chan ToolStreamChunk, error) {
 	ch := make(chan ToolStreamChunk, 100)
 
 	go func() {
 		defer close(ch)
 
+		emit := func(content string) {
+			ch <- ToolStreamChunk{
+				ID:        uuid.New(),
+				Content:   content,
+				ToolCalls: []ToolCall{},
+				Done:      false,
+			}
+		}
+
 		// Build tool-enhanced prompt
 		enhancedPrompt := p.buildToolEnhancedPrompt(req.Prompt, req.Tools)
 
@@ -141,8 +241,7 @@ func (p *ToolCallingProvider) StreamWithTools(ctx context.Context, req ToolGener
 			Stream:      true,
 		}
 
-		streamCh := make(chan LLMResponse, 100)
-		err := p.baseProvider.GenerateStream(ctx, streamReq, streamCh)
+		fullResponse, err := p.streamText(ctx, streamReq, emit)
 		if err != nil {
 			ch <- ToolStreamChunk{
 				ID:    uuid.New(),
@@ -152,38 +251,20 @@ func (p *ToolCallingProvider) StreamWithTools(ctx context.Context, req ToolGener
 			return
 		}
 
-		var fullResponse string
-		var toolCalls []ToolCall
-		var reasoning string
-
-		for resp := range streamCh {
-			// Check for errors (in a real implementation, you'd have error handling)
-			// For now, we'll assume no errors in streaming
-			
-			fullResponse += resp.Content
-
-			// Send streaming chunk
-			ch <- ToolStreamChunk{
-				ID:        uuid.New(),
-				Content:   resp.Content,
-				ToolCalls: []ToolCall{},
-				Reasoning: "",
-				Done:      false,
-			}
-		}
-
 		// Parse tool calls after streaming completes
-		toolCalls, reasoning = p.extractToolCallsAndReasoning(fullResponse)
+		toolCalls, reasoning := p.extractToolCallsAndReasoning(fullResponse)
 
 		// Execute tool calls if any
+		var results map[string]interface{}
 		if len(toolCalls) > 0 {
-			results, err := p.executeToolCalls(ctx, toolCalls)
+			valid, invalid := p.validateToolCalls(toolCalls)
+			results, err = p.executeToolCalls(ctx, valid)
 			if err != nil {
 				log.Printf("Warning: Some tool calls failed: %v", err)
 			}
 
 			// Generate final response with tool results
-			finalPrompt := p.buildFinalPrompt(req.Prompt, fullResponse, results)
+			finalPrompt := p.buildToolFeedbackPrompt(invalid) + p.buildFinalPrompt(req.Prompt, fullResponse, results)
 			
 			// Stream final response
 			finalStreamReq := &LLMRequest{
@@ -194,9 +275,7 @@ func (p *ToolCallingProvider) StreamWithTools(ctx context.Context, req ToolGener
 				Stream:      true,
 			}
 
-			finalStreamCh := make(chan LLMResponse, 100)
-			err = p.baseProvider.GenerateStream(ctx, finalStreamReq, finalStreamCh)
-			if err != nil {
+			if _, err := p.streamText(ctx, finalStreamReq, emit); err != nil {
 				ch <- ToolStreamChunk{
 					ID:    uuid.New(),
 					Error: fmt.Sprintf("Failed to stream final response: %v", err),
@@ -204,32 +283,152 @@ func (p *ToolCallingProvider) StreamWithTools(ctx context.Context, req ToolGener
 				}
 				return
 			}
+		}
 
-			for resp := range finalStreamCh {
-				// Check for errors (in a real implementation, you'd have error handling)
-				// For now, we'll assume no errors in streaming
-				
-				ch <- ToolStreamChunk{
-					ID:        uuid.New(),
-					Content:   resp.Content,
-					ToolCalls: toolCalls,
-					Reasoning: reasoning,
-					Done:      true, // Assume done when we get the final response
+		// Send final chunk with the tool calls that were made
+		ch <- ToolStreamChunk{
+			ID:          uuid.New(),
+			Content:     "",
+			ToolCalls:   toolCalls,
+			ToolResults: results,
+			Reasoning:   reasoning,
+			Done:        true,
+		}
+	}()
+
+	return ch, nil
+}
+
+// CollectToolStream drains a StreamWithTools channel into the equivalent
+// non-streaming response: content concatenated, tool calls merged in order
+// and reasoning joined. An error chunk, or a channel closed before the final
+// chunk, returns the error along with what was collected so far.
+func CollectToolStream(ch <-chan ToolStreamChunk) (*ToolGenerationResponse, error) {
+	var (
+		text      strings.Builder
+		toolCalls []ToolCall
+		results   map[string]interface{}
+		reasoning []string
+		seen      = make(map[string]bool)
+		chunks    int
+		done      bool
+		streamErr error
+	)
+
+	for chunk := range ch {
+		chunks++
+		text.WriteString(chunk.Content)
+		for _, toolCall := range chunk.ToolCalls {
+			// Calls repeated in later chunks are identified by ID
+			if toolCall.ID != "" {
+				if seen[toolCall.ID] {
+					continue
 				}
+				seen[toolCall.ID] = true
 			}
-		} else {
-			// No tool calls, send final chunk
-			ch <- ToolStreamChunk{
-				ID:        uuid.New(),
-				Content:   "",
-				ToolCalls: toolCalls,
-				Reasoning: reasoning,
-				Done:      true,
+			toolCalls = append(toolCalls, toolCall)
+		}
+		for name, result := range chunk.ToolResults {
+			if results == nil {
+				results = make(map[string]interface{})
 			}
+			results[name] = result
 		}
-	}()
+		if chunk.Reasoning != "" {
+			reasoning = append(reasoning, chunk.Reasoning)
+		}
+		if chunk.Error != "" && streamErr == nil {
+			streamErr = fmt.Errorf("tool stream failed: %s", chunk.Error)
+		}
+		if chunk.Done {
+			done = true
+		}
+	}
+	if streamErr == nil && !done {
+		streamErr = fmt.Errorf("tool stream ended before its final chunk")
+	}
 
-	return ch, nil
+	return &ToolGenerationResponse{
+		ID:          uuid.New(),
+		Text:        text.String(),
+		ToolCalls:   toolCalls,
+		ToolResults: results,
+		Reasoning:   strings.Join(reasoning, "\n"),
+		Metadata: map[string]interface{}{
+			"streamed": true,
+			"chunks":   chunks,
+		},
+	}, streamErr
+}
+
+// SupportsStreaming reports whether the base provider supports GenerateStream.
+// StreamWithTools works either way, falling back to Generate.
+func (p *ToolCallingProvider) SupportsStreaming() bool {
+	return GetProviderCapabilities(p.baseProvider).Streaming
+}
+
+// SupportsNativeTools reports whether the base provider supports tools
+// natively. Otherwise tool calls are emulated through prompting.
+func (p *ToolCallingProvider) SupportsNativeTools() bool {
+	return GetProviderCapabilities(p.baseProvider).NativeTools
+}
+
+// supportsStreaming reports whether to attempt streaming from the base
+// provider. Providers that do not report it are tried, since a stream that
+// fails with ErrStreamingNotSupported falls back to Generate.
+func (p *ToolCallingProvider) supportsStreaming() bool {
+	if supporter, ok := p.baseProvider.(StreamingSupporter); ok {
+		return supporter.SupportsStreaming()
+	}
+	return true
+}
+
+// streamText streams a response from the base provider, passing each piece of
+// content to emit, and returns the full text. It falls back to a single
+// Generate call when the base provider does not support streaming.
+func (p *ToolCallingProvider) streamText(ctx context.Context, req *LLMRequest, emit func(string)) (string, error) {
+	if !p.supportsStreaming() {
+		return p.generateAsChunk(ctx, req, emit)
+	}
+
+	var full strings.Builder
+	received := false
+	stream := OpenStream(ctx, p.baseProvider, req)
+	for resp := range stream.C {
+		received = true
+		full.WriteString(resp.Content)
+		emit(resp.Content)
+	}
+	return p.finishStream(ctx, req, emit, full.String(), received, stream.Err())
+}
+
+// finishStream handles the result of GenerateStream, falling back to Generate
+// when the provider reported that it cannot stream before sending anything
+func (p *ToolCallingProvider) finishStream(ctx context.Context, req *LLMRequest, emit func(string), text string, received bool, err error) (string, error) {
+	if errors.Is(err, ErrStreamingNotSupported) && !received {
+		return p.generateAsChunk(ctx, req, emit)
+	}
+	return text, err
+}
+
+// generateAsChunk generates a complete response and emits it as one chunk
+func (p *ToolCallingProvider) generateAsChunk(ctx context.Context, req *LLMRequest, emit func(string)) (string, error) {
+	genReq := *req
+	genReq.Stream = false
+
+	resp, err := p.baseProvider.Generate(ctx, &genReq)
+	if err != nil {
+		return "", err
+	}
+	emit(resp.Content)
+	return resp.Content, nil
+}
+
+// GenerateWithReasoning runs the provider's reasoning engine, which shares the
+// provider's registered tools. Use ReasoningTypeReAct to interleave tool calls
+// with reasoning steps.
+func (p *ToolCallingProvider) GenerateWithReasoning(ctx context.Context, req ReasoningRequest) (*ReasoningResponse, error) {
+	return p.reasoningEngine.GenerateWithReasoning(ctx, req)
 }
 
 // ListAvailableTools returns all registered tools
@@ -243,6 +442,9 @@ func (p *ToolCallingProvider) ListAvailableTools() []Tool {
 
 // RegisterTool registers a new tool with the provider
 func (p *ToolCallingProvider) RegisterTool(tool Tool) error {
+	if err := ToolSchemaFromTool(tool).Validate(); err != nil {
+		return fmt.Errorf("invalid tool: %v", err)
+	}
 	if _, exists := p.tools[tool.Function.Name]; exists {
 		return fmt.Errorf("tool %s already registered", tool.Function.Name)
 	}
@@ -256,8 +458,7 @@ func (p *ToolCallingProvider) RegisterTool(tool Tool) error {
 			Description: tool.Function.Description,
 			Parameters:  tool.Function.Parameters,
 			Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-				// This would need to be implemented based on the actual tool functionality
-				return "tool_executed", nil
+				return p.executeToolHandler(ctx, tool.Function.Name, args)
 			},
 		}
 		p.reasoningEngine.RegisterTool(reasoningTool)
@@ -267,6 +468,16 @@ func (p *ToolCallingProvider) RegisterTool(tool Tool) error {
 	return nil
 }
 
+// SetToolHandler sets the function that executes a registered tool. Tools
+// without a handler return a placeholder result.
+func (p *ToolCallingProvider) SetToolHandler(name string, handler ReasoningToolHandler) error {
+	if _, exists := p.tools[name]; !exists {
+		return fmt.Errorf("tool %s is not registered", name)
+	}
+	p.handlers[name] = handler
+	return nil
+}
+
 // Implement base Provider interface
 
 func (p *ToolCallingProvider) GetType() ProviderType {
@@ -312,9 +523,7 @@ func (p *ToolCallingProvider) Close() error {
 func (p *ToolCallingProvider) buildToolEnhancedPrompt(prompt string, tools []Tool) string {
 	toolDescriptions := ""
 	for _, tool := range tools {
-		paramsJSON, _ := json.Marshal(tool.Function.Parameters)
-		toolDescriptions += fmt.Sprintf("- %s: %s (parameters: %s)\n", 
-			tool.Function.Name, tool.Function.Description, string(paramsJSON))
+		toolDescriptions += ToolSchemaFromTool(tool).PromptLine() + "\n"
 	}
 
 	return fmt.Sprintf(`You have access to the following tools:
@@ -330,45 +539,122 @@ User request: %s
 Your response:`, toolDescriptions, prompt)
 }
 
+// toolCallMarker introduces a tool call's JSON object in prompt-based tool calling
+const toolCallMarker = "TOOL_CALL:"
+
+// extractToolCallsAndReasoning returns the tool calls in text, each a JSON
+// object after a TOOL_CALL: marker that may span several lines, and the
+// rest of the text as reasoning. Malformed tool calls are logged and dropped.
 func (p *ToolCallingProvider) extractToolCallsAndReasoning(text string) ([]ToolCall, string) {
 	var toolCalls []ToolCall
-	reasoning := ""
-
-	// Simple parsing for tool calls
-	// In a real implementation, you would use more sophisticated parsing
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "TOOL_CALL:") {
-			// Extract JSON from tool call
-			jsonStart := strings.Index(line, "{")
-			jsonEnd := strings.LastIndex(line, "}")
-			if jsonStart != -1 && jsonEnd != -1 {
-				jsonStr := line[jsonStart:jsonEnd+1]
-				var toolCall ToolCall
-				if err := json.Unmarshal([]byte(jsonStr), &toolCall); err == nil {
-					toolCalls = append(toolCalls, toolCall)
-				}
+	var reasoning strings.Builder
+
+	if strings.TrimSpace(text) == "" {
+		return nil, ""
+	}
+
+	for {
+		marker := strings.Index(text, toolCallMarker)
+		if marker < 0 {
+			reasoning.WriteString(text)
+			break
+		}
+
+		// Text before the marker is reasoning, without the indentation of the marker's line
+		before := strings.TrimRight(text[:marker], " \t")
+		reasoning.WriteString(before)
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			reasoning.WriteString("\n")
+		}
+
+		rest := strings.TrimLeft(text[marker+len(toolCallMarker):], " \t\r\n")
+		isObject := strings.HasPrefix(rest, "{")
+		end := -1
+		if isObject {
+			end = jsonObjectEnd(rest)
+		}
+		if isObject && end < 0 {
+			log.Printf("⚠️ Ignoring unterminated tool call: %s", rest)
+			break
+		}
+
+		if !isObject {
+			// No JSON object follows the marker; drop its line
+			log.Printf("⚠️ Ignoring tool call without a JSON object")
+			end = strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+		} else {
+			var toolCall ToolCall
+			if err := json.Unmarshal([]byte(rest[:end]), &toolCall); err != nil {
+				log.Printf("⚠️ Ignoring malformed tool call: %v", err)
+			} else if toolCall.Function.Name == "" {
+				log.Printf("⚠️ Ignoring tool call without a function name")
+			} else {
+				toolCalls = append(toolCalls, toolCall)
 			}
-		} else if !strings.Contains(line, "TOOL_CALL:") {
-			// Collect reasoning (non-tool-call lines)
-			reasoning += line + "\n"
 		}
+
+		// Skip the end of the tool call's line when nothing else is on it
+		text = strings.TrimLeft(rest[end:], " \t\r")
+		text = strings.TrimPrefix(text, "\n")
 	}
 
-	return toolCalls, strings.TrimSpace(reasoning)
+	return toolCalls, strings.TrimSpace(reasoning.String())
+}
+
+// jsonObjectEnd returns the index just past the '}' closing the JSON object
+// that text starts with, skipping braces inside strings, or -1 when the
+// object is not closed
+func jsonObjectEnd(text string) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
 }
 
 func (p *ToolCallingProvider) executeToolCalls(ctx context.Context, toolCalls []ToolCall) (map[string]interface{}, error) {
 	results := make(map[string]interface{})
 	
 	for _, toolCall := range toolCalls {
-		_, exists := p.tools[toolCall.Function.Name]
+		tool, exists := p.tools[toolCall.Function.Name]
 		if !exists {
 			results[toolCall.Function.Name] = fmt.Sprintf("Tool not found: %s", toolCall.Function.Name)
 			continue
 		}
 
-		result, err := p.executeToolHandler(ctx, toolCall.Function.Name, toolCall.Function.Arguments)
+		args, err := CoerceToolArguments(tool.Function.Parameters, toolCall.Function.Arguments)
+		if err != nil {
+			results[toolCall.Function.Name] = fmt.Sprintf("Tool error: %v", err)
+			continue
+		}
+
+		result, err := p.executeToolHandler(ctx, toolCall.Function.Name, args)
 		if err != nil {
 			results[toolCall.Function.Name] = fmt.Sprintf("Tool error: %v", err)
 		} else {
@@ -379,14 +665,117 @@ func (p *ToolCallingProvider) executeToolCalls(ctx context.Context, toolCalls []
 	return results, nil
 }
 
+// validateToolCalls checks parsed tool calls against the registered tools and
+// their argument schemas, separating the calls that can be executed from the
+// ones the model needs to correct
+func (p *ToolCallingProvider) validateToolCalls(toolCalls []ToolCall) ([]ToolCall, []InvalidToolCall) {
+	var valid []ToolCall
+	var invalid []InvalidToolCall
+
+	for _, toolCall := range toolCalls {
+		tool, exists := p.tools[toolCall.Function.Name]
+		if !exists {
+			invalid = append(invalid, InvalidToolCall{
+				Call:   toolCall,
+				Reason: fmt.Sprintf("unknown tool %q (available: %s)", toolCall.Function.Name, strings.Join(p.toolNames(), ", ")),
+			})
+			continue
+		}
+
+		if reason := checkToolArguments(tool.Function.Parameters, toolCall.Function.Arguments); reason != "" {
+			invalid = append(invalid, InvalidToolCall{Call: toolCall, Reason: reason})
+			continue
+		}
+
+		valid = append(valid, toolCall)
+	}
+
+	return valid, invalid
+}
+
+// checkToolArguments returns why arguments do not match a tool's schema, or
+// an empty string when they do
+func checkToolArguments(schema map[string]interface{}, args map[string]interface{}) string {
+	var missing []string
+	for _, name := range requiredArguments(schema) {
+		if _, ok := args[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("missing required arguments: %s", strings.Join(missing, ", "))
+	}
+
+	if _, err := CoerceToolArguments(schema, args); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// requiredArguments returns the names listed in a schema's required field
+func requiredArguments(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		var names []string
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// toolNames returns the names of the registered tools, sorted
+func (p *ToolCallingProvider) toolNames() []string {
+	names := make([]string, 0, len(p.tools))
+	for name := range p.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildToolFeedbackPrompt describes the rejected tool calls so the model can
+// correct them. It is empty when every call was valid.
+func (p *ToolCallingProvider) buildToolFeedbackPrompt(invalid []InvalidToolCall) string {
+	if len(invalid) == 0 {
+		return ""
+	}
+
+	feedback := "The following tool calls were invalid and were not executed:\n"
+	for _, call := range invalid {
+		args, _ := json.Marshal(call.Call.Function.Arguments)
+		feedback += fmt.Sprintf("- %s %s: %s\n", call.Call.Function.Name, string(args), call.Reason)
+	}
+
+	return feedback + "Correct these calls using the registered tools and their parameters.\n\n"
+}
+
 // executeToolHandler executes a tool handler based on the tool name
 func (p *ToolCallingProvider) executeToolHandler(ctx context.Context, toolName string, args map[string]interface{}) (interface{}, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would have actual tool handlers
-	// For now, we'll return a simple response
+	if handler, ok := p.handlers[toolName]; ok {
+		return handler(ctx, args)
+	}
+
+	// Tools without a handler get a placeholder response
 	return fmt.Sprintf("Executed tool %s with args %v", toolName, args), nil
 }
 
+func (p *ToolCallingProvider) buildToolResultsPrompt(toolResults map[string]interface{}) string {
+	resultsStr := ""
+	for toolName, result := range toolResults {
+		resultsStr += fmt.Sprintf("- %s: %v\n", toolName, result)
+	}
+
+	return fmt.Sprintf(`Tool execution results:
+%s
+Use another tool if you need more information, otherwise provide your final answer:`, resultsStr)
+}
+
 func (p *ToolCallingProvider) buildFinalPrompt(originalPrompt, initialResponse string, toolResults map[string]interface{}) string {
 	resultsStr := ""
 	for toolName, result := range toolResults {
@@ -0,0 +1,452 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider returns canned responses in order. Streaming providers send
+// each response word by word; others report ErrStreamingNotSupported.
+type scriptedProvider struct {
+	*MockProvider
+	mu        sync.Mutex
+	responses []string
+	streaming bool
+	prompts   []string
+}
+
+func newScriptedProvider(streaming bool, responses ...string) *scriptedProvider {
+	return &scriptedProvider{MockProvider: new(MockProvider), responses: responses, streaming: streaming}
+}
+
+func (p *scriptedProvider) next(request *LLMRequest) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prompts = append(p.prompts, request.Messages[len(request.Messages)-1].Content)
+	if len(p.responses) == 0 {
+		return ""
+	}
+	response := p.responses[0]
+	p.responses = p.responses[1:]
+	return response
+}
+
+func (p *scriptedProvider) Generate(ctx context.Context, request *LLMRequest) (*LLMResponse, error) {
+	return &LLMResponse{Content: p.next(request), CreatedAt: time.Now()}, nil
+}
+
+func (p *scriptedProvider) GenerateStream(ctx context.Context, request *LLMRequest, ch chan<- LLMResponse) error {
+	if !p.streaming {
+		return ErrStreamingNotSupported
+	}
+	for _, word := range strings.SplitAfter(p.next(request), " ") {
+		ch <- LLMResponse{Content: word}
+	}
+	return nil
+}
+
+// collectStream reads a tool stream to completion
+func collectStream(t *testing.T, ch <-chan ToolStreamChunk) (string, []ToolStreamChunk) {
+	var content strings.Builder
+	var chunks []ToolStreamChunk
+	for chunk := range ch {
+		require.Empty(t, chunk.Error)
+		content.WriteString(chunk.Content)
+		chunks = append(chunks, chunk)
+	}
+	require.NotEmpty(t, chunks)
+	assert.True(t, chunks[len(chunks)-1].Done)
+	return content.String(), chunks
+}
+
+// TestToolCallingProvider_StreamWithTools tests tool streaming with and without base provider streaming
+func TestToolCallingProvider_StreamWithTools(t *testing.T) {
+	toolCall := `TOOL_CALL: {"function": {"name": "lookup", "arguments": {"key": "answer"}}}`
+
+	for _, streaming := range []bool{true, false} {
+		name := "non-streaming"
+		if streaming {
+			name = "streaming"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			base := newScriptedProvider(streaming, "let me check\n"+toolCall, "the answer is 42")
+			provider := NewToolCallingProvider(base)
+			require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "lookup"}}))
+
+			ch, err := provider.StreamWithTools(context.Background(), ToolGenerationRequest{Prompt: "what is the answer?"})
+			require.NoError(t, err)
+
+			content, chunks := collectStream(t, ch)
+			assert.Contains(t, content, "the answer is 42")
+
+			final := chunks[len(chunks)-1]
+			require.Len(t, final.ToolCalls, 1)
+			assert.Equal(t, "lookup", final.ToolCalls[0].Function.Name)
+			assert.Equal(t, "let me check", final.Reasoning)
+
+			// The final prompt includes the tool result
+			require.Len(t, base.prompts, 2)
+			assert.Contains(t, base.prompts[1], "Executed tool lookup")
+
+			if streaming {
+				assert.Greater(t, len(chunks), 4)
+			} else {
+				// One chunk per Generate call plus the final chunk
+				assert.Len(t, chunks, 3)
+			}
+		})
+	}
+}
+
+// TestCollectToolStream tests that a tool stream collects into the same
+// result as GenerateWithTools
+func TestCollectToolStream(t *testing.T) {
+	toolCall := `TOOL_CALL: {"function": {"name": "lookup", "arguments": {"key": "answer"}}}`
+	base := newScriptedProvider(true, "let me check\n"+toolCall, "the answer is 42")
+	provider := NewToolCallingProvider(base)
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "lookup"}}))
+
+	ch, err := provider.StreamWithTools(context.Background(), ToolGenerationRequest{Prompt: "what is the answer?"})
+	require.NoError(t, err)
+
+	response, err := CollectToolStream(ch)
+	require.NoError(t, err)
+	assert.Contains(t, response.Text, "let me check")
+	assert.True(t, strings.HasSuffix(response.Text, "the answer is 42"))
+	require.Len(t, response.ToolCalls, 1)
+	assert.Equal(t, "lookup", response.ToolCalls[0].Function.Name)
+	assert.Equal(t, "let me check", response.Reasoning)
+	assert.Greater(t, response.Metadata["chunks"], 4)
+}
+
+func TestCollectToolStream_MergesToolCalls(t *testing.T) {
+	first := ToolCall{ID: "call_1", Function: ToolCallFunction{Name: "read"}}
+	second := ToolCall{ID: "call_2", Function: ToolCallFunction{Name: "write"}}
+
+	ch := make(chan ToolStreamChunk, 4)
+	ch <- ToolStreamChunk{Content: "reading ", ToolCalls: []ToolCall{first}, Reasoning: "need the file"}
+	ch <- ToolStreamChunk{Content: "then writing"}
+	ch <- ToolStreamChunk{ToolCalls: []ToolCall{first, second}, Reasoning: "then update it", Done: true}
+	close(ch)
+
+	response, err := CollectToolStream(ch)
+	require.NoError(t, err)
+	assert.Equal(t, "reading then writing", response.Text)
+	assert.Equal(t, []ToolCall{first, second}, response.ToolCalls)
+	assert.Equal(t, "need the file\nthen update it", response.Reasoning)
+}
+
+func TestCollectToolStream_Errors(t *testing.T) {
+	ch := make(chan ToolStreamChunk, 2)
+	ch <- ToolStreamChunk{Content: "partial"}
+	ch <- ToolStreamChunk{Error: "Failed to stream final response: connection reset", Done: true}
+	close(ch)
+
+	response, err := CollectToolStream(ch)
+	assert.ErrorContains(t, err, "connection reset")
+	require.NotNil(t, response)
+	assert.Equal(t, "partial", response.Text)
+
+	// A stream closed without its final chunk is incomplete
+	truncated := make(chan ToolStreamChunk, 1)
+	truncated <- ToolStreamChunk{Content: "partial"}
+	close(truncated)
+
+	_, err = CollectToolStream(truncated)
+	assert.ErrorContains(t, err, "before its final chunk")
+}
+
+// streamingCapability lets a provider declare that it cannot stream
+type streamingCapability struct {
+	*scriptedProvider
+}
+
+func (p streamingCapability) SupportsStreaming() bool { return false }
+
+// TestToolCallingProvider_StreamWithToolsCapabilityCheck tests that providers declaring no streaming support are never streamed
+func TestToolCallingProvider_StreamWithToolsCapabilityCheck(t *testing.T) {
+	// GenerateStream would succeed, but the capability check must route around it
+	base := newScriptedProvider(true, "plain answer")
+	provider := NewToolCallingProvider(streamingCapability{base})
+
+	ch, err := provider.StreamWithTools(context.Background(), ToolGenerationRequest{Prompt: "hello"})
+	require.NoError(t, err)
+
+	content, chunks := collectStream(t, ch)
+	assert.Equal(t, "plain answer", content)
+	assert.Len(t, chunks, 2)
+	assert.Empty(t, chunks[1].ToolCalls)
+}
+
+// TestToolCallingProvider_GenerateWithToolsLoop tests a flow that needs two rounds of tool calls
+func TestToolCallingProvider_GenerateWithToolsLoop(t *testing.T) {
+	base := newScriptedProvider(false,
+		`TOOL_CALL: {"function": {"name": "find_user", "arguments": {"name": "ada"}}}`,
+		`TOOL_CALL: {"function": {"name": "get_orders", "arguments": {"user_id": "7"}}}`,
+		"ada has 3 orders",
+	)
+	provider := NewToolCallingProvider(base)
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "find_user"}}))
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{
+		Name: "get_orders",
+		Parameters: map[string]interface{}{
+			"properties": map[string]interface{}{"user_id": map[string]interface{}{"type": "integer"}},
+		},
+	}}))
+
+	resp, err := provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "how many orders does ada have?"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ada has 3 orders", resp.Text)
+	require.Len(t, resp.ToolCalls, 2)
+	assert.Equal(t, "find_user", resp.ToolCalls[0].Function.Name)
+	assert.Equal(t, "get_orders", resp.ToolCalls[1].Function.Name)
+
+	// Each round sees the results of the previous one
+	require.Len(t, base.prompts, 3)
+	assert.Contains(t, base.prompts[1], "find_user: Executed tool find_user")
+	assert.Contains(t, base.prompts[2], "get_orders: Executed tool get_orders with args map[user_id:7]")
+
+	iterations := resp.Metadata["iterations"].([]ToolIteration)
+	require.Len(t, iterations, 3)
+	assert.Equal(t, []string{"find_user"}, iterations[0].ToolCalls)
+	assert.Equal(t, []string{"get_orders"}, iterations[1].ToolCalls)
+	assert.Empty(t, iterations[2].ToolCalls)
+	assert.Equal(t, 3, resp.Metadata["iteration_count"])
+	assert.Equal(t, false, resp.Metadata["max_iterations_reached"])
+}
+
+// TestToolCallingProvider_ToolResults tests that what each tool returned is surfaced, including failures
+func TestToolCallingProvider_ToolResults(t *testing.T) {
+	base := newScriptedProvider(false,
+		"TOOL_CALL: {\"function\": {\"name\": \"find_user\", \"arguments\": {\"name\": \"ada\"}}}\n"+
+			`TOOL_CALL: {"function": {"name": "get_orders", "arguments": {"user_id": 7}}}`,
+		"ada has no orders I can see",
+	)
+	provider := NewToolCallingProvider(base)
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "find_user"}}))
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "get_orders"}}))
+	require.NoError(t, provider.SetToolHandler("find_user", func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"id": 7, "name": args["name"]}, nil
+	}))
+	require.NoError(t, provider.SetToolHandler("get_orders", func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return nil, errors.New("orders service unavailable")
+	}))
+	assert.Error(t, provider.SetToolHandler("missing", nil))
+
+	resp, err := provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "how many orders does ada have?"})
+	require.NoError(t, err)
+
+	require.Len(t, resp.ToolResults, 2)
+	assert.Equal(t, map[string]interface{}{"id": 7, "name": "ada"}, resp.ToolResults["find_user"])
+	assert.Equal(t, "Tool error: orders service unavailable", resp.ToolResults["get_orders"])
+	assert.Contains(t, base.prompts[1], "get_orders: Tool error: orders service unavailable")
+
+	// Streaming reports the same results on its final chunk
+	streamBase := newScriptedProvider(true, `TOOL_CALL: {"function": {"name": "find_user", "arguments": {"name": "ada"}}}`, "found ada")
+	streaming := NewToolCallingProvider(streamBase)
+	require.NoError(t, streaming.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "find_user"}}))
+	ch, err := streaming.StreamWithTools(context.Background(), ToolGenerationRequest{Prompt: "find ada"})
+	require.NoError(t, err)
+	collected, err := CollectToolStream(ch)
+	require.NoError(t, err)
+	assert.Equal(t, "Executed tool find_user with args map[name:ada]", collected.ToolResults["find_user"])
+}
+
+// TestToolCallingProvider_InvalidToolCallFeedback tests that invalid calls are reported back instead of executed
+func TestToolCallingProvider_InvalidToolCallFeedback(t *testing.T) {
+	base := newScriptedProvider(false,
+		"TOOL_CALL: {\"function\": {\"name\": \"delete_user\", \"arguments\": {\"id\": 7}}}\n"+
+			"TOOL_CALL: {\"function\": {\"name\": \"get_orders\", \"arguments\": {\"user_id\": \"seven\"}}}\n"+
+			"TOOL_CALL: {\"function\": {\"name\": \"get_orders\", \"arguments\": {}}}",
+		`TOOL_CALL: {"function": {"name": "get_orders", "arguments": {"user_id": "7"}}}`,
+		"user 7 has 3 orders",
+	)
+	provider := NewToolCallingProvider(base)
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{
+		Name: "get_orders",
+		Parameters: map[string]interface{}{
+			"properties": map[string]interface{}{"user_id": map[string]interface{}{"type": "integer"}},
+			"required":   []interface{}{"user_id"},
+		},
+	}}))
+
+	resp, err := provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "how many orders does user 7 have?"})
+	require.NoError(t, err)
+	assert.Equal(t, "user 7 has 3 orders", resp.Text)
+
+	// Only the corrected call was executed
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, map[string]interface{}{"user_id": "7"}, resp.ToolCalls[0].Function.Arguments)
+
+	require.Len(t, base.prompts, 3)
+	feedback := base.prompts[1]
+	assert.Contains(t, feedback, "were invalid and were not executed")
+	assert.Contains(t, feedback, `- delete_user {"id":7}: unknown tool "delete_user" (available: get_orders)`)
+	assert.Contains(t, feedback, `- get_orders {"user_id":"seven"}: invalid argument user_id: cannot convert seven (string) to integer`)
+	assert.Contains(t, feedback, "- get_orders {}: missing required arguments: user_id")
+	assert.NotContains(t, base.prompts[2], "invalid")
+
+	iterations := resp.Metadata["iterations"].([]ToolIteration)
+	require.Len(t, iterations, 3)
+	assert.Len(t, iterations[0].Invalid, 3)
+	assert.Empty(t, iterations[0].Results)
+	assert.Empty(t, iterations[1].Invalid)
+}
+
+// TestToolCallingProvider_GenerateWithToolsMaxIterations tests that a model that keeps calling tools is stopped
+func TestToolCallingProvider_GenerateWithToolsMaxIterations(t *testing.T) {
+	toolCall := `TOOL_CALL: {"function": {"name": "ping", "arguments": {}}}`
+	base := newScriptedProvider(false, toolCall, toolCall, toolCall, toolCall, toolCall)
+	provider := NewToolCallingProvider(base)
+	require.NoError(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "ping"}}))
+
+	resp, err := provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "loop", MaxIterations: 3})
+	require.NoError(t, err)
+
+	assert.Len(t, base.prompts, 3)
+	assert.Len(t, resp.ToolCalls, 2, "tool calls of the final iteration are not executed")
+	assert.Equal(t, 3, resp.Metadata["iteration_count"])
+	assert.Equal(t, true, resp.Metadata["max_iterations_reached"])
+}
+
+// TestToolCallingProvider_EmptyResponse tests retrying and reporting empty responses
+func TestToolCallingProvider_EmptyResponse(t *testing.T) {
+	base := newScriptedProvider(false, "", "Recovered answer")
+	base.On("GetName").Return("scripted")
+	provider := NewToolCallingProvider(base)
+
+	resp, err := provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "Recovered answer", resp.Text)
+	assert.Len(t, base.prompts, 2)
+
+	base = newScriptedProvider(false, "  \n", "")
+	base.On("GetName").Return("scripted")
+	provider = NewToolCallingProvider(base)
+
+	resp, err = provider.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "hello"})
+	assert.ErrorIs(t, err, ErrEmptyResponse)
+	assert.Nil(t, resp)
+	assert.Len(t, base.prompts, 1+DefaultEmptyResponseRetries)
+
+	toolCalls, reasoning := provider.extractToolCallsAndReasoning("")
+	assert.Empty(t, toolCalls)
+	assert.Empty(t, reasoning)
+
+	toolCalls, _ = provider.extractToolCallsAndReasoning(`TOOL_CALL: {"function": {}}`)
+	assert.Empty(t, toolCalls)
+}
+
+// TestWithToolCalling_SharesProvider tests that one concrete provider serves
+// both the model manager and the tool-calling layer
+func TestWithToolCalling_SharesProvider(t *testing.T) {
+	server, _ := newKeepAliveServer(t)
+	ollama, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	manager := NewModelManager()
+	require.NoError(t, manager.RegisterProvider(ollama))
+
+	provider, err := manager.GetProviderForModel("llama3", ProviderTypeLocal)
+	require.NoError(t, err)
+	assert.Same(t, ollama, provider)
+
+	enhanced, err := manager.GetToolCallingProvider("llama3", ProviderTypeLocal)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeLocal, enhanced.GetType())
+
+	response, err := enhanced.GenerateWithTools(context.Background(), ToolGenerationRequest{Prompt: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response.Text)
+
+	// Wrapping an enhanced provider again keeps it and its tools
+	require.NoError(t, enhanced.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "lookup"}}))
+	assert.Same(t, enhanced, WithToolCalling(enhanced))
+	assert.Len(t, WithToolCalling(enhanced).ListAvailableTools(), 1)
+
+	_, err = manager.GetToolCallingProvider("missing", ProviderTypeLocal)
+	assert.Error(t, err)
+}
+
+func TestToolCallingProvider_ExtractToolCalls(t *testing.T) {
+	provider := NewToolCallingProvider(newScriptedProvider(false))
+
+	tests := []struct {
+		name      string
+		text      string
+		calls     []string
+		arguments []map[string]interface{}
+		reasoning string
+	}{
+		{
+			name:      "single line",
+			text:      "Let me look.\nTOOL_CALL: {\"function\": {\"name\": \"lookup\", \"arguments\": {\"key\": \"a}b\"}}}\nDone.",
+			calls:     []string{"lookup"},
+			arguments: []map[string]interface{}{{"key": "a}b"}},
+			reasoning: "Let me look.\nDone.",
+		},
+		{
+			name: "multi-line",
+			text: "I will search both.\n" +
+				"TOOL_CALL: {\n" +
+				"  \"function\": {\n" +
+				"    \"name\": \"search\",\n" +
+				"    \"arguments\": {\n" +
+				"      \"query\": \"go {generics}\",\n" +
+				"      \"limit\": 5\n" +
+				"    }\n" +
+				"  }\n" +
+				"}\n" +
+				"  TOOL_CALL:\n" +
+				"{\"function\": {\"name\": \"lookup\",\n" +
+				"  \"arguments\": {\"key\": \"x\"}}}\n" +
+				"Then I will answer.",
+			calls:     []string{"search", "lookup"},
+			arguments: []map[string]interface{}{{"query": "go {generics}", "limit": float64(5)}, {"key": "x"}},
+			reasoning: "I will search both.\nThen I will answer.",
+		},
+		{
+			name:      "malformed JSON",
+			text:      "First.\nTOOL_CALL: {\"function\": {\"name\": \"lookup\", \"arguments\": {\"key\": }}}\nTOOL_CALL: {\"function\": {\"name\": \"ping\", \"arguments\": {}}}\nLast.",
+			calls:     []string{"ping"},
+			arguments: []map[string]interface{}{{}},
+			reasoning: "First.\nLast.",
+		},
+		{
+			name:      "no JSON object",
+			text:      "Thinking.\nTOOL_CALL: lookup the key\nAnswer.",
+			reasoning: "Thinking.\nAnswer.",
+		},
+		{
+			name:      "unterminated",
+			text:      "Before.\nTOOL_CALL: {\"function\": {\"name\": \"lookup\",\n\"arguments\": {",
+			reasoning: "Before.",
+		},
+		{
+			name:      "prose only",
+			text:      "  The answer is {42}.\n",
+			reasoning: "The answer is {42}.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolCalls, reasoning := provider.extractToolCallsAndReasoning(tt.text)
+			require.Len(t, toolCalls, len(tt.calls))
+			for i, call := range toolCalls {
+				assert.Equal(t, tt.calls[i], call.Function.Name)
+				assert.Equal(t, tt.arguments[i], call.Function.Arguments)
+			}
+			assert.Equal(t, tt.reasoning, reasoning)
+		})
+	}
+}
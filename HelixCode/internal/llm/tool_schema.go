@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// toolNamePattern is the tool name format both OpenAI and Anthropic accept
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// jsonSchemaTypes are the type names a JSON Schema may use
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true,
+	"array": true, "object": true, "null": true,
+}
+
+// ToolSchema is the canonical, provider-agnostic definition of a tool. Tools
+// are validated once in this form and converted to each provider's format.
+type ToolSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"` // JSON Schema for the arguments object
+}
+
+// AnthropicTool is a tool definition in the Anthropic Messages API format
+type AnthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// NewToolSchema creates a tool schema and validates it
+func NewToolSchema(name, description string, parameters map[string]interface{}) (ToolSchema, error) {
+	schema := ToolSchema{Name: name, Description: description, Parameters: parameters}
+	if err := schema.Validate(); err != nil {
+		return ToolSchema{}, err
+	}
+	return schema, nil
+}
+
+// ToolSchemaFromTool converts an OpenAI-style Tool to its canonical schema
+func ToolSchemaFromTool(tool Tool) ToolSchema {
+	return ToolSchema{
+		Name:        tool.Function.Name,
+		Description: tool.Function.Description,
+		Parameters:  tool.Function.Parameters,
+	}
+}
+
+// Validate checks the tool name and that the parameters are a well-formed
+// JSON Schema for an object: known types, object properties and required
+// arguments that are declared as properties
+func (s ToolSchema) Validate() error {
+	if !toolNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid tool name %q: use 1-64 letters, digits, underscores or hyphens", s.Name)
+	}
+	if s.Parameters == nil {
+		return nil
+	}
+	if schemaType, ok := s.Parameters["type"]; ok && schemaType != "object" {
+		return fmt.Errorf("tool %s: parameters must be an object schema, got type %v", s.Name, schemaType)
+	}
+	if err := validateJSONSchema(s.Parameters, "parameters"); err != nil {
+		return fmt.Errorf("tool %s: %v", s.Name, err)
+	}
+	return nil
+}
+
+// ValidateArguments checks arguments against the parameter schema
+func (s ToolSchema) ValidateArguments(args map[string]interface{}) error {
+	if reason := checkToolArguments(s.Parameters, args); reason != "" {
+		return fmt.Errorf("tool %s: %s", s.Name, reason)
+	}
+	return nil
+}
+
+// objectParameters returns the parameters as a complete object schema, as
+// providers with native tools require
+func (s ToolSchema) objectParameters() map[string]interface{} {
+	parameters := make(map[string]interface{}, len(s.Parameters)+2)
+	for key, value := range s.Parameters {
+		parameters[key] = value
+	}
+	if _, ok := parameters["type"]; !ok {
+		parameters["type"] = "object"
+	}
+	if _, ok := parameters["properties"]; !ok {
+		parameters["properties"] = map[string]interface{}{}
+	}
+	return parameters
+}
+
+// Tool returns the schema as an OpenAI function tool
+func (s ToolSchema) Tool() Tool {
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        s.Name,
+			Description: s.Description,
+			Parameters:  s.objectParameters(),
+		},
+	}
+}
+
+// AnthropicTool returns the schema in the Anthropic tools format
+func (s ToolSchema) AnthropicTool() AnthropicTool {
+	return AnthropicTool{
+		Name:        s.Name,
+		Description: s.Description,
+		InputSchema: s.objectParameters(),
+	}
+}
+
+// PromptLine describes the tool for models that call tools through the
+// TOOL_CALL text convention rather than natively
+func (s ToolSchema) PromptLine() string {
+	parameters, _ := json.Marshal(s.Parameters)
+	return fmt.Sprintf("- %s: %s (parameters: %s)", s.Name, s.Description, parameters)
+}
+
+// validateJSONSchema checks the parts of a JSON Schema that tools rely on,
+// recursing into object properties and array items
+func validateJSONSchema(schema map[string]interface{}, path string) error {
+	if err := validateSchemaType(schema["type"], path); err != nil {
+		return err
+	}
+
+	var properties map[string]interface{}
+	if raw, ok := schema["properties"]; ok {
+		properties, ok = raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.properties must be an object", path)
+		}
+		for name, property := range properties {
+			propertySchema, ok := property.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.properties.%s must be a schema object", path, name)
+			}
+			if err := validateJSONSchema(propertySchema, path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if raw, ok := schema["required"]; ok {
+		valid := false
+		switch list := raw.(type) {
+		case []string:
+			valid = true
+		case []interface{}:
+			valid = len(requiredArguments(schema)) == len(list)
+		}
+		if !valid {
+			return fmt.Errorf("%s.required must be a list of property names", path)
+		}
+		var undeclared []string
+		for _, name := range requiredArguments(schema) {
+			if _, declared := properties[name]; !declared {
+				undeclared = append(undeclared, name)
+			}
+		}
+		if len(undeclared) > 0 {
+			return fmt.Errorf("%s.required names undeclared properties: %s", path, strings.Join(undeclared, ", "))
+		}
+	}
+
+	if raw, ok := schema["items"]; ok {
+		items, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.items must be a schema object", path)
+		}
+		if err := validateJSONSchema(items, path+".items"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSchemaType checks a schema's type, which may be absent, a type
+// name or a list of type names
+func validateSchemaType(schemaType interface{}, path string) error {
+	switch value := schemaType.(type) {
+	case nil:
+		return nil
+	case string:
+		if !jsonSchemaTypes[value] {
+			return fmt.Errorf("%s has unknown type %q", path, value)
+		}
+	case []interface{}:
+		for _, item := range value {
+			name, ok := item.(string)
+			if !ok || !jsonSchemaTypes[name] {
+				return fmt.Errorf("%s has unknown type %v", path, item)
+			}
+		}
+	case []string:
+		for _, name := range value {
+			if !jsonSchemaTypes[name] {
+				return fmt.Errorf("%s has unknown type %q", path, name)
+			}
+		}
+	default:
+		return fmt.Errorf("%s.type must be a string or list of strings", path)
+	}
+	return nil
+}
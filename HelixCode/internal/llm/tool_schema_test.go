@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weatherSchema(t *testing.T) ToolSchema {
+	t.Helper()
+	schema, err := NewToolSchema("get_weather", "Current weather for a city", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city":  map[string]interface{}{"type": "string"},
+			"units": map[string]interface{}{"type": "string"},
+		},
+		"required": []interface{}{"city"},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func TestToolSchema_Validate(t *testing.T) {
+	_, err := NewToolSchema("lookup", "", nil)
+	assert.NoError(t, err)
+
+	tests := map[string]ToolSchema{
+		"empty name":          {Name: ""},
+		"name with spaces":    {Name: "get weather"},
+		"non-object params":   {Name: "lookup", Parameters: map[string]interface{}{"type": "string"}},
+		"unknown type":        {Name: "lookup", Parameters: map[string]interface{}{"properties": map[string]interface{}{"id": map[string]interface{}{"type": "int"}}}},
+		"property not schema": {Name: "lookup", Parameters: map[string]interface{}{"properties": map[string]interface{}{"id": "string"}}},
+		"undeclared required": {Name: "lookup", Parameters: map[string]interface{}{"properties": map[string]interface{}{}, "required": []interface{}{"id"}}},
+		"bad items": {Name: "lookup", Parameters: map[string]interface{}{"properties": map[string]interface{}{
+			"ids": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "uuid"}},
+		}}},
+	}
+	for name, schema := range tests {
+		assert.Error(t, schema.Validate(), name)
+	}
+}
+
+func TestToolSchema_ValidateArguments(t *testing.T) {
+	schema := weatherSchema(t)
+	assert.NoError(t, schema.ValidateArguments(map[string]interface{}{"city": "Oslo"}))
+	assert.ErrorContains(t, schema.ValidateArguments(map[string]interface{}{"units": "metric"}), "missing required arguments: city")
+}
+
+func TestToolSchema_OpenAIFormat(t *testing.T) {
+	tool := weatherSchema(t).Tool()
+	data, err := json.Marshal(tool)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "function",
+		"function": {
+			"name": "get_weather",
+			"description": "Current weather for a city",
+			"parameters": {
+				"type": "object",
+				"properties": {"city": {"type": "string"}, "units": {"type": "string"}},
+				"required": ["city"]
+			}
+		}
+	}`, string(data))
+
+	// A Tool round-trips through the canonical schema
+	assert.Equal(t, weatherSchema(t), ToolSchemaFromTool(tool))
+}
+
+func TestToolSchema_AnthropicFormat(t *testing.T) {
+	data, err := json.Marshal(weatherSchema(t).AnthropicTool())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "get_weather",
+		"description": "Current weather for a city",
+		"input_schema": {
+			"type": "object",
+			"properties": {"city": {"type": "string"}, "units": {"type": "string"}},
+			"required": ["city"]
+		}
+	}`, string(data))
+
+	// Tools without parameters still get an object schema
+	data, err = json.Marshal(ToolSchema{Name: "ping"}.AnthropicTool())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "ping", "input_schema": {"type": "object", "properties": {}}}`, string(data))
+}
+
+func TestToolSchema_PromptLine(t *testing.T) {
+	line := ToolSchema{Name: "lookup", Description: "Find a key", Parameters: map[string]interface{}{"type": "object"}}.PromptLine()
+	assert.Equal(t, `- lookup: Find a key (parameters: {"type":"object"})`, line)
+
+	provider := NewToolCallingProvider(newScriptedProvider(false))
+	assert.Error(t, provider.RegisterTool(Tool{Type: "function", Function: FunctionDefinition{Name: "bad name"}}))
+}
@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngImage is the start of a PNG file, enough for content type detection
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// newCaptureServer records the body of each request to path, answering it
+// with reply. Other paths list a vision model and a text-only model.
+func newCaptureServer(t *testing.T, path, reply string) (*httptest.Server, chan []byte) {
+	t.Helper()
+
+	bodies := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			fmt.Fprint(w, `{"models":[{"name":"llava:7b","details":{"families":["llama","clip"]}},{"name":"llama3"}],"data":[]}`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		fmt.Fprint(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func imageRequest() *LLMRequest {
+	return &LLMRequest{
+		Model:    "llava:7b",
+		Messages: []Message{{Role: "user", Content: "what is in this picture?", Images: [][]byte{pngImage}}},
+	}
+}
+
+func TestOllamaProvider_ForwardsImages(t *testing.T) {
+	server, bodies := newCaptureServer(t, "/api/chat",
+		`{"model":"llava:7b","message":{"role":"assistant","content":"a cat"},"done":true}`)
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	response, err := provider.Generate(context.Background(), imageRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "a cat", response.Content)
+
+	var body struct {
+		Messages []struct {
+			Content string   `json:"content"`
+			Images  []string `json:"images"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &body))
+	require.Len(t, body.Messages, 1)
+	assert.Equal(t, "what is in this picture?", body.Messages[0].Content)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString(pngImage)}, body.Messages[0].Images)
+}
+
+func TestOllamaProvider_VisionModels(t *testing.T) {
+	server, _ := newCaptureServer(t, "/api/chat", "")
+	provider, err := NewOllamaProvider(OllamaConfig{BaseURL: server.URL, Timeout: 5})
+	require.NoError(t, err)
+
+	models := provider.GetModels()
+	require.Len(t, models, 2)
+	assert.True(t, models[0].SupportsVision)
+	assert.Contains(t, models[0].Capabilities, CapabilityVision)
+	assert.False(t, models[1].SupportsVision)
+	assert.NotContains(t, models[1].Capabilities, CapabilityVision)
+}
+
+func TestOpenAIProvider_ForwardsImages(t *testing.T) {
+	server, bodies := newCaptureServer(t, "/chat/completions",
+		`{"choices":[{"message":{"role":"assistant","content":"a cat"},"finish_reason":"stop"}]}`)
+	provider, err := NewOpenAIProvider(ProviderConfigEntry{Endpoint: server.URL, APIKey: "test-key"})
+	require.NoError(t, err)
+
+	request := imageRequest()
+	request.Messages = append([]Message{{Role: "system", Content: "describe images"}}, request.Messages...)
+	_, err = provider.Generate(context.Background(), request)
+	require.NoError(t, err)
+
+	var body struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &body))
+	require.Len(t, body.Messages, 2)
+
+	// Messages without images keep plain string content
+	assert.JSONEq(t, `"describe images"`, string(body.Messages[0].Content))
+
+	var parts []OpenAIContentPart
+	require.NoError(t, json.Unmarshal(body.Messages[1].Content, &parts))
+	require.Len(t, parts, 2)
+	assert.Equal(t, OpenAIContentPart{Type: "text", Text: "what is in this picture?"}, parts[0])
+	assert.Equal(t, "image_url", parts[1].Type)
+	require.NotNil(t, parts[1].ImageURL)
+	assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(pngImage), parts[1].ImageURL.URL)
+}
+
+func TestLlamaCPPProvider_RejectsImages(t *testing.T) {
+	provider, err := NewLlamaCPPProvider(LlamaConfig{ModelPath: "llava.gguf", ContextSize: 2048})
+	require.NoError(t, err)
+
+	request := imageRequest()
+	request.Model = ""
+	_, err = provider.Generate(context.Background(), request)
+	assert.ErrorIs(t, err, ErrUnsupportedInput)
+
+	err = provider.GenerateStream(context.Background(), request, make(chan LLMResponse, 1))
+	assert.ErrorIs(t, err, ErrUnsupportedInput)
+}
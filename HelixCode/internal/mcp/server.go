@@ -11,6 +11,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/google/uuid"
+	"dev.helix.code/internal/llm"
 )
 
 // MCPServer implements the Model Context Protocol server
@@ -42,6 +43,11 @@ type Tool struct {
 	Permissions []string               `json:"permissions"`
 }
 
+// Schema returns the tool's canonical schema for conversion to LLM provider formats
+func (t *Tool) Schema() llm.ToolSchema {
+	return llm.ToolSchema{Name: t.ID, Description: t.Description, Parameters: t.Parameters}
+}
+
 // ToolHandler is the function signature for tool execution
 type ToolHandler func(ctx context.Context, session *MCPSession, args map[string]interface{}) (interface{}, error)
 
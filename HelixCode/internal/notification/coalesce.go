@@ -0,0 +1,141 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/clock"
+)
+
+// DefaultCoalesceWindow is how long progress updates are collected before a
+// summary is sent
+const DefaultCoalesceWindow = 30 * time.Second
+
+// ProgressCoalescer summarizes progress on a group of items, such as the
+// subtasks of a split task, so that a large fan-out sends one "N/M complete"
+// notification per window instead of one per item
+type ProgressCoalescer struct {
+	engine  *NotificationEngine
+	window  time.Duration
+	mu      sync.Mutex
+	clock   clock.Clock
+	groups  map[string]*progressGroup
+	windows int // Windows opened so far, numbering each group's generation
+}
+
+// progressGroup is the latest progress of one group within a window
+type progressGroup struct {
+	title      string
+	done       int
+	total      int
+	metadata   map[string]interface{}
+	generation int // Distinguishes windows so a stale timer sends nothing
+}
+
+// NewProgressCoalescer creates a coalescer that sends through engine. A zero
+// window uses DefaultCoalesceWindow.
+func NewProgressCoalescer(engine *NotificationEngine, window time.Duration) *ProgressCoalescer {
+	if window <= 0 {
+		window = DefaultCoalesceWindow
+	}
+	return &ProgressCoalescer{
+		engine: engine,
+		window: window,
+		clock:  clock.New(),
+		groups: make(map[string]*progressGroup),
+	}
+}
+
+// SetClock replaces the clock that ends coalescing windows
+func (c *ProgressCoalescer) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clk
+}
+
+// Progress records that done of total items in group are complete. The first
+// update opens a window; when it closes one summary with the latest counts is
+// sent for all updates received during it.
+func (c *ProgressCoalescer) Progress(group, title string, done, total int, metadata map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pending, exists := c.groups[group]; exists {
+		pending.title = title
+		pending.done = done
+		pending.total = total
+		pending.metadata = metadata
+		return
+	}
+
+	c.windows++
+	pending := &progressGroup{title: title, done: done, total: total, metadata: metadata, generation: c.windows}
+	c.groups[group] = pending
+	c.schedule(group, pending.generation)
+}
+
+// schedule flushes group when the window ends. The caller must hold c.mu.
+func (c *ProgressCoalescer) schedule(group string, generation int) {
+	timer := c.clock.After(c.window)
+	go func() {
+		<-timer
+		c.flush(group, generation)
+	}()
+}
+
+// flush sends the summary of a window unless Complete already ended it
+func (c *ProgressCoalescer) flush(group string, generation int) {
+	c.mu.Lock()
+	pending, exists := c.groups[group]
+	if !exists || pending.generation != generation {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.groups, group)
+	c.mu.Unlock()
+
+	c.send(&Notification{
+		Title:    pending.title,
+		Message:  fmt.Sprintf("%d/%d subtasks complete", pending.done, pending.total),
+		Type:     NotificationTypeInfo,
+		Priority: NotificationPriorityLow,
+		Metadata: progressMetadata(pending.metadata, pending.done, pending.total),
+	})
+}
+
+// Complete sends the final notification for group, replacing any summary
+// still waiting for its window to close
+func (c *ProgressCoalescer) Complete(group, title string, total int, metadata map[string]interface{}) {
+	c.mu.Lock()
+	delete(c.groups, group)
+	c.mu.Unlock()
+
+	c.send(&Notification{
+		Title:    title,
+		Message:  fmt.Sprintf("All %d subtasks complete", total),
+		Type:     NotificationTypeSuccess,
+		Priority: NotificationPriorityMedium,
+		Metadata: progressMetadata(metadata, total, total),
+	})
+}
+
+// send delivers a summary, logging rather than returning failures
+func (c *ProgressCoalescer) send(notification *Notification) {
+	if err := c.engine.SendNotification(context.Background(), notification); err != nil {
+		log.Printf("⚠️ Failed to send progress notification %q: %v", notification.Title, err)
+	}
+}
+
+// progressMetadata copies metadata and adds the progress counts
+func progressMetadata(metadata map[string]interface{}, done, total int) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+2)
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	copied["completed"] = done
+	copied["total"] = total
+	return copied
+}
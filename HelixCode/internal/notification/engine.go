@@ -3,17 +3,21 @@ package notification
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"net/smtp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/clock"
 )
 
 // NotificationEngine manages multi-channel notifications
@@ -22,6 +26,11 @@ type NotificationEngine struct {
 	rules    []NotificationRule
 	templates map[string]*template.Template
 	mutex    sync.RWMutex
+
+	clock       clock.Clock
+	dedupWindow time.Duration
+	recent      map[string]time.Time // dedup key -> last sent
+	store       Store                // Pending notifications, replayed after a restart
 }
 
 // NotificationChannel represents a notification channel
@@ -32,6 +41,21 @@ type NotificationChannel interface {
 	GetConfig() map[string]interface{}
 }
 
+// ChannelTester is implemented by channels that can check they are reachable
+// and correctly configured without sending a notification
+type ChannelTester interface {
+	TestChannel(ctx context.Context) error
+}
+
+// ChannelHealth is the result of testing one channel
+type ChannelHealth struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Tested  bool   `json:"tested"` // False for channels without a connectivity check
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
 // Notification represents a notification to be sent
 type Notification struct {
 	ID        uuid.UUID
@@ -82,7 +106,63 @@ func NewNotificationEngine() *NotificationEngine {
 		channels:  make(map[string]NotificationChannel),
 		rules:     []NotificationRule{},
 		templates: make(map[string]*template.Template),
+		clock:     clock.New(),
+		recent:    make(map[string]time.Time),
+	}
+}
+
+// SetClock replaces the clock used to timestamp and deduplicate notifications
+func (e *NotificationEngine) SetClock(c clock.Clock) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.clock = c
+}
+
+// SetDedupWindow suppresses notifications identical to one sent within window.
+// A zero window disables deduplication.
+func (e *NotificationEngine) SetDedupWindow(window time.Duration) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.dedupWindow = window
+}
+
+// SetStore persists notifications until each of their channels delivers them.
+// Call ReplayPending once channels are registered to send what an earlier run
+// left undelivered.
+func (e *NotificationEngine) SetStore(store Store) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.store = store
+}
+
+// ReplayPending sends notifications left undelivered by an earlier run to the
+// channels that have not delivered them yet and returns how many were replayed
+// without error
+func (e *NotificationEngine) ReplayPending(ctx context.Context) (int, error) {
+	e.mutex.RLock()
+	store := e.store
+	e.mutex.RUnlock()
+	if store == nil {
+		return 0, nil
+	}
+
+	pending, err := store.Pending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending notifications: %v", err)
+	}
+
+	replayed := 0
+	for _, notification := range pending {
+		if err := e.deliver(ctx, notification); err != nil {
+			log.Printf("Failed to replay notification %s: %v", notification.ID, err)
+			continue
+		}
+		replayed++
+	}
+	if len(pending) > 0 {
+		log.Printf("Replayed %d of %d pending notifications", replayed, len(pending))
 	}
+	return replayed, nil
 }
 
 // RegisterChannel registers a notification channel
@@ -128,8 +208,13 @@ func (e *NotificationEngine) LoadTemplate(name string, templateStr string) error
 
 // SendNotification sends a notification based on rules
 func (e *NotificationEngine) SendNotification(ctx context.Context, notification *Notification) error {
+	if e.isDuplicate(notification) {
+		log.Printf("Notification suppressed as duplicate: %s", notification.Title)
+		return nil
+	}
+
 	notification.ID = uuid.New()
-	notification.CreatedAt = time.Now()
+	notification.CreatedAt = e.now()
 
 	// Apply rules to determine channels and priority
 	e.applyRules(notification)
@@ -141,12 +226,43 @@ func (e *NotificationEngine) SendNotification(ctx context.Context, notification
 // SendDirect sends a notification directly to specified channels
 func (e *NotificationEngine) SendDirect(ctx context.Context, notification *Notification, channels []string) error {
 	notification.ID = uuid.New()
-	notification.CreatedAt = time.Now()
+	notification.CreatedAt = e.now()
 	notification.Channels = channels
 
 	return e.sendToChannels(ctx, notification)
 }
 
+// isDuplicate reports whether an identical notification was sent within the
+// dedup window, recording this one if not. Expired entries are dropped.
+func (e *NotificationEngine) isDuplicate(notification *Notification) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.dedupWindow <= 0 {
+		return false
+	}
+
+	now := e.clock.Now()
+	for key, sentAt := range e.recent {
+		if now.Sub(sentAt) >= e.dedupWindow {
+			delete(e.recent, key)
+		}
+	}
+
+	key := string(notification.Type) + "\x00" + notification.Title + "\x00" + notification.Message
+	if _, seen := e.recent[key]; seen {
+		return true
+	}
+	e.recent[key] = now
+	return false
+}
+
+func (e *NotificationEngine) now() time.Time {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.clock.Now()
+}
+
 // applyRules applies notification rules to determine channels and priority
 func (e *NotificationEngine) applyRules(notification *Notification) {
 	e.mutex.RLock()
@@ -179,8 +295,25 @@ func (e *NotificationEngine) applyRules(notification *Notification) {
 	}
 }
 
-// sendToChannels sends notification to all specified channels
+// sendToChannels records the notification as pending, if a store is set, and
+// sends it to all specified channels
 func (e *NotificationEngine) sendToChannels(ctx context.Context, notification *Notification) error {
+	e.mutex.RLock()
+	store := e.store
+	e.mutex.RUnlock()
+
+	if store != nil && len(notification.Channels) > 0 {
+		if err := store.Save(ctx, notification); err != nil {
+			log.Printf("Warning: Failed to persist notification %s: %v", notification.ID, err)
+		}
+	}
+	return e.deliver(ctx, notification)
+}
+
+// deliver sends notification to all specified channels, marking each
+// successful delivery in the store. Missing and disabled channels stay
+// pending so a replay can deliver once they are registered.
+func (e *NotificationEngine) deliver(ctx context.Context, notification *Notification) error {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 
@@ -198,6 +331,11 @@ func (e *NotificationEngine) sendToChannels(ctx context.Context, notification *N
 			log.Printf("Failed to send notification via %s: %v", channelName, err)
 		} else {
 			log.Printf("Notification sent via %s: %s", channelName, notification.Title)
+			if e.store != nil {
+				if err := e.store.MarkDelivered(ctx, notification.ID, channelName); err != nil {
+					log.Printf("Warning: Failed to mark notification %s delivered via %s: %v", notification.ID, channelName, err)
+				}
+			}
 		}
 	}
 
@@ -302,6 +440,47 @@ func (e *NotificationEngine) GetChannelStats() map[string]interface{} {
 	return stats
 }
 
+// TestChannels checks every registered channel concurrently and returns the
+// results sorted by name. Disabled channels are reported as misconfigured;
+// enabled channels without a check are assumed healthy.
+func (e *NotificationEngine) TestChannels(ctx context.Context) []ChannelHealth {
+	e.mutex.RLock()
+	channels := make([]NotificationChannel, 0, len(e.channels))
+	for _, channel := range e.channels {
+		channels = append(channels, channel)
+	}
+	e.mutex.RUnlock()
+
+	results := make([]ChannelHealth, len(channels))
+	var wg sync.WaitGroup
+	for i, channel := range channels {
+		results[i] = ChannelHealth{Name: channel.GetName(), Enabled: channel.IsEnabled()}
+		if !channel.IsEnabled() {
+			results[i].Error = "channel disabled: configuration incomplete"
+			continue
+		}
+		tester, ok := channel.(ChannelTester)
+		if !ok {
+			results[i].Healthy = true
+			continue
+		}
+		wg.Add(1)
+		go func(result *ChannelHealth, tester ChannelTester) {
+			defer wg.Done()
+			result.Tested = true
+			if err := tester.TestChannel(ctx); err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.Healthy = true
+		}(&results[i], tester)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
 func (e *NotificationEngine) countActiveRules() int {
 	count := 0
 	for _, rule := range e.rules {
@@ -361,6 +540,36 @@ func (c *SlackChannel) Send(ctx context.Context, notification *Notification) err
 	return nil
 }
 
+// TestChannel posts an empty payload to the webhook. Slack rejects it with
+// 400 when the webhook is valid, and with 403, 404 or 410 when it is not,
+// so nothing is posted to the channel.
+func (c *SlackChannel) TestChannel(ctx context.Context) error {
+	if !c.enabled {
+		return fmt.Errorf("slack channel disabled")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhook, strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("invalid slack webhook: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusBadRequest:
+		return nil
+	case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		return fmt.Errorf("slack rejected the webhook with status %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+}
+
 func (c *SlackChannel) GetName() string {
 	return c.name
 }
@@ -441,6 +650,42 @@ func (c *EmailChannel) Send(ctx context.Context, notification *Notification) err
 	return smtp.SendMail(addr, auth, c.from, []string{to}, []byte(msg))
 }
 
+// TestChannel connects to the SMTP server and logs in without sending mail
+func (c *EmailChannel) TestChannel(ctx context.Context) error {
+	if !c.enabled {
+		return fmt.Errorf("email channel disabled")
+	}
+
+	addr := fmt.Sprintf("%s:%d", c.smtpServer, c.port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp server unreachable: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.smtpServer)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.smtpServer}); err != nil {
+			return fmt.Errorf("smtp STARTTLS failed: %v", err)
+		}
+	}
+	if ok, _ := client.Extension("AUTH"); ok {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.smtpServer)); err != nil {
+			return fmt.Errorf("smtp login failed: %v", err)
+		}
+	}
+	return client.Quit()
+}
+
 func (c *EmailChannel) GetName() string {
 	return c.name
 }
@@ -500,6 +745,30 @@ func (c *DiscordChannel) Send(ctx context.Context, notification *Notification) e
 	return nil
 }
 
+// TestChannel fetches the webhook, which Discord answers with the webhook's
+// details without posting a message
+func (c *DiscordChannel) TestChannel(ctx context.Context) error {
+	if !c.enabled {
+		return fmt.Errorf("discord channel disabled")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.webhook, nil)
+	if err != nil {
+		return fmt.Errorf("invalid discord webhook: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discord rejected the webhook with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (c *DiscordChannel) GetName() string {
 	return c.name
 }
@@ -0,0 +1,222 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+)
+
+// recordingChannel counts the notifications it is asked to send
+type recordingChannel struct {
+	mu   sync.Mutex
+	sent []*Notification
+}
+
+func (c *recordingChannel) Send(ctx context.Context, notification *Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, notification)
+	return nil
+}
+
+func (c *recordingChannel) GetName() string                   { return "recording" }
+func (c *recordingChannel) IsEnabled() bool                   { return true }
+func (c *recordingChannel) GetConfig() map[string]interface{} { return nil }
+
+func (c *recordingChannel) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+func TestNotificationEngine_DedupWindowExpires(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(start)
+
+	engine := NewNotificationEngine()
+	engine.SetClock(mock)
+	engine.SetDedupWindow(time.Minute)
+
+	channel := &recordingChannel{}
+	if err := engine.RegisterChannel(channel); err != nil {
+		t.Fatalf("Failed to register channel: %v", err)
+	}
+
+	send := func(title string) {
+		n := &Notification{Title: title, Message: "disk full", Type: NotificationTypeError, Channels: []string{"recording"}}
+		if err := engine.SendNotification(context.Background(), n); err != nil {
+			t.Fatalf("Failed to send notification: %v", err)
+		}
+	}
+
+	send("worker-1")
+	send("worker-1")
+	send("worker-2")
+	if got := channel.count(); got != 2 {
+		t.Fatalf("Expected the repeat to be suppressed, got %d sends", got)
+	}
+
+	mock.Advance(30 * time.Second)
+	send("worker-1")
+	if got := channel.count(); got != 2 {
+		t.Fatalf("Expected a repeat within the window to be suppressed, got %d sends", got)
+	}
+
+	mock.Advance(30 * time.Second)
+	send("worker-1")
+	if got := channel.count(); got != 3 {
+		t.Fatalf("Expected the notification to be sent again once the window expired, got %d sends", got)
+	}
+	if last := channel.sent[2]; !last.CreatedAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected CreatedAt from the mock clock, got %v", last.CreatedAt)
+	}
+}
+
+// flakyChannel fails every send while down
+type flakyChannel struct {
+	recordingChannel
+	name string
+	down bool
+}
+
+func (c *flakyChannel) Send(ctx context.Context, notification *Notification) error {
+	if c.down {
+		return errors.New("connection refused")
+	}
+	return c.recordingChannel.Send(ctx, notification)
+}
+
+func (c *flakyChannel) GetName() string { return c.name }
+
+func TestNotificationEngine_PersistsUndelivered(t *testing.T) {
+	store := NewMemoryStore()
+	engine := NewNotificationEngine()
+	engine.SetStore(store)
+
+	slack := &flakyChannel{name: "slack"}
+	email := &flakyChannel{name: "email", down: true}
+	for _, channel := range []NotificationChannel{slack, email} {
+		if err := engine.RegisterChannel(channel); err != nil {
+			t.Fatalf("Failed to register channel: %v", err)
+		}
+	}
+
+	delivered := &Notification{Title: "build passed", Type: NotificationTypeSuccess, Channels: []string{"slack"}}
+	if err := engine.SendNotification(context.Background(), delivered); err != nil {
+		t.Fatalf("Failed to send notification: %v", err)
+	}
+	failed := &Notification{Title: "worker lost", Type: NotificationTypeError, Channels: []string{"slack", "email"}}
+	if err := engine.SendNotification(context.Background(), failed); err == nil {
+		t.Fatal("Expected an error from the failing channel")
+	}
+
+	pending, err := store.Pending(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load pending notifications: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != failed.ID {
+		t.Fatalf("Expected only the failed notification to be pending, got %v", pending)
+	}
+	if channels := pending[0].Channels; len(channels) != 1 || channels[0] != "email" {
+		t.Errorf("Expected only the email channel to be pending, got %v", channels)
+	}
+}
+
+func TestNotificationEngine_ReplayPendingAfterRestart(t *testing.T) {
+	store := NewMemoryStore()
+	before := NewNotificationEngine()
+	before.SetStore(store)
+	if err := before.RegisterChannel(&flakyChannel{name: "slack", down: true}); err != nil {
+		t.Fatalf("Failed to register channel: %v", err)
+	}
+	if err := before.SendNotification(context.Background(), &Notification{
+		Title:    "task dead-lettered",
+		Type:     NotificationTypeError,
+		Channels: []string{"slack"},
+		Metadata: map[string]interface{}{"attempts": 3},
+	}); err == nil {
+		t.Fatal("Expected the send to fail while the channel is down")
+	}
+
+	// A new engine sharing the store stands in for the restarted server
+	after := NewNotificationEngine()
+	after.SetStore(store)
+	slack := &flakyChannel{name: "slack"}
+	if err := after.RegisterChannel(slack); err != nil {
+		t.Fatalf("Failed to register channel: %v", err)
+	}
+
+	replayed, err := after.ReplayPending(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if replayed != 1 || slack.count() != 1 {
+		t.Fatalf("Expected one replayed delivery, got %d replayed and %d sent", replayed, slack.count())
+	}
+	if sent := slack.sent[0]; sent.Title != "task dead-lettered" || sent.Metadata["attempts"] != 3 {
+		t.Errorf("Unexpected replayed notification: %+v", sent)
+	}
+
+	// Delivered notifications are not sent again
+	if replayed, err := after.ReplayPending(context.Background()); err != nil || replayed != 0 {
+		t.Errorf("Expected nothing left to replay, got %d (%v)", replayed, err)
+	}
+	if slack.count() != 1 {
+		t.Errorf("Expected no duplicate deliveries, got %d", slack.count())
+	}
+}
+
+func TestNotificationEngine_TestChannels(t *testing.T) {
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slack rejects an empty payload on a valid webhook
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("no_text"))
+	}))
+	defer slackServer.Close()
+
+	discordServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Unknown Webhook"}`, http.StatusNotFound)
+	}))
+	defer discordServer.Close()
+
+	engine := NewNotificationEngine()
+	for _, channel := range []NotificationChannel{
+		NewSlackChannel(slackServer.URL, "#alerts", "helix"),
+		NewDiscordChannel(discordServer.URL),
+		NewEmailChannel("", 587, "", "", ""),
+		&recordingChannel{},
+	} {
+		if err := engine.RegisterChannel(channel); err != nil {
+			t.Fatalf("Failed to register channel: %v", err)
+		}
+	}
+
+	results := engine.TestChannels(context.Background())
+	byName := make(map[string]ChannelHealth)
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+	if len(results) != 4 || results[0].Name != "discord" {
+		t.Fatalf("Expected 4 results sorted by name, got %+v", results)
+	}
+
+	if slack := byName["slack"]; !slack.Healthy || !slack.Tested {
+		t.Errorf("Expected slack to be reachable, got %+v", slack)
+	}
+	if discord := byName["discord"]; discord.Healthy || !strings.Contains(discord.Error, "404") {
+		t.Errorf("Expected discord to report the rejected webhook, got %+v", discord)
+	}
+	if email := byName["email"]; email.Healthy || email.Enabled || email.Tested {
+		t.Errorf("Expected the unconfigured email channel to be reported without testing, got %+v", email)
+	}
+	if recording := byName["recording"]; !recording.Healthy || recording.Tested {
+		t.Errorf("Expected a channel without a check to be assumed healthy, got %+v", recording)
+	}
+}
@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+)
+
+// Store persists notifications until every channel has delivered them, so
+// notifications still pending when the process stops are sent after a restart
+type Store interface {
+	// Save records a notification as pending on each of its channels
+	Save(ctx context.Context, notification *Notification) error
+	// MarkDelivered records that a channel delivered the notification
+	MarkDelivered(ctx context.Context, id uuid.UUID, channel string) error
+	// Pending returns undelivered notifications, oldest first, with Channels
+	// narrowed to the channels that have not delivered them yet
+	Pending(ctx context.Context) ([]*Notification, error)
+}
+
+// NewStore returns a database store when a database is configured and an
+// in-memory store otherwise
+func NewStore(db *database.Database) Store {
+	if db.IsConfigured() {
+		return NewDatabaseStore(db)
+	}
+	return NewMemoryStore()
+}
+
+// MemoryStore keeps pending notifications in memory, for tests and
+// deployments without a database
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending map[uuid.UUID]*Notification
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{pending: make(map[uuid.UUID]*Notification)}
+}
+
+// Save records a notification as pending on each of its channels
+func (s *MemoryStore) Save(ctx context.Context, notification *Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *notification
+	copied.Channels = append([]string(nil), notification.Channels...)
+	s.pending[notification.ID] = &copied
+	return nil
+}
+
+// MarkDelivered records that a channel delivered the notification
+func (s *MemoryStore) MarkDelivered(ctx context.Context, id uuid.UUID, channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, exists := s.pending[id]
+	if !exists {
+		return nil
+	}
+	remaining := notification.Channels[:0]
+	for _, name := range notification.Channels {
+		if name != channel {
+			remaining = append(remaining, name)
+		}
+	}
+	notification.Channels = remaining
+	if len(remaining) == 0 {
+		delete(s.pending, id)
+	}
+	return nil
+}
+
+// Pending returns undelivered notifications, oldest first
+func (s *MemoryStore) Pending(ctx context.Context) ([]*Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make([]*Notification, 0, len(s.pending))
+	for _, notification := range s.pending {
+		copied := *notification
+		copied.Channels = append([]string(nil), notification.Channels...)
+		pending = append(pending, &copied)
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending, nil
+}
+
+// DatabaseStore persists pending notifications in the notification_outbox table
+type DatabaseStore struct {
+	db *database.Database
+}
+
+// NewDatabaseStore creates a store backed by the database
+func NewDatabaseStore(db *database.Database) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+// Save records a notification as pending on each of its channels
+func (s *DatabaseStore) Save(ctx context.Context, notification *Notification) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	channels, err := json.Marshal(notification.Channels)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification channels: %v", err)
+	}
+	metadata, err := json.Marshal(notification.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification metadata: %v", err)
+	}
+
+	_, err = s.db.Pool.Exec(ctx, `
+		INSERT INTO notification_outbox (
+			id, title, message, type, priority, pending_channels, metadata, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET pending_channels = EXCLUDED.pending_channels, delivered_at = NULL
+	`, notification.ID, notification.Title, notification.Message, string(notification.Type),
+		string(notification.Priority), channels, metadata, notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store notification: %v", err)
+	}
+	return nil
+}
+
+// MarkDelivered removes the channel from the notification's pending channels
+// and marks the notification delivered once none remain
+func (s *DatabaseStore) MarkDelivered(ctx context.Context, id uuid.UUID, channel string) error {
+	if !s.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	_, err := s.db.Pool.Exec(ctx, `
+		UPDATE notification_outbox
+		SET pending_channels = pending_channels - $2::text,
+			delivered_at = CASE WHEN pending_channels - $2::text = '[]'::jsonb THEN NOW() END
+		WHERE id = $1 AND delivered_at IS NULL
+	`, id, channel)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification delivered: %v", err)
+	}
+	return nil
+}
+
+// Pending returns undelivered notifications, oldest first
+func (s *DatabaseStore) Pending(ctx context.Context) ([]*Notification, error) {
+	if !s.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, title, message, type, priority, pending_channels, metadata, created_at
+		FROM notification_outbox
+		WHERE delivered_at IS NULL
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending notifications: %v", err)
+	}
+	defer rows.Close()
+
+	var pending []*Notification
+	for rows.Next() {
+		var (
+			notification       Notification
+			notificationType   string
+			priority           string
+			channels, metadata []byte
+		)
+		if err := rows.Scan(&notification.ID, &notification.Title, &notification.Message, &notificationType,
+			&priority, &channels, &metadata, &notification.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %v", err)
+		}
+		notification.Type = NotificationType(notificationType)
+		notification.Priority = NotificationPriority(priority)
+		if err := json.Unmarshal(channels, &notification.Channels); err != nil {
+			return nil, fmt.Errorf("failed to decode notification channels: %v", err)
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &notification.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode notification metadata: %v", err)
+			}
+		}
+		pending = append(pending, &notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification rows: %v", err)
+	}
+	return pending, nil
+}
@@ -0,0 +1,94 @@
+// Package paths expands the ~ and environment variable shorthands users write
+// in configured file paths.
+package paths
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Expand expands environment variables ($VAR or ${VAR}) and a leading ~ or
+// ~user in a file path. Undefined variables and unknown users are left as
+// written, so later errors still name the path the user wrote.
+func Expand(path string) string {
+	if path == "" {
+		return path
+	}
+
+	path = expandEnv(path)
+
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexRune(name, filepath.Separator); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return path
+		}
+		home = u.HomeDir
+	}
+
+	if rest == "" {
+		return home
+	}
+	return filepath.Join(home, rest)
+}
+
+// expandEnv replaces the defined environment variables in path
+func expandEnv(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); {
+		if path[i] != '$' {
+			b.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		name, width := envName(path[i+1:])
+		if name == "" {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(path[i : i+1+width])
+		}
+		i += 1 + width
+	}
+	return b.String()
+}
+
+// envName returns the variable name at the start of s, written as NAME or
+// {NAME}, and the length of the text naming it
+func envName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+
+	n := 0
+	for n < len(s) && (s[n] == '_' || 'a' <= s[n] && s[n] <= 'z' || 'A' <= s[n] && s[n] <= 'Z' || '0' <= s[n] && s[n] <= '9') {
+		n++
+	}
+	return s[:n], n
+}
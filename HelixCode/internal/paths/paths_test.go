@@ -0,0 +1,55 @@
+package paths
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+// TestExpand tests tilde and environment variable expansion
+func TestExpand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("No home directory: %v", err)
+	}
+	t.Setenv("HELIX_TEST_DIR", "/opt/helix")
+	t.Setenv("HELIX_TEST_TILDE", "~/models")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"/absolute/path", "/absolute/path"},
+		{"relative/path", "relative/path"},
+		{"~", home},
+		{"~/models/llama.gguf", filepath.Join(home, "models/llama.gguf")},
+		{"$HELIX_TEST_DIR/models", "/opt/helix/models"},
+		{"${HELIX_TEST_DIR}/keys/id_rsa", "/opt/helix/keys/id_rsa"},
+		{"$HELIX_TEST_TILDE/7b.gguf", filepath.Join(home, "models/7b.gguf")},
+		{"$HELIX_TEST_UNDEFINED/file", "$HELIX_TEST_UNDEFINED/file"},
+		{"${HELIX_TEST_UNDEFINED}/file", "${HELIX_TEST_UNDEFINED}/file"},
+		{"/cost/$5", "/cost/$5"},
+		{"/price$/${", "/price$/${"},
+		{"~no-such-user-helix/file", "~no-such-user-helix/file"},
+		{"/data/~backup", "/data/~backup"},
+	}
+
+	for _, tt := range tests {
+		if got := Expand(tt.input); got != tt.expected {
+			t.Errorf("Expand(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	// ~user expands to that user's home directory
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("Cannot look up current user: %v", err)
+	}
+	if got := Expand("~" + current.Username + "/keys"); got != filepath.Join(current.HomeDir, "keys") {
+		t.Errorf("Expand(~%s/keys) = %q, want %q", current.Username, got, filepath.Join(current.HomeDir, "keys"))
+	}
+
+	t.Log("✅ Path expansion test passed")
+}
@@ -2,24 +2,64 @@ package project
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
+// ErrNotFound is returned when a project does not exist
+var ErrNotFound = errors.New("project not found")
+
 // Project represents a development project
 type Project struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Path        string    `json:"path"`
-	Type        string    `json:"type"` // "go", "node", "python", "rust", etc.
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Metadata    Metadata  `json:"metadata"`
-	Active      bool      `json:"active"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Path          string    `json:"path"`
+	Type          string    `json:"type"` // "go", "node", "python", "rust", etc.
+	OwnerID       string    `json:"owner_id"`
+	Collaborators []string  `json:"collaborators,omitempty"` // Users the owner shares the project with
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Metadata      Metadata  `json:"metadata"`
+	Active        bool      `json:"active"`
+}
+
+// IsOwner reports whether userID owns the project
+func (p *Project) IsOwner(userID string) bool {
+	return p.OwnerID != "" && p.OwnerID == userID
+}
+
+// CanAccess reports whether userID owns the project or collaborates on it
+func (p *Project) CanAccess(userID string) bool {
+	if p.IsOwner(userID) {
+		return true
+	}
+	for _, collaborator := range p.Collaborators {
+		if collaborator == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy of the project, so callers outside the manager's
+// lock never share its slices or maps
+func (p *Project) clone() *Project {
+	copied := *p
+	copied.Collaborators = append([]string(nil), p.Collaborators...)
+	copied.Metadata.Dependencies = append([]string(nil), p.Metadata.Dependencies...)
+	if p.Metadata.Environment != nil {
+		copied.Metadata.Environment = make(map[string]string, len(p.Metadata.Environment))
+		for key, value := range p.Metadata.Environment {
+			copied.Metadata.Environment[key] = value
+		}
+	}
+	return &copied
 }
 
 // Metadata contains project-specific configuration
@@ -33,7 +73,8 @@ type Metadata struct {
 	LanguageVersion string            `json:"language_version"`
 }
 
-// Manager handles project lifecycle and operations
+// Manager handles project lifecycle and operations. Projects it returns are
+// copies, so changing them does not change the managed project.
 type Manager struct {
 	mu           sync.RWMutex
 	projects     map[string]*Project
@@ -47,8 +88,8 @@ func NewManager() *Manager {
 	}
 }
 
-// CreateProject creates a new project
-func (m *Manager) CreateProject(ctx context.Context, name, description, path, projectType string) (*Project, error) {
+// CreateProject creates a new project owned by ownerID
+func (m *Manager) CreateProject(ctx context.Context, name, description, path, projectType, ownerID string) (*Project, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -66,6 +107,7 @@ func (m *Manager) CreateProject(ctx context.Context, name, description, path, pr
 		Description: description,
 		Path:        path,
 		Type:        projectType,
+		OwnerID:     ownerID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Metadata:    Metadata{
@@ -80,7 +122,7 @@ func (m *Manager) CreateProject(ctx context.Context, name, description, path, pr
 	}
 
 	m.projects[id] = project
-	return project, nil
+	return project.clone(), nil
 }
 
 // GetProject retrieves a project by ID
@@ -88,40 +130,83 @@ func (m *Manager) GetProject(ctx context.Context, id string) (*Project, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Check cache first
-	if project, exists := m.projects[id]; exists {
-		return project, nil
+	project, err := m.project(id)
+	if err != nil {
+		return nil, err
 	}
+	return project.clone(), nil
+}
 
-	// Check if project exists in memory
+// project returns the managed project with the given ID. The caller must
+// hold m.mu.
+func (m *Manager) project(id string) (*Project, error) {
 	project, exists := m.projects[id]
 	if !exists {
-		return nil, fmt.Errorf("project not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
-
 	return project, nil
 }
 
-// ListProjects returns all projects
-func (m *Manager) ListProjects(ctx context.Context) ([]*Project, error) {
+// ListProjects returns the projects userID owns or collaborates on
+func (m *Manager) ListProjects(ctx context.Context, userID string) ([]*Project, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Return all projects from memory
 	var projects []*Project
 	for _, project := range m.projects {
-		projects = append(projects, project)
+		if project.CanAccess(userID) {
+			projects = append(projects, project.clone())
+		}
 	}
 
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].CreatedAt.After(projects[j].CreatedAt)
+	})
 	return projects, nil
 }
 
+// UpdateProject changes a project's name and description, keeping the
+// current value of any that are empty
+func (m *Manager) UpdateProject(ctx context.Context, id, name, description string) (*Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, exists := m.projects[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if name != "" {
+		project.Name = name
+	}
+	if description != "" {
+		project.Description = description
+	}
+	project.UpdatedAt = time.Now()
+	return project.clone(), nil
+}
+
+// SetCollaborators replaces the users a project is shared with
+func (m *Manager) SetCollaborators(ctx context.Context, id string, collaborators []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, exists := m.projects[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	project.Collaborators = append([]string(nil), collaborators...)
+	project.UpdatedAt = time.Now()
+	return nil
+}
+
 // SetActiveProject sets the currently active project
 func (m *Manager) SetActiveProject(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	project, err := m.GetProject(ctx, id)
+	project, err := m.project(id)
 	if err != nil {
 		return err
 	}
@@ -145,14 +230,13 @@ func (m *Manager) GetActiveProject(ctx context.Context) (*Project, error) {
 	defer m.mu.RUnlock()
 
 	if m.activeProject != nil {
-		return m.activeProject, nil
+		return m.activeProject.clone(), nil
 	}
 
 	// Try to find active project in memory
 	for _, project := range m.projects {
 		if project.Active {
-			m.activeProject = project
-			return project, nil
+			return project.clone(), nil
 		}
 	}
 
@@ -164,7 +248,7 @@ func (m *Manager) UpdateProjectMetadata(ctx context.Context, id string, metadata
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	project, err := m.GetProject(ctx, id)
+	project, err := m.project(id)
 	if err != nil {
 		return err
 	}
@@ -180,6 +264,10 @@ func (m *Manager) DeleteProject(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if _, exists := m.projects[id]; !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
 	if m.activeProject != nil && m.activeProject.ID == id {
 		m.activeProject = nil
 	}
@@ -41,6 +41,7 @@ func (m *DatabaseManager) CreateProject(ctx context.Context, name, description,
 		Description: description,
 		Path:        path,
 		Type:        projectType,
+		OwnerID:     ownerUUID.String(),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Metadata:    metadata,
@@ -74,40 +75,30 @@ func (m *DatabaseManager) CreateProject(ctx context.Context, name, description,
 	return project, nil
 }
 
-// GetProject retrieves a project by ID from database
-func (m *DatabaseManager) GetProject(ctx context.Context, id string) (*Project, error) {
-	projectID, err := uuid.Parse(id)
-	if err != nil {
-		return nil, fmt.Errorf("invalid project ID: %v", err)
-	}
-
-	query := `
-		SELECT id, name, description, owner_id, workspace_path, config, status, created_at, updated_at
-		FROM projects
-		WHERE id = $1 AND status = 'active'
-	`
+// projectColumns are the columns read by scanProject
+const projectColumns = `
+	p.id, p.name, p.description, p.owner_id, p.workspace_path, p.config, p.created_at, p.updated_at,
+	ARRAY(SELECT c.user_id::text FROM project_collaborators c WHERE c.project_id = p.id ORDER BY c.user_id)
+`
 
+// scanProject reads a project selected with projectColumns
+func (m *DatabaseManager) scanProject(row pgx.Row) (*Project, error) {
 	var (
 		dbID          uuid.UUID
 		name          string
-		description   string
+		description   *string
 		ownerID       uuid.UUID
-		workspacePath string
+		workspacePath *string
 		config        map[string]interface{}
-		status        string
 		createdAt     time.Time
 		updatedAt     time.Time
+		collaborators []string
 	)
 
-	err = m.db.Pool.QueryRow(ctx, query, projectID).Scan(
-		&dbID, &name, &description, &ownerID, &workspacePath, &config, &status, &createdAt, &updatedAt,
-	)
-
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("project not found: %s", id)
-		}
-		return nil, fmt.Errorf("failed to get project from database: %v", err)
+	if err := row.Scan(
+		&dbID, &name, &description, &ownerID, &workspacePath, &config, &createdAt, &updatedAt, &collaborators,
+	); err != nil {
+		return nil, err
 	}
 
 	// Extract type and metadata from config
@@ -116,35 +107,64 @@ func (m *DatabaseManager) GetProject(ctx context.Context, id string) (*Project,
 	metadata := m.convertToMetadata(metadataMap)
 
 	project := &Project{
-		ID:          dbID.String(),
-		Name:        name,
-		Description: description,
-		Path:        workspacePath,
-		Type:        projectType,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
-		Metadata:    metadata,
-		Active:      false, // This would need to be tracked separately
+		ID:            dbID.String(),
+		Name:          name,
+		Type:          projectType,
+		OwnerID:       ownerID.String(),
+		Collaborators: collaborators,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		Metadata:      metadata,
+		Active:        false, // This would need to be tracked separately
+	}
+	if description != nil {
+		project.Description = *description
+	}
+	if workspacePath != nil {
+		project.Path = *workspacePath
 	}
 
 	return project, nil
 }
 
-// ListProjects returns all projects for a user from database
-func (m *DatabaseManager) ListProjects(ctx context.Context, ownerID string) ([]*Project, error) {
-	ownerUUID, err := uuid.Parse(ownerID)
+// GetProject retrieves a project by ID from database
+func (m *DatabaseManager) GetProject(ctx context.Context, id string) (*Project, error) {
+	projectID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid owner ID: %v", err)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	query := `SELECT ` + projectColumns + ` FROM projects p WHERE p.id = $1 AND p.status = 'active'`
+
+	project, err := m.scanProject(m.db.Pool.QueryRow(ctx, query, projectID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get project from database: %v", err)
+	}
+
+	return project, nil
+}
+
+// ListProjects returns the projects a user owns or collaborates on from database
+func (m *DatabaseManager) ListProjects(ctx context.Context, userID string) ([]*Project, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %v", err)
 	}
 
 	query := `
-		SELECT id, name, description, owner_id, workspace_path, config, status, created_at, updated_at
-		FROM projects
-		WHERE owner_id = $1 AND status = 'active'
-		ORDER BY created_at DESC
+		SELECT ` + projectColumns + `
+		FROM projects p
+		WHERE p.status = 'active' AND (
+			p.owner_id = $1 OR
+			EXISTS (SELECT 1 FROM project_collaborators c WHERE c.project_id = p.id AND c.user_id = $1)
+		)
+		ORDER BY p.created_at DESC
 	`
 
-	rows, err := m.db.Pool.Query(ctx, query, ownerUUID)
+	rows, err := m.db.Pool.Query(ctx, query, userUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query projects: %v", err)
 	}
@@ -152,41 +172,10 @@ func (m *DatabaseManager) ListProjects(ctx context.Context, ownerID string) ([]*
 
 	var projects []*Project
 	for rows.Next() {
-		var (
-			dbID          uuid.UUID
-			name          string
-			description   string
-			ownerID       uuid.UUID
-			workspacePath string
-			config        map[string]interface{}
-			status        string
-			createdAt     time.Time
-			updatedAt     time.Time
-		)
-
-		if err := rows.Scan(
-			&dbID, &name, &description, &ownerID, &workspacePath, &config, &status, &createdAt, &updatedAt,
-		); err != nil {
+		project, err := m.scanProject(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan project row: %v", err)
 		}
-
-		// Extract type and metadata from config
-		projectType, _ := config["type"].(string)
-		metadataMap, _ := config["metadata"].(map[string]interface{})
-		metadata := m.convertToMetadata(metadataMap)
-
-		project := &Project{
-			ID:          dbID.String(),
-			Name:        name,
-			Description: description,
-			Path:        workspacePath,
-			Type:        projectType,
-			CreatedAt:   createdAt,
-			UpdatedAt:   updatedAt,
-			Metadata:    metadata,
-			Active:      false,
-		}
-
 		projects = append(projects, project)
 	}
 
@@ -197,6 +186,71 @@ func (m *DatabaseManager) ListProjects(ctx context.Context, ownerID string) ([]*
 	return projects, nil
 }
 
+// UpdateProject changes a project's name and description in database,
+// keeping the current value of any that are empty
+func (m *DatabaseManager) UpdateProject(ctx context.Context, id, name, description string) (*Project, error) {
+	projectID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	query := `
+		UPDATE projects
+		SET name = COALESCE(NULLIF($1, ''), name),
+			description = COALESCE(NULLIF($2, ''), description),
+			updated_at = NOW()
+		WHERE id = $3 AND status = 'active'
+	`
+
+	result, err := m.db.Pool.Exec(ctx, query, name, description, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return m.GetProject(ctx, id)
+}
+
+// SetCollaborators replaces the users a project is shared with in database
+func (m *DatabaseManager) SetCollaborators(ctx context.Context, id string, collaborators []string) error {
+	projectID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		userID, err := uuid.Parse(collaborator)
+		if err != nil {
+			return fmt.Errorf("invalid collaborator ID %q: %v", collaborator, err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	tx, err := m.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM project_collaborators WHERE project_id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to clear project collaborators: %v", err)
+	}
+	for _, userID := range userIDs {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO project_collaborators (project_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, projectID, userID); err != nil {
+			return fmt.Errorf("failed to add project collaborator: %v", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
 // UpdateProjectMetadata updates project metadata in database
 func (m *DatabaseManager) UpdateProjectMetadata(ctx context.Context, id string, metadata Metadata) error {
 	projectID, err := uuid.Parse(id)
@@ -249,7 +303,7 @@ func (m *DatabaseManager) DeleteProject(ctx context.Context, id string) error {
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("project not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
 	return nil
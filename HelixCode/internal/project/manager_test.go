@@ -0,0 +1,57 @@
+package project
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestManager_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+	created, err := manager.CreateProject(ctx, "app", "", t.TempDir(), "", "owner")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if err := manager.SetCollaborators(ctx, created.ID, []string{"alice"}); err != nil {
+		t.Fatalf("Failed to set collaborators: %v", err)
+	}
+
+	got, err := manager.GetProject(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get project: %v", err)
+	}
+	got.OwnerID = "mallory"
+	got.Collaborators[0] = "mallory"
+	again, _ := manager.GetProject(ctx, created.ID)
+	if again.OwnerID != "owner" || again.Collaborators[0] != "alice" {
+		t.Errorf("Expected changes to a returned project not to reach the manager, got %+v", again)
+	}
+
+	// Access checks on returned projects may run while collaborators change
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			manager.SetCollaborators(ctx, created.ID, []string{"alice", "bob"})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if project, err := manager.GetProject(ctx, created.ID); err == nil {
+				project.CanAccess("alice")
+			}
+			manager.ListProjects(ctx, "alice")
+		}
+	}()
+	wg.Wait()
+
+	if err := manager.SetActiveProject(ctx, created.ID); err != nil {
+		t.Fatalf("Failed to set active project: %v", err)
+	}
+	if active, err := manager.GetActiveProject(ctx); err != nil || active.ID != created.ID {
+		t.Errorf("Expected %s to be active, got %v (%v)", created.ID, active, err)
+	}
+}
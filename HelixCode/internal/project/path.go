@@ -0,0 +1,69 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidPath is returned for project paths that are unsafe or outside
+// the allowed root
+var ErrInvalidPath = errors.New("invalid project path")
+
+// CleanPath validates a project path and returns it as an absolute path with
+// symlinks resolved. Paths containing ".." are rejected outright, and when
+// root is set the resolved path must be root or lie beneath it, so that a
+// symlink cannot lead out of it either.
+func CleanPath(path, root string) (string, error) {
+	if strings.TrimSpace(path) == "" || strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("%w: path is empty", ErrInvalidPath)
+	}
+	for _, element := range strings.FieldsFunc(path, isSeparator) {
+		if element == ".." {
+			return "", fmt.Errorf("%w: %s contains \"..\"", ErrInvalidPath, path)
+		}
+	}
+
+	resolved, err := canonicalPath(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+	if root == "" {
+		return resolved, nil
+	}
+
+	resolvedRoot, err := canonicalPath(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid project root: %v", err)
+	}
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", ErrInvalidPath, path, root)
+	}
+	return resolved, nil
+}
+
+// canonicalPath returns the absolute path of an existing file with symlinks
+// resolved
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s does not exist", path)
+		}
+		return "", err
+	}
+	return resolved, nil
+}
+
+// isSeparator reports whether r separates path elements. Both slashes are
+// checked so that "..\" is caught on every platform.
+func isSeparator(r rune) bool {
+	return r == '/' || r == '\\' || r == filepath.Separator
+}
@@ -0,0 +1,62 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	app := filepath.Join(root, "app")
+	if err := os.Mkdir(app, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	escape := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Skipf("Symlinks unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		root string
+		want string
+	}{
+		{name: "inside root", path: app, root: root, want: app},
+		{name: "root itself", path: root, root: root, want: root},
+		{name: "redundant elements", path: root + "/./app/", root: root, want: app},
+		{name: "any path without root", path: outside, want: outside},
+		{name: "parent element", path: app + "/../app", root: root},
+		{name: "backslash parent element", path: `app\..\..`},
+		{name: "outside root", path: outside, root: root},
+		{name: "symlink out of root", path: escape, root: root},
+		{name: "missing", path: filepath.Join(root, "missing"), root: root},
+		{name: "empty", path: " "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanPath(tt.path, tt.root)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidPath) {
+					t.Fatalf("Expected ErrInvalidPath, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %s to be accepted: %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
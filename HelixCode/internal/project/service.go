@@ -0,0 +1,33 @@
+package project
+
+import (
+	"context"
+
+	"dev.helix.code/internal/database"
+)
+
+// Service is the project API used by the HTTP handlers. It is implemented by
+// DatabaseManager for persistent storage and by Manager for deployments
+// without a database.
+type Service interface {
+	CreateProject(ctx context.Context, name, description, path, projectType, ownerID string) (*Project, error)
+	GetProject(ctx context.Context, id string) (*Project, error)
+	ListProjects(ctx context.Context, userID string) ([]*Project, error)
+	UpdateProject(ctx context.Context, id, name, description string) (*Project, error)
+	SetCollaborators(ctx context.Context, id string, collaborators []string) error
+	DeleteProject(ctx context.Context, id string) error
+}
+
+var (
+	_ Service = (*Manager)(nil)
+	_ Service = (*DatabaseManager)(nil)
+)
+
+// NewService returns a database-backed service when a database is configured
+// and an in-memory one otherwise
+func NewService(db *database.Database) Service {
+	if db.IsConfigured() {
+		return NewDatabaseManager(db)
+	}
+	return NewManager()
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/project"
+	"dev.helix.code/internal/task"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// mockAuthenticator accepts requests carrying its token
+type mockAuthenticator struct {
+	token string
+	user  *auth.User
+}
+
+func (m *mockAuthenticator) Authenticate(ctx context.Context, r *http.Request) (*auth.User, error) {
+	token, ok := auth.BearerToken(r)
+	if !ok {
+		return nil, auth.ErrNoCredentials
+	}
+	if token != m.token {
+		return nil, auth.ErrTokenInvalid
+	}
+	return m.user, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &auth.User{ID: uuid.New(), Username: "alice"}
+
+	router := gin.New()
+	router.Use(AuthMiddleware(&mockAuthenticator{token: "secret", user: user}))
+	router.GET("/whoami", func(c *gin.Context) {
+		ctxUser, ok := auth.UserFromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		actor := audit.ActorFromContext(c.Request.Context(), audit.SourceSystem)
+		c.String(http.StatusOK, ctxUser.Username+" "+actor.Name)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		body   string
+	}{
+		{name: "valid token", header: "Bearer secret", status: http.StatusOK, body: "alice alice"},
+		{name: "invalid token", header: "Bearer wrong", status: http.StatusUnauthorized},
+		{name: "no credentials", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, w.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized && strings.Contains(w.Body.String(), auth.ErrTokenInvalid.Error()) {
+				t.Errorf("Expected a generic 401 without the reason, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// testUsers issues JWTs for users it adds to the test server's repository
+type testUsers struct {
+	*auth.AuthService
+	repo *auth.MemoryAuthRepository
+}
+
+// GenerateJWT stores user as an active user, unless already stored, and
+// returns a JWT for them
+func (u *testUsers) GenerateJWT(user *auth.User) (string, error) {
+	ctx := context.Background()
+	if _, err := u.repo.GetUserByID(ctx, user.ID); errors.Is(err, auth.ErrUserNotFound) {
+		stored := *user
+		stored.IsActive = true
+		if stored.Email == "" {
+			stored.Email = user.ID.String() + "@example.com"
+		}
+		if err := u.repo.CreateUser(ctx, &stored, ""); err != nil {
+			return "", err
+		}
+	}
+	return u.AuthService.GenerateJWT(user)
+}
+
+// newAuthTestServer returns a server with in-memory users, tasks and API keys
+// that accepts JWTs from the returned users
+func newAuthTestServer(t *testing.T) (*Server, *testUsers) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := auth.NewMemoryAuthRepository()
+	jwtService := auth.NewAuthService(auth.DefaultConfig(), repo)
+	s := &Server{
+		router:      gin.New(),
+		tasks:       task.NewTaskManager(nil).Service(),
+		projects:    project.NewManager(),
+		authService: jwtService,
+		apiKeys:     auth.NewAPIKeyService(auth.NewMemoryAPIKeyStore(), repo),
+	}
+	s.authenticator = auth.NewChainAuthenticator(jwtService, s.apiKeys)
+	s.setupRoutes()
+	return s, &testUsers{AuthService: jwtService, repo: repo}
+}
+
+// serve sends a request through the server's router
+func serve(s *Server, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_RegisterLoginLogout(t *testing.T) {
+	s, _ := newAuthTestServer(t)
+
+	register := `{"username": "alice", "email": "alice@example.com", "password": "correct horse"}`
+	if w := serve(s, http.MethodPost, "/api/v1/auth/register", register, http.Header{}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected the user to be registered, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodPost, "/api/v1/auth/register", register, http.Header{}); w.Code != http.StatusConflict {
+		t.Errorf("Expected a second registration to conflict, got %d", w.Code)
+	}
+	short := `{"username": "bob", "email": "bob@example.com", "password": "short"}`
+	if w := serve(s, http.MethodPost, "/api/v1/auth/register", short, http.Header{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a short password to be rejected, got %d", w.Code)
+	}
+
+	wrong := `{"username": "alice", "password": "wrong password"}`
+	if w := serve(s, http.MethodPost, "/api/v1/auth/login", wrong, http.Header{}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong password to be rejected, got %d", w.Code)
+	}
+
+	w := serve(s, http.MethodPost, "/api/v1/auth/login", `{"username": "alice@example.com", "password": "correct horse"}`, http.Header{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected login to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var login struct {
+		Token string    `json:"token"`
+		User  auth.User `json:"user"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("Expected a session token, got %s (%v)", w.Body.String(), err)
+	}
+	if login.User.Username != "alice" {
+		t.Errorf("Expected the logged in user, got %+v", login.User)
+	}
+	withSession := http.Header{"Authorization": {"Bearer " + login.Token}}
+
+	if w := serve(s, http.MethodGet, "/api/v1/tasks", "", withSession); w.Code != http.StatusOK {
+		t.Errorf("Expected the session to authenticate, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodPost, "/api/v1/auth/logout", "", withSession); w.Code != http.StatusOK {
+		t.Fatalf("Expected logout to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodGet, "/api/v1/tasks", "", withSession); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the ended session to be rejected, got %d", w.Code)
+	}
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	s, jwtService := newAuthTestServer(t)
+	token, err := jwtService.GenerateJWT(&auth.User{ID: uuid.New(), Username: "alice"})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	withJWT := http.Header{"Authorization": {"Bearer " + token}}
+
+	w := serve(s, http.MethodPost, "/api/v1/api-keys", `{"name": "ci", "scope": "read"}`, withJWT)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the key to be minted, got %d: %s", w.Code, w.Body.String())
+	}
+	var minted struct {
+		Key    string      `json:"key"`
+		APIKey auth.APIKey `json:"api_key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &minted); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	withKey := http.Header{}
+	withKey.Set(auth.APIKeyHeader, minted.Key)
+
+	if w := serve(s, http.MethodGet, "/api/v1/tasks", "", withKey); w.Code != http.StatusOK {
+		t.Errorf("Expected the key to list tasks, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodPost, "/api/v1/tasks", `{"name": "x", "type": "building"}`, withKey); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a read-only key to be refused writes, got %d", w.Code)
+	}
+
+	if w := serve(s, http.MethodDelete, "/api/v1/api-keys/"+minted.APIKey.ID.String(), "", withJWT); w.Code != http.StatusOK {
+		t.Fatalf("Expected the key to be revoked, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodGet, "/api/v1/tasks", "", withKey); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestServer_RequireRole(t *testing.T) {
+	s, jwtService := newAuthTestServer(t)
+	bearer := func(role auth.Role) http.Header {
+		token, err := jwtService.GenerateJWT(&auth.User{ID: uuid.New(), Username: string(role), Role: role})
+		if err != nil {
+			t.Fatalf("Failed to generate JWT: %v", err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+	admin, user := bearer(auth.RoleAdmin), bearer(auth.RoleUser)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		path   string
+		status int
+	}{
+		{name: "admin manages workers", header: admin, path: "/api/v1/workers", status: http.StatusOK},
+		{name: "user denied workers", header: user, path: "/api/v1/workers", status: http.StatusForbidden},
+		{name: "user denied system", header: user, path: "/api/v1/system/stats", status: http.StatusForbidden},
+		{name: "user denied audit", header: user, path: "/api/v1/audit", status: http.StatusForbidden},
+		{name: "user lists tasks", header: user, path: "/api/v1/tasks", status: http.StatusOK},
+		{name: "admin lists tasks", header: admin, path: "/api/v1/tasks", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(s, http.MethodGet, tt.path, "", tt.header); w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// API keys act with the role of the user who minted them
+	w := serve(s, http.MethodPost, "/api/v1/api-keys", `{"name": "ci"}`, user)
+	var minted struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &minted); err != nil || minted.Key == "" {
+		t.Fatalf("Failed to mint key: %d %s", w.Code, w.Body.String())
+	}
+	withKey := http.Header{}
+	withKey.Set(auth.APIKeyHeader, minted.Key)
+	if w := serve(s, http.MethodGet, "/api/v1/workers", "", withKey); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a user's key to be denied workers, got %d", w.Code)
+	}
+}
+
+func TestServer_RoleChangeTakesEffect(t *testing.T) {
+	s, users := newAuthTestServer(t)
+	admin := &auth.User{ID: uuid.New(), Username: "admin", Role: auth.RoleAdmin}
+	token, err := users.GenerateJWT(admin)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	withJWT := http.Header{"Authorization": {"Bearer " + token}}
+
+	w := serve(s, http.MethodPost, "/api/v1/api-keys", `{"name": "ops"}`, withJWT)
+	var minted struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &minted); err != nil || minted.Key == "" {
+		t.Fatalf("Failed to mint key: %d %s", w.Code, w.Body.String())
+	}
+	withKey := http.Header{}
+	withKey.Set(auth.APIKeyHeader, minted.Key)
+	if w := serve(s, http.MethodGet, "/api/v1/workers", "", withKey); w.Code != http.StatusOK {
+		t.Fatalf("Expected an admin's key to manage workers, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Demoting the admin applies to the JWT and key they already hold
+	if err := users.repo.SetUserRole(context.Background(), admin.ID, auth.RoleUser); err != nil {
+		t.Fatalf("Failed to demote user: %v", err)
+	}
+	for name, header := range map[string]http.Header{"jwt": withJWT, "api key": withKey} {
+		if w := serve(s, http.MethodGet, "/api/v1/workers", "", header); w.Code != http.StatusForbidden {
+			t.Errorf("Expected the demoted user's %s to be denied workers, got %d", name, w.Code)
+		}
+	}
+}
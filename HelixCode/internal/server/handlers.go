@@ -1,31 +1,73 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/auth"
 	"dev.helix.code/internal/project"
-	"dev.helix.code/internal/workflow"
+	"dev.helix.code/internal/task"
+	"dev.helix.code/internal/worker"
 )
 
 // Project Handlers
 
+// projectOwner returns the ID of the user making the request, who owns the
+// projects they create
+func projectOwner(c *gin.Context) string {
+	user, ok := auth.UserFromContext(c.Request.Context())
+	if !ok {
+		return ""
+	}
+	return user.ID.String()
+}
+
+// parseCollaborators checks that collaborators are user IDs
+func parseCollaborators(collaborators []string) error {
+	for _, collaborator := range collaborators {
+		if _, err := uuid.Parse(collaborator); err != nil {
+			return fmt.Errorf("invalid collaborator ID %q: %v", collaborator, err)
+		}
+	}
+	return nil
+}
+
 func (s *Server) listProjects(c *gin.Context) {
-	// For now, return empty list until we have user authentication
-	// In production, this would use: projectManager := project.NewDatabaseManager(s.db)
+	projects, err := s.projects.ListProjects(c.Request.Context(), projectOwner(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to list projects",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if projects == nil {
+		projects = []*project.Project{}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":   "success",
-		"projects": []interface{}{},
+		"projects": projects,
 	})
 }
 
 func (s *Server) createProject(c *gin.Context) {
 	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-		Path        string `json:"path" binding:"required"`
-		Type        string `json:"type"`
+		Name          string   `json:"name" binding:"required"`
+		Description   string   `json:"description"`
+		Path          string   `json:"path" binding:"required"`
+		Type          string   `json:"type"`
+		Collaborators []string `json:"collaborators"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -36,17 +78,39 @@ func (s *Server) createProject(c *gin.Context) {
 		})
 		return
 	}
+	if err := parseCollaborators(req.Collaborators); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	path, err := project.CleanPath(req.Path, s.projectRoot)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid project path",
+			"error":   err.Error(),
+		})
+		return
+	}
 
-	// For now, return placeholder until we have user authentication
-	// In production, this would use: projectManager := project.NewDatabaseManager(s.db)
-	proj := gin.H{
-		"id":          "proj_placeholder",
-		"name":        req.Name,
-		"description": req.Description,
-		"path":        req.Path,
-		"type":        req.Type,
-		"created_at":  time.Now(),
-		"updated_at":  time.Now(),
+	ctx := c.Request.Context()
+	proj, err := s.projects.CreateProject(ctx, req.Name, req.Description, path, req.Type, projectOwner(c))
+	if err == nil && len(req.Collaborators) > 0 {
+		if err = s.projects.SetCollaborators(ctx, proj.ID, req.Collaborators); err == nil {
+			proj, err = s.projects.GetProject(ctx, proj.ID)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to create project",
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -56,23 +120,9 @@ func (s *Server) createProject(c *gin.Context) {
 }
 
 func (s *Server) getProject(c *gin.Context) {
-	id := c.Param("id")
-
-	// For now, return placeholder until we have user authentication
-	// In production, this would use: projectManager := project.NewDatabaseManager(s.db)
-	proj := gin.H{
-		"id":          id,
-		"name":        "Sample Project",
-		"description": "This is a sample project",
-		"path":        "/path/to/project",
-		"type":        "go",
-		"created_at":  time.Now(),
-		"updated_at":  time.Now(),
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"project": proj,
+		"project": c.MustGet("project"),
 	})
 }
 
@@ -93,16 +143,14 @@ func (s *Server) updateProject(c *gin.Context) {
 		return
 	}
 
-	// For now, return placeholder until we have user authentication
-	// In production, this would use: projectManager := project.NewDatabaseManager(s.db)
-	proj := gin.H{
-		"id":          id,
-		"name":        req.Name,
-		"description": req.Description,
-		"path":        "/path/to/project",
-		"type":        "go",
-		"created_at":  time.Now(),
-		"updated_at":  time.Now(),
+	proj, err := s.projects.UpdateProject(c.Request.Context(), id, req.Name, req.Description)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to update project",
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -112,21 +160,101 @@ func (s *Server) updateProject(c *gin.Context) {
 }
 
 func (s *Server) deleteProject(c *gin.Context) {
-	// For now, return success until we have user authentication
-	// In production, this would use: projectManager := project.NewDatabaseManager(s.db)
+	proj := c.MustGet("project").(*project.Project)
+	if !proj.IsOwner(projectOwner(c)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Only the project owner can delete it",
+		})
+		return
+	}
+
+	if err := s.projects.DeleteProject(c.Request.Context(), proj.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to delete project",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Project deleted",
 	})
 }
 
+func (s *Server) setProjectCollaborators(c *gin.Context) {
+	proj := c.MustGet("project").(*project.Project)
+	if !proj.IsOwner(projectOwner(c)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Only the project owner can share it",
+		})
+		return
+	}
+
+	var req struct {
+		Collaborators []string `json:"collaborators"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := parseCollaborators(req.Collaborators); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := s.projects.SetCollaborators(ctx, proj.ID, req.Collaborators)
+	if err == nil {
+		proj, err = s.projects.GetProject(ctx, proj.ID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to update collaborators",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"project": proj,
+	})
+}
+
 // Task Handlers
 
+// listTasks lists the user's tasks, or every task for admins
 func (s *Server) listTasks(c *gin.Context) {
-	// Return empty list for now
+	createdBy := projectOwner(c)
+	if user, ok := auth.UserFromContext(c.Request.Context()); !ok || user.HasRole(auth.RoleAdmin) {
+		createdBy = ""
+	}
+	tasks, err := s.tasks.ListTasks(c.Request.Context(), createdBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to list tasks",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"tasks": []interface{}{},
+		"tasks":  tasks,
 	})
 }
 
@@ -149,46 +277,79 @@ func (s *Server) createTask(c *gin.Context) {
 		return
 	}
 
-	// Return placeholder task
-	task := gin.H{
-		"id":          "task_placeholder",
-		"name":        req.Name,
-		"description": req.Description,
-		"type":        req.Type,
-		"status":      "pending",
-		"created_at":  time.Now(),
+	t, err := s.tasks.CreateTask(c.Request.Context(), req.Name, req.Description, req.Type, req.Priority, req.Parameters, req.Dependencies, projectOwner(c))
+	if errors.Is(err, task.ErrQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "error",
+			"message": "Task queue is full, retry later",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to create task",
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"status": "success",
-		"task":   task,
+		"task":   t,
 	})
 }
 
-func (s *Server) getTask(c *gin.Context) {
-	id := c.Param("id")
+// taskETag returns the entity tag of a task's version, which clients send
+// back in If-Match to update the task only if it has not changed since
+func taskETag(t *task.Task) string {
+	return strconv.Quote(strconv.Itoa(t.Version))
+}
 
-	// Return placeholder task
-	task := gin.H{
-		"id":          id,
-		"name":        "Sample Task",
-		"description": "This is a sample task",
-		"type":        "generic",
-		"status":      "pending",
-		"created_at":  time.Now(),
+// expectedVersion returns the task version in the If-Match header, or
+// task.AnyVersion when the header is absent or "*"
+func expectedVersion(c *gin.Context) (int, error) {
+	match := c.GetHeader("If-Match")
+	if match == "" || match == "*" {
+		return task.AnyVersion, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(match, `"`))
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid If-Match task version: %s", match)
 	}
+	return version, nil
+}
 
+func (s *Server) getTask(c *gin.Context) {
+	t := c.MustGet("task").(*task.Task)
+	c.Header("ETag", taskETag(t))
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"task":   task,
+		"task":   t,
 	})
 }
 
+// updateTask moves a task to running, completed or failed. With If-Match the
+// update applies only while the task is at that version, so a worker whose
+// attempt was failed and retried cannot report on the newer attempt.
 func (s *Server) updateTask(c *gin.Context) {
 	id := c.Param("id")
 
+	version, err := expectedVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	var req struct {
-		Status string `json:"status"`
+		Status string                 `json:"status" binding:"required"`
+		Result map[string]interface{} `json:"result"`
+		Error  string                 `json:"error"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -200,24 +361,82 @@ func (s *Server) updateTask(c *gin.Context) {
 		return
 	}
 
-	// Return updated placeholder task
-	task := gin.H{
-		"id":          id,
-		"name":        "Sample Task",
-		"description": "This is a sample task",
-		"type":        "generic",
-		"status":      req.Status,
-		"created_at":  time.Now(),
-		"updated_at":  time.Now(),
+	status, err := task.ParseTaskStatus(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid task status",
+			"error":   err.Error(),
+		})
+		return
 	}
 
+	ctx := c.Request.Context()
+	switch status {
+	case task.TaskStatusRunning:
+		err = s.tasks.StartTask(ctx, id, version)
+	case task.TaskStatusCompleted:
+		err = s.tasks.CompleteTask(ctx, id, version, req.Result)
+	case task.TaskStatusFailed:
+		err = s.tasks.FailTask(ctx, id, version, req.Error)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Unsupported status transition",
+			"error":   "status must be running, completed or failed",
+		})
+		return
+	}
+	switch {
+	case errors.Is(err, task.ErrStatusConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Task status conflict",
+			"error":   err.Error(),
+		})
+		return
+	case errors.Is(err, task.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Task not found",
+		})
+		return
+	case err != nil:
+		log.Printf("❌ Failed to update task %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to update task",
+		})
+		return
+	}
+
+	t, err := s.tasks.GetTask(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to load updated task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", taskETag(t))
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"task":   task,
+		"task":   t,
 	})
 }
 
 func (s *Server) deleteTask(c *gin.Context) {
+	if err := s.tasks.DeleteTask(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Failed to delete task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Task deleted",
@@ -252,18 +471,359 @@ func (s *Server) getWorker(c *gin.Context) {
 	})
 }
 
+// drainWorker starts draining a worker and returns its initial progress.
+// The drain continues in the background; poll getDrainProgress for updates.
+func (s *Server) drainWorker(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid worker ID",
+			"error":   err.Error(),
+		})
+		return
+	}
+	progress, err := s.workers.StartDrain(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Failed to drain worker",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"drain":  progress,
+	})
+}
+
+func (s *Server) getDrainProgress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid worker ID",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	progress, ok := s.workers.GetDrainProgress(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Worker is not being drained",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"drain":  progress,
+	})
+}
+
+// Audit Handlers
+
+func (s *Server) listAuditEvents(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid audit query",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page, err := s.audit.Query(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to query audit log",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"events": page.Events,
+		"total":  page.Total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+// parseAuditQuery reads the audit filters and pagination from the query string
+func parseAuditQuery(c *gin.Context) (audit.Query, error) {
+	var query audit.Query
+
+	switch entityType := audit.EntityType(c.Query("entity_type")); entityType {
+	case "", audit.EntityTask, audit.EntityWorker:
+		query.EntityType = entityType
+	default:
+		return query, fmt.Errorf("unknown entity_type %q", entityType)
+	}
+
+	if value := c.Query("entity_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return query, fmt.Errorf("invalid entity_id: %v", err)
+		}
+		query.EntityID = id
+	}
+
+	for name, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*target = parsed
+		}
+	}
+
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return query, fmt.Errorf("invalid %s: %q", name, value)
+			}
+			*target = parsed
+		}
+	}
+
+	return query, nil
+}
+
+// API Key Handlers
+
+// register creates a user account
+func (s *Server) register(c *gin.Context) {
+	var req struct {
+		Username    string `json:"username" binding:"required"`
+		Email       string `json:"email" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		DisplayName string `json:"display_name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, err := s.authService.Register(c.Request.Context(), req.Username, req.Email, req.Password, req.DisplayName)
+	switch {
+	case errors.Is(err, auth.ErrInvalidRegistration):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid registration",
+			"error":   err.Error(),
+		})
+		return
+	case errors.Is(err, auth.ErrUserExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Username or email already registered",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to register user",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"user":   user,
+	})
+}
+
+// login verifies a username or email and password and starts a session.
+// The session token is used as a bearer token until it expires or the user
+// logs out.
+func (s *Server) login(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"` // Username or email
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	session, user, err := s.authService.Login(c.Request.Context(), req.Username, req.Password, "rest_api",
+		c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, auth.ErrUserInactive) {
+		// Deactivated accounts are not told apart, so accounts cannot be probed
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Invalid credentials",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to log in",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"token":      session.SessionToken,
+		"expires_at": session.ExpiresAt,
+		"user":       user,
+	})
+}
+
+// logout ends the session whose token authenticated the request
+func (s *Server) logout(c *gin.Context) {
+	token, ok := auth.BearerToken(c.Request)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Logging out requires a session token",
+		})
+		return
+	}
+	if err := s.authService.Logout(c.Request.Context(), token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to log out",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Logged out",
+	})
+}
+
+// listAPIKeys lists the authenticated user's API keys
+func (s *Server) listAPIKeys(c *gin.Context) {
+	user, _ := auth.UserFromContext(c.Request.Context())
+	keys, err := s.apiKeys.List(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to list API keys",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"api_keys": keys,
+	})
+}
+
+// createAPIKey mints an API key for the authenticated user. The key is only
+// ever returned in this response.
+func (s *Server) createAPIKey(c *gin.Context) {
+	user, _ := auth.UserFromContext(c.Request.Context())
+	if user.Scope != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "API keys cannot mint API keys",
+		})
+		return
+	}
+
+	var req struct {
+		Name  string `json:"name" binding:"required"`
+		Scope string `json:"scope"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	key, record, err := s.apiKeys.Mint(c.Request.Context(), user, req.Name, auth.Scope(req.Scope))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to create API key",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"key":     key,
+		"api_key": record,
+	})
+}
+
+// revokeAPIKey revokes one of the authenticated user's API keys
+func (s *Server) revokeAPIKey(c *gin.Context) {
+	user, _ := auth.UserFromContext(c.Request.Context())
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid API key ID",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := s.apiKeys.Revoke(c.Request.Context(), user.ID, keyID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"status":  "error",
+			"message": "Failed to revoke API key",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "API key revoked",
+	})
+}
+
 // System Handlers
 
 func (s *Server) getSystemStats(c *gin.Context) {
-	// TODO: Implement task and worker statistics
-	// taskManager := task.NewManager(nil)
-	// tasks, _ := taskManager.ListTasks(c.Request.Context())
-	// workerManager := worker.NewManager(nil)
-	// workers, _ := workerManager.ListWorkers(c.Request.Context())
-	
-	// Placeholder data for now
-	tasks := []interface{}{}
-	workers := []interface{}{}
+	ctx := c.Request.Context()
+
+	// The worker manager returns empty results and database.ErrNotConfigured without a database
+	tasks, err := s.tasks.ListTasks(ctx, "")
+	if err != nil {
+		log.Printf("⚠️ Failed to list tasks for stats: %v", err)
+	}
+	workers, err := worker.NewDatabaseManager(s.db).ListWorkers(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to list workers for stats: %v", err)
+	}
 
 	// Calculate statistics
 	var (
@@ -276,32 +836,24 @@ func (s *Server) getSystemStats(c *gin.Context) {
 		activeWorkers = 0
 	)
 
-	// TODO: Implement proper task and worker status counting
-	// for _, t := range tasks {
-	// 	switch t.Status {
-	// 	case "pending":
-	// 		pendingTasks++
-	// 	case "running":
-	// 		runningTasks++
-	// 	case "completed":
-	// 		completedTasks++
-	// 	case "failed":
-	// 		failedTasks++
-	// 	}
-	// }
-	// 
-	// for _, w := range workers {
-	// 	if w.Status == "active" {
-	// 		activeWorkers++
-	// 	}
-	// }
-	
-	// Placeholder values for now
-	pendingTasks = 0
-	runningTasks = 0
-	completedTasks = 0
-	failedTasks = 0
-	activeWorkers = 0
+	for _, t := range tasks {
+		switch t.Status {
+		case task.TaskStatusPending:
+			pendingTasks++
+		case task.TaskStatusRunning:
+			runningTasks++
+		case task.TaskStatusCompleted:
+			completedTasks++
+		case task.TaskStatusFailed:
+			failedTasks++
+		}
+	}
+
+	for _, w := range workers {
+		if w.Status == worker.WorkerStatusActive {
+			activeWorkers++
+		}
+	}
 
 	stats := gin.H{
 		"tasks": gin.H{
@@ -319,6 +871,9 @@ func (s *Server) getSystemStats(c *gin.Context) {
 			"uptime": "0s", // TODO: Implement actual uptime tracking
 		},
 	}
+	if queue, ok := s.tasks.(task.QueueReporter); ok {
+		stats["queue"] = queue.QueueStats()
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
@@ -345,15 +900,36 @@ func (s *Server) getSystemStatus(c *gin.Context) {
 	})
 }
 
+// getNotificationHealth checks that each notification channel is reachable
+// and correctly configured, without sending a notification
+func (s *Server) getNotificationHealth(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	channels := s.notifications.TestChannels(ctx)
+	misconfigured := []string{}
+	for _, channel := range channels {
+		if !channel.Healthy {
+			misconfigured = append(misconfigured, channel.Name)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"healthy":       len(misconfigured) == 0,
+		"channels":      channels,
+		"misconfigured": misconfigured,
+	})
+}
+
 // Workflow Handlers
 
 func (s *Server) executePlanningWorkflow(c *gin.Context) {
-	projectID := c.Param("projectId")
+	proj := c.MustGet("project").(*project.Project)
 
-	projectManager := project.NewManager()
-	workflowExecutor := workflow.NewExecutor(projectManager)
+	workflowExecutor := s.newWorkflowExecutor(proj)
 	
-	wf, err := workflowExecutor.ExecutePlanningWorkflow(c.Request.Context(), projectID)
+	wf, err := workflowExecutor.ExecutePlanningWorkflow(c.Request.Context(), proj.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -370,12 +946,11 @@ func (s *Server) executePlanningWorkflow(c *gin.Context) {
 }
 
 func (s *Server) executeBuildingWorkflow(c *gin.Context) {
-	projectID := c.Param("projectId")
+	proj := c.MustGet("project").(*project.Project)
 
-	projectManager := project.NewManager()
-	workflowExecutor := workflow.NewExecutor(projectManager)
+	workflowExecutor := s.newWorkflowExecutor(proj)
 	
-	wf, err := workflowExecutor.ExecuteBuildingWorkflow(c.Request.Context(), projectID)
+	wf, err := workflowExecutor.ExecuteBuildingWorkflow(c.Request.Context(), proj.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -392,12 +967,11 @@ func (s *Server) executeBuildingWorkflow(c *gin.Context) {
 }
 
 func (s *Server) executeTestingWorkflow(c *gin.Context) {
-	projectID := c.Param("projectId")
+	proj := c.MustGet("project").(*project.Project)
 
-	projectManager := project.NewManager()
-	workflowExecutor := workflow.NewExecutor(projectManager)
+	workflowExecutor := s.newWorkflowExecutor(proj)
 	
-	wf, err := workflowExecutor.ExecuteTestingWorkflow(c.Request.Context(), projectID)
+	wf, err := workflowExecutor.ExecuteTestingWorkflow(c.Request.Context(), proj.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -414,12 +988,11 @@ func (s *Server) executeTestingWorkflow(c *gin.Context) {
 }
 
 func (s *Server) executeRefactoringWorkflow(c *gin.Context) {
-	projectID := c.Param("projectId")
+	proj := c.MustGet("project").(*project.Project)
 
-	projectManager := project.NewManager()
-	workflowExecutor := workflow.NewExecutor(projectManager)
+	workflowExecutor := s.newWorkflowExecutor(proj)
 	
-	wf, err := workflowExecutor.ExecuteRefactoringWorkflow(c.Request.Context(), projectID)
+	wf, err := workflowExecutor.ExecuteRefactoringWorkflow(c.Request.Context(), proj.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/project"
+	"github.com/google/uuid"
+)
+
+func TestServer_ProjectOwnership(t *testing.T) {
+	s, jwtService := newAuthTestServer(t)
+	bearer := func(user *auth.User) http.Header {
+		token, err := jwtService.GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("Failed to generate JWT: %v", err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+	owner := &auth.User{ID: uuid.New(), Username: "owner"}
+	collaborator := &auth.User{ID: uuid.New(), Username: "collaborator"}
+	stranger := &auth.User{ID: uuid.New(), Username: "stranger"}
+
+	body := fmt.Sprintf(`{"name": "shared", "path": %q, "collaborators": [%q]}`, t.TempDir(), collaborator.ID)
+	w := serve(s, http.MethodPost, "/api/v1/projects", body, bearer(owner))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the project to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Project project.Project `json:"project"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Project.OwnerID != owner.ID.String() {
+		t.Fatalf("Expected owner %s, got %q", owner.ID, created.Project.OwnerID)
+	}
+	path := "/api/v1/projects/" + created.Project.ID
+
+	tests := []struct {
+		name   string
+		user   *auth.User
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "owner reads", user: owner, method: http.MethodGet, path: path, status: http.StatusOK},
+		{name: "collaborator reads", user: collaborator, method: http.MethodGet, path: path, status: http.StatusOK},
+		{name: "collaborator updates", user: collaborator, method: http.MethodPut, path: path, body: `{"description": "edited"}`, status: http.StatusOK},
+		{name: "collaborator cannot delete", user: collaborator, method: http.MethodDelete, path: path, status: http.StatusForbidden},
+		{name: "collaborator cannot share", user: collaborator, method: http.MethodPut, path: path + "/collaborators", body: `{"collaborators": []}`, status: http.StatusForbidden},
+		{name: "stranger cannot read", user: stranger, method: http.MethodGet, path: path, status: http.StatusNotFound},
+		{name: "stranger cannot update", user: stranger, method: http.MethodPut, path: path, body: `{"name": "mine"}`, status: http.StatusNotFound},
+		{name: "stranger cannot delete", user: stranger, method: http.MethodDelete, path: path, status: http.StatusNotFound},
+		{name: "stranger cannot run workflows", user: stranger, method: http.MethodPost, path: path + "/workflows/planning", status: http.StatusNotFound},
+		{name: "stranger cannot list sessions", user: stranger, method: http.MethodGet, path: path + "/sessions", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(s, tt.method, tt.path, tt.body, bearer(tt.user)); w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	listed := func(user *auth.User) int {
+		w := serve(s, http.MethodGet, "/api/v1/projects", "", bearer(user))
+		var list struct {
+			Projects []project.Project `json:"projects"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return len(list.Projects)
+	}
+	if n := listed(collaborator); n != 1 {
+		t.Errorf("Expected the collaborator to see the shared project, got %d projects", n)
+	}
+	if n := listed(stranger); n != 0 {
+		t.Errorf("Expected the stranger to see no projects, got %d", n)
+	}
+
+	// Removing a collaborator revokes their access
+	if w := serve(s, http.MethodPut, path+"/collaborators", `{"collaborators": []}`, bearer(owner)); w.Code != http.StatusOK {
+		t.Fatalf("Expected the owner to update collaborators, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(s, http.MethodGet, path, "", bearer(collaborator)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a removed collaborator to be denied, got %d", w.Code)
+	}
+	if w := serve(s, http.MethodDelete, path, "", bearer(owner)); w.Code != http.StatusOK {
+		t.Errorf("Expected the owner to delete the project, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_CreateProjectPath(t *testing.T) {
+	s, jwtService := newAuthTestServer(t)
+	s.projectRoot = t.TempDir()
+	token, err := jwtService.GenerateJWT(&auth.User{ID: uuid.New(), Username: "alice"})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	withJWT := http.Header{"Authorization": {"Bearer " + token}}
+	inside := filepath.Join(s.projectRoot, "app")
+	if err := os.Mkdir(inside, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{name: "inside root", path: inside, status: http.StatusCreated},
+		{name: "traversal", path: inside + "/../../etc", status: http.StatusBadRequest},
+		{name: "outside root", path: t.TempDir(), status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"name": "app", "path": %q}`, tt.path)
+			if w := serve(s, http.MethodPost, "/api/v1/projects", body, withJWT); w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -2,14 +2,23 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/auth"
 	"dev.helix.code/internal/config"
+	"dev.helix.code/internal/paths"
 	"dev.helix.code/internal/database"
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/notification"
+	"dev.helix.code/internal/project"
+	"dev.helix.code/internal/task"
+	"dev.helix.code/internal/worker"
 )
 
 // Server represents the HTTP server
@@ -18,6 +27,18 @@ type Server struct {
 	db     *database.Database
 	server *http.Server
 	router *gin.Engine
+	models *llm.ModelManager
+	hub    *Hub
+	tasks  task.Service
+	projects project.Service
+	projectRoot string // Directory project paths must be inside, if set
+	workers *worker.DistributedWorkerManager
+	audit   *audit.Log
+	notifications *notification.NotificationEngine
+	authenticator auth.Authenticator
+	authService   *auth.AuthService // Registers users and logs them in
+	apiKeys       *auth.APIKeyService
+	stopBackground context.CancelFunc // Stops background work started by Start
 }
 
 // New creates a new HTTP server
@@ -37,11 +58,44 @@ func New(cfg *config.Config, db *database.Database) *Server {
 	router.Use(CORSMiddleware())
 	router.Use(SecurityMiddleware())
 
+	// Initialize LLM providers from configuration
+	llm.SetVerboseLogging(cfg.Logging.Level == "debug")
+	models := llm.NewModelManager()
+	if _, err := models.InitFromConfig(cfg.LLM); err != nil {
+		log.Printf("⚠️ LLM providers not initialized: %v", err)
+	}
+
+	auditLog := audit.NewLog(audit.NewStore(db))
+	workers := worker.NewDistributedWorkerManager(worker.WorkerConfig{
+		MaxConcurrentTasks: cfg.Workers.MaxConcurrentTasks,
+		RebalanceInterval:  cfg.Workers.RebalanceInterval,
+		RebalanceThreshold: cfg.Workers.RebalanceThreshold,
+	})
+	workers.SetAuditLog(auditLog)
+	workers.SetMaxWaitingTasks(cfg.Tasks.MaxQueueSize)
+	notifications := notification.NewNotificationEngine()
+	notifications.SetStore(notification.NewStore(db))
+
 	server := &Server{
 		config: cfg,
 		db:     db,
 		router: router,
+		models: models,
+		hub:    NewHub(),
+		tasks:  newTaskService(db, auditLog, notifications, cfg.Tasks.MaxQueueSize),
+		projects: project.NewService(db),
+		projectRoot: paths.Expand(cfg.Server.ProjectRoot),
+		workers: workers,
+		audit:   auditLog,
+		notifications: notifications,
 	}
+	users := auth.NewAuthRepository(db)
+	server.apiKeys = auth.NewAPIKeyService(auth.NewAPIKeyStore(db), users)
+	server.authService = auth.NewAuthService(authConfig(cfg.Auth), users)
+	if err := server.authService.BootstrapAdmin(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to bootstrap the initial admin: %v", err)
+	}
+	server.authenticator = auth.NewChainAuthenticator(server.authService, server.apiKeys)
 
 	// Setup routes
 	server.setupRoutes()
@@ -58,14 +112,78 @@ func New(cfg *config.Config, db *database.Database) *Server {
 	return server
 }
 
+// authConfig converts the server's auth settings, whose expiries are in
+// seconds, for the local JWT authenticator
+func authConfig(cfg config.AuthConfig) auth.AuthConfig {
+	authCfg := auth.DefaultConfig()
+	authCfg.JWTSecret = cfg.JWTSecret
+	authCfg.TokenExpiry = time.Duration(cfg.TokenExpiry) * time.Second
+	authCfg.SessionExpiry = time.Duration(cfg.SessionExpiry) * time.Second
+	authCfg.BcryptCost = cfg.BcryptCost
+	authCfg.InitialAdmin = cfg.InitialAdmin
+	return authCfg
+}
+
+// SetAuthenticator replaces the backend that verifies API requests, which by
+// default accepts local JWTs and API keys
+func (s *Server) SetAuthenticator(authenticator auth.Authenticator) {
+	s.authenticator = authenticator
+}
+
+// newTaskService selects the task backend for the HTTP handlers
+func newTaskService(db *database.Database, auditLog *audit.Log, notifications *notification.NotificationEngine, maxQueueSize int) task.Service {
+	if db.IsConfigured() {
+		manager := task.NewDatabaseManager(db)
+		manager.SetAuditLog(auditLog)
+		if err := manager.Durations().Load(context.Background()); err != nil {
+			log.Printf("⚠️ Failed to load task duration estimates: %v", err)
+		}
+		return manager
+	}
+	log.Printf("⚠️ No database configured, tasks are kept in memory")
+	manager := task.NewTaskManager(db)
+	manager.SetAuditLog(auditLog)
+	manager.SetMaxQueueSize(maxQueueSize)
+	manager.SetNotificationEngine(notifications)
+	return manager.Service()
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
+	if err := s.checkPorts(); err != nil {
+		return err
+	}
 	log.Printf("🚀 Starting HelixCode server on %s", s.server.Addr)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+	go s.replayNotifications()
+	go s.workers.StartRebalancing(ctx)
 	return s.server.ListenAndServe()
 }
 
+// checkPorts reports an LLM provider's server on the HTTP port, which would
+// otherwise surface only as a bind failure from whichever started second
+func (s *Server) checkPorts() error {
+	if err := s.models.CheckPortConflict(s.config.Server.Port); err != nil {
+		return fmt.Errorf("cannot start HTTP server on port %d: %w", s.config.Server.Port, err)
+	}
+	return nil
+}
+
+// replayNotifications sends notifications a previous run left undelivered
+func (s *Server) replayNotifications() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := s.notifications.ReplayPending(ctx); err != nil {
+		log.Printf("⚠️ Failed to replay pending notifications: %v", err)
+	}
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
 	return s.server.Shutdown(ctx)
 }
 
@@ -78,12 +196,21 @@ func (s *Server) setupRoutes() {
 	api := s.router.Group("/api/v1")
 	{
 		// Authentication routes
-		auth := api.Group("/auth")
+		authRoutes := api.Group("/auth")
+		{
+			authRoutes.POST("/register", s.register)
+			authRoutes.POST("/login", s.login)
+			authRoutes.POST("/logout", s.authMiddleware(), s.logout)
+			authRoutes.POST("/refresh", s.notImplemented)
+		}
+
+		// API key routes
+		apiKeys := api.Group("/api-keys")
+		apiKeys.Use(s.authMiddleware())
 		{
-			auth.POST("/register", s.notImplemented)
-			auth.POST("/login", s.notImplemented)
-			auth.POST("/logout", s.notImplemented)
-			auth.POST("/refresh", s.notImplemented)
+			apiKeys.GET("", s.listAPIKeys)
+			apiKeys.POST("", s.createAPIKey)
+			apiKeys.DELETE("/:id", s.revokeAPIKey)
 		}
 
 		// User routes
@@ -97,7 +224,7 @@ func (s *Server) setupRoutes() {
 
 		// Worker routes
 		workers := api.Group("/workers")
-		workers.Use(s.authMiddleware())
+		workers.Use(s.authMiddleware(), RequireRole(auth.RoleAdmin))
 		{
 			workers.GET("", s.listWorkers)
 			workers.POST("", s.notImplemented)
@@ -106,6 +233,8 @@ func (s *Server) setupRoutes() {
 			workers.DELETE("/:id", s.notImplemented)
 			workers.POST("/:id/heartbeat", s.notImplemented)
 			workers.GET("/:id/metrics", s.notImplemented)
+			workers.POST("/:id/drain", s.drainWorker)
+			workers.GET("/:id/drain", s.getDrainProgress)
 		}
 
 		// Task routes
@@ -114,9 +243,9 @@ func (s *Server) setupRoutes() {
 		{
 			tasks.GET("", s.listTasks)
 			tasks.POST("", s.createTask)
-			tasks.GET("/:id", s.getTask)
-			tasks.PUT("/:id", s.updateTask)
-			tasks.DELETE("/:id", s.deleteTask)
+			tasks.GET("/:id", s.taskAccess(), s.getTask)
+			tasks.PUT("/:id", s.taskAccess(), s.updateTask)
+			tasks.DELETE("/:id", s.taskAccess(), s.deleteTask)
 			tasks.POST("/:id/assign", s.notImplemented)
 			tasks.POST("/:id/start", s.notImplemented)
 			tasks.POST("/:id/complete", s.notImplemented)
@@ -132,16 +261,17 @@ func (s *Server) setupRoutes() {
 		{
 			projects.GET("", s.listProjects)
 			projects.POST("", s.createProject)
-			projects.GET("/:id", s.getProject)
-			projects.PUT("/:id", s.updateProject)
-			projects.DELETE("/:id", s.deleteProject)
-			projects.GET("/:id/sessions", s.notImplemented)
+			projects.GET("/:id", s.projectAccess("id"), s.getProject)
+			projects.PUT("/:id", s.projectAccess("id"), s.updateProject)
+			projects.DELETE("/:id", s.projectAccess("id"), s.deleteProject)
+			projects.PUT("/:id/collaborators", s.projectAccess("id"), s.setProjectCollaborators)
+			projects.GET("/:id/sessions", s.projectAccess("id"), s.notImplemented)
 			
 			// Workflow routes
-			projects.POST("/:projectId/workflows/planning", s.executePlanningWorkflow)
-			projects.POST("/:projectId/workflows/building", s.executeBuildingWorkflow)
-			projects.POST("/:projectId/workflows/testing", s.executeTestingWorkflow)
-			projects.POST("/:projectId/workflows/refactoring", s.executeRefactoringWorkflow)
+			projects.POST("/:projectId/workflows/planning", s.projectAccess("projectId"), s.executePlanningWorkflow)
+			projects.POST("/:projectId/workflows/building", s.projectAccess("projectId"), s.executeBuildingWorkflow)
+			projects.POST("/:projectId/workflows/testing", s.projectAccess("projectId"), s.executeTestingWorkflow)
+			projects.POST("/:projectId/workflows/refactoring", s.projectAccess("projectId"), s.executeRefactoringWorkflow)
 		}
 
 		// Session routes
@@ -155,17 +285,25 @@ func (s *Server) setupRoutes() {
 			sessions.DELETE("/:id", s.notImplemented)
 		}
 
+		// Audit routes
+		auditRoutes := api.Group("/audit")
+		auditRoutes.Use(s.authMiddleware(), RequireRole(auth.RoleAdmin))
+		{
+			auditRoutes.GET("", s.listAuditEvents)
+		}
+
 		// System routes
 		system := api.Group("/system")
-		system.Use(s.authMiddleware())
+		system.Use(s.authMiddleware(), RequireRole(auth.RoleAdmin))
 		{
 			system.GET("/stats", s.getSystemStats)
 			system.GET("/status", s.getSystemStatus)
+			system.GET("/notifications/health", s.getNotificationHealth)
 		}
 	}
 
 	// WebSocket routes
-	s.router.GET("/ws", s.notImplemented)
+	s.router.GET("/ws", s.authMiddleware(), s.handleWebSocket)
 
 	// Static file serving for web interface
 	s.router.Static("/static", "./web/frontend/static")
@@ -204,8 +342,106 @@ func (s *Server) notImplemented(c *gin.Context) {
 
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Implement authentication middleware
-		// For now, just continue
+		AuthMiddleware(s.authenticator)(c)
+	}
+}
+
+// AuthMiddleware rejects requests the authenticator cannot verify with 401,
+// and requests outside a read-only credential's scope with 403. The user is
+// stored in the request context and audited changes are attributed to them.
+func AuthMiddleware(authenticator auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := authenticator.Authenticate(c.Request.Context(), c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "Authentication required",
+			})
+			return
+		}
+		if !user.Scope.AllowsMethod(c.Request.Method) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Credential is read-only",
+			})
+			return
+		}
+
+		ctx := auth.WithUser(c.Request.Context(), user)
+		ctx = audit.WithActor(ctx, audit.Actor{Source: audit.SourceAPI, Name: user.Username})
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// RequireRole rejects authenticated users without role with 403. It must run
+// after the auth middleware.
+func RequireRole(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := auth.UserFromContext(c.Request.Context())
+		if !ok || !user.HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": fmt.Sprintf("Requires the %s role", role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// taskAccess loads the task named by the id route parameter for the handler,
+// and rejects users who neither created it nor are admins with 404 so that
+// other users' tasks are not revealed. It must run after the auth middleware.
+func (s *Server) taskAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := s.tasks.GetTask(c.Request.Context(), c.Param("id"))
+		user, ok := auth.UserFromContext(c.Request.Context())
+		if err != nil || !ok || !canAccessTask(user, t) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Task not found",
+			})
+			return
+		}
+		c.Set("task", t)
+		c.Next()
+	}
+}
+
+// canAccessTask reports whether user created the task or is an admin
+func canAccessTask(user *auth.User, t *task.Task) bool {
+	if user.HasRole(auth.RoleAdmin) {
+		return true
+	}
+	return t.CreatedBy != nil && *t.CreatedBy == user.ID
+}
+
+// projectAccess loads the project named by the route parameter for the
+// handler, and rejects users who neither own nor collaborate on it with 404 so
+// that other users' projects are not revealed. It must run after the auth
+// middleware.
+func (s *Server) projectAccess(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proj, err := s.projects.GetProject(c.Request.Context(), c.Param(param))
+		if err != nil && !errors.Is(err, project.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Failed to get project",
+				"error":   err.Error(),
+			})
+			return
+		}
+		user, ok := auth.UserFromContext(c.Request.Context())
+		if err != nil || !ok || !proj.CanAccess(user.ID.String()) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Project not found",
+			})
+			return
+		}
+		c.Set("project", proj)
 		c.Next()
 	}
 }
@@ -215,7 +451,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key, If-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
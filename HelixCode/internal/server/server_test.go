@@ -0,0 +1,55 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/config"
+	"dev.helix.code/internal/llm"
+	"github.com/gin-gonic/gin"
+)
+
+func TestServer_CheckPorts(t *testing.T) {
+	models := llm.NewModelManager()
+	provider, err := llm.NewLlamaCPPProvider(llm.LlamaConfig{ModelPath: "model.gguf", ServerPort: 8080})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	if err := models.RegisterProvider(provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	s := &Server{config: &config.Config{Server: config.ServerConfig{Port: 8080}}, models: models}
+	if err := s.checkPorts(); !errors.Is(err, llm.ErrPortConflict) {
+		t.Errorf("Expected a port conflict with the llama-cpp server, got %v", err)
+	}
+
+	s.config.Server.Port = 8081
+	if err := s.checkPorts(); err != nil {
+		t.Errorf("Expected no conflict on another port, got %v", err)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/tasks/1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected a preflight to succeed, got %d", w.Code)
+	}
+	allowed := w.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"Authorization", "X-API-Key", "If-Match"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("Expected %s to be allowed, got %q", header, allowed)
+		}
+	}
+	if exposed := w.Header().Get("Access-Control-Expose-Headers"); exposed != "ETag" {
+		t.Errorf("Expected ETag to be exposed, got %q", exposed)
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/task"
+	"github.com/google/uuid"
+)
+
+func TestServer_TaskOwnership(t *testing.T) {
+	s, users := newAuthTestServer(t)
+	bearer := func(user *auth.User) http.Header {
+		token, err := users.GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("Failed to generate JWT: %v", err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+	creator := &auth.User{ID: uuid.New(), Username: "creator"}
+	stranger := &auth.User{ID: uuid.New(), Username: "stranger"}
+	admin := &auth.User{ID: uuid.New(), Username: "admin", Role: auth.RoleAdmin}
+
+	w := serve(s, http.MethodPost, "/api/v1/tasks", `{"name": "build", "type": "building"}`, bearer(creator))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the task to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Task task.Task `json:"task"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Task.CreatedBy == nil || *created.Task.CreatedBy != creator.ID {
+		t.Fatalf("Expected the task to record its creator %s, got %v", creator.ID, created.Task.CreatedBy)
+	}
+	path := "/api/v1/tasks/" + created.Task.ID.String()
+
+	tests := []struct {
+		name   string
+		user   *auth.User
+		method string
+		body   string
+		status int
+	}{
+		{name: "creator reads", user: creator, method: http.MethodGet, status: http.StatusOK},
+		{name: "admin reads", user: admin, method: http.MethodGet, status: http.StatusOK},
+		{name: "stranger cannot read", user: stranger, method: http.MethodGet, status: http.StatusNotFound},
+		{name: "stranger cannot update", user: stranger, method: http.MethodPut, body: `{"status": "running"}`, status: http.StatusNotFound},
+		{name: "stranger cannot delete", user: stranger, method: http.MethodDelete, status: http.StatusNotFound},
+		{name: "creator updates", user: creator, method: http.MethodPut, body: `{"status": "running"}`, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(s, tt.method, path, tt.body, bearer(tt.user)); w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	listed := func(user *auth.User) int {
+		w := serve(s, http.MethodGet, "/api/v1/tasks", "", bearer(user))
+		var list struct {
+			Tasks []task.Task `json:"tasks"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return len(list.Tasks)
+	}
+	if n := listed(creator); n != 1 {
+		t.Errorf("Expected the creator to see their task, got %d tasks", n)
+	}
+	if n := listed(stranger); n != 0 {
+		t.Errorf("Expected the stranger to see no tasks, got %d", n)
+	}
+	if n := listed(admin); n != 1 {
+		t.Errorf("Expected the admin to see every task, got %d", n)
+	}
+
+	if w := serve(s, http.MethodDelete, path, "", bearer(creator)); w.Code != http.StatusOK {
+		t.Errorf("Expected the creator to delete the task, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_UpdateTaskIfMatch(t *testing.T) {
+	s, users := newAuthTestServer(t)
+	token, err := users.GenerateJWT(&auth.User{ID: uuid.New(), Username: "worker"})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	header := func(ifMatch string) http.Header {
+		h := http.Header{"Authorization": {"Bearer " + token}}
+		if ifMatch != "" {
+			h.Set("If-Match", ifMatch)
+		}
+		return h
+	}
+
+	w := serve(s, http.MethodPost, "/api/v1/tasks", `{"name": "build", "type": "building"}`, header(""))
+	var created struct {
+		Task task.Task `json:"task"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	path := "/api/v1/tasks/" + created.Task.ID.String()
+
+	// A pending task has no attempt to report on
+	if w := serve(s, http.MethodPut, path, `{"status": "completed"}`, header("")); w.Code != http.StatusConflict {
+		t.Errorf("Expected completing a pending task to conflict, got %d", w.Code)
+	}
+
+	w = serve(s, http.MethodPut, path, `{"status": "running"}`, header(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the task to start, got %d: %s", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected the updated task's ETag")
+	}
+
+	if w := serve(s, http.MethodPut, path, `{"status": "completed"}`, header(`"0"`)); w.Code != http.StatusConflict {
+		t.Errorf("Expected a stale If-Match to conflict, got %d", w.Code)
+	}
+	if w := serve(s, http.MethodPut, path, `{"status": "completed"}`, header("latest")); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid If-Match to be rejected, got %d", w.Code)
+	}
+	if w := serve(s, http.MethodPut, path, `{"status": "completed"}`, header(etag)); w.Code != http.StatusOK {
+		t.Errorf("Expected the current version to complete the task, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// failingTasks fails every status update with err
+type failingTasks struct {
+	task.Service
+	err error
+}
+
+func (f failingTasks) CompleteTask(ctx context.Context, id string, version int, result map[string]interface{}) error {
+	return f.err
+}
+
+func TestServer_UpdateTaskErrors(t *testing.T) {
+	s, users := newAuthTestServer(t)
+	token, err := users.GenerateJWT(&auth.User{ID: uuid.New(), Username: "worker"})
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	header := http.Header{"Authorization": {"Bearer " + token}}
+
+	w := serve(s, http.MethodPost, "/api/v1/tasks", `{"name": "build", "type": "building"}`, header)
+	var created struct {
+		Task task.Task `json:"task"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	path := "/api/v1/tasks/" + created.Task.ID.String()
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"conflict", fmt.Errorf("%w: stale", task.ErrStatusConflict), http.StatusConflict},
+		{"not found", fmt.Errorf("%w: gone", task.ErrTaskNotFound), http.StatusNotFound},
+		{"internal", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	tasks := s.tasks
+	for _, tt := range tests {
+		s.tasks = failingTasks{Service: tasks, err: tt.err}
+		w := serve(s, http.MethodPut, path, `{"status": "completed"}`, header)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.code, w.Code)
+		}
+		if tt.code == http.StatusInternalServerError && strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("%s: expected internal errors not to be exposed, got %s", tt.name, w.Body.String())
+		}
+	}
+}
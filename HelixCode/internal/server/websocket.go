@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/project"
+	"dev.helix.code/internal/workflow"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const wsWriteTimeout = 5 * time.Second
+
+// newUpgrader creates the WebSocket upgrader, which only accepts browser
+// connections from the server's own origin so that other sites cannot read
+// events with a visitor's credentials
+func (s *Server) newUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkOrigin,
+	}
+}
+
+// checkOrigin accepts requests without an Origin header, which only
+// non-browser clients omit, and origins matching the requested or configured
+// host
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if s.config == nil || s.config.Server.Address == "" {
+		return false
+	}
+	configured := net.JoinHostPort(s.config.Server.Address, strconv.Itoa(s.config.Server.Port))
+	return strings.EqualFold(u.Host, configured)
+}
+
+// WorkflowProgressEvent is broadcast to WebSocket clients as workflow steps finish
+type WorkflowProgressEvent struct {
+	Type       string                  `json:"type"`
+	ProjectID  string                  `json:"project_id"`
+	WorkflowID string                  `json:"workflow_id"`
+	Status     workflow.WorkflowStatus `json:"status"`
+	Progress   workflow.Progress       `json:"progress"`
+}
+
+// wsSendBuffer is how many events may wait to be written to a client before
+// it is dropped as too slow
+const wsSendBuffer = 64
+
+// wsClient is a connected WebSocket client, the user it authenticated as and
+// the events waiting to be written to it by its writer goroutine
+type wsClient struct {
+	conn   *websocket.Conn
+	userID string
+	send   chan []byte
+}
+
+// Hub keeps track of connected WebSocket clients, by the ID of the user each
+// authenticated as, and broadcasts events to them. Each client has its own
+// writer, so a slow client never holds up the others.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+// NewHub creates a new WebSocket hub
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*wsClient]struct{}),
+	}
+}
+
+// Broadcast queues an event for every connected client whose user allowed
+// accepts, dropping clients whose queue is full
+func (h *Hub) Broadcast(event interface{}, allowed func(userID string) bool) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ Failed to encode WebSocket event: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		if !allowed(client.userID) {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			log.Printf("⚠️ Dropping slow WebSocket client of user %s", client.userID)
+			h.removeLocked(client)
+		}
+	}
+}
+
+// ClientCount returns the number of connected clients
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// add registers a connection and starts writing events to it
+func (h *Hub) add(conn *websocket.Conn, userID string) *wsClient {
+	client := &wsClient{conn: conn, userID: userID, send: make(chan []byte, wsSendBuffer)}
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	go h.write(client)
+	return client
+}
+
+// remove unregisters a client, which makes its writer close the connection
+func (h *Hub) remove(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(client)
+}
+
+// removeLocked unregisters a client. The caller must hold h.mu.
+func (h *Hub) removeLocked(client *wsClient) {
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.send)
+	}
+}
+
+// write writes a client's queued events until it is removed or a write fails,
+// then closes its connection
+func (h *Hub) write(client *wsClient) {
+	defer client.conn.Close()
+	for data := range client.send {
+		client.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := client.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			log.Printf("⚠️ Dropping WebSocket client %s: %v", client.conn.RemoteAddr(), err)
+			h.remove(client)
+			return
+		}
+	}
+}
+
+// handleWebSocket upgrades the connection and registers it with the hub for
+// the authenticated user. Clients only receive events; incoming messages are
+// read and discarded so that close frames are processed.
+func (s *Server) handleWebSocket(c *gin.Context) {
+	user, ok := auth.UserFromContext(c.Request.Context())
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	conn, err := s.newUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("⚠️ WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	client := s.hub.add(conn, user.ID.String())
+	go func() {
+		defer s.hub.remove(client)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// newWorkflowExecutor creates a workflow executor that streams the progress
+// of proj's workflows to the WebSocket clients of users who can access it
+func (s *Server) newWorkflowExecutor(proj *project.Project) *workflow.Executor {
+	executor := workflow.NewExecutor(project.NewManager())
+	executor.OnProgress(func(wf *workflow.Workflow, progress workflow.Progress) {
+		s.hub.Broadcast(WorkflowProgressEvent{
+			Type:       "workflow_progress",
+			ProjectID:  proj.ID,
+			WorkflowID: wf.ID,
+			Status:     wf.Status,
+			Progress:   progress,
+		}, proj.CanAccess)
+	})
+	return executor
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/auth"
+	"dev.helix.code/internal/project"
+	"dev.helix.code/internal/workflow"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func TestServer_WebSocket(t *testing.T) {
+	s, jwtService := newAuthTestServer(t)
+	s.hub = NewHub()
+	httpServer := httptest.NewServer(s.router)
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws"
+
+	dial := func(user *auth.User, origin string) (*websocket.Conn, int) {
+		t.Helper()
+		header := http.Header{}
+		if user != nil {
+			token, err := jwtService.GenerateJWT(user)
+			if err != nil {
+				t.Fatalf("Failed to generate JWT: %v", err)
+			}
+			header.Set("Authorization", "Bearer "+token)
+		}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if err != nil {
+			if resp == nil {
+				t.Fatalf("Failed to dial: %v", err)
+			}
+			return nil, resp.StatusCode
+		}
+		return conn, resp.StatusCode
+	}
+
+	owner := &auth.User{ID: uuid.New(), Username: "owner"}
+	stranger := &auth.User{ID: uuid.New(), Username: "stranger"}
+
+	if _, status := dial(nil, ""); status != http.StatusUnauthorized {
+		t.Errorf("Expected an unauthenticated client to be rejected, got %d", status)
+	}
+	if _, status := dial(owner, "https://evil.example"); status != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin client to be rejected, got %d", status)
+	}
+
+	ownerConn, _ := dial(owner, httpServer.URL)
+	defer ownerConn.Close()
+	strangerConn, _ := dial(stranger, "")
+	defer strangerConn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.hub.ClientCount() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Clients were not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Only users with access to the project receive its progress
+	proj := &project.Project{ID: "app", OwnerID: owner.ID.String()}
+	wf := &workflow.Workflow{ID: "planning", Status: workflow.WorkflowStatusCompleted}
+	s.hub.Broadcast(WorkflowProgressEvent{Type: "workflow_progress", ProjectID: proj.ID, WorkflowID: wf.ID, Status: wf.Status}, proj.CanAccess)
+
+	var event WorkflowProgressEvent
+	ownerConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := ownerConn.ReadJSON(&event); err != nil {
+		t.Fatalf("Expected the owner to receive the event: %v", err)
+	}
+	if event.ProjectID != "app" || event.Status != workflow.WorkflowStatusCompleted {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	strangerConn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if err := strangerConn.ReadJSON(&event); err == nil {
+		t.Errorf("Expected the stranger to receive nothing, got %+v", event)
+	}
+}
+
+func TestHub_DropsSlowClient(t *testing.T) {
+	hub := NewHub()
+	// A client whose writer is stuck never drains its queue
+	slow := &wsClient{userID: "slow", send: make(chan []byte, 1)}
+	hub.clients[slow] = struct{}{}
+	all := func(string) bool { return true }
+
+	done := make(chan struct{})
+	go func() {
+		hub.Broadcast(WorkflowProgressEvent{Type: "workflow_progress"}, all)
+		hub.Broadcast(WorkflowProgressEvent{Type: "workflow_progress"}, all)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Broadcast blocked on a slow client")
+	}
+	if n := hub.ClientCount(); n != 0 {
+		t.Errorf("Expected the slow client to be dropped, got %d clients", n)
+	}
+	if _, open := <-slow.send; !open {
+		t.Error("Expected the queued event to stay readable")
+	}
+	if _, open := <-slow.send; open {
+		t.Error("Expected the dropped client's queue to be closed")
+	}
+}
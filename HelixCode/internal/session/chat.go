@@ -0,0 +1,171 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/paths"
+)
+
+// ErrChatNotFound is wrapped by errors for chat sessions that were never saved
+var ErrChatNotFound = errors.New("chat session not found")
+
+// chatNamePattern restricts session names to ones that are safe file names
+var chatNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ChatSession is a named chat conversation kept between CLI invocations
+type ChatSession struct {
+	Name      string        `json:"name"`
+	Model     string        `json:"model,omitempty"`
+	Messages  []llm.Message `json:"messages"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ChatSessionInfo summarizes a saved chat session
+type ChatSessionInfo struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model,omitempty"`
+	Messages  int       `json:"messages"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChatStore saves chat sessions as JSON files in a directory, one per session
+type ChatStore struct {
+	dir string
+}
+
+// DefaultChatDir returns the directory chat sessions are saved in, from
+// HELIX_SESSIONS_DIR or else in the user's HelixCode config directory
+func DefaultChatDir() string {
+	if dir := os.Getenv("HELIX_SESSIONS_DIR"); dir != "" {
+		return paths.Expand(dir)
+	}
+	return paths.Expand("~/.config/helixcode/sessions")
+}
+
+// NewChatStore creates a store for the sessions in dir, which is created on
+// the first save
+func NewChatStore(dir string) *ChatStore {
+	return &ChatStore{dir: paths.Expand(dir)}
+}
+
+// ValidateChatName checks that a session name can be used as a file name
+func ValidateChatName(name string) error {
+	if !chatNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid session name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// Save writes a session, replacing any saved under the same name. It sets
+// UpdatedAt, and CreatedAt for a session saved for the first time.
+func (s *ChatStore) Save(chat *ChatSession) error {
+	if err := ValidateChatName(chat.Name); err != nil {
+		return err
+	}
+	now := time.Now()
+	if chat.CreatedAt.IsZero() {
+		chat.CreatedAt = now
+	}
+	chat.UpdatedAt = now
+
+	data, err := json.MarshalIndent(chat, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode chat session: %v", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %v", err)
+	}
+
+	// Write a temporary file first so an interrupted save keeps the old session
+	tmp, err := os.CreateTemp(s.dir, chat.Name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write chat session: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write chat session: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write chat session: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(chat.Name)); err != nil {
+		return fmt.Errorf("failed to write chat session: %v", err)
+	}
+	return nil
+}
+
+// Load reads the session saved under name
+func (s *ChatStore) Load(name string) (*ChatSession, error) {
+	if err := ValidateChatName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat session: %v", err)
+	}
+
+	var chat ChatSession
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, fmt.Errorf("failed to parse chat session %s: %v", s.path(name), err)
+	}
+	chat.Name = name
+	return &chat, nil
+}
+
+// List summarizes the saved sessions, most recently updated first. Files
+// that cannot be parsed are skipped.
+func (s *ChatStore) List() ([]ChatSessionInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat sessions: %v", err)
+	}
+
+	var sessions []ChatSessionInfo
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		chat, err := s.Load(name)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, ChatSessionInfo{
+			Name:      chat.Name,
+			Model:     chat.Model,
+			Messages:  len(chat.Messages),
+			UpdatedAt: chat.UpdatedAt,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].UpdatedAt.Equal(sessions[j].UpdatedAt) {
+			return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+		}
+		return sessions[i].Name < sessions[j].Name
+	})
+	return sessions, nil
+}
+
+// path returns the file of the session saved under name
+func (s *ChatStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/llm"
+)
+
+func TestChatStore_SaveLoad(t *testing.T) {
+	store := NewChatStore(filepath.Join(t.TempDir(), "sessions"))
+
+	chat := &ChatSession{
+		Name:  "mywork",
+		Model: "llama3",
+		Messages: []llm.Message{
+			{Role: "user", Content: "hello"},
+			{Role: "assistant", Content: "Hi!"},
+		},
+	}
+	if err := store.Save(chat); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if chat.CreatedAt.IsZero() || chat.UpdatedAt.IsZero() {
+		t.Fatal("save did not set the timestamps")
+	}
+
+	loaded, err := store.Load("mywork")
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if loaded.Name != chat.Name || loaded.Model != chat.Model || !reflect.DeepEqual(loaded.Messages, chat.Messages) {
+		t.Fatalf("loaded %+v, want %+v", loaded, chat)
+	}
+	if !loaded.CreatedAt.Equal(chat.CreatedAt) {
+		t.Fatalf("created at %v, want %v", loaded.CreatedAt, chat.CreatedAt)
+	}
+
+	// Saving again keeps the creation time and replaces the messages
+	created := loaded.CreatedAt
+	loaded.Messages = append(loaded.Messages, llm.Message{Role: "user", Content: "more"})
+	if err := store.Save(loaded); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	reloaded, err := store.Load("mywork")
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if len(reloaded.Messages) != 3 || !reloaded.CreatedAt.Equal(created) {
+		t.Fatalf("reloaded %+v", reloaded)
+	}
+}
+
+func TestChatStore_LoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	store := NewChatStore(dir)
+
+	if _, err := store.Load("missing"); !errors.Is(err, ErrChatNotFound) {
+		t.Fatalf("expected ErrChatNotFound, got %v", err)
+	}
+	for _, name := range []string{"", "../escape", "a/b", ".hidden"} {
+		if _, err := store.Load(name); err == nil {
+			t.Errorf("expected an error loading %q", name)
+		}
+		if err := store.Save(&ChatSession{Name: name}); err == nil {
+			t.Errorf("expected an error saving %q", name)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := store.Load("broken"); err == nil {
+		t.Fatal("expected an error loading a corrupt session")
+	}
+}
+
+func TestChatStore_List(t *testing.T) {
+	dir := t.TempDir()
+	store := NewChatStore(dir)
+
+	sessions, err := store.List()
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no sessions, got %v (%v)", sessions, err)
+	}
+	sessions, err = NewChatStore(filepath.Join(dir, "missing")).List()
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no sessions for a missing directory, got %v (%v)", sessions, err)
+	}
+
+	for _, name := range []string{"older", "newer"} {
+		chat := &ChatSession{Name: name, Model: "llama3", Messages: []llm.Message{{Role: "user", Content: name}}}
+		if err := store.Save(chat); err != nil {
+			t.Fatalf("failed to save session: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Files that are not sessions are left out
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)
+
+	sessions, err = store.List()
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Name != "newer" || sessions[1].Name != "older" {
+		t.Fatalf("listed %+v, want newer then older", sessions)
+	}
+	if sessions[0].Model != "llama3" || sessions[0].Messages != 1 {
+		t.Fatalf("unexpected summary %+v", sessions[0])
+	}
+}
@@ -0,0 +1,27 @@
+package task
+
+import (
+	"context"
+
+	"dev.helix.code/internal/audit"
+	"github.com/google/uuid"
+)
+
+// SetAuditLog records task creation, status changes and deletion in log.
+// Changes not attributed to an actor in the context are recorded as the scheduler's.
+func (tm *TaskManager) SetAuditLog(log *audit.Log) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.audit = log
+}
+
+// SetAuditLog records task status changes and deletion in log.
+// Changes not attributed to an actor in the context are recorded as the API's.
+func (m *DatabaseManager) SetAuditLog(log *audit.Log) {
+	m.audit = log
+}
+
+// auditTask records a change to a task in log, which may be nil
+func auditTask(ctx context.Context, log *audit.Log, fallback audit.Source, taskID uuid.UUID, action string, from, to TaskStatus, details map[string]interface{}) {
+	log.Record(ctx, fallback, audit.EntityTask, taskID, action, string(from), string(to), details)
+}
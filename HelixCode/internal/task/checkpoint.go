@@ -19,6 +19,10 @@ func NewCheckpointManager(db *database.Database) *CheckpointManager {
 
 // CreateCheckpoint creates a checkpoint for a task
 func (cm *CheckpointManager) CreateCheckpoint(taskID uuid.UUID, checkpointName string, checkpointData map[string]interface{}) error {
+	if !cm.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	// Convert checkpoint data to JSON
@@ -48,6 +52,10 @@ func (cm *CheckpointManager) CreateCheckpoint(taskID uuid.UUID, checkpointName s
 
 // GetCheckpoints returns all checkpoints for a task
 func (cm *CheckpointManager) GetCheckpoints(taskID uuid.UUID) ([]Checkpoint, error) {
+	if !cm.db.IsConfigured() {
+		return []Checkpoint{}, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	rows, err := cm.db.Pool.Query(ctx, `
@@ -92,6 +100,10 @@ func (cm *CheckpointManager) GetCheckpoints(taskID uuid.UUID) ([]Checkpoint, err
 
 // GetLatestCheckpoint returns the latest checkpoint for a task
 func (cm *CheckpointManager) GetLatestCheckpoint(taskID uuid.UUID) (*Checkpoint, error) {
+	if !cm.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	var checkpoint Checkpoint
@@ -125,6 +137,10 @@ func (cm *CheckpointManager) GetLatestCheckpoint(taskID uuid.UUID) (*Checkpoint,
 
 // DeleteCheckpoint deletes a specific checkpoint
 func (cm *CheckpointManager) DeleteCheckpoint(checkpointID uuid.UUID) error {
+	if !cm.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	_, err := cm.db.Pool.Exec(ctx, `
@@ -141,6 +157,10 @@ func (cm *CheckpointManager) DeleteCheckpoint(checkpointID uuid.UUID) error {
 
 // DeleteAllCheckpoints deletes all checkpoints for a task
 func (cm *CheckpointManager) DeleteAllCheckpoints(taskID uuid.UUID) error {
+	if !cm.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	_, err := cm.db.Pool.Exec(ctx, `
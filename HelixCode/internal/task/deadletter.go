@@ -0,0 +1,124 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"dev.helix.code/internal/notification"
+	"github.com/google/uuid"
+)
+
+// RetryAttempt records a single failed attempt of a task
+type RetryAttempt struct {
+	Attempt  int        `json:"attempt"`
+	Error    string     `json:"error"`
+	WorkerID *uuid.UUID `json:"worker_id,omitempty"`
+	FailedAt time.Time  `json:"failed_at"`
+}
+
+// DeadLetterEntry captures a task that exhausted its retries
+type DeadLetterEntry struct {
+	Task           *Task          `json:"task"`
+	FinalError     string         `json:"final_error"`
+	RetryHistory   []RetryAttempt `json:"retry_history"`
+	DeadLetteredAt time.Time      `json:"dead_lettered_at"`
+}
+
+// SetNotificationEngine sets the engine notified when tasks are dead-lettered
+// and as the subtasks of split tasks complete
+func (tm *TaskManager) SetNotificationEngine(engine *notification.NotificationEngine) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.notifier = engine
+	tm.progress = nil
+	if engine != nil {
+		tm.progress = notification.NewProgressCoalescer(engine, notification.DefaultCoalesceWindow)
+		tm.progress.SetClock(tm.clock)
+	}
+}
+
+// ListDeadLetterTasks returns snapshots of all dead-lettered tasks, oldest first
+func (tm *TaskManager) ListDeadLetterTasks() []*DeadLetterEntry {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	entries := make([]*DeadLetterEntry, 0, len(tm.deadLetters))
+	for _, entry := range tm.deadLetters {
+		c := *entry
+		c.Task = entry.Task.snapshot()
+		entries = append(entries, &c)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeadLetteredAt.Before(entries[j].DeadLetteredAt)
+	})
+	return entries
+}
+
+// RequeueDeadLetter resubmits a dead-lettered task with a fresh retry budget.
+// The retry history of earlier attempts is kept on the task.
+func (tm *TaskManager) RequeueDeadLetter(taskID uuid.UUID) (*Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	entry, exists := tm.deadLetters[taskID]
+	if !exists {
+		return nil, fmt.Errorf("dead-lettered task not found: %s", taskID)
+	}
+
+	task := entry.Task
+	task.setStatus(TaskStatusPending)
+	task.RetryCount = 0
+	task.ErrorMessage = ""
+	task.AssignedWorker = nil
+	task.CompletedAt = nil
+	task.UpdatedAt = tm.clock.Now()
+
+	delete(tm.deadLetters, taskID)
+	tm.tasks[taskID] = task
+	tm.queue.AddTask(task)
+	tm.updateTaskInDB(task)
+
+	log.Printf("🔄 Dead-lettered task %s requeued", taskID)
+	return task, nil
+}
+
+// deadLetter moves a permanently failed task into the dead-letter store.
+// The caller must hold tm.mu.
+func (tm *TaskManager) deadLetter(task *Task, errorMessage string) {
+	entry := &DeadLetterEntry{
+		Task:           task,
+		FinalError:     errorMessage,
+		RetryHistory:   append([]RetryAttempt(nil), task.RetryHistory...),
+		DeadLetteredAt: tm.clock.Now(),
+	}
+	tm.deadLetters[task.ID] = entry
+	log.Printf("☠️ Task %s dead-lettered after %d attempts", task.ID, len(entry.RetryHistory))
+
+	if tm.notifier == nil {
+		return
+	}
+
+	n := &notification.Notification{
+		Title:    fmt.Sprintf("Task %s dead-lettered", task.ID),
+		Message:  fmt.Sprintf("%s task failed permanently after %d attempts: %s", task.Type, len(entry.RetryHistory), errorMessage),
+		Type:     notification.NotificationTypeError,
+		Priority: notification.NotificationPriorityHigh,
+		Metadata: map[string]interface{}{
+			"task_id":     task.ID.String(),
+			"task_type":   string(task.Type),
+			"final_error": errorMessage,
+			"attempts":    len(entry.RetryHistory),
+		},
+	}
+
+	// Send outside the manager lock so slow channels do not block task processing
+	notifier := tm.notifier
+	go func() {
+		if err := notifier.SendNotification(context.Background(), n); err != nil {
+			log.Printf("⚠️ Failed to send dead-letter notification for task %s: %v", task.ID, err)
+		}
+	}()
+}
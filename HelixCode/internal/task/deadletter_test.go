@@ -0,0 +1,93 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/notification"
+	"github.com/google/uuid"
+)
+
+// captureChannel records notifications sent through it
+type captureChannel struct {
+	sent chan *notification.Notification
+}
+
+func (c *captureChannel) Send(ctx context.Context, n *notification.Notification) error {
+	c.sent <- n
+	return nil
+}
+func (c *captureChannel) GetName() string                   { return "capture" }
+func (c *captureChannel) IsEnabled() bool                   { return true }
+func (c *captureChannel) GetConfig() map[string]interface{} { return nil }
+
+// TestDeadLetterAfterMaxRetries tests dead-lettering, notification and requeuing
+func TestDeadLetterAfterMaxRetries(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+
+	channel := &captureChannel{sent: make(chan *notification.Notification, 1)}
+	engine := notification.NewNotificationEngine()
+	engine.RegisterChannel(channel)
+	engine.AddRule(notification.NotificationRule{Name: "errors", Condition: "type==error", Channels: []string{"capture"}, Enabled: true})
+	tm.SetNotificationEngine(engine)
+
+	task, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// The first MaxRetries failures are retried
+	for i := 0; i < task.MaxRetries; i++ {
+		startTask(t, tm, task.ID)
+		if err := tm.FailTask(task.ID, "compile error"); err != nil {
+			t.Fatalf("FailTask failed: %v", err)
+		}
+		if len(tm.ListDeadLetterTasks()) != 0 {
+			t.Fatalf("Task dead-lettered after %d failures", i+1)
+		}
+	}
+
+	startTask(t, tm, task.ID)
+	if err := tm.FailTask(task.ID, "linker error"); err != nil {
+		t.Fatalf("FailTask failed: %v", err)
+	}
+
+	entries := tm.ListDeadLetterTasks()
+	if len(entries) != 1 {
+		t.Fatalf("Expected one dead-lettered task, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.FinalError != "linker error" || entry.Task.Status != TaskStatusFailed {
+		t.Errorf("Unexpected dead-letter entry: %q, %s", entry.FinalError, entry.Task.Status)
+	}
+	if len(entry.RetryHistory) != task.MaxRetries+1 {
+		t.Errorf("Expected %d attempts in history, got %d", task.MaxRetries+1, len(entry.RetryHistory))
+	}
+
+	select {
+	case n := <-channel.sent:
+		if n.Metadata["task_id"] != task.ID.String() {
+			t.Errorf("Notification for wrong task: %v", n.Metadata["task_id"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a dead-letter notification")
+	}
+
+	requeued, err := tm.RequeueDeadLetter(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to requeue task: %v", err)
+	}
+	if requeued.Status != TaskStatusPending || requeued.RetryCount != 0 || requeued.ErrorMessage != "" {
+		t.Errorf("Requeued task not reset: %s, retries %d, error %q", requeued.Status, requeued.RetryCount, requeued.ErrorMessage)
+	}
+	if len(requeued.RetryHistory) != task.MaxRetries+1 {
+		t.Errorf("Retry history should be kept after requeue, got %d entries", len(requeued.RetryHistory))
+	}
+	if len(tm.ListDeadLetterTasks()) != 0 {
+		t.Error("Dead-letter store should be empty after requeue")
+	}
+	if _, err := tm.RequeueDeadLetter(task.ID); err == nil {
+		t.Error("Expected error when requeuing a task that is not dead-lettered")
+	}
+}
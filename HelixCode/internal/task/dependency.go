@@ -21,6 +21,10 @@ func (dm *DependencyManager) ValidateDependencies(dependencies []uuid.UUID) erro
 		return nil
 	}
 
+	if !dm.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	// Check if all dependencies exist
@@ -48,6 +52,10 @@ func (dm *DependencyManager) CheckDependenciesCompleted(dependencies []uuid.UUID
 		return true, nil
 	}
 
+	if !dm.db.IsConfigured() {
+		return false, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	// Count completed dependencies
@@ -70,6 +78,10 @@ func (dm *DependencyManager) GetBlockingDependencies(dependencies []uuid.UUID) (
 		return []uuid.UUID{}, nil
 	}
 
+	if !dm.db.IsConfigured() {
+		return []uuid.UUID{}, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	rows, err := dm.db.Pool.Query(ctx, `
@@ -132,6 +144,10 @@ func (dm *DependencyManager) GetDependencyChain(taskID uuid.UUID) ([]uuid.UUID,
 
 // GetDependentTasks returns all tasks that depend on the given task
 func (dm *DependencyManager) GetDependentTasks(taskID uuid.UUID) ([]uuid.UUID, error) {
+	if !dm.db.IsConfigured() {
+		return []uuid.UUID{}, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	rows, err := dm.db.Pool.Query(ctx, `
@@ -189,6 +205,10 @@ func (dm *DependencyManager) checkCircularDependency(currentID uuid.UUID, target
 }
 
 func (dm *DependencyManager) getTaskDependencies(taskID uuid.UUID) ([]uuid.UUID, error) {
+	if !dm.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
 	ctx := context.Background()
 
 	var dependencies []uuid.UUID
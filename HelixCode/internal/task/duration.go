@@ -0,0 +1,158 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"dev.helix.code/internal/database"
+)
+
+const (
+	// DefaultEstimatedDuration is used for task types without any recorded runs
+	DefaultEstimatedDuration = 10 * time.Minute
+	// DefaultDurationSmoothing is the weight given to the newest observation in the rolling average
+	DefaultDurationSmoothing = 0.3
+)
+
+// DurationStats holds the learned duration of a task type
+type DurationStats struct {
+	TaskType TaskType      `json:"task_type"`
+	Average  time.Duration `json:"average"`
+	Samples  int           `json:"samples"`
+}
+
+// DurationEstimator learns task durations per task type from completed runs.
+// Estimates are a rolling (exponentially weighted) average of observed durations
+// and are persisted in the task_duration_stats table when a database is configured.
+type DurationEstimator struct {
+	db        *database.Database
+	mu        sync.RWMutex
+	stats     map[TaskType]*DurationStats
+	fallback  time.Duration
+	smoothing float64
+}
+
+// NewDurationEstimator creates a new duration estimator
+func NewDurationEstimator(db *database.Database) *DurationEstimator {
+	return &DurationEstimator{
+		db:        db,
+		stats:     make(map[TaskType]*DurationStats),
+		fallback:  DefaultEstimatedDuration,
+		smoothing: DefaultDurationSmoothing,
+	}
+}
+
+// SetDefault sets the estimate used for task types without history
+func (e *DurationEstimator) SetDefault(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fallback = d
+}
+
+// Estimate returns the expected duration of a task type
+func (e *DurationEstimator) Estimate(taskType TaskType) time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if stats, ok := e.stats[taskType]; ok && stats.Samples > 0 {
+		return stats.Average
+	}
+	return e.fallback
+}
+
+// Stats returns the learned statistics of a task type
+func (e *DurationEstimator) Stats(taskType TaskType) (DurationStats, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	stats, ok := e.stats[taskType]
+	if !ok {
+		return DurationStats{TaskType: taskType}, false
+	}
+	return *stats, true
+}
+
+// Record adds an observed duration for a task type and persists the updated average
+func (e *DurationEstimator) Record(ctx context.Context, taskType TaskType, actual time.Duration) error {
+	if actual <= 0 {
+		return fmt.Errorf("invalid duration for %s task: %v", taskType, actual)
+	}
+
+	e.mu.Lock()
+	stats, ok := e.stats[taskType]
+	if !ok {
+		stats = &DurationStats{TaskType: taskType}
+		e.stats[taskType] = stats
+	}
+	if stats.Samples == 0 {
+		stats.Average = actual
+	} else {
+		stats.Average = time.Duration(e.smoothing*float64(actual) + (1-e.smoothing)*float64(stats.Average))
+	}
+	stats.Samples++
+	snapshot := *stats
+	e.mu.Unlock()
+
+	if !e.db.IsConfigured() {
+		return nil
+	}
+
+	_, err := e.db.Pool.Exec(ctx, `
+		INSERT INTO task_duration_stats (task_type, average_duration_ms, sample_count, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (task_type) DO UPDATE
+		SET average_duration_ms = EXCLUDED.average_duration_ms,
+			sample_count = EXCLUDED.sample_count,
+			updated_at = NOW()
+	`, string(snapshot.TaskType), snapshot.Average.Milliseconds(), snapshot.Samples)
+	if err != nil {
+		return fmt.Errorf("failed to store duration stats: %v", err)
+	}
+
+	return nil
+}
+
+// Load restores learned durations from the database
+func (e *DurationEstimator) Load(ctx context.Context) error {
+	if !e.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
+	rows, err := e.db.Pool.Query(ctx, `
+		SELECT task_type, average_duration_ms, sample_count FROM task_duration_stats
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query duration stats: %v", err)
+	}
+	defer rows.Close()
+
+	loaded := make(map[TaskType]*DurationStats)
+	for rows.Next() {
+		var (
+			taskType  string
+			averageMS int64
+			samples   int
+		)
+		if err := rows.Scan(&taskType, &averageMS, &samples); err != nil {
+			return fmt.Errorf("failed to scan duration stats: %v", err)
+		}
+		loaded[TaskType(taskType)] = &DurationStats{
+			TaskType: TaskType(taskType),
+			Average:  time.Duration(averageMS) * time.Millisecond,
+			Samples:  samples,
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating duration stats: %v", err)
+	}
+
+	e.mu.Lock()
+	e.stats = loaded
+	e.mu.Unlock()
+
+	log.Printf("✅ Loaded duration estimates for %d task types", len(loaded))
+	return nil
+}
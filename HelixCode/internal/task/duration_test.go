@@ -0,0 +1,85 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestDurationEstimator tests that estimates move toward observed durations
+func TestDurationEstimator(t *testing.T) {
+	ctx := context.Background()
+	e := NewDurationEstimator(MockDatabase())
+
+	// Cold start falls back to the default
+	if got := e.Estimate(TaskTypeBuilding); got != DefaultEstimatedDuration {
+		t.Errorf("Expected default estimate %v, got %v", DefaultEstimatedDuration, got)
+	}
+
+	// The first observation replaces the default
+	if err := e.Record(ctx, TaskTypeBuilding, 2*time.Minute); err != nil {
+		t.Fatalf("Failed to record duration: %v", err)
+	}
+	if got := e.Estimate(TaskTypeBuilding); got != 2*time.Minute {
+		t.Errorf("Expected estimate of 2m after first run, got %v", got)
+	}
+
+	// Further observations pull the estimate toward the new durations
+	previous := e.Estimate(TaskTypeBuilding)
+	for i := 0; i < 10; i++ {
+		if err := e.Record(ctx, TaskTypeBuilding, 30*time.Second); err != nil {
+			t.Fatalf("Failed to record duration: %v", err)
+		}
+		current := e.Estimate(TaskTypeBuilding)
+		if current >= previous || current < 30*time.Second {
+			t.Fatalf("Estimate should decrease toward 30s, went from %v to %v", previous, current)
+		}
+		previous = current
+	}
+	if previous > 35*time.Second {
+		t.Errorf("Expected estimate close to 30s after repeated runs, got %v", previous)
+	}
+
+	stats, ok := e.Stats(TaskTypeBuilding)
+	if !ok || stats.Samples != 11 {
+		t.Errorf("Expected 11 samples, got %+v", stats)
+	}
+
+	// Other task types are unaffected
+	if got := e.Estimate(TaskTypeTesting); got != DefaultEstimatedDuration {
+		t.Errorf("Expected default estimate for testing tasks, got %v", got)
+	}
+	if err := e.Record(ctx, TaskTypeTesting, 0); err == nil {
+		t.Error("Expected error for zero duration")
+	}
+}
+
+// TestTaskManagerLearnsDurations tests that new tasks use durations learned from completed ones
+func TestTaskManagerLearnsDurations(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+
+	first, err := tm.CreateTask(TaskTypeTesting, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if first.EstimatedDuration != DefaultEstimatedDuration {
+		t.Errorf("Expected default estimate for first task, got %v", first.EstimatedDuration)
+	}
+
+	startTask(t, tm, first.ID)
+	started := time.Now().Add(-3 * time.Minute)
+	first.StartedAt = &started
+	if err := tm.CompleteTask(first.ID, nil); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	second, err := tm.CreateTask(TaskTypeTesting, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if second.EstimatedDuration < 3*time.Minute || second.EstimatedDuration > 4*time.Minute {
+		t.Errorf("Expected estimate of about 3m, got %v", second.EstimatedDuration)
+	}
+}
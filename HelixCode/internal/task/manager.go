@@ -1,13 +1,17 @@
 package task
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/clock"
 	"dev.helix.code/internal/database"
+	"dev.helix.code/internal/notification"
 )
 
 // TaskType represents different types of tasks
@@ -54,6 +58,7 @@ const (
 	TaskStatusRunning            TaskStatus = "running"
 	TaskStatusCompleted          TaskStatus = "completed"
 	TaskStatusFailed             TaskStatus = "failed"
+	TaskStatusCancelled          TaskStatus = "cancelled"
 	TaskStatusPaused             TaskStatus = "paused"
 	TaskStatusWaitingForWorker   TaskStatus = "waiting_for_worker"
 	TaskStatusWaitingForDeps     TaskStatus = "waiting_for_deps"
@@ -84,22 +89,42 @@ type Task struct {
 	ErrorMessage    string          `json:"error_message"`
 	ResultData      map[string]interface{} `json:"result_data"`
 	CheckpointData  map[string]interface{} `json:"checkpoint_data"`
+	RetryHistory    []RetryAttempt  `json:"retry_history,omitempty"`
 	EstimatedDuration time.Duration `json:"estimated_duration"`
 	StartedAt       *time.Time      `json:"started_at"`
 	CompletedAt     *time.Time      `json:"completed_at"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
+	Version         int             `json:"version"` // Bumped on every status change
+	CreatedBy       *uuid.UUID      `json:"created_by,omitempty"` // The user who submitted the task through the API
 }
 
-// TaskManager manages distributed tasks
+// TaskManager manages distributed tasks.
+//
+// Locking: mu guards tasks, workers, subtasks, parents, deadLetters, notifier,
+// progress, payloadLimits and clock,
+// and every field of the tasks and workers stored in them. Readers take mu.RLock,
+// anything that mutates a task or worker takes mu.Lock. Tasks leave the manager
+// as snapshots so callers never read fields a concurrent update is writing. The
+// queue, estimator and sub-managers synchronize themselves and may be called
+// with mu held; mu is always acquired first.
 type TaskManager struct {
 	db            *database.Database
 	mu            sync.RWMutex
 	tasks         map[uuid.UUID]*Task
 	workers       map[uuid.UUID]*Worker
+	subtasks      map[uuid.UUID][]uuid.UUID // Split task -> its subtasks
+	parents       map[uuid.UUID]uuid.UUID   // Subtask -> the task it was split from
 	queue         *TaskQueue
 	checkpointMgr *CheckpointManager
 	dependencyMgr *DependencyManager
+	durations     *DurationEstimator
+	deadLetters   map[uuid.UUID]*DeadLetterEntry
+	notifier      *notification.NotificationEngine
+	progress      *notification.ProgressCoalescer
+	payloadLimits PayloadLimits
+	clock         clock.Clock
+	audit         *audit.Log
 }
 
 // Worker represents a worker node
@@ -128,6 +153,14 @@ type TaskQueue struct {
 	normalPriority []*Task
 	lowPriority    []*Task
 	mu             sync.RWMutex
+
+	// enqueuedAt and the wait samples time how long tasks wait for a worker
+	enqueuedAt  map[uuid.UUID]time.Time
+	highWaits   waitSamples
+	normalWaits waitSamples
+	lowWaits    waitSamples
+	clock       clock.Clock
+	maxSize     int // Tasks Admit accepts; 0 is unlimited
 }
 
 // CheckpointManager manages task checkpoints
@@ -175,17 +208,54 @@ func NewTaskManager(db *database.Database) *TaskManager {
 		db:            db,
 		tasks:         make(map[uuid.UUID]*Task),
 		workers:       make(map[uuid.UUID]*Worker),
+		subtasks:      make(map[uuid.UUID][]uuid.UUID),
+		parents:       make(map[uuid.UUID]uuid.UUID),
 		queue:         NewTaskQueue(),
 		checkpointMgr: NewCheckpointManager(db),
 		dependencyMgr: NewDependencyManager(db),
+		durations:     NewDurationEstimator(db),
+		deadLetters:   make(map[uuid.UUID]*DeadLetterEntry),
+		payloadLimits: DefaultPayloadLimits(),
+		clock:         clock.New(),
+	}
+}
+
+// SetClock replaces the clock used for task timestamps and progress estimates
+func (tm *TaskManager) SetClock(c clock.Clock) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.clock = c
+	tm.queue.SetClock(c)
+	if tm.progress != nil {
+		tm.progress.SetClock(c)
 	}
 }
 
+// SetMaxQueueSize limits how many tasks may be queued before CreateTask
+// rejects new ones with ErrQueueFull; 0 is unlimited
+func (tm *TaskManager) SetMaxQueueSize(maxSize int) {
+	tm.queue.SetMaxSize(maxSize)
+}
+
+// QueueStats returns the depth of the task queue and how long tasks wait in it
+func (tm *TaskManager) QueueStats() QueueStats {
+	return tm.queue.GetQueueStats()
+}
+
 // CreateTask creates a new task
 func (tm *TaskManager) CreateTask(taskType TaskType, data map[string]interface{}, 
 	priority TaskPriority, criticality TaskCriticality, dependencies []uuid.UUID) (*Task, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	return tm.createTask(taskType, data, priority, criticality, dependencies)
+}
+
+// createTask creates and queues a task. The caller must hold tm.mu.
+func (tm *TaskManager) createTask(taskType TaskType, data map[string]interface{},
+	priority TaskPriority, criticality TaskCriticality, dependencies []uuid.UUID) (*Task, error) {
+	if err := tm.queue.Admit(priority); err != nil {
+		return nil, err
+	}
 
 	task := &Task{
 		ID:              uuid.New(),
@@ -196,8 +266,9 @@ func (tm *TaskManager) CreateTask(taskType TaskType, data map[string]interface{}
 		Criticality:     criticality,
 		Dependencies:    dependencies,
 		MaxRetries:      3,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		EstimatedDuration: tm.durations.Estimate(taskType),
+		CreatedAt:       tm.clock.Now(),
+		UpdatedAt:       tm.clock.Now(),
 	}
 
 	// Validate dependencies
@@ -216,7 +287,43 @@ func (tm *TaskManager) CreateTask(taskType TaskType, data map[string]interface{}
 
 	// Add to appropriate queue
 	tm.queue.AddTask(task)
+	auditTask(context.Background(), tm.audit, audit.SourceScheduler, task.ID, audit.ActionCreated, "", task.Status,
+		map[string]interface{}{"type": string(taskType)})
 
 	log.Printf("✅ Task created: %s (type: %s, priority: %d)", task.ID, taskType, priority)
 	return task, nil
-}
\ No newline at end of file
+}
+
+// snapshot returns a copy of the task that is safe to read without holding the
+// manager lock. The caller must hold tm.mu.
+func (t *Task) snapshot() *Task {
+	c := *t
+	c.Dependencies = append([]uuid.UUID(nil), t.Dependencies...)
+	c.RetryHistory = append([]RetryAttempt(nil), t.RetryHistory...)
+	if t.AssignedWorker != nil {
+		id := *t.AssignedWorker
+		c.AssignedWorker = &id
+	}
+	if t.OriginalWorker != nil {
+		id := *t.OriginalWorker
+		c.OriginalWorker = &id
+	}
+	if t.CreatedBy != nil {
+		id := *t.CreatedBy
+		c.CreatedBy = &id
+	}
+	if t.StartedAt != nil {
+		startedAt := *t.StartedAt
+		c.StartedAt = &startedAt
+	}
+	if t.CompletedAt != nil {
+		completedAt := *t.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	return &c
+}
+
+// Durations returns the estimator used to predict task durations
+func (tm *TaskManager) Durations() *DurationEstimator {
+	return tm.durations
+}
@@ -3,40 +3,43 @@ package task
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"dev.helix.code/internal/audit"
 	"dev.helix.code/internal/database"
 )
 
 // DatabaseManager handles task lifecycle and operations with database persistence
 type DatabaseManager struct {
-	db *database.Database
+	db        *database.Database
+	durations *DurationEstimator
+	audit     *audit.Log
 }
 
 // NewDatabaseManager creates a new task manager with database persistence
 func NewDatabaseManager(db *database.Database) *DatabaseManager {
 	return &DatabaseManager{
-		db: db,
+		db:        db,
+		durations: NewDurationEstimator(db),
 	}
 }
 
 // CreateTask creates a new task with database persistence
-func (m *DatabaseManager) CreateTask(ctx context.Context, name, description, taskType, priority string, parameters map[string]interface{}, dependencies []string) (*Task, error) {
-	// Convert priority string to TaskPriority
-	var taskPriority TaskPriority
-	switch priority {
-	case "high":
-		taskPriority = PriorityHigh
-	case "critical":
-		taskPriority = PriorityCritical
-	case "low":
-		taskPriority = PriorityLow
-	default:
-		taskPriority = PriorityNormal
+func (m *DatabaseManager) CreateTask(ctx context.Context, name, description, taskType, priority string, parameters map[string]interface{}, dependencies []string, createdBy string) (*Task, error) {
+	if !m.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
 	}
 
+	creator, err := parseCreator(createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	taskPriority := ParsePriority(priority)
+
 	// Convert dependencies to UUIDs
 	var dependencyUUIDs []uuid.UUID
 	for _, dep := range dependencies {
@@ -54,24 +57,26 @@ func (m *DatabaseManager) CreateTask(ctx context.Context, name, description, tas
 		Criticality: CriticalityNormal,
 		Dependencies: dependencyUUIDs,
 		MaxRetries:  3,
+		EstimatedDuration: m.durations.Estimate(TaskType(taskType)),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+		CreatedBy:   creator,
 	}
 
 	// Insert into database
 	query := `
 		INSERT INTO distributed_tasks (
 			id, task_type, task_data, status, priority, criticality, 
-			dependencies, max_retries, created_at, updated_at
+			dependencies, max_retries, estimated_duration, created_at, updated_at, created_by
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING created_at, updated_at
 	`
 
 	var createdAt, updatedAt time.Time
-	err := m.db.Pool.QueryRow(ctx, query,
+	err = m.db.Pool.QueryRow(ctx, query,
 		task.ID, task.Type, task.Data, task.Status, task.Priority, task.Criticality,
-		task.Dependencies, task.MaxRetries, task.CreatedAt, task.UpdatedAt,
+		task.Dependencies, task.MaxRetries, task.EstimatedDuration, task.CreatedAt, task.UpdatedAt, task.CreatedBy,
 	).Scan(&createdAt, &updatedAt)
 
 	if err != nil {
@@ -80,12 +85,23 @@ func (m *DatabaseManager) CreateTask(ctx context.Context, name, description, tas
 
 	task.CreatedAt = createdAt
 	task.UpdatedAt = updatedAt
+	auditTask(ctx, m.audit, audit.SourceAPI, task.ID, audit.ActionCreated, "", task.Status,
+		map[string]interface{}{"type": taskType})
 
 	return task, nil
 }
 
+// Durations returns the estimator used to predict task durations
+func (m *DatabaseManager) Durations() *DurationEstimator {
+	return m.durations
+}
+
 // GetTask retrieves a task by ID from database
 func (m *DatabaseManager) GetTask(ctx context.Context, id string) (*Task, error) {
+	if !m.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
 	taskID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid task ID: %v", err)
@@ -97,7 +113,7 @@ func (m *DatabaseManager) GetTask(ctx context.Context, id string) (*Task, error)
 			assigned_worker_id, original_worker_id, dependencies,
 			retry_count, max_retries, error_message, result_data,
 			checkpoint_data, estimated_duration, started_at, completed_at,
-			created_at, updated_at
+			created_at, updated_at, version, created_by
 		FROM distributed_tasks
 		WHERE id = $1
 	`
@@ -122,6 +138,8 @@ func (m *DatabaseManager) GetTask(ctx context.Context, id string) (*Task, error)
 		completedAt       *time.Time
 		createdAt         time.Time
 		updatedAt         time.Time
+		version           int
+		createdBy         *uuid.UUID
 	)
 
 	err = m.db.Pool.QueryRow(ctx, query, taskID).Scan(
@@ -129,12 +147,12 @@ func (m *DatabaseManager) GetTask(ctx context.Context, id string) (*Task, error)
 		&assignedWorkerID, &originalWorkerID, &dependencies,
 		&retryCount, &maxRetries, &errorMessage, &resultData,
 		&checkpointData, &estimatedDuration, &startedAt, &completedAt,
-		&createdAt, &updatedAt,
+		&createdAt, &updatedAt, &version, &createdBy,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("task not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get task from database: %v", err)
 	}
@@ -171,25 +189,38 @@ func (m *DatabaseManager) GetTask(ctx context.Context, id string) (*Task, error)
 		CompletedAt:      completedAt,
 		CreatedAt:        createdAt,
 		UpdatedAt:        updatedAt,
+		Version:          version,
+		CreatedBy:        createdBy,
 	}
 
 	return task, nil
 }
 
-// ListTasks returns all tasks from database
-func (m *DatabaseManager) ListTasks(ctx context.Context) ([]*Task, error) {
+// ListTasks returns the tasks createdBy submitted from database, or all tasks
+// when createdBy is empty
+func (m *DatabaseManager) ListTasks(ctx context.Context, createdBy string) ([]*Task, error) {
+	if !m.db.IsConfigured() {
+		return []*Task{}, database.ErrNotConfigured
+	}
+
+	creator, err := parseCreator(createdBy)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT 
 			id, task_type, task_data, status, priority, criticality,
 			assigned_worker_id, original_worker_id, dependencies,
 			retry_count, max_retries, error_message, result_data,
 			checkpoint_data, estimated_duration, started_at, completed_at,
-			created_at, updated_at
+			created_at, updated_at, version, created_by
 		FROM distributed_tasks
+		WHERE $1::uuid IS NULL OR created_by = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := m.db.Pool.Query(ctx, query)
+	rows, err := m.db.Pool.Query(ctx, query, creator)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %v", err)
 	}
@@ -217,6 +248,8 @@ func (m *DatabaseManager) ListTasks(ctx context.Context) ([]*Task, error) {
 			completedAt       *time.Time
 			createdAt         time.Time
 			updatedAt         time.Time
+			version           int
+			createdBy         *uuid.UUID
 		)
 
 		if err := rows.Scan(
@@ -224,7 +257,7 @@ func (m *DatabaseManager) ListTasks(ctx context.Context) ([]*Task, error) {
 			&assignedWorkerID, &originalWorkerID, &dependencies,
 			&retryCount, &maxRetries, &errorMessage, &resultData,
 			&checkpointData, &estimatedDuration, &startedAt, &completedAt,
-			&createdAt, &updatedAt,
+			&createdAt, &updatedAt, &version, &createdBy,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan task row: %v", err)
 		}
@@ -267,6 +300,8 @@ func (m *DatabaseManager) ListTasks(ctx context.Context) ([]*Task, error) {
 			CompletedAt:      completedAt,
 			CreatedAt:        createdAt,
 			UpdatedAt:        updatedAt,
+			Version:          version,
+			CreatedBy:        createdBy,
 		}
 
 		tasks = append(tasks, task)
@@ -280,7 +315,11 @@ func (m *DatabaseManager) ListTasks(ctx context.Context) ([]*Task, error) {
 }
 
 // StartTask marks a task as running
-func (m *DatabaseManager) StartTask(ctx context.Context, id string) error {
+func (m *DatabaseManager) StartTask(ctx context.Context, id string, version int) error {
+	if !m.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	taskID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid task ID: %v", err)
@@ -288,24 +327,29 @@ func (m *DatabaseManager) StartTask(ctx context.Context, id string) error {
 
 	query := `
 		UPDATE distributed_tasks 
-		SET status = 'running', started_at = NOW(), updated_at = NOW()
-		WHERE id = $1 AND status = 'pending'
+		SET status = 'running', started_at = NOW(), updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND status = 'pending' AND ($2::int = -1 OR version = $2)
 	`
 
-	result, err := m.db.Pool.Exec(ctx, query, taskID)
+	result, err := m.db.Pool.Exec(ctx, query, taskID, version)
 	if err != nil {
 		return fmt.Errorf("failed to start task: %v", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("task not found or not in pending state: %s", id)
+		return m.unappliedUpdate(ctx, taskID, fmt.Errorf("%w: task not in pending state or not at version %d: %s", ErrStatusConflict, version, id))
 	}
+	auditTask(ctx, m.audit, audit.SourceAPI, taskID, audit.ActionStatusChanged, TaskStatusPending, TaskStatusRunning, nil)
 
 	return nil
 }
 
 // CompleteTask marks a task as completed
-func (m *DatabaseManager) CompleteTask(ctx context.Context, id string, result map[string]interface{}) error {
+func (m *DatabaseManager) CompleteTask(ctx context.Context, id string, version int, result map[string]interface{}) error {
+	if !m.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	taskID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid task ID: %v", err)
@@ -313,24 +357,41 @@ func (m *DatabaseManager) CompleteTask(ctx context.Context, id string, result ma
 
 	query := `
 		UPDATE distributed_tasks 
-		SET status = 'completed', result_data = $1, completed_at = NOW(), updated_at = NOW()
-		WHERE id = $2 AND status = 'running'
+		SET status = 'completed', result_data = $1, completed_at = NOW(), updated_at = NOW(), version = version + 1
+		WHERE id = $2 AND status = 'running' AND ($3::int = -1 OR version = $3)
+		RETURNING task_type, started_at, completed_at
 	`
 
-	execResult, err := m.db.Pool.Exec(ctx, query, result, taskID)
+	var (
+		taskType    string
+		startedAt   *time.Time
+		completedAt time.Time
+	)
+	err = m.db.Pool.QueryRow(ctx, query, result, taskID, version).Scan(&taskType, &startedAt, &completedAt)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return m.unappliedUpdate(ctx, taskID, fmt.Errorf("%w: task not in running state or not at version %d: %s", ErrStatusConflict, version, id))
+		}
 		return fmt.Errorf("failed to complete task: %v", err)
 	}
+	auditTask(ctx, m.audit, audit.SourceAPI, taskID, audit.ActionStatusChanged, TaskStatusRunning, TaskStatusCompleted, nil)
 
-	if execResult.RowsAffected() == 0 {
-		return fmt.Errorf("task not found or not in running state: %s", id)
+	// Learn from the actual run time for future estimates
+	if startedAt != nil {
+		if err := m.durations.Record(ctx, TaskType(taskType), completedAt.Sub(*startedAt)); err != nil {
+			log.Printf("⚠️ Failed to record duration for task %s: %v", id, err)
+		}
 	}
 
 	return nil
 }
 
 // FailTask marks a task as failed
-func (m *DatabaseManager) FailTask(ctx context.Context, id, errorMessage string) error {
+func (m *DatabaseManager) FailTask(ctx context.Context, id string, version int, errorMessage string) error {
+	if !m.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	taskID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid task ID: %v", err)
@@ -338,24 +399,46 @@ func (m *DatabaseManager) FailTask(ctx context.Context, id, errorMessage string)
 
 	query := `
 		UPDATE distributed_tasks 
-		SET status = 'failed', error_message = $1, updated_at = NOW()
-		WHERE id = $2
+		SET status = 'failed', error_message = $1, updated_at = NOW(), version = version + 1
+		WHERE id = $2 AND status = ANY($3) AND ($4::int = -1 OR version = $4)
+		-- The subquery reads the row as it was before this update
+		RETURNING (SELECT status FROM distributed_tasks WHERE id = $2)
 	`
 
-	result, err := m.db.Pool.Exec(ctx, query, errorMessage, taskID)
+	var from string
+	err = m.db.Pool.QueryRow(ctx, query, errorMessage, taskID, sourceStatuses(TaskStatusFailed), version).Scan(&from)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return m.unappliedUpdate(ctx, taskID, fmt.Errorf("%w: task not running or not at version %d: %s", ErrStatusConflict, version, id))
+		}
 		return fmt.Errorf("failed to mark task as failed: %v", err)
 	}
-
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("task not found: %s", id)
-	}
+	auditTask(ctx, m.audit, audit.SourceAPI, taskID, audit.ActionStatusChanged, TaskStatus(from), TaskStatusFailed,
+		map[string]interface{}{"error": errorMessage})
 
 	return nil
 }
 
+// unappliedUpdate explains a status update that matched no row, returning
+// ErrTaskNotFound if the task does not exist and conflict otherwise
+func (m *DatabaseManager) unappliedUpdate(ctx context.Context, taskID uuid.UUID, conflict error) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM distributed_tasks WHERE id = $1)`
+	if err := m.db.Pool.QueryRow(ctx, query, taskID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check task: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	return conflict
+}
+
 // DeleteTask deletes a task from database
 func (m *DatabaseManager) DeleteTask(ctx context.Context, id string) error {
+	if !m.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	taskID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid task ID: %v", err)
@@ -369,8 +452,9 @@ func (m *DatabaseManager) DeleteTask(ctx context.Context, id string) error {
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("task not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
 	}
+	auditTask(ctx, m.audit, audit.SourceAPI, taskID, audit.ActionDeleted, "", "", nil)
 
 	return nil
 }
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+)
+
+// TestDatabaseManagerNilDatabase tests that DB-backed methods fail cleanly without a database
+func TestDatabaseManagerNilDatabase(t *testing.T) {
+	ctx := context.Background()
+	m := NewDatabaseManager(nil)
+	id := uuid.New().String()
+
+	tasks, err := m.ListTasks(ctx, "")
+	if !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("ListTasks: expected ErrNotConfigured, got %v", err)
+	}
+	if tasks == nil || len(tasks) != 0 {
+		t.Errorf("ListTasks: expected empty result, got %v", tasks)
+	}
+
+	if _, err := m.CreateTask(ctx, "build", "", string(TaskTypeBuilding), "high", nil, nil, ""); !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("CreateTask: expected ErrNotConfigured, got %v", err)
+	}
+	if _, err := m.GetTask(ctx, id); !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("GetTask: expected ErrNotConfigured, got %v", err)
+	}
+
+	for name, call := range map[string]func() error{
+		"StartTask":    func() error { return m.StartTask(ctx, id, AnyVersion) },
+		"CompleteTask": func() error { return m.CompleteTask(ctx, id, AnyVersion, nil) },
+		"FailTask":     func() error { return m.FailTask(ctx, id, AnyVersion, "boom") },
+		"DeleteTask":   func() error { return m.DeleteTask(ctx, id) },
+	} {
+		if err := call(); !errors.Is(err, database.ErrNotConfigured) {
+			t.Errorf("%s: expected ErrNotConfigured, got %v", name, err)
+		}
+	}
+}
+
+// TestTaskManagerNilDatabase tests checkpoint and dependency operations without a database
+func TestTaskManagerNilDatabase(t *testing.T) {
+	tm := NewTaskManager(nil)
+
+	// Tasks with dependencies cannot be validated without a database
+	if _, err := tm.CreateTask(TaskTypeTesting, map[string]interface{}{}, PriorityNormal, CriticalityNormal,
+		[]uuid.UUID{uuid.New()}); err == nil || !strings.Contains(err.Error(), database.ErrNotConfigured.Error()) {
+		t.Errorf("Expected no database error for dependency validation, got %v", err)
+	}
+
+	task, err := tm.CreateTask(TaskTypeTesting, map[string]interface{}{}, PriorityNormal, CriticalityNormal, nil)
+	if err != nil {
+		t.Fatalf("Failed to create task without dependencies: %v", err)
+	}
+
+	if err := tm.CreateCheckpoint(task.ID, "step-1", map[string]interface{}{"done": 1}); !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("CreateCheckpoint: expected ErrNotConfigured, got %v", err)
+	}
+
+	checkpoints, err := tm.checkpointMgr.GetCheckpoints(task.ID)
+	if !errors.Is(err, database.ErrNotConfigured) || len(checkpoints) != 0 {
+		t.Errorf("GetCheckpoints: expected empty result and ErrNotConfigured, got %v, %v", checkpoints, err)
+	}
+
+	if _, err := tm.dependencyMgr.GetDependencyChain(task.ID); !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("GetDependencyChain: expected ErrNotConfigured, got %v", err)
+	}
+	if _, err := tm.dependencyMgr.GetDependentTasks(task.ID); !errors.Is(err, database.ErrNotConfigured) {
+		t.Errorf("GetDependentTasks: expected ErrNotConfigured, got %v", err)
+	}
+	if done, err := tm.dependencyMgr.CheckDependenciesCompleted(nil); err != nil || !done {
+		t.Errorf("Tasks without dependencies should not need a database, got %v, %v", done, err)
+	}
+}
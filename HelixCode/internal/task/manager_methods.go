@@ -1,11 +1,13 @@
 package task
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
+	"dev.helix.code/internal/audit"
 	"github.com/google/uuid"
 )
 
@@ -34,7 +36,7 @@ func (tm *TaskManager) SplitTask(parentTaskID uuid.UUID, strategy SplitStrategy)
 	// Create subtasks
 	var createdSubtasks []*Task
 	for _, subtaskData := range subtasks {
-		subtask, err := tm.CreateTask(
+		subtask, err := tm.createTask(
 			parentTask.Type,
 			subtaskData.Data,
 			parentTask.Priority,
@@ -44,11 +46,17 @@ func (tm *TaskManager) SplitTask(parentTaskID uuid.UUID, strategy SplitStrategy)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create subtask: %v", err)
 		}
+		subtask.CreatedBy = parentTask.CreatedBy
 		createdSubtasks = append(createdSubtasks, subtask)
+		tm.subtasks[parentTaskID] = append(tm.subtasks[parentTaskID], subtask.ID)
+		tm.parents[subtask.ID] = parentTaskID
 	}
 
 	// Update parent task status
-	parentTask.Status = TaskStatusWaitingForDeps
+	parentTask.setStatus(TaskStatusWaitingForDeps)
+	if parentTask.Data == nil {
+		parentTask.Data = make(map[string]interface{})
+	}
 	parentTask.Data["subtasks"] = createdSubtasks
 	tm.updateTaskInDB(parentTask)
 
@@ -81,14 +89,22 @@ func (tm *TaskManager) AssignTask(taskID uuid.UUID, workerID uuid.UUID) error {
 		return fmt.Errorf("worker %s is at capacity", workerID)
 	}
 
+	if err := task.checkTransition(TaskStatusAssigned, AnyVersion); err != nil {
+		return err
+	}
+
 	// Update task
+	tm.queue.TakeTask(taskID.String())
+	from := task.Status
 	task.AssignedWorker = &workerID
-	task.Status = TaskStatusAssigned
-	task.UpdatedAt = time.Now()
+	task.setStatus(TaskStatusAssigned)
+	task.UpdatedAt = tm.clock.Now()
+	auditTask(context.Background(), tm.audit, audit.SourceScheduler, taskID, audit.ActionStatusChanged, from, task.Status,
+		map[string]interface{}{"worker_id": workerID.String()})
 
 	// Update worker
 	worker.CurrentTasksCount++
-	worker.UpdatedAt = time.Now()
+	worker.UpdatedAt = tm.clock.Now()
 
 	// Update in database
 	tm.updateTaskInDB(task)
@@ -98,22 +114,70 @@ func (tm *TaskManager) AssignTask(taskID uuid.UUID, workerID uuid.UUID) error {
 	return nil
 }
 
-// CompleteTask marks a task as completed
+// CompleteTask marks a task as completed. It fails with ErrStatusConflict
+// if the task has already finished or is pending, with no attempt running.
 func (tm *TaskManager) CompleteTask(taskID uuid.UUID, result map[string]interface{}) error {
+	return tm.completeTask(context.Background(), taskID, AnyVersion, result)
+}
+
+// CompleteTaskAtVersion marks a task as completed only if it is still at the
+// version the caller last saw, so a worker whose attempt was failed and
+// retried elsewhere cannot complete the newer attempt. Stale updates fail with
+// ErrStatusConflict.
+func (tm *TaskManager) CompleteTaskAtVersion(taskID uuid.UUID, version int, result map[string]interface{}) error {
+	return tm.completeTask(context.Background(), taskID, version, result)
+}
+
+// completeTask marks a task as completed, auditing the change as ctx's actor.
+// The run time is recorded outside the manager lock so that a slow database
+// does not stall other task operations.
+func (tm *TaskManager) completeTask(ctx context.Context, taskID uuid.UUID, version int, result map[string]interface{}) error {
+	taskType, elapsed, err := tm.markCompleted(ctx, taskID, version, result)
+	if err != nil {
+		return err
+	}
+
+	// Learn from the actual run time for future estimates
+	if elapsed > 0 {
+		if err := tm.durations.Record(ctx, taskType, elapsed); err != nil {
+			log.Printf("⚠️ Failed to record duration for task %s: %v", taskID, err)
+		}
+	}
+	return nil
+}
+
+// markCompleted completes a task under the manager lock, returning its type
+// and run time, which is zero for a task that was never started
+func (tm *TaskManager) markCompleted(ctx context.Context, taskID uuid.UUID, version int, result map[string]interface{}) (TaskType, time.Duration, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
 	task, exists := tm.tasks[taskID]
 	if !exists {
-		return fmt.Errorf("task not found: %s", taskID)
+		return "", 0, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if err := task.checkTransition(TaskStatusCompleted, version); err != nil {
+		return "", 0, err
+	}
+
+	result, err := limitPayload("result", result, tm.payloadLimits.MaxResultBytes, tm.payloadLimits.Mode)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to complete task %s: %v", taskID, err)
 	}
 
 	// Update task
-	task.Status = TaskStatusCompleted
+	from := task.Status
+	task.setStatus(TaskStatusCompleted)
 	task.ResultData = result
-	now := time.Now()
+	now := tm.clock.Now()
 	task.CompletedAt = &now
 	task.UpdatedAt = now
+	auditTask(ctx, tm.audit, audit.SourceScheduler, taskID, audit.ActionStatusChanged, from, task.Status, nil)
+
+	var elapsed time.Duration
+	if task.StartedAt != nil {
+		elapsed = now.Sub(*task.StartedAt)
+	}
 
 	// Update worker if assigned
 	if task.AssignedWorker != nil {
@@ -126,44 +190,104 @@ func (tm *TaskManager) CompleteTask(taskID uuid.UUID, result map[string]interfac
 
 	// Update in database
 	tm.updateTaskInDB(task)
+	tm.notifySubtaskProgress(task)
 
 	log.Printf("✅ Task %s completed", taskID)
-	return nil
+	return task.Type, elapsed, nil
+}
+
+// notifySubtaskProgress reports a completed subtask to the progress
+// coalescer, which summarizes completions of the same split task, and sends
+// the final notification when the split task itself completes. The caller
+// must hold tm.mu.
+func (tm *TaskManager) notifySubtaskProgress(task *Task) {
+	if tm.progress == nil {
+		return
+	}
+
+	if subtasks, split := tm.subtasks[task.ID]; split {
+		// Send outside the manager lock so slow channels do not block task processing
+		progress := tm.progress
+		go progress.Complete(task.ID.String(), fmt.Sprintf("Task %s complete", task.ID), len(subtasks),
+			map[string]interface{}{"task_id": task.ID.String(), "task_type": string(task.Type)})
+		return
+	}
+
+	parentID, isSubtask := tm.parents[task.ID]
+	if !isSubtask {
+		return
+	}
+	subtasks := tm.subtasks[parentID]
+	done := 0
+	for _, id := range subtasks {
+		if subtask, exists := tm.tasks[id]; exists && subtask.Status == TaskStatusCompleted {
+			done++
+		}
+	}
+	tm.progress.Progress(parentID.String(), fmt.Sprintf("Task %s progress", parentID), done, len(subtasks),
+		map[string]interface{}{"task_id": parentID.String(), "task_type": string(task.Type)})
 }
 
-// FailTask marks a task as failed
+// FailTask marks a task as failed, retrying it if it has retries left. It
+// fails with ErrStatusConflict if the task has already finished or is
+// pending, with no attempt running.
 func (tm *TaskManager) FailTask(taskID uuid.UUID, errorMessage string) error {
+	return tm.failTask(context.Background(), taskID, AnyVersion, errorMessage)
+}
+
+// FailTaskAtVersion marks a task as failed only if it is still at the version
+// the caller last saw. Stale updates fail with ErrStatusConflict.
+func (tm *TaskManager) FailTaskAtVersion(taskID uuid.UUID, version int, errorMessage string) error {
+	return tm.failTask(context.Background(), taskID, version, errorMessage)
+}
+
+// failTask marks a task as failed, auditing the change as ctx's actor
+func (tm *TaskManager) failTask(ctx context.Context, taskID uuid.UUID, version int, errorMessage string) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
 	task, exists := tm.tasks[taskID]
 	if !exists {
-		return fmt.Errorf("task not found: %s", taskID)
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
 	}
+	if err := task.checkTransition(TaskStatusFailed, version); err != nil {
+		return err
+	}
+
+	from := task.Status
+	task.RetryHistory = append(task.RetryHistory, RetryAttempt{
+		Attempt:  len(task.RetryHistory) + 1,
+		Error:    errorMessage,
+		WorkerID: task.AssignedWorker,
+		FailedAt: tm.clock.Now(),
+	})
 
 	// Check if we should retry
 	if task.RetryCount < task.MaxRetries {
 		task.RetryCount++
-		task.Status = TaskStatusPending
+		task.setStatus(TaskStatusPending)
 		task.ErrorMessage = errorMessage
 		task.AssignedWorker = nil
-		task.UpdatedAt = time.Now()
+		task.UpdatedAt = tm.clock.Now()
 
 		// Add back to queue
 		tm.queue.AddTask(task)
 		log.Printf("🔄 Task %s failed, retrying (attempt %d/%d)", taskID, task.RetryCount, task.MaxRetries)
 	} else {
-		task.Status = TaskStatusFailed
+		task.setStatus(TaskStatusFailed)
 		task.ErrorMessage = errorMessage
-		task.UpdatedAt = time.Now()
+		task.UpdatedAt = tm.clock.Now()
 		log.Printf("❌ Task %s failed permanently", taskID)
+		tm.deadLetter(task, errorMessage)
 	}
+	auditTask(ctx, tm.audit, audit.SourceScheduler, taskID, audit.ActionStatusChanged, from, task.Status,
+		map[string]interface{}{"error": errorMessage})
 
 	// Update worker if assigned
 	if task.AssignedWorker != nil {
 		if worker, exists := tm.workers[*task.AssignedWorker]; exists {
 			worker.CurrentTasksCount--
-			worker.UpdatedAt = time.Now()
+			worker.UpdatedAt = tm.clock.Now()
 			tm.updateWorkerInDB(worker)
 		}
 	}
@@ -184,6 +308,11 @@ func (tm *TaskManager) CreateCheckpoint(taskID uuid.UUID, checkpointName string,
 		return fmt.Errorf("task not found: %s", taskID)
 	}
 
+	checkpointData, err := limitPayload("checkpoint", checkpointData, tm.payloadLimits.MaxCheckpointBytes, tm.payloadLimits.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint for task %s: %v", taskID, err)
+	}
+
 	return tm.checkpointMgr.CreateCheckpoint(taskID, checkpointName, checkpointData)
 }
 
@@ -212,7 +341,7 @@ func (tm *TaskManager) GetTaskProgress(taskID uuid.UUID) (*TaskProgress, error)
 	case TaskStatusRunning:
 		// Estimate progress based on elapsed time vs estimated duration
 		if task.StartedAt != nil && task.EstimatedDuration > 0 {
-			elapsed := time.Since(*task.StartedAt)
+			elapsed := tm.clock.Since(*task.StartedAt)
 			progress.Progress = float64(elapsed) / float64(task.EstimatedDuration) * 100
 			if progress.Progress > 95 {
 				progress.Progress = 95 // Cap at 95% until actually completed
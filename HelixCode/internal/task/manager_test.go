@@ -1,11 +1,19 @@
 package task
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/clock"
 	"dev.helix.code/internal/database"
+	"dev.helix.code/internal/notification"
 )
 
 // MockDatabase creates a mock database for testing
@@ -71,6 +79,7 @@ func TestTaskManager_CompleteTask(t *testing.T) {
 		"duration": "2m30s",
 	}
 
+	startTask(t, tm, task.ID)
 	err = tm.CompleteTask(task.ID, result)
 	if err != nil {
 		t.Fatalf("Failed to complete task: %v", err)
@@ -97,6 +106,7 @@ func TestTaskManager_FailTask(t *testing.T) {
 		t.Fatalf("Failed to create task: %v", err)
 	}
 
+	startTask(t, tm, task.ID)
 	err = tm.FailTask(task.ID, "Test failure")
 	if err != nil {
 		t.Fatalf("Failed to mark task as failed: %v", err)
@@ -105,6 +115,47 @@ func TestTaskManager_FailTask(t *testing.T) {
 	// In a real test, we would verify the task status and retry count
 }
 
+func TestTaskManager_AuditLog(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+	auditLog := audit.NewLog(audit.NewMemoryStore())
+	tm.SetAuditLog(auditLog)
+
+	task, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := tm.Service().StartTask(context.Background(), task.ID.String(), AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	ctx := audit.WithActor(context.Background(), audit.Actor{Source: audit.SourceAPI, Name: "alice"})
+	if err := tm.Service().CompleteTask(ctx, task.ID.String(), AnyVersion, nil); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	page, err := auditLog.Query(context.Background(), audit.Query{EntityType: audit.EntityTask, EntityID: task.ID})
+	if err != nil {
+		t.Fatalf("Failed to query audit log: %v", err)
+	}
+	if len(page.Events) != 3 {
+		t.Fatalf("Expected 3 audit events, got %d", len(page.Events))
+	}
+
+	created, started, completed := page.Events[0], page.Events[1], page.Events[2]
+	if created.Action != audit.ActionCreated || created.Source != audit.SourceScheduler {
+		t.Errorf("Unexpected creation event: %+v", created)
+	}
+	// Service calls without an actor are attributed to the API
+	if started.ToStatus != string(TaskStatusRunning) || started.Source != audit.SourceAPI {
+		t.Errorf("Unexpected start event: %+v", started)
+	}
+	if completed.FromStatus != string(TaskStatusRunning) || completed.ToStatus != string(TaskStatusCompleted) {
+		t.Errorf("Unexpected completion transition %s -> %s", completed.FromStatus, completed.ToStatus)
+	}
+	if completed.Source != audit.SourceAPI || completed.Actor != "alice" {
+		t.Errorf("Expected completion attributed to the API user, got %s/%s", completed.Source, completed.Actor)
+	}
+}
+
 func TestTaskQueue_AddAndGet(t *testing.T) {
 	tq := NewTaskQueue()
 
@@ -214,6 +265,111 @@ func TestTaskQueue_Stats(t *testing.T) {
 	}
 }
 
+// TestTaskQueue_WaitStats tests that queue stats report how long tasks waited per priority
+func TestTaskQueue_WaitStats(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tq := NewTaskQueue()
+	tq.SetClock(mock)
+
+	var normal []*Task
+	for i := 0; i < 4; i++ {
+		task := &Task{ID: uuid.New(), Priority: PriorityNormal}
+		tq.AddTask(task)
+		normal = append(normal, task)
+		mock.Advance(time.Second)
+	}
+	high := &Task{ID: uuid.New(), Priority: PriorityHigh}
+	tq.AddTask(high)
+	cancelled := &Task{ID: uuid.New(), Priority: PriorityLow}
+	tq.AddTask(cancelled)
+
+	// The high priority task goes first, then the normal ones in order, at 10s
+	mock.Advance(10 * time.Second)
+	if next := tq.GetNextTask(); next.ID != high.ID {
+		t.Fatalf("Expected the high priority task first")
+	}
+	for _, task := range normal[:3] {
+		if next := tq.GetNextTask(); next.ID != task.ID {
+			t.Fatalf("Expected normal tasks in order")
+		}
+	}
+	mock.Advance(20 * time.Second)
+	if !tq.TakeTask(normal[3].ID.String()) {
+		t.Fatal("Expected TakeTask to find the last normal task")
+	}
+	tq.RemoveTask(cancelled.ID.String())
+
+	stats := tq.GetQueueStats()
+	if stats.Total != 0 {
+		t.Errorf("Expected an empty queue, got %d tasks", stats.Total)
+	}
+	if stats.HighPriorityWait.Count != 1 || stats.HighPriorityWait.Max != 10*time.Second {
+		t.Errorf("Unexpected high priority wait: %+v", stats.HighPriorityWait)
+	}
+
+	// Normal tasks waited 14s, 13s, 12s and 31s
+	want := WaitStats{Count: 4, P50: 13 * time.Second, P90: 31 * time.Second, P99: 31 * time.Second, Max: 31 * time.Second}
+	if stats.NormalPriorityWait != want {
+		t.Errorf("Normal priority wait = %+v, want %+v", stats.NormalPriorityWait, want)
+	}
+
+	// Removed tasks were never assigned, so they are not counted
+	if stats.LowPriorityWait.Count != 0 {
+		t.Errorf("Expected no low priority waits, got %+v", stats.LowPriorityWait)
+	}
+}
+
+// TestTaskManager_QueueStats tests that starting a task records its wait
+func TestTaskManager_QueueStats(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tm := NewTaskManager(MockDatabase())
+	tm.SetClock(mock)
+
+	task, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityLow, CriticalityNormal, nil)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if stats := tm.QueueStats(); stats.LowPriority != 1 {
+		t.Fatalf("Expected one queued low priority task, got %+v", stats)
+	}
+
+	mock.Advance(time.Minute)
+	if err := tm.Service().StartTask(context.Background(), task.ID.String(), AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	stats := tm.QueueStats()
+	if stats.LowPriority != 0 || stats.LowPriorityWait.Count != 1 || stats.LowPriorityWait.P50 != time.Minute {
+		t.Errorf("Unexpected queue stats after start: %+v", stats)
+	}
+}
+
+func TestTaskManager_MaxQueueSize(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+	tm.SetMaxQueueSize(2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, nil); err != nil {
+			t.Fatalf("Failed to create task %d: %v", i, err)
+		}
+	}
+	if _, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull for a full queue, got %v", err)
+	}
+
+	// Critical tasks may fill the queue up to its hard cap
+	for depth := 2; depth < QueueHardCap(2); depth++ {
+		if _, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityCritical, CriticalityCritical, nil); err != nil {
+			t.Fatalf("Expected a critical task to be admitted at depth %d, got %v", depth, err)
+		}
+	}
+	if _, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityCritical, CriticalityCritical, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull at the hard cap, got %v", err)
+	}
+	if depth := tm.QueueStats().Total; depth != QueueHardCap(2) {
+		t.Errorf("Expected %d queued tasks, got %d", QueueHardCap(2), depth)
+	}
+}
+
 func TestTaskManager_GetTaskProgress(t *testing.T) {
 	tm := NewTaskManager(MockDatabase())
 
@@ -247,4 +403,313 @@ func TestTaskManager_GetTaskProgress(t *testing.T) {
 	if progress.Progress != 0.0 {
 		t.Errorf("Expected progress 0.0, got %f", progress.Progress)
 	}
-}
\ No newline at end of file
+}
+
+func TestTaskManager_GetTaskProgressUsesClock(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tm := NewTaskManager(MockDatabase())
+	tm.SetClock(mock)
+	tm.durations.SetDefault(10 * time.Minute)
+
+	task, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, nil)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := tm.Service().StartTask(context.Background(), task.ID.String(), AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+
+	mock.Advance(5 * time.Minute)
+	progress, err := tm.GetTaskProgress(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to get task progress: %v", err)
+	}
+	if progress.Progress != 50.0 {
+		t.Errorf("Expected 50%% progress halfway through the estimate, got %f", progress.Progress)
+	}
+
+	mock.Advance(time.Hour)
+	progress, err = tm.GetTaskProgress(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to get task progress: %v", err)
+	}
+	if progress.Progress != 95.0 {
+		t.Errorf("Expected progress capped at 95%% past the estimate, got %f", progress.Progress)
+	}
+}
+
+// TestTaskManager_ConcurrentAccess creates, reads and updates tasks from many
+// goroutines. Run with -race to surface unsynchronized access.
+func TestTaskManager_ConcurrentAccess(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+	svc := tm.Service()
+	ctx := context.Background()
+
+	const goroutines = 8
+	const tasksPerGoroutine = 20
+
+	var writers, readers sync.WaitGroup
+	errs := make(chan error, goroutines*tasksPerGoroutine)
+	done := make(chan struct{})
+
+	for g := 0; g < goroutines; g++ {
+		writers.Add(1)
+		readers.Add(1)
+
+		// Writers walk each task through its lifecycle
+		go func(g int) {
+			defer writers.Done()
+			for i := 0; i < tasksPerGoroutine; i++ {
+				task, err := tm.CreateTask(TaskTypeTesting, map[string]interface{}{"n": i}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+				if err != nil {
+					errs <- err
+					return
+				}
+				id := task.ID.String()
+				if err := svc.StartTask(ctx, id, AnyVersion); err != nil {
+					errs <- err
+					continue
+				}
+				if i%2 == 0 {
+					err = tm.CompleteTask(task.ID, map[string]interface{}{"worker": g})
+				} else {
+					err = tm.FailTask(task.ID, fmt.Sprintf("attempt from %d", g))
+				}
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+
+		// Readers inspect every task until the writers are finished
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				tasks, err := svc.ListTasks(ctx, "")
+				if err != nil {
+					errs <- err
+					return
+				}
+				for _, task := range tasks {
+					if _, err := json.Marshal(task); err != nil {
+						errs <- err
+					}
+					if _, err := svc.GetTask(ctx, task.ID.String()); err != nil {
+						errs <- err
+					}
+					if _, err := tm.GetTaskProgress(task.ID); err != nil {
+						errs <- err
+					}
+				}
+				tm.ListDeadLetterTasks()
+			}
+		}()
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Concurrent operation failed: %v", err)
+	}
+
+	tasks, _ := svc.ListTasks(ctx, "")
+	if len(tasks) != goroutines*tasksPerGoroutine {
+		t.Errorf("Expected %d tasks, got %d", goroutines*tasksPerGoroutine, len(tasks))
+	}
+
+	t.Log("✅ Concurrent task access test passed")
+}
+
+// fixedSplit splits a task into count independent subtasks
+type fixedSplit struct {
+	count int
+}
+
+func (s fixedSplit) GenerateSubtasks(parent *Task, analysis *TaskAnalysis) ([]SubtaskData, error) {
+	subtasks := make([]SubtaskData, s.count)
+	for i := range subtasks {
+		subtasks[i] = SubtaskData{Data: map[string]interface{}{"part": i}}
+	}
+	return subtasks, nil
+}
+
+func TestTaskManager_SubtaskCompletionsCoalesced(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tm := NewTaskManager(MockDatabase())
+	tm.SetClock(mock)
+
+	channel := &captureChannel{sent: make(chan *notification.Notification, 10)}
+	engine := notification.NewNotificationEngine()
+	engine.RegisterChannel(channel)
+	engine.AddRule(notification.NotificationRule{Name: "all", Channels: []string{"capture"}, Enabled: true})
+	tm.SetNotificationEngine(engine)
+
+	parent, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, nil)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	subtasks, err := tm.SplitTask(parent.ID, fixedSplit{count: 5})
+	if err != nil {
+		t.Fatalf("Failed to split task: %v", err)
+	}
+
+	receive := func() *notification.Notification {
+		t.Helper()
+		select {
+		case n := <-channel.sent:
+			return n
+		case <-time.After(time.Second):
+			t.Fatal("Expected a notification")
+			return nil
+		}
+	}
+
+	for _, subtask := range subtasks[:3] {
+		startTask(t, tm, subtask.ID)
+		if err := tm.CompleteTask(subtask.ID, nil); err != nil {
+			t.Fatalf("Failed to complete subtask: %v", err)
+		}
+	}
+	select {
+	case n := <-channel.sent:
+		t.Fatalf("Expected completions to be held until the window closes, got %q", n.Message)
+	default:
+	}
+
+	mock.Advance(notification.DefaultCoalesceWindow)
+	if n := receive(); n.Message != "3/5 subtasks complete" || n.Metadata["task_id"] != parent.ID.String() {
+		t.Errorf("Unexpected summary: %q %v", n.Message, n.Metadata)
+	}
+
+	// The parent's completion replaces the summary still pending for the last subtasks
+	for _, subtask := range subtasks[3:] {
+		startTask(t, tm, subtask.ID)
+		if err := tm.CompleteTask(subtask.ID, nil); err != nil {
+			t.Fatalf("Failed to complete subtask: %v", err)
+		}
+	}
+	if err := tm.CompleteTask(parent.ID, nil); err != nil {
+		t.Fatalf("Failed to complete parent: %v", err)
+	}
+	if n := receive(); n.Message != "All 5 subtasks complete" {
+		t.Errorf("Expected the final notification, got %q", n.Message)
+	}
+
+	mock.Advance(notification.DefaultCoalesceWindow)
+	select {
+	case n := <-channel.sent:
+		t.Errorf("Expected no summary after the final notification, got %q", n.Message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTaskManager_StaleStatusUpdates(t *testing.T) {
+	tm := NewTaskManager(MockDatabase())
+	svc := tm.Service()
+	ctx := context.Background()
+
+	created, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	id := created.ID.String()
+	if err := svc.StartTask(ctx, id, AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	running, _ := svc.GetTask(ctx, id)
+
+	// Many workers race to report the same attempt; exactly one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	applied, conflicts := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				err = tm.CompleteTaskAtVersion(created.ID, running.Version, nil)
+			} else {
+				err = tm.FailTaskAtVersion(created.ID, running.Version, "timed out")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				applied++
+			case errors.Is(err, ErrStatusConflict):
+				conflicts++
+			default:
+				t.Errorf("Expected a conflict, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if applied != 1 || conflicts != 9 {
+		t.Fatalf("Expected 1 update and 9 conflicts, got %d and %d", applied, conflicts)
+	}
+
+	// A retried task rejects a late completion of the earlier attempt
+	retry, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := svc.StartTask(ctx, retry.ID.String(), AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	attempt, _ := svc.GetTask(ctx, retry.ID.String())
+	if err := tm.FailTask(retry.ID, "worker lost"); err != nil {
+		t.Fatalf("Failed to fail task: %v", err)
+	}
+	if err := tm.CompleteTaskAtVersion(retry.ID, attempt.Version, nil); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected a stale completion to conflict, got %v", err)
+	}
+	// Even without a version, a requeued task has no attempt to complete
+	if err := svc.CompleteTask(ctx, retry.ID.String(), AnyVersion, nil); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected completing a requeued task to conflict, got %v", err)
+	}
+	if err := svc.FailTask(ctx, retry.ID.String(), AnyVersion, "late failure"); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected failing a requeued task to conflict, got %v", err)
+	}
+	if got, _ := svc.GetTask(ctx, retry.ID.String()); got.Status != TaskStatusPending {
+		t.Errorf("Expected the retry to stay pending, got %s", got.Status)
+	}
+
+	// The next attempt may only be reported at its own version
+	startTask(t, tm, retry.ID)
+	if err := svc.CompleteTask(ctx, retry.ID.String(), attempt.Version, nil); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected a completion at the earlier attempt's version to conflict, got %v", err)
+	}
+
+	// A permanently failed task is not overwritten as completed
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			startTask(t, tm, retry.ID)
+		}
+		if err := tm.FailTask(retry.ID, "worker lost"); err != nil {
+			t.Fatalf("Failed to fail task: %v", err)
+		}
+	}
+	if err := tm.CompleteTask(retry.ID, nil); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected completing a failed task to conflict, got %v", err)
+	}
+	if got, _ := svc.GetTask(ctx, retry.ID.String()); got.Status != TaskStatusFailed {
+		t.Errorf("Expected the task to stay failed, got %s", got.Status)
+	}
+}
+
+// startTask moves a task to running, as a worker picking it up does
+func startTask(t *testing.T, tm *TaskManager, id uuid.UUID) {
+	t.Helper()
+	if err := tm.Service().StartTask(context.Background(), id.String(), AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+}
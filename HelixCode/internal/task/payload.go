@@ -0,0 +1,86 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// PayloadLimitMode controls what happens to payloads above the size limit
+type PayloadLimitMode string
+
+const (
+	// PayloadLimitTruncate replaces oversized payloads with a truncated preview
+	PayloadLimitTruncate PayloadLimitMode = "truncate"
+	// PayloadLimitReject fails the operation that carried the oversized payload
+	PayloadLimitReject PayloadLimitMode = "reject"
+)
+
+const (
+	// DefaultMaxPayloadBytes is the default limit for result and checkpoint payloads
+	DefaultMaxPayloadBytes = 1 << 20
+
+	// PayloadTruncatedKey marks a payload that was truncated
+	PayloadTruncatedKey = "_truncated"
+	// PayloadOriginalSizeKey holds the encoded size of a truncated payload
+	PayloadOriginalSizeKey = "_original_size"
+	// PayloadPreviewKey holds the beginning of the encoded payload
+	PayloadPreviewKey = "_preview"
+)
+
+// PayloadLimits configures size limits for task result and checkpoint data.
+// A limit of zero or less disables the check.
+type PayloadLimits struct {
+	MaxResultBytes     int
+	MaxCheckpointBytes int
+	Mode               PayloadLimitMode
+}
+
+// DefaultPayloadLimits returns the default payload limits
+func DefaultPayloadLimits() PayloadLimits {
+	return PayloadLimits{
+		MaxResultBytes:     DefaultMaxPayloadBytes,
+		MaxCheckpointBytes: DefaultMaxPayloadBytes,
+		Mode:               PayloadLimitTruncate,
+	}
+}
+
+// SetPayloadLimits sets the size limits applied to results and checkpoints
+func (tm *TaskManager) SetPayloadLimits(limits PayloadLimits) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.payloadLimits = limits
+}
+
+// limitPayload enforces a size limit on a payload, returning the payload to store
+func limitPayload(kind string, payload map[string]interface{}, maxBytes int, mode PayloadLimitMode) (map[string]interface{}, error) {
+	if payload == nil || maxBytes <= 0 {
+		return payload, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %v", kind, err)
+	}
+	if len(data) <= maxBytes {
+		return payload, nil
+	}
+
+	if mode == PayloadLimitReject {
+		return nil, fmt.Errorf("%s payload of %d bytes exceeds limit of %d bytes", kind, len(data), maxBytes)
+	}
+
+	log.Printf("⚠️ Truncating %s payload from %d to %d bytes", kind, len(data), maxBytes)
+	return map[string]interface{}{
+		PayloadTruncatedKey:    true,
+		PayloadOriginalSizeKey: len(data),
+		PayloadPreviewKey:      strings.ToValidUTF8(string(data[:maxBytes]), ""),
+	}, nil
+}
+
+// IsPayloadTruncated reports whether a payload was truncated by the size limit
+func IsPayloadTruncated(payload map[string]interface{}) bool {
+	truncated, _ := payload[PayloadTruncatedKey].(bool)
+	return truncated
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestCompleteTaskPayloadLimits tests oversized result handling in both limit modes
+func TestCompleteTaskPayloadLimits(t *testing.T) {
+	oversized := map[string]interface{}{"output": strings.Repeat("x", 4096)}
+
+	// Truncate mode stores a marked preview
+	tm := NewTaskManager(MockDatabase())
+	tm.SetPayloadLimits(PayloadLimits{MaxResultBytes: 1024, MaxCheckpointBytes: 1024, Mode: PayloadLimitTruncate})
+
+	task, err := tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	startTask(t, tm, task.ID)
+	if err := tm.CompleteTask(task.ID, oversized); err != nil {
+		t.Fatalf("Expected truncated completion, got %v", err)
+	}
+	if !IsPayloadTruncated(task.ResultData) {
+		t.Fatalf("Expected truncated result, got %v", task.ResultData)
+	}
+	if preview := task.ResultData[PayloadPreviewKey].(string); len(preview) > 1024 || !strings.HasPrefix(preview, `{"output":"xxx`) {
+		t.Errorf("Unexpected preview of %d bytes", len(preview))
+	}
+	if size := task.ResultData[PayloadOriginalSizeKey].(int); size <= 4096 {
+		t.Errorf("Expected original size above 4096, got %d", size)
+	}
+
+	// Reject mode refuses the completion and leaves the task untouched
+	tm = NewTaskManager(MockDatabase())
+	tm.SetPayloadLimits(PayloadLimits{MaxResultBytes: 1024, MaxCheckpointBytes: 1024, Mode: PayloadLimitReject})
+
+	task, err = tm.CreateTask(TaskTypeBuilding, map[string]interface{}{}, PriorityNormal, CriticalityNormal, []uuid.UUID{})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	startTask(t, tm, task.ID)
+	if err := tm.CompleteTask(task.ID, oversized); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Fatalf("Expected size limit error, got %v", err)
+	}
+	if task.Status != TaskStatusRunning || task.ResultData != nil {
+		t.Errorf("Rejected completion should not change the task, got %s", task.Status)
+	}
+
+	// Small results pass through unchanged
+	if err := tm.CompleteTask(task.ID, map[string]interface{}{"output": "ok"}); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+	if task.ResultData["output"] != "ok" || IsPayloadTruncated(task.ResultData) {
+		t.Errorf("Small result should be stored as-is, got %v", task.ResultData)
+	}
+
+	// Oversized checkpoints are rejected before reaching storage
+	if err := tm.CreateCheckpoint(task.ID, "big", oversized); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("Expected size limit error for checkpoint, got %v", err)
+	}
+}
@@ -1,23 +1,85 @@
 package task
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"sort"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"github.com/google/uuid"
 )
 
+// maxWaitSamples bounds the wait times kept for each priority band
+const maxWaitSamples = 1000
+
+// ErrQueueFull is returned when a task is rejected because the queue is at
+// its size limit
+var ErrQueueFull = errors.New("task queue is full")
+
+// QueueHardCap returns how many tasks critical ones may fill a queue limited
+// to maxSize to: a quarter more than the limit
+func QueueHardCap(maxSize int) int {
+	return maxSize + (maxSize+3)/4
+}
+
+// AdmitTask checks whether a queue holding depth tasks may take another.
+// Tasks are rejected with ErrQueueFull once maxSize are queued, except
+// critical ones, which are rejected at QueueHardCap. A maxSize of 0 is unlimited.
+func AdmitTask(depth, maxSize int, critical bool) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	limit := maxSize
+	if critical {
+		limit = QueueHardCap(maxSize)
+	}
+	if depth >= limit {
+		return fmt.Errorf("%w: %d tasks queued (limit %d)", ErrQueueFull, depth, limit)
+	}
+	return nil
+}
+
 // NewTaskQueue creates a new task queue
 func NewTaskQueue() *TaskQueue {
 	return &TaskQueue{
 		highPriority:   make([]*Task, 0),
 		normalPriority: make([]*Task, 0),
 		lowPriority:    make([]*Task, 0),
+		enqueuedAt:     make(map[uuid.UUID]time.Time),
+		clock:          clock.New(),
 	}
 }
 
+// SetMaxSize limits how many tasks Admit lets into the queue; 0 is unlimited
+func (tq *TaskQueue) SetMaxSize(maxSize int) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.maxSize = maxSize
+}
+
+// Admit checks whether a task of the given priority may be queued
+func (tq *TaskQueue) Admit(priority TaskPriority) error {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	depth := len(tq.highPriority) + len(tq.normalPriority) + len(tq.lowPriority)
+	return AdmitTask(depth, tq.maxSize, priority >= PriorityCritical)
+}
+
+// SetClock replaces the clock used to time how long tasks wait
+func (tq *TaskQueue) SetClock(c clock.Clock) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.clock = c
+}
+
 // AddTask adds a task to the appropriate queue based on priority
 func (tq *TaskQueue) AddTask(task *Task) {
 	tq.mu.Lock()
 	defer tq.mu.Unlock()
 
+	tq.enqueuedAt[task.ID] = tq.clock.Now()
 	switch task.Priority {
 	case PriorityCritical, PriorityHigh:
 		tq.highPriority = append(tq.highPriority, task)
@@ -39,6 +101,7 @@ func (tq *TaskQueue) GetNextTask() *Task {
 	if len(tq.highPriority) > 0 {
 		task := tq.highPriority[0]
 		tq.highPriority = tq.highPriority[1:]
+		tq.recordWait(task)
 		return task
 	}
 
@@ -46,6 +109,7 @@ func (tq *TaskQueue) GetNextTask() *Task {
 	if len(tq.normalPriority) > 0 {
 		task := tq.normalPriority[0]
 		tq.normalPriority = tq.normalPriority[1:]
+		tq.recordWait(task)
 		return task
 	}
 
@@ -53,17 +117,40 @@ func (tq *TaskQueue) GetNextTask() *Task {
 	if len(tq.lowPriority) > 0 {
 		task := tq.lowPriority[0]
 		tq.lowPriority = tq.lowPriority[1:]
+		tq.recordWait(task)
 		return task
 	}
 
 	return nil
 }
 
+// TakeTask removes a task that is being assigned to a worker, recording how
+// long it waited. RemoveTask is for tasks leaving the queue unassigned.
+func (tq *TaskQueue) TakeTask(taskID string) bool {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+
+	for _, slice := range []*[]*Task{&tq.highPriority, &tq.normalPriority, &tq.lowPriority} {
+		for i, task := range *slice {
+			if task.ID.String() == taskID {
+				*slice = append((*slice)[:i], (*slice)[i+1:]...)
+				tq.recordWait(task)
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RemoveTask removes a specific task from the queue
 func (tq *TaskQueue) RemoveTask(taskID string) bool {
 	tq.mu.Lock()
 	defer tq.mu.Unlock()
 
+	if id, err := uuid.Parse(taskID); err == nil {
+		delete(tq.enqueuedAt, id)
+	}
+
 	// Try to remove from high priority
 	if removed := tq.removeFromSlice(&tq.highPriority, taskID); removed {
 		return true
@@ -88,10 +175,13 @@ func (tq *TaskQueue) GetQueueStats() QueueStats {
 	defer tq.mu.RUnlock()
 
 	return QueueStats{
-		HighPriority:   len(tq.highPriority),
-		NormalPriority: len(tq.normalPriority),
-		LowPriority:    len(tq.lowPriority),
-		Total:          len(tq.highPriority) + len(tq.normalPriority) + len(tq.lowPriority),
+		HighPriority:       len(tq.highPriority),
+		NormalPriority:     len(tq.normalPriority),
+		LowPriority:        len(tq.lowPriority),
+		Total:              len(tq.highPriority) + len(tq.normalPriority) + len(tq.lowPriority),
+		HighPriorityWait:   tq.highWaits.stats(),
+		NormalPriorityWait: tq.normalWaits.stats(),
+		LowPriorityWait:    tq.lowWaits.stats(),
 	}
 }
 
@@ -103,10 +193,31 @@ func (tq *TaskQueue) Clear() {
 	tq.highPriority = make([]*Task, 0)
 	tq.normalPriority = make([]*Task, 0)
 	tq.lowPriority = make([]*Task, 0)
+	tq.enqueuedAt = make(map[uuid.UUID]time.Time)
 }
 
 // Helper methods
 
+// recordWait records how long a task leaving the queue waited, in the band
+// of its priority. The caller must hold tq.mu.
+func (tq *TaskQueue) recordWait(task *Task) {
+	enqueuedAt, ok := tq.enqueuedAt[task.ID]
+	if !ok {
+		return
+	}
+	delete(tq.enqueuedAt, task.ID)
+
+	wait := tq.clock.Now().Sub(enqueuedAt)
+	switch task.Priority {
+	case PriorityCritical, PriorityHigh:
+		tq.highWaits.add(wait)
+	case PriorityNormal:
+		tq.normalWaits.add(wait)
+	case PriorityLow:
+		tq.lowWaits.add(wait)
+	}
+}
+
 func (tq *TaskQueue) sortHighPriorityTasks() {
 	sort.Slice(tq.highPriority, func(i, j int) bool {
 		taskA := tq.highPriority[i]
@@ -147,10 +258,61 @@ func (tq *TaskQueue) removeFromSlice(slice *[]*Task, taskID string) bool {
 	return false
 }
 
-// QueueStats represents queue statistics
+// QueueStats represents queue statistics: the depth of each priority band
+// and how long its recently assigned tasks waited
 type QueueStats struct {
 	HighPriority   int `json:"high_priority"`
 	NormalPriority int `json:"normal_priority"`
 	LowPriority    int `json:"low_priority"`
 	Total          int `json:"total"`
-}
\ No newline at end of file
+
+	HighPriorityWait   WaitStats `json:"high_priority_wait"`
+	NormalPriorityWait WaitStats `json:"normal_priority_wait"`
+	LowPriorityWait    WaitStats `json:"low_priority_wait"`
+}
+
+// WaitStats summarizes how long tasks waited in the queue before assignment,
+// over the most recent maxWaitSamples tasks
+type WaitStats struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// waitSamples keeps the most recent wait times of a priority band
+type waitSamples struct {
+	samples []time.Duration
+	next    int
+}
+
+// add records a wait, replacing the oldest once maxWaitSamples are kept
+func (w *waitSamples) add(wait time.Duration) {
+	if len(w.samples) < maxWaitSamples {
+		w.samples = append(w.samples, wait)
+		return
+	}
+	w.samples[w.next] = wait
+	w.next = (w.next + 1) % maxWaitSamples
+}
+
+// stats returns the nearest-rank percentiles of the kept waits
+func (w *waitSamples) stats() WaitStats {
+	if len(w.samples) == 0 {
+		return WaitStats{}
+	}
+
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return WaitStats{
+		Count: len(sorted),
+		P50:   percentile(0.5),
+		P90:   percentile(0.9),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
@@ -0,0 +1,238 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dev.helix.code/internal/audit"
+	"github.com/google/uuid"
+)
+
+// Service is the task API used by the HTTP handlers. It is implemented by
+// DatabaseManager for persistent storage and by TaskManager.Service for the
+// in-memory distributed manager. createdBy is the ID of the submitting user;
+// ListTasks returns only their tasks, or every task when it is empty. Status
+// updates apply only while the task is at version, unless it is AnyVersion,
+// and fail with ErrStatusConflict otherwise. Operations on a missing task
+// fail with ErrTaskNotFound.
+type Service interface {
+	CreateTask(ctx context.Context, name, description, taskType, priority string, parameters map[string]interface{}, dependencies []string, createdBy string) (*Task, error)
+	GetTask(ctx context.Context, id string) (*Task, error)
+	ListTasks(ctx context.Context, createdBy string) ([]*Task, error)
+	StartTask(ctx context.Context, id string, version int) error
+	CompleteTask(ctx context.Context, id string, version int, result map[string]interface{}) error
+	FailTask(ctx context.Context, id string, version int, errorMessage string) error
+	DeleteTask(ctx context.Context, id string) error
+}
+
+var _ Service = (*DatabaseManager)(nil)
+
+// ErrTaskNotFound is returned for operations on a task that does not exist
+var ErrTaskNotFound = errors.New("task not found")
+
+// QueueReporter is implemented by services that queue tasks for workers
+type QueueReporter interface {
+	QueueStats() QueueStats
+}
+
+// ParseTaskStatus converts a status string into a TaskStatus
+func ParseTaskStatus(status string) (TaskStatus, error) {
+	switch s := TaskStatus(status); s {
+	case TaskStatusPending, TaskStatusAssigned, TaskStatusRunning, TaskStatusCompleted,
+		TaskStatusFailed, TaskStatusCancelled, TaskStatusPaused,
+		TaskStatusWaitingForWorker, TaskStatusWaitingForDeps:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown task status: %q", status)
+	}
+}
+
+// ParseTaskType converts a task type string into a TaskType
+func ParseTaskType(taskType string) (TaskType, error) {
+	switch t := TaskType(taskType); t {
+	case TaskTypePlanning, TaskTypeBuilding, TaskTypeTesting, TaskTypeRefactoring, TaskTypeDebugging,
+		TaskTypeDesign, TaskTypeDiagram, TaskTypeDeployment, TaskTypePorting:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown task type: %q", taskType)
+	}
+}
+
+// parseCreator converts a creator ID into a UUID, which is nil when the ID is empty
+func parseCreator(createdBy string) (*uuid.UUID, error) {
+	if createdBy == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid creator ID: %v", err)
+	}
+	return &id, nil
+}
+
+// ParsePriority converts a priority name into a TaskPriority, defaulting to normal
+func ParsePriority(priority string) TaskPriority {
+	switch priority {
+	case "high":
+		return PriorityHigh
+	case "critical":
+		return PriorityCritical
+	case "low":
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// Service returns the in-memory task manager as a Service
+func (tm *TaskManager) Service() Service {
+	return &managerService{tm: tm}
+}
+
+// managerService adapts TaskManager to the Service interface
+type managerService struct {
+	tm *TaskManager
+}
+
+var _ QueueReporter = (*managerService)(nil)
+
+func (s *managerService) QueueStats() QueueStats {
+	return s.tm.QueueStats()
+}
+
+func (s *managerService) CreateTask(ctx context.Context, name, description, taskType, priority string, parameters map[string]interface{}, dependencies []string, createdBy string) (*Task, error) {
+	creator, err := parseCreator(createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependencyUUIDs []uuid.UUID
+	for _, dep := range dependencies {
+		depUUID, err := uuid.Parse(dep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dependency ID: %v", err)
+		}
+		dependencyUUIDs = append(dependencyUUIDs, depUUID)
+	}
+
+	if parameters == nil {
+		parameters = make(map[string]interface{})
+	}
+	if name != "" {
+		parameters["name"] = name
+	}
+	if description != "" {
+		parameters["description"] = description
+	}
+
+	s.tm.mu.Lock()
+	defer s.tm.mu.Unlock()
+
+	task, err := s.tm.createTask(TaskType(taskType), parameters, ParsePriority(priority), CriticalityNormal, dependencyUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	task.CreatedBy = creator
+	return task.snapshot(), nil
+}
+
+func (s *managerService) GetTask(ctx context.Context, id string) (*Task, error) {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %v", err)
+	}
+
+	s.tm.mu.RLock()
+	defer s.tm.mu.RUnlock()
+
+	task, exists := s.tm.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	return task.snapshot(), nil
+}
+
+func (s *managerService) ListTasks(ctx context.Context, createdBy string) ([]*Task, error) {
+	creator, err := parseCreator(createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.tm.mu.RLock()
+	defer s.tm.mu.RUnlock()
+
+	tasks := make([]*Task, 0, len(s.tm.tasks))
+	for _, task := range s.tm.tasks {
+		if creator != nil && (task.CreatedBy == nil || *task.CreatedBy != *creator) {
+			continue
+		}
+		tasks = append(tasks, task.snapshot())
+	}
+	return tasks, nil
+}
+
+func (s *managerService) StartTask(ctx context.Context, id string, version int) error {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %v", err)
+	}
+
+	s.tm.mu.Lock()
+	defer s.tm.mu.Unlock()
+
+	task, exists := s.tm.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if err := task.checkVersion(version); err != nil {
+		return err
+	}
+	if task.Status != TaskStatusPending && task.Status != TaskStatusAssigned {
+		return fmt.Errorf("%w: task %s is %s, not pending", ErrStatusConflict, id, task.Status)
+	}
+
+	s.tm.queue.TakeTask(id)
+	from := task.Status
+	now := s.tm.clock.Now()
+	task.setStatus(TaskStatusRunning)
+	task.StartedAt = &now
+	task.UpdatedAt = now
+	auditTask(ctx, s.tm.audit, audit.SourceAPI, taskID, audit.ActionStatusChanged, from, task.Status, nil)
+	return nil
+}
+
+func (s *managerService) CompleteTask(ctx context.Context, id string, version int, result map[string]interface{}) error {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %v", err)
+	}
+	return s.tm.completeTask(ctx, taskID, version, result)
+}
+
+func (s *managerService) FailTask(ctx context.Context, id string, version int, errorMessage string) error {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %v", err)
+	}
+	return s.tm.failTask(ctx, taskID, version, errorMessage)
+}
+
+func (s *managerService) DeleteTask(ctx context.Context, id string) error {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %v", err)
+	}
+
+	s.tm.mu.Lock()
+	defer s.tm.mu.Unlock()
+
+	task, exists := s.tm.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	delete(s.tm.tasks, taskID)
+	s.tm.queue.RemoveTask(id)
+	auditTask(ctx, s.tm.audit, audit.SourceAPI, taskID, audit.ActionDeleted, task.Status, "", nil)
+	return nil
+}
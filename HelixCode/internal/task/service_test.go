@@ -0,0 +1,83 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestManagerService tests the in-memory task manager through the Service interface
+func TestManagerService(t *testing.T) {
+	ctx := context.Background()
+	var svc Service = NewTaskManager(MockDatabase()).Service()
+
+	created, err := svc.CreateTask(ctx, "build", "Build the project", string(TaskTypeBuilding), "high", nil, nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if created.Priority != PriorityHigh || created.Status != TaskStatusPending {
+		t.Errorf("Unexpected task: priority %d, status %s", created.Priority, created.Status)
+	}
+
+	id := created.ID.String()
+	if err := svc.StartTask(ctx, id, AnyVersion); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+	if err := svc.StartTask(ctx, id, AnyVersion); !errors.Is(err, ErrStatusConflict) {
+		t.Errorf("Expected a conflict when starting a running task, got %v", err)
+	}
+	if err := svc.CompleteTask(ctx, id, AnyVersion, map[string]interface{}{"ok": true}); err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	got, err := svc.GetTask(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if got.Status != TaskStatusCompleted {
+		t.Errorf("Expected status %s, got %s", TaskStatusCompleted, got.Status)
+	}
+
+	tasks, err := svc.ListTasks(ctx, "")
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("Expected one task, got %d (%v)", len(tasks), err)
+	}
+
+	if err := svc.DeleteTask(ctx, id); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+	if _, err := svc.GetTask(ctx, id); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for deleted task, got %v", err)
+	}
+	if err := svc.StartTask(ctx, id, AnyVersion); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound when starting a deleted task, got %v", err)
+	}
+	if _, err := svc.GetTask(ctx, "not-a-uuid"); err == nil {
+		t.Error("Expected error for invalid task ID")
+	}
+}
+
+// TestParseTaskStatus tests conversion of raw status strings
+func TestParseTaskStatus(t *testing.T) {
+	for _, status := range []TaskStatus{TaskStatusPending, TaskStatusRunning, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled} {
+		parsed, err := ParseTaskStatus(string(status))
+		if err != nil || parsed != status {
+			t.Errorf("ParseTaskStatus(%q) = %q, %v", status, parsed, err)
+		}
+	}
+
+	if _, err := ParseTaskStatus("done"); err == nil {
+		t.Error("Expected error for unknown status")
+	}
+
+	if parsed, err := ParseTaskType("refactoring"); err != nil || parsed != TaskTypeRefactoring {
+		t.Errorf("ParseTaskType(refactoring) = %q, %v", parsed, err)
+	}
+	if _, err := ParseTaskType("cooking"); err == nil {
+		t.Error("Expected error for unknown task type")
+	}
+
+	if ParsePriority("critical") != PriorityCritical || ParsePriority("") != PriorityNormal {
+		t.Error("Unexpected priority conversion")
+	}
+}
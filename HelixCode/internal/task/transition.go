@@ -0,0 +1,78 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStatusConflict is returned when a status update does not apply to the
+// task's current state, such as a late completion for a task that has
+// already failed or been handed to another worker
+var ErrStatusConflict = errors.New("task status conflict")
+
+// AnyVersion may be passed as the expected version to apply an update
+// whatever the task's version, as long as the transition is valid
+const AnyVersion = -1
+
+// transitions lists the statuses each status may move to. Completed and
+// cancelled tasks are final; failed tasks may only be requeued. Pending tasks
+// have not been handed to a worker, so they can neither complete nor fail:
+// a late report from an attempt that failed and was requeued is rejected.
+var transitions = map[TaskStatus][]TaskStatus{
+	TaskStatusPending:          {TaskStatusPending, TaskStatusAssigned, TaskStatusRunning, TaskStatusCancelled, TaskStatusPaused, TaskStatusWaitingForWorker, TaskStatusWaitingForDeps},
+	TaskStatusAssigned:         {TaskStatusPending, TaskStatusRunning, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusPaused},
+	TaskStatusRunning:          {TaskStatusPending, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusPaused},
+	TaskStatusPaused:           {TaskStatusPending, TaskStatusRunning, TaskStatusFailed, TaskStatusCancelled},
+	TaskStatusWaitingForWorker: {TaskStatusPending, TaskStatusAssigned, TaskStatusRunning, TaskStatusFailed, TaskStatusCancelled},
+	TaskStatusWaitingForDeps:   {TaskStatusPending, TaskStatusAssigned, TaskStatusRunning, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled},
+	TaskStatusFailed:           {TaskStatusPending},
+}
+
+// CanTransition reports whether a task may move from one status to another
+func CanTransition(from, to TaskStatus) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceStatuses returns the statuses that may move to status
+func sourceStatuses(to TaskStatus) []string {
+	var sources []string
+	for from := range transitions {
+		if CanTransition(from, to) {
+			sources = append(sources, string(from))
+		}
+	}
+	return sources
+}
+
+// checkVersion returns ErrStatusConflict unless the task is at the expected
+// version. The caller must hold tm.mu.
+func (t *Task) checkVersion(version int) error {
+	if version != AnyVersion && version != t.Version {
+		return fmt.Errorf("%w: task %s is at version %d, not %d", ErrStatusConflict, t.ID, t.Version, version)
+	}
+	return nil
+}
+
+// checkTransition returns ErrStatusConflict unless the task is at the
+// expected version and may move to status. The caller must hold tm.mu.
+func (t *Task) checkTransition(to TaskStatus, version int) error {
+	if err := t.checkVersion(version); err != nil {
+		return err
+	}
+	if !CanTransition(t.Status, to) {
+		return fmt.Errorf("%w: task %s cannot move from %s to %s", ErrStatusConflict, t.ID, t.Status, to)
+	}
+	return nil
+}
+
+// setStatus moves the task to status and bumps its version, so updates
+// based on the previous state become stale. The caller must hold tm.mu.
+func (t *Task) setStatus(status TaskStatus) {
+	t.Status = status
+	t.Version++
+}
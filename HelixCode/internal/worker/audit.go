@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+
+	"dev.helix.code/internal/audit"
+	"github.com/google/uuid"
+)
+
+// SetAuditLog records worker registration and status changes in log.
+// Changes not attributed to an actor in the context are recorded as the monitor's.
+func (wm *WorkerManager) SetAuditLog(log *audit.Log) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	wm.audit = log
+}
+
+// SetAuditLog records worker membership and status changes in log.
+// Changes not attributed to an actor in the context are recorded as the scheduler's.
+func (dwm *DistributedWorkerManager) SetAuditLog(log *audit.Log) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.audit = log
+}
+
+// auditWorker records a change to a worker in log, which may be nil
+func auditWorker(ctx context.Context, log *audit.Log, fallback audit.Source, workerID uuid.UUID, action string, from, to WorkerStatus, details map[string]interface{}) {
+	log.Record(ctx, fallback, audit.EntityWorker, workerID, action, string(from), string(to), details)
+}
@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BuildStatus is the overall outcome of a distributed build
+type BuildStatus string
+
+const (
+	BuildStatusSucceeded BuildStatus = "succeeded"
+	BuildStatusPartial   BuildStatus = "partial"
+	BuildStatusFailed    BuildStatus = "failed"
+)
+
+// ServiceBuild describes how to build one service on a worker
+type ServiceBuild struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command"`
+	WorkDir string            `json:"work_dir"`
+	Env     map[string]string `json:"env"`
+}
+
+// ServiceBuildResult is the outcome of building one service
+type ServiceBuildResult struct {
+	Service  string        `json:"service"`
+	WorkerID uuid.UUID     `json:"worker_id"`
+	Hostname string        `json:"hostname"`
+	Success  bool          `json:"success"`
+	Output   string        `json:"output"`
+	Error    string        `json:"error,omitempty"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+
+	triedWorkers map[uuid.UUID]bool
+}
+
+// BuildResult collects per-service results of a distributed build.
+// Successful services are kept even when others fail.
+type BuildResult struct {
+	Status   BuildStatus                    `json:"status"`
+	Services map[string]*ServiceBuildResult `json:"services"`
+	Duration time.Duration                  `json:"duration"`
+}
+
+// FailedServices returns the names of services that did not build, sorted
+func (r *BuildResult) FailedServices() []string {
+	var failed []string
+	for name, result := range r.Services {
+		if !result.Success {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// BuildOptions controls a distributed build
+type BuildOptions struct {
+	// RetryFailed rebuilds failed services on workers they have not yet run on
+	RetryFailed bool
+	// MaxRetries bounds the retry rounds when RetryFailed is set (default 1)
+	MaxRetries int
+}
+
+// ExecuteDistributedBuild builds services concurrently across the pool's active
+// workers. A failing service does not affect the others: each gets its own
+// result and the build reports a partial status. An error is returned only if
+// the build could not be attempted.
+func (p *SSHWorkerPool) ExecuteDistributedBuild(ctx context.Context, services []ServiceBuild, opts BuildOptions) (*BuildResult, error) {
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no services to build")
+	}
+	if p.buildWorkers() == nil {
+		return nil, fmt.Errorf("no available workers")
+	}
+
+	start := time.Now()
+	result := &BuildResult{Services: make(map[string]*ServiceBuildResult, len(services))}
+	for _, service := range services {
+		result.Services[service.Name] = &ServiceBuildResult{
+			Service:      service.Name,
+			triedWorkers: make(map[uuid.UUID]bool),
+		}
+	}
+
+	p.buildRound(ctx, services, result)
+
+	if opts.RetryFailed {
+		retries := opts.MaxRetries
+		if retries <= 0 {
+			retries = 1
+		}
+		for i := 0; i < retries && len(result.FailedServices()) > 0 && ctx.Err() == nil; i++ {
+			p.RetryFailedServices(ctx, services, result)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// RetryFailedServices rebuilds only the failed services of a previous build,
+// each on an available worker it has not failed on, and updates result in place
+func (p *SSHWorkerPool) RetryFailedServices(ctx context.Context, services []ServiceBuild, result *BuildResult) {
+	failed := make(map[string]bool)
+	for _, name := range result.FailedServices() {
+		failed[name] = true
+	}
+
+	var retry []ServiceBuild
+	for _, service := range services {
+		if failed[service.Name] {
+			retry = append(retry, service)
+		}
+	}
+	if len(retry) == 0 {
+		return
+	}
+
+	log.Printf("🔄 Retrying %d failed service build(s)", len(retry))
+	p.buildRound(ctx, retry, result)
+}
+
+// buildRound builds each service once, concurrently, and records the results
+func (p *SSHWorkerPool) buildRound(ctx context.Context, services []ServiceBuild, result *BuildResult) {
+	workers := p.buildWorkers()
+
+	var wg sync.WaitGroup
+	for i, service := range services {
+		serviceResult := result.Services[service.Name]
+		if serviceResult.triedWorkers == nil {
+			serviceResult.triedWorkers = make(map[uuid.UUID]bool)
+		}
+
+		worker := pickBuildWorker(workers, i, serviceResult.triedWorkers)
+		if worker == nil {
+			if serviceResult.Error == "" {
+				serviceResult.Error = "no untried worker available"
+			}
+			continue
+		}
+		serviceResult.triedWorkers[worker.ID] = true
+
+		wg.Add(1)
+		go func(service ServiceBuild, worker *SSHWorker, serviceResult *ServiceBuildResult) {
+			defer wg.Done()
+			p.buildService(ctx, service, worker, serviceResult)
+		}(service, worker, serviceResult)
+	}
+	wg.Wait()
+
+	result.Status = buildStatus(result)
+}
+
+// buildService runs one service build and records its outcome
+func (p *SSHWorkerPool) buildService(ctx context.Context, service ServiceBuild, worker *SSHWorker, result *ServiceBuildResult) {
+	start := time.Now()
+	output, err := p.ExecuteCommandOpts(ctx, worker.ID, service.Command, CommandOptions{
+		Env:     service.Env,
+		WorkDir: service.WorkDir,
+	})
+
+	result.WorkerID = worker.ID
+	result.Hostname = worker.Hostname
+	result.Attempts++
+	result.Duration = time.Since(start)
+	result.Output = output
+
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		log.Printf("❌ Service %s failed on %s: %v", service.Name, worker.Hostname, err)
+		return
+	}
+
+	result.Success = true
+	result.Error = ""
+	log.Printf("✅ Service %s built on %s", service.Name, worker.Hostname)
+}
+
+// buildWorkers returns the active, healthy workers sorted by hostname
+func (p *SSHWorkerPool) buildWorkers() []*SSHWorker {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	var workers []*SSHWorker
+	for _, worker := range p.workers {
+		if worker.Status == WorkerStatusActive && worker.HealthStatus == WorkerHealthHealthy {
+			workers = append(workers, worker)
+		}
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Hostname < workers[j].Hostname
+	})
+	return workers
+}
+
+// pickBuildWorker spreads services round-robin, skipping workers already tried
+func pickBuildWorker(workers []*SSHWorker, index int, tried map[uuid.UUID]bool) *SSHWorker {
+	for offset := 0; offset < len(workers); offset++ {
+		worker := workers[(index+offset)%len(workers)]
+		if !tried[worker.ID] {
+			return worker
+		}
+	}
+	return nil
+}
+
+func buildStatus(result *BuildResult) BuildStatus {
+	failed := len(result.FailedServices())
+	switch {
+	case failed == 0:
+		return BuildStatusSucceeded
+	case failed == len(result.Services):
+		return BuildStatusFailed
+	default:
+		return BuildStatusPartial
+	}
+}
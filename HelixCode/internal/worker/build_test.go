@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hostFailingExecutor fails every command run on the given hosts
+type hostFailingExecutor struct {
+	mu        sync.Mutex
+	failHosts map[string]bool
+	runs      map[string][]string // hostname -> commands
+}
+
+func (e *hostFailingExecutor) Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.runs[worker.Hostname] = append(e.runs[worker.Hostname], command)
+	if e.failHosts[worker.Hostname] {
+		return "", fmt.Errorf("command execution failed: exit status 1")
+	}
+	return "built", nil
+}
+
+func newBuildPool(t *testing.T, executor CommandExecutor, hostnames ...string) *SSHWorkerPool {
+	t.Helper()
+	pool := NewSSHWorkerPool(false)
+	pool.SetExecutor(executor)
+	for _, hostname := range hostnames {
+		require.NoError(t, pool.RegisterWorker(&SSHWorker{
+			Hostname:     hostname,
+			Status:       WorkerStatusActive,
+			HealthStatus: WorkerHealthHealthy,
+		}))
+	}
+	return pool
+}
+
+var testServices = []ServiceBuild{
+	{Name: "api-service", Command: "go build ./...", WorkDir: "/src/api"},
+	{Name: "auth-service", Command: "go build ./...", WorkDir: "/src/auth"},
+	{Name: "database-service", Command: "go build ./...", WorkDir: "/src/db"},
+}
+
+// TestExecuteDistributedBuild_PartialResults tests that successful services survive a failing worker
+func TestExecuteDistributedBuild_PartialResults(t *testing.T) {
+	executor := &hostFailingExecutor{failHosts: map[string]bool{"worker-2": true}, runs: make(map[string][]string)}
+	pool := newBuildPool(t, executor, "worker-1", "worker-2", "worker-3")
+
+	result, err := pool.ExecuteDistributedBuild(context.Background(), testServices, BuildOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, BuildStatusPartial, result.Status)
+	assert.Equal(t, []string{"auth-service"}, result.FailedServices())
+
+	auth := result.Services["auth-service"]
+	assert.False(t, auth.Success)
+	assert.Equal(t, "worker-2", auth.Hostname)
+	assert.Contains(t, auth.Error, "exit status 1")
+	assert.Equal(t, 1, auth.Attempts)
+
+	for _, name := range []string{"api-service", "database-service"} {
+		service := result.Services[name]
+		assert.True(t, service.Success, name)
+		assert.Equal(t, "built", service.Output)
+		assert.Empty(t, service.Error)
+	}
+	assert.Equal(t, []string{"cd '/src/auth' && { go build ./...\n}"}, executor.runs["worker-2"])
+}
+
+// TestExecuteDistributedBuild_RetryFailed tests that only failed services are retried on other workers
+func TestExecuteDistributedBuild_RetryFailed(t *testing.T) {
+	executor := &hostFailingExecutor{failHosts: map[string]bool{"worker-2": true}, runs: make(map[string][]string)}
+	pool := newBuildPool(t, executor, "worker-1", "worker-2", "worker-3")
+
+	result, err := pool.ExecuteDistributedBuild(context.Background(), testServices, BuildOptions{RetryFailed: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, BuildStatusSucceeded, result.Status)
+	assert.Empty(t, result.FailedServices())
+
+	auth := result.Services["auth-service"]
+	assert.True(t, auth.Success)
+	assert.Equal(t, 2, auth.Attempts)
+	assert.NotEqual(t, "worker-2", auth.Hostname)
+	assert.Equal(t, 1, result.Services["api-service"].Attempts)
+	assert.Equal(t, 1, result.Services["database-service"].Attempts)
+
+	// Every worker failing leaves the build failed once no untried worker remains
+	executor.failHosts = map[string]bool{"worker-1": true, "worker-2": true, "worker-3": true}
+	result, err = pool.ExecuteDistributedBuild(context.Background(), testServices, BuildOptions{RetryFailed: true, MaxRetries: 5})
+	require.NoError(t, err)
+	assert.Equal(t, BuildStatusFailed, result.Status)
+	for _, service := range result.Services {
+		assert.Equal(t, 3, service.Attempts, service.Service)
+		assert.Contains(t, service.Error, "exit status 1")
+	}
+}
+
+// TestExecuteDistributedBuild_NoWorkers tests that a build without workers is rejected
+func TestExecuteDistributedBuild_NoWorkers(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	_, err := pool.ExecuteDistributedBuild(context.Background(), testServices, BuildOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no available workers")
+}
@@ -2,10 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/clock"
+	"dev.helix.code/internal/task"
 )
 
 // TestDistributedWorkerManager tests the distributed worker manager
@@ -302,4 +307,277 @@ func TestWorkerHealthMonitoring(t *testing.T) {
 
 	t.Logf("Worker health: %s, last heartbeat: %v", worker.HealthStatus, worker.LastHeartbeat)
 	t.Log("✅ Worker health monitoring test passed")
-}
\ No newline at end of file
+}
+// TestDistributedWorkerManagerRegisterWorker tests adding and removing workers through the public API
+func TestDistributedWorkerManagerRegisterWorker(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+
+	var events []WorkerEvent
+	manager.OnWorkerEvent(func(event WorkerEvent) {
+		events = append(events, event)
+	})
+
+	worker := &Worker{Hostname: "sim-1", Status: WorkerStatusActive, HealthStatus: WorkerHealthHealthy}
+	if err := manager.RegisterWorker(worker); err != nil {
+		t.Fatalf("Failed to register worker: %v", err)
+	}
+	if worker.ID == uuid.Nil {
+		t.Fatal("Registered worker should be assigned an ID")
+	}
+	if err := manager.RegisterWorker(worker); err == nil {
+		t.Error("Registering a worker twice should fail")
+	}
+	if available := manager.GetAvailableWorkers(); len(available) != 1 {
+		t.Errorf("Expected 1 available worker, got %d", len(available))
+	}
+
+	if err := manager.RemoveWorker(worker.ID); err != nil {
+		t.Fatalf("Failed to remove worker: %v", err)
+	}
+	if err := manager.RemoveWorker(worker.ID); err == nil {
+		t.Error("Removing an unknown worker should fail")
+	}
+
+	if len(events) != 2 || events[0].Type != WorkerEventAdded || events[1].Type != WorkerEventRemoved {
+		t.Errorf("Unexpected worker events: %+v", events)
+	}
+
+	t.Log("✅ Distributed worker registration test passed")
+}
+
+// TestSimulatedWorkerLoad tests load balancing and failure injection across simulated workers
+func TestSimulatedWorkerLoad(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	manager.SetSimulationSeed(42)
+
+	var healthy []*Worker
+	for i := 0; i < 3; i++ {
+		worker, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{Latency: 2 * time.Millisecond, Jitter: time.Millisecond})
+		if err != nil {
+			t.Fatalf("Failed to add simulated worker: %v", err)
+		}
+		healthy = append(healthy, worker)
+	}
+	flaky, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{Hostname: "flaky", Latency: 2 * time.Millisecond, FailureRate: 1})
+	if err != nil {
+		t.Fatalf("Failed to add simulated worker: %v", err)
+	}
+
+	if _, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{FailureRate: 1.5}); err == nil {
+		t.Error("Expected invalid failure rate to be rejected")
+	}
+
+	const submitters = 8
+	const tasksPerSubmitter = 10
+
+	var wg sync.WaitGroup
+	var failures int64
+	for i := 0; i < submitters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < tasksPerSubmitter; j++ {
+				task := &DistributedTask{Type: "load-test", Priority: 5, Criticality: CriticalityNormal}
+				if err := manager.SubmitTask(task); err != nil {
+					atomic.AddInt64(&failures, 1)
+					if task.Status != TaskStatusFailed || task.WorkerID != flaky.ID {
+						t.Errorf("Unexpected failure on worker %s: %v", task.WorkerID, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	metrics := manager.GetThroughputMetrics()
+	total := submitters * tasksPerSubmitter
+	if metrics.Submitted != total || metrics.Completed+metrics.Failed != total {
+		t.Fatalf("Expected %d tasks accounted for, got %+v", total, metrics)
+	}
+	if int64(metrics.Failed) != failures || metrics.FailedPerWorker[flaky.ID] != metrics.Failed {
+		t.Errorf("Failures should all come from the flaky worker: %+v", metrics)
+	}
+	if metrics.Failed == 0 {
+		t.Error("Expected injected failures")
+	}
+	for _, worker := range healthy {
+		if metrics.PerWorker[worker.ID] == 0 {
+			t.Errorf("Worker %s received no tasks", worker.Hostname)
+		}
+	}
+	if metrics.TasksPerSecond <= 0 || metrics.AverageLatency < 2*time.Millisecond {
+		t.Errorf("Unexpected throughput metrics: %+v", metrics)
+	}
+
+	stats := manager.GetWorkerStats()
+	if stats["total_tasks"].(int) != 0 {
+		t.Errorf("Expected no tasks in flight after the run, got %v", stats["total_tasks"])
+	}
+
+	t.Logf("✅ Simulated load test passed: %.0f tasks/s, %d failed", metrics.TasksPerSecond, metrics.Failed)
+}
+
+// TestSimulatedWorkerMockClock tests that task execution time comes from the injected clock
+func TestSimulatedWorkerMockClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(start)
+
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	manager.SetClock(mock)
+
+	if _, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{Latency: time.Hour}); err != nil {
+		t.Fatalf("Failed to add simulated worker: %v", err)
+	}
+
+	task := &DistributedTask{Type: "slow-build"}
+	if err := manager.SubmitTask(task); err != nil {
+		t.Fatalf("Failed to submit task: %v", err)
+	}
+
+	if !task.CreatedAt.Equal(start) || task.CompletedAt == nil || !task.CompletedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the task to span one mock hour, got created %v completed %v", task.CreatedAt, task.CompletedAt)
+	}
+	if metrics := manager.GetThroughputMetrics(); metrics.AverageLatency != time.Hour {
+		t.Errorf("Expected an average latency of 1h, got %v", metrics.AverageLatency)
+	}
+}
+
+// TestTaskAffinity tests that pinned tasks run on their worker and fall back when it is unavailable
+func TestTaskAffinity(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+
+	workers := make(map[string]*Worker)
+	for _, hostname := range []string{"builder-1", "builder-2", "builder-3"} {
+		worker, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{Hostname: hostname})
+		if err != nil {
+			t.Fatalf("Failed to add simulated worker: %v", err)
+		}
+		workers[hostname] = worker
+	}
+
+	warm := workers["builder-2"].ID
+	for i := 0; i < 5; i++ {
+		task := &DistributedTask{Type: "incremental-build", OriginalWorker: &warm}
+		if err := manager.SubmitTask(task); err != nil {
+			t.Fatalf("Failed to submit task: %v", err)
+		}
+		if task.WorkerID != warm {
+			t.Fatalf("Expected task to run on its original worker, got %s", task.WorkerID)
+		}
+	}
+
+	task := &DistributedTask{Type: "incremental-build", PreferredWorker: "builder-3", OriginalWorker: &warm}
+	if err := manager.SubmitTask(task); err != nil {
+		t.Fatalf("Failed to submit task: %v", err)
+	}
+	if task.WorkerID != workers["builder-3"].ID {
+		t.Errorf("Expected the named worker to take precedence, got %s", task.WorkerID)
+	}
+
+	// An unhealthy, unknown or removed affinity worker falls back to normal selection
+	workers["builder-2"].HealthStatus = WorkerHealthUnhealthy
+	removed := workers["builder-1"].ID
+	if err := manager.RemoveWorker(removed); err != nil {
+		t.Fatalf("Failed to remove worker: %v", err)
+	}
+	fallbacks := []*DistributedTask{
+		{Type: "incremental-build", OriginalWorker: &warm},
+		{Type: "incremental-build", PreferredWorker: "no-such-host"},
+		{Type: "incremental-build", OriginalWorker: &removed},
+	}
+	for _, task := range fallbacks {
+		if err := manager.SubmitTask(task); err != nil {
+			t.Fatalf("Expected fallback scheduling to succeed: %v", err)
+		}
+		if task.WorkerID != workers["builder-3"].ID {
+			t.Errorf("Expected fallback to the only available worker, got %s", task.WorkerID)
+		}
+	}
+}
+
+// TestTaskResourceRequirements tests that tasks only run on workers meeting their
+// minimum resources and otherwise wait until one is registered
+func TestTaskResourceRequirements(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	manager.SetClock(clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	small, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{
+		Hostname:  "small",
+		Resources: Resources{CPUCount: 2, TotalMemory: 4 << 30},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add simulated worker: %v", err)
+	}
+	large, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{
+		Hostname:  "large",
+		Resources: Resources{CPUCount: 16, TotalMemory: 64 << 30},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add simulated worker: %v", err)
+	}
+
+	// The heavy task must skip the small worker even when asked for by name
+	heavy := &DistributedTask{
+		Type:                 "compile",
+		PreferredWorker:      "small",
+		ResourceRequirements: ResourceRequirements{MinCPUCount: 8, MinMemory: 32 << 30},
+	}
+	if err := manager.SubmitTask(heavy); err != nil {
+		t.Fatalf("Failed to submit task: %v", err)
+	}
+	if heavy.WorkerID != large.ID {
+		t.Errorf("Expected the heavy task on the large worker, got %s", heavy.WorkerID)
+	}
+
+	light := &DistributedTask{Type: "lint", PreferredWorker: "small"}
+	if err := manager.SubmitTask(light); err != nil {
+		t.Fatalf("Failed to submit task: %v", err)
+	}
+	if light.WorkerID != small.ID {
+		t.Errorf("Expected the light task on the small worker, got %s", light.WorkerID)
+	}
+
+	// No worker has a GPU, so the task waits
+	gpu := &DistributedTask{Type: "train", ResourceRequirements: ResourceRequirements{MinGPUCount: 1}}
+	if err := manager.SubmitTask(gpu); err != nil {
+		t.Fatalf("Expected the task to be queued, got error: %v", err)
+	}
+	if gpu.Status != TaskStatusWaitingForWorker {
+		t.Fatalf("Expected status %s, got %s", TaskStatusWaitingForWorker, gpu.Status)
+	}
+	if waiting := manager.WaitingTasks(); len(waiting) != 1 || waiting[0] != gpu {
+		t.Fatalf("Expected the GPU task to be waiting, got %v", waiting)
+	}
+
+	gpuWorker, err := manager.AddSimulatedWorker(SimulatedWorkerSpec{
+		Hostname:  "gpu",
+		Resources: Resources{CPUCount: 8, TotalMemory: 32 << 30, GPUCount: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add simulated worker: %v", err)
+	}
+	if waiting := manager.WaitingTasks(); len(waiting) != 0 {
+		t.Errorf("Expected no waiting tasks after a GPU worker joined, got %d", len(waiting))
+	}
+	if gpu.WorkerID != gpuWorker.ID {
+		t.Errorf("Expected the waiting task to be assigned to the GPU worker, got %s", gpu.WorkerID)
+	}
+}
+
+func TestDistributedWorkerManager_MaxWaitingTasks(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{Enabled: true})
+	manager.SetMaxWaitingTasks(1)
+
+	if err := manager.SubmitTask(&DistributedTask{Type: "build"}); err != nil {
+		t.Fatalf("Expected the first task to wait, got error: %v", err)
+	}
+	if err := manager.SubmitTask(&DistributedTask{Type: "build"}); !errors.Is(err, task.ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+	if err := manager.SubmitTask(&DistributedTask{Type: "hotfix", Priority: int(task.PriorityCritical)}); err != nil {
+		t.Fatalf("Expected the critical task to be admitted, got error: %v", err)
+	}
+	if waiting := manager.WaitingTasks(); len(waiting) != 2 {
+		t.Errorf("Expected 2 waiting tasks, got %d", len(waiting))
+	}
+}
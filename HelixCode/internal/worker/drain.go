@@ -0,0 +1,229 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"dev.helix.code/internal/audit"
+	"github.com/google/uuid"
+)
+
+// DefaultDrainTimeout is how long a drain waits for in-flight tasks before
+// reassigning them to other workers
+const DefaultDrainTimeout = 10 * time.Minute
+
+// DrainProgress reports how far draining a worker has got
+type DrainProgress struct {
+	WorkerID    uuid.UUID    `json:"worker_id"`
+	Hostname    string       `json:"hostname"`
+	Status      WorkerStatus `json:"status"`     // WorkerStatusDraining, then WorkerStatusDrained
+	InFlight    int          `json:"in_flight"`  // Tasks still running on the worker
+	Reassigned  int          `json:"reassigned"` // Tasks moved to other workers: queued ones at once, in-flight ones after the timeout
+	StartedAt   time.Time    `json:"started_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+}
+
+// SetDrainTimeout sets how long DrainWorker waits for in-flight tasks before
+// reassigning them. A zero timeout reassigns them immediately.
+func (dwm *DistributedWorkerManager) SetDrainTimeout(timeout time.Duration) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.drainTimeout = timeout
+}
+
+// DrainWorker takes a worker out of service without killing its tasks. The
+// worker stops receiving new tasks at once and the tasks queued on it move to
+// other workers; its in-flight tasks may finish
+// until the drain timeout, after which they are reassigned. The worker is then
+// marked drained. If ctx ends first the worker stays draining and ctx's error
+// is returned. Progress is available from GetDrainProgress.
+func (dwm *DistributedWorkerManager) DrainWorker(ctx context.Context, workerID uuid.UUID) error {
+	worker, progress, timeout, err := dwm.beginDrain(ctx, workerID)
+	if err != nil || worker == nil {
+		return err
+	}
+	return dwm.waitForDrain(ctx, worker, progress, timeout)
+}
+
+// StartDrain begins draining a worker like DrainWorker, but returns the initial
+// progress at once and lets the drain finish in the background. ctx only
+// attributes the drain; cancelling it does not stop the drain.
+func (dwm *DistributedWorkerManager) StartDrain(ctx context.Context, workerID uuid.UUID) (DrainProgress, error) {
+	worker, progress, timeout, err := dwm.beginDrain(ctx, workerID)
+	if err != nil {
+		return DrainProgress{}, err
+	}
+	if worker != nil {
+		go func() {
+			if err := dwm.waitForDrain(context.WithoutCancel(ctx), worker, progress, timeout); err != nil {
+				log.Printf("❌ Failed to drain worker %s: %v", worker.Hostname, err)
+			}
+		}()
+	}
+
+	current, _ := dwm.GetDrainProgress(workerID)
+	return current, nil
+}
+
+// beginDrain stops new tasks going to a worker and records the drain. It
+// returns a nil worker if the worker is already drained.
+func (dwm *DistributedWorkerManager) beginDrain(ctx context.Context, workerID uuid.UUID) (*Worker, *DrainProgress, <-chan time.Time, error) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+
+	worker, exists := dwm.workers[workerID]
+	if !exists {
+		return nil, nil, nil, fmt.Errorf("worker not found: %s", workerID)
+	}
+	if worker.Status == WorkerStatusDrained {
+		return nil, nil, nil, nil
+	}
+
+	progress, draining := dwm.drains[workerID]
+	if !draining || progress.Status == WorkerStatusDrained {
+		progress = &DrainProgress{
+			WorkerID:  workerID,
+			Hostname:  worker.Hostname,
+			Status:    WorkerStatusDraining,
+			StartedAt: dwm.clock.Now(),
+		}
+		dwm.drains[workerID] = progress
+	}
+	previous := worker.Status
+	worker.Status = WorkerStatusDraining
+	worker.UpdatedAt = dwm.clock.Now()
+	// Queued tasks have not started, so they move without waiting for the timeout
+	started, moved := dwm.moveQueuedTasksLocked(workerID)
+	progress.Reassigned += moved
+	progress.InFlight = len(dwm.inFlightTasksLocked(workerID))
+	if previous != WorkerStatusDraining {
+		auditWorker(ctx, dwm.audit, audit.SourceScheduler, workerID, audit.ActionStatusChanged, previous, WorkerStatusDraining,
+			map[string]interface{}{"in_flight": progress.InFlight})
+	}
+
+	log.Printf("🔄 Draining worker %s", worker.Hostname)
+	// The tasks start once the caller releases dwm.mutex
+	dwm.startReassigned(started)
+	return worker, progress, dwm.clock.After(dwm.drainTimeout), nil
+}
+
+// waitForDrain waits for, or after timeout reassigns, the worker's in-flight
+// tasks and marks it drained
+func (dwm *DistributedWorkerManager) waitForDrain(ctx context.Context, worker *Worker, progress *DrainProgress, timeout <-chan time.Time) error {
+	for {
+		dwm.mutex.Lock()
+		inFlight := dwm.inFlightTasksLocked(worker.ID)
+		progress.InFlight = len(inFlight)
+		done := dwm.taskDone
+		dwm.mutex.Unlock()
+
+		if len(inFlight) == 0 {
+			break
+		}
+		log.Printf("🔄 Draining worker %s: %d task(s) in flight", worker.Hostname, len(inFlight))
+
+		select {
+		case <-done:
+		case <-timeout:
+			dwm.mutex.Lock()
+			started, moved := dwm.reassignTasksLocked(worker)
+			progress.Reassigned += moved
+			progress.InFlight = 0
+			dwm.mutex.Unlock()
+
+			log.Printf("⚠️ Drain timeout on %s, reassigned %d task(s)", worker.Hostname, moved)
+			dwm.startReassigned(started)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	dwm.mutex.Lock()
+	completedAt := dwm.clock.Now()
+	worker.Status = WorkerStatusDrained
+	worker.UpdatedAt = completedAt
+	progress.Status = WorkerStatusDrained
+	progress.CompletedAt = &completedAt
+	auditLog := dwm.audit
+	dwm.mutex.Unlock()
+
+	auditWorker(ctx, auditLog, audit.SourceScheduler, worker.ID, audit.ActionStatusChanged, WorkerStatusDraining, WorkerStatusDrained,
+		map[string]interface{}{"reassigned": progress.Reassigned})
+	log.Printf("✅ Worker %s drained", worker.Hostname)
+	return nil
+}
+
+// GetDrainProgress returns the progress of the worker's latest drain
+func (dwm *DistributedWorkerManager) GetDrainProgress(workerID uuid.UUID) (DrainProgress, bool) {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+
+	progress, ok := dwm.drains[workerID]
+	if !ok {
+		return DrainProgress{}, false
+	}
+	return *progress, true
+}
+
+// notifyTaskFinishedLocked wakes drains waiting for tasks to finish.
+// The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) notifyTaskFinishedLocked() {
+	close(dwm.taskDone)
+	dwm.taskDone = make(chan struct{})
+}
+
+// inFlightTasksLocked returns the tasks assigned to a worker that have not
+// finished. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) inFlightTasksLocked(workerID uuid.UUID) []*DistributedTask {
+	var tasks []*DistributedTask
+	for _, task := range dwm.tasks {
+		if task.WorkerID != workerID {
+			continue
+		}
+		if task.Status == TaskStatusPending || task.Status == TaskStatusRunning {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// reassignTasksLocked moves a worker's in-flight tasks to other workers,
+// cancelling their current execution. Tasks no worker has capacity for are
+// queued on one that can run them, or else wait. It returns the tasks to
+// start and how many were moved. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) reassignTasksLocked(worker *Worker) (started []assignment, moved int) {
+	tasks := dwm.inFlightTasksLocked(worker.ID)
+	for _, task := range tasks {
+		if cancel, ok := dwm.running[task.ID]; ok {
+			cancel()
+			delete(dwm.running, task.ID)
+		}
+		worker.CurrentTasksCount--
+
+		next := dwm.reserveWorker(task)
+		if next == nil {
+			if dwm.queueTask(task) != nil {
+				continue
+			}
+			task.WorkerID = uuid.Nil
+			task.Status = TaskStatusWaitingForWorker
+			dwm.waiting = append(dwm.waiting, task)
+			continue
+		}
+		task.WorkerID = next.ID
+		task.Status = TaskStatusPending
+		started = append(started, assignment{task, next})
+	}
+	dwm.notifyTaskFinishedLocked()
+	return started, len(tasks)
+}
+
+// startReassigned runs reassigned tasks on their new workers
+func (dwm *DistributedWorkerManager) startReassigned(started []assignment) {
+	for _, a := range started {
+		log.Printf("🔄 Reassigned %s task %s to %s", a.task.Type, a.task.ID, a.worker.Hostname)
+		go dwm.executeTask(a.task, a.worker)
+	}
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/clock"
+	"github.com/google/uuid"
+)
+
+// blockingExecutor runs each task until released, reporting which worker it started on
+type blockingExecutor struct {
+	started chan *Worker
+	release chan struct{}
+}
+
+func newBlockingExecutor() *blockingExecutor {
+	return &blockingExecutor{started: make(chan *Worker, 10), release: make(chan struct{})}
+}
+
+func (e *blockingExecutor) ExecuteTask(ctx context.Context, task *DistributedTask, worker *Worker) (map[string]interface{}, error) {
+	e.started <- worker
+	select {
+	case <-e.release:
+		return map[string]interface{}{"output": "done"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *blockingExecutor) waitStarted(t *testing.T) *Worker {
+	t.Helper()
+	select {
+	case worker := <-e.started:
+		return worker
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a task to start")
+		return nil
+	}
+}
+
+func registerDrainTestWorker(t *testing.T, manager *DistributedWorkerManager, hostname string) *Worker {
+	t.Helper()
+	worker := &Worker{Hostname: hostname, Status: WorkerStatusActive, HealthStatus: WorkerHealthHealthy}
+	if err := manager.RegisterWorker(worker); err != nil {
+		t.Fatalf("Failed to register worker: %v", err)
+	}
+	return worker
+}
+
+// waitForInFlight polls the drain progress until it reports n in-flight tasks
+func waitForInFlight(t *testing.T, manager *DistributedWorkerManager, workerID uuid.UUID, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if progress, ok := manager.GetDrainProgress(workerID); ok && progress.InFlight == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d in-flight task(s)", n)
+}
+
+// TestDrainWorker tests that a draining worker takes no new tasks and finishes its running ones
+func TestDrainWorker(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	executor := newBlockingExecutor()
+	manager.SetTaskExecutor(executor)
+
+	draining := registerDrainTestWorker(t, manager, "draining")
+	running := &DistributedTask{Type: "build"}
+	go manager.SubmitTask(running)
+	executor.waitStarted(t)
+
+	other := registerDrainTestWorker(t, manager, "other")
+	drained := make(chan error, 1)
+	go func() { drained <- manager.DrainWorker(context.Background(), draining.ID) }()
+	waitForInFlight(t, manager, draining.ID, 1)
+
+	go manager.SubmitTask(&DistributedTask{Type: "build", PreferredWorker: "draining"})
+	if worker := executor.waitStarted(t); worker.ID != other.ID {
+		t.Errorf("Expected the new task on the other worker, got %s", worker.Hostname)
+	}
+
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain finished with a task still running: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(executor.release)
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	progress, ok := manager.GetDrainProgress(draining.ID)
+	if !ok || progress.Status != WorkerStatusDrained || progress.InFlight != 0 || progress.Reassigned != 0 || progress.CompletedAt == nil {
+		t.Errorf("Unexpected drain progress: %+v", progress)
+	}
+	if running.Status != TaskStatusCompleted || running.WorkerID != draining.ID {
+		t.Errorf("Expected the running task to complete on the draining worker, got %s on %s", running.Status, running.WorkerID)
+	}
+	if draining.Status != WorkerStatusDrained {
+		t.Errorf("Expected worker status %s, got %s", WorkerStatusDrained, draining.Status)
+	}
+}
+
+// TestDrainWorker_ReassignsAfterTimeout tests that tasks still running at the drain timeout move to another worker
+func TestDrainWorker_ReassignsAfterTimeout(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	manager.SetClock(mock)
+	manager.SetDrainTimeout(time.Minute)
+	executor := newBlockingExecutor()
+	manager.SetTaskExecutor(executor)
+
+	draining := registerDrainTestWorker(t, manager, "draining")
+	task := &DistributedTask{Type: "build"}
+	go manager.SubmitTask(task)
+	executor.waitStarted(t)
+
+	other := registerDrainTestWorker(t, manager, "other")
+	drained := make(chan error, 1)
+	go func() { drained <- manager.DrainWorker(context.Background(), draining.ID) }()
+	waitForInFlight(t, manager, draining.ID, 1)
+
+	mock.Advance(time.Minute)
+	if worker := executor.waitStarted(t); worker.ID != other.ID {
+		t.Fatalf("Expected the task to be reassigned to the other worker, got %s", worker.Hostname)
+	}
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	progress, _ := manager.GetDrainProgress(draining.ID)
+	if progress.Status != WorkerStatusDrained || progress.Reassigned != 1 {
+		t.Errorf("Unexpected drain progress: %+v", progress)
+	}
+	if draining.CurrentTasksCount != 0 {
+		t.Errorf("Expected no tasks counted against the drained worker, got %d", draining.CurrentTasksCount)
+	}
+	close(executor.release)
+}
+
+func TestDrainWorker_UnknownWorker(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	if err := manager.DrainWorker(context.Background(), uuid.New()); err == nil {
+		t.Error("Expected an error draining an unknown worker")
+	}
+}
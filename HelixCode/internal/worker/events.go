@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkerEventType represents a change in worker membership
+type WorkerEventType string
+
+const (
+	WorkerEventAdded   WorkerEventType = "added"
+	WorkerEventRemoved WorkerEventType = "removed"
+)
+
+// WorkerEvent describes a worker joining or leaving a pool
+type WorkerEvent struct {
+	Type      WorkerEventType `json:"type"`
+	WorkerID  uuid.UUID       `json:"worker_id"`
+	Hostname  string          `json:"hostname"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// WorkerEventListener is called after a worker is added or removed.
+// Listeners run without the pool lock held and may call back into the pool.
+type WorkerEventListener func(WorkerEvent)
+
+// workerEvents holds the listeners of a pool or manager
+type workerEvents struct {
+	listeners []WorkerEventListener
+}
+
+func (e *workerEvents) add(listener WorkerEventListener) {
+	e.listeners = append(e.listeners, listener)
+}
+
+// snapshot returns the current listeners. The caller must hold the owner's lock.
+func (e *workerEvents) snapshot() []WorkerEventListener {
+	return append([]WorkerEventListener(nil), e.listeners...)
+}
+
+// emitWorkerEvent notifies listeners of a membership change
+func emitWorkerEvent(listeners []WorkerEventListener, eventType WorkerEventType, id uuid.UUID, hostname string) {
+	event := WorkerEvent{
+		Type:      eventType,
+		WorkerID:  id,
+		Hostname:  hostname,
+		Timestamp: time.Now(),
+	}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/clock"
 )
 
 // WorkerStatus represents the status of a worker
@@ -21,6 +23,8 @@ const (
 	WorkerStatusMaintenance WorkerStatus = "maintenance"
 	WorkerStatusFailed      WorkerStatus = "failed"
 	WorkerStatusOffline     WorkerStatus = "offline"
+	WorkerStatusDraining    WorkerStatus = "draining"
+	WorkerStatusDrained     WorkerStatus = "drained"
 )
 
 // WorkerHealth represents the health status of a worker
@@ -95,6 +99,8 @@ type WorkerManager struct {
 	workers   map[uuid.UUID]*Worker
 	mutex     sync.RWMutex
 	healthTTL time.Duration
+	clock     clock.Clock
+	audit     *audit.Log
 }
 
 // NewWorkerManager creates a new worker manager
@@ -103,9 +109,17 @@ func NewWorkerManager(repo WorkerRepository, healthTTL time.Duration) *WorkerMan
 		repo:      repo,
 		workers:   make(map[uuid.UUID]*Worker),
 		healthTTL: healthTTL,
+		clock:     clock.New(),
 	}
 }
 
+// SetClock replaces the clock used for heartbeats and health TTLs
+func (wm *WorkerManager) SetClock(c clock.Clock) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	wm.clock = c
+}
+
 // RegisterWorker registers a new worker
 func (wm *WorkerManager) RegisterWorker(ctx context.Context, worker *Worker) error {
 	wm.mutex.Lock()
@@ -113,20 +127,22 @@ func (wm *WorkerManager) RegisterWorker(ctx context.Context, worker *Worker) err
 
 	// Check if worker already exists
 	existing, err := wm.repo.GetWorkerByHostname(ctx, worker.Hostname)
+	var from WorkerStatus
 	if err == nil && existing != nil {
 		// Update existing worker
 		worker.ID = existing.ID
 		worker.CreatedAt = existing.CreatedAt
+		from = existing.Status
 	} else {
 		// Create new worker
 		worker.ID = uuid.New()
-		worker.CreatedAt = time.Now()
+		worker.CreatedAt = wm.clock.Now()
 	}
 
-	worker.UpdatedAt = time.Now()
+	worker.UpdatedAt = wm.clock.Now()
 	worker.Status = WorkerStatusActive
 	worker.HealthStatus = WorkerHealthHealthy
-	worker.LastHeartbeat = time.Now()
+	worker.LastHeartbeat = wm.clock.Now()
 
 	if err := wm.repo.CreateWorker(ctx, worker); err != nil {
 		return fmt.Errorf("failed to register worker: %v", err)
@@ -134,6 +150,12 @@ func (wm *WorkerManager) RegisterWorker(ctx context.Context, worker *Worker) err
 
 	// Cache worker
 	wm.workers[worker.ID] = worker
+	action := audit.ActionStatusChanged
+	if from == "" {
+		action = audit.ActionCreated
+	}
+	auditWorker(ctx, wm.audit, audit.SourceMonitor, worker.ID, action, from, worker.Status,
+		map[string]interface{}{"hostname": worker.Hostname})
 
 	log.Printf("✅ Worker registered: %s (%s)", worker.Hostname, worker.ID)
 	return nil
@@ -149,8 +171,8 @@ func (wm *WorkerManager) UpdateWorkerHeartbeat(ctx context.Context, workerID uui
 		return fmt.Errorf("worker not found: %v", err)
 	}
 
-	worker.LastHeartbeat = time.Now()
-	worker.UpdatedAt = time.Now()
+	worker.LastHeartbeat = wm.clock.Now()
+	worker.UpdatedAt = wm.clock.Now()
 
 	// Update metrics if provided
 	if metrics != nil {
@@ -162,7 +184,7 @@ func (wm *WorkerManager) UpdateWorkerHeartbeat(ctx context.Context, workerID uui
 		// Record metrics
 		metrics.ID = uuid.New()
 		metrics.WorkerID = workerID
-		metrics.RecordedAt = time.Now()
+		metrics.RecordedAt = wm.clock.Now()
 		if err := wm.repo.RecordMetrics(ctx, metrics); err != nil {
 			log.Printf("Warning: Failed to record metrics: %v", err)
 		}
@@ -221,7 +243,7 @@ func (wm *WorkerManager) AssignTask(ctx context.Context, workerID uuid.UUID) err
 	}
 
 	worker.CurrentTasksCount++
-	worker.UpdatedAt = time.Now()
+	worker.UpdatedAt = wm.clock.Now()
 
 	if err := wm.repo.UpdateWorker(ctx, worker); err != nil {
 		return fmt.Errorf("failed to assign task: %v", err)
@@ -245,7 +267,7 @@ func (wm *WorkerManager) CompleteTask(ctx context.Context, workerID uuid.UUID) e
 
 	if worker.CurrentTasksCount > 0 {
 		worker.CurrentTasksCount--
-		worker.UpdatedAt = time.Now()
+		worker.UpdatedAt = wm.clock.Now()
 
 		if err := wm.repo.UpdateWorker(ctx, worker); err != nil {
 			return fmt.Errorf("failed to complete task: %v", err)
@@ -268,10 +290,11 @@ func (wm *WorkerManager) HealthCheck(ctx context.Context) error {
 		return err
 	}
 
-	now := time.Now()
+	now := wm.clock.Now()
 	for _, worker := range workers {
 		// Check if worker has timed out
 		if now.Sub(worker.LastHeartbeat) > wm.healthTTL {
+			from := worker.Status
 			worker.HealthStatus = WorkerHealthUnhealthy
 			worker.Status = WorkerStatusOffline
 			worker.UpdatedAt = now
@@ -281,6 +304,8 @@ func (wm *WorkerManager) HealthCheck(ctx context.Context) error {
 			} else {
 				log.Printf("⚠️  Worker marked as unhealthy: %s (last heartbeat: %v)", 
 					worker.Hostname, worker.LastHeartbeat)
+				auditWorker(ctx, wm.audit, audit.SourceMonitor, worker.ID, audit.ActionStatusChanged, from, worker.Status,
+					map[string]interface{}{"last_heartbeat": worker.LastHeartbeat})
 			}
 
 			// Update cache
@@ -371,7 +396,7 @@ func (wm *WorkerManager) hasCapabilities(worker *Worker, required []string) bool
 
 func (wm *WorkerManager) isWorkerHealthy(worker *Worker) bool {
 	return worker.HealthStatus == WorkerHealthHealthy &&
-		time.Since(worker.LastHeartbeat) <= wm.healthTTL
+		wm.clock.Since(worker.LastHeartbeat) <= wm.healthTTL
 }
 
 func (wm *WorkerManager) calculateHealthStatus(worker *Worker, metrics *WorkerMetrics) WorkerHealth {
@@ -24,6 +24,10 @@ func NewDatabaseManager(db *database.Database) *DatabaseManager {
 
 // GetWorker retrieves a worker by ID from database
 func (m *DatabaseManager) GetWorker(ctx context.Context, id string) (*Worker, error) {
+	if !m.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
 	workerID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid worker ID: %v", err)
@@ -117,6 +121,10 @@ func (m *DatabaseManager) GetWorker(ctx context.Context, id string) (*Worker, er
 
 // ListWorkers returns all workers from database
 func (m *DatabaseManager) ListWorkers(ctx context.Context) ([]*Worker, error) {
+	if !m.db.IsConfigured() {
+		return []*Worker{}, database.ErrNotConfigured
+	}
+
 	query := `
 		SELECT 
 			id, hostname, display_name, ssh_config, capabilities, resources,
@@ -215,6 +223,10 @@ func (m *DatabaseManager) ListWorkers(ctx context.Context) ([]*Worker, error) {
 
 // RegisterWorker registers a new worker in the system
 func (m *DatabaseManager) RegisterWorker(ctx context.Context, hostname, displayName string, sshConfig map[string]interface{}, capabilities []string, resources map[string]interface{}) (*Worker, error) {
+	if !m.db.IsConfigured() {
+		return nil, database.ErrNotConfigured
+	}
+
 	worker := &Worker{
 		ID:                  uuid.New(),
 		Hostname:            hostname,
@@ -260,6 +272,10 @@ func (m *DatabaseManager) RegisterWorker(ctx context.Context, hostname, displayN
 
 // UpdateWorkerHeartbeat updates worker heartbeat and metrics
 func (m *DatabaseManager) UpdateWorkerHeartbeat(ctx context.Context, id string, metrics map[string]interface{}) error {
+	if !m.db.IsConfigured() {
+		return database.ErrNotConfigured
+	}
+
 	workerID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid worker ID: %v", err)
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"dev.helix.code/internal/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDatabaseManager_NilDatabase tests that DB-backed methods fail cleanly without a database
+func TestDatabaseManager_NilDatabase(t *testing.T) {
+	ctx := context.Background()
+	m := NewDatabaseManager(nil)
+	id := uuid.New().String()
+
+	assert.NotPanics(t, func() {
+		workers, err := m.ListWorkers(ctx)
+		assert.ErrorIs(t, err, database.ErrNotConfigured)
+		assert.NotNil(t, workers)
+		assert.Empty(t, workers)
+
+		_, err = m.GetWorker(ctx, id)
+		assert.ErrorIs(t, err, database.ErrNotConfigured)
+
+		_, err = m.RegisterWorker(ctx, "host", "Host", nil, []string{"build"}, nil)
+		assert.ErrorIs(t, err, database.ErrNotConfigured)
+
+		err = m.UpdateWorkerHeartbeat(ctx, id, map[string]interface{}{})
+		assert.ErrorIs(t, err, database.ErrNotConfigured)
+	})
+
+	var db *database.Database
+	assert.False(t, db.IsConfigured())
+	assert.ErrorIs(t, db.HealthCheck(), database.ErrNotConfigured)
+	assert.NotPanics(t, db.Close)
+}
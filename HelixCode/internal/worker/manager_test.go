@@ -0,0 +1,180 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/clock"
+	"github.com/google/uuid"
+)
+
+// memoryWorkerRepository is an in-memory WorkerRepository for tests
+type memoryWorkerRepository struct {
+	mu      sync.Mutex
+	workers map[uuid.UUID]Worker
+}
+
+func newMemoryWorkerRepository() *memoryWorkerRepository {
+	return &memoryWorkerRepository{workers: make(map[uuid.UUID]Worker)}
+}
+
+func (r *memoryWorkerRepository) CreateWorker(ctx context.Context, worker *Worker) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workers[worker.ID] = *worker
+	return nil
+}
+
+func (r *memoryWorkerRepository) GetWorker(ctx context.Context, id uuid.UUID) (*Worker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	worker, ok := r.workers[id]
+	if !ok {
+		return nil, fmt.Errorf("worker %s not found", id)
+	}
+	return &worker, nil
+}
+
+func (r *memoryWorkerRepository) GetWorkerByHostname(ctx context.Context, hostname string) (*Worker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, worker := range r.workers {
+		if worker.Hostname == hostname {
+			return &worker, nil
+		}
+	}
+	return nil, fmt.Errorf("worker %s not found", hostname)
+}
+
+func (r *memoryWorkerRepository) ListWorkers(ctx context.Context, status WorkerStatus) ([]*Worker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var workers []*Worker
+	for _, worker := range r.workers {
+		if status == "" || worker.Status == status {
+			w := worker
+			workers = append(workers, &w)
+		}
+	}
+	return workers, nil
+}
+
+func (r *memoryWorkerRepository) UpdateWorker(ctx context.Context, worker *Worker) error {
+	return r.CreateWorker(ctx, worker)
+}
+
+func (r *memoryWorkerRepository) DeleteWorker(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.workers, id)
+	return nil
+}
+
+func (r *memoryWorkerRepository) RecordMetrics(ctx context.Context, metrics *WorkerMetrics) error {
+	return nil
+}
+
+func (r *memoryWorkerRepository) GetWorkerMetrics(ctx context.Context, workerID uuid.UUID, since time.Time) ([]*WorkerMetrics, error) {
+	return nil, nil
+}
+
+func TestWorkerManager_StaleWorkerMarkedOffline(t *testing.T) {
+	ctx := context.Background()
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := newMemoryWorkerRepository()
+
+	manager := NewWorkerManager(repo, time.Minute)
+	manager.SetClock(mock)
+
+	stale := &Worker{Hostname: "stale", MaxConcurrentTasks: 2}
+	fresh := &Worker{Hostname: "fresh", MaxConcurrentTasks: 2}
+	for _, worker := range []*Worker{stale, fresh} {
+		if err := manager.RegisterWorker(ctx, worker); err != nil {
+			t.Fatalf("Failed to register worker: %v", err)
+		}
+	}
+
+	mock.Advance(45 * time.Second)
+	if err := manager.UpdateWorkerHeartbeat(ctx, fresh.ID, nil); err != nil {
+		t.Fatalf("Failed to update heartbeat: %v", err)
+	}
+
+	available, err := manager.GetAvailableWorkers(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to list available workers: %v", err)
+	}
+	if len(available) != 2 {
+		t.Fatalf("Expected both workers available within the TTL, got %d", len(available))
+	}
+
+	// The stale worker's heartbeat is now older than the TTL, the fresh one's is not
+	mock.Advance(30 * time.Second)
+	available, err = manager.GetAvailableWorkers(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to list available workers: %v", err)
+	}
+	if len(available) != 1 || available[0].ID != fresh.ID {
+		t.Fatalf("Expected only the fresh worker to be available, got %v", available)
+	}
+
+	if err := manager.HealthCheck(ctx); err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	got, err := repo.GetWorker(ctx, stale.ID)
+	if err != nil {
+		t.Fatalf("Failed to get worker: %v", err)
+	}
+	if got.Status != WorkerStatusOffline || got.HealthStatus != WorkerHealthUnhealthy {
+		t.Errorf("Expected stale worker offline and unhealthy, got %s/%s", got.Status, got.HealthStatus)
+	}
+	if !got.UpdatedAt.Equal(mock.Now()) {
+		t.Errorf("Expected UpdatedAt from the mock clock, got %v", got.UpdatedAt)
+	}
+
+	got, err = repo.GetWorker(ctx, fresh.ID)
+	if err != nil {
+		t.Fatalf("Failed to get worker: %v", err)
+	}
+	if got.Status != WorkerStatusActive {
+		t.Errorf("Expected fresh worker to stay active, got %s", got.Status)
+	}
+}
+
+func TestWorkerManager_AuditLog(t *testing.T) {
+	ctx := context.Background()
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	auditLog := audit.NewLog(audit.NewMemoryStore())
+	auditLog.SetClock(mock)
+
+	manager := NewWorkerManager(newMemoryWorkerRepository(), time.Minute)
+	manager.SetClock(mock)
+	manager.SetAuditLog(auditLog)
+
+	worker := &Worker{Hostname: "audited", MaxConcurrentTasks: 1}
+	if err := manager.RegisterWorker(ctx, worker); err != nil {
+		t.Fatalf("Failed to register worker: %v", err)
+	}
+	mock.Advance(2 * time.Minute)
+	if err := manager.HealthCheck(ctx); err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+
+	page, err := auditLog.Query(ctx, audit.Query{EntityID: worker.ID})
+	if err != nil {
+		t.Fatalf("Failed to query audit log: %v", err)
+	}
+	if len(page.Events) != 2 {
+		t.Fatalf("Expected 2 audit events, got %d", len(page.Events))
+	}
+	if page.Events[0].Action != audit.ActionCreated {
+		t.Errorf("Expected registration to be recorded first, got %s", page.Events[0].Action)
+	}
+	offline := page.Events[1]
+	if offline.ToStatus != string(WorkerStatusOffline) || offline.Source != audit.SourceMonitor {
+		t.Errorf("Expected the monitor to mark the worker offline, got %+v", offline)
+	}
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"dev.helix.code/internal/audit"
+	"github.com/google/uuid"
+)
+
+// DefaultRebalanceThreshold is how many more tasks a worker must hold than
+// another before the rebalancing pass moves queued tasks between them
+const DefaultRebalanceThreshold = 2
+
+// RebalanceStats reports what the rebalancing pass has done
+type RebalanceStats struct {
+	Runs    int        `json:"runs"`     // Passes run so far
+	Moved   int        `json:"moved"`    // Tasks moved by all passes
+	LastRun *time.Time `json:"last_run"` // When the latest pass ran
+}
+
+// rebalanceThreshold returns the configured load difference that triggers a
+// move. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) rebalanceThreshold() int {
+	if dwm.config.RebalanceThreshold > 0 {
+		return dwm.config.RebalanceThreshold
+	}
+	return DefaultRebalanceThreshold
+}
+
+// Rebalance moves tasks queued on busy workers to less loaded ones that can
+// run them, starting them there if the target has a free slot. A worker's
+// load is the tasks it runs plus those queued on it. A task moves if its
+// worker can no longer run it, or holds at least the rebalance threshold more
+// tasks than the target; tasks pinned to their worker by affinity stay put. It
+// returns how many tasks were moved.
+func (dwm *DistributedWorkerManager) Rebalance(ctx context.Context) int {
+	dwm.mutex.Lock()
+	threshold := dwm.rebalanceThreshold()
+
+	// Move the most recently queued tasks first; older ones are likely to start soonest
+	queued := make([]*DistributedTask, 0)
+	for _, queue := range dwm.queued {
+		for i := len(queue) - 1; i >= 0; i-- {
+			queued = append(queued, queue[i])
+		}
+	}
+
+	var moved, started []assignment
+	sources := make(map[uuid.UUID]uuid.UUID)
+	for _, task := range queued {
+		source, exists := dwm.workers[task.WorkerID]
+		if !exists || dwm.pinnedTo(task, source) {
+			continue
+		}
+		var target *Worker
+		for _, worker := range dwm.workers {
+			if worker.ID == source.ID || !canQueueTask(worker, task) {
+				continue
+			}
+			if target == nil || dwm.load(worker) < dwm.load(target) {
+				target = worker
+			}
+		}
+		if target == nil {
+			continue
+		}
+		if canQueueTask(source, task) && dwm.load(source)-dwm.load(target) < threshold {
+			continue
+		}
+
+		dwm.dequeueTask(task)
+		sources[task.ID] = source.ID
+		task.WorkerID = target.ID
+		moved = append(moved, assignment{task, target})
+		if canRunTask(target, task) {
+			target.CurrentTasksCount++
+			started = append(started, assignment{task, target})
+		} else {
+			dwm.queued[target.ID] = append(dwm.queued[target.ID], task)
+		}
+	}
+
+	now := dwm.clock.Now()
+	dwm.rebalance.Runs++
+	dwm.rebalance.Moved += len(moved)
+	dwm.rebalance.LastRun = &now
+	auditLog := dwm.audit
+	dwm.mutex.Unlock()
+
+	for _, a := range moved {
+		auditLog.Record(ctx, audit.SourceScheduler, audit.EntityTask, a.task.ID, audit.ActionReassigned, "", "",
+			map[string]interface{}{"from_worker": sources[a.task.ID].String(), "to_worker": a.worker.ID.String()})
+		log.Printf("🔄 Rebalanced %s task %s to %s", a.task.Type, a.task.ID, a.worker.Hostname)
+	}
+	for _, a := range started {
+		go dwm.executeTask(a.task, a.worker)
+	}
+	return len(moved)
+}
+
+// pinnedTo reports whether affinity ties the task to worker. The caller must
+// hold dwm.mutex.
+func (dwm *DistributedWorkerManager) pinnedTo(task *DistributedTask, worker *Worker) bool {
+	if task.PreferredWorker != "" && task.PreferredWorker == worker.Hostname {
+		return true
+	}
+	return task.OriginalWorker != nil && *task.OriginalWorker == worker.ID
+}
+
+// RebalanceStats returns what the rebalancing pass has done so far
+func (dwm *DistributedWorkerManager) RebalanceStats() RebalanceStats {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+
+	stats := dwm.rebalance
+	if stats.LastRun != nil {
+		lastRun := *stats.LastRun
+		stats.LastRun = &lastRun
+	}
+	return stats
+}
+
+// StartRebalancing runs Rebalance every configured rebalance interval until
+// ctx ends. It returns at once if the interval is not positive.
+func (dwm *DistributedWorkerManager) StartRebalancing(ctx context.Context) {
+	interval := time.Duration(dwm.config.RebalanceInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+	log.Printf("🔄 Rebalancing workers every %v", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-dwm.clock.After(interval):
+			dwm.Rebalance(ctx)
+		}
+	}
+}
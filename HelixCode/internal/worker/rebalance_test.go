@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func registerRebalanceTestWorker(t *testing.T, manager *DistributedWorkerManager, hostname string) *Worker {
+	t.Helper()
+	worker := &Worker{Hostname: hostname, Status: WorkerStatusActive, HealthStatus: WorkerHealthHealthy, MaxConcurrentTasks: 1}
+	if err := manager.RegisterWorker(worker); err != nil {
+		t.Fatalf("Failed to register worker: %v", err)
+	}
+	return worker
+}
+
+func TestRebalance_MovesQueuedTaskToIdleWorker(t *testing.T) {
+	manager := NewDistributedWorkerManager(WorkerConfig{})
+	executor := newBlockingExecutor()
+	manager.SetTaskExecutor(executor)
+
+	busy := registerRebalanceTestWorker(t, manager, "busy")
+	running := &DistributedTask{Type: "build"}
+	go manager.SubmitTask(running)
+	executor.waitStarted(t)
+
+	// The busy worker is full, so these queue on it, the newest pinned to it
+	queued := []*DistributedTask{
+		{Type: "build"},
+		{Type: "build"},
+		{Type: "build", PreferredWorker: "busy"},
+	}
+	for _, task := range queued {
+		if err := manager.SubmitTask(task); err != nil {
+			t.Fatalf("Failed to submit task: %v", err)
+		}
+		if task.Status != TaskStatusPending || task.WorkerID != busy.ID {
+			t.Fatalf("Expected the task to queue on the busy worker, got %s on %s", task.Status, task.WorkerID)
+		}
+	}
+	if stats := manager.GetWorkerStats(); stats["queued_tasks"] != 3 {
+		t.Fatalf("Expected 3 queued tasks, got %v", stats["queued_tasks"])
+	}
+
+	// A new worker does not take tasks already queued elsewhere
+	idle := registerRebalanceTestWorker(t, manager, "idle")
+
+	// Loads are 4 and 0: the newest unpinned task starts on the idle worker,
+	// leaving 3 and 1, so the next one queues there, leaving 2 and 2
+	if moved := manager.Rebalance(context.Background()); moved != 2 {
+		t.Fatalf("Expected two tasks to move, got %d", moved)
+	}
+	if worker := executor.waitStarted(t); worker.ID != idle.ID {
+		t.Errorf("Expected the moved task to start on the idle worker, got %s", worker.Hostname)
+	}
+
+	manager.mutex.RLock()
+	first, second, pinned := queued[0].WorkerID, queued[1].WorkerID, queued[2].WorkerID
+	busyLoad, idleLoad := manager.load(busy), manager.load(idle)
+	manager.mutex.RUnlock()
+	if pinned != busy.ID {
+		t.Error("Expected the pinned task to stay on its preferred worker")
+	}
+	if first != idle.ID || second != idle.ID {
+		t.Error("Expected both unpinned tasks to move to the idle worker")
+	}
+	if busyLoad != 2 || idleLoad != 2 {
+		t.Errorf("Expected loads 2 and 2, got %d and %d", busyLoad, idleLoad)
+	}
+
+	// The loads are now within the threshold
+	if moved := manager.Rebalance(context.Background()); moved != 0 {
+		t.Errorf("Expected a balanced pool to stay put, got %d moves", moved)
+	}
+	stats := manager.RebalanceStats()
+	if stats.Runs != 2 || stats.Moved != 2 || stats.LastRun == nil {
+		t.Errorf("Unexpected rebalance stats: %+v", stats)
+	}
+
+	// Each worker works through its own queue once tasks finish
+	close(executor.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for _, task := range append(queued, running) {
+		for {
+			manager.mutex.RLock()
+			status := task.Status
+			manager.mutex.RUnlock()
+			if status == TaskStatusCompleted {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for task %s, which is %s", task.ID, status)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
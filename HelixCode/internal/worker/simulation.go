@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SimulatedWorkerSpec describes a worker whose command execution is simulated
+type SimulatedWorkerSpec struct {
+	Hostname           string
+	Capabilities       []string
+	Resources          Resources
+	Latency            time.Duration // Base time each task takes
+	Jitter             time.Duration // Random extra latency of up to this amount
+	FailureRate        float64       // Probability between 0 and 1 that a task fails
+	MaxConcurrentTasks int
+}
+
+// ThroughputMetrics aggregates task execution across all workers
+type ThroughputMetrics struct {
+	Submitted       int               `json:"submitted"`
+	Completed       int               `json:"completed"`
+	Failed          int               `json:"failed"`
+	AverageLatency  time.Duration     `json:"average_latency"`
+	Elapsed         time.Duration     `json:"elapsed"`
+	TasksPerSecond  float64           `json:"tasks_per_second"`
+	PerWorker       map[uuid.UUID]int `json:"per_worker"`
+	FailedPerWorker map[uuid.UUID]int `json:"failed_per_worker"`
+}
+
+// throughputTracker records task executions. The owner's lock must be held.
+type throughputTracker struct {
+	submitted       int
+	completed       int
+	failed          int
+	totalLatency    time.Duration
+	firstSubmit     time.Time
+	lastFinish      time.Time
+	perWorker       map[uuid.UUID]int
+	failedPerWorker map[uuid.UUID]int
+}
+
+func newThroughputTracker() *throughputTracker {
+	return &throughputTracker{
+		perWorker:       make(map[uuid.UUID]int),
+		failedPerWorker: make(map[uuid.UUID]int),
+	}
+}
+
+func (t *throughputTracker) recordSubmit(now time.Time) {
+	if t.submitted == 0 {
+		t.firstSubmit = now
+	}
+	t.submitted++
+}
+
+func (t *throughputTracker) recordFinish(workerID uuid.UUID, latency time.Duration, failed bool, now time.Time) {
+	t.totalLatency += latency
+	t.lastFinish = now
+	t.perWorker[workerID]++
+	if failed {
+		t.failed++
+		t.failedPerWorker[workerID]++
+	} else {
+		t.completed++
+	}
+}
+
+func (t *throughputTracker) metrics() ThroughputMetrics {
+	m := ThroughputMetrics{
+		Submitted:       t.submitted,
+		Completed:       t.completed,
+		Failed:          t.failed,
+		PerWorker:       make(map[uuid.UUID]int, len(t.perWorker)),
+		FailedPerWorker: make(map[uuid.UUID]int, len(t.failedPerWorker)),
+	}
+	for id, count := range t.perWorker {
+		m.PerWorker[id] = count
+	}
+	for id, count := range t.failedPerWorker {
+		m.FailedPerWorker[id] = count
+	}
+
+	finished := t.completed + t.failed
+	if finished > 0 {
+		m.AverageLatency = t.totalLatency / time.Duration(finished)
+		m.Elapsed = t.lastFinish.Sub(t.firstSubmit)
+		if m.Elapsed > 0 {
+			m.TasksPerSecond = float64(finished) / m.Elapsed.Seconds()
+		}
+	}
+	return m
+}
+
+// AddSimulatedWorker registers a worker that executes tasks without SSH, using the
+// spec's latency and failure rate. Simulated workers let the scheduler be load tested.
+func (dwm *DistributedWorkerManager) AddSimulatedWorker(spec SimulatedWorkerSpec) (*Worker, error) {
+	if spec.FailureRate < 0 || spec.FailureRate > 1 {
+		return nil, fmt.Errorf("invalid failure rate %.2f: must be between 0 and 1", spec.FailureRate)
+	}
+	if spec.Latency < 0 || spec.Jitter < 0 {
+		return nil, fmt.Errorf("latency and jitter must not be negative")
+	}
+
+	id := uuid.New()
+	hostname := spec.Hostname
+	if hostname == "" {
+		hostname = "simulated-" + id.String()[:8]
+	}
+
+	now := dwm.clock.Now()
+	worker := &Worker{
+		ID:                 id,
+		Hostname:           hostname,
+		DisplayName:        hostname + " (simulated)",
+		Capabilities:       spec.Capabilities,
+		Resources:          spec.Resources,
+		Status:             WorkerStatusActive,
+		HealthStatus:       WorkerHealthHealthy,
+		LastHeartbeat:      now,
+		MaxConcurrentTasks: spec.MaxConcurrentTasks,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	dwm.mutex.Lock()
+	dwm.simulated[worker.ID] = &spec
+	dwm.mutex.Unlock()
+
+	if err := dwm.RegisterWorker(worker); err != nil {
+		dwm.mutex.Lock()
+		delete(dwm.simulated, worker.ID)
+		dwm.mutex.Unlock()
+		return nil, err
+	}
+	return worker, nil
+}
+
+// SetSimulationSeed makes simulated latency jitter and failures reproducible
+func (dwm *DistributedWorkerManager) SetSimulationSeed(seed int64) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.rng = rand.New(rand.NewSource(seed))
+}
+
+// GetThroughputMetrics returns aggregate task throughput across all workers
+func (dwm *DistributedWorkerManager) GetThroughputMetrics() ThroughputMetrics {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+	return dwm.throughput.metrics()
+}
+
+// simulateExecution returns how long a task takes on a simulated worker and
+// whether it fails. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) simulateExecution(spec *SimulatedWorkerSpec) (time.Duration, bool) {
+	latency := spec.Latency
+	if spec.Jitter > 0 {
+		latency += time.Duration(dwm.rng.Int63n(int64(spec.Jitter) + 1))
+	}
+	return latency, dwm.rng.Float64() < spec.FailureRate
+}
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"dev.helix.code/internal/paths"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh"
 )
@@ -16,10 +21,27 @@ import (
 // SSHWorkerPool manages SSH-based distributed workers
 type SSHWorkerPool struct {
 	workers     map[uuid.UUID]*SSHWorker
-	mutex       sync.RWMutex
+	mutex       sync.RWMutex // guards workers and events
 	autoInstall bool
+	events      workerEvents
+	executor    CommandExecutor
 }
 
+// CommandExecutor runs a shell command on a worker. stdin may be nil.
+type CommandExecutor interface {
+	Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error)
+}
+
+// CommandOptions sets the context a command runs in on a remote worker
+type CommandOptions struct {
+	Env     map[string]string // Exported before the command runs
+	WorkDir string            // Directory to run the command in
+	Stdin   io.Reader         // Input piped to the command
+}
+
+// envNamePattern matches the variable names a POSIX shell accepts
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // SSHWorker represents an SSH-accessible worker node
 type SSHWorker struct {
 	ID           uuid.UUID
@@ -48,27 +70,53 @@ type SSHWorkerConfig struct {
 
 // NewSSHWorkerPool creates a new SSH worker pool
 func NewSSHWorkerPool(autoInstall bool) *SSHWorkerPool {
-	return &SSHWorkerPool{
+	pool := &SSHWorkerPool{
 		workers:     make(map[uuid.UUID]*SSHWorker),
 		autoInstall: autoInstall,
 	}
+	pool.executor = &sshExecutor{pool: pool}
+	return pool
 }
 
-// AddWorker adds a new worker to the pool
-func (p *SSHWorkerPool) AddWorker(ctx context.Context, worker *SSHWorker) error {
+// SetExecutor replaces how commands are run on workers, e.g. with a fake in tests
+func (p *SSHWorkerPool) SetExecutor(executor CommandExecutor) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
+	p.executor = executor
+}
 
+// AddWorker connects to a new worker, adds it to the pool and detects its capabilities
+func (p *SSHWorkerPool) AddWorker(ctx context.Context, worker *SSHWorker) error {
 	// Validate SSH configuration
 	if err := p.validateSSHConfig(worker.SSHConfig); err != nil {
 		return fmt.Errorf("invalid SSH config: %v", err)
 	}
+	worker.SSHConfig.KeyPath = paths.Expand(worker.SSHConfig.KeyPath)
 
 	// Test SSH connection
 	if err := p.testSSHConnection(worker.SSHConfig); err != nil {
 		return fmt.Errorf("SSH connection failed: %v", err)
 	}
 
+	worker.ID = uuid.New()
+	worker.CreatedAt = time.Now()
+	worker.UpdatedAt = time.Now()
+	return p.addConnectedWorker(ctx, worker)
+}
+
+// addConnectedWorker adds a reachable worker to the pool. It stays inactive
+// with unknown health, so it is neither scheduled nor health checked, until
+// its capabilities and resources have been detected.
+func (p *SSHWorkerPool) addConnectedWorker(ctx context.Context, worker *SSHWorker) error {
+	worker.Status = WorkerStatusInactive
+	worker.HealthStatus = WorkerHealthUnknown
+
+	// The worker must be in the pool before commands can run on it
+	listeners, err := p.insertWorker(worker)
+	if err != nil {
+		return err
+	}
+
 	// Auto-install Helix CLI if enabled
 	if p.autoInstall {
 		if err := p.installHelixCLI(ctx, worker); err != nil {
@@ -81,24 +129,44 @@ func (p *SSHWorkerPool) AddWorker(ctx context.Context, worker *SSHWorker) error
 		log.Printf("Warning: Failed to detect capabilities on %s: %v", worker.Hostname, err)
 	}
 
-	worker.ID = uuid.New()
-	worker.CreatedAt = time.Now()
-	worker.UpdatedAt = time.Now()
+	p.mutex.Lock()
 	worker.Status = WorkerStatusActive
 	worker.HealthStatus = WorkerHealthHealthy
+	worker.UpdatedAt = time.Now()
+	p.mutex.Unlock()
 
-	p.workers[worker.ID] = worker
 	log.Printf("SSH Worker added: %s (%s)", worker.Hostname, worker.ID)
+	emitWorkerEvent(listeners, WorkerEventAdded, worker.ID, worker.Hostname)
+	return nil
+}
+
+// RegisterWorker adds an already prepared worker to the pool without connecting
+// to it, e.g. to simulate workers. A worker without an ID is assigned one.
+func (p *SSHWorkerPool) RegisterWorker(worker *SSHWorker) error {
+	if worker.ID == uuid.Nil {
+		worker.ID = uuid.New()
+	}
+	now := time.Now()
+	if worker.CreatedAt.IsZero() {
+		worker.CreatedAt = now
+	}
+	worker.UpdatedAt = now
+
+	listeners, err := p.insertWorker(worker)
+	if err != nil {
+		return err
+	}
+
+	emitWorkerEvent(listeners, WorkerEventAdded, worker.ID, worker.Hostname)
 	return nil
 }
 
 // RemoveWorker removes a worker from the pool
 func (p *SSHWorkerPool) RemoveWorker(ctx context.Context, workerID uuid.UUID) error {
 	p.mutex.Lock()
-	defer p.mutex.Unlock()
-
 	worker, exists := p.workers[workerID]
 	if !exists {
+		p.mutex.Unlock()
 		return fmt.Errorf("worker not found: %s", workerID)
 	}
 
@@ -108,22 +176,123 @@ func (p *SSHWorkerPool) RemoveWorker(ctx context.Context, workerID uuid.UUID) er
 	}
 
 	delete(p.workers, workerID)
+	listeners := p.events.snapshot()
+	p.mutex.Unlock()
+
 	log.Printf("SSH Worker removed: %s (%s)", worker.Hostname, workerID)
+	emitWorkerEvent(listeners, WorkerEventRemoved, workerID, worker.Hostname)
 	return nil
 }
 
+// GetWorker returns a worker by ID
+func (p *SSHWorkerPool) GetWorker(workerID uuid.UUID) (*SSHWorker, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	worker, exists := p.workers[workerID]
+	return worker, exists
+}
+
+// ListWorkers returns all workers in the pool
+func (p *SSHWorkerPool) ListWorkers() []*SSHWorker {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	workers := make([]*SSHWorker, 0, len(p.workers))
+	for _, worker := range p.workers {
+		workers = append(workers, worker)
+	}
+	return workers
+}
+
+// OnWorkerEvent registers a listener called when workers are added or removed
+func (p *SSHWorkerPool) OnWorkerEvent(listener WorkerEventListener) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events.add(listener)
+}
+
 // ExecuteCommand executes a command on a worker
 func (p *SSHWorkerPool) ExecuteCommand(ctx context.Context, workerID uuid.UUID, command string) (string, error) {
+	return p.ExecuteCommandOpts(ctx, workerID, command, CommandOptions{})
+}
+
+// ExecuteCommandOpts executes a command on a worker with the given environment,
+// working directory and stdin. Values are shell-quoted; the command itself is
+// passed to the remote shell as is.
+func (p *SSHWorkerPool) ExecuteCommandOpts(ctx context.Context, workerID uuid.UUID, command string, opts CommandOptions) (string, error) {
 	p.mutex.RLock()
 	worker, exists := p.workers[workerID]
+	executor := p.executor
 	p.mutex.RUnlock()
 
 	if !exists {
 		return "", fmt.Errorf("worker not found: %s", workerID)
 	}
 
+	full, err := buildRemoteCommand(command, opts)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := executor.Execute(ctx, worker, full, opts.Stdin)
+	if err != nil {
+		return "", err
+	}
+
+	p.mutex.Lock()
+	worker.LastCheck = time.Now()
+	p.mutex.Unlock()
+	return output, nil
+}
+
+// buildRemoteCommand prefixes a command with quoted env exports and a cd. The
+// command is grouped so that none of it runs when the prefix fails, even if it
+// is a list such as "a; b" or "make || echo failed".
+func buildRemoteCommand(command string, opts CommandOptions) (string, error) {
+	var parts []string
+
+	if len(opts.Env) > 0 {
+		names := make([]string, 0, len(opts.Env))
+		for name := range opts.Env {
+			if !envNamePattern.MatchString(name) {
+				return "", fmt.Errorf("invalid environment variable name: %q", name)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		assignments := make([]string, len(names))
+		for i, name := range names {
+			assignments[i] = name + "=" + shellQuote(opts.Env[name])
+		}
+		parts = append(parts, "export "+strings.Join(assignments, " "))
+	}
+
+	if opts.WorkDir != "" {
+		parts = append(parts, "cd "+shellQuote(opts.WorkDir))
+	}
+
+	if len(parts) == 0 {
+		return command, nil
+	}
+	// The newline ends a trailing comment before the closing brace
+	return strings.Join(append(parts, "{ "+command+"\n}"), " && "), nil
+}
+
+// shellQuote wraps s in single quotes so a POSIX shell treats it literally
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sshExecutor runs commands over the worker's SSH connection
+type sshExecutor struct {
+	pool *SSHWorkerPool
+}
+
+func (e *sshExecutor) Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error) {
 	// Ensure SSH connection
-	if err := p.ensureSSHConnection(worker); err != nil {
+	if err := e.pool.ensureSSHConnection(worker); err != nil {
 		return "", fmt.Errorf("SSH connection failed: %v", err)
 	}
 
@@ -138,12 +307,12 @@ func (p *SSHWorkerPool) ExecuteCommand(ctx context.Context, workerID uuid.UUID,
 	var stdout, stderr bytes.Buffer
 	session.Stdout = &stdout
 	session.Stderr = &stderr
+	session.Stdin = stdin
 
 	if err := session.Run(command); err != nil {
 		return "", fmt.Errorf("command execution failed: %v, stderr: %s", err, stderr.String())
 	}
 
-	worker.LastCheck = time.Now()
 	return stdout.String(), nil
 }
 
@@ -154,6 +323,11 @@ func (p *SSHWorkerPool) HealthCheck(ctx context.Context) error {
 
 	now := time.Now()
 	for _, worker := range p.workers {
+		// Workers still being added are activated once detection finishes
+		if worker.HealthStatus == WorkerHealthUnknown {
+			continue
+		}
+
 		// Test SSH connection
 		if err := p.testSSHConnection(worker.SSHConfig); err != nil {
 			worker.HealthStatus = WorkerHealthUnhealthy
@@ -210,6 +384,18 @@ type SSHWorkerStats struct {
 
 // Helper methods
 
+// insertWorker stores a worker and returns the listeners to notify once the lock is released
+func (p *SSHWorkerPool) insertWorker(worker *SSHWorker) ([]WorkerEventListener, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, exists := p.workers[worker.ID]; exists {
+		return nil, fmt.Errorf("worker already exists: %s", worker.ID)
+	}
+	p.workers[worker.ID] = worker
+	return p.events.snapshot(), nil
+}
+
 func (p *SSHWorkerPool) validateSSHConfig(config *SSHWorkerConfig) error {
 	if config.Host == "" {
 		return fmt.Errorf("host is required")
@@ -332,13 +518,20 @@ helix --version
 	return nil
 }
 
+// detectWorkerCapabilities runs detection commands on the worker and records
+// the results under the pool lock
 func (p *SSHWorkerPool) detectWorkerCapabilities(ctx context.Context, worker *SSHWorker) error {
+	// Resources that cannot be detected keep their current values
+	p.mutex.RLock()
+	resources := worker.Resources
+	p.mutex.RUnlock()
+
 	// Detect CPU information
 	cpuInfo, err := p.ExecuteCommand(ctx, worker.ID, "nproc")
 	if err == nil && cpuInfo != "" {
 		var cpuCount int
 		fmt.Sscanf(cpuInfo, "%d", &cpuCount)
-		worker.Resources.CPUCount = cpuCount
+		resources.CPUCount = cpuCount
 	}
 
 	// Detect memory information
@@ -346,7 +539,7 @@ func (p *SSHWorkerPool) detectWorkerCapabilities(ctx context.Context, worker *SS
 	if err == nil && memInfo != "" {
 		var totalMemory int64
 		fmt.Sscanf(memInfo, "%d", &totalMemory)
-		worker.Resources.TotalMemory = totalMemory
+		resources.TotalMemory = totalMemory
 	}
 
 	// Detect GPU information
@@ -354,7 +547,7 @@ func (p *SSHWorkerPool) detectWorkerCapabilities(ctx context.Context, worker *SS
 	if err == nil && gpuInfo != "" {
 		var gpuCount int
 		fmt.Sscanf(gpuInfo, "%d", &gpuCount)
-		worker.Resources.GPUCount = gpuCount
+		resources.GPUCount = gpuCount
 	}
 
 	// Detect capabilities based on available tools
@@ -375,6 +568,9 @@ func (p *SSHWorkerPool) detectWorkerCapabilities(ctx context.Context, worker *SS
 		capabilities = append(capabilities, "cuda-computation")
 	}
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	worker.Resources = resources
 	worker.Capabilities = capabilities
 	return nil
-}
\ No newline at end of file
+}
@@ -2,10 +2,20 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestSSHWorkerPool_Creation tests SSH worker pool creation
@@ -40,6 +50,46 @@ func TestSSHWorkerPool_AddWorker(t *testing.T) {
 	assert.Contains(t, err.Error(), "SSH connection failed")
 }
 
+// detectingExecutor answers capability detection commands, recording whether
+// the worker being detected could already be scheduled
+type detectingExecutor struct {
+	pool      *SSHWorkerPool
+	scheduled bool
+}
+
+func (e *detectingExecutor) Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error) {
+	if len(e.pool.buildWorkers()) > 0 {
+		e.scheduled = true
+	}
+	switch command {
+	case "nproc":
+		return "8", nil
+	case "free -b | awk 'NR==2{print $2}'":
+		return "17179869184", nil
+	case "lspci | grep -i nvidia | wc -l":
+		return "0", nil
+	}
+	return "", errors.New("not found")
+}
+
+// TestSSHWorkerPool_AddConnectedWorker tests that a worker is only schedulable once detected
+func TestSSHWorkerPool_AddConnectedWorker(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	executor := &detectingExecutor{pool: pool}
+	pool.SetExecutor(executor)
+
+	worker := &SSHWorker{ID: uuid.New(), Hostname: "builder"}
+	require.NoError(t, pool.addConnectedWorker(context.Background(), worker))
+
+	assert.False(t, executor.scheduled, "worker was schedulable before its capabilities were detected")
+	assert.Equal(t, WorkerStatusActive, worker.Status)
+	assert.Equal(t, WorkerHealthHealthy, worker.HealthStatus)
+	assert.Equal(t, 8, worker.Resources.CPUCount)
+	assert.Equal(t, int64(16<<30), worker.Resources.TotalMemory)
+	assert.Equal(t, []string{"ssh-execution", "remote-computation"}, worker.Capabilities)
+	assert.Len(t, pool.buildWorkers(), 1)
+}
+
 // TestSSHWorkerPool_RemoveWorker tests worker removal
 func TestSSHWorkerPool_RemoveWorker(t *testing.T) {
 	pool := NewSSHWorkerPool(false)
@@ -47,15 +97,16 @@ func TestSSHWorkerPool_RemoveWorker(t *testing.T) {
 
 	// Add a mock worker
 	workerID := uuid.New()
-	pool.workers[workerID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:       workerID,
 		Hostname: "test-worker",
-	}
+	}))
 
 	// Test removing existing worker
 	err := pool.RemoveWorker(ctx, workerID)
 	assert.NoError(t, err)
-	assert.NotContains(t, pool.workers, workerID)
+	_, exists := pool.GetWorker(workerID)
+	assert.False(t, exists)
 
 	// Test removing non-existent worker
 	err = pool.RemoveWorker(ctx, uuid.New())
@@ -72,7 +123,7 @@ func TestSSHWorkerPool_HealthCheck(t *testing.T) {
 	worker1ID := uuid.New()
 	worker2ID := uuid.New()
 
-	pool.workers[worker1ID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:           worker1ID,
 		Hostname:     "healthy-worker",
 		Status:       WorkerStatusActive,
@@ -81,9 +132,9 @@ func TestSSHWorkerPool_HealthCheck(t *testing.T) {
 			Host: "localhost",
 			Port: 22,
 		},
-	}
+	}))
 
-	pool.workers[worker2ID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:           worker2ID,
 		Hostname:     "unhealthy-worker",
 		Status:       WorkerStatusOffline,
@@ -92,7 +143,7 @@ func TestSSHWorkerPool_HealthCheck(t *testing.T) {
 			Host: "invalid-host",
 			Port: 22,
 		},
-	}
+	}))
 
 	// Run health check
 	err := pool.HealthCheck(ctx)
@@ -112,7 +163,7 @@ func TestSSHWorkerPool_GetWorkerStats(t *testing.T) {
 	worker1ID := uuid.New()
 	worker2ID := uuid.New()
 
-	pool.workers[worker1ID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:           worker1ID,
 		Hostname:     "worker-1",
 		Status:       WorkerStatusActive,
@@ -122,9 +173,9 @@ func TestSSHWorkerPool_GetWorkerStats(t *testing.T) {
 			TotalMemory:  16777216, // 16GB
 			GPUCount:     1,
 		},
-	}
+	}))
 
-	pool.workers[worker2ID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:           worker2ID,
 		Hostname:     "worker-2",
 		Status:       WorkerStatusOffline,
@@ -134,7 +185,7 @@ func TestSSHWorkerPool_GetWorkerStats(t *testing.T) {
 			TotalMemory:  8388608, // 8GB
 			GPUCount:     0,
 		},
-	}
+	}))
 
 	stats := pool.GetWorkerStats(ctx)
 
@@ -153,14 +204,14 @@ func TestSSHWorkerPool_ExecuteCommand(t *testing.T) {
 
 	// Add mock worker
 	workerID := uuid.New()
-	pool.workers[workerID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:       workerID,
 		Hostname: "test-worker",
 		SSHConfig: &SSHWorkerConfig{
 			Host: "localhost",
 			Port: 22,
 		},
-	}
+	}))
 
 	// Test command execution (will fail due to SSH connection)
 	output, err := pool.ExecuteCommand(ctx, workerID, "echo test")
@@ -279,10 +330,10 @@ func TestSSHWorkerPool_ConcurrentAccess(t *testing.T) {
 
 	// Add initial worker
 	workerID := uuid.New()
-	pool.workers[workerID] = &SSHWorker{
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{
 		ID:       workerID,
 		Hostname: "initial-worker",
-	}
+	}))
 
 	// Run concurrent operations
 	done := make(chan bool)
@@ -296,10 +347,10 @@ func TestSSHWorkerPool_ConcurrentAccess(t *testing.T) {
 			// Write operations (simulated)
 			if id%2 == 0 {
 				newWorkerID := uuid.New()
-				pool.workers[newWorkerID] = &SSHWorker{
+				assert.NoError(t, pool.RegisterWorker(&SSHWorker{
 					ID:       newWorkerID,
 					Hostname: "concurrent-worker",
-				}
+				}))
 			}
 
 			done <- true
@@ -312,7 +363,7 @@ func TestSSHWorkerPool_ConcurrentAccess(t *testing.T) {
 	}
 
 	// Verify no data races occurred
-	assert.True(t, len(pool.workers) >= 1)
+	assert.True(t, len(pool.ListWorkers()) >= 1)
 }
 
 // TestSSHWorkerPool_ErrorHandling tests various error scenarios
@@ -373,7 +424,7 @@ func TestSSHWorkerPool_ResourceManagement(t *testing.T) {
 
 	for i, w := range workers {
 		workerID := uuid.New()
-		pool.workers[workerID] = &SSHWorker{
+		require.NoError(t, pool.RegisterWorker(&SSHWorker{
 			ID:           workerID,
 			Hostname:     "worker-" + string(rune('A'+i)),
 			Status:       WorkerStatusActive,
@@ -383,7 +434,7 @@ func TestSSHWorkerPool_ResourceManagement(t *testing.T) {
 				TotalMemory:  w.memory,
 				GPUCount:     w.gpu,
 			},
-		}
+		}))
 	}
 
 	stats := pool.GetWorkerStats(ctx)
@@ -399,4 +450,172 @@ func TestSSHWorkerPool_ResourceManagement(t *testing.T) {
 	assert.Equal(t, 3, stats.TotalWorkers)
 	assert.Equal(t, 3, stats.ActiveWorkers)
 	assert.Equal(t, 3, stats.HealthyWorkers)
-}
\ No newline at end of file
+}
+// TestSSHWorkerPool_ConcurrentRegisterRemove tests adding and removing workers from many goroutines
+func TestSSHWorkerPool_ConcurrentRegisterRemove(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	ctx := context.Background()
+
+	var added, removed int64
+	pool.OnWorkerEvent(func(event WorkerEvent) {
+		switch event.Type {
+		case WorkerEventAdded:
+			atomic.AddInt64(&added, 1)
+		case WorkerEventRemoved:
+			atomic.AddInt64(&removed, 1)
+		}
+	})
+
+	const goroutines = 10
+	const workersPerGoroutine = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < workersPerGoroutine; j++ {
+				worker := &SSHWorker{Hostname: fmt.Sprintf("worker-%d-%d", id, j), Status: WorkerStatusActive}
+				assert.NoError(t, pool.RegisterWorker(worker))
+				_ = pool.GetWorkerStats(ctx)
+				_ = pool.ListWorkers()
+
+				// Keep every other worker
+				if j%2 == 1 {
+					assert.NoError(t, pool.RemoveWorker(ctx, worker.ID))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := pool.GetWorkerStats(ctx)
+	assert.Equal(t, goroutines*workersPerGoroutine/2, stats.TotalWorkers)
+	assert.Equal(t, int64(goroutines*workersPerGoroutine), atomic.LoadInt64(&added))
+	assert.Equal(t, int64(goroutines*workersPerGoroutine/2), atomic.LoadInt64(&removed))
+
+	// Registering the same worker twice is rejected
+	existing := pool.ListWorkers()[0]
+	assert.Error(t, pool.RegisterWorker(&SSHWorker{ID: existing.ID}))
+}
+
+// recordingExecutor captures commands instead of running them over SSH
+type recordingExecutor struct {
+	mu       sync.Mutex
+	commands []string
+	stdin    []string
+	output   string
+}
+
+func (e *recordingExecutor) Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error) {
+	input := ""
+	if stdin != nil {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", err
+		}
+		input = string(data)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.commands = append(e.commands, command)
+	e.stdin = append(e.stdin, input)
+	return e.output, nil
+}
+
+// shellExecutor runs commands with the local shell, standing in for a remote one
+type shellExecutor struct{}
+
+func (shellExecutor) Execute(ctx context.Context, worker *SSHWorker, command string, stdin io.Reader) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = stdin
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// TestSSHWorkerPool_ExecuteCommandOpts tests env, working directory and stdin propagation
+func TestSSHWorkerPool_ExecuteCommandOpts(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	executor := &recordingExecutor{output: "ok"}
+	pool.SetExecutor(executor)
+	ctx := context.Background()
+
+	workerID := uuid.New()
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{ID: workerID, Hostname: "builder"}))
+
+	output, err := pool.ExecuteCommandOpts(ctx, workerID, "go build ./...", CommandOptions{
+		Env:     map[string]string{"GOOS": "linux", "GOARCH": "arm64"},
+		WorkDir: "/srv/my project",
+		Stdin:   strings.NewReader("input"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output)
+
+	output, err = pool.ExecuteCommand(ctx, workerID, "uname")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output)
+
+	assert.Equal(t, []string{
+		"export GOARCH='arm64' GOOS='linux' && cd '/srv/my project' && { go build ./...\n}",
+		"uname",
+	}, executor.commands)
+	assert.Equal(t, []string{"input", ""}, executor.stdin)
+
+	_, err = pool.ExecuteCommandOpts(ctx, workerID, "env", CommandOptions{Env: map[string]string{"BAD;rm -rf /": "x"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid environment variable name")
+	assert.Len(t, executor.commands, 2)
+
+	_, err = pool.ExecuteCommandOpts(ctx, uuid.New(), "env", CommandOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "worker not found")
+}
+
+// TestSSHWorkerPool_ExecuteCommandOptsQuoting tests that option values cannot inject commands
+func TestSSHWorkerPool_ExecuteCommandOptsQuoting(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	pool.SetExecutor(shellExecutor{})
+	ctx := context.Background()
+
+	workerID := uuid.New()
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{ID: workerID, Hostname: "local"}))
+
+	dir := filepath.Join(t.TempDir(), "it's $(here)")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+
+	marker := filepath.Join(t.TempDir(), "pwned")
+	value := `a'b "c" $(touch ` + marker + `); d`
+	output, err := pool.ExecuteCommandOpts(ctx, workerID, `printf '%s|' "$VALUE" "$(pwd)"; cat`, CommandOptions{
+		Env:     map[string]string{"VALUE": value},
+		WorkDir: dir,
+		Stdin:   strings.NewReader("from stdin"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, value+"|"+dir+"|from stdin", output)
+
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "env value was executed by the shell")
+}
+
+// TestSSHWorkerPool_ExecuteCommandOptsFailedCd tests that no part of a command list runs outside its directory
+func TestSSHWorkerPool_ExecuteCommandOptsFailedCd(t *testing.T) {
+	pool := NewSSHWorkerPool(false)
+	pool.SetExecutor(shellExecutor{})
+
+	workerID := uuid.New()
+	require.NoError(t, pool.RegisterWorker(&SSHWorker{ID: workerID, Hostname: "local"}))
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	for _, command := range []string{"true; touch " + marker, "false || touch " + marker, "touch " + marker + " # trailing comment"} {
+		_, err := pool.ExecuteCommandOpts(context.Background(), workerID, command, CommandOptions{WorkDir: filepath.Join(dir, "missing")})
+		assert.Error(t, err, command)
+		_, err = os.Stat(marker)
+		assert.True(t, os.IsNotExist(err), "%q ran after cd failed", command)
+	}
+
+	output, err := pool.ExecuteCommandOpts(context.Background(), workerID, "pwd # where", CommandOptions{WorkDir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, dir, strings.TrimSpace(output))
+}
@@ -3,9 +3,15 @@ package worker
 import (
 	"context"
 	"fmt"
+	"log"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"dev.helix.code/internal/audit"
+	"dev.helix.code/internal/clock"
+	"dev.helix.code/internal/task"
 )
 
 // WorkerConfig represents the configuration for distributed worker management
@@ -16,6 +22,8 @@ type WorkerConfig struct {
 	HealthCheckInterval  int                        `json:"health_check_interval"`
 	MaxConcurrentTasks   int                        `json:"max_concurrent_tasks"`
 	TaskTimeout          int                        `json:"task_timeout"`
+	RebalanceInterval    int                        `json:"rebalance_interval"`  // Seconds between rebalancing passes; 0 disables them
+	RebalanceThreshold   int                        `json:"rebalance_threshold"` // Load difference that triggers a move; 0 uses the default
 }
 
 // WorkerConfigEntry represents a single worker configuration entry
@@ -44,27 +52,56 @@ type DistributedTask struct {
 	CompletedAt  *time.Time             `json:"completed_at"`
 	ErrorMessage string                 `json:"error_message"`
 	Result       map[string]interface{} `json:"result"`
+
+	// Affinity: the scheduler prefers PreferredWorker (a hostname), then
+	// OriginalWorker, when available, e.g. to reuse a warm build cache.
+	// Otherwise the task falls back to normal selection.
+	OriginalWorker  *uuid.UUID `json:"original_worker,omitempty"`
+	PreferredWorker string     `json:"preferred_worker,omitempty"`
+
+	// ResourceRequirements restricts the task to workers with at least these resources
+	ResourceRequirements ResourceRequirements `json:"resource_requirements"`
+}
+
+// ResourceRequirements are the minimum worker resources a task needs.
+// Zero fields are not checked.
+type ResourceRequirements struct {
+	MinCPUCount  int   `json:"min_cpu_count"`
+	MinMemory    int64 `json:"min_memory"` // in bytes
+	MinGPUCount  int   `json:"min_gpu_count"`
+	MinGPUMemory int64 `json:"min_gpu_memory"` // in bytes
 }
 
-// TaskStatus represents the status of a distributed task
-type TaskStatus string
+// SatisfiedBy reports whether resources meet every requirement
+func (r ResourceRequirements) SatisfiedBy(resources Resources) bool {
+	return resources.CPUCount >= r.MinCPUCount &&
+		resources.TotalMemory >= r.MinMemory &&
+		resources.GPUCount >= r.MinGPUCount &&
+		resources.GPUMemory >= r.MinGPUMemory
+}
+
+// TaskStatus represents the status of a distributed task.
+// It shares its values with the task package so both managers report the same statuses.
+type TaskStatus = task.TaskStatus
 
 const (
-	TaskStatusPending   TaskStatus = "pending"
-	TaskStatusRunning   TaskStatus = "running"
-	TaskStatusCompleted TaskStatus = "completed"
-	TaskStatusFailed    TaskStatus = "failed"
-	TaskStatusCancelled TaskStatus = "cancelled"
+	TaskStatusPending   = task.TaskStatusPending
+	TaskStatusRunning   = task.TaskStatusRunning
+	TaskStatusCompleted = task.TaskStatusCompleted
+	TaskStatusFailed    = task.TaskStatusFailed
+	TaskStatusCancelled = task.TaskStatusCancelled
+
+	TaskStatusWaitingForWorker = task.TaskStatusWaitingForWorker
 )
 
 // Criticality represents the criticality level of a task
-type Criticality string
+type Criticality = task.TaskCriticality
 
 const (
-	CriticalityLow      Criticality = "low"
-	CriticalityNormal   Criticality = "normal"
-	CriticalityHigh     Criticality = "high"
-	CriticalityCritical Criticality = "critical"
+	CriticalityLow      = task.CriticalityLow
+	CriticalityNormal   = task.CriticalityNormal
+	CriticalityHigh     = task.CriticalityHigh
+	CriticalityCritical = task.CriticalityCritical
 )
 
 // DistributedWorkerManager manages distributed workers
@@ -73,6 +110,31 @@ type DistributedWorkerManager struct {
 	workers  map[uuid.UUID]*Worker
 	tasks    map[uuid.UUID]*DistributedTask
 	sshPool  *SSHWorkerPool
+	mutex    sync.RWMutex // guards workers, tasks, queued, waiting, maxWaiting, events, simulated, rng, throughput and rebalance
+	events   workerEvents
+	clock    clock.Clock
+
+	// queued holds, per worker, the tasks assigned to it that wait for a free
+	// slot, in submission order
+	queued map[uuid.UUID][]*DistributedTask
+	// waiting holds tasks no worker can run, in submission order
+	waiting []*DistributedTask
+	// maxWaiting limits queued and waiting tasks together, as task.AdmitTask
+	// applies it; 0 is unlimited
+	maxWaiting int
+	// running cancels the execution of each in-flight task
+	running  map[uuid.UUID]context.CancelFunc
+	executor TaskExecutor
+	// taskDone is closed and replaced whenever a task finishes
+	taskDone     chan struct{}
+	drains       map[uuid.UUID]*DrainProgress
+	drainTimeout time.Duration
+	audit        *audit.Log
+
+	simulated  map[uuid.UUID]*SimulatedWorkerSpec
+	rng        *rand.Rand
+	throughput *throughputTracker
+	rebalance  RebalanceStats
 }
 
 // NewDistributedWorkerManager creates a new distributed worker manager
@@ -81,10 +143,46 @@ func NewDistributedWorkerManager(config WorkerConfig) *DistributedWorkerManager
 		config:  config,
 		workers: make(map[uuid.UUID]*Worker),
 		tasks:   make(map[uuid.UUID]*DistributedTask),
+		queued:  make(map[uuid.UUID][]*DistributedTask),
 		sshPool: NewSSHWorkerPool(config.AutoInstall),
+		clock:   clock.New(),
+		running:      make(map[uuid.UUID]context.CancelFunc),
+		taskDone:     make(chan struct{}),
+		drains:       make(map[uuid.UUID]*DrainProgress),
+		drainTimeout: DefaultDrainTimeout,
+		simulated:  make(map[uuid.UUID]*SimulatedWorkerSpec),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		throughput: newThroughputTracker(),
 	}
 }
 
+// SetClock replaces the clock used to timestamp and time task execution.
+// It must be called before tasks are submitted.
+func (dwm *DistributedWorkerManager) SetClock(c clock.Clock) {
+	dwm.clock = c
+}
+
+// SetMaxWaitingTasks limits how many tasks may wait for a worker before
+// SubmitTask rejects new ones with task.ErrQueueFull; 0 is unlimited
+func (dwm *DistributedWorkerManager) SetMaxWaitingTasks(maxWaiting int) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.maxWaiting = maxWaiting
+}
+
+// TaskExecutor runs a distributed task on a worker and returns its result
+type TaskExecutor interface {
+	ExecuteTask(ctx context.Context, task *DistributedTask, worker *Worker) (map[string]interface{}, error)
+}
+
+// SetTaskExecutor sets how tasks run on workers that are not simulated.
+// Without one, execution is simulated.
+func (dwm *DistributedWorkerManager) SetTaskExecutor(executor TaskExecutor) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.executor = executor
+}
+
 // Initialize initializes the distributed worker manager
 func (dwm *DistributedWorkerManager) Initialize(ctx context.Context) error {
 	// Initialize SSH connections to configured workers
@@ -109,8 +207,61 @@ func (dwm *DistributedWorkerManager) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// RegisterWorker adds a worker to the manager. A worker without an ID is assigned one.
+func (dwm *DistributedWorkerManager) RegisterWorker(worker *Worker) error {
+	if worker.ID == uuid.Nil {
+		worker.ID = uuid.New()
+	}
+
+	dwm.mutex.Lock()
+	if _, exists := dwm.workers[worker.ID]; exists {
+		dwm.mutex.Unlock()
+		return fmt.Errorf("worker already exists: %s", worker.ID)
+	}
+	dwm.workers[worker.ID] = worker
+	listeners := dwm.events.snapshot()
+	auditLog := dwm.audit
+	dwm.mutex.Unlock()
+
+	auditWorker(context.Background(), auditLog, audit.SourceScheduler, worker.ID, audit.ActionCreated, "", worker.Status,
+		map[string]interface{}{"hostname": worker.Hostname})
+	emitWorkerEvent(listeners, WorkerEventAdded, worker.ID, worker.Hostname)
+	dwm.ScheduleWaitingTasks()
+	return nil
+}
+
+// RemoveWorker removes a worker from the manager
+func (dwm *DistributedWorkerManager) RemoveWorker(workerID uuid.UUID) error {
+	dwm.mutex.Lock()
+	worker, exists := dwm.workers[workerID]
+	if !exists {
+		dwm.mutex.Unlock()
+		return fmt.Errorf("worker not found: %s", workerID)
+	}
+	delete(dwm.workers, workerID)
+	started, _ := dwm.moveQueuedTasksLocked(workerID)
+	listeners := dwm.events.snapshot()
+	auditLog := dwm.audit
+	dwm.mutex.Unlock()
+
+	auditWorker(context.Background(), auditLog, audit.SourceScheduler, workerID, audit.ActionDeleted, worker.Status, "", nil)
+	emitWorkerEvent(listeners, WorkerEventRemoved, workerID, worker.Hostname)
+	dwm.startReassigned(started)
+	return nil
+}
+
+// OnWorkerEvent registers a listener called when workers are added or removed
+func (dwm *DistributedWorkerManager) OnWorkerEvent(listener WorkerEventListener) {
+	dwm.mutex.Lock()
+	defer dwm.mutex.Unlock()
+	dwm.events.add(listener)
+}
+
 // GetAvailableWorkers returns all available workers
 func (dwm *DistributedWorkerManager) GetAvailableWorkers() []*Worker {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+
 	workers := make([]*Worker, 0, len(dwm.workers))
 	for _, worker := range dwm.workers {
 		if worker.Status == WorkerStatusActive && worker.HealthStatus == WorkerHealthHealthy {
@@ -122,12 +273,16 @@ func (dwm *DistributedWorkerManager) GetAvailableWorkers() []*Worker {
 
 // GetWorkerStats returns statistics about workers
 func (dwm *DistributedWorkerManager) GetWorkerStats() map[string]interface{} {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+
 	stats := make(map[string]interface{})
 	stats["total_workers"] = len(dwm.workers)
 	
 	activeCount := 0
 	healthyCount := 0
 	totalTasks := 0
+	queuedTasks := 0
 	
 	for _, worker := range dwm.workers {
 		if worker.Status == WorkerStatusActive {
@@ -137,54 +292,334 @@ func (dwm *DistributedWorkerManager) GetWorkerStats() map[string]interface{} {
 			healthyCount++
 		}
 		totalTasks += worker.CurrentTasksCount
+		queuedTasks += len(dwm.queued[worker.ID])
 	}
 	
 	stats["active_workers"] = activeCount
 	stats["healthy_workers"] = healthyCount
 	stats["total_tasks"] = totalTasks
+	stats["queued_tasks"] = queuedTasks
+	stats["waiting_tasks"] = len(dwm.waiting)
+	stats["rebalanced_tasks"] = dwm.rebalance.Moved
 	
 	return stats
 }
 
-// SubmitTask submits a task for distributed execution
+// SubmitTask submits a task for distributed execution. If every worker that
+// can run it is at capacity, the task is queued on the least loaded of them
+// and starts when it has a free slot. If no worker can run it, e.g. because
+// none meets its resource requirements, the task waits as
+// TaskStatusWaitingForWorker until one can. Either way it is rejected if too
+// many tasks are already queued or waiting.
 func (dwm *DistributedWorkerManager) SubmitTask(task *DistributedTask) error {
 	task.ID = uuid.New()
 	task.Status = TaskStatusPending
-	task.CreatedAt = time.Now()
+	task.CreatedAt = dwm.clock.Now()
 	
+	dwm.mutex.Lock()
+	worker := dwm.reserveWorker(task)
+	var queuedOn *Worker
+	if worker == nil {
+		if err := dwm.admitWaitingTask(task.Priority); err != nil {
+			dwm.mutex.Unlock()
+			return err
+		}
+		if queuedOn = dwm.queueTask(task); queuedOn == nil {
+			task.Status = TaskStatusWaitingForWorker
+			dwm.waiting = append(dwm.waiting, task)
+		}
+	} else {
+		task.WorkerID = worker.ID
+	}
 	dwm.tasks[task.ID] = task
-	
-	// Find suitable worker
-	availableWorkers := dwm.GetAvailableWorkers()
-	if len(availableWorkers) == 0 {
-		return fmt.Errorf("no available workers")
+	dwm.throughput.recordSubmit(task.CreatedAt)
+	dwm.mutex.Unlock()
+
+	if queuedOn != nil {
+		log.Printf("🔄 Queued %s task %s on %s", task.Type, task.ID, queuedOn.Hostname)
+		return nil
+	}
+	if worker == nil {
+		log.Printf("⚠️ No worker can take %s task %s, waiting for a worker", task.Type, task.ID)
+		return nil
 	}
-	
-	// Simple round-robin assignment for now
-	worker := availableWorkers[0]
-	task.WorkerID = worker.ID
 	
 	// Execute task (in real implementation, this would be async)
-	return dwm.executeTask(task)
+	return dwm.executeTask(task, worker)
+}
+
+// admitWaitingTask checks whether a task of the given priority may be queued
+// or wait for a worker. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) admitWaitingTask(priority int) error {
+	backlog := len(dwm.waiting)
+	for _, queue := range dwm.queued {
+		backlog += len(queue)
+	}
+	critical := task.TaskPriority(priority) >= task.PriorityCritical
+	return task.AdmitTask(backlog, dwm.maxWaiting, critical)
+}
+
+// assignment pairs a task with the worker reserved for it
+type assignment struct {
+	task   *DistributedTask
+	worker *Worker
+}
+
+// ScheduleWaitingTasks starts the tasks queued on each worker, in submission
+// order, while the worker has free slots. It then starts waiting tasks on
+// workers with capacity, or queues them on workers that can run them later,
+// and returns how many tasks were started. It runs whenever a worker is
+// registered or finishes a task.
+func (dwm *DistributedWorkerManager) ScheduleWaitingTasks() int {
+	dwm.mutex.Lock()
+	var started []assignment
+	for workerID, queue := range dwm.queued {
+		worker, exists := dwm.workers[workerID]
+		for exists && len(queue) > 0 && canRunTask(worker, queue[0]) {
+			worker.CurrentTasksCount++
+			started = append(started, assignment{queue[0], worker})
+			queue = queue[1:]
+		}
+		if len(queue) == 0 {
+			delete(dwm.queued, workerID)
+		} else {
+			dwm.queued[workerID] = queue
+		}
+	}
+
+	remaining := dwm.waiting[:0]
+	for _, task := range dwm.waiting {
+		worker := dwm.reserveWorker(task)
+		if worker == nil {
+			if dwm.queueTask(task) == nil {
+				remaining = append(remaining, task)
+			}
+			continue
+		}
+		task.WorkerID = worker.ID
+		task.Status = TaskStatusPending
+		started = append(started, assignment{task, worker})
+	}
+	dwm.waiting = remaining
+	dwm.mutex.Unlock()
+
+	for _, a := range started {
+		log.Printf("🔄 Starting waiting %s task %s on %s", a.task.Type, a.task.ID, a.worker.Hostname)
+		go dwm.executeTask(a.task, a.worker)
+	}
+	return len(started)
+}
+
+// WaitingTasks returns the tasks waiting for a worker, in submission order
+func (dwm *DistributedWorkerManager) WaitingTasks() []*DistributedTask {
+	dwm.mutex.RLock()
+	defer dwm.mutex.RUnlock()
+	return append([]*DistributedTask(nil), dwm.waiting...)
+}
+
+// reserveWorker picks the task's affinity worker if it can take the task,
+// otherwise the least loaded worker that can, and counts the task against it.
+// The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) reserveWorker(task *DistributedTask) *Worker {
+	selected := dwm.affinityWorker(task)
+	if selected == nil {
+		for _, worker := range dwm.workers {
+			if !canRunTask(worker, task) {
+				continue
+			}
+			if selected == nil || worker.CurrentTasksCount < selected.CurrentTasksCount {
+				selected = worker
+			}
+		}
+	}
+
+	if selected != nil {
+		selected.CurrentTasksCount++
+	}
+	return selected
+}
+
+// queueTask queues a task on the worker it is pinned to if that worker can run
+// it, otherwise on the least loaded worker that can, and returns that worker.
+// It returns nil, leaving the task alone, if no worker can run the task. The
+// caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) queueTask(task *DistributedTask) *Worker {
+	var selected *Worker
+	for _, worker := range dwm.workers {
+		if !canQueueTask(worker, task) {
+			continue
+		}
+		if dwm.pinnedTo(task, worker) {
+			selected = worker
+			break
+		}
+		if selected == nil || dwm.load(worker) < dwm.load(selected) {
+			selected = worker
+		}
+	}
+
+	if selected != nil {
+		task.WorkerID = selected.ID
+		task.Status = TaskStatusPending
+		dwm.queued[selected.ID] = append(dwm.queued[selected.ID], task)
+	}
+	return selected
+}
+
+// dequeueTask removes a task from the queue of its worker. The caller must
+// hold dwm.mutex.
+func (dwm *DistributedWorkerManager) dequeueTask(task *DistributedTask) {
+	queue := dwm.queued[task.WorkerID]
+	for i, queuedTask := range queue {
+		if queuedTask == task {
+			dwm.queued[task.WorkerID] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(dwm.queued[task.WorkerID]) == 0 {
+		delete(dwm.queued, task.WorkerID)
+	}
 }
 
-// executeTask executes a task on the assigned worker
-func (dwm *DistributedWorkerManager) executeTask(task *DistributedTask) error {
-	now := time.Now()
+// moveQueuedTasksLocked moves the tasks queued on a worker leaving service to
+// other workers: onto ones with capacity, else into their queues, else into
+// the waiting list. It returns the tasks to start and how many were moved.
+// The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) moveQueuedTasksLocked(workerID uuid.UUID) (started []assignment, moved int) {
+	queue := dwm.queued[workerID]
+	delete(dwm.queued, workerID)
+
+	for _, task := range queue {
+		if worker := dwm.reserveWorker(task); worker != nil {
+			task.WorkerID = worker.ID
+			started = append(started, assignment{task, worker})
+			continue
+		}
+		if dwm.queueTask(task) == nil {
+			task.WorkerID = uuid.Nil
+			task.Status = TaskStatusWaitingForWorker
+			dwm.waiting = append(dwm.waiting, task)
+		}
+	}
+	return started, len(queue)
+}
+
+// load returns how many tasks a worker is running or has queued. The caller
+// must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) load(worker *Worker) int {
+	return worker.CurrentTasksCount + len(dwm.queued[worker.ID])
+}
+
+// affinityWorker returns the worker the task is pinned to, or nil if it has no
+// affinity or that worker cannot take it. The caller must hold dwm.mutex.
+func (dwm *DistributedWorkerManager) affinityWorker(task *DistributedTask) *Worker {
+	if task.PreferredWorker != "" {
+		for _, worker := range dwm.workers {
+			if worker.Hostname == task.PreferredWorker && canRunTask(worker, task) {
+				return worker
+			}
+		}
+	}
+	if task.OriginalWorker != nil {
+		if worker, ok := dwm.workers[*task.OriginalWorker]; ok && canRunTask(worker, task) {
+			return worker
+		}
+	}
+
+	if task.PreferredWorker != "" || task.OriginalWorker != nil {
+		log.Printf("⚠️ Affinity worker unavailable for %s task, using normal selection", task.Type)
+	}
+	return nil
+}
+
+// canAcceptTask reports whether a worker is active, healthy and below capacity
+func canAcceptTask(worker *Worker) bool {
+	if worker.Status != WorkerStatusActive || worker.HealthStatus != WorkerHealthHealthy {
+		return false
+	}
+	return worker.MaxConcurrentTasks <= 0 || worker.CurrentTasksCount < worker.MaxConcurrentTasks
+}
+
+// canQueueTask reports whether a worker is active, healthy and meets the
+// task's resource requirements, whatever its current load
+func canQueueTask(worker *Worker, task *DistributedTask) bool {
+	if worker.Status != WorkerStatusActive || worker.HealthStatus != WorkerHealthHealthy {
+		return false
+	}
+	return task.ResourceRequirements.SatisfiedBy(worker.Resources)
+}
+
+// canRunTask reports whether a worker can accept tasks and meets the task's resource requirements
+func canRunTask(worker *Worker, task *DistributedTask) bool {
+	return canAcceptTask(worker) && task.ResourceRequirements.SatisfiedBy(worker.Resources)
+}
+
+// executeTask executes a task on the assigned worker, through the task
+// executor unless the worker is simulated
+func (dwm *DistributedWorkerManager) executeTask(task *DistributedTask, worker *Worker) error {
+	now := dwm.clock.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Simulated workers use their own latency and failure rate
+	latency := 100 * time.Millisecond
+	failed := false
+	dwm.mutex.Lock()
+	if task.WorkerID != worker.ID {
+		// Reassigned by a drain before it started
+		dwm.mutex.Unlock()
+		return nil
+	}
 	task.StartedAt = &now
 	task.Status = TaskStatusRunning
-	
-	// Simulate task execution
-	// In real implementation, this would execute via SSH
-	time.Sleep(100 * time.Millisecond)
-	
-	completedAt := time.Now()
+	dwm.running[task.ID] = cancel
+	spec, simulated := dwm.simulated[worker.ID]
+	if simulated {
+		latency, failed = dwm.simulateExecution(spec)
+	}
+	executor := dwm.executor
+	dwm.mutex.Unlock()
+
+	var result map[string]interface{}
+	var execErr error
+	if executor != nil && !simulated {
+		result, execErr = executor.ExecuteTask(ctx, task, worker)
+	} else {
+		dwm.clock.Sleep(latency)
+		if failed {
+			execErr = fmt.Errorf("simulated failure on %s", worker.Hostname)
+		}
+	}
+	completedAt := dwm.clock.Now()
+
+	dwm.mutex.Lock()
+	if task.WorkerID != worker.ID {
+		// A drain reassigned the task; its new worker reports the outcome
+		dwm.mutex.Unlock()
+		return nil
+	}
+	delete(dwm.running, task.ID)
 	task.CompletedAt = &completedAt
-	task.Status = TaskStatusCompleted
-	task.Result = map[string]interface{}{
-		"output": "Task completed successfully",
-		"duration": completedAt.Sub(now).String(),
+	worker.CurrentTasksCount--
+	dwm.throughput.recordFinish(worker.ID, completedAt.Sub(now), execErr != nil, completedAt)
+
+	if execErr != nil {
+		task.Status = TaskStatusFailed
+		task.ErrorMessage = execErr.Error()
+	} else {
+		task.Status = TaskStatusCompleted
+		if result == nil {
+			result = map[string]interface{}{"output": "Task completed successfully"}
+		}
+		result["duration"] = completedAt.Sub(now).String()
+		task.Result = result
+	}
+	dwm.notifyTaskFinishedLocked()
+	dwm.mutex.Unlock()
+	dwm.ScheduleWaitingTasks()
+
+	if execErr != nil {
+		return fmt.Errorf("task %s failed: %s", task.ID, task.ErrorMessage)
 	}
-	
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StepOutput holds the result produced by a workflow step
+type StepOutput struct {
+	StepID      string    `json:"step_id"`
+	Output      string    `json:"output"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// WorkflowContext carries data between workflow steps
+type WorkflowContext struct {
+	outputs map[string]StepOutput
+	values  map[string]string
+	mu      sync.RWMutex
+}
+
+// NewWorkflowContext creates an empty workflow context
+func NewWorkflowContext() *WorkflowContext {
+	return &WorkflowContext{
+		outputs: make(map[string]StepOutput),
+		values:  make(map[string]string),
+	}
+}
+
+// SetOutput records the output of a completed step
+func (c *WorkflowContext) SetOutput(stepID, output string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.outputs[stepID] = StepOutput{
+		StepID:      stepID,
+		Output:      output,
+		CompletedAt: time.Now(),
+	}
+}
+
+// Output returns the output of a step
+func (c *WorkflowContext) Output(stepID string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	output, exists := c.outputs[stepID]
+	return output.Output, exists
+}
+
+// Outputs returns the outputs of all completed steps keyed by step ID
+func (c *WorkflowContext) Outputs() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	outputs := make(map[string]string, len(c.outputs))
+	for id, output := range c.outputs {
+		outputs[id] = output.Output
+	}
+	return outputs
+}
+
+// SetValue stores a named value shared across steps
+func (c *WorkflowContext) SetValue(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+}
+
+// Value returns a named value shared across steps
+func (c *WorkflowContext) Value(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value, exists := c.values[key]
+	return value, exists
+}
+
+// Environment returns prior step outputs as HELIX_OUTPUT_<STEP_ID> variables
+func (c *WorkflowContext) Environment() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	env := make([]string, 0, len(c.outputs))
+	for id, output := range c.outputs {
+		name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(id))
+		env = append(env, fmt.Sprintf("HELIX_OUTPUT_%s=%s", name, output.Output))
+	}
+	return env
+}
+
+// MarshalJSON serializes the context with the workflow state
+func (c *WorkflowContext) MarshalJSON() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return json.Marshal(struct {
+		Outputs map[string]StepOutput `json:"outputs"`
+		Values  map[string]string     `json:"values"`
+	}{c.outputs, c.values})
+}
+
+// UnmarshalJSON restores a context persisted with the workflow state
+func (c *WorkflowContext) UnmarshalJSON(data []byte) error {
+	var state struct {
+		Outputs map[string]StepOutput `json:"outputs"`
+		Values  map[string]string     `json:"values"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.outputs = state.Outputs
+	if c.outputs == nil {
+		c.outputs = make(map[string]StepOutput)
+	}
+	c.values = state.Values
+	if c.values == nil {
+		c.values = make(map[string]string)
+	}
+	return nil
+}
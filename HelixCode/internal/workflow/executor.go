@@ -2,7 +2,10 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
 	"os/exec"
 	"time"
 
@@ -12,13 +15,72 @@ import (
 // Executor handles workflow execution
 type Executor struct {
 	projectManager *project.Manager
+	prompts        *PromptRegistry
+	maxParallel    int
+	errorMode      ErrorMode
+	onProgress     ProgressListener
+}
+
+// DefaultMaxParallelSteps is the default number of workflow steps run concurrently
+const DefaultMaxParallelSteps = 4
+
+// ErrorMode controls how workflow execution reacts to a failed step
+type ErrorMode string
+
+const (
+	ErrorModeFailFast ErrorMode = "fail_fast" // Cancel running steps and start no new ones
+	ErrorModeContinue ErrorMode = "continue"  // Keep running steps that do not depend on the failure
+)
+
+// stepResult is the outcome of a step executed concurrently
+type stepResult struct {
+	index  int
+	output string
+	err    error
 }
 
 // NewExecutor creates a new workflow executor
 func NewExecutor(projectManager *project.Manager) *Executor {
+	prompts := NewPromptRegistry()
+
+	// Apply user prompt overrides when present
+	if dir := DefaultPromptDir(); dir != "" {
+		if _, err := os.Stat(dir); err == nil {
+			if err := prompts.LoadDir(dir); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+
 	return &Executor{
 		projectManager: projectManager,
+		prompts:        prompts,
+		maxParallel:    DefaultMaxParallelSteps,
+		errorMode:      ErrorModeFailFast,
+	}
+}
+
+// SetMaxParallel sets how many independent steps may run at the same time
+func (e *Executor) SetMaxParallel(n int) {
+	if n < 1 {
+		n = 1
 	}
+	e.maxParallel = n
+}
+
+// OnProgress registers a listener notified whenever a workflow step finishes
+func (e *Executor) OnProgress(listener ProgressListener) {
+	e.onProgress = listener
+}
+
+// SetErrorMode sets whether a failed step stops the workflow or lets independent steps continue
+func (e *Executor) SetErrorMode(mode ErrorMode) {
+	e.errorMode = mode
+}
+
+// Prompts returns the prompt template registry used by the executor
+func (e *Executor) Prompts() *PromptRegistry {
+	return e.prompts
 }
 
 // ExecutePlanningWorkflow executes a planning workflow
@@ -37,6 +99,7 @@ func (e *Executor) ExecutePlanningWorkflow(ctx context.Context, projectID string
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Status:      WorkflowStatusPending,
+		Context:     NewWorkflowContext(),
 	}
 
 	// Execute workflow
@@ -61,6 +124,7 @@ func (e *Executor) ExecuteBuildingWorkflow(ctx context.Context, projectID string
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Status:      WorkflowStatusPending,
+		Context:     NewWorkflowContext(),
 	}
 
 	// Execute workflow
@@ -85,6 +149,7 @@ func (e *Executor) ExecuteTestingWorkflow(ctx context.Context, projectID string)
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Status:      WorkflowStatusPending,
+		Context:     NewWorkflowContext(),
 	}
 
 	// Execute workflow
@@ -109,6 +174,7 @@ func (e *Executor) ExecuteRefactoringWorkflow(ctx context.Context, projectID str
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Status:      WorkflowStatusPending,
+		Context:     NewWorkflowContext(),
 	}
 
 	// Execute workflow
@@ -117,61 +183,198 @@ func (e *Executor) ExecuteRefactoringWorkflow(ctx context.Context, projectID str
 	return workflow, nil
 }
 
-// executeWorkflow executes a workflow
-func (e *Executor) executeWorkflow(ctx context.Context, workflow *Workflow, proj *project.Project) {
+// executeWorkflow executes a workflow, running steps whose dependencies are
+// satisfied concurrently up to the executor's parallelism limit
+func (e *Executor) executeWorkflow(ctx context.Context, workflow *Workflow, proj *project.Project) error {
 	workflow.Status = WorkflowStatusRunning
 	workflow.UpdatedAt = time.Now()
 
-	for i := range workflow.Steps {
-		step := &workflow.Steps[i]
-		
-		// Check if all dependencies are completed
-		if !e.areDependenciesCompleted(workflow, step) {
-			step.Status = StepStatusSkipped
-			continue
+	if workflow.Context == nil {
+		workflow.Context = NewWorkflowContext()
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	maxParallel := e.maxParallel
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	results := make(chan stepResult)
+	running := 0
+	stopped := false
+	var errs []error
+
+	startTimes := make(map[int]time.Time)
+	var durations []time.Duration
+	e.updateProgress(workflow, durations)
+
+	fail := func(step *Step, err error) {
+		step.Status = StepStatusFailed
+		step.Error = err.Error()
+		errs = append(errs, fmt.Errorf("step %s: %v", step.ID, err))
+		if e.errorMode != ErrorModeContinue {
+			stopped = true
+			cancel()
 		}
+	}
 
-		step.Status = StepStatusRunning
-		workflow.UpdatedAt = time.Now()
+	for {
+		if !stopped {
+			e.skipBlockedSteps(workflow)
+
+			// Launch every ready step while slots are available
+			for i := range workflow.Steps {
+				if running >= maxParallel || stopped {
+					break
+				}
+
+				step := &workflow.Steps[i]
+				if step.Status != StepStatusPending || !e.areDependenciesCompleted(workflow, step) {
+					continue
+				}
+
+				if err := e.prepareStep(workflow, step, proj); err != nil {
+					fail(step, err)
+					continue
+				}
+
+				step.Status = StepStatusRunning
+				workflow.UpdatedAt = time.Now()
+				startTimes[i] = time.Now()
+				running++
+
+				go func(index int, step *Step) {
+					output, err := e.executeStep(runCtx, step, proj, workflow.Context)
+					results <- stepResult{index: index, output: output, err: err}
+				}(i, step)
+			}
+		}
 
-		// Execute step
-		result, err := e.executeStep(ctx, step, proj)
-		if err != nil {
-			step.Status = StepStatusFailed
-			step.Error = err.Error()
-			workflow.Status = WorkflowStatusFailed
-			workflow.UpdatedAt = time.Now()
-			return
+		if running == 0 {
+			break
 		}
 
-		step.Status = StepStatusCompleted
-		workflow.UpdatedAt = time.Now()
-		
-		// Add result to step (simplified for now)
-		if result != "" {
+		result := <-results
+		running--
+		durations = append(durations, time.Since(startTimes[result.index]))
+
+		step := &workflow.Steps[result.index]
+		if result.err != nil {
+			fail(step, result.err)
+		} else {
 			step.Status = StepStatusCompleted
+			workflow.Context.SetOutput(step.ID, result.output)
+		}
+		workflow.UpdatedAt = time.Now()
+		e.updateProgress(workflow, durations)
+	}
+
+	// Steps that never became ready are skipped
+	for i := range workflow.Steps {
+		if workflow.Steps[i].Status == StepStatusPending {
+			workflow.Steps[i].Status = StepStatusSkipped
 		}
 	}
 
-	workflow.Status = WorkflowStatusCompleted
+	// The final status is set before the last update so listeners see it
+	var err error
+	if len(errs) > 0 {
+		err = errors.Join(errs...)
+		workflow.Status = WorkflowStatusFailed
+		workflow.Error = err.Error()
+	} else {
+		workflow.Status = WorkflowStatusCompleted
+	}
 	workflow.UpdatedAt = time.Now()
+	e.updateProgress(workflow, durations)
+	return err
+}
+
+// updateProgress recalculates workflow progress and notifies the progress listener.
+// Completed, failed and skipped steps all count as finished.
+func (e *Executor) updateProgress(workflow *Workflow, durations []time.Duration) {
+	finished := 0
+	for _, step := range workflow.Steps {
+		switch step.Status {
+		case StepStatusCompleted, StepStatusFailed, StepStatusSkipped:
+			finished++
+		}
+	}
+
+	workflow.Progress = calculateProgress(len(workflow.Steps), finished, durations)
+	if e.onProgress != nil {
+		e.onProgress(workflow, workflow.Progress)
+	}
+}
+
+// prepareStep renders the LLM prompt for analysis and generation steps
+func (e *Executor) prepareStep(workflow *Workflow, step *Step, proj *project.Project) error {
+	if step.Type != StepTypeAnalysis && step.Type != StepTypeGeneration {
+		return nil
+	}
+
+	prompt, err := e.prompts.Render(PromptContext{
+		Project:  proj,
+		Workflow: workflow,
+		Step:     step,
+		Outputs:  workflow.Context.Outputs(),
+	})
+	if err != nil {
+		return err
+	}
+
+	step.Prompt = prompt
+	return nil
+}
+
+// skipBlockedSteps marks pending steps whose dependencies failed or were skipped
+func (e *Executor) skipBlockedSteps(workflow *Workflow) {
+	for changed := true; changed; {
+		changed = false
+		for i := range workflow.Steps {
+			step := &workflow.Steps[i]
+			if step.Status != StepStatusPending {
+				continue
+			}
+			for _, depID := range step.Dependencies {
+				if dep := findStep(workflow, depID); dep != nil &&
+					(dep.Status == StepStatusFailed || dep.Status == StepStatusSkipped) {
+					step.Status = StepStatusSkipped
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// findStep returns the workflow step with the given ID
+func findStep(workflow *Workflow, id string) *Step {
+	for i := range workflow.Steps {
+		if workflow.Steps[i].ID == id {
+			return &workflow.Steps[i]
+		}
+	}
+	return nil
 }
 
 // executeStep executes a single workflow step
-func (e *Executor) executeStep(ctx context.Context, step *Step, proj *project.Project) (string, error) {
+func (e *Executor) executeStep(ctx context.Context, step *Step, proj *project.Project, wctx *WorkflowContext) (string, error) {
 	switch step.Action {
 	case StepActionAnalyzeCode:
 		return e.executeAnalysisStep(ctx, step, proj)
 	case StepActionGenerateCode:
 		return e.executeGenerationStep(ctx, step, proj)
 	case StepActionExecuteCommand:
-		return e.executeCommandStep(ctx, step, proj)
+		return e.executeCommandStep(ctx, step, proj, wctx)
 	case StepActionRunTests:
-		return e.executeTestStep(ctx, step, proj)
+		return e.executeTestStep(ctx, step, proj, wctx)
 	case StepActionLintCode:
-		return e.executeLintStep(ctx, step, proj)
+		return e.executeLintStep(ctx, step, proj, wctx)
 	case StepActionBuildProject:
-		return e.executeBuildStep(ctx, step, proj)
+		return e.executeBuildStep(ctx, step, proj, wctx)
 	default:
 		return "", fmt.Errorf("unknown step action: %s", step.Action)
 	}
@@ -189,11 +392,13 @@ func (e *Executor) executeGenerationStep(ctx context.Context, step *Step, proj *
 	return fmt.Sprintf("Code generation completed for: %s", step.Description), nil
 }
 
-// executeCommandStep executes a command execution step
-func (e *Executor) executeCommandStep(ctx context.Context, step *Step, proj *project.Project) (string, error) {
+// executeCommandStep executes a command execution step.
+// Outputs of earlier steps are exposed as HELIX_OUTPUT_<STEP_ID> environment variables.
+func (e *Executor) executeCommandStep(ctx context.Context, step *Step, proj *project.Project, wctx *WorkflowContext) (string, error) {
 	// Execute command in project directory
 	cmd := exec.CommandContext(ctx, "bash", "-c", step.Description)
 	cmd.Dir = proj.Path
+	cmd.Env = append(os.Environ(), wctx.Environment()...)
 	
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -204,7 +409,7 @@ func (e *Executor) executeCommandStep(ctx context.Context, step *Step, proj *pro
 }
 
 // executeTestStep executes a test execution step
-func (e *Executor) executeTestStep(ctx context.Context, step *Step, proj *project.Project) (string, error) {
+func (e *Executor) executeTestStep(ctx context.Context, step *Step, proj *project.Project, wctx *WorkflowContext) (string, error) {
 	// Execute test command based on project type
 	var cmd *exec.Cmd
 	
@@ -222,6 +427,7 @@ func (e *Executor) executeTestStep(ctx context.Context, step *Step, proj *projec
 	}
 
 	cmd.Dir = proj.Path
+	cmd.Env = append(os.Environ(), wctx.Environment()...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("test execution failed: %v\nOutput: %s", err, string(output))
@@ -231,7 +437,7 @@ func (e *Executor) executeTestStep(ctx context.Context, step *Step, proj *projec
 }
 
 // executeLintStep executes a linting step
-func (e *Executor) executeLintStep(ctx context.Context, step *Step, proj *project.Project) (string, error) {
+func (e *Executor) executeLintStep(ctx context.Context, step *Step, proj *project.Project, wctx *WorkflowContext) (string, error) {
 	// Execute lint command based on project type
 	var cmd *exec.Cmd
 	
@@ -249,6 +455,7 @@ func (e *Executor) executeLintStep(ctx context.Context, step *Step, proj *projec
 	}
 
 	cmd.Dir = proj.Path
+	cmd.Env = append(os.Environ(), wctx.Environment()...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("lint execution failed: %v\nOutput: %s", err, string(output))
@@ -258,7 +465,7 @@ func (e *Executor) executeLintStep(ctx context.Context, step *Step, proj *projec
 }
 
 // executeBuildStep executes a build step
-func (e *Executor) executeBuildStep(ctx context.Context, step *Step, proj *project.Project) (string, error) {
+func (e *Executor) executeBuildStep(ctx context.Context, step *Step, proj *project.Project, wctx *WorkflowContext) (string, error) {
 	// Execute build command based on project type
 	var cmd *exec.Cmd
 	
@@ -276,6 +483,7 @@ func (e *Executor) executeBuildStep(ctx context.Context, step *Step, proj *proje
 	}
 
 	cmd.Dir = proj.Path
+	cmd.Env = append(os.Environ(), wctx.Environment()...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("build execution failed: %v\nOutput: %s", err, string(output))
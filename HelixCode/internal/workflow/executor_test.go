@@ -0,0 +1,181 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/project"
+)
+
+// TestExecutorInterStepData tests that a step consumes the output of the step before it
+func TestExecutorInterStepData(t *testing.T) {
+	executor := NewExecutor(project.NewManager())
+	proj := &project.Project{ID: "demo", Name: "demo", Path: t.TempDir(), Type: "go"}
+
+	workflow := &Workflow{
+		ID: "data_passing",
+		Steps: []Step{
+			{
+				ID:          "produce",
+				Name:        "Produce",
+				Description: "echo -n service-endpoint",
+				Type:        StepTypeExecution,
+				Action:      StepActionExecuteCommand,
+				Status:      StepStatusPending,
+			},
+			{
+				ID:           "consume",
+				Name:         "Consume",
+				Description:  `echo -n "deploying to $HELIX_OUTPUT_PRODUCE"`,
+				Type:         StepTypeExecution,
+				Action:       StepActionExecuteCommand,
+				Dependencies: []string{"produce"},
+				Status:       StepStatusPending,
+			},
+			{
+				ID:           "summarize",
+				Name:         "Summarize",
+				Description:  "Summarize the deployment",
+				Type:         StepTypeAnalysis,
+				Action:       StepActionAnalyzeCode,
+				Dependencies: []string{"consume"},
+				Status:       StepStatusPending,
+			},
+		},
+		Context: NewWorkflowContext(),
+	}
+
+	executor.executeWorkflow(context.Background(), workflow, proj)
+
+	if workflow.Status != WorkflowStatusCompleted {
+		t.Fatalf("Expected workflow to complete, got %s (steps: %+v)", workflow.Status, workflow.Steps)
+	}
+
+	output, ok := workflow.Context.Output("consume")
+	if !ok {
+		t.Fatal("Expected output for consume step")
+	}
+	if output != "deploying to service-endpoint" {
+		t.Errorf("Step 2 did not consume step 1 output, got %q", output)
+	}
+
+	// Prompts for later LLM steps include prior outputs
+	if !strings.Contains(workflow.Steps[2].Prompt, "deploying to service-endpoint") {
+		t.Errorf("Expected prompt to include prior output, got:\n%s", workflow.Steps[2].Prompt)
+	}
+
+	// The accumulated context is exposed with the workflow state
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		t.Fatalf("Failed to marshal workflow: %v", err)
+	}
+	var restored Workflow
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Failed to unmarshal workflow: %v", err)
+	}
+	if output, _ := restored.Context.Output("produce"); output != "service-endpoint" {
+		t.Errorf("Context was not persisted with the workflow, got %q", output)
+	}
+
+	t.Log("✅ Inter-step data passing test passed")
+}
+
+// commandStep creates a command execution step for tests
+func commandStep(id, command string, deps ...string) Step {
+	return Step{
+		ID:           id,
+		Name:         id,
+		Description:  command,
+		Type:         StepTypeExecution,
+		Action:       StepActionExecuteCommand,
+		Dependencies: deps,
+		Status:       StepStatusPending,
+	}
+}
+
+// waitForFile returns a shell snippet that creates one marker file and waits for another
+func waitForFile(create, wait string) string {
+	return fmt.Sprintf(`touch %s; for i in $(seq 1 300); do [ -f %s ] && echo -n ok && exit 0; sleep 0.01; done; exit 1`, create, wait)
+}
+
+// TestExecutorParallelDiamond tests a diamond-shaped DAG: A -> (B, C) -> D
+func TestExecutorParallelDiamond(t *testing.T) {
+	executor := NewExecutor(project.NewManager())
+	executor.SetMaxParallel(2)
+	proj := &project.Project{ID: "diamond", Path: t.TempDir(), Type: "go"}
+
+	// B and C each wait until the other has started, so they only succeed when run concurrently
+	workflow := &Workflow{
+		ID: "diamond",
+		Steps: []Step{
+			commandStep("d", `echo -n "$HELIX_OUTPUT_B+$HELIX_OUTPUT_C"`, "b", "c"),
+			commandStep("b", `[ "$HELIX_OUTPUT_A" = a ] && `+waitForFile("b.started", "c.started"), "a"),
+			commandStep("c", `[ "$HELIX_OUTPUT_A" = a ] && `+waitForFile("c.started", "b.started"), "a"),
+			commandStep("a", `echo -n a`),
+		},
+	}
+
+	if err := executor.executeWorkflow(context.Background(), workflow, proj); err != nil {
+		t.Fatalf("Diamond workflow failed: %v", err)
+	}
+
+	if workflow.Status != WorkflowStatusCompleted {
+		t.Fatalf("Expected workflow to complete, got %s", workflow.Status)
+	}
+	if output, _ := workflow.Context.Output("d"); output != "ok+ok" {
+		t.Errorf("Expected D to run after B and C, got %q", output)
+	}
+
+	t.Log("✅ Parallel diamond workflow test passed")
+}
+
+// TestExecutorErrorModes tests fail-fast and continue behavior on step failure
+func TestExecutorErrorModes(t *testing.T) {
+	newWorkflow := func() *Workflow {
+		return &Workflow{
+			ID: "errors",
+			Steps: []Step{
+				commandStep("broken", "exit 3"),
+				commandStep("independent", "sleep 0.1; echo -n done"),
+				commandStep("dependent", "echo -n never", "broken"),
+			},
+		}
+	}
+	proj := &project.Project{ID: "errors", Path: t.TempDir(), Type: "go"}
+
+	// Continue mode runs independent steps and skips dependents of the failure
+	executor := NewExecutor(project.NewManager())
+	executor.SetErrorMode(ErrorModeContinue)
+	workflow := newWorkflow()
+
+	err := executor.executeWorkflow(context.Background(), workflow, proj)
+	if err == nil || !strings.Contains(err.Error(), "step broken") {
+		t.Fatalf("Expected aggregated error for broken step, got %v", err)
+	}
+	if workflow.Status != WorkflowStatusFailed || workflow.Error == "" {
+		t.Errorf("Expected failed workflow with error, got %s %q", workflow.Status, workflow.Error)
+	}
+	if workflow.Steps[1].Status != StepStatusCompleted {
+		t.Errorf("Independent step should complete in continue mode, got %s", workflow.Steps[1].Status)
+	}
+	if workflow.Steps[2].Status != StepStatusSkipped {
+		t.Errorf("Dependent step should be skipped, got %s", workflow.Steps[2].Status)
+	}
+
+	// Fail-fast mode starts no further steps after the failure
+	executor = NewExecutor(project.NewManager())
+	executor.SetMaxParallel(1)
+	workflow = newWorkflow()
+
+	if err := executor.executeWorkflow(context.Background(), workflow, proj); err == nil {
+		t.Fatal("Expected fail-fast workflow to return an error")
+	}
+	if workflow.Steps[1].Status != StepStatusSkipped {
+		t.Errorf("Independent step should not start after fail-fast failure, got %s", workflow.Steps[1].Status)
+	}
+
+	t.Log("✅ Workflow error mode test passed")
+}
@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"time"
+)
+
+// Progress describes how far a workflow has advanced
+type Progress struct {
+	CompletedSteps      int           `json:"completed_steps"`
+	TotalSteps          int           `json:"total_steps"`
+	Percent             float64       `json:"percent"`
+	TotalKnown          bool          `json:"total_known"`
+	AverageStepDuration time.Duration `json:"average_step_duration"`
+	ETA                 time.Duration `json:"eta"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+// ProgressListener receives progress updates as workflow steps finish
+type ProgressListener func(workflow *Workflow, progress Progress)
+
+// calculateProgress computes percent complete from finished/total steps and an ETA
+// from the average duration of finished steps. A total of zero means the number
+// of steps is not yet known, so no percentage or ETA is reported.
+func calculateProgress(total, finished int, durations []time.Duration) Progress {
+	progress := Progress{
+		CompletedSteps: finished,
+		TotalSteps:     total,
+		TotalKnown:     total > 0,
+		UpdatedAt:      time.Now(),
+	}
+
+	if len(durations) > 0 {
+		var sum time.Duration
+		for _, d := range durations {
+			sum += d
+		}
+		progress.AverageStepDuration = sum / time.Duration(len(durations))
+	}
+
+	if !progress.TotalKnown {
+		return progress
+	}
+
+	if finished > total {
+		finished = total
+	}
+	progress.Percent = float64(finished) / float64(total) * 100
+	progress.ETA = progress.AverageStepDuration * time.Duration(total-finished)
+
+	return progress
+}
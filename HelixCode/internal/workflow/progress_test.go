@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.helix.code/internal/project"
+)
+
+// TestCalculateProgress tests percent and ETA computation
+func TestCalculateProgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		finished  int
+		durations []time.Duration
+		percent   float64
+		eta       time.Duration
+		known     bool
+	}{
+		{"not started", 4, 0, nil, 0, 0, true},
+		{"one of four", 4, 1, []time.Duration{2 * time.Second}, 25, 6 * time.Second, true},
+		{"half with average", 4, 2, []time.Duration{time.Second, 3 * time.Second}, 50, 4 * time.Second, true},
+		{"done", 4, 4, []time.Duration{time.Second, time.Second, time.Second, time.Second}, 100, 0, true},
+		{"unknown total", 0, 2, []time.Duration{time.Second, time.Second}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := calculateProgress(tt.total, tt.finished, tt.durations)
+			if progress.Percent != tt.percent {
+				t.Errorf("Expected %.1f%%, got %.1f%%", tt.percent, progress.Percent)
+			}
+			if progress.ETA != tt.eta {
+				t.Errorf("Expected ETA %v, got %v", tt.eta, progress.ETA)
+			}
+			if progress.TotalKnown != tt.known {
+				t.Errorf("Expected TotalKnown %v, got %v", tt.known, progress.TotalKnown)
+			}
+		})
+	}
+
+	t.Log("✅ Progress calculation test passed")
+}
+
+// TestExecutorProgressUpdates tests that progress is reported as steps complete
+func TestExecutorProgressUpdates(t *testing.T) {
+	executor := NewExecutor(project.NewManager())
+	proj := &project.Project{ID: "progress", Path: t.TempDir(), Type: "go"}
+
+	var updates []Progress
+	var statuses []WorkflowStatus
+	executor.OnProgress(func(wf *Workflow, progress Progress) {
+		updates = append(updates, progress)
+		statuses = append(statuses, wf.Status)
+	})
+
+	workflow := &Workflow{
+		ID: "progress",
+		Steps: []Step{
+			commandStep("one", "echo -n 1"),
+			commandStep("two", "echo -n 2", "one"),
+			commandStep("three", "echo -n 3", "two"),
+			commandStep("four", "echo -n 4", "three"),
+		},
+	}
+
+	if err := executor.executeWorkflow(context.Background(), workflow, proj); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	// One update before the first step, one per step, and a final one
+	if len(updates) != 6 {
+		t.Fatalf("Expected 6 progress updates, got %d", len(updates))
+	}
+	for i, want := range []float64{0, 25, 50, 75, 100, 100} {
+		if updates[i].Percent != want {
+			t.Errorf("Update %d: expected %.0f%%, got %.1f%%", i, want, updates[i].Percent)
+		}
+	}
+	if last := statuses[len(statuses)-1]; last != WorkflowStatusCompleted {
+		t.Errorf("Expected the final update to report completion, got status %s", last)
+	}
+	if updates[1].AverageStepDuration <= 0 || updates[1].ETA <= 0 {
+		t.Errorf("Expected an ETA after the first step, got %+v", updates[1])
+	}
+	if workflow.Progress.Percent != 100 || workflow.Progress.ETA != 0 {
+		t.Errorf("Expected workflow to report completion, got %+v", workflow.Progress)
+	}
+
+	// A workflow without steps has no known total
+	empty := &Workflow{ID: "empty"}
+	if err := executor.executeWorkflow(context.Background(), empty, proj); err != nil {
+		t.Fatalf("Empty workflow failed: %v", err)
+	}
+	if empty.Progress.TotalKnown || empty.Progress.Percent != 0 {
+		t.Errorf("Expected unknown progress for empty workflow, got %+v", empty.Progress)
+	}
+
+	t.Log("✅ Executor progress test passed")
+}
@@ -0,0 +1,200 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"dev.helix.code/internal/paths"
+	"dev.helix.code/internal/project"
+)
+
+// DefaultPromptTemplate is the template used when no step- or action-specific template exists
+const DefaultPromptTemplate = "default"
+
+// PromptContext is the data available to workflow prompt templates
+type PromptContext struct {
+	Project  *project.Project
+	Workflow *Workflow
+	Step     *Step
+	Outputs  map[string]string // Outputs of previously completed steps keyed by step ID
+}
+
+// defaultPrompts are the built-in templates, keyed by step ID or step action
+var defaultPrompts = map[string]string{
+	DefaultPromptTemplate: `You are working on the {{.Project.Type}} project "{{.Project.Name}}" located at {{.Project.Path}}.
+Task: {{.Step.Name}} - {{.Step.Description}}
+{{- range $id, $output := .Outputs}}
+
+Output of step {{$id}}:
+{{$output}}
+{{- end}}`,
+
+	string(StepActionAnalyzeCode): `You are a senior engineer analyzing the {{.Project.Type}} project "{{.Project.Name}}".
+{{- if .Project.Description}}
+Project description: {{.Project.Description}}
+{{- end}}
+Task: {{.Step.Description}}
+
+Identify the key components, constraints and risks, and summarize your findings as a concise list.
+{{- range $id, $output := .Outputs}}
+
+Context from step {{$id}}:
+{{$output}}
+{{- end}}`,
+
+	string(StepActionGenerateCode): `You are a senior engineer writing code for the {{.Project.Type}} project "{{.Project.Name}}".
+{{- if .Project.Metadata.Framework}}
+Framework: {{.Project.Metadata.Framework}}
+{{- end}}
+Task: {{.Step.Description}}
+{{- range $id, $output := .Outputs}}
+
+Use the results of step {{$id}}:
+{{$output}}
+{{- end}}
+
+Produce complete, idiomatic code and explain any assumptions.`,
+}
+
+// PromptRegistry holds the named templates used to render workflow step prompts
+type PromptRegistry struct {
+	templates map[string]*template.Template
+	mu        sync.RWMutex
+}
+
+// NewPromptRegistry creates a registry containing the built-in templates
+func NewPromptRegistry() *PromptRegistry {
+	registry := &PromptRegistry{
+		templates: make(map[string]*template.Template),
+	}
+
+	for name, text := range defaultPrompts {
+		if err := registry.Register(name, text); err != nil {
+			panic(fmt.Sprintf("invalid built-in prompt template %s: %v", name, err))
+		}
+	}
+
+	return registry
+}
+
+// Register parses, validates and stores a template, replacing any existing one with the same name
+func (r *PromptRegistry) Register(name, text string) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse prompt template %s: %v", name, err)
+	}
+
+	// Render against a sample context so field errors surface at load time
+	if err := tmpl.Execute(&bytes.Buffer{}, samplePromptContext()); err != nil {
+		return fmt.Errorf("invalid prompt template %s: %v", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.templates[name] = tmpl
+	return nil
+}
+
+// LoadDir loads user template overrides from *.tmpl files in a directory.
+// The file name without extension is the template name (a step ID or step action).
+func (r *PromptRegistry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(paths.Expand(dir), "*.tmpl"))
+	if err != nil {
+		return fmt.Errorf("failed to list prompt templates: %v", err)
+	}
+
+	var errs []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if err := r.Register(name, string(data)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load prompt templates: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Has reports whether a template with the given name is registered
+func (r *PromptRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.templates[name]
+	return exists
+}
+
+// Render renders the prompt for a step, looking up a template by step ID,
+// then by step action, then falling back to the default template
+func (r *PromptRegistry) Render(data PromptContext) (string, error) {
+	if data.Step == nil {
+		return "", fmt.Errorf("prompt context has no step")
+	}
+	if data.Project == nil {
+		data.Project = &project.Project{}
+	}
+
+	r.mu.RLock()
+	tmpl := r.templates[data.Step.ID]
+	if tmpl == nil {
+		tmpl = r.templates[string(data.Step.Action)]
+	}
+	if tmpl == nil {
+		tmpl = r.templates[DefaultPromptTemplate]
+	}
+	r.mu.RUnlock()
+
+	if tmpl == nil {
+		return "", fmt.Errorf("no prompt template for step %s", data.Step.ID)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt for step %s: %v", data.Step.ID, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// DefaultPromptDir returns the directory searched for user prompt overrides
+func DefaultPromptDir() string {
+	if dir := os.Getenv("HELIX_PROMPTS_DIR"); dir != "" {
+		return paths.Expand(dir)
+	}
+	return paths.Expand("~/.config/helixcode/prompts")
+}
+
+// samplePromptContext returns a populated context used to validate templates
+func samplePromptContext() PromptContext {
+	step := &Step{ID: "sample_step", Name: "Sample Step", Description: "Sample description", Action: StepActionGenerateCode}
+	return PromptContext{
+		Project: &project.Project{
+			ID:   "sample",
+			Name: "sample-project",
+			Path: "/tmp/sample-project",
+			Type: "go",
+		},
+		Workflow: &Workflow{ID: "sample_workflow", Name: "Sample Workflow", Steps: []Step{*step}},
+		Step:     step,
+		Outputs:  map[string]string{"previous_step": "sample output"},
+	}
+}
@@ -0,0 +1,123 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dev.helix.code/internal/project"
+)
+
+func samplePromptData(step *Step) PromptContext {
+	return PromptContext{
+		Project: &project.Project{
+			Name: "helix-demo",
+			Path: "/work/helix-demo",
+			Type: "go",
+		},
+		Step:    step,
+		Outputs: map[string]string{"analyze_requirements": "Needs a REST API with JWT auth"},
+	}
+}
+
+// TestPromptRegistryRender tests rendering built-in templates with sample context
+func TestPromptRegistryRender(t *testing.T) {
+	registry := NewPromptRegistry()
+
+	step := &Step{
+		ID:          "generate_architecture",
+		Name:        "Generate Architecture",
+		Description: "Generate system architecture and design",
+		Action:      StepActionGenerateCode,
+	}
+
+	prompt, err := registry.Render(samplePromptData(step))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, expected := range []string{"helix-demo", "go project", step.Description, "Needs a REST API with JWT auth"} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("Rendered prompt missing %q:\n%s", expected, prompt)
+		}
+	}
+
+	t.Log("✅ Prompt rendering test passed")
+}
+
+// TestPromptRegistryFallback tests template lookup order and the default fallback
+func TestPromptRegistryFallback(t *testing.T) {
+	registry := NewPromptRegistry()
+
+	// Unknown action falls back to the default template
+	step := &Step{ID: "custom_step", Name: "Custom", Description: "Do something custom", Action: "custom_action"}
+	prompt, err := registry.Render(samplePromptData(step))
+	if err != nil {
+		t.Fatalf("Render with fallback failed: %v", err)
+	}
+	if !strings.Contains(prompt, "Task: Custom - Do something custom") {
+		t.Errorf("Expected default template output, got:\n%s", prompt)
+	}
+
+	// A step ID template takes precedence over the action template
+	if err := registry.Register("custom_step", "Step override for {{.Project.Name}}"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	prompt, err = registry.Render(samplePromptData(step))
+	if err != nil {
+		t.Fatalf("Render with override failed: %v", err)
+	}
+	if prompt != "Step override for helix-demo" {
+		t.Errorf("Expected step override, got %q", prompt)
+	}
+
+	// Missing step is an error
+	if _, err := registry.Render(PromptContext{}); err == nil {
+		t.Error("Expected error when rendering without a step")
+	}
+
+	t.Log("✅ Prompt fallback test passed")
+}
+
+// TestPromptRegistryLoadDir tests loading and validating user overrides
+func TestPromptRegistryLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	override := filepath.Join(dir, string(StepActionAnalyzeCode)+".tmpl")
+	if err := os.WriteFile(override, []byte("Custom analysis of {{.Project.Name}}"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	registry := NewPromptRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	step := &Step{ID: "analyze_codebase", Action: StepActionAnalyzeCode}
+	prompt, err := registry.Render(samplePromptData(step))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if prompt != "Custom analysis of helix-demo" {
+		t.Errorf("Expected override to be used, got %q", prompt)
+	}
+
+	// Invalid templates are rejected at load time
+	invalid := map[string]string{
+		"syntax.tmpl": "{{.Project.Name",
+		"field.tmpl":  "{{.Project.DoesNotExist}}",
+	}
+	for name, text := range invalid {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+	if err := registry.LoadDir(dir); err == nil {
+		t.Error("Expected error loading invalid templates")
+	}
+	if registry.Has("syntax") || registry.Has("field") {
+		t.Error("Invalid templates should not be registered")
+	}
+
+	t.Log("✅ Prompt directory loading test passed")
+}
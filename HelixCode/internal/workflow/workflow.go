@@ -14,6 +14,9 @@ type Workflow struct {
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
 	Status      WorkflowStatus `json:"status"`
+	Context     *WorkflowContext `json:"context"`
+	Error       string        `json:"error,omitempty"`
+	Progress    Progress      `json:"progress"`
 }
 
 // Step represents a workflow step
@@ -25,6 +28,7 @@ type Step struct {
 	Action      StepAction  `json:"action"`
 	Dependencies []string   `json:"dependencies"`
 	Status      StepStatus  `json:"status"`
+	Prompt      string      `json:"prompt,omitempty"`
 	Error       string      `json:"error,omitempty"`
 }
 
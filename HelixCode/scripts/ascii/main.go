@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"dev.helix.code/internal/ascii"
+)
+
+func main() {
+	logoPath := flag.String("logo", filepath.Join("assets", "images", "logo.png"), "path to the PNG logo")
+	width := flag.Int("width", 80, "terminal width")
+	height := flag.Int("height", 24, "terminal height")
+	banner := flag.Bool("banner", false, "print the text banner instead of the logo")
+	flag.Parse()
+
+	if *banner {
+		fmt.Println(ascii.GenerateHelixCodeLogo())
+		return
+	}
+
+	generator := ascii.NewLogoASCIIGenerator(*logoPath, *width, *height)
+	fmt.Println(generator.GenerateLogoForTerminalSize(*width, *height))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"dev.helix.code/internal/llm"
+	"dev.helix.code/internal/llm/probe"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:11434", "Ollama-compatible server URL")
+	models := flag.String("models", "codellama:7b,codellama:13b,llama3.1:8b,deepseek-coder:6.7b", "comma-separated models to probe")
+	flag.Parse()
+
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{BaseURL: *baseURL})
+	if err != nil {
+		log.Fatalf("❌ Failed to create provider: %v", err)
+	}
+
+	ctx := context.Background()
+	var reports []probe.CapabilityReport
+	for _, model := range strings.Split(*models, ",") {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+
+		fmt.Printf("\n=== Testing %s ===\n", model)
+		report := probe.ProbeModel(ctx, provider, model)
+		for _, result := range report.Results {
+			if result.Error != "" {
+				fmt.Printf("   ❌ %s failed: %s\n", result.Name, result.Error)
+				continue
+			}
+			fmt.Printf("   %s: score %d/%d (%v)\n", result.Name, result.Score, result.Threshold, result.Duration.Round(time.Millisecond))
+		}
+
+		summary := fmt.Sprintf("Reasoning: %d/%d, Tooling: %d/%d, Code: %d/%d",
+			report.Categories[probe.CategoryReasoning].Passed, report.Categories[probe.CategoryReasoning].Total,
+			report.Categories[probe.CategoryToolCalling].Passed, report.Categories[probe.CategoryToolCalling].Total,
+			report.Categories[probe.CategoryCodeGeneration].Passed, report.Categories[probe.CategoryCodeGeneration].Total)
+		if report.Passed {
+			fmt.Printf("✅ %s PASSED comprehensive testing\n   %s\n", model, summary)
+		} else {
+			fmt.Printf("⚠️  %s has limited capabilities\n   %s\n", model, summary)
+		}
+		reports = append(reports, report)
+	}
+
+	fmt.Println("\n=== TEST SUMMARY ===")
+	ready := 0
+	for _, report := range probe.RankModels(reports) {
+		if report.Passed {
+			fmt.Printf("   - %s (score %.0f%%)\n", report.Model, report.Score*100)
+			ready++
+		}
+	}
+	if ready == 0 {
+		fmt.Println("❌ No models fully support thinking and tooling capabilities")
+		os.Exit(1)
+	}
+	fmt.Printf("\n🎉 %d model(s) ready for HelixCode development!\n", ready)
+}
@@ -10,8 +10,8 @@ import (
 	"time"
 
 	"dev.helix.code/internal/llm"
-	"dev.helix.code/internal/task"
-	"dev.helix.code/internal/workflow"
+	"dev.helix.code/internal/paths"
+	"dev.helix.code/internal/worker"
 )
 
 func main() {
@@ -169,20 +169,25 @@ func testDistributedBuild() {
 	}
 
 	// Execute distributed build
-	buildResult, err := executeDistributedBuild(project, workers)
+	buildResult, err := executeDistributedBuild(context.Background(), project, workers)
 	if err != nil {
 		log.Printf("❌ Distributed build failed: %v", err)
 		return
 	}
 
-	// Verify all services built successfully
+	// Report every service, keeping the ones that built
 	for service, result := range buildResult.Services {
 		if !result.Success {
-			log.Printf("❌ Service %s build failed: %v", service, result.Error)
-			return
+			log.Printf("❌ Service %s build failed on %s: %s", service, result.Hostname, result.Error)
 		}
 	}
 
+	if buildResult.Status != worker.BuildStatusSucceeded {
+		log.Printf("⚠️  Distributed build %s: %d of %d services failed",
+			buildResult.Status, len(buildResult.FailedServices()), len(buildResult.Services))
+		return
+	}
+
 	fmt.Printf("✅ Distributed build completed successfully across %d workers!\n", len(workers))
 }
 
@@ -206,7 +211,10 @@ func testRealModelIntegration() {
 func testLocalModelInference() {
 	fmt.Println("Testing local model inference with LLama.cpp...")
 
-	provider, err := llm.NewLLamaCPPProvider("/path/to/coding/model.gguf")
+	provider, err := llm.NewLlamaCPPProvider(llm.LlamaConfig{
+		ModelPath:   "/path/to/coding/model.gguf",
+		ContextSize: 4096,
+	})
 	if err != nil {
 		log.Printf("❌ LLama.cpp provider setup failed: %v", err)
 		return
@@ -214,15 +222,15 @@ func testLocalModelInference() {
 
 	// Test code generation
 	ctx := context.Background()
-	request := llm.GenerationRequest{
-		Prompt: `Write a Go function that:
+	request := &llm.LLMRequest{
+		Messages: []llm.Message{{Role: "user", Content: `Write a Go function that:
 1. Takes a slice of integers
 2. Returns the sum of all even numbers
 3. Uses efficient iteration
 4. Includes proper error handling
 
-Please provide the complete function with tests.`,
-		MaxTokens: 500,
+Please provide the complete function with tests.`}},
+		MaxTokens:   500,
 		Temperature: 0.7,
 	}
 
@@ -233,7 +241,7 @@ Please provide the complete function with tests.`,
 	}
 
 	// Validate generated code
-	if !isValidGoCode(response.Text) {
+	if !isValidGoCode(response.Content) {
 		log.Printf("❌ Generated code is not valid Go")
 		return
 	}
@@ -244,11 +252,15 @@ Please provide the complete function with tests.`,
 func testOllamaIntegration() {
 	fmt.Println("Testing Ollama integration...")
 
-	provider, err := llm.NewOllamaProvider("http://localhost:11434")
+	ollama, err := llm.NewOllamaProvider(llm.OllamaConfig{
+		BaseURL: "http://localhost:11434",
+		Timeout: 120,
+	})
 	if err != nil {
 		log.Printf("❌ Ollama provider setup failed: %v", err)
 		return
 	}
+	provider := llm.NewToolCallingProvider(ollama)
 
 	// Test tool calling
 	ctx := context.Background()
@@ -256,25 +268,31 @@ func testOllamaIntegration() {
 		Prompt: "Create a new directory structure for a Go project and initialize it with a basic module.",
 		Tools: []llm.Tool{
 			{
-				Name:        "create_directory",
-				Description: "Create a new directory",
-				Schema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type": "string",
+				Type: "function",
+				Function: llm.FunctionDefinition{
+					Name:        "create_directory",
+					Description: "Create a new directory",
+					Parameters: map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"path": map[string]interface{}{
+								"type": "string",
+							},
 						},
 					},
 				},
 			},
 			{
-				Name:        "execute_command",
-				Description: "Execute a shell command",
-				Schema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"command": map[string]interface{}{
-							"type": "string",
+				Type: "function",
+				Function: llm.FunctionDefinition{
+					Name:        "execute_command",
+					Description: "Execute a shell command",
+					Parameters: map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"command": map[string]interface{}{
+								"type": "string",
+							},
 						},
 					},
 				},
@@ -299,8 +317,16 @@ func testOllamaIntegration() {
 func testAdvancedReasoning() {
 	fmt.Println("Testing advanced reasoning capabilities...")
 
-	reasoningEngine := workflow.NewReasoningEngine()
-	
+	provider, err := llm.NewOllamaProvider(llm.OllamaConfig{
+		BaseURL: "http://localhost:11434",
+		Timeout: 120,
+	})
+	if err != nil {
+		log.Printf("❌ Reasoning provider setup failed: %v", err)
+		return
+	}
+	reasoningEngine := llm.NewReasoningEngine(provider)
+
 	problem := `We need to design a distributed task scheduling system that:
 1. Can handle 1000+ concurrent tasks
 2. Provides fault tolerance for worker failures
@@ -310,18 +336,23 @@ func testAdvancedReasoning() {
 
 Please provide a detailed architecture design and implementation strategy.`
 
-	result, err := reasoningEngine.ChainOfThought(context.Background(), problem)
+	result, err := reasoningEngine.GenerateWithReasoning(context.Background(), llm.ReasoningRequest{
+		Prompt:        problem,
+		ReasoningType: llm.ReasoningTypeChainOfThought,
+		MaxSteps:      5,
+		Temperature:   0.3,
+	})
 	if err != nil {
 		log.Printf("❌ Reasoning test failed: %v", err)
 		return
 	}
 
-	if len(result.Steps) < 3 {
+	if len(result.ReasoningSteps) < 3 {
 		log.Printf("❌ Insufficient reasoning steps generated")
 		return
 	}
 
-	fmt.Printf("✅ Advanced reasoning test passed! Generated %d reasoning steps\n", len(result.Steps))
+	fmt.Printf("✅ Advanced reasoning test passed! Generated %d reasoning steps\n", len(result.ReasoningSteps))
 }
 
 // Helper functions
@@ -375,7 +406,7 @@ func isValidGoCode(code string) bool {
 }
 
 func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && 
+	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
 		(s[:len(substr)] == substr || contains(s[1:], substr)))
 }
 
@@ -387,8 +418,13 @@ func hasLocalModels() bool {
 
 func hasOllama() bool {
 	// Check if Ollama is running
-	cmd := exec.Command("curl", "-s", "http://localhost:11434/api/version")
-	return cmd.Run() == nil
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	status := llm.ProbeOllama(ctx, "http://localhost:11434")
+	if status.Available {
+		log.Printf("✅ Ollama %s is running", status.Version)
+	}
+	return status.Available
 }
 
 func getAvailableWorkers() []string {
@@ -396,27 +432,33 @@ func getAvailableWorkers() []string {
 	return []string{"worker-1", "worker-2", "worker-3"}
 }
 
-func executeDistributedBuild(project *Project, workers []string) (*BuildResult, error) {
-	// Implementation would distribute build tasks across workers
-	return &BuildResult{
-		Services: map[string]ServiceBuildResult{
-			"api-service":      {Success: true},
-			"auth-service":     {Success: true},
-			"database-service": {Success: true},
-		},
-	}, nil
+func executeDistributedBuild(ctx context.Context, project *Project, workers []string) (*worker.BuildResult, error) {
+	pool := worker.NewSSHWorkerPool(false)
+	for _, host := range workers {
+		err := pool.RegisterWorker(&worker.SSHWorker{
+			Hostname:     host,
+			SSHConfig:    &worker.SSHWorkerConfig{Host: host, Port: 22, Username: os.Getenv("USER"), KeyPath: paths.Expand("~/.ssh/id_rsa")},
+			Status:       worker.WorkerStatusActive,
+			HealthStatus: worker.WorkerHealthHealthy,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var services []worker.ServiceBuild
+	for _, name := range []string{"api-service", "auth-service", "database-service"} {
+		services = append(services, worker.ServiceBuild{
+			Name:    name,
+			Command: "go build ./...",
+			WorkDir: filepath.Join(project.Path, name),
+		})
+	}
+
+	return pool.ExecuteDistributedBuild(ctx, services, worker.BuildOptions{RetryFailed: true})
 }
 
 type Project struct {
 	Name string
 	Path string
 }
-
-type BuildResult struct {
-	Services map[string]ServiceBuildResult
-}
-
-type ServiceBuildResult struct {
-	Success bool
-	Error   error
-}
\ No newline at end of file
@@ -92,7 +92,8 @@ func TestDistributedTaskExecution(t *testing.T) {
 		t.Logf("Found %d workers for automation testing", stats.TotalWorkers)
 
 		// Test task execution on available workers
-		for workerID := range workerPool.workers {
+		for _, w := range workerPool.ListWorkers() {
+			workerID := w.ID
 			t.Run(workerID.String(), func(t *testing.T) {
 				// Execute simple command
 				output, err := workerPool.ExecuteCommand(ctx, workerID, "echo 'automation test'")
@@ -213,10 +214,10 @@ func TestResourceUsage(t *testing.T) {
 	// Add multiple workers (simulated)
 	for i := 0; i < 100; i++ {
 		workerID := uuid.New()
-		workerPool.workers[workerID] = &worker.SSHWorker{
+		require.NoError(t, workerPool.RegisterWorker(&worker.SSHWorker{
 			ID:       workerID,
 			Hostname: "worker-" + string(rune('A'+i)),
-		}
+		}))
 	}
 
 	stats := workerPool.GetWorkerStats(ctx)
@@ -255,7 +255,7 @@ func TestScalabilityEndToEnd(t *testing.T) {
 	numWorkers := 100
 	for i := 0; i < numWorkers; i++ {
 		workerID := uuid.New()
-		workerPool.workers[workerID] = &worker.SSHWorker{
+		require.NoError(t, workerPool.RegisterWorker(&worker.SSHWorker{
 			ID:           workerID,
 			Hostname:     "worker-" + string(rune('A'+(i%26))),
 			Status:       worker.WorkerStatusActive,
@@ -265,7 +265,7 @@ func TestScalabilityEndToEnd(t *testing.T) {
 				TotalMemory: 8589934592, // 8GB
 				GPUCount:    1,
 			},
-		}
+		}))
 	}
 
 	// Test performance with many workers
@@ -281,6 +281,26 @@ func TestScalabilityEndToEnd(t *testing.T) {
 	
 	t.Logf("Scalability: %d workers processed in %v", numWorkers, duration)
 
+	// Load test the scheduler against simulated workers
+	manager := worker.NewDistributedWorkerManager(worker.WorkerConfig{})
+	manager.SetSimulationSeed(1)
+	for i := 0; i < numWorkers; i++ {
+		_, err := manager.AddSimulatedWorker(worker.SimulatedWorkerSpec{
+			Latency:     time.Millisecond,
+			FailureRate: 0.05,
+		})
+		require.NoError(t, err)
+	}
+
+	numTasks := 500
+	for i := 0; i < numTasks; i++ {
+		_ = manager.SubmitTask(&worker.DistributedTask{Type: "scalability", Criticality: worker.CriticalityNormal})
+	}
+
+	metrics := manager.GetThroughputMetrics()
+	assert.Equal(t, numTasks, metrics.Completed+metrics.Failed)
+	t.Logf("Simulated load: %.0f tasks/s, %d failed", metrics.TasksPerSecond, metrics.Failed)
+
 	// Test notification system with many channels
 	notificationEngine := notification.NewNotificationEngine()
 	
@@ -343,10 +363,10 @@ func TestFaultToleranceEndToEnd(t *testing.T) {
 	
 	// Add a worker after previous failures
 	workerID := uuid.New()
-	workerPool.workers[workerID] = &worker.SSHWorker{
+	require.NoError(t, workerPool.RegisterWorker(&worker.SSHWorker{
 		ID:       workerID,
 		Hostname: "recovered-worker",
-	}
+	}))
 	
 	recoveredStats := workerPool.GetWorkerStats(ctx)
 	assert.Equal(t, 1, recoveredStats.TotalWorkers)